@@ -24,9 +24,11 @@ package admin
 
 import (
 	"net"
+	"runtime"
 	"strconv"
 
 	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/proxy"
 	"github.com/berkaroad/saashard/utils/simplelog"
 )
 
@@ -35,15 +37,22 @@ type Server struct {
 	cfg    *config.Config
 	bindIP net.IP
 	port   int
+	proxy  *proxy.Server
 
 	listener net.Listener
 	running  bool
+
+	// httpListener of admin HTTP API, nil if disabled.
+	httpListener net.Listener
+	// httpRoutes are routes of admin HTTP API, and of each SHOW command.
+	httpRoutes []*httpRoute
 }
 
 // NewServer create admin.
-func NewServer(cfg *config.Config) (*Server, error) {
+func NewServer(cfg *config.Config, proxy *proxy.Server) (*Server, error) {
 	admin := new(Server)
 	admin.cfg = cfg
+	admin.proxy = proxy
 	admin.bindIP = net.ParseIP(cfg.BindIP)
 	admin.port = cfg.AdminPort
 
@@ -59,12 +68,27 @@ func NewServer(cfg *config.Config) (*Server, error) {
 	simplelog.Info("%s %s %s netProto=%s,address=%s", "server/admin", "NewServer", "Server running",
 		netProto,
 		addr)
+
+	if cfg.AdminHTTPPort > 0 {
+		httpAddr := admin.bindIP.String() + ":" + strconv.Itoa(cfg.AdminHTTPPort)
+		if admin.httpListener, err = net.Listen(netProto, httpAddr); err != nil {
+			admin.listener.Close()
+			return nil, err
+		}
+		simplelog.Info("%s %s %s netProto=%s,address=%s", "server/admin", "NewServer", "HTTP API running",
+			netProto,
+			httpAddr)
+		admin.httpRoutes = append(append([]*httpRoute(nil), httpRoutes...), showRoutes()...)
+	}
 	return admin, nil
 }
 
 // Run admin server.
 func (admin *Server) Run() {
 	admin.running = true
+	if admin.httpListener != nil {
+		go admin.runHTTP()
+	}
 
 	// proxy
 	for admin.running {
@@ -83,8 +107,32 @@ func (admin *Server) Close() {
 	if admin.listener != nil {
 		admin.listener.Close()
 	}
+	if admin.httpListener != nil {
+		admin.httpListener.Close()
+	}
 }
 
 func (admin *Server) onConn(c net.Conn) {
 	simplelog.Info("%s %s %s", "server/admin", "onConn", c.RemoteAddr().String())
+	conn := admin.newClientConn(c)
+
+	defer func() {
+		err := recover()
+		if err != nil {
+			const size = 4096
+			buf := make([]byte, size)
+			buf = buf[:runtime.Stack(buf, false)]
+			simplelog.Error("%s %s %s remoteAddr=%s,stack=%s", "server/admin", "onConn", "error",
+				c.RemoteAddr().String(),
+				string(buf),
+			)
+		}
+		conn.Close()
+	}()
+
+	if err := conn.Handshake(); err != nil {
+		simplelog.Error("%s %s %s", "server/admin", "onConn", err.Error())
+		return
+	}
+	conn.Run()
 }
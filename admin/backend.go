@@ -0,0 +1,333 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/berkaroad/saashard/backend"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+const (
+	usageShowBackendPools  = "SHOW BACKEND POOLS"
+	usageShowBackendHealth = "SHOW BACKEND HEALTH"
+	usageFailbackHost      = "FAILBACK HOST <host>"
+	usageSetSlaveWeight    = "SET SLAVE WEIGHT <host> <addr> <weight>"
+	usageSetSlave          = "SET SLAVE <host> <addr> <online|offline>"
+	// promote slave even if data loss is possible, candidate configured is chosen if addr is omitted.
+	usageFailoverHost           = "FAILOVER HOST <host> [<addr>]"
+	usageShowBackendReplication = "SHOW BACKEND REPLICATION"
+)
+
+var backendHealthColumns = []string{"Host", "Role", "Addr", "Status", "Failures", "Down_Since", "Latency_Ms", "Weight", "Offline"}
+
+func init() {
+	registerCommand(usageShowBackendPools, handleShowBackendPools)
+	registerCommand(usageShowBackendHealth, handleShowBackendHealth)
+	registerCommand(usageFailbackHost, handleFailbackHost)
+	registerCommand(usageSetSlaveWeight, handleSetSlaveWeight)
+	registerCommand(usageSetSlave, handleSetSlave)
+	registerCommand(usageFailoverHost, handleFailoverHost)
+	registerCommand(usageShowBackendReplication, handleShowBackendReplication)
+}
+
+func handleShowBackendPools(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageShowBackendPools)
+	}
+	hosts := c.admin.proxy.GetDataHosts()
+	names := sortedHostNames(hosts)
+
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		host := hosts[name]
+		rows = append(rows, backendPoolRow(name, "master", host.GetMaster()))
+		for _, slave := range host.Slaves {
+			rows = append(rows, backendPoolRow(name, "slave", slave))
+		}
+		for _, delayed := range host.DelayedSlaves {
+			rows = append(rows, backendPoolRow(name, "delayed", delayed))
+		}
+		for _, role := range sortedRoles(host.RoleSlaves) {
+			for _, roleSlave := range host.RoleSlaves[role] {
+				rows = append(rows, backendPoolRow(name, "slave:"+role, roleSlave))
+			}
+		}
+	}
+	return newResult([]string{"Host", "Role", "Addr", "Max_Conn", "Used_Conn", "Cached_Conn",
+		"Opened_Conn", "Max_Idle", "Min_Idle", "Waiting", "Waits", "Wait_Timeouts", "Wait_Rejected", "Expired", "Reaped",
+		"Max_Concurrent", "In_Flight", "Max_Queue", "Queued", "Max_Queued", "Total", "Rejected", "Timeouts"}, rows), nil
+}
+
+func backendPoolRow(name, role string, dbHost *backend.DBHost) []string {
+	stats := dbHost.Limiter.Stats()
+	poolStats := dbHost.Pool.Stats()
+	return []string{
+		name,
+		role,
+		dbHost.Addr,
+		strconv.FormatUint(uint64(poolStats.MaxPoolSize), 10),
+		strconv.FormatUint(uint64(poolStats.Used), 10),
+		strconv.Itoa(poolStats.Cached),
+		strconv.FormatUint(uint64(poolStats.Opened), 10),
+		strconv.FormatUint(uint64(poolStats.MaxIdle), 10),
+		strconv.FormatUint(uint64(poolStats.MinIdle), 10),
+		strconv.Itoa(poolStats.Waiting),
+		strconv.FormatUint(poolStats.Waits, 10),
+		strconv.FormatUint(poolStats.WaitTimeouts, 10),
+		strconv.FormatUint(poolStats.Rejected, 10),
+		strconv.FormatUint(poolStats.Expired, 10),
+		strconv.FormatUint(poolStats.Reaped, 10),
+		strconv.Itoa(stats.MaxConcurrent),
+		strconv.FormatInt(stats.InFlight, 10),
+		strconv.Itoa(stats.MaxQueueSize),
+		strconv.FormatInt(stats.Queued, 10),
+		strconv.FormatInt(stats.MaxQueued, 10),
+		strconv.FormatUint(stats.Total, 10),
+		strconv.FormatUint(stats.Rejected, 10),
+		strconv.FormatUint(stats.Timeouts, 10),
+	}
+}
+
+func handleShowBackendHealth(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageShowBackendHealth)
+	}
+	hosts := c.admin.proxy.GetDataHosts()
+	rows := make([][]string, 0, len(hosts))
+	for _, name := range sortedHostNames(hosts) {
+		host := hosts[name]
+		rows = append(rows, backendHealthRow(name, "master", host.GetMaster()))
+		for _, slave := range host.Slaves {
+			rows = append(rows, backendHealthRow(name, "slave", slave))
+		}
+		for _, delayed := range host.DelayedSlaves {
+			rows = append(rows, backendHealthRow(name, "delayed", delayed))
+		}
+		for _, role := range sortedRoles(host.RoleSlaves) {
+			for _, roleSlave := range host.RoleSlaves[role] {
+				rows = append(rows, backendHealthRow(name, "slave:"+role, roleSlave))
+			}
+		}
+		// master configured is failed over.
+		if origin := host.GetOrigin(); origin != host.GetMaster() {
+			rows = append(rows, backendHealthRow(name, "origin", origin))
+		}
+	}
+	return newResult(backendHealthColumns, rows), nil
+}
+
+func handleFailbackHost(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 1 {
+		return nil, errArgs(usageFailbackHost)
+	}
+	name := args[0]
+	host := c.admin.proxy.GetDataHosts()[name]
+	if host == nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, fmt.Sprintf("data host '%s' not exists", name))
+	}
+	if err := host.Failback(); err != nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+	}
+	return newResult([]string{"Host", "Master"}, [][]string{{name, host.GetMaster().Addr}}), nil
+}
+
+func handleFailoverHost(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, errArgs(usageFailoverHost)
+	}
+	name := args[0]
+	host := c.admin.proxy.GetDataHosts()[name]
+	if host == nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, fmt.Sprintf("data host '%s' not exists", name))
+	}
+	var addr string
+	if len(args) == 2 {
+		addr = args[1]
+	}
+	report, err := host.Failover(addr)
+	if err != nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+	}
+	return newResult([]string{"Host", "Master", "Master_Semi_Sync", "Missing_Transactions", "Missing_GTID_Set", "Possible_Data_Loss"},
+		[][]string{{name, host.GetMaster().Addr, strconv.FormatBool(report.MasterSemiSync),
+			strconv.FormatInt(report.Missing.Count(), 10), report.Missing.String(), strconv.FormatBool(report.PossibleDataLoss())}}), nil
+}
+
+// handleShowBackendReplication show replication status at last health check, checked if failover candidate is set
+// or master is failed over.
+func handleShowBackendReplication(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageShowBackendReplication)
+	}
+	hosts := c.admin.proxy.GetDataHosts()
+	rows := make([][]string, 0, len(hosts))
+	for _, name := range sortedHostNames(hosts) {
+		host := hosts[name]
+		rows = append(rows, backendReplicationRow(name, "master", host.GetMaster()))
+		// master configured is checked to fail back, after failed over.
+		if origin := host.GetOrigin(); origin != host.GetMaster() && !containsDBHost(host.Slaves, origin) {
+			rows = append(rows, backendReplicationRow(name, "origin", origin))
+		}
+		for _, slave := range host.Slaves {
+			if slave != host.GetMaster() {
+				rows = append(rows, backendReplicationRow(name, "slave", slave))
+			}
+		}
+		for _, delayed := range host.DelayedSlaves {
+			rows = append(rows, backendReplicationRow(name, "delayed", delayed))
+		}
+		for _, role := range sortedRoles(host.RoleSlaves) {
+			for _, roleSlave := range host.RoleSlaves[role] {
+				rows = append(rows, backendReplicationRow(name, "slave:"+role, roleSlave))
+			}
+		}
+	}
+	return newResult([]string{"Host", "Role", "Addr", "Semi_Sync_Master", "Semi_Sync_Slave", "Source", "Replicating",
+		"GTID_Executed", "Checked_At"}, rows), nil
+}
+
+func containsDBHost(dbHosts []*backend.DBHost, dbHost *backend.DBHost) bool {
+	for _, h := range dbHosts {
+		if h == dbHost {
+			return true
+		}
+	}
+	return false
+}
+
+func backendReplicationRow(name, role string, dbHost *backend.DBHost) []string {
+	status := dbHost.Replication()
+	if status == nil {
+		return []string{name, role, dbHost.Addr, "", "", "", "", "", ""}
+	}
+	return []string{
+		name,
+		role,
+		dbHost.Addr,
+		onOff(status.SemiSyncMaster),
+		onOff(status.SemiSyncSlave),
+		status.Source,
+		onOff(status.Replicating),
+		status.GTIDExecuted.String(),
+		status.CheckedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func handleSetSlaveWeight(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 3 {
+		return nil, errArgs(usageSetSlaveWeight)
+	}
+	weight, err := strconv.Atoi(args[2])
+	if err != nil || weight <= 0 {
+		return nil, errArgs(usageSetSlaveWeight)
+	}
+	return setSlave(c, args[0], args[1], func(host *backend.DataHost) error {
+		return host.SetSlaveWeight(args[1], weight)
+	})
+}
+
+// handleSetSlave take slave offline for maintenance, reads are on other slaves, or on master if no other.
+func handleSetSlave(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 3 {
+		return nil, errArgs(usageSetSlave)
+	}
+	var offline bool
+	switch strings.ToLower(args[2]) {
+	case "online":
+	case "offline":
+		offline = true
+	default:
+		return nil, errArgs(usageSetSlave)
+	}
+	return setSlave(c, args[0], args[1], func(host *backend.DataHost) error {
+		return host.SetSlaveOffline(args[1], offline)
+	})
+}
+
+func setSlave(c *ClientConn, name, addr string, update func(host *backend.DataHost) error) (*mysql.Result, error) {
+	host := c.admin.proxy.GetDataHosts()[name]
+	if host == nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, fmt.Sprintf("data host '%s' not exists", name))
+	}
+	if err := update(host); err != nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+	}
+	simplelog.Info("%s %s %s host=%s,addr=%s", "server/admin", "setSlave", "slave changed", name, addr)
+	rows := make([][]string, 0, len(host.Slaves))
+	for _, slave := range host.Slaves {
+		rows = append(rows, backendHealthRow(name, "slave", slave))
+	}
+	for _, role := range sortedRoles(host.RoleSlaves) {
+		for _, roleSlave := range host.RoleSlaves[role] {
+			rows = append(rows, backendHealthRow(name, "slave:"+role, roleSlave))
+		}
+	}
+	return newResult(backendHealthColumns, rows), nil
+}
+
+func backendHealthRow(name, role string, dbHost *backend.DBHost) []string {
+	status := "up"
+	if dbHost.IsDown() {
+		status = "down"
+	}
+	var downSince string
+	if t := dbHost.DownSince(); !t.IsZero() {
+		downSince = t.Format("2006-01-02 15:04:05")
+	}
+	return []string{
+		name,
+		role,
+		dbHost.Addr,
+		status,
+		strconv.Itoa(int(dbHost.Failures())),
+		downSince,
+		strconv.FormatFloat(float64(dbHost.Latency())/float64(time.Millisecond), 'f', 3, 64),
+		strconv.Itoa(dbHost.Weight),
+		strconv.FormatBool(dbHost.IsOffline()),
+	}
+}
+
+func sortedRoles(roleSlaves map[string][]*backend.DBHost) []string {
+	roles := make([]string, 0, len(roleSlaves))
+	for role := range roleSlaves {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+func sortedHostNames(hosts map[string]*backend.DataHost) []string {
+	names := make([]string, 0, len(hosts))
+	for name := range hosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
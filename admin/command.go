@@ -0,0 +1,171 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+// command is admin command, matched by leading upper case keywords of usage.
+type command struct {
+	keywords []string
+	usage    string
+	handle   func(c *ClientConn, args []string) (*mysql.Result, error)
+}
+
+var commands []*command
+
+// registerCommand register admin command, such as 'SHOW SHARD MAPS' or 'SWITCH SHARD MAP <schema>'.
+func registerCommand(usage string, handle func(c *ClientConn, args []string) (*mysql.Result, error)) {
+	cmd := new(command)
+	cmd.usage = usage
+	cmd.handle = handle
+	for _, word := range strings.Fields(usage) {
+		if word != strings.ToUpper(word) || strings.ContainsAny(word, "<[") {
+			break
+		}
+		cmd.keywords = append(cmd.keywords, word)
+	}
+	commands = append(commands, cmd)
+	// match longer keywords first.
+	sort.SliceStable(commands, func(i, j int) bool { return len(commands[i].keywords) > len(commands[j].keywords) })
+}
+
+func init() {
+	registerCommand("SHOW COMMANDS", func(c *ClientConn, args []string) (*mysql.Result, error) {
+		rows := make([][]string, 0, len(commands))
+		for _, cmd := range commands {
+			rows = append(rows, []string{cmd.usage})
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+		return newResult([]string{"usage"}, rows), nil
+	})
+	// mysql client query it after connected.
+	registerCommand("SELECT @@VERSION_COMMENT", func(c *ClientConn, args []string) (*mysql.Result, error) {
+		return newResult([]string{"@@version_comment"}, [][]string{{mysql.SourceInfo + " admin"}}), nil
+	})
+}
+
+// execCommand find command and execute it.
+func execCommand(c *ClientConn, sql string) (*mysql.Result, error) {
+	tokens := splitArgs(sql)
+	if len(tokens) == 0 {
+		return nil, mysql.NewError(mysql.ER_EMPTY_QUERY, "Query was empty")
+	}
+	return execTokens(c, tokens)
+}
+
+// execTokens find command by tokens split and execute it.
+func execTokens(c *ClientConn, tokens []string) (*mysql.Result, error) {
+	for _, cmd := range commands {
+		if len(tokens) < len(cmd.keywords) {
+			continue
+		}
+		matched := true
+		for i, keyword := range cmd.keywords {
+			if strings.ToUpper(tokens[i]) != keyword {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return cmd.handle(c, tokens[len(cmd.keywords):])
+		}
+	}
+	return nil, mysql.NewError(mysql.ER_SYNTAX_ERROR, fmt.Sprintf("unknown admin command '%s', see 'SHOW COMMANDS'", strings.Join(tokens, " ")))
+}
+
+// splitArgs split sql by spaces, quoted token could contain spaces, and quotes will be removed.
+func splitArgs(sql string) []string {
+	sql = strings.TrimRight(strings.TrimSpace(sql), "; \t\r\n")
+	var tokens []string
+	var token []byte
+	var quote byte
+	var quoted bool
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		if quote != 0 {
+			if ch == quote {
+				quote = 0
+			} else {
+				token = append(token, ch)
+			}
+			continue
+		}
+		switch ch {
+		case '\'', '"', '`':
+			quote = ch
+			quoted = true
+		case ' ', '\t', '\r', '\n':
+			if len(token) > 0 || quoted {
+				tokens = append(tokens, string(token))
+			}
+			token = token[:0]
+			quoted = false
+		default:
+			token = append(token, ch)
+		}
+	}
+	if len(token) > 0 || quoted {
+		tokens = append(tokens, string(token))
+	}
+	return tokens
+}
+
+// newResult create text result set, all columns are string.
+func newResult(names []string, rows [][]string) *mysql.Result {
+	result := new(mysql.Result)
+	result.Status = mysql.SERVER_STATUS_AUTOCOMMIT
+	result.Resultset = new(mysql.Resultset)
+	result.Fields = make([]*mysql.Field, len(names))
+	for i, name := range names {
+		result.Fields[i] = &mysql.Field{
+			Name:         []byte(name),
+			OrgName:      []byte(name),
+			Charset:      uint16(mysql.DEFAULT_COLLATION_ID),
+			ColumnLength: 1024,
+			ColumnType:   mysql.MYSQL_TYPE_VAR_STRING,
+		}
+	}
+	result.Rows = make([]*mysql.Row, len(rows))
+	result.Values = make([][]interface{}, len(rows))
+	for i, values := range rows {
+		row := mysql.NewTextRow(result.Fields)
+		result.Values[i] = make([]interface{}, len(values))
+		for j, value := range values {
+			row.AppendStringValue(value)
+			result.Values[i][j] = value
+		}
+		result.Rows[i] = row
+	}
+	return result
+}
+
+// errArgs wrong arguments of command.
+func errArgs(usage string) error {
+	return mysql.NewError(mysql.ER_WRONG_ARGUMENTS, fmt.Sprintf("wrong arguments, usage: %s", usage))
+}
@@ -0,0 +1,182 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// DB is database name of admin.
+const DB = "saashard"
+
+// DefaultMaxAllowedPacket is default max bytes of packet from clients of admin.
+const DefaultMaxAllowedPacket = 4 << 20
+
+var baseConnID uint32
+
+// ClientConn client <-> admin
+type ClientConn struct {
+	pkg          *mysql.PacketIO
+	c            net.Conn
+	admin        *Server
+	capability   uint32
+	connectionID uint32
+	status       uint16
+	user         string
+	db           string
+	salt         []byte
+	closed       bool
+}
+
+func (admin *Server) newClientConn(co net.Conn) *ClientConn {
+	c := new(ClientConn)
+	c.c = co
+	c.pkg = mysql.NewPacketIO(co)
+	c.pkg.MaxAllowedPacket = admin.cfg.AdminMaxAllowedPacket
+	if c.pkg.MaxAllowedPacket <= 0 {
+		c.pkg.MaxAllowedPacket = DefaultMaxAllowedPacket
+	}
+	c.admin = admin
+	c.pkg.Sequence = 0
+	c.connectionID = atomic.AddUint32(&baseConnID, 1)
+	c.status = mysql.SERVER_STATUS_AUTOCOMMIT
+	c.salt, _ = mysql.RandomBuf(20)
+	c.closed = false
+	return c
+}
+
+// Handshake between client and admin.
+func (c *ClientConn) Handshake() error {
+	var err error
+	if err = c.pkg.WriteInitialHandshake(c.connectionID, c.salt, mysql.DEFAULT_COLLATION_ID, mysql.DEFAULT_CAPABILITY, c.status, ""); err != nil {
+		return err
+	}
+
+	getDefaultSchemaByUser := func(user string) (string, error) {
+		return DB, nil
+	}
+	getCredentialsConfigBySchema := func(db string) (string, []string, error) {
+		if db != DB {
+			return "", nil, mysql.NewDefaultError(mysql.ER_BAD_DB_ERROR, db)
+		}
+		if len(c.admin.cfg.AdminUser) == 0 {
+			return "", nil, mysql.NewError(mysql.ER_ACCESS_DENIED_ERROR, "admin user not configured")
+		}
+		return c.admin.cfg.AdminUser, []string{c.admin.cfg.AdminPassword, c.admin.cfg.AdminSecondaryPassword}, nil
+	}
+	c.capability, _, c.user, c.db, err = c.pkg.ReadHandshakeResponse(getDefaultSchemaByUser, c.c.RemoteAddr().String(), c.salt, getCredentialsConfigBySchema, nil)
+	if err != nil {
+		c.pkg.WriteError(c.capability, err)
+		return err
+	}
+
+	if err = c.pkg.WriteOK(c.capability, c.status, nil); err != nil {
+		return err
+	}
+	c.pkg.Sequence = 0
+	return nil
+}
+
+// Run after handshake.
+func (c *ClientConn) Run() {
+	defer func() {
+		r := recover()
+		if err, ok := r.(error); ok {
+			const size = 4096
+			buf := make([]byte, size)
+			buf = buf[:runtime.Stack(buf, false)]
+
+			simplelog.Error("%s %s %s stack=%s", "admin", "Run", err.Error(), string(buf))
+		}
+		c.Close()
+	}()
+
+	for {
+		data, err := c.pkg.ReadPacket()
+		if err == mysql.ErrPacketTooLarge {
+			c.pkg.WriteError(c.capability, err)
+			return
+		} else if err != nil {
+			return
+		}
+		if err := c.dispatch(data); err != nil {
+			simplelog.Error("%s %s %s connection id=%d", "admin", "Run", err.Error(), c.connectionID)
+			c.pkg.WriteError(c.capability, err)
+		}
+		if c.closed {
+			return
+		}
+		c.pkg.Sequence = 0
+	}
+}
+
+// Close client
+func (c *ClientConn) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.c.Close()
+	c.closed = true
+	return nil
+}
+
+func (c *ClientConn) dispatch(data []byte) error {
+	cmd := data[0]
+	data = data[1:]
+
+	switch cmd {
+	case mysql.COM_QUIT:
+		c.Close()
+		return nil
+	case mysql.COM_QUERY:
+		return c.handleQuery(string(data))
+	case mysql.COM_PING:
+		return c.pkg.WriteOK(c.capability, c.status, nil)
+	case mysql.COM_INIT_DB:
+		if db := strings.Trim(strings.ToLower(string(data)), "`"); db != DB {
+			return mysql.NewDefaultError(mysql.ER_BAD_DB_ERROR, db)
+		}
+		return c.pkg.WriteOK(c.capability, c.status, nil)
+	default:
+		msg := fmt.Sprintf("command %d not supported now", cmd)
+		return mysql.NewError(mysql.ER_UNKNOWN_ERROR, msg)
+	}
+}
+
+func (c *ClientConn) handleQuery(sql string) error {
+	result, err := execCommand(c, sql)
+	if err != nil {
+		return err
+	}
+	if result != nil && result.Resultset != nil {
+		return c.pkg.WriteResultSet(c.capability, c.status, result)
+	}
+	return c.pkg.WriteOK(c.capability, c.status, result)
+}
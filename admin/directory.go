@@ -0,0 +1,119 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/utils"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// Lookup table of directory shard algorithm:
+//   - SHOW DIRECTORY db1 tenant1          (node of shard key in lookup table and cache)
+//   - MOVE DIRECTORY db1 tenant1 db1_node2 (after rows of shard key copied to the node)
+//   - FLUSH DIRECTORY CACHE [db1 [tenant1]]
+const (
+	usageShowDirectory       = "SHOW DIRECTORY <schema> <shard_key>"
+	usageMoveDirectory       = "MOVE DIRECTORY <schema> <shard_key> <node>"
+	usageFlushDirectoryCache = "FLUSH DIRECTORY CACHE [<schema> [<shard_key>]]"
+)
+
+var (
+	directoryColumns      = []string{"schema", "shard_key", "node", "cached_node"}
+	flushDirectoryColumns = []string{"invalidated"}
+)
+
+var errNoDirectory = mysql.NewError(mysql.ER_UNKNOWN_ERROR, "directory is not configured")
+
+func init() {
+	registerCommand(usageShowDirectory, handleShowDirectory)
+	registerCommand(usageMoveDirectory, handleMoveDirectory)
+	registerCommand(usageFlushDirectoryCache, handleFlushDirectoryCache)
+}
+
+func handleShowDirectory(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 2 {
+		return nil, errArgs(usageShowDirectory)
+	}
+	if c.admin.proxy.Directory() == nil {
+		return nil, errNoDirectory
+	}
+	return directoryResult(c, strings.ToLower(args[0]), args[1])
+}
+
+func handleMoveDirectory(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 3 {
+		return nil, errArgs(usageMoveDirectory)
+	}
+	dir := c.admin.proxy.Directory()
+	if dir == nil {
+		return nil, errNoDirectory
+	}
+	schemaName, key, node := strings.ToLower(args[0]), args[1], args[2]
+	m := c.admin.proxy.ShardMaps().Get(schemaName)
+	if m == nil {
+		return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "schema '"+schemaName+"' not exists or not sharded")
+	}
+	if !utils.Contains(m.AllNodes(), node) {
+		return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "data node '"+node+"' not in schema '"+schemaName+"'")
+	}
+	if err := dir.Move(schemaName, key, node); err != nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+	}
+	simplelog.Info("%s %s %s schema=%s,shard_key=%s,node=%s", "admin", "handleMoveDirectory", "Shard key moved",
+		schemaName, key, node)
+	return directoryResult(c, schemaName, key)
+}
+
+func handleFlushDirectoryCache(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) > 2 {
+		return nil, errArgs(usageFlushDirectoryCache)
+	}
+	dir := c.admin.proxy.Directory()
+	if dir == nil {
+		return nil, errNoDirectory
+	}
+	var schemaName, key string
+	if len(args) > 0 {
+		schemaName = strings.ToLower(args[0])
+	}
+	if len(args) > 1 {
+		key = args[1]
+	}
+	count := dir.Invalidate(schemaName, key)
+	return newResult(flushDirectoryColumns, [][]string{{strconv.Itoa(count)}}), nil
+}
+
+// directoryResult get node of shard key in lookup table and cache.
+func directoryResult(c *ClientConn, schemaName string, key string) (*mysql.Result, error) {
+	dir := c.admin.proxy.Directory()
+	node, err := dir.Get(schemaName, key)
+	if err != nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+	}
+	cachedNode, _ := dir.Cached(schemaName, key)
+	return newResult(directoryColumns, [][]string{{schemaName, key, node, cachedNode}}), nil
+}
@@ -0,0 +1,50 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// Sessions of user are closed once their transactions are finished, or forcibly after drain_timeout.
+const usageDrainUser = "DRAIN USER <user>"
+
+var drainColumns = []string{"user", "drained_sessions"}
+
+func init() {
+	registerCommand(usageDrainUser, handleDrainUser)
+}
+
+func handleDrainUser(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 1 {
+		return nil, errArgs(usageDrainUser)
+	}
+	user := strings.Trim(args[0], "'\"`")
+	count := c.admin.proxy.DrainUser(user)
+	simplelog.Info("%s %s %s user=%s,sessions=%d", "admin", "handleDrainUser", "Sessions drained", user, count)
+	return newResult(drainColumns, [][]string{{user, strconv.Itoa(count)}}), nil
+}
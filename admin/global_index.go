@@ -0,0 +1,141 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/proxy"
+)
+
+// Jobs of global index, run in background:
+//   - BACKFILL GLOBAL INDEX db1 t1       (add index entries of rows in shards, to adopt global index on existing data)
+//   - REPAIR GLOBAL INDEX db1 t1 [PURGE] (backfill, and report orphan entries whose rows not exist, removed if purge)
+//   - SHOW GLOBAL INDEX JOBS
+//   - SHOW GLOBAL INDEX ORPHANS 1        (orphans reported by repair job)
+//   - CANCEL GLOBAL INDEX JOB 1
+//
+// Entries are added before rows are written, so purge of repair may remove entries of rows being written.
+const (
+	usageBackfillGlobalIndex    = "BACKFILL GLOBAL INDEX <schema> <table>"
+	usageRepairGlobalIndex      = "REPAIR GLOBAL INDEX <schema> <table> [PURGE]"
+	usageShowGlobalIndexJobs    = "SHOW GLOBAL INDEX JOBS"
+	usageShowGlobalIndexOrphans = "SHOW GLOBAL INDEX ORPHANS <job_id>"
+	usageCancelGlobalIndexJob   = "CANCEL GLOBAL INDEX JOB <job_id>"
+)
+
+var (
+	globalIndexJobColumns = []string{"id", "kind", "schema", "table", "purge", "status", "started_at", "finished_at",
+		"scanned", "skipped", "checked", "orphans", "purged", "error"}
+	globalIndexOrphanColumns = []string{"column", "value", "node"}
+)
+
+func init() {
+	registerCommand(usageBackfillGlobalIndex, handleBackfillGlobalIndex)
+	registerCommand(usageRepairGlobalIndex, handleRepairGlobalIndex)
+	registerCommand(usageShowGlobalIndexJobs, handleShowGlobalIndexJobs)
+	registerCommand(usageShowGlobalIndexOrphans, handleShowGlobalIndexOrphans)
+	registerCommand(usageCancelGlobalIndexJob, handleCancelGlobalIndexJob)
+}
+
+func handleBackfillGlobalIndex(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 2 {
+		return nil, errArgs(usageBackfillGlobalIndex)
+	}
+	return startGlobalIndexJob(c, proxy.GlobalIndexBackfill, args[0], args[1], false)
+}
+
+func handleRepairGlobalIndex(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 2 && (len(args) != 3 || strings.ToUpper(args[2]) != "PURGE") {
+		return nil, errArgs(usageRepairGlobalIndex)
+	}
+	return startGlobalIndexJob(c, proxy.GlobalIndexRepair, args[0], args[1], len(args) == 3)
+}
+
+func startGlobalIndexJob(c *ClientConn, kind, schemaName, tableName string, purge bool) (*mysql.Result, error) {
+	job, err := c.admin.proxy.StartGlobalIndexJob(kind, strings.ToLower(schemaName), strings.ToLower(tableName), purge)
+	if err == errors.ErrNoGlobalIndex {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, "global index is not configured")
+	} else if err != nil {
+		return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, err.Error())
+	}
+	return newResult([]string{"id"}, [][]string{{strconv.FormatInt(job.ID, 10)}}), nil
+}
+
+func handleShowGlobalIndexJobs(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageShowGlobalIndexJobs)
+	}
+	jobs := c.admin.proxy.GlobalIndexJobs()
+	rows := make([][]string, len(jobs))
+	for i, job := range jobs {
+		var finishedAt string
+		if !job.FinishedAt.IsZero() {
+			finishedAt = job.FinishedAt.Format(time.RFC3339)
+		}
+		rows[i] = []string{strconv.FormatInt(job.ID, 10), job.Kind, job.Schema, job.Table, strconv.FormatBool(job.Purge),
+			job.Status, job.StartedAt.Format(time.RFC3339), finishedAt,
+			strconv.FormatInt(job.Scanned, 10), strconv.FormatInt(job.Skipped, 10), strconv.FormatInt(job.Checked, 10),
+			strconv.FormatInt(job.OrphanCount, 10), strconv.FormatInt(job.Purged, 10), job.Error}
+	}
+	return newResult(globalIndexJobColumns, rows), nil
+}
+
+func handleShowGlobalIndexOrphans(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 1 {
+		return nil, errArgs(usageShowGlobalIndexOrphans)
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, errArgs(usageShowGlobalIndexOrphans)
+	}
+	for _, job := range c.admin.proxy.GlobalIndexJobs() {
+		if job.ID != id {
+			continue
+		}
+		rows := make([][]string, len(job.Orphans))
+		for i, orphan := range job.Orphans {
+			rows[i] = []string{orphan.Column, orphan.Value, orphan.Node}
+		}
+		return newResult(globalIndexOrphanColumns, rows), nil
+	}
+	return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "global index job "+args[0]+" not exists")
+}
+
+func handleCancelGlobalIndexJob(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 1 {
+		return nil, errArgs(usageCancelGlobalIndexJob)
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, errArgs(usageCancelGlobalIndexJob)
+	}
+	if !c.admin.proxy.CancelGlobalIndexJob(id) {
+		return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "global index job "+args[0]+" not exists or not running")
+	}
+	return handleShowGlobalIndexJobs(c, nil)
+}
@@ -0,0 +1,273 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// HTTPReadTimeout is timeout of reading request of admin HTTP API.
+const HTTPReadTimeout = 30 * time.Second
+
+// httpRoute map HTTP request to admin command, by template such as 'SET SLAVE {host} {addr} offline'.
+// '{name}' is from path or parameters of query and form, '{name?}' is optional.
+type httpRoute struct {
+	method   string
+	path     string
+	template string
+}
+
+var httpRoutes = []*httpRoute{
+	{http.MethodGet, "/api/commands", "SHOW COMMANDS"},
+	{http.MethodPost, "/api/commands", "{command}"},
+	{http.MethodGet, "/api/status", "SHOW STATUS"},
+	{http.MethodGet, "/api/nodes", "SHOW NODES"},
+	{http.MethodGet, "/api/schemas", "SHOW SCHEMAS"},
+	{http.MethodGet, "/api/rules", "SHOW SHARD MAPS {schema?}"},
+	{http.MethodGet, "/api/backends", "SHOW BACKEND HEALTH"},
+	{http.MethodGet, "/api/pools", "SHOW BACKEND POOLS"},
+	{http.MethodGet, "/api/connections", "SHOW CONNECTION DETAIL {tag?}"},
+	{http.MethodDelete, "/api/connections/{id}", "KILL CONNECTION {id}"},
+	{http.MethodDelete, "/api/connections/{id}/query", "KILL QUERY {id}"},
+	{http.MethodPost, "/api/connections/{id}/route", "ROUTE CONNECTION {id} TO {to}"},
+	{http.MethodPost, "/api/slaves/offline", "SET SLAVE {host} {addr} offline"},
+	{http.MethodPost, "/api/slaves/online", "SET SLAVE {host} {addr} online"},
+	{http.MethodPost, "/api/slaves/weight", "SET SLAVE WEIGHT {host} {addr} {weight}"},
+	{http.MethodPost, "/api/hosts/{host}/failback", "FAILBACK HOST {host}"},
+	{http.MethodGet, "/api/read_only", "SHOW READ ONLY"},
+	{http.MethodPost, "/api/read_only", "SET READ ONLY {mode} {schema?}"},
+	{http.MethodPost, "/api/config/reload", "RELOAD CONFIG"},
+}
+
+// showRoutes map 'GET /api/show/backend/pools' to 'SHOW BACKEND POOLS' for each SHOW command,
+// arguments in usage are parameters of query, such as '/api/show/shard/maps?schema=db1'.
+func showRoutes() []*httpRoute {
+	var routes []*httpRoute
+	for _, cmd := range commands {
+		if len(cmd.keywords) < 2 || cmd.keywords[0] != "SHOW" {
+			continue
+		}
+		words := append([]string(nil), cmd.keywords...)
+		optional := false
+		for _, word := range strings.Fields(cmd.usage)[len(cmd.keywords):] {
+			optional = optional || strings.HasPrefix(word, "[")
+			name := strings.Trim(word, "[]<>")
+			if optional {
+				name += "?"
+			}
+			words = append(words, "{"+name+"}")
+			if strings.HasSuffix(word, "]") {
+				optional = false
+			}
+		}
+		path := "/api/show/" + strings.ToLower(strings.Join(cmd.keywords[1:], "/"))
+		routes = append(routes, &httpRoute{http.MethodGet, path, strings.Join(words, " ")})
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].path < routes[j].path })
+	return routes
+}
+
+// httpError is error in response of admin HTTP API.
+type httpError struct {
+	Code    uint16 `json:"code"`
+	Message string `json:"message"`
+}
+
+// runHTTP serve admin HTTP API in JSON, authenticated by admin user in basic auth.
+func (admin *Server) runHTTP() {
+	svr := &http.Server{Handler: admin, ReadTimeout: HTTPReadTimeout}
+	if err := svr.Serve(admin.httpListener); err != nil && admin.running {
+		simplelog.Error("%s %s %s", "server/admin", "runHTTP", err.Error())
+	}
+}
+
+// ServeHTTP execute admin command mapped by route.
+func (admin *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, password, ok := r.BasicAuth()
+	if !ok || !admin.checkPassword(user, password) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="saashard"`)
+		writeHTTPError(w, http.StatusUnauthorized, mysql.NewDefaultError(mysql.ER_ACCESS_DENIED_NO_PASSWORD_ERROR, user, r.RemoteAddr))
+		return
+	}
+	if r.URL.Path == "/api" || r.URL.Path == "/api/" {
+		rows := make([][]string, 0, len(admin.httpRoutes))
+		for _, route := range admin.httpRoutes {
+			rows = append(rows, []string{route.method, route.path, route.template})
+		}
+		writeHTTPResult(w, newResult([]string{"method", "path", "command"}, rows))
+		return
+	}
+
+	var methodMatched bool
+	for _, route := range admin.httpRoutes {
+		params, ok := route.match(r.URL.Path)
+		if !ok {
+			continue
+		}
+		if route.method != r.Method {
+			methodMatched = true
+			continue
+		}
+		tokens, err := route.tokens(r, params)
+		if err != nil {
+			writeHTTPError(w, http.StatusBadRequest, err)
+			return
+		}
+		c := &ClientConn{admin: admin, user: user, db: DB, connectionID: atomic.AddUint32(&baseConnID, 1)}
+		simplelog.Info("%s %s %s user=%s,remoteAddr=%s,command=%s", "server/admin", "ServeHTTP", r.Method+" "+r.URL.Path,
+			user, r.RemoteAddr, strings.Join(tokens, " "))
+		result, err := execTokens(c, tokens)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if sqlErr, ok := err.(*errors.SqlError); ok && (sqlErr.Code == mysql.ER_WRONG_ARGUMENTS || sqlErr.Code == mysql.ER_SYNTAX_ERROR ||
+				sqlErr.Code == mysql.ER_EMPTY_QUERY) {
+				status = http.StatusBadRequest
+			} else if ok && sqlErr.Code == mysql.ER_NO_SUCH_THREAD {
+				status = http.StatusNotFound
+			}
+			writeHTTPError(w, status, err)
+			return
+		}
+		writeHTTPResult(w, result)
+		return
+	}
+	if methodMatched {
+		writeHTTPError(w, http.StatusMethodNotAllowed, mysql.NewError(mysql.ER_NOT_SUPPORTED_YET, "method "+r.Method+" not allowed"))
+		return
+	}
+	writeHTTPError(w, http.StatusNotFound, mysql.NewError(mysql.ER_UNKNOWN_ERROR, "not found, see '/api'"))
+}
+
+// checkPassword of admin user, secondary password is also accepted.
+func (admin *Server) checkPassword(user, password string) bool {
+	if len(admin.cfg.AdminUser) == 0 || user != admin.cfg.AdminUser {
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(password), []byte(admin.cfg.AdminPassword)) == 1 {
+		return true
+	}
+	secondary := admin.cfg.AdminSecondaryPassword
+	return len(secondary) > 0 && subtle.ConstantTimeCompare([]byte(password), []byte(secondary)) == 1
+}
+
+// match path of route, and get parameters in path.
+func (route *httpRoute) match(path string) (map[string]string, bool) {
+	segments := strings.Split(strings.Trim(route.path, "/"), "/")
+	values := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) != len(values) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params[segment[1:len(segment)-1]] = values[i]
+		} else if segment != values[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// tokens of admin command by template, '{command}' is split as admin command.
+func (route *httpRoute) tokens(r *http.Request, params map[string]string) ([]string, error) {
+	var tokens []string
+	for _, word := range strings.Fields(route.template) {
+		if !strings.HasPrefix(word, "{") {
+			tokens = append(tokens, word)
+			continue
+		}
+		name := strings.Trim(word, "{}")
+		optional := strings.HasSuffix(name, "?")
+		name = strings.TrimSuffix(name, "?")
+		value, ok := params[name]
+		if !ok {
+			value = r.FormValue(name)
+		}
+		if len(value) == 0 {
+			if optional {
+				continue
+			}
+			return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "parameter '"+name+"' is required")
+		}
+		if name == "command" {
+			tokens = append(tokens, splitArgs(value)...)
+		} else {
+			tokens = append(tokens, value)
+		}
+	}
+	return tokens, nil
+}
+
+// writeHTTPResult write result set as columns and rows of objects by column name.
+func writeHTTPResult(w http.ResponseWriter, result *mysql.Result) {
+	body := struct {
+		Columns []string            `json:"columns"`
+		Rows    []map[string]string `json:"rows"`
+	}{Columns: []string{}, Rows: []map[string]string{}}
+	if result != nil && result.Resultset != nil {
+		for _, field := range result.Fields {
+			body.Columns = append(body.Columns, string(field.Name))
+		}
+		for i := 0; i < result.RowNumber(); i++ {
+			row := make(map[string]string, len(body.Columns))
+			for j, column := range body.Columns {
+				row[column], _ = result.GetString(i, j)
+			}
+			body.Rows = append(body.Rows, row)
+		}
+	}
+	writeHTTPJSON(w, http.StatusOK, body)
+}
+
+func writeHTTPError(w http.ResponseWriter, status int, err error) {
+	body := struct {
+		Error httpError `json:"error"`
+	}{httpError{Code: mysql.ER_UNKNOWN_ERROR, Message: err.Error()}}
+	if sqlErr, ok := err.(*errors.SqlError); ok {
+		body.Error.Code = sqlErr.Code
+		body.Error.Message = sqlErr.Message
+	}
+	writeHTTPJSON(w, status, body)
+}
+
+func writeHTTPJSON(w http.ResponseWriter, status int, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		simplelog.Error("%s %s %s", "server/admin", "writeHTTPJSON", err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
@@ -0,0 +1,64 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+// Statistics of statements by annotations, such as 'select /*app=checkout,endpoint=create_order*/ ...',
+// sorted by total time desc.
+const usageShowStatementLabels = "SHOW STATEMENT LABELS"
+
+var labelColumns = []string{"labels", "queries", "errors", "slow_queries", "total_time_ms", "avg_time_ms"}
+
+func init() {
+	registerCommand(usageShowStatementLabels, handleShowStatementLabels)
+}
+
+func handleShowStatementLabels(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) > 0 {
+		return nil, errArgs(usageShowStatementLabels)
+	}
+	list := c.admin.proxy.GetCounter().GetLabelStats()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].TotalTime != list[j].TotalTime {
+			return list[i].TotalTime > list[j].TotalTime
+		}
+		return list[i].Labels < list[j].Labels
+	})
+	rows := make([][]string, len(list))
+	for i, stats := range list {
+		var avgTime float64
+		if stats.Queries > 0 {
+			avgTime = float64(stats.TotalTime) / 1000 / float64(stats.Queries)
+		}
+		rows[i] = []string{stats.Labels, strconv.FormatInt(stats.Queries, 10), strconv.FormatInt(stats.Errors, 10),
+			strconv.FormatInt(stats.SlowQueries, 10), fmt.Sprintf("%.1f", float64(stats.TotalTime)/1000), fmt.Sprintf("%.3f", avgTime)}
+	}
+	return newResult(labelColumns, rows), nil
+}
@@ -0,0 +1,56 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+// Advisory locks of GET_LOCK() held by sessions of proxy.
+const usageShowAdvisoryLocks = "SHOW ADVISORY LOCKS"
+
+var advisoryLockColumns = []string{"name", "connection_id"}
+
+func init() {
+	registerCommand(usageShowAdvisoryLocks, handleShowAdvisoryLocks)
+}
+
+func handleShowAdvisoryLocks(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) > 0 {
+		return nil, errArgs(usageShowAdvisoryLocks)
+	}
+	owners := c.admin.proxy.AdvisoryLocks().Locks()
+	names := make([]string, 0, len(owners))
+	for name := range owners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rows := make([][]string, len(names))
+	for i, name := range names {
+		rows[i] = []string{name, strconv.FormatUint(uint64(owners[name]), 10)}
+	}
+	return newResult(advisoryLockColumns, rows), nil
+}
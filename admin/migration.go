@@ -0,0 +1,102 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+const (
+	usageShowDualWriteStatus = "SHOW DUAL WRITE STATUS"
+	usageShowDarkReadStatus  = "SHOW DARK READ STATUS"
+)
+
+func init() {
+	registerCommand(usageShowDualWriteStatus, handleShowDualWriteStatus)
+	registerCommand(usageShowDarkReadStatus, handleShowDarkReadStatus)
+}
+
+func handleShowDualWriteStatus(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageShowDualWriteStatus)
+	}
+	maps := c.admin.proxy.ShardMaps().List()
+	sort.Slice(maps, func(i, j int) bool { return maps[i].Schema < maps[j].Schema })
+	rows := make([][]string, 0, len(maps))
+	for _, m := range maps {
+		schemaConfig := c.admin.proxy.GetSchemaConfig(m.Schema)
+		counter := c.admin.proxy.GetMigrationCounter(m.Schema)
+		if schemaConfig == nil || counter == nil {
+			continue
+		}
+		var stagedVersion string
+		if m.Staged != nil {
+			stagedVersion = strconv.Itoa(m.Staged.Version)
+		}
+		rows = append(rows, []string{
+			m.Schema,
+			schemaConfig.GetDualWrite(),
+			stagedVersion,
+			strconv.FormatInt(atomic.LoadInt64(&counter.DualWrites), 10),
+			strconv.FormatInt(atomic.LoadInt64(&counter.DualWriteErrors), 10),
+			strconv.FormatInt(atomic.LoadInt64(&counter.DualWriteMismatches), 10),
+			strconv.FormatInt(atomic.LoadInt64(&counter.DualWriteDiscarded), 10),
+			strconv.FormatFloat(counter.DualWriteMismatchRate(), 'f', 4, 64),
+		})
+	}
+	return newResult([]string{"schema", "mode", "staged_version", "writes", "errors", "mismatches", "discarded", "mismatch_rate"}, rows), nil
+}
+
+func handleShowDarkReadStatus(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageShowDarkReadStatus)
+	}
+	maps := c.admin.proxy.ShardMaps().List()
+	sort.Slice(maps, func(i, j int) bool { return maps[i].Schema < maps[j].Schema })
+	rows := make([][]string, 0, len(maps))
+	for _, m := range maps {
+		schemaConfig := c.admin.proxy.GetSchemaConfig(m.Schema)
+		counter := c.admin.proxy.GetMigrationCounter(m.Schema)
+		if schemaConfig == nil || counter == nil {
+			continue
+		}
+		var stagedVersion string
+		if m.Staged != nil {
+			stagedVersion = strconv.Itoa(m.Staged.Version)
+		}
+		rows = append(rows, []string{
+			m.Schema,
+			strconv.FormatFloat(schemaConfig.DarkReadRate, 'f', -1, 64),
+			stagedVersion,
+			strconv.FormatInt(atomic.LoadInt64(&counter.DarkReads), 10),
+			strconv.FormatInt(atomic.LoadInt64(&counter.DarkReadErrors), 10),
+			strconv.FormatInt(atomic.LoadInt64(&counter.DarkReadMismatches), 10),
+			strconv.FormatFloat(counter.DarkReadMismatchRate(), 'f', 4, 64),
+		})
+	}
+	return newResult([]string{"schema", "sample_rate", "staged_version", "reads", "errors", "mismatches", "mismatch_rate"}, rows), nil
+}
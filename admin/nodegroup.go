@@ -0,0 +1,101 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/route"
+	"github.com/berkaroad/saashard/utils/alert"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// Blue/green switching for major version upgrade of databases:
+//  1. SWITCH NODE GROUP db1 green reads   (verify green nodes by reads first)
+//  2. SWITCH NODE GROUP db1 green writes
+//  3. SWITCH NODE GROUP db1 blue          (rollback all if need)
+const (
+	usageShowNodeGroups  = "SHOW NODE GROUPS [<schema>]"
+	usageSwitchNodeGroup = "SWITCH NODE GROUP <schema> <blue|green> [reads|writes]"
+)
+
+var nodeGroupColumns = []string{"schema", "read_group", "write_group", "blue_nodes", "green_nodes"}
+
+func init() {
+	registerCommand(usageShowNodeGroups, handleShowNodeGroups)
+	registerCommand(usageSwitchNodeGroup, handleSwitchNodeGroup)
+}
+
+func handleShowNodeGroups(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) > 1 {
+		return nil, errArgs(usageShowNodeGroups)
+	}
+	groups := c.admin.proxy.NodeGroups().List()
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Schema < groups[j].Schema })
+	rows := make([][]string, 0, len(groups))
+	for _, g := range groups {
+		if len(args) == 1 && g.Schema != strings.ToLower(args[0]) {
+			continue
+		}
+		rows = append(rows, nodeGroupRow(g))
+	}
+	return newResult(nodeGroupColumns, rows), nil
+}
+
+func handleSwitchNodeGroup(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, errArgs(usageSwitchNodeGroup)
+	}
+	schemaName := strings.ToLower(args[0])
+	group := strings.ToLower(args[1])
+	scope := route.SwitchAll
+	if len(args) == 3 {
+		scope = strings.ToLower(args[2])
+		if scope != route.SwitchReads && scope != route.SwitchWrites {
+			return nil, errArgs(usageSwitchNodeGroup)
+		}
+	}
+	if group != route.NodeGroupBlue && group != route.NodeGroupGreen {
+		return nil, errArgs(usageSwitchNodeGroup)
+	}
+	if err := c.admin.proxy.CheckNodeGroup(schemaName, group); err != nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+	}
+	g, err := c.admin.proxy.NodeGroups().Switch(schemaName, group, scope)
+	if err != nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+	}
+	simplelog.Info("%s %s %s schema=%s,group=%s,scope=%s,read_group=%s,write_group=%s", "admin", "handleSwitchNodeGroup", "Node group switched",
+		schemaName, group, scope, g.Read, g.Write)
+	alert.Emit(alert.EventFailover, alert.SeverityInfo, schemaName,
+		fmt.Sprintf("%s of schema '%s' switched to %s node group", scope, schemaName, group),
+		map[string]string{"group": group, "scope": scope, "read_group": g.Read, "write_group": g.Write})
+	return newResult(nodeGroupColumns, [][]string{nodeGroupRow(g)}), nil
+}
+
+func nodeGroupRow(g *route.NodeGroup) []string {
+	return []string{g.Schema, g.Read, g.Write, strings.Join(g.Blue, ","), strings.Join(g.Green, ",")}
+}
@@ -0,0 +1,105 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/route"
+	"github.com/berkaroad/saashard/utils"
+)
+
+// Pin routing and index hints to queries of the same fingerprint as sql, values ignored, overriding automatic decisions:
+//
+//	PIN PLAN db1 'select * from t1 where name = 1' MASTER NODE=node1 FORCE_INDEX=t1.idx_name
+//	SHOW PLAN PINS
+//	UNPIN PLAN db1 8f3a2c1b0d9e7f65  (digest of fingerprint)
+//
+// Pins are saved to plan_pin_file, FORCE_INDEX is not injected to prepared statements.
+const (
+	usagePinPlan      = "PIN PLAN <schema> <sql> [MASTER] [NODE=<node>] [FORCE_INDEX=<table>.<index> ...]"
+	usageShowPlanPins = "SHOW PLAN PINS"
+	usageUnpinPlan    = "UNPIN PLAN <schema> <digest>"
+)
+
+var planPinColumns = []string{"schema", "digest", "fingerprint", "on_master", "node", "force_indexes", "created_at", "hits"}
+
+func init() {
+	registerCommand(usagePinPlan, handlePinPlan)
+	registerCommand(usageShowPlanPins, handleShowPlanPins)
+	registerCommand(usageUnpinPlan, handleUnpinPlan)
+}
+
+func handlePinPlan(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) < 3 {
+		return nil, errArgs(usagePinPlan)
+	}
+	schemaName := strings.ToLower(args[0])
+	schemaConfig := c.admin.proxy.GetSchemaConfig(schemaName)
+	if schemaConfig == nil {
+		return nil, mysql.NewDefaultError(mysql.ER_BAD_DB_ERROR, schemaName)
+	}
+	pin, err := route.NewPlanPin(schemaName, args[1], args[2:])
+	if err != nil {
+		return nil, err
+	}
+	if len(pin.Node) > 0 && !utils.Contains(schemaConfig.Nodes, pin.Node) {
+		return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "data node '"+pin.Node+"' not in schema '"+schemaName+"'")
+	}
+	if err = c.admin.proxy.PlanPins().Pin(pin); err != nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+	}
+	return newResult([]string{"digest", "fingerprint"}, [][]string{{pin.Digest, pin.Fingerprint}}), nil
+}
+
+func handleShowPlanPins(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageShowPlanPins)
+	}
+	pins := c.admin.proxy.PlanPins().List()
+	rows := make([][]string, len(pins))
+	for i, pin := range pins {
+		rows[i] = []string{pin.Schema, pin.Digest, pin.Fingerprint, strconv.FormatBool(pin.OnMaster), pin.Node,
+			strings.Join(pin.ForceIndexes, ","), pin.CreatedAt.Format(time.RFC3339),
+			strconv.FormatInt(atomic.LoadInt64(&pin.Hits), 10)}
+	}
+	return newResult(planPinColumns, rows), nil
+}
+
+func handleUnpinPlan(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 2 {
+		return nil, errArgs(usageUnpinPlan)
+	}
+	removed, err := c.admin.proxy.PlanPins().Unpin(strings.ToLower(args[0]), strings.ToLower(args[1]))
+	if err != nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+	}
+	if !removed {
+		return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "plan pin '"+args[1]+"' not exists in schema '"+args[0]+"'")
+	}
+	return newResult([]string{"digest"}, [][]string{{strings.ToLower(args[1])}}), nil
+}
@@ -0,0 +1,50 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"strconv"
+
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+// Idle client connections parked in each frontend poller, empty if frontend_pollers is disabled.
+const usageShowFrontendPollers = "SHOW FRONTEND POLLERS"
+
+var pollerColumns = []string{"poller", "parked_connections"}
+
+func init() {
+	registerCommand(usageShowFrontendPollers, handleShowFrontendPollers)
+}
+
+func handleShowFrontendPollers(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) > 0 {
+		return nil, errArgs(usageShowFrontendPollers)
+	}
+	parked := c.admin.proxy.ParkedConnections()
+	rows := make([][]string, len(parked))
+	for i, count := range parked {
+		rows[i] = []string{strconv.Itoa(i), strconv.Itoa(count)}
+	}
+	return newResult(pollerColumns, rows), nil
+}
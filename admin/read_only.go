@@ -0,0 +1,90 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// Writes of whole proxy or a schema are rejected in read-only mode, such as during maintenance,
+// failover, or billing suspension of tenant.
+const (
+	usageShowReadOnly = "SHOW READ ONLY"
+	usageSetReadOnly  = "SET READ ONLY <on|off> [<schema>]"
+)
+
+var readOnlyColumns = []string{"scope", "read_only"}
+
+func init() {
+	registerCommand(usageShowReadOnly, handleShowReadOnly)
+	registerCommand(usageSetReadOnly, handleSetReadOnly)
+}
+
+func handleShowReadOnly(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageShowReadOnly)
+	}
+	all, schemas := c.admin.proxy.ReadOnlyMode().List()
+	rows := make([][]string, 0, len(schemas)+1)
+	rows = append(rows, []string{"*", onOff(all)})
+	for _, schemaName := range schemas {
+		rows = append(rows, []string{schemaName, onOff(true)})
+	}
+	return newResult(readOnlyColumns, rows), nil
+}
+
+func handleSetReadOnly(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, errArgs(usageSetReadOnly)
+	}
+	var readOnly bool
+	switch strings.ToLower(args[0]) {
+	case "on":
+		readOnly = true
+	case "off":
+	default:
+		return nil, errArgs(usageSetReadOnly)
+	}
+	scope := "*"
+	var schemaName string
+	if len(args) == 2 {
+		schemaName = strings.ToLower(args[1])
+		if c.admin.proxy.GetSchemaConfig(schemaName) == nil {
+			return nil, mysql.NewDefaultError(mysql.ER_BAD_DB_ERROR, schemaName)
+		}
+		scope = schemaName
+	}
+	c.admin.proxy.ReadOnlyMode().Set(schemaName, readOnly)
+	simplelog.Info("%s %s %s scope=%s,read_only=%s", "admin", "handleSetReadOnly", "Read-only mode set", scope, onOff(readOnly))
+	return newResult(readOnlyColumns, [][]string{{scope, onOff(readOnly)}}), nil
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
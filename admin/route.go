@@ -0,0 +1,125 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/route"
+)
+
+// Explain routing decision of sql statements in order by current shard rules, without executing them, such as:
+//
+//	EXPLAIN ROUTE db1 'select * from table1 where tenantid = 1' 'delete from table2 where tenantid in (1,2)'
+const usageExplainRoute = "EXPLAIN ROUTE <schema> <sql> [<sql> ...]"
+
+// Check sql compatibility of statements by routing category, such as:
+//
+//	CHECK SQL COMPAT db1 'select * from table1' 'select * from table2'         (count by category)
+//	CHECK SQL COMPAT db1 DETAIL 'select * from table1' 'select * from table2'  (category of each statement)
+//
+// General log or sql file of application is checked by cmd/sscompat, files in host of saashard are not read by admin.
+const usageCheckSQLCompat = "CHECK SQL COMPAT <schema> [DETAIL] <sql> [<sql> ...]"
+
+var routeColumns = []string{"seq", "schema", "sql", "data_nodes", "on_slave", "mirror_nodes", "plan_sql", "error"}
+
+var compatColumns = []string{"category", "count", "percent", "sample_sql", "sample_error"}
+
+func init() {
+	registerCommand(usageExplainRoute, handleExplainRoute)
+	registerCommand(usageCheckSQLCompat, handleCheckSQLCompat)
+}
+
+func handleExplainRoute(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) < 2 {
+		return nil, errArgs(usageExplainRoute)
+	}
+	schemaName := strings.ToLower(args[0])
+	router := c.admin.proxy.NewRouter(schemaName)
+	if router == nil {
+		return nil, mysql.NewDefaultError(mysql.ER_BAD_DB_ERROR, schemaName)
+	}
+	decisions := router.RouteStatements(args[1:])
+	rows := make([][]string, len(decisions))
+	for i, d := range decisions {
+		var errMsg string
+		if d.Err != nil {
+			errMsg = d.Err.Error()
+		}
+		rows[i] = []string{strconv.Itoa(i + 1), d.Schema, d.SQL, strings.Join(d.NodeNames, ","),
+			strconv.FormatBool(d.OnSlave), strings.Join(d.MirrorNodes, ","), d.PlanSQL, errMsg}
+	}
+	return newResult(routeColumns, rows), nil
+}
+
+func handleCheckSQLCompat(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) < 2 {
+		return nil, errArgs(usageCheckSQLCompat)
+	}
+	schemaName := strings.ToLower(args[0])
+	sqls := args[1:]
+	detail := strings.ToUpper(sqls[0]) == "DETAIL"
+	if detail {
+		if sqls = sqls[1:]; len(sqls) == 0 {
+			return nil, errArgs(usageCheckSQLCompat)
+		}
+	}
+	router := c.admin.proxy.NewRouter(schemaName)
+	if router == nil {
+		return nil, mysql.NewDefaultError(mysql.ER_BAD_DB_ERROR, schemaName)
+	}
+	decisions := router.RouteStatements(sqls)
+
+	if detail {
+		rows := make([][]string, len(decisions))
+		for i, d := range decisions {
+			var errMsg string
+			if d.Err != nil {
+				errMsg = d.Err.Error()
+			}
+			rows[i] = []string{strconv.Itoa(i + 1), d.Category(), d.Schema, d.SQL, strings.Join(d.NodeNames, ","), errMsg}
+		}
+		return newResult([]string{"seq", "category", "schema", "sql", "data_nodes", "error"}, rows), nil
+	}
+
+	summaries := route.SummarizeCompat(decisions)
+	rows := make([][]string, len(summaries))
+	for i, s := range summaries {
+		var percent float64
+		if len(decisions) > 0 {
+			percent = float64(s.Count) * 100 / float64(len(decisions))
+		}
+		var sampleSQL, sampleErr string
+		if s.Sample != nil {
+			sampleSQL = s.Sample.SQL
+			if s.Sample.Err != nil {
+				sampleErr = s.Sample.Err.Error()
+			}
+		}
+		rows[i] = []string{s.Category, strconv.Itoa(s.Count), fmt.Sprintf("%.2f", percent), sampleSQL, sampleErr}
+	}
+	return newResult(compatColumns, rows), nil
+}
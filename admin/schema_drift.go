@@ -0,0 +1,100 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/proxy"
+)
+
+const (
+	usageShowSchemaDrift       = "SHOW SCHEMA DRIFT"
+	usageShowSchemaDriftStatus = "SHOW SCHEMA DRIFT STATUS"
+	usageCheckSchemaDrift      = "CHECK SCHEMA DRIFT [schema]"
+)
+
+func init() {
+	registerCommand(usageShowSchemaDrift, handleShowSchemaDrift)
+	registerCommand(usageShowSchemaDriftStatus, handleShowSchemaDriftStatus)
+	registerCommand(usageCheckSchemaDrift, handleCheckSchemaDrift)
+}
+
+func handleShowSchemaDrift(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageShowSchemaDrift)
+	}
+	return schemaDriftResult(c.admin.proxy.GetSchemaDriftReport()), nil
+}
+
+func handleCheckSchemaDrift(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) > 1 {
+		return nil, errArgs(usageCheckSchemaDrift)
+	}
+	var schemaName string
+	if len(args) == 1 {
+		schemaName = strings.ToLower(args[0])
+		if schemaConfig := c.admin.proxy.GetSchemaConfig(schemaName); schemaConfig == nil || !schemaConfig.ShardEnabled() {
+			return nil, mysql.NewDefaultError(mysql.ER_BAD_DB_ERROR, schemaName)
+		}
+	}
+	return schemaDriftResult(c.admin.proxy.CheckSchemaDrift(schemaName)), nil
+}
+
+func handleShowSchemaDriftStatus(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageShowSchemaDriftStatus)
+	}
+	counter := c.admin.proxy.GetCounter()
+	var checkedAt, tables string
+	if report := c.admin.proxy.GetSchemaDriftReport(); report != nil {
+		checkedAt = report.CheckedAt.Format(time.RFC3339)
+		tables = strconv.Itoa(report.Tables)
+	}
+	return newResult([]string{"Checks", "Last_Checked_At", "Tables", "Drifts", "Errors"}, [][]string{{
+		strconv.FormatInt(atomic.LoadInt64(&counter.SchemaDriftChecks), 10),
+		checkedAt,
+		tables,
+		strconv.FormatInt(atomic.LoadInt64(&counter.SchemaDrifts), 10),
+		strconv.FormatInt(atomic.LoadInt64(&counter.SchemaDriftErrors), 10),
+	}}), nil
+}
+
+// schemaDriftResult list drifts and errors of report, error is in column 'Kind' as 'error'.
+func schemaDriftResult(report *proxy.SchemaDriftReport) *mysql.Result {
+	rows := make([][]string, 0, 16)
+	if report != nil {
+		for _, drift := range report.Drifts {
+			rows = append(rows, []string{drift.Schema, drift.Table, drift.Node, drift.RefNode,
+				drift.Kind, drift.Object, drift.Expected, drift.Actual})
+		}
+		for _, err := range report.Errors {
+			rows = append(rows, []string{"", "", "", "", "error", "", "", err})
+		}
+	}
+	return newResult([]string{"Schema", "Table", "Node", "Ref_Node", "Kind", "Object", "Expected", "Actual"}, rows)
+}
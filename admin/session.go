@@ -0,0 +1,113 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// Client sessions of proxy with tag of 'SET saashard_tag', optionally filtered by tag.
+const usageShowConnectionDetail = "SHOW CONNECTION DETAIL [<tag>]"
+
+// Kill client session of proxy, or only statement executing in it.
+const (
+	usageKillConnection = "KILL CONNECTION <connection_id>"
+	usageKillQuery      = "KILL QUERY <connection_id>"
+)
+
+// Route reads of client session to delayed slaves for data rescue, or back to slaves by default.
+// Reads of nodes whose host has no delayed slave are on master.
+const usageRouteConnection = "ROUTE CONNECTION <connection_id> TO <delayed|default>"
+
+var sessionColumns = []string{"connection_id", "user", "db", "client_addr", "tag", "state", "draining", "delayed_read"}
+
+func init() {
+	registerCommand(usageShowConnectionDetail, handleShowConnectionDetail)
+	registerCommand(usageRouteConnection, handleRouteConnection)
+	registerCommand(usageKillConnection, func(c *ClientConn, args []string) (*mysql.Result, error) {
+		return killConnection(c, args, false, usageKillConnection)
+	})
+	registerCommand(usageKillQuery, func(c *ClientConn, args []string) (*mysql.Result, error) {
+		return killConnection(c, args, true, usageKillQuery)
+	})
+}
+
+func handleShowConnectionDetail(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) > 1 {
+		return nil, errArgs(usageShowConnectionDetail)
+	}
+	sessions := c.admin.proxy.GetSessions()
+	rows := make([][]string, 0, len(sessions))
+	for _, s := range sessions {
+		if len(args) == 1 && s.Tag != strings.Trim(args[0], "'\"`") {
+			continue
+		}
+		rows = append(rows, []string{strconv.FormatUint(uint64(s.ConnectionID), 10), s.User, s.DB, s.ClientAddr,
+			s.Tag, s.State, strconv.FormatBool(s.Draining), strconv.FormatBool(s.DelayedRead)})
+	}
+	return newResult(sessionColumns, rows), nil
+}
+
+func killConnection(c *ClientConn, args []string, query bool, usage string) (*mysql.Result, error) {
+	if len(args) != 1 {
+		return nil, errArgs(usage)
+	}
+	connID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		return nil, errArgs(usage)
+	}
+	if !c.admin.proxy.KillConnection(uint32(connID), query) {
+		return nil, mysql.NewDefaultError(mysql.ER_NO_SUCH_THREAD, connID)
+	}
+	simplelog.Info("%s %s %s connectionID=%d,query=%t,user=%s", "server/admin", "killConnection", "killed",
+		connID, query, c.user)
+	return newResult([]string{"connection_id", "killed"}, [][]string{{args[0], strconv.FormatBool(true)}}), nil
+}
+
+func handleRouteConnection(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 3 || strings.ToUpper(args[1]) != "TO" {
+		return nil, errArgs(usageRouteConnection)
+	}
+	connID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		return nil, errArgs(usageRouteConnection)
+	}
+	var delayed bool
+	switch strings.ToLower(args[2]) {
+	case "delayed":
+		delayed = true
+	case "default":
+	default:
+		return nil, errArgs(usageRouteConnection)
+	}
+	if !c.admin.proxy.SetDelayedRead(uint32(connID), delayed) {
+		return nil, mysql.NewDefaultError(mysql.ER_NO_SUCH_THREAD, connID)
+	}
+	simplelog.Info("%s %s %s connectionID=%d,delayed=%t,user=%s", "server/admin", "handleRouteConnection", "routed",
+		connID, delayed, c.user)
+	return newResult([]string{"connection_id", "delayed_read"}, [][]string{{args[0], strconv.FormatBool(delayed)}}), nil
+}
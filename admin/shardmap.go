@@ -0,0 +1,184 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/route"
+	"github.com/berkaroad/saashard/utils/alert"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// Shard map cutover:
+//  1. STAGE SHARD MAP db1 hash db1_node1,db1_node2,db1_node3
+//  2. VALIDATE SHARD MAP db1
+//  3. SWITCH SHARD MAP db1 100-199,300  (optional, switch some key ranges first)
+//  4. SWITCH SHARD MAP db1              (switch all)
+//  5. ROLLBACK SHARD MAP db1            (if need)
+const (
+	usageShowShardMaps    = "SHOW SHARD MAPS [<schema>]"
+	usageStageShardMap    = "STAGE SHARD MAP <schema> <shard_algo> <node1,node2,...>"
+	usageValidateShardMap = "VALIDATE SHARD MAP <schema>"
+	usageSwitchShardMap   = "SWITCH SHARD MAP <schema> [<min-max,...>]"
+	usageRollbackShardMap = "ROLLBACK SHARD MAP <schema>"
+	usageDiscardShardMap  = "DISCARD SHARD MAP <schema>"
+)
+
+var shardMapColumns = []string{"schema", "version", "shard_algo", "nodes",
+	"staged_version", "staged_shard_algo", "staged_nodes", "validated", "switched_ranges", "previous_version"}
+
+func init() {
+	registerCommand(usageShowShardMaps, handleShowShardMaps)
+	registerCommand(usageStageShardMap, handleStageShardMap)
+	registerCommand(usageValidateShardMap, handleValidateShardMap)
+	registerCommand(usageSwitchShardMap, handleSwitchShardMap)
+	registerCommand(usageRollbackShardMap, handleRollbackShardMap)
+	registerCommand(usageDiscardShardMap, handleDiscardShardMap)
+}
+
+func handleShowShardMaps(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) > 1 {
+		return nil, errArgs(usageShowShardMaps)
+	}
+	maps := c.admin.proxy.ShardMaps().List()
+	sort.Slice(maps, func(i, j int) bool { return maps[i].Schema < maps[j].Schema })
+	rows := make([][]string, 0, len(maps))
+	for _, m := range maps {
+		if len(args) == 1 && m.Schema != strings.ToLower(args[0]) {
+			continue
+		}
+		rows = append(rows, shardMapRow(m))
+	}
+	return newResult(shardMapColumns, rows), nil
+}
+
+func handleStageShardMap(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 3 {
+		return nil, errArgs(usageStageShardMap)
+	}
+	schemaName := strings.ToLower(args[0])
+	if !route.IsShardAlgorithm(args[1]) {
+		return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "shard algorithm '"+args[1]+"' not supported")
+	}
+	var nodes []string
+	for _, node := range strings.Split(args[2], ",") {
+		if node = strings.TrimSpace(node); len(node) > 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	return updateShardMap(c, "stage", schemaName, func(shardMaps *route.ShardMaps) (*route.ShardRule, error) {
+		return shardMaps.Stage(schemaName, strings.ToLower(args[1]), nodes)
+	})
+}
+
+func handleValidateShardMap(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 1 {
+		return nil, errArgs(usageValidateShardMap)
+	}
+	schemaName := strings.ToLower(args[0])
+	return updateShardMap(c, "validate", schemaName, func(shardMaps *route.ShardMaps) (*route.ShardRule, error) {
+		return shardMaps.Validate(schemaName, func(rule *route.ShardRule) error {
+			return c.admin.proxy.CheckShardRule(schemaName, rule)
+		})
+	})
+}
+
+func handleSwitchShardMap(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, errArgs(usageSwitchShardMap)
+	}
+	schemaName := strings.ToLower(args[0])
+	var ranges []route.KeyRange
+	if len(args) == 2 {
+		for _, s := range strings.Split(args[1], ",") {
+			kr, err := route.ParseKeyRange(s)
+			if err != nil {
+				return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, err.Error())
+			}
+			ranges = append(ranges, kr)
+		}
+	}
+	return updateShardMap(c, "switch", schemaName, func(shardMaps *route.ShardMaps) (*route.ShardRule, error) {
+		return shardMaps.Switch(schemaName, ranges)
+	})
+}
+
+func handleRollbackShardMap(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 1 {
+		return nil, errArgs(usageRollbackShardMap)
+	}
+	schemaName := strings.ToLower(args[0])
+	return updateShardMap(c, "rollback", schemaName, func(shardMaps *route.ShardMaps) (*route.ShardRule, error) {
+		return shardMaps.Rollback(schemaName)
+	})
+}
+
+func handleDiscardShardMap(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 1 {
+		return nil, errArgs(usageDiscardShardMap)
+	}
+	schemaName := strings.ToLower(args[0])
+	return updateShardMap(c, "discard", schemaName, func(shardMaps *route.ShardMaps) (*route.ShardRule, error) {
+		return shardMaps.Discard(schemaName)
+	})
+}
+
+// updateShardMap update shard map, log it and return the shard map after updated.
+func updateShardMap(c *ClientConn, action string, schemaName string, update func(shardMaps *route.ShardMaps) (*route.ShardRule, error)) (*mysql.Result, error) {
+	shardMaps := c.admin.proxy.ShardMaps()
+	rule, err := update(shardMaps)
+	if err != nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+	}
+	simplelog.Info("%s %s %s schema=%s,action=%s,version=%d,shard_algo=%s,nodes=%s", "admin", "updateShardMap", "Shard map updated",
+		schemaName, action, rule.Version, rule.ShardAlgo, strings.Join(rule.Nodes, ","))
+	alert.Emit(alert.EventMigrationStep, alert.SeverityInfo, schemaName,
+		fmt.Sprintf("shard map of schema '%s' %s completed, version %d", schemaName, action, rule.Version),
+		map[string]string{"action": action, "version": strconv.Itoa(rule.Version),
+			"shard_algo": rule.ShardAlgo, "nodes": strings.Join(rule.Nodes, ",")})
+	return newResult(shardMapColumns, [][]string{shardMapRow(shardMaps.Get(schemaName))}), nil
+}
+
+func shardMapRow(m *route.ShardMap) []string {
+	row := []string{m.Schema, strconv.Itoa(m.Active.Version), m.Active.ShardAlgo, strings.Join(m.Active.Nodes, ","), "", "", "", "", "", ""}
+	if m.Staged != nil {
+		row[4] = strconv.Itoa(m.Staged.Version)
+		row[5] = m.Staged.ShardAlgo
+		row[6] = strings.Join(m.Staged.Nodes, ",")
+		row[7] = strconv.FormatBool(m.Validated)
+		ranges := make([]string, len(m.Ranges))
+		for i, kr := range m.Ranges {
+			ranges[i] = kr.String()
+		}
+		row[8] = strings.Join(ranges, ",")
+	}
+	if m.Previous != nil {
+		row[9] = strconv.Itoa(m.Previous.Version)
+	}
+	return row
+}
@@ -0,0 +1,125 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/statistic"
+)
+
+// Top statements by fingerprint, values replaced by '?', to locate chatty or slow endpoints, such as:
+//
+//	SHOW TOP STATEMENTS                 (top 20 by total time)
+//	SHOW TOP STATEMENTS bytes_sent 10   (order by total_time, avg_time, max_time, queries, errors, bytes_sent or bytes_received)
+//	RESET STATEMENT STATS
+//
+// Digest of fingerprint is the same as of 'PIN PLAN'.
+const (
+	usageShowTopStatements   = "SHOW TOP STATEMENTS [<order>] [<limit>]"
+	usageResetStatementStats = "RESET STATEMENT STATS"
+)
+
+// defaultTopStatements is count of top statements if limit is not set.
+const defaultTopStatements = 20
+
+var topStatementColumns = []string{"schema", "digest", "fingerprint", "queries", "errors", "total_time_ms", "avg_time_ms",
+	"max_time_ms", "bytes_received", "bytes_sent", "avg_bytes_sent"}
+
+// topStatementOrders are values of statistics to order by, desc.
+var topStatementOrders = map[string]func(stats *statistic.StatementStats) float64{
+	"total_time": func(stats *statistic.StatementStats) float64 { return float64(stats.TotalTime) },
+	"avg_time": func(stats *statistic.StatementStats) float64 {
+		return float64(stats.TotalTime) / float64(stats.Queries)
+	},
+	"max_time":       func(stats *statistic.StatementStats) float64 { return float64(stats.MaxTime) },
+	"queries":        func(stats *statistic.StatementStats) float64 { return float64(stats.Queries) },
+	"errors":         func(stats *statistic.StatementStats) float64 { return float64(stats.Errors) },
+	"bytes_sent":     func(stats *statistic.StatementStats) float64 { return float64(stats.BytesSent) },
+	"bytes_received": func(stats *statistic.StatementStats) float64 { return float64(stats.BytesReceived) },
+}
+
+func init() {
+	registerCommand(usageShowTopStatements, handleShowTopStatements)
+	registerCommand(usageResetStatementStats, handleResetStatementStats)
+}
+
+func handleShowTopStatements(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) > 2 {
+		return nil, errArgs(usageShowTopStatements)
+	}
+	order := "total_time"
+	limit := defaultTopStatements
+	if len(args) > 0 {
+		order = strings.ToLower(args[0])
+	}
+	if len(args) > 1 {
+		var err error
+		if limit, err = strconv.Atoi(args[1]); err != nil || limit <= 0 {
+			return nil, errArgs(usageShowTopStatements)
+		}
+	}
+	value := topStatementOrders[order]
+	if value == nil {
+		return nil, errArgs(usageShowTopStatements)
+	}
+
+	list := c.admin.proxy.GetCounter().GetStatementStats()
+	// queries is 0 if stats is just added.
+	for i := 0; i < len(list); i++ {
+		if list[i].Queries == 0 {
+			list = append(list[:i], list[i+1:]...)
+			i--
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if vi, vj := value(&list[i]), value(&list[j]); vi != vj {
+			return vi > vj
+		}
+		return list[i].Schema+list[i].Digest < list[j].Schema+list[j].Digest
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	rows := make([][]string, len(list))
+	for i, stats := range list {
+		rows[i] = []string{stats.Schema, stats.Digest, stats.Fingerprint, strconv.FormatInt(stats.Queries, 10),
+			strconv.FormatInt(stats.Errors, 10), fmt.Sprintf("%.1f", float64(stats.TotalTime)/1000),
+			fmt.Sprintf("%.3f", float64(stats.TotalTime)/1000/float64(stats.Queries)),
+			fmt.Sprintf("%.3f", float64(stats.MaxTime)/1000), strconv.FormatInt(stats.BytesReceived, 10),
+			strconv.FormatInt(stats.BytesSent, 10), strconv.FormatInt(stats.BytesSent/stats.Queries, 10)}
+	}
+	return newResult(topStatementColumns, rows), nil
+}
+
+func handleResetStatementStats(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageResetStatementStats)
+	}
+	c.admin.proxy.GetCounter().ResetStatementStats()
+	return newResult([]string{"result"}, [][]string{{"reset"}}), nil
+}
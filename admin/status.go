@@ -0,0 +1,137 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/berkaroad/saashard"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+const (
+	usageShowStatus   = "SHOW STATUS"
+	usageShowNodes    = "SHOW NODES"
+	usageShowSchemas  = "SHOW SCHEMAS"
+	usageReloadConfig = "RELOAD CONFIG"
+)
+
+func init() {
+	registerCommand(usageShowStatus, handleShowStatus)
+	registerCommand(usageShowNodes, handleShowNodes)
+	registerCommand(usageShowSchemas, handleShowSchemas)
+	registerCommand(usageReloadConfig, handleReloadConfig)
+}
+
+func handleShowStatus(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageShowStatus)
+	}
+	p := c.admin.proxy
+	counter := p.GetCounter()
+	all, _ := p.ReadOnlyMode().List()
+	startTime := p.StartTime()
+	rows := [][]string{
+		{"version", saashard.Version},
+		{"start_time", startTime.Format("2006-01-02 15:04:05")},
+		{"uptime", strconv.FormatInt(int64(time.Since(startTime)/time.Second), 10)},
+		{"client_conns", strconv.FormatInt(atomic.LoadInt64(&counter.ClientConns), 10)},
+		{"client_qps", strconv.FormatInt(atomic.LoadInt64(&counter.OldClientQPS), 10)},
+		{"error_log_total", strconv.FormatInt(atomic.LoadInt64(&counter.OldErrLogTotal), 10)},
+		{"slow_log_total", strconv.FormatInt(atomic.LoadInt64(&counter.OldSlowLogTotal), 10)},
+		{"bytes_received_total", strconv.FormatInt(atomic.LoadInt64(&counter.BytesReceived), 10)},
+		{"bytes_sent_total", strconv.FormatInt(atomic.LoadInt64(&counter.BytesSent), 10)},
+		{"read_only", onOff(all)},
+		{"hosts", strconv.Itoa(len(p.GetDataHosts()))},
+		{"nodes", strconv.Itoa(len(p.GetDataNodes()))},
+		{"schemas", strconv.Itoa(len(p.GetSchemaConfigs()))},
+	}
+	return newResult([]string{"name", "value"}, rows), nil
+}
+
+func handleShowNodes(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageShowNodes)
+	}
+	nodes := c.admin.proxy.GetDataNodes()
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		node := nodes[name]
+		slaves := make([]string, 0, len(node.DataHost.Slaves))
+		for _, slave := range node.DataHost.Slaves {
+			slaves = append(slaves, slave.Addr)
+		}
+		delayedSlaves := make([]string, 0, len(node.DataHost.DelayedSlaves))
+		for _, delayed := range node.DataHost.DelayedSlaves {
+			delayedSlaves = append(delayedSlaves, delayed.Addr)
+		}
+		rows = append(rows, []string{name, node.Database, node.DataHost.Name, node.DataHost.GetMaster().Addr,
+			strings.Join(slaves, ","), strings.Join(delayedSlaves, ",")})
+	}
+	return newResult([]string{"node", "database", "host", "master", "slaves", "delayed_slaves"}, rows), nil
+}
+
+func handleShowSchemas(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageShowSchemas)
+	}
+	p := c.admin.proxy
+	schemas := p.GetSchemaConfigs()
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		schema := schemas[name]
+		rows = append(rows, []string{name, schema.User, strconv.FormatBool(schema.ShardEnabled()), schema.ShardKey,
+			schema.ShardAlgo, strings.Join(schema.Nodes, ","), strconv.Itoa(len(schema.Tables)),
+			onOff(p.ReadOnlyMode().IsReadOnly(name))})
+	}
+	return newResult([]string{"schema", "user", "sharded", "shard_key", "shard_algo", "nodes", "tables", "read_only"}, rows), nil
+}
+
+// handleReloadConfig reload hosts, nodes, schemas, shard rules and settings of session from config file.
+func handleReloadConfig(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) != 0 {
+		return nil, errArgs(usageReloadConfig)
+	}
+	if err := c.admin.proxy.ReloadConfig(); err != nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+	}
+	simplelog.Info("%s %s %s user=%s", "server/admin", "handleReloadConfig", "config reloaded", c.user)
+	return newResult([]string{"result"}, [][]string{{"reloaded"}}), nil
+}
@@ -0,0 +1,104 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package admin
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+// Transaction log and recovery of distributed transactions:
+//   - SHOW XA RECOVER   (pending entries of transaction log, not done)
+//   - COMPACT XA LOG    (remove entries of transactions done from storage)
+//   - RECOVER XA        (resolve dangling prepared transactions by transaction log, it's also done on startup)
+const (
+	usageShowXARecover = "SHOW XA RECOVER"
+	usageCompactXALog  = "COMPACT XA LOG"
+	usageRecoverXA     = "RECOVER XA"
+)
+
+var (
+	xaLogColumns     = []string{"gtrid", "state", "nodes", "time", "owned"}
+	recoverXAColumns = []string{"host", "gtrid", "bqual", "format_id", "log_state", "action", "error"}
+)
+
+var errNoXALog = mysql.NewError(mysql.ER_UNKNOWN_ERROR, "xa log is not configured")
+
+func init() {
+	registerCommand(usageShowXARecover, handleShowXARecover)
+	registerCommand(usageCompactXALog, handleCompactXALog)
+	registerCommand(usageRecoverXA, handleRecoverXA)
+}
+
+func handleShowXARecover(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) > 0 {
+		return nil, errArgs(usageShowXARecover)
+	}
+	xaLog := c.admin.proxy.XALog()
+	if xaLog == nil {
+		return nil, errNoXALog
+	}
+	entries := xaLog.Pending()
+	rows := make([][]string, len(entries))
+	for i, entry := range entries {
+		rows[i] = []string{entry.Gtrid, entry.State, strings.Join(entry.Nodes, ","),
+			time.Unix(entry.Time, 0).Format("2006-01-02 15:04:05"), strconv.FormatBool(xaLog.Owned(entry.Gtrid))}
+	}
+	return newResult(xaLogColumns, rows), nil
+}
+
+func handleCompactXALog(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) > 0 {
+		return nil, errArgs(usageCompactXALog)
+	}
+	xaLog := c.admin.proxy.XALog()
+	if xaLog == nil {
+		return nil, errNoXALog
+	}
+	if err := xaLog.Compact(); err != nil {
+		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+	}
+	return handleShowXARecover(c, nil)
+}
+
+func handleRecoverXA(c *ClientConn, args []string) (*mysql.Result, error) {
+	if len(args) > 0 {
+		return nil, errArgs(usageRecoverXA)
+	}
+	branches := c.admin.proxy.RecoverXA()
+	rows := make([][]string, len(branches))
+	for i, branch := range branches {
+		var errMsg, formatID string
+		if branch.Err != nil {
+			errMsg = branch.Err.Error()
+		}
+		if len(branch.Xid.Gtrid) > 0 {
+			formatID = strconv.FormatInt(branch.Xid.FormatID, 10)
+		}
+		rows[i] = []string{branch.Host, branch.Xid.Gtrid, branch.Xid.Bqual, formatID, branch.LogState, branch.Action, errMsg}
+	}
+	return newResult(recoverXAColumns, rows), nil
+}
@@ -79,6 +79,9 @@ type Connection interface {
 
 	// IsClosed check connection status
 	IsClosed() bool
+
+	// ResetSession restore session to the state of pool, such as transaction rolled back.
+	ResetSession() error
 }
 
 // nilConnection default connection.
@@ -130,14 +133,70 @@ func (c *nilConnection) GetAddr() string { return "127.0.0.1:6051" }
 // IsClosed check connection status
 func (c *nilConnection) IsClosed() bool { return true }
 
+// ResetSession restore session to the state of pool.
+func (c *nilConnection) ResetSession() error { return nil }
+
 // ConnectionPool to manage connection pool.
+// Connections opened, used and cached, are limited by MaxPoolSize, others wait in queue until one is returned.
 type ConnectionPool struct {
 	locker      *sync.Mutex
 	MaxPoolSize uint32
 	used        uint32
+	lastConnID  uint32 // id of connections created, unique in pool.
 	dbHost      *DBHost
-	connections *list.List
+	connections *list.List // of *idleConn, most recently returned at front.
 	connids     map[uint32]interface{}
+
+	// MaxIdle is max count of connections cached, others are closed when returned.
+	MaxIdle uint32
+	// MinIdle is count of connections cached kept when reaping.
+	MinIdle uint32
+	// MaxLifetime of connection, it's reconnected when taken or closed when reaping if exceeded, 0 is unlimited.
+	MaxLifetime time.Duration
+	// IdleTimeout close connection cached longer than it when reaping, 0 is never.
+	IdleTimeout time.Duration
+	// MaxWaitQueue is max count of waiting for connection when all are used.
+	MaxWaitQueue int
+	// WaitTimeout of waiting for connection, 0 is waiting until one is returned.
+	WaitTimeout time.Duration
+
+	opened      uint32               // connections opened, used and cached.
+	waiters     *list.List           // of *poolWaiter, first come first served.
+	connectedAt map[uint32]time.Time // time connected of connections opened, by connection id.
+
+	waits        uint64
+	waitTimeouts uint64
+	rejected     uint64
+	expired      uint64
+	reaped       uint64
+}
+
+// idleConn is connection cached in pool.
+type idleConn struct {
+	conn  Connection
+	since time.Time
+}
+
+// poolWaiter wait for connection returned, or nil to connect a new one.
+type poolWaiter struct {
+	ready chan Connection
+	elem  *list.Element // nil once served.
+}
+
+// ConnectionPoolStats is stats of connection pool.
+type ConnectionPoolStats struct {
+	MaxPoolSize  uint32
+	MaxIdle      uint32
+	MinIdle      uint32
+	Opened       uint32
+	Used         uint32
+	Cached       int
+	Waiting      int
+	Waits        uint64
+	WaitTimeouts uint64
+	Rejected     uint64
+	Expired      uint64
+	Reaped       uint64
 }
 
 // NewConnectionPool create connection pool
@@ -148,97 +207,284 @@ func NewConnectionPool(maxPoolSize uint32, dbHost *DBHost) *ConnectionPool {
 	if p.MaxPoolSize == 0 {
 		p.MaxPoolSize = math.MaxUint32
 	}
+	p.MaxIdle = p.MaxPoolSize
 	p.dbHost = dbHost
 	p.connections = list.New()
 	p.connids = make(map[uint32]interface{})
+	p.waiters = list.New()
+	p.connectedAt = make(map[uint32]time.Time)
 	return p
 }
 
+// GetUsedCount Get used count.
+func (p *ConnectionPool) GetUsedCount() uint32 {
+	return atomic.LoadUint32(&p.used)
+}
+
+// GetCachedCount Get count of connections cached in pool.
+func (p *ConnectionPool) GetCachedCount() int {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	return p.connections.Len()
+}
+
 // GetIdleCount Get Idle count.
 func (p *ConnectionPool) GetIdleCount() uint32 {
-	return p.MaxPoolSize - p.used
+	return p.MaxPoolSize - atomic.LoadUint32(&p.used)
+}
+
+// Stats of connection pool.
+func (p *ConnectionPool) Stats() ConnectionPoolStats {
+	var stats ConnectionPoolStats
+	p.locker.Lock()
+	stats.Opened = p.opened
+	stats.Cached = p.connections.Len()
+	stats.Waiting = p.waiters.Len()
+	p.locker.Unlock()
+	stats.MaxPoolSize = p.MaxPoolSize
+	stats.MaxIdle = p.MaxIdle
+	stats.MinIdle = p.MinIdle
+	stats.Used = atomic.LoadUint32(&p.used)
+	stats.Waits = atomic.LoadUint64(&p.waits)
+	stats.WaitTimeouts = atomic.LoadUint64(&p.waitTimeouts)
+	stats.Rejected = atomic.LoadUint64(&p.rejected)
+	stats.Expired = atomic.LoadUint64(&p.expired)
+	stats.Reaped = atomic.LoadUint64(&p.reaped)
+	return stats
 }
 
-// GetConnection get connection from pool
+// GetConnection get connection from pool, the most recently returned is taken first,
+// or connect a new one if not full, otherwise wait in queue. Lock is not held when connecting and waiting.
 func (p *ConnectionPool) GetConnection(database string) (Connection, error) {
-	defer p.locker.Unlock()
 	defer p.logConnIdleInfo()
 
-	p.locker.Lock()
-	var conn Connection
 	var err error
-	var retryCount int
-	f := func() (Connection, error) {
+	for retryCount := 0; retryCount <= 3; retryCount++ {
 		var conn Connection
-		var err error
-		if p.GetIdleCount() > 0 {
-			atomic.AddUint32(&p.used, 1)
-			if p.connections.Len() > 0 {
-				elem := p.connections.Back()
-				p.connections.Remove(elem)
-				conn = elem.Value.(Connection)
-				delete(p.connids, conn.GetConnectionID())
-				err = conn.Reconnect()
-				if err != nil {
-					atomic.AddUint32(&p.used, ^uint32(0))
-					conn = nil
-				}
-			} else {
-				conn = CreateConnection(p.dbHost)
-				err = conn.Connect(p.dbHost, database)
-				if err != nil {
-					atomic.AddUint32(&p.used, ^uint32(0))
-					conn = nil
-				} else if conn.GetConnectionID() == 0 {
-					conn.SetConnectionID(p.used)
-				}
-			}
-		} else {
-			time.Sleep(time.Second)
-			err = errors.ErrNoIdleConn
+		if conn, err = p.acquire(); err != nil {
+			return nil, err
+		}
+		if conn, err = p.connect(conn, database); err == nil {
+			p.dbHost.markUp()
+			return conn, nil
 		}
-		return conn, err
 	}
-	conn, err = f()
-	retryCount++
-	for ; err != nil && retryCount <= 3; retryCount++ {
-		conn, err = f()
+	p.dbHost.markDown(err)
+	return nil, err
+}
+
+// acquire take a cached connection, or nil to connect a new one, wait in queue if pool is full.
+func (p *ConnectionPool) acquire() (Connection, error) {
+	p.locker.Lock()
+	if elem := p.connections.Front(); elem != nil {
+		conn := elem.Value.(*idleConn).conn
+		p.connections.Remove(elem)
+		delete(p.connids, conn.GetConnectionID())
+		atomic.AddUint32(&p.used, 1)
+		p.locker.Unlock()
+		return conn, nil
+	}
+	if p.opened < p.MaxPoolSize {
+		p.opened++
+		atomic.AddUint32(&p.used, 1)
+		p.locker.Unlock()
+		return nil, nil
+	}
+	if p.waiters.Len() >= p.MaxWaitQueue {
+		p.locker.Unlock()
+		atomic.AddUint64(&p.rejected, 1)
+		return nil, errors.ErrNoIdleConn
+	}
+	w := &poolWaiter{ready: make(chan Connection, 1)}
+	w.elem = p.waiters.PushBack(w)
+	p.locker.Unlock()
+	atomic.AddUint64(&p.waits, 1)
+
+	var timeout <-chan time.Time
+	if p.WaitTimeout > 0 {
+		timer := time.NewTimer(p.WaitTimeout)
+		defer timer.Stop()
+		timeout = timer.C
 	}
+	select {
+	case conn := <-w.ready:
+		return conn, nil
+	case <-timeout:
+		p.locker.Lock()
+		if w.elem != nil {
+			p.waiters.Remove(w.elem)
+			p.locker.Unlock()
+			atomic.AddUint64(&p.waitTimeouts, 1)
+			return nil, errors.ErrConnWaitTimeout
+		}
+		p.locker.Unlock()
+		// served just before timeout.
+		return <-w.ready, nil
+	}
+}
 
-	return conn, err
+// connect a new connection if conn is nil, or reconnect cached one, it's closed first if exceeds max lifetime.
+func (p *ConnectionPool) connect(conn Connection, database string) (Connection, error) {
+	var err error
+	if conn == nil {
+		conn = CreateConnection(p.dbHost)
+		if err = conn.Connect(p.dbHost, database); err == nil && conn.GetConnectionID() == 0 {
+			conn.SetConnectionID(atomic.AddUint32(&p.lastConnID, 1))
+		}
+	} else {
+		p.locker.Lock()
+		expired := p.isExpired(conn, time.Now())
+		p.locker.Unlock()
+		if expired {
+			atomic.AddUint64(&p.expired, 1)
+			conn.Close()
+		}
+		err = conn.Reconnect()
+	}
+
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	if err != nil {
+		atomic.AddUint32(&p.used, ^uint32(0))
+		p.forget(conn)
+		return nil, err
+	}
+	if _, ok := p.connectedAt[conn.GetConnectionID()]; !ok || p.isExpired(conn, time.Now()) {
+		p.connectedAt[conn.GetConnectionID()] = time.Now()
+	}
+	return conn, nil
 }
 
-// ReturnConnection give back connection to pool
+// isExpired connection exceeds max lifetime, must hold lock.
+func (p *ConnectionPool) isExpired(conn Connection, now time.Time) bool {
+	if p.MaxLifetime <= 0 {
+		return false
+	}
+	connectedAt, ok := p.connectedAt[conn.GetConnectionID()]
+	return ok && now.Sub(connectedAt) > p.MaxLifetime
+}
+
+// serve the first waiter by connection returned or nil to connect a new one, false if no waiter. Must hold lock.
+func (p *ConnectionPool) serve(conn Connection) bool {
+	elem := p.waiters.Front()
+	if elem == nil {
+		return false
+	}
+	w := p.waiters.Remove(elem).(*poolWaiter)
+	w.elem = nil
+	w.ready <- conn
+	return true
+}
+
+// forget connection closed by pool or failed to connect, its slot is given to waiter if any. Must hold lock.
+func (p *ConnectionPool) forget(conn Connection) {
+	delete(p.connectedAt, conn.GetConnectionID())
+	if p.serve(nil) {
+		atomic.AddUint32(&p.used, 1)
+		return
+	}
+	p.opened--
+}
+
+// ReturnConnection give back connection to pool, it's given to waiter if any, or closed if cached are more than max idle.
 func (p *ConnectionPool) ReturnConnection(conn Connection) {
-	defer p.locker.Unlock()
 	defer p.logConnIdleInfo()
+	p.release(conn)
+}
+
+// release connection to waiter or cache, or close it.
+func (p *ConnectionPool) release(conn Connection) {
+	if conn == nil || conn.GetConnectionID() == 0 {
+		return
+	}
+	// transaction, autocommit and sql_mode left by client session or background writer aren't taken by next one.
+	if !conn.IsClosed() && conn.ResetSession() != nil {
+		conn.Close()
+	}
+	p.locker.Lock()
+	if _, exists := p.connids[conn.GetConnectionID()]; exists {
+		p.locker.Unlock()
+		return
+	}
+	if p.serve(conn) {
+		p.locker.Unlock()
+		return
+	}
+	atomic.AddUint32(&p.used, ^uint32(0))
+	if uint32(p.connections.Len()) >= p.MaxIdle {
+		p.forget(conn)
+		p.locker.Unlock()
+		conn.Close()
+		return
+	}
+	p.connections.PushFront(&idleConn{conn: conn, since: time.Now()})
+	p.connids[conn.GetConnectionID()] = nil
+	p.locker.Unlock()
+}
 
+// ReapIdle close connections cached longer than idle timeout or exceed max lifetime, min idle ones are kept.
+func (p *ConnectionPool) ReapIdle() {
+	now := time.Now()
+	var conns []Connection
 	p.locker.Lock()
-	if conn != nil && conn.GetConnectionID() > 0 {
-		if _, exists := p.connids[conn.GetConnectionID()]; !exists {
-			p.connections.PushFront(conn)
-			p.connids[conn.GetConnectionID()] = nil
-			atomic.AddUint32(&p.used, ^uint32(0))
+	for elem := p.connections.Back(); elem != nil && uint32(p.connections.Len()) > p.MinIdle; {
+		prev := elem.Prev()
+		idle := elem.Value.(*idleConn)
+		if (p.IdleTimeout > 0 && now.Sub(idle.since) > p.IdleTimeout) || p.isExpired(idle.conn, now) {
+			p.connections.Remove(elem)
+			delete(p.connids, idle.conn.GetConnectionID())
+			p.forget(idle.conn)
+			conns = append(conns, idle.conn)
 		}
+		elem = prev
+	}
+	p.locker.Unlock()
+
+	if len(conns) > 0 {
+		atomic.AddUint64(&p.reaped, uint64(len(conns)))
+		for _, conn := range conns {
+			conn.Close()
+		}
+		simplelog.Info("%s %s %s DBHost=%s,reaped=%d", "backend", "ReapIdle", "Idle connections closed", p.dbHost.Addr, len(conns))
+	}
+}
+
+// Drain close connections cached in pool, such as db host is down.
+func (p *ConnectionPool) Drain() {
+	p.locker.Lock()
+	conns := make([]Connection, 0, p.connections.Len())
+	for elem := p.connections.Front(); elem != nil; elem = elem.Next() {
+		conn := elem.Value.(*idleConn).conn
+		p.forget(conn)
+		conns = append(conns, conn)
+	}
+	p.connections.Init()
+	p.connids = make(map[uint32]interface{})
+	p.locker.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
 	}
 }
 
 func (p *ConnectionPool) logConnIdleInfo() {
-	idleCount := p.GetIdleCount()
+	used := p.GetUsedCount()
+	cached := p.GetCachedCount()
+	idleCount := p.MaxPoolSize - used
 	// idleCount is zero, or less or equal then 20%, then warn
 	if idleCount*100/p.MaxPoolSize <= 20 {
 		simplelog.Warn("%s %s %s DBHost=%s,used=%d,cached=%d,idle=%d",
 			"backend", "NewConnectionPool", "Idle count is less or equal then 20 pecent",
 			p.dbHost.Addr,
-			p.used,
-			p.connections.Len(),
+			used,
+			cached,
 			idleCount)
 	} else {
 		simplelog.Info("%s %s %s DBHost=%s,used=%d,cached=%d,idle=%d",
 			"backend", "NewConnectionPool", "Idle info",
 			p.dbHost.Addr,
-			p.used,
-			p.connections.Len(),
+			used,
+			cached,
 			idleCount)
 	}
 }
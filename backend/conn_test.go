@@ -0,0 +1,203 @@
+package backend
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/berkaroad/saashard/errors"
+)
+
+// failingConnection is nilConnection failed to connect when fail is set.
+type failingConnection struct {
+	nilConnection
+	fail *int32
+}
+
+func (c *failingConnection) Connect(dbHost *DBHost, db string) error {
+	if atomic.LoadInt32(c.fail) > 0 {
+		return fmt.Errorf("connect refused")
+	}
+	return nil
+}
+
+// newTestPool create pool of nilConnection, failed to connect when fail is set.
+func newTestPool(t *testing.T, maxPoolSize uint32, fail *int32) *ConnectionPool {
+	createConnection := CreateConnection
+	CreateConnection = func(dbHost *DBHost) Connection { return &failingConnection{fail: fail} }
+	t.Cleanup(func() { CreateConnection = createConnection })
+	return NewDBHost("127.0.0.1:3306", "root", "", 1, int(maxPoolSize)).Pool
+}
+
+// takeConnection acquire and connect as GetConnection does, without marking db host up or down.
+func takeConnection(p *ConnectionPool) (Connection, error) {
+	conn, err := p.acquire()
+	if err == nil {
+		conn, err = p.connect(conn, "db1")
+	}
+	return conn, err
+}
+
+// waitForWaiters wait until count of waiting in queue is n.
+func waitForWaiters(t *testing.T, p *ConnectionPool, n int) {
+	for i := 0; p.Stats().Waiting != n; i++ {
+		if i >= 1000 {
+			t.Fatalf("expect %d waiting, got %d", n, p.Stats().Waiting)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestPoolWaiterServed connection returned is given to waiter, instead of cached.
+func TestPoolWaiterServed(t *testing.T) {
+	var fail int32
+	p := newTestPool(t, 1, &fail)
+	p.MaxWaitQueue = 1
+	conn, err := takeConnection(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan Connection, 1)
+	go func() {
+		waited, err := takeConnection(p)
+		if err != nil {
+			t.Error(err)
+		}
+		served <- waited
+	}()
+	waitForWaiters(t, p, 1)
+	p.release(conn)
+	if waited := <-served; waited != conn {
+		t.Fatalf("expect returned connection served to waiter, got %v", waited)
+	}
+	if stats := p.Stats(); stats.Opened != 1 || stats.Used != 1 || stats.Cached != 0 || stats.Waits != 1 {
+		t.Errorf("expect 1 opened and used, none cached, 1 wait, got %+v", stats)
+	}
+}
+
+// TestPoolWaitTimeout waiter gives up after wait timeout, and it's counted.
+func TestPoolWaitTimeout(t *testing.T) {
+	var fail int32
+	p := newTestPool(t, 1, &fail)
+	p.MaxWaitQueue = 1
+	p.WaitTimeout = 10 * time.Millisecond
+	if _, err := takeConnection(p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := takeConnection(p); err != errors.ErrConnWaitTimeout {
+		t.Fatalf("expect wait timeout, got %v", err)
+	}
+	if stats := p.Stats(); stats.WaitTimeouts != 1 || stats.Waiting != 0 || stats.Used != 1 {
+		t.Errorf("expect 1 wait timeout, none waiting, got %+v", stats)
+	}
+}
+
+// TestPoolWaitQueueFull get connection is rejected if wait queue is full.
+func TestPoolWaitQueueFull(t *testing.T) {
+	var fail int32
+	p := newTestPool(t, 1, &fail)
+	p.MaxWaitQueue = 1
+	conn, err := takeConnection(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := takeConnection(p); err != nil {
+			t.Error(err)
+		}
+	}()
+	waitForWaiters(t, p, 1)
+	if _, err = takeConnection(p); err != errors.ErrNoIdleConn {
+		t.Fatalf("expect no idle conn, got %v", err)
+	}
+	if stats := p.Stats(); stats.Rejected != 1 || stats.Waiting != 1 {
+		t.Errorf("expect 1 rejected, 1 waiting, got %+v", stats)
+	}
+	p.release(conn)
+	<-done
+}
+
+// TestPoolFailedConnect slot of connection failed to connect is given to waiter or freed,
+// so connections opened never exceed max pool size.
+func TestPoolFailedConnect(t *testing.T) {
+	var fail int32
+	const maxPoolSize = 2
+	p := newTestPool(t, maxPoolSize, &fail)
+	p.MaxWaitQueue = 100
+	conn, err := takeConnection(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt32(&fail, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := takeConnection(p); err == nil {
+					t.Error("expect failed to connect")
+					return
+				}
+				if opened := p.Stats().Opened; opened > maxPoolSize {
+					t.Errorf("expect opened no more than %d, got %d", maxPoolSize, opened)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if stats := p.Stats(); stats.Opened != 1 || stats.Used != 1 || stats.Waiting != 0 {
+		t.Errorf("expect only connection taken opened and used, got %+v", stats)
+	}
+
+	atomic.StoreInt32(&fail, 0)
+	p.release(conn)
+	for i := 0; i < maxPoolSize; i++ {
+		if _, err = takeConnection(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := p.Stats(); stats.Opened != maxPoolSize || stats.Used != maxPoolSize {
+		t.Errorf("expect pool full after connected again, got %+v", stats)
+	}
+}
+
+// benchmarkPool take and give back connections concurrently, without logging of GetConnection and ReturnConnection.
+func benchmarkPool(b *testing.B, maxPoolSize uint32) {
+	p := NewDBHost("127.0.0.1:3306", "root", "", 1, int(maxPoolSize)).Pool
+	p.MaxWaitQueue = 1 << 20
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			conn, err := p.acquire()
+			if err == nil {
+				conn, err = p.connect(conn, "db1")
+			}
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			p.release(conn)
+		}
+	})
+}
+
+// BenchmarkConnectionPool pool is large enough, connections are taken from cache.
+func BenchmarkConnectionPool(b *testing.B) {
+	benchmarkPool(b, 1024)
+}
+
+// BenchmarkConnectionPoolWaiting pool is smaller than goroutines, half of them wait in queue.
+func BenchmarkConnectionPoolWaiting(b *testing.B) {
+	maxPoolSize := runtime.GOMAXPROCS(0) / 2
+	if maxPoolSize == 0 {
+		maxPoolSize = 1
+	}
+	benchmarkPool(b, uint32(maxPoolSize))
+}
@@ -0,0 +1,164 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package backend
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GTIDSet is transactions executed, intervals of sequence numbers by source uuid, such as 'uuid:1-5:11-18,uuid2:1-27'.
+type GTIDSet map[string][]GTIDInterval
+
+// GTIDInterval is sequence numbers from Start to End, inclusive.
+type GTIDInterval struct {
+	Start int64
+	End   int64
+}
+
+// ParseGTIDSet parse @@gtid_executed, empty if gtid is disabled.
+func ParseGTIDSet(str string) (GTIDSet, error) {
+	set := make(GTIDSet)
+	for _, item := range strings.Split(str, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid gtid set '%s'", item)
+		}
+		uuid := strings.ToLower(strings.TrimSpace(parts[0]))
+		for _, part := range parts[1:] {
+			var interval GTIDInterval
+			var err error
+			bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+			if interval.Start, err = strconv.ParseInt(bounds[0], 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid gtid set '%s'", item)
+			}
+			interval.End = interval.Start
+			if len(bounds) == 2 {
+				if interval.End, err = strconv.ParseInt(bounds[1], 10, 64); err != nil || interval.End < interval.Start {
+					return nil, fmt.Errorf("invalid gtid set '%s'", item)
+				}
+			}
+			set[uuid] = append(set[uuid], interval)
+		}
+	}
+	for uuid, intervals := range set {
+		set[uuid] = mergeGTIDIntervals(intervals)
+	}
+	return set, nil
+}
+
+// mergeGTIDIntervals sort intervals and merge overlapped or adjacent ones.
+func mergeGTIDIntervals(intervals []GTIDInterval) []GTIDInterval {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].Start < intervals[j].Start })
+	merged := make([]GTIDInterval, 0, len(intervals))
+	for _, interval := range intervals {
+		if last := len(merged) - 1; last >= 0 && interval.Start <= merged[last].End+1 {
+			if interval.End > merged[last].End {
+				merged[last].End = interval.End
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+// Union get transactions in either set.
+func (s GTIDSet) Union(other GTIDSet) GTIDSet {
+	set := make(GTIDSet, len(s))
+	for uuid, intervals := range s {
+		set[uuid] = append([]GTIDInterval(nil), intervals...)
+	}
+	for uuid, intervals := range other {
+		set[uuid] = mergeGTIDIntervals(append(set[uuid], intervals...))
+	}
+	return set
+}
+
+// Subtract get transactions in s but not in other.
+func (s GTIDSet) Subtract(other GTIDSet) GTIDSet {
+	set := make(GTIDSet)
+	for uuid, intervals := range s {
+		var rest []GTIDInterval
+		for _, interval := range intervals {
+			for _, sub := range other[uuid] {
+				if sub.End < interval.Start || sub.Start > interval.End {
+					continue
+				}
+				if sub.Start > interval.Start {
+					rest = append(rest, GTIDInterval{interval.Start, sub.Start - 1})
+				}
+				interval.Start = sub.End + 1
+				if interval.Start > interval.End {
+					break
+				}
+			}
+			if interval.Start <= interval.End {
+				rest = append(rest, interval)
+			}
+		}
+		if len(rest) > 0 {
+			set[uuid] = rest
+		}
+	}
+	return set
+}
+
+// Count of transactions in set.
+func (s GTIDSet) Count() int64 {
+	var count int64
+	for _, intervals := range s {
+		for _, interval := range intervals {
+			count += interval.End - interval.Start + 1
+		}
+	}
+	return count
+}
+
+// String format as @@gtid_executed, sorted by uuid.
+func (s GTIDSet) String() string {
+	uuids := make([]string, 0, len(s))
+	for uuid := range s {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	items := make([]string, 0, len(uuids))
+	for _, uuid := range uuids {
+		item := uuid
+		for _, interval := range s[uuid] {
+			if interval.Start == interval.End {
+				item += ":" + strconv.FormatInt(interval.Start, 10)
+			} else {
+				item += ":" + strconv.FormatInt(interval.Start, 10) + "-" + strconv.FormatInt(interval.End, 10)
+			}
+		}
+		items = append(items, item)
+	}
+	return strings.Join(items, ",")
+}
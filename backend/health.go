@@ -0,0 +1,381 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package backend
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/utils/alert"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// DefaultPingInterval is seconds between health checks of host.
+const DefaultPingInterval = 10
+
+// DefaultFailureThreshold is count of failed pings in a row to mark db host down.
+const DefaultFailureThreshold = 3
+
+// Promoter is connection able to promote its mysql server to master, or demote it.
+type Promoter interface {
+	// Promote stop replication and make mysql server writable.
+	Promote() error
+	// Demote make mysql server read-only.
+	Demote() error
+}
+
+// GetPingInterval get interval between health checks.
+func (h *DataHost) GetPingInterval() time.Duration {
+	if h.PingInterval <= 0 {
+		return DefaultPingInterval * time.Second
+	}
+	return time.Duration(h.PingInterval) * time.Second
+}
+
+// CheckHealth ping master and slaves, mark them down if failed pings reach threshold, or up once ping is ok.
+// Master follows topology file if set, or candidate is promoted if master is down more than down_after_noalive seconds,
+// and failed back to master configured by failback policy once it recovers.
+func (h *DataHost) CheckHealth() {
+	timeout := h.GetPingInterval()
+	master := h.GetMaster()
+	var wg sync.WaitGroup
+	check := func(dbHost *DBHost) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.checkDBHost(dbHost, timeout)
+		}()
+	}
+	check(master)
+	for _, slave := range h.Slaves {
+		if slave != master {
+			check(slave)
+		}
+	}
+	for _, delayed := range h.DelayedSlaves {
+		check(delayed)
+	}
+	for _, roleSlaves := range h.RoleSlaves {
+		for _, roleSlave := range roleSlaves {
+			check(roleSlave)
+		}
+	}
+	// master configured is still checked after failed over, to fail back.
+	if h.origin != master && h.getSlaveByAddr(h.origin.Addr) == nil {
+		check(h.origin)
+	}
+	wg.Wait()
+
+	if len(h.cfg.Failover.TopologyFile) > 0 {
+		h.followTopology(master)
+	} else if candidate := h.cfg.Failover.Candidate; len(candidate) > 0 && candidate != master.Addr && h.shouldFailover(master) {
+		h.promoteCandidate(master)
+	} else if h.shouldFailback(master) {
+		if err := h.Failback(); err != nil {
+			simplelog.Error("%s %s %s host=%s,master=%s,origin=%s", "backend", "CheckHealth", err.Error(),
+				h.Name, master.Addr, h.origin.Addr)
+		}
+	}
+}
+
+// checkDBHost ping db host, connections cached are closed once it's marked down.
+// Replication status is also checked if failover candidate is set, or master is failed over to check failback.
+func (h *DataHost) checkDBHost(dbHost *DBHost, timeout time.Duration) {
+	var onPing func(conn Connection)
+	if len(h.cfg.Failover.Candidate) > 0 || h.GetMaster() != h.origin {
+		onPing = func(conn Connection) { h.checkReplication(dbHost, conn) }
+	}
+	err := ping(dbHost, timeout, onPing)
+	if err == nil {
+		atomic.StoreInt32(&dbHost.failures, 0)
+		dbHost.markUp()
+		return
+	}
+	failures := atomic.AddInt32(&dbHost.failures, 1)
+	simplelog.Warn("%s %s %s host=%s,addr=%s,failures=%d", "backend", "checkDBHost", err.Error(), h.Name, dbHost.Addr, failures)
+	if int(failures) >= h.FailureThreshold {
+		dbHost.markDown(err)
+		dbHost.Pool.Drain()
+	}
+}
+
+// ping connect to db host and ping it by a new connection, failed if not done in timeout.
+// onPing is called with the connection if ping is ok, optional.
+func ping(dbHost *DBHost, timeout time.Duration, onPing func(conn Connection)) error {
+	done := make(chan error, 1)
+	go func() {
+		conn := CreateConnection(dbHost)
+		defer conn.Close()
+		err := conn.Connect(dbHost, "")
+		if err == nil {
+			err = conn.Ping()
+		}
+		done <- err
+		if err == nil && onPing != nil {
+			onPing(conn)
+		}
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return errors.ErrPingTimeout
+	}
+}
+
+// shouldFailover master is marked down by health check, and down more than down_after_noalive seconds.
+func (h *DataHost) shouldFailover(master *DBHost) bool {
+	if !master.IsDown() || int(master.Failures()) < h.FailureThreshold {
+		return false
+	}
+	return time.Since(master.DownSince()) >= time.Duration(h.DownAfterNoAlive)*time.Second
+}
+
+// shouldFailback master is failed over, and master configured is up as long as failback policy required.
+func (h *DataHost) shouldFailback(master *DBHost) bool {
+	if master == h.origin || h.origin.IsDown() || h.origin.Failures() > 0 {
+		return false
+	}
+	switch h.cfg.Failover.GetFailback() {
+	case config.FailbackImmediate:
+		return true
+	case config.FailbackHealthy:
+		return time.Since(h.origin.UpSince()) >= time.Duration(h.cfg.Failover.FailbackAfter)*time.Minute
+	}
+	return false
+}
+
+// Failback switch master back to master configured if it's up and has caught up with current master.
+// Master configured must replicate from current master, then current master is made read-only, and failback is
+// refused unless master configured has executed all transactions known in failback_catch_up seconds.
+// Writes accepted by current master since failover would be lost otherwise.
+func (h *DataHost) Failback() error {
+	h.switchLock.Lock()
+	defer h.switchLock.Unlock()
+	master := h.GetMaster()
+	if master == h.origin {
+		return fmt.Errorf("master of host '%s' is not failed over", h.Name)
+	}
+	if h.origin.IsDown() {
+		return errors.ErrMasterDown
+	}
+	if status := h.origin.Replication(); status == nil || status.Source != master.Addr || !status.Replicating {
+		return fmt.Errorf("master configured '%s' of host '%s' doesn't replicate from current master '%s'",
+			h.origin.Addr, h.Name, master.Addr)
+	}
+	if err := execPromoter(master, Promoter.Demote); err != nil {
+		return err
+	}
+	if report := h.waitCaughtUp(master, h.origin); report.Unknown || report.Missing.Count() > 0 {
+		// writable again, as it's still master.
+		if err := execPromoter(master, Promoter.Promote); err != nil {
+			simplelog.Error("%s %s %s host=%s,master=%s", "backend", "Failback", err.Error(), h.Name, master.Addr)
+		}
+		return fmt.Errorf("master configured '%s' of host '%s' has not caught up with current master '%s', missing '%s'",
+			h.origin.Addr, h.Name, master.Addr, report.Missing.String())
+	}
+	if err := execPromoter(h.origin, Promoter.Promote); err != nil {
+		if err := execPromoter(master, Promoter.Promote); err != nil {
+			simplelog.Error("%s %s %s host=%s,master=%s", "backend", "Failback", err.Error(), h.Name, master.Addr)
+		}
+		return err
+	}
+	h.switchMaster(master, h.origin, "failback", nil)
+	return nil
+}
+
+// waitCaughtUp wait until slave has executed all transactions known of master read-only, or timed out.
+func (h *DataHost) waitCaughtUp(master, slave *DBHost) *FailoverReport {
+	deadline := time.Now().Add(time.Duration(h.cfg.Failover.GetFailbackCatchUp()) * time.Second)
+	for {
+		errMaster := refreshReplication(master)
+		errSlave := refreshReplication(slave)
+		report := h.assessFailover(master, slave)
+		if errMaster != nil || errSlave != nil {
+			report.Unknown = true
+		}
+		if !report.Unknown && report.Missing.Count() == 0 || time.Now().After(deadline) {
+			return report
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// execPromoter promote or demote mysql server of db host.
+func execPromoter(dbHost *DBHost, exec func(Promoter) error) error {
+	conn, err := dbHost.GetConnection("")
+	if err != nil {
+		return err
+	}
+	defer conn.ReturnConnection()
+	if promoter, ok := conn.(Promoter); ok {
+		return exec(promoter)
+	}
+	return nil
+}
+
+// promoteCandidate promote candidate slave to master, if it's up and not master yet.
+// It's refused if data loss is possible and require_no_data_loss is set.
+func (h *DataHost) promoteCandidate(master *DBHost) {
+	h.switchLock.Lock()
+	defer h.switchLock.Unlock()
+	if master != h.GetMaster() {
+		return
+	}
+	candidate := h.chooseCandidate(master)
+	if candidate == nil || candidate.IsDown() {
+		simplelog.Error("%s %s %s host=%s,master=%s,candidate=%s", "backend", "promoteCandidate", "Candidate is down",
+			h.Name, master.Addr, h.cfg.Failover.Candidate)
+		return
+	}
+	report := h.assessFailover(master, candidate)
+	if report.PossibleDataLoss() && h.cfg.Failover.RequireNoDataLoss {
+		if h.failoverRefused != master {
+			h.failoverRefused = master
+			simplelog.Error("%s %s %s host=%s,master=%s,candidate=%s,missing=%s", "backend", "promoteCandidate",
+				"Failover refused for possible data loss", h.Name, master.Addr, candidate.Addr, report.Missing.String())
+			details := report.details()
+			details["from"] = master.Addr
+			details["to"] = candidate.Addr
+			alert.Emit(alert.EventFailover, alert.SeverityCritical, h.Name,
+				fmt.Sprintf("failover of host %s from %s to %s is refused for possible data loss, see 'FAILOVER HOST' in admin",
+					h.Name, master.Addr, candidate.Addr), details)
+		}
+		return
+	}
+	if err := execPromoter(candidate, Promoter.Promote); err != nil {
+		simplelog.Error("%s %s %s host=%s,master=%s,candidate=%s", "backend", "promoteCandidate", err.Error(),
+			h.Name, master.Addr, candidate.Addr)
+		return
+	}
+	h.switchMaster(master, candidate, "promoted", report)
+}
+
+// Failover promote slave to master by admin, even if data loss is possible, master must be down.
+// Candidate is chosen as configured if addr is empty.
+func (h *DataHost) Failover(addr string) (*FailoverReport, error) {
+	h.switchLock.Lock()
+	defer h.switchLock.Unlock()
+	master := h.GetMaster()
+	if !master.IsDown() {
+		return nil, fmt.Errorf("master '%s' of host '%s' is not down", master.Addr, h.Name)
+	}
+	var candidate *DBHost
+	if len(addr) > 0 {
+		if candidate = h.getSlaveByAddr(addr); candidate == nil {
+			return nil, fmt.Errorf("slave '%s' of host '%s' not exists", addr, h.Name)
+		}
+	} else if len(h.cfg.Failover.Candidate) > 0 {
+		candidate = h.chooseCandidate(master)
+	}
+	if candidate == nil || candidate == master || candidate.IsDown() {
+		return nil, fmt.Errorf("no candidate of host '%s' is up", h.Name)
+	}
+	report := h.assessFailover(master, candidate)
+	if err := execPromoter(candidate, Promoter.Promote); err != nil {
+		return nil, err
+	}
+	h.switchMaster(master, candidate, "manual", report)
+	return report, nil
+}
+
+// followTopology switch master to address in topology file, if it's changed by external tool.
+func (h *DataHost) followTopology(master *DBHost) {
+	data, err := ioutil.ReadFile(h.cfg.Failover.TopologyFile)
+	if err != nil {
+		simplelog.Error("%s %s %s host=%s,file=%s", "backend", "followTopology", err.Error(), h.Name, h.cfg.Failover.TopologyFile)
+		return
+	}
+	addr := strings.TrimSpace(string(data))
+	h.switchLock.Lock()
+	defer h.switchLock.Unlock()
+	if len(addr) == 0 || addr == master.Addr || master != h.GetMaster() {
+		return
+	}
+	to := h.getSlaveByAddr(addr)
+	if addr == h.origin.Addr {
+		to = h.origin
+	}
+	if to == nil {
+		if to, err = h.newDBHost(addr, 0); err != nil {
+			simplelog.Error("%s %s %s host=%s,addr=%s", "backend", "followTopology", err.Error(), h.Name, addr)
+			return
+		}
+	}
+	h.switchMaster(master, to, "topology", nil)
+}
+
+// switchMaster switch master of host, connections cached of old master are closed. Must hold switch lock.
+// Possible data loss is reported in failover event, if candidate is promoted.
+func (h *DataHost) switchMaster(from, to *DBHost, reason string, report *FailoverReport) {
+	h.master.Store(to)
+	h.failoverRefused = nil
+	from.Pool.Drain()
+	details := map[string]string{}
+	summary := fmt.Sprintf("master of host %s switched from %s to %s by %s", h.Name, from.Addr, to.Addr, reason)
+	if report != nil {
+		details = report.details()
+		if report.PossibleDataLoss() {
+			summary += fmt.Sprintf(", possible data loss: master semi-sync %t, %d transactions missing",
+				report.MasterSemiSync, report.Missing.Count())
+		}
+	}
+	details["from"] = from.Addr
+	details["to"] = to.Addr
+	details["reason"] = reason
+	simplelog.Warn("%s %s %s host=%s,from=%s,to=%s,reason=%s,possible_data_loss=%s,missing=%s", "backend", "switchMaster",
+		"Master switched", h.Name, from.Addr, to.Addr, reason, details["possible_data_loss"], details["missing_gtid_set"])
+	alert.Emit(alert.EventFailover, alert.SeverityCritical, h.Name, summary, details)
+}
+
+// GetOrigin get master configured, it's different from master if failed over.
+func (h *DataHost) GetOrigin() *DBHost {
+	return h.origin
+}
+
+// Failures get count of failed pings in a row.
+func (h *DBHost) Failures() int32 {
+	return atomic.LoadInt32(&h.failures)
+}
+
+// UpSince get time when marked up after down, zero if never down.
+func (h *DBHost) UpSince() time.Time {
+	if upSince := atomic.LoadInt64(&h.upSince); upSince > 0 {
+		return time.Unix(0, upSince)
+	}
+	return time.Time{}
+}
+
+// DownSince get time when marked down, zero if never.
+func (h *DBHost) DownSince() time.Time {
+	if downSince := atomic.LoadInt64(&h.downSince); downSince > 0 {
+		return time.Unix(0, downSince)
+	}
+	return time.Time{}
+}
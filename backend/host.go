@@ -24,11 +24,27 @@ package backend
 
 import (
 	"container/ring"
+	"crypto/tls"
+	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/berkaroad/saashard/config"
 	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/utils/alert"
+)
+
+// Defaults of connection pool.
+const (
+	// DefaultConnIdleTimeout is seconds of connection cached before closed.
+	DefaultConnIdleTimeout = 600
+	// DefaultConnWaitTimeout is ms of waiting for connection when pool is full.
+	DefaultConnWaitTimeout = 3000
+	// ReapInterval is interval of closing idle connections.
+	ReapInterval = 10 * time.Second
 )
 
 // DataHost is data host.
@@ -37,78 +53,420 @@ type DataHost struct {
 	MaxConnNum         int
 	DownAfterNoAlive   int
 	PingInterval       int
-	Master             *DBHost
 	Slaves             []*DBHost
 	slavePolling       *ring.Ring
 	slavePollingLength int
+	// Balance is policy to choose slave for reads.
+	Balance string
+
+	// master is *DBHost, switched by failover.
+	master atomic.Value
+	// FailureThreshold is count of failed pings in a row to mark db host down.
+	FailureThreshold int
+	// cfg of host, to fail over and create db host of master followed.
+	cfg config.HostConfig
+	// origin is master configured, failed back to it after recovered.
+	origin *DBHost
+	// switchLock is held when master is switched.
+	switchLock sync.Mutex
+	// slaveLock is held when choosing slave and changing weights.
+	slaveLock sync.Mutex
+	// failoverRefused is master down whose failover is refused for possible data loss, alerted once.
+	failoverRefused *DBHost
+
+	// DelayedSlaves are delayed intentionally, excluded from reads and failover.
+	DelayedSlaves []*DBHost
+	// RoleSlaves are slaves by role, serving only reads of the role.
+	RoleSlaves map[string][]*DBHost
 }
 
-// NewDataHost new host.
-func NewDataHost(hostCfg config.HostConfig) *DataHost {
+// NewDataHost new host, error if failed to load certificates of TLS.
+func NewDataHost(hostCfg config.HostConfig) (*DataHost, error) {
 	h := new(DataHost)
 	h.Name = hostCfg.Name
 	h.MaxConnNum = hostCfg.MaxConnNum
 	h.DownAfterNoAlive = hostCfg.DownAfterNoAlive
 	h.PingInterval = hostCfg.PingInterval
-	h.Master = NewDBHost(hostCfg.Master, hostCfg.User, hostCfg.Password, 0, h.MaxConnNum)
+	h.Balance = hostCfg.GetBalance()
+	h.FailureThreshold = hostCfg.FailureThreshold
+	if h.FailureThreshold <= 0 {
+		h.FailureThreshold = DefaultFailureThreshold
+	}
+	h.cfg = hostCfg
+	master, err := h.newDBHost(hostCfg.Master, 0)
+	if err != nil {
+		return nil, err
+	}
+	h.master.Store(master)
+	h.origin = master
 
 	if len(hostCfg.Slaves) > 0 {
 		h.Slaves = make([]*DBHost, len(hostCfg.Slaves))
-
-		minWeight := 0
-		maxWeight := 0
-		totalWeight := 0
 		for i, slave := range hostCfg.Slaves {
 			slaveConfig := strings.Split(slave, "@")
 			var slaveWeight int
 			if len(slaveConfig) > 1 {
 				slaveWeight, _ = strconv.Atoi(slaveConfig[1])
-				if minWeight <= 0 || slaveWeight < minWeight {
-					minWeight = slaveWeight
-				}
-				if slaveWeight > maxWeight {
-					maxWeight = slaveWeight
-				}
-				totalWeight += slaveWeight
 			}
-			h.Slaves[i] = NewDBHost(slaveConfig[0], hostCfg.User, hostCfg.Password, slaveWeight, h.MaxConnNum)
-		}
-		adjustWeight := 1 - minWeight // the min weight must 1.
-		minWeight = 1
-		maxWeight = maxWeight + adjustWeight
-
-		if len(hostCfg.Slaves) > 1 {
-			h.slavePollingLength = totalWeight + len(hostCfg.Slaves)*adjustWeight
-			h.slavePolling = ring.New(h.slavePollingLength)
-
-			for currentWeight := minWeight; currentWeight <= maxWeight; currentWeight++ {
-				for _, slave := range h.Slaves {
-					if slave.Weight+adjustWeight >= currentWeight {
-						h.slavePolling.Value = slave
-						h.slavePolling = h.slavePolling.Next()
-					}
-				}
+			if h.Slaves[i], err = h.newDBHost(slaveConfig[0], slaveWeight); err != nil {
+				return nil, err
 			}
-			h.slavePolling = h.slavePolling.Next()
+		}
+		h.buildSlavePolling()
+	}
+	for _, addr := range hostCfg.DelayedSlaves {
+		if addr == hostCfg.Master || h.getSlaveByAddr(addr) != nil {
+			return nil, fmt.Errorf("delayed slave '%s' of host '%s' is master or slave", addr, h.Name)
+		}
+		delayed, err := h.newDBHost(addr, 0)
+		if err != nil {
+			return nil, err
+		}
+		delayed.delayed = true
+		h.DelayedSlaves = append(h.DelayedSlaves, delayed)
+	}
+	for role, addrs := range hostCfg.SlaveRoles {
+		if h.RoleSlaves == nil {
+			h.RoleSlaves = make(map[string][]*DBHost)
+		}
+		for _, addr := range addrs {
+			if addr == hostCfg.Master || h.getSlaveByAddr(addr) != nil || h.getRoleSlaveByAddr(addr) != nil {
+				return nil, fmt.Errorf("slave '%s' of role '%s' of host '%s' is master or other slave", addr, role, h.Name)
+			}
+			roleSlave, err := h.newDBHost(addr, 0)
+			if err != nil {
+				return nil, err
+			}
+			roleSlave.role = role
+			h.RoleSlaves[role] = append(h.RoleSlaves[role], roleSlave)
 		}
 	}
 
-	return h
+	if candidate := hostCfg.Failover.Candidate; len(candidate) > 0 && candidate != config.FailoverCandidateAuto &&
+		h.getSlaveByAddr(candidate) == nil {
+		return nil, fmt.Errorf("failover candidate '%s' of host '%s' is not a slave", candidate, h.Name)
+	}
+
+	return h, nil
+}
+
+// newDBHost new db host of mysql server in host, error if failed to load certificates of TLS.
+func (h *DataHost) newDBHost(addr string, weight int) (*DBHost, error) {
+	dbHost := NewDBHost(addr, h.cfg.User, h.cfg.Password, weight, h.MaxConnNum)
+	dbHost.Limiter = newQueryLimiter(h.cfg)
+	configurePool(dbHost.Pool, h.cfg)
+	dbHost.Compress = h.cfg.Compress
+	if err := dbHost.setTLS(h.cfg.TLS); err != nil {
+		return nil, err
+	}
+	return dbHost, nil
+}
+
+// buildSlavePolling build ring of slaves polled by weight, must hold slave lock if serving.
+func (h *DataHost) buildSlavePolling() {
+	if len(h.Slaves) <= 1 {
+		return
+	}
+	minWeight := 0
+	maxWeight := 0
+	totalWeight := 0
+	for _, slave := range h.Slaves {
+		if slave.Weight > 0 {
+			if minWeight <= 0 || slave.Weight < minWeight {
+				minWeight = slave.Weight
+			}
+			if slave.Weight > maxWeight {
+				maxWeight = slave.Weight
+			}
+			totalWeight += slave.Weight
+		}
+	}
+	adjustWeight := 1 - minWeight // the min weight must 1.
+	minWeight = 1
+	maxWeight = maxWeight + adjustWeight
+
+	h.slavePollingLength = totalWeight + len(h.Slaves)*adjustWeight
+	h.slavePolling = ring.New(h.slavePollingLength)
+	for currentWeight := minWeight; currentWeight <= maxWeight; currentWeight++ {
+		for _, slave := range h.Slaves {
+			if slave.Weight+adjustWeight >= currentWeight {
+				h.slavePolling.Value = slave
+				h.slavePolling = h.slavePolling.Next()
+			}
+		}
+	}
+	h.slavePolling = h.slavePolling.Next()
+}
+
+// SetSlaveWeight change read weight of slave.
+func (h *DataHost) SetSlaveWeight(addr string, weight int) error {
+	slave := h.getSlaveByAddr(addr)
+	if slave == nil {
+		return fmt.Errorf("slave '%s' of host '%s' not exists", addr, h.Name)
+	}
+	h.slaveLock.Lock()
+	defer h.slaveLock.Unlock()
+	slave.Weight = weight
+	h.buildSlavePolling()
+	return nil
+}
+
+// SetSlaveOffline take slave offline or online, slave offline is excluded from reads.
+func (h *DataHost) SetSlaveOffline(addr string, offline bool) error {
+	slave := h.getSlaveByAddr(addr)
+	if slave == nil {
+		slave = h.getRoleSlaveByAddr(addr)
+	}
+	if slave == nil {
+		return fmt.Errorf("slave '%s' of host '%s' not exists", addr, h.Name)
+	}
+	var value int32
+	if offline {
+		value = 1
+	}
+	atomic.StoreInt32(&slave.offline, value)
+	return nil
+}
+
+// IsDelayed is delayed slave for disaster recovery.
+func (h *DBHost) IsDelayed() bool {
+	return h.delayed
+}
+
+// Role of slave serving only reads of the role, empty if not tagged.
+func (h *DBHost) Role() string {
+	return h.role
+}
+
+// GetMaster get master, it's switched if failed over.
+func (h *DataHost) GetMaster() *DBHost {
+	return h.master.Load().(*DBHost)
+}
+
+// getSlaveByAddr get slave by address, nil if not exists.
+func (h *DataHost) getSlaveByAddr(addr string) *DBHost {
+	for _, slave := range h.Slaves {
+		if slave.Addr == addr {
+			return slave
+		}
+	}
+	return nil
+}
+
+func newQueryLimiter(hostCfg config.HostConfig) *QueryLimiter {
+	return NewQueryLimiter(hostCfg.MaxConcurrentQueries, hostCfg.MaxQueueSize,
+		time.Duration(hostCfg.QueueTimeout)*time.Millisecond)
+}
+
+// configurePool set options of pool by config of host.
+func configurePool(p *ConnectionPool, hostCfg config.HostConfig) {
+	if hostCfg.MaxIdleConns > 0 && uint32(hostCfg.MaxIdleConns) < p.MaxPoolSize {
+		p.MaxIdle = uint32(hostCfg.MaxIdleConns)
+	}
+	if hostCfg.MinIdleConns > 0 {
+		p.MinIdle = uint32(hostCfg.MinIdleConns)
+	}
+	p.MaxLifetime = time.Duration(hostCfg.ConnMaxLifetime) * time.Second
+	p.IdleTimeout = DefaultConnIdleTimeout * time.Second
+	if hostCfg.ConnIdleTimeout > 0 {
+		p.IdleTimeout = time.Duration(hostCfg.ConnIdleTimeout) * time.Second
+	}
+	p.MaxWaitQueue = hostCfg.MaxConnNum
+	if hostCfg.ConnWaitQueueSize > 0 {
+		p.MaxWaitQueue = hostCfg.ConnWaitQueueSize
+	}
+	p.WaitTimeout = DefaultConnWaitTimeout * time.Millisecond
+	if hostCfg.ConnWaitTimeout > 0 {
+		p.WaitTimeout = time.Duration(hostCfg.ConnWaitTimeout) * time.Millisecond
+	}
+}
+
+// ReapIdleConns close idle connections of master and slaves.
+func (h *DataHost) ReapIdleConns() {
+	for _, dbHost := range h.dbHosts() {
+		dbHost.Pool.ReapIdle()
+	}
+}
+
+// Config of host created by.
+func (h *DataHost) Config() config.HostConfig {
+	return h.cfg
+}
+
+// Drain close connections cached of master and slaves, such as host is removed by reload.
+func (h *DataHost) Drain() {
+	for _, dbHost := range h.dbHosts() {
+		dbHost.Pool.Drain()
+	}
+}
+
+// InUse is true if any connection of master or slaves is in use.
+func (h *DataHost) InUse() bool {
+	for _, dbHost := range h.dbHosts() {
+		if dbHost.Pool.GetUsedCount() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dbHosts get master, origin master if failed over, and slaves of all kinds.
+func (h *DataHost) dbHosts() []*DBHost {
+	dbHosts := []*DBHost{h.GetMaster()}
+	if h.origin != dbHosts[0] {
+		dbHosts = append(dbHosts, h.origin)
+	}
+	dbHosts = append(dbHosts, h.Slaves...)
+	dbHosts = append(dbHosts, h.DelayedSlaves...)
+	for _, roleSlaves := range h.RoleSlaves {
+		dbHosts = append(dbHosts, roleSlaves...)
+	}
+	return dbHosts
+}
+
+// HasRoleSlave host has slave of role online, reads of role are on default slaves if not.
+func (h *DataHost) HasRoleSlave(role string) bool {
+	for _, slave := range h.RoleSlaves[role] {
+		if !slave.IsOffline() {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRoleSlave get slave of role online with least connections in use, slaves down are skipped if any is up.
+func (h *DataHost) GetRoleSlave(role string) (*DBHost, error) {
+	var chosen *DBHost
+	for _, slave := range h.RoleSlaves[role] {
+		if slave.IsOffline() {
+			continue
+		}
+		if chosen == nil || !slave.IsDown() && chosen.IsDown() ||
+			slave.IsDown() == chosen.IsDown() && slave.Pool.Stats().Used < chosen.Pool.Stats().Used {
+			chosen = slave
+		}
+	}
+	if chosen == nil {
+		return nil, errors.ErrNoSlaveDB
+	}
+	return chosen, nil
+}
+
+// getRoleSlaveByAddr get slave of any role by address.
+func (h *DataHost) getRoleSlaveByAddr(addr string) *DBHost {
+	for _, roleSlaves := range h.RoleSlaves {
+		for _, roleSlave := range roleSlaves {
+			if roleSlave.Addr == addr {
+				return roleSlave
+			}
+		}
+	}
+	return nil
+}
+
+// GetDelayedSlave get delayed slave for data rescue, the first up one is preferred.
+func (h *DataHost) GetDelayedSlave() (*DBHost, error) {
+	if len(h.DelayedSlaves) == 0 {
+		return nil, errors.ErrNoSlaveDB
+	}
+	for _, delayed := range h.DelayedSlaves {
+		if !delayed.IsDown() {
+			return delayed, nil
+		}
+	}
+	return h.DelayedSlaves[0], nil
 }
 
 // GetSlave get slave by balance algorithm
 func (h *DataHost) GetSlave() (*DBHost, error) {
-	if len(h.Slaves) == 0 {
+	if !h.HasOnlineSlave() {
 		return nil, errors.ErrNoSlaveDB
 	}
 	if len(h.Slaves) == 1 {
 		return h.Slaves[0], nil
 	}
+	h.slaveLock.Lock()
+	defer h.slaveLock.Unlock()
+	switch h.Balance {
+	case config.BalanceLeastConn:
+		return h.leastConnSlave(), nil
+	case config.BalanceLatency:
+		return h.lowestLatencySlave(), nil
+	}
+	// slaves down or offline are skipped if any is available, slaves offline are never chosen.
 	slave := h.slavePolling.Value.(*DBHost)
+	for i := 0; i < h.slavePollingLength && !slave.isAvailable(); i++ {
+		h.slavePolling = h.slavePolling.Next()
+		if next := h.slavePolling.Value.(*DBHost); next.isAvailable() {
+			slave = next
+		}
+	}
 	h.slavePolling = h.slavePolling.Next()
+	if slave.IsOffline() {
+		return h.leastConnSlave(), nil
+	}
 	return slave, nil
 }
 
+// HasOnlineSlave any slave is not taken offline, reads are on master if no slave is online.
+func (h *DataHost) HasOnlineSlave() bool {
+	for _, slave := range h.Slaves {
+		if !slave.IsOffline() {
+			return true
+		}
+	}
+	return false
+}
+
+// leastConnSlave choose slave with least connections in use by weight, slaves down are skipped if any is up.
+func (h *DataHost) leastConnSlave() *DBHost {
+	var chosen *DBHost
+	var chosenUsed, chosenWeight uint64
+	for _, slave := range h.availableSlaves() {
+		used, weight := uint64(slave.Pool.GetUsedCount()), uint64(1)
+		if slave.Weight > 1 {
+			weight = uint64(slave.Weight)
+		}
+		// used / weight < chosenUsed / chosenWeight
+		if chosen == nil || used*chosenWeight < chosenUsed*weight {
+			chosen, chosenUsed, chosenWeight = slave, used, weight
+		}
+	}
+	return chosen
+}
+
+// lowestLatencySlave choose slave with lowest latency of queries, slave without latency is tried first.
+func (h *DataHost) lowestLatencySlave() *DBHost {
+	var chosen *DBHost
+	var chosenLatency time.Duration
+	for _, slave := range h.availableSlaves() {
+		latency := slave.Latency()
+		if chosen == nil || latency < chosenLatency {
+			chosen, chosenLatency = slave, latency
+		}
+	}
+	return chosen
+}
+
+// availableSlaves get slaves up and online, or slaves online if all are down.
+func (h *DataHost) availableSlaves() []*DBHost {
+	slaves := make([]*DBHost, 0, len(h.Slaves))
+	online := make([]*DBHost, 0, len(h.Slaves))
+	for _, slave := range h.Slaves {
+		if slave.IsOffline() {
+			continue
+		}
+		online = append(online, slave)
+		if !slave.IsDown() {
+			slaves = append(slaves, slave)
+		}
+	}
+	if len(slaves) == 0 {
+		return online
+	}
+	return slaves
+}
+
 // DBHost db host.
 type DBHost struct {
 	Addr     string
@@ -116,6 +474,34 @@ type DBHost struct {
 	Password string
 	Weight   int
 	Pool     *ConnectionPool
+	Limiter  *QueryLimiter
+
+	// TLSConfig of connections, nil if TLS disabled.
+	TLSConfig *tls.Config
+	// TLSRequired fail to connect if TLS is not supported by mysql server.
+	TLSRequired bool
+	// Compress connections by compressed protocol, if it's supported by mysql server.
+	Compress bool
+
+	down int32 // 1 if failed to connect, alert once when it's changed.
+	// downSince is unix nano when marked down, upSince is when marked up after down.
+	downSince int64
+	upSince   int64
+	// failures is count of failed pings in a row.
+	failures int32
+	// offline is 1 if taken offline by admin, excluded from reads.
+	offline int32
+	// max_allowed_packet of server, 0 if not known yet.
+	maxAllowedPacket int64
+	// latency is moving average of queries in nanoseconds, 0 if not queried yet.
+	latency int64
+
+	// replication is *ReplicationStatus at last health check, if failover candidate is set.
+	replication atomic.Value
+	// delayed slave, excluded from reads and failover.
+	delayed bool
+	// role of slave serving only reads of the role, empty if not tagged.
+	role string
 }
 
 // NewDBHost new db host.
@@ -129,6 +515,15 @@ func NewDBHost(addr string, user, password string, weight int, maxConnNum int) *
 	return h
 }
 
+// setTLS set TLS of connections by config of host.
+func (h *DBHost) setTLS(tlsCfg config.BackendTLSConfig) (err error) {
+	if h.TLSConfig, err = tlsCfg.ClientConfig(h.Addr); err != nil {
+		return fmt.Errorf("load TLS of '%s' failed: %v", h.Addr, err)
+	}
+	h.TLSRequired = tlsCfg.GetMode() == config.BackendTLSRequired
+	return nil
+}
+
 // GetConnection to connect a backend conn.
 func (h *DBHost) GetConnection(database string) (Connection, error) {
 	return h.Pool.GetConnection(database)
@@ -138,3 +533,65 @@ func (h *DBHost) GetConnection(database string) (Connection, error) {
 func (h *DBHost) ReturnConnection(conn Connection) {
 	h.Pool.ReturnConnection(conn)
 }
+
+// MaxAllowedPacket get max_allowed_packet of server, 0 if not known yet.
+func (h *DBHost) MaxAllowedPacket() int {
+	return int(atomic.LoadInt64(&h.maxAllowedPacket))
+}
+
+// SetMaxAllowedPacket set max_allowed_packet of server, read by connection.
+func (h *DBHost) SetMaxAllowedPacket(maxAllowedPacket int) {
+	atomic.StoreInt64(&h.maxAllowedPacket, int64(maxAllowedPacket))
+}
+
+// latencyWeight is weight of new latency in moving average, 1/latencyWeight.
+const latencyWeight = 8
+
+// ObserveLatency add latency of a query to moving average.
+func (h *DBHost) ObserveLatency(latency time.Duration) {
+	for {
+		old := atomic.LoadInt64(&h.latency)
+		value := int64(latency)
+		if old > 0 {
+			value = old + (value-old)/latencyWeight
+		}
+		if atomic.CompareAndSwapInt64(&h.latency, old, value) {
+			return
+		}
+	}
+}
+
+// Latency get moving average of queries, 0 if not queried yet.
+func (h *DBHost) Latency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&h.latency))
+}
+
+// IsOffline taken offline by admin.
+func (h *DBHost) IsOffline() bool {
+	return atomic.LoadInt32(&h.offline) == 1
+}
+
+// isAvailable db host is up and online.
+func (h *DBHost) isAvailable() bool {
+	return !h.IsDown() && !h.IsOffline()
+}
+
+// IsDown failed to connect db host last time.
+func (h *DBHost) IsDown() bool {
+	return atomic.LoadInt32(&h.down) == 1
+}
+
+func (h *DBHost) markDown(err error) {
+	if atomic.CompareAndSwapInt32(&h.down, 0, 1) {
+		atomic.StoreInt64(&h.downSince, time.Now().UnixNano())
+		alert.Emit(alert.EventNodeDown, alert.SeverityCritical, h.Addr,
+			fmt.Sprintf("db host %s is down: %s", h.Addr, err.Error()), map[string]string{"error": err.Error()})
+	}
+}
+
+func (h *DBHost) markUp() {
+	if atomic.CompareAndSwapInt32(&h.down, 1, 0) {
+		atomic.StoreInt64(&h.upSince, time.Now().UnixNano())
+		alert.Emit(alert.EventNodeUp, alert.SeverityInfo, h.Addr, fmt.Sprintf("db host %s is up", h.Addr), nil)
+	}
+}
@@ -0,0 +1,148 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package backend
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/berkaroad/saashard/errors"
+)
+
+// QueryLimiter limit concurrent queries of db host independent of pool size,
+// queries above the limit wait in queue, and are rejected if queue is full or wait timeout.
+type QueryLimiter struct {
+	MaxConcurrent int
+	MaxQueueSize  int
+	QueueTimeout  time.Duration
+
+	slots chan struct{}
+
+	inFlight  int64
+	queued    int64
+	maxQueued int64
+	total     uint64
+	rejected  uint64
+	timeouts  uint64
+}
+
+// QueryLimiterStats is stats of query limiter.
+type QueryLimiterStats struct {
+	MaxConcurrent int
+	MaxQueueSize  int
+	InFlight      int64
+	Queued        int64
+	MaxQueued     int64
+	Total         uint64
+	Rejected      uint64
+	Timeouts      uint64
+}
+
+// NewQueryLimiter create query limiter, maxConcurrent 0 is unlimited,
+// queueTimeout 0 is waiting until a query finished.
+func NewQueryLimiter(maxConcurrent, maxQueueSize int, queueTimeout time.Duration) *QueryLimiter {
+	l := new(QueryLimiter)
+	l.MaxConcurrent = maxConcurrent
+	l.MaxQueueSize = maxQueueSize
+	l.QueueTimeout = queueTimeout
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+// Acquire a slot before query, must Release after query if no error.
+func (l *QueryLimiter) Acquire() error {
+	if l == nil {
+		return nil
+	}
+	atomic.AddUint64(&l.total, 1)
+	if l.slots == nil {
+		atomic.AddInt64(&l.inFlight, 1)
+		return nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		atomic.AddInt64(&l.inFlight, 1)
+		return nil
+	default:
+	}
+
+	// wait in queue.
+	queued := atomic.AddInt64(&l.queued, 1)
+	defer atomic.AddInt64(&l.queued, -1)
+	if queued > int64(l.MaxQueueSize) {
+		atomic.AddUint64(&l.rejected, 1)
+		return errors.ErrTooManyQueries
+	}
+	for {
+		maxQueued := atomic.LoadInt64(&l.maxQueued)
+		if queued <= maxQueued || atomic.CompareAndSwapInt64(&l.maxQueued, maxQueued, queued) {
+			break
+		}
+	}
+
+	var timeout <-chan time.Time
+	if l.QueueTimeout > 0 {
+		timer := time.NewTimer(l.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case l.slots <- struct{}{}:
+		atomic.AddInt64(&l.inFlight, 1)
+		return nil
+	case <-timeout:
+		atomic.AddUint64(&l.timeouts, 1)
+		return errors.ErrQueryQueueTimeout
+	}
+}
+
+// Release the slot after query.
+func (l *QueryLimiter) Release() {
+	if l == nil {
+		return
+	}
+	atomic.AddInt64(&l.inFlight, -1)
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// Stats of query limiter.
+func (l *QueryLimiter) Stats() QueryLimiterStats {
+	var stats QueryLimiterStats
+	if l == nil {
+		return stats
+	}
+	stats.MaxConcurrent = l.MaxConcurrent
+	stats.MaxQueueSize = l.MaxQueueSize
+	stats.InFlight = atomic.LoadInt64(&l.inFlight)
+	stats.Queued = atomic.LoadInt64(&l.queued)
+	stats.MaxQueued = atomic.LoadInt64(&l.maxQueued)
+	stats.Total = atomic.LoadUint64(&l.total)
+	stats.Rejected = atomic.LoadUint64(&l.rejected)
+	stats.Timeouts = atomic.LoadUint64(&l.timeouts)
+	return stats
+}
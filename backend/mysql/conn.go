@@ -23,14 +23,18 @@
 package mysql
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/berkaroad/saashard/backend"
 	"github.com/berkaroad/saashard/net/mysql"
 )
 
+var errTLSNotSupported = errors.New("TLS is required, but not supported by mysql server")
+
 func init() {
 	backend.CreateConnection = func(dbHost *backend.DBHost) backend.Connection {
 		return new(Conn)
@@ -46,6 +50,7 @@ type Conn struct {
 	dbHost       *backend.DBHost
 	db           string
 	connectionID uint32
+	threadID     uint32 // connection id in mysql server.
 
 	capability uint32
 	status     uint16
@@ -53,8 +58,22 @@ type Conn struct {
 	collation mysql.CollationID
 	charset   string
 	salt      []byte
+
+	stmts *stmtCache
+
+	// sql_mode of session set by proxy, DefaultSQLMode is default of server.
+	sqlMode string
+
+	// localInfile send local file of LOAD DATA LOCAL INFILE, see mysql.PacketIO.LocalInfile.
+	localInfile func(filename []byte, server *mysql.PacketIO) error
 }
 
+// DefaultSQLMode is sql_mode of session by default of server.
+const DefaultSQLMode = "DEFAULT"
+
+// unknownSQLMode is sql_mode of session not known, it's set again by SetSQLMode.
+const unknownSQLMode = "?"
+
 // GetConnectionID get connection id
 func (c *Conn) GetConnectionID() uint32 {
 	return c.connectionID
@@ -63,6 +82,16 @@ func (c *Conn) GetConnectionID() uint32 {
 // SetConnectionID set connection id
 func (c *Conn) SetConnectionID(id uint32) { c.connectionID = id }
 
+// GetThreadID get connection id in mysql server, such as to kill the query.
+func (c *Conn) GetThreadID() uint32 {
+	return c.threadID
+}
+
+// GetDBHost get db host connected.
+func (c *Conn) GetDBHost() *backend.DBHost {
+	return c.dbHost
+}
+
 // Connect to mysql
 func (c *Conn) Connect(dbHost *backend.DBHost, db string) error {
 	c.dbHost = dbHost
@@ -109,24 +138,46 @@ func (c *Conn) Reconnect() error {
 
 		c.conn = netConn
 		c.pkg = mysql.NewPacketIO(netConn)
-
-		if c.capability, c.status, c.collation, err = c.pkg.ReadInitialHandshake(&(c.salt)); err != nil {
+		c.pkg.TLSConfig = c.dbHost.TLSConfig
+		c.pkg.LocalInfile = c.localInfile
+		c.pkg.Compress = c.dbHost.Compress
+		// stmt handles are bound to backend session, drop them when reconnect.
+		c.stmts = newStmtCache(DefaultStmtCacheSize)
+		c.sqlMode = DefaultSQLMode
+
+		var authPlugin string
+		if c.capability, c.status, c.collation, c.threadID, authPlugin, err = c.pkg.ReadInitialHandshake(&(c.salt)); err != nil {
 			c.conn.Close()
 			c.conn = nil
 			return err
 		}
+		if c.dbHost.TLSRequired && c.capability&mysql.CLIENT_SSL == 0 {
+			c.conn.Close()
+			c.conn = nil
+			return errTLSNotSupported
+		}
 
-		if err := c.pkg.WriteAuthHandshake(&(c.capability), c.dbHost.User, c.dbHost.Password, c.db, c.salt, c.collation); err != nil {
+		if err := c.pkg.WriteAuthHandshake(&(c.capability), c.dbHost.User, c.dbHost.Password, c.db, c.salt, c.collation, authPlugin); err != nil {
 			c.conn.Close()
 			c.conn = nil
 			return err
 		}
 
-		if _, err := c.pkg.ReadOK(c.capability, &(c.status)); err != nil {
-			c.conn.Close()
+		// auth may be switched, or exchanged more by caching_sha2_password of MySQL 8.0.
+		if err := c.pkg.ReadAuthResult(c.capability, &(c.status), c.dbHost.Password, c.salt, authPlugin); err != nil {
+			c.pkg.Conn().Close()
 			c.conn = nil
 			return err
 		}
+		// TLS connection if upgraded in handshake.
+		c.conn = c.pkg.Conn()
+		if c.capability&mysql.CLIENT_COMPRESS > 0 {
+			if err := c.pkg.SetCompressed(); err != nil {
+				c.conn.Close()
+				c.conn = nil
+				return err
+			}
+		}
 	}
 	c.pkg.Sequence = 0
 
@@ -139,12 +190,43 @@ func (c *Conn) Reconnect() error {
 		}
 	}
 
+	// query bigger than max_allowed_packet of server is rejected before sent, instead of closed by server mid-stream.
+	if c.dbHost.MaxAllowedPacket() == 0 {
+		if r, err := c.pkg.Query(c.capability, &(c.status), "select @@global.max_allowed_packet"); err == nil {
+			if maxAllowedPacket, err := r.GetUint(0, 0); err == nil {
+				c.dbHost.SetMaxAllowedPacket(int(maxAllowedPacket))
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetLocalInfile set handler to send local file requested by server in LOAD DATA LOCAL INFILE,
+// the request is refused if nil.
+func (c *Conn) SetLocalInfile(handler func(filename []byte, server *mysql.PacketIO) error) {
+	c.localInfile = handler
+	if c.pkg != nil {
+		c.pkg.LocalInfile = handler
+	}
+}
+
+// checkPacketSize check query is not bigger than max_allowed_packet of server.
+func (c *Conn) checkPacketSize(query string) error {
+	if maxAllowedPacket := c.dbHost.MaxAllowedPacket(); maxAllowedPacket > 0 && len(query)+1 > maxAllowedPacket {
+		return mysql.ErrPacketTooLarge
+	}
 	return nil
 }
 
 // Close conn
 func (c *Conn) Close() error {
 	if !c.IsClosed() {
+		if c.stmts != nil {
+			for _, s := range c.stmts.clear() {
+				s.Close()
+			}
+		}
 		c.pkg.Quit(c.capability, &(c.status))
 		c.conn.Close()
 		c.conn = nil
@@ -204,14 +286,92 @@ func (c *Conn) IsClosed() bool {
 
 // Query command.
 func (c *Conn) Query(query string) (*mysql.Result, error) {
+	if err := c.dbHost.Limiter.Acquire(); err != nil {
+		return nil, err
+	}
+	defer c.dbHost.Limiter.Release()
+	return c.query(query)
+}
+
+// query without limit of concurrent queries, such as commit and rollback which release locks.
+func (c *Conn) query(query string) (*mysql.Result, error) {
+	if c.IsClosed() {
+		c.Reconnect()
+	}
+	if err := c.checkPacketSize(query); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	r, err := c.pkg.Query(c.capability, &(c.status), query)
+	c.dbHost.ObserveLatency(time.Since(start))
+	c.trackSessionState(r)
+	return r, err
+}
+
+// QueryRaw is the same as Query, but rows of result set are raw packets to write as is.
+func (c *Conn) QueryRaw(query string) (*mysql.Result, error) {
+	if err := c.dbHost.Limiter.Acquire(); err != nil {
+		return nil, err
+	}
+	defer c.dbHost.Limiter.Release()
+	if c.IsClosed() {
+		c.Reconnect()
+	}
+	if err := c.checkPacketSize(query); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	r, err := c.pkg.QueryRaw(c.capability, &(c.status), query)
+	c.dbHost.ObserveLatency(time.Since(start))
+	c.trackSessionState(r)
+	return r, err
+}
+
+// QueryStream is the same as QueryRaw, but result set is written to client as it's read, without rows kept.
+func (c *Conn) QueryStream(query string, client *mysql.PacketIO, capability uint32, status uint16) (*mysql.Result, error) {
+	if err := c.dbHost.Limiter.Acquire(); err != nil {
+		return nil, err
+	}
+	defer c.dbHost.Limiter.Release()
+	if c.IsClosed() {
+		c.Reconnect()
+	}
+	if err := c.checkPacketSize(query); err != nil {
+		return nil, err
+	}
+	r, err := c.pkg.QueryStream(c.capability, &(c.status), query, client, capability, status)
+	c.trackSessionState(r)
+	return r, err
+}
+
+// QueryStreamPart query and write result set to client as a part of result set merged from several nodes,
+// see mysql.PacketIO.StreamResultSetPart.
+func (c *Conn) QueryStreamPart(query string, client *mysql.PacketIO, capability uint32, status uint16,
+	merged *mysql.Result, last bool) (*mysql.Result, error) {
+	if err := c.dbHost.Limiter.Acquire(); err != nil {
+		return nil, err
+	}
+	defer c.dbHost.Limiter.Release()
 	if c.IsClosed() {
 		c.Reconnect()
 	}
-	return c.pkg.Query(c.capability, &(c.status), query)
+	if err := c.checkPacketSize(query); err != nil {
+		return nil, err
+	}
+	if err := c.pkg.WriteCommandStr(mysql.COM_QUERY, query); err != nil {
+		return nil, err
+	}
+	r, err := c.pkg.StreamResultSetPart(c.capability, &(c.status), false, client, capability, status, merged, last)
+	c.trackSessionState(r)
+	return r, err
 }
 
 // FieldList return field list.
 func (c *Conn) FieldList(table string, wildcard string) ([]*mysql.Field, error) {
+	if err := c.dbHost.Limiter.Acquire(); err != nil {
+		return nil, err
+	}
+	defer c.dbHost.Limiter.Release()
 	if c.IsClosed() {
 		c.Reconnect()
 	}
@@ -224,18 +384,57 @@ func (c *Conn) Execute(command string, args []interface{}) (*mysql.Result, error
 		c.Reconnect()
 	}
 	if len(args) == 0 {
-		return c.pkg.Query(c.capability, &(c.status), command)
+		return c.Query(command)
+	}
+	return c.ExecuteStmt(command, args)
+}
+
+// ExecuteStmt execute command with binary protocol, using cached stmt.
+func (c *Conn) ExecuteStmt(command string, args []interface{}) (*mysql.Result, error) {
+	if err := c.dbHost.Limiter.Acquire(); err != nil {
+		return nil, err
 	}
-	s, err := c.Prepare(command)
+	defer c.dbHost.Limiter.Release()
+	s, err := c.PrepareCached(command)
 	if err != nil {
 		return nil, err
 	}
 	var r *mysql.Result
 	r, err = s.Execute(args)
-	s.Close()
+	if isUnknownStmtHandler(err) {
+		// stmt handle is lost in backend, re-prepare and retry once.
+		c.stmts.remove(normalizeStmtSQL(command))
+		if s, err = c.PrepareCached(command); err != nil {
+			return nil, err
+		}
+		r, err = s.Execute(args)
+	}
+	c.trackSessionState(r)
 	return r, err
 }
 
+// PrepareCached get stmt from cache, or prepare and cache it.
+// Don't close the returned stmt, it's managed by cache.
+func (c *Conn) PrepareCached(query string) (*mysql.Stmt, error) {
+	if c.IsClosed() {
+		c.Reconnect()
+	}
+	if c.stmts == nil {
+		c.stmts = newStmtCache(DefaultStmtCacheSize)
+	}
+	key := normalizeStmtSQL(query)
+	if s := c.stmts.get(key); s != nil {
+		return s, nil
+	}
+
+	s, err := c.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts.put(key, s)
+	return s, nil
+}
+
 // Prepare stmt.
 func (c *Conn) Prepare(query string) (*mysql.Stmt, error) {
 	if c.IsClosed() {
@@ -260,13 +459,13 @@ func (c *Conn) Begin() error {
 
 // Commit Tx
 func (c *Conn) Commit() error {
-	_, err := c.Query("commit")
+	_, err := c.query("commit")
 	return err
 }
 
 // Rollback Tx
 func (c *Conn) Rollback() error {
-	_, err := c.Query("rollback")
+	_, err := c.query("rollback")
 	return err
 }
 
@@ -314,6 +513,120 @@ func (c *Conn) SetAutoCommit(autocommit bool) error {
 	return nil
 }
 
+// SetSQLMode set sql_mode of session if changed, DefaultSQLMode is default of server.
+func (c *Conn) SetSQLMode(sqlMode string) error {
+	if c.IsClosed() {
+		c.Reconnect()
+	}
+	if c.sqlMode == sqlMode {
+		return nil
+	}
+	value := "default"
+	if sqlMode != DefaultSQLMode {
+		value = "'" + strings.Replace(sqlMode, "'", "''", -1) + "'"
+	}
+	if _, err := c.pkg.Query(c.capability, &(c.status), "set session sql_mode = "+value); err != nil {
+		c.sqlMode = unknownSQLMode
+		return err
+	}
+	c.sqlMode = sqlMode
+	return nil
+}
+
+// ResetSession restore session to the state of pool, transaction rolled back, autocommit and default sql_mode.
+// It's called by pool when returned, so that background writers never run in session left by client.
+func (c *Conn) ResetSession() error {
+	if c.IsInTransaction() {
+		if err := c.Rollback(); err != nil {
+			return err
+		}
+	}
+	if !c.IsAutoCommit() {
+		if err := c.SetAutoCommit(true); err != nil {
+			return err
+		}
+	}
+	return c.SetSQLMode(DefaultSQLMode)
+}
+
+// EvalSQLMode set sql_mode of session by expression, and get the value, such as combination modes expanded by server.
+func (c *Conn) EvalSQLMode(expr string) (string, error) {
+	if _, err := c.Query("set session sql_mode = " + expr); err != nil {
+		return "", err
+	}
+	c.sqlMode = unknownSQLMode
+	result, err := c.Query("select @@session.sql_mode")
+	if err != nil {
+		return "", err
+	}
+	sqlMode, err := result.GetString(0, 0)
+	if err != nil {
+		return "", err
+	}
+	c.sqlMode = sqlMode
+	return sqlMode, nil
+}
+
+// Promote stop replication and make mysql server writable, to be master when failed over.
+func (c *Conn) Promote() error {
+	for _, query := range []string{"stop slave", "reset slave all", "set global read_only = 0"} {
+		if _, err := c.Query(query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Demote make mysql server read-only, when failed back to master configured.
+func (c *Conn) Demote() error {
+	_, err := c.Query("set global read_only = 1")
+	return err
+}
+
+// ReplicationStatus get gtid executed, semi-sync and slave status, to choose candidate and assess data loss of failover,
+// and to check master configured has caught up before failback.
+func (c *Conn) ReplicationStatus() (*backend.ReplicationStatus, error) {
+	status := new(backend.ReplicationStatus)
+	result, err := c.Query("select @@global.gtid_executed")
+	if err != nil {
+		return nil, err
+	}
+	gtidExecuted, err := result.GetString(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if status.GTIDExecuted, err = backend.ParseGTIDSet(gtidExecuted); err != nil {
+		return nil, err
+	}
+	// no rows if semi-sync plugins are not installed.
+	if result, err = c.Query("show global status like 'Rpl_semi_sync_%_status'"); err != nil {
+		return nil, err
+	}
+	for i := 0; i < result.RowNumber(); i++ {
+		name, _ := result.GetString(i, 0)
+		value, _ := result.GetString(i, 1)
+		switch strings.ToLower(name) {
+		case "rpl_semi_sync_master_status":
+			status.SemiSyncMaster = strings.EqualFold(value, "ON")
+		case "rpl_semi_sync_slave_status":
+			status.SemiSyncSlave = strings.EqualFold(value, "ON")
+		}
+	}
+	// no rows if it's not a slave.
+	if result, err = c.Query("show slave status"); err != nil {
+		return nil, err
+	}
+	if result.RowNumber() > 0 {
+		host, _ := result.GetStringByName(0, "Master_Host")
+		port, _ := result.GetStringByName(0, "Master_Port")
+		ioRunning, _ := result.GetStringByName(0, "Slave_IO_Running")
+		sqlRunning, _ := result.GetStringByName(0, "Slave_SQL_Running")
+		status.Source = net.JoinHostPort(host, port)
+		status.Replicating = strings.EqualFold(ioRunning, "Yes") && strings.EqualFold(sqlRunning, "Yes")
+	}
+	return status, nil
+}
+
 // IsAutoCommit status.
 func (c *Conn) IsAutoCommit() bool {
 	return c.status&mysql.SERVER_STATUS_AUTOCOMMIT > 0
@@ -323,3 +636,23 @@ func (c *Conn) IsAutoCommit() bool {
 func (c *Conn) IsInTransaction() bool {
 	return c.status&mysql.SERVER_STATUS_IN_TRANS > 0
 }
+
+// trackSessionState keep schema and charset of conn same as backend, by session state changes in OK packet,
+// when CLIENT_SESSION_TRACK is on.
+func (c *Conn) trackSessionState(r *mysql.Result) {
+	if r == nil || r.SessionState == nil {
+		return
+	}
+	state := r.SessionState
+	if len(state.Schema) > 0 {
+		c.db = state.Schema
+	}
+	for name, value := range state.SystemVariables {
+		if strings.EqualFold(name, "character_set_client") {
+			if cid, ok := mysql.CharsetIds[value]; ok {
+				c.collation = cid
+				c.charset = value
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mysql
+
+import (
+	"strings"
+)
+
+// QuoteString quote s as string expression of SQL, the same in any sql_mode, such as NO_BACKSLASH_ESCAPES.
+// Quote is doubled, and backslash is written as CHAR(92), since it's escape character only if not NO_BACKSLASH_ESCAPES.
+// It's an expression instead of literal if s contains backslash, so it couldn't be used where literal is required.
+func QuoteString(s string) string {
+	if !strings.Contains(s, "\\") {
+		return "'" + strings.Replace(s, "'", "''", -1) + "'"
+	}
+	parts := strings.Split(s, "\\")
+	for i, part := range parts {
+		parts[i] = "'" + strings.Replace(part, "'", "''", -1) + "'"
+	}
+	return "CONCAT(" + strings.Join(parts, ", CHAR(92 USING utf8mb4), ") + ")"
+}
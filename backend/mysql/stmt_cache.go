@@ -0,0 +1,177 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mysql
+
+import (
+	"container/list"
+	"strings"
+
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+// DefaultStmtCacheSize is max count of prepared stmt handles cached on one backend connection.
+var DefaultStmtCacheSize = 64
+
+// stmtCache is LRU cache of prepared stmt handles, keyed by normalized sql.
+// It belongs to one backend connection, so it's not thread-safe.
+type stmtCache struct {
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+// stmtCacheEntry stmt with its key, the stmt is prepared by original sql, not the key.
+type stmtCacheEntry struct {
+	key  string
+	stmt *mysql.Stmt
+}
+
+func newStmtCache(size int) *stmtCache {
+	c := new(stmtCache)
+	c.size = size
+	c.ll = list.New()
+	c.items = make(map[string]*list.Element, size)
+	return c
+}
+
+// get stmt by normalized sql, and mark it as recently used.
+func (c *stmtCache) get(key string) *mysql.Stmt {
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		return e.Value.(*stmtCacheEntry).stmt
+	}
+	return nil
+}
+
+// put stmt to cache, the replaced or least recently used stmt is closed in backend.
+func (c *stmtCache) put(key string, stmt *mysql.Stmt) {
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		entry := e.Value.(*stmtCacheEntry)
+		if entry.stmt != stmt {
+			entry.stmt.Close()
+			entry.stmt = stmt
+		}
+		return
+	}
+	c.items[key] = c.ll.PushFront(&stmtCacheEntry{key: key, stmt: stmt})
+	if c.size > 0 && c.ll.Len() > c.size {
+		e := c.ll.Back()
+		entry := e.Value.(*stmtCacheEntry)
+		c.ll.Remove(e)
+		delete(c.items, entry.key)
+		entry.stmt.Close()
+	}
+}
+
+// remove stmt from cache.
+func (c *stmtCache) remove(key string) *mysql.Stmt {
+	if e, ok := c.items[key]; ok {
+		c.ll.Remove(e)
+		delete(c.items, key)
+		return e.Value.(*stmtCacheEntry).stmt
+	}
+	return nil
+}
+
+// clear all stmts, return them for closing.
+func (c *stmtCache) clear() []*mysql.Stmt {
+	stmts := make([]*mysql.Stmt, 0, c.ll.Len())
+	for e := c.ll.Front(); e != nil; e = e.Next() {
+		stmts = append(stmts, e.Value.(*stmtCacheEntry).stmt)
+	}
+	c.ll.Init()
+	c.items = make(map[string]*list.Element, c.size)
+	return stmts
+}
+
+// normalizeStmtSQL trim spaces and tail ';', and collapse whitespaces outside quotes and comments.
+// It's only the cache key, stmt is always prepared by original sql.
+// Comments are kept as they are, with the newline ending '--' and '#' comment,
+// so that sqls different in comments never share a key.
+func normalizeStmtSQL(query string) string {
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+	buf := make([]byte, 0, len(query))
+	var quote byte
+	var lastSpace bool
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		if quote != 0 {
+			buf = append(buf, ch)
+			if ch == '\\' && i+1 < len(query) {
+				i++
+				buf = append(buf, query[i])
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+		if end := commentEnd(query, i); end > i {
+			buf = append(buf, query[i:end]...)
+			i = end - 1
+			lastSpace = query[i] == '\n'
+			continue
+		}
+		switch ch {
+		case '\'', '"', '`':
+			quote = ch
+		case ' ', '\t', '\r', '\n':
+			if !lastSpace {
+				buf = append(buf, ' ')
+			}
+			lastSpace = true
+			continue
+		}
+		lastSpace = false
+		buf = append(buf, ch)
+	}
+	return string(buf)
+}
+
+// commentEnd return end of comment starts at i, including the newline ending '--' and '#' comment,
+// or i if it's not a comment.
+func commentEnd(query string, i int) int {
+	switch {
+	case query[i] == '#',
+		strings.HasPrefix(query[i:], "--") && (i+2 == len(query) || strings.IndexByte(" \t\r\n", query[i+2]) >= 0):
+		if n := strings.IndexByte(query[i:], '\n'); n >= 0 {
+			return i + n + 1
+		}
+		return len(query)
+	case strings.HasPrefix(query[i:], "/*"):
+		if n := strings.Index(query[i+2:], "*/"); n >= 0 {
+			return i + 2 + n + 2
+		}
+		return len(query)
+	}
+	return i
+}
+
+// isUnknownStmtHandler the stmt handle is not exists in backend, such as closed by server.
+func isUnknownStmtHandler(err error) bool {
+	if sqlErr, ok := err.(*errors.SqlError); ok {
+		return sqlErr.Code == mysql.ER_UNKNOWN_STMT_HANDLER
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package mysql
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+// TestStmtCacheEviction least recently used stmt is evicted and closed in backend.
+func TestStmtCacheEviction(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	closed := make(chan uint32, 8)
+	go func() {
+		defer server.Close()
+		pkg := mysql.NewPacketIO(server)
+		for {
+			data, err := pkg.ReadPacket()
+			if err != nil {
+				close(closed)
+				return
+			}
+			if data[0] == mysql.COM_STMT_CLOSE {
+				closed <- binary.LittleEndian.Uint32(data[1:])
+			}
+			pkg.Sequence = 0
+		}
+	}()
+
+	pkg := mysql.NewPacketIO(client)
+	var status uint16
+	newStmt := func(id uint32) *mysql.Stmt {
+		s := mysql.NewStmt(pkg, mysql.CLIENT_PROTOCOL_41, &status)
+		s.ID = id
+		return s
+	}
+	c := newStmtCache(2)
+	c.put("a", newStmt(1))
+	c.put("b", newStmt(2))
+	if c.get("a") == nil {
+		t.Fatal("expect stmt a cached")
+	}
+	// b is least recently used.
+	c.put("c", newStmt(3))
+	if id := <-closed; id != 2 {
+		t.Errorf("expect stmt 2 closed, got %d", id)
+	}
+	if c.get("b") != nil || c.get("a") == nil || c.get("c") == nil {
+		t.Error("expect b evicted, and a, c kept")
+	}
+	// a is least recently used now.
+	c.put("d", newStmt(4))
+	if id := <-closed; id != 1 {
+		t.Errorf("expect stmt 1 closed, got %d", id)
+	}
+	// stmt prepared again with same key replaces the old one.
+	c.put("c", newStmt(5))
+	if id := <-closed; id != 3 {
+		t.Errorf("expect stmt 3 closed, got %d", id)
+	}
+	if s := c.get("c"); s == nil || s.ID != 5 {
+		t.Errorf("expect stmt 5 cached for c, got %v", s)
+	}
+	if stmts := c.clear(); len(stmts) != 2 {
+		t.Errorf("expect 2 stmts cleared, got %d", len(stmts))
+	}
+}
+
+// TestNormalizeStmtSQL whitespaces are collapsed only outside quotes and comments.
+func TestNormalizeStmtSQL(t *testing.T) {
+	cases := []struct {
+		sql, key string
+	}{
+		{"select  a,\n\tb from t ;", "select a, b from t"},
+		{"select 'a  b' from t", "select 'a  b' from t"},
+		{"select a -- x\nfrom t", "select a -- x\nfrom t"},
+		{"select a # x\n  from t", "select a # x\nfrom t"},
+		{"select a /* x\n y */  from t", "select a /* x\n y */ from t"},
+		{"select a--1\nfrom t", "select a--1 from t"},
+	}
+	for _, tc := range cases {
+		if key := normalizeStmtSQL(tc.sql); key != tc.key {
+			t.Errorf("%q: expect key %q, got %q", tc.sql, tc.key, key)
+		}
+	}
+	if normalizeStmtSQL("select a -- x\nfrom t") == normalizeStmtSQL("select a -- x from t") {
+		t.Error("expect different keys of sql different in comment")
+	}
+}
@@ -0,0 +1,161 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package backend
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// ReplicationStatus is semi-sync status and gtid executed of mysql server, got in health check.
+type ReplicationStatus struct {
+	GTIDExecuted GTIDSet
+	// SemiSyncMaster is ON of Rpl_semi_sync_master_status, commits are acked by a slave at least.
+	SemiSyncMaster bool
+	// SemiSyncSlave is ON of Rpl_semi_sync_slave_status.
+	SemiSyncSlave bool
+	// Source is address of master replicated from, as host:port in 'SHOW SLAVE STATUS', empty if not a slave.
+	Source string
+	// Replicating is true if both IO and SQL threads of replication are running.
+	Replicating bool
+	CheckedAt   time.Time
+}
+
+// ReplicationReporter is connection able to report replication status of its mysql server.
+type ReplicationReporter interface {
+	ReplicationStatus() (*ReplicationStatus, error)
+}
+
+// FailoverReport is data loss assessment of promoting candidate, in failover event.
+type FailoverReport struct {
+	// MasterSemiSync is semi-sync status of master at last check, transactions since then may be lost if it's off.
+	MasterSemiSync bool
+	// Missing is transactions known executed in master or other slaves, but not in candidate.
+	Missing GTIDSet
+	// Unknown is true if replication status of master or candidate is not known.
+	Unknown bool
+}
+
+// PossibleDataLoss is true unless master was semi-sync and candidate has all transactions known.
+func (r *FailoverReport) PossibleDataLoss() bool {
+	return r.Unknown || !r.MasterSemiSync || r.Missing.Count() > 0
+}
+
+// details of failover event.
+func (r *FailoverReport) details() map[string]string {
+	return map[string]string{
+		"master_semi_sync":     strconv.FormatBool(r.MasterSemiSync),
+		"missing_transactions": strconv.FormatInt(r.Missing.Count(), 10),
+		"missing_gtid_set":     r.Missing.String(),
+		"replication_unknown":  strconv.FormatBool(r.Unknown),
+		"possible_data_loss":   strconv.FormatBool(r.PossibleDataLoss()),
+	}
+}
+
+// Replication get replication status at last health check, nil if not known.
+func (h *DBHost) Replication() *ReplicationStatus {
+	status, _ := h.replication.Load().(*ReplicationStatus)
+	return status
+}
+
+// checkReplication get replication status by connection of health check.
+func (h *DataHost) checkReplication(dbHost *DBHost, conn Connection) {
+	if err := updateReplication(dbHost, conn); err != nil {
+		simplelog.Warn("%s %s %s host=%s,addr=%s", "backend", "checkReplication", err.Error(), h.Name, dbHost.Addr)
+	}
+}
+
+// refreshReplication get replication status of db host now, not waiting for health check.
+func refreshReplication(dbHost *DBHost) error {
+	conn, err := dbHost.GetConnection("")
+	if err != nil {
+		return err
+	}
+	defer conn.ReturnConnection()
+	return updateReplication(dbHost, conn)
+}
+
+// updateReplication get replication status by connection, nothing if it's not able to report.
+func updateReplication(dbHost *DBHost, conn Connection) error {
+	reporter, ok := conn.(ReplicationReporter)
+	if !ok {
+		return nil
+	}
+	status, err := reporter.ReplicationStatus()
+	if err != nil {
+		return err
+	}
+	status.CheckedAt = time.Now()
+	dbHost.replication.Store(status)
+	return nil
+}
+
+// chooseCandidate get candidate configured, or the most up-to-date slave up if candidate is auto.
+// Slave with fewest transactions missing is chosen, and semi-sync slave is preferred if the same.
+func (h *DataHost) chooseCandidate(master *DBHost) *DBHost {
+	if h.cfg.Failover.Candidate != config.FailoverCandidateAuto {
+		return h.getSlaveByAddr(h.cfg.Failover.Candidate)
+	}
+	var chosen *DBHost
+	var chosenMissing int64
+	for _, slave := range h.Slaves {
+		if slave == master || slave.IsDown() || slave.Replication() == nil {
+			continue
+		}
+		missing := h.assessFailover(master, slave).Missing.Count()
+		if chosen == nil || missing < chosenMissing ||
+			missing == chosenMissing && slave.Replication().SemiSyncSlave && !chosen.Replication().SemiSyncSlave {
+			chosen, chosenMissing = slave, missing
+		}
+	}
+	return chosen
+}
+
+// assessFailover assess data loss of promoting candidate, by replication status of master and slaves at last check.
+func (h *DataHost) assessFailover(master, candidate *DBHost) *FailoverReport {
+	report := new(FailoverReport)
+	report.Missing = make(GTIDSet)
+	masterStatus := master.Replication()
+	candidateStatus := candidate.Replication()
+	if masterStatus == nil || candidateStatus == nil {
+		report.Unknown = true
+	}
+	if candidateStatus == nil {
+		return report
+	}
+	known := make(GTIDSet)
+	if masterStatus != nil {
+		report.MasterSemiSync = masterStatus.SemiSyncMaster
+		known = known.Union(masterStatus.GTIDExecuted)
+	}
+	for _, slave := range h.Slaves {
+		if status := slave.Replication(); slave != candidate && slave != master && status != nil {
+			known = known.Union(status.GTIDExecuted)
+		}
+	}
+	report.Missing = known.Subtract(candidateStatus.GTIDExecuted)
+	return report
+}
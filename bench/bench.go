@@ -0,0 +1,288 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package bench generates sysbench-like load on a sharded schema through saashard,
+// and reports throughput and latency of each workload.
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berkaroad/saashard/backend"
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+)
+
+// Workloads.
+const (
+	WorkloadPoint = "point"
+	WorkloadRange = "range"
+	WorkloadWrite = "write"
+)
+
+// Workloads supported, in order of report.
+var Workloads = []string{WorkloadPoint, WorkloadRange, WorkloadWrite}
+
+// Options of benchmark.
+type Options struct {
+	Addr     string // address of saashard proxy.
+	User     string
+	Password string
+	Schema   string
+	ShardKey string // shard key of schema, as column of table.
+	Table    string // table configured in schema, default is sbtest.
+
+	Tenants   int           // distinct values of shard key, default is 100.
+	TableSize int           // rows of each tenant, default is 1000.
+	RangeSize int           // rows of range select, default is 100.
+	Threads   int           // concurrent connections, default is 8.
+	Duration  time.Duration // default is 30s.
+	Mix       string        // weight of workloads, default is 'point=70,range=10,write=20'.
+	Seed      int64         // seed of random, the same seed generates the same statements.
+}
+
+func (o *Options) setDefaults() {
+	if len(o.Table) == 0 {
+		o.Table = "sbtest"
+	}
+	if o.Tenants <= 0 {
+		o.Tenants = 100
+	}
+	if o.TableSize <= 0 {
+		o.TableSize = 1000
+	}
+	if o.RangeSize <= 0 {
+		o.RangeSize = 100
+	}
+	if o.Threads <= 0 {
+		o.Threads = 8
+	}
+	if o.Duration <= 0 {
+		o.Duration = 30 * time.Second
+	}
+	if len(o.Mix) == 0 {
+		o.Mix = "point=70,range=10,write=20"
+	}
+}
+
+// parseMix parse weight of workloads, such as 'point=70,range=10,write=20'.
+func parseMix(mix string) (map[string]int, int, error) {
+	weights := make(map[string]int)
+	total := 0
+	for _, item := range strings.Split(mix, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		name := strings.ToLower(strings.TrimSpace(kv[0]))
+		if !isWorkload(name) {
+			return nil, 0, fmt.Errorf("unknown workload '%s'", name)
+		}
+		weight := 1
+		if len(kv) == 2 {
+			var err error
+			if weight, err = strconv.Atoi(strings.TrimSpace(kv[1])); err != nil || weight < 0 {
+				return nil, 0, fmt.Errorf("invalid weight of workload '%s'", name)
+			}
+		}
+		weights[name] = weight
+		total += weight
+	}
+	if total == 0 {
+		return nil, 0, fmt.Errorf("no workload in mix '%s'", mix)
+	}
+	return weights, total, nil
+}
+
+func isWorkload(name string) bool {
+	for _, workload := range Workloads {
+		if workload == name {
+			return true
+		}
+	}
+	return false
+}
+
+// connect to saashard as a client.
+func connect(opts *Options) (*mysqlBackend.Conn, error) {
+	dbHost := backend.NewDBHost(opts.Addr, opts.User, opts.Password, 0, opts.Threads)
+	conn := new(mysqlBackend.Conn)
+	if err := conn.Connect(dbHost, opts.Schema); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Prepare create table and load rows of each tenant.
+func Prepare(opts Options) error {
+	opts.setDefaults()
+	conn, err := connect(&opts)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ddl := fmt.Sprintf("create table if not exists %s (id int not null, %s int not null, k int not null default 0, "+
+		"c char(120) not null default '', pad char(60) not null default '', primary key (%s, id), key k_1 (k))",
+		opts.Table, opts.ShardKey, opts.ShardKey)
+	if _, err = conn.Query(ddl); err != nil {
+		return err
+	}
+	const batchSize = 100
+	rnd := rand.New(rand.NewSource(opts.Seed))
+	for tenant := 1; tenant <= opts.Tenants; tenant++ {
+		for start := 1; start <= opts.TableSize; start += batchSize {
+			values := make([]string, 0, batchSize)
+			for id := start; id < start+batchSize && id <= opts.TableSize; id++ {
+				values = append(values, fmt.Sprintf("(%d, %d, %d, '%s', '%s')", id, tenant, rnd.Intn(opts.TableSize)+1,
+					randomString(rnd, 120), randomString(rnd, 60)))
+			}
+			sql := fmt.Sprintf("insert into %s (id, %s, k, c, pad) values %s", opts.Table, opts.ShardKey, strings.Join(values, ", "))
+			if _, err = conn.Query(sql); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Cleanup drop table.
+func Cleanup(opts Options) error {
+	opts.setDefaults()
+	conn, err := connect(&opts)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Query(fmt.Sprintf("drop table if exists %s", opts.Table))
+	return err
+}
+
+// Run workloads by threads in duration, return report of each workload and total.
+func Run(opts Options) ([]*Report, error) {
+	opts.setDefaults()
+	weights, totalWeight, err := parseMix(opts.Mix)
+	if err != nil {
+		return nil, err
+	}
+
+	conns := make([]*mysqlBackend.Conn, opts.Threads)
+	for i := range conns {
+		if conns[i], err = connect(&opts); err != nil {
+			for _, conn := range conns[:i] {
+				conn.Close()
+			}
+			return nil, err
+		}
+	}
+
+	recorders := make([]map[string]*recorder, opts.Threads)
+	var wg sync.WaitGroup
+	start := time.Now()
+	deadline := start.Add(opts.Duration)
+	for i := range conns {
+		recorders[i] = make(map[string]*recorder, len(Workloads))
+		for _, workload := range Workloads {
+			recorders[i][workload] = new(recorder)
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer conns[i].Close()
+			rnd := rand.New(rand.NewSource(opts.Seed + int64(i)))
+			for time.Now().Before(deadline) {
+				workload := pickWorkload(rnd, weights, totalWeight)
+				sqls := opts.statements(rnd, workload)
+				begin := time.Now()
+				var err error
+				for _, sql := range sqls {
+					if _, err = conns[i].Query(sql); err != nil {
+						break
+					}
+				}
+				recorders[i][workload].record(time.Since(begin), err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	reports := make([]*Report, 0, len(Workloads)+1)
+	total := new(recorder)
+	for _, workload := range Workloads {
+		merged := new(recorder)
+		for i := range recorders {
+			merged.merge(recorders[i][workload])
+		}
+		if weights[workload] > 0 {
+			reports = append(reports, merged.report(workload, elapsed))
+		}
+		total.merge(merged)
+	}
+	reports = append(reports, total.report("total", elapsed))
+	return reports, nil
+}
+
+func pickWorkload(rnd *rand.Rand, weights map[string]int, totalWeight int) string {
+	n := rnd.Intn(totalWeight)
+	for _, workload := range Workloads {
+		if n < weights[workload] {
+			return workload
+		}
+		n -= weights[workload]
+	}
+	return Workloads[0]
+}
+
+// statements of workload, all of them are routed by shard key to one node.
+func (o *Options) statements(rnd *rand.Rand, workload string) []string {
+	tenant := rnd.Intn(o.Tenants) + 1
+	id := rnd.Intn(o.TableSize) + 1
+	switch workload {
+	case WorkloadRange:
+		return []string{fmt.Sprintf("select c from %s where %s = %d and id between %d and %d",
+			o.Table, o.ShardKey, tenant, id, id+o.RangeSize-1)}
+	case WorkloadWrite:
+		where := fmt.Sprintf("%s = %d and id = %d", o.ShardKey, tenant, id)
+		return []string{
+			fmt.Sprintf("update %s set k = k + 1 where %s", o.Table, where),
+			fmt.Sprintf("update %s set c = '%s' where %s", o.Table, randomString(rnd, 120), where),
+		}
+	default:
+		return []string{fmt.Sprintf("select c from %s where %s = %d and id = %d", o.Table, o.ShardKey, tenant, id)}
+	}
+}
+
+const letters = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+func randomString(rnd *rand.Rand, n int) string {
+	buf := make([]byte, n)
+	for i := range buf {
+		buf[i] = letters[rnd.Intn(len(letters))]
+	}
+	return string(buf)
+}
@@ -0,0 +1,110 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bench
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Report of workload.
+type Report struct {
+	Workload   string
+	Count      int64
+	Errors     int64
+	Throughput float64 // per second.
+	Avg        time.Duration
+	P50        time.Duration
+	P95        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+	LastError  error
+}
+
+// recorder of latencies, one per thread and workload, so that it's not locked.
+type recorder struct {
+	latencies []time.Duration
+	errors    int64
+	lastError error
+}
+
+func (r *recorder) record(latency time.Duration, err error) {
+	if err != nil {
+		r.errors++
+		r.lastError = err
+		return
+	}
+	r.latencies = append(r.latencies, latency)
+}
+
+func (r *recorder) merge(other *recorder) {
+	r.latencies = append(r.latencies, other.latencies...)
+	r.errors += other.errors
+	if other.lastError != nil {
+		r.lastError = other.lastError
+	}
+}
+
+func (r *recorder) report(workload string, elapsed time.Duration) *Report {
+	report := &Report{Workload: workload, Count: int64(len(r.latencies)), Errors: r.errors, LastError: r.lastError}
+	if elapsed > 0 {
+		report.Throughput = float64(report.Count) / elapsed.Seconds()
+	}
+	if len(r.latencies) == 0 {
+		return report
+	}
+	sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+	var sum time.Duration
+	for _, latency := range r.latencies {
+		sum += latency
+	}
+	percentile := func(p float64) time.Duration {
+		return r.latencies[int(float64(len(r.latencies)-1)*p)]
+	}
+	report.Avg = sum / time.Duration(len(r.latencies))
+	report.P50 = percentile(0.50)
+	report.P95 = percentile(0.95)
+	report.P99 = percentile(0.99)
+	report.Max = r.latencies[len(r.latencies)-1]
+	return report
+}
+
+// PrintReports print reports as table, latencies in milliseconds.
+func PrintReports(w io.Writer, reports []*Report) {
+	fmt.Fprintf(w, "%-8s %10s %8s %10s %9s %9s %9s %9s %9s\n",
+		"workload", "count", "errors", "tps", "avg(ms)", "p50(ms)", "p95(ms)", "p99(ms)", "max(ms)")
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	for _, r := range reports {
+		fmt.Fprintf(w, "%-8s %10d %8d %10.1f %9.3f %9.3f %9.3f %9.3f %9.3f\n",
+			r.Workload, r.Count, r.Errors, r.Throughput, ms(r.Avg), ms(r.P50), ms(r.P95), ms(r.P99), ms(r.Max))
+	}
+	for _, r := range reports {
+		if r.LastError != nil && r.Workload != "total" {
+			fmt.Fprintf(w, "last error of %s: %s\n", r.Workload, r.LastError.Error())
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/berkaroad/saashard/bench"
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/server"
+)
+
+// runBench run 'saashard bench', generate sysbench-like load on a sharded schema through the full proxy path.
+// If addr is empty, saashard is started in process by config, otherwise bench a running one.
+//
+//	saashard bench -config ss.yaml -schema db1 -prepare -duration 60s -threads 16 -mix point=70,range=10,write=20
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	configFile := fs.String("config", "/opt/saashard/conf/ss.yaml", "saashard config file")
+	schemaName := fs.String("schema", "", "sharded schema to bench")
+	addr := fs.String("addr", "", "address of running saashard, start one in process by config if empty")
+	table := fs.String("table", "sbtest", "table to bench, should be configured in schema")
+	tenants := fs.Int("tenants", 100, "distinct values of shard key")
+	tableSize := fs.Int("table-size", 1000, "rows of each tenant")
+	rangeSize := fs.Int("range-size", 100, "rows of range select")
+	threads := fs.Int("threads", 8, "concurrent connections")
+	duration := fs.Duration("duration", 30*time.Second, "duration of run")
+	mix := fs.String("mix", "point=70,range=10,write=20", "weight of workloads [point|range|write]")
+	seed := fs.Int64("seed", 1, "seed of random, the same seed generates the same statements")
+	prepare := fs.Bool("prepare", false, "create table and load rows before run")
+	cleanup := fs.Bool("cleanup", false, "drop table after run")
+	run := fs.Bool("run", true, "run workloads")
+	fs.Parse(args)
+
+	if len(*schemaName) == 0 {
+		fmt.Fprintln(os.Stderr, "must set schema")
+		return 2
+	}
+	cfg, err := config.ParseConfigFile(*configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "parse config file error:%v\n", err.Error())
+		return 1
+	}
+	var schemaConfig *config.SchemaConfig
+	for i := range cfg.Schemas {
+		if strings.EqualFold(cfg.Schemas[i].Name, *schemaName) {
+			schemaConfig = &cfg.Schemas[i]
+			break
+		}
+	}
+	if schemaConfig == nil || !schemaConfig.ShardEnabled() {
+		fmt.Fprintf(os.Stderr, "schema '%s' is not a sharded schema\n", *schemaName)
+		return 1
+	}
+
+	if len(*addr) == 0 {
+		svr, err := server.NewServer(cfg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		go svr.Run()
+		defer svr.Close()
+		*addr = "127.0.0.1:" + strconv.Itoa(cfg.ProxyPort)
+	}
+
+	opts := bench.Options{
+		Addr:      *addr,
+		User:      schemaConfig.User,
+		Password:  schemaConfig.Password,
+		Schema:    schemaConfig.Name,
+		ShardKey:  schemaConfig.ShardKey,
+		Table:     *table,
+		Tenants:   *tenants,
+		TableSize: *tableSize,
+		RangeSize: *rangeSize,
+		Threads:   *threads,
+		Duration:  *duration,
+		Mix:       *mix,
+		Seed:      *seed,
+	}
+	if *prepare {
+		fmt.Printf("prepare %d rows of %d tenants in %s.%s\n", *tenants**tableSize, *tenants, opts.Schema, opts.Table)
+		if err = bench.Prepare(opts); err != nil {
+			fmt.Fprintf(os.Stderr, "prepare error:%v\n", err.Error())
+			return 1
+		}
+	}
+	if *run {
+		fmt.Printf("run %s by %d threads in %s\n", *mix, *threads, duration.String())
+		reports, err := bench.Run(opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "run error:%v\n", err.Error())
+			return 1
+		}
+		bench.PrintReports(os.Stdout, reports)
+	}
+	if *cleanup {
+		if err = bench.Cleanup(opts); err != nil {
+			fmt.Fprintf(os.Stderr, "cleanup error:%v\n", err.Error())
+			return 1
+		}
+	}
+	return 0
+}
@@ -68,6 +68,9 @@ func main() {
 	fmt.Print(banner)
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		os.Exit(runBench(os.Args[2:]))
+	}
 	flag.Parse()
 	fmt.Printf("Git commit:%s\n", saashard.Version)
 	fmt.Printf("Build time:%s\n", saashard.Compile)
@@ -120,6 +123,7 @@ func main() {
 		syscall.SIGTERM,
 		syscall.SIGQUIT,
 		syscall.SIGPIPE,
+		syscall.SIGHUP,
 	)
 
 	go func() {
@@ -131,6 +135,9 @@ func main() {
 			} else if sig == syscall.SIGPIPE {
 				simplelog.Info("%s %s %s", "main", "main", "Ignore broken pipe signal")
 				signal.Ignore(sig)
+			} else if sig == syscall.SIGHUP {
+				simplelog.Info("%s %s %s signal=%s", "main", "main", "Reload config", sig)
+				svr.Reload()
 			}
 		}
 	}()
@@ -0,0 +1,94 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// sscompat reports sql compatibility of application with shard config of saashard, without live backends.
+// Statements are read from mysql general log or plain sql file, and categorized as single_shard, scatter,
+// rejected or parse_error, so that migration effort can be estimated.
+//
+//	sscompat -config ss.yaml -schema db1 -file general.log [-detail]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/route"
+)
+
+var (
+	configFile = flag.String("config", "/opt/saashard/conf/ss.yaml", "saashard config file")
+	schemaName = flag.String("schema", "", "schema to route statements, may be changed by 'use db'")
+	sqlFile    = flag.String("file", "", "mysql general log or plain sql file, read from stdin if empty")
+	detail     = flag.Bool("detail", false, "print category of each statement")
+)
+
+func main() {
+	flag.Parse()
+	if len(*schemaName) == 0 {
+		fmt.Fprintln(os.Stderr, "must set schema")
+		os.Exit(2)
+	}
+
+	cfg, err := config.ParseConfigFile(*configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "parse config file error:%v\n", err.Error())
+		os.Exit(1)
+	}
+
+	in := os.Stdin
+	if len(*sqlFile) > 0 {
+		if in, err = os.Open(*sqlFile); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		defer in.Close()
+	}
+	sqls, err := route.ReadSQLLog(in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	decisions, err := route.RouteStatements(cfg, *schemaName, sqls)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	if *detail {
+		for i, d := range decisions {
+			fmt.Printf("%d\t%s\t%s\t%s\t%s\n", i+1, d.Category(), d.Schema, d, strings.Replace(d.SQL, "\n", " ", -1))
+		}
+		fmt.Println()
+	}
+	fmt.Printf("%-14s %8s %8s\n", "category", "count", "percent")
+	for _, s := range route.SummarizeCompat(decisions) {
+		var percent float64
+		if len(decisions) > 0 {
+			percent = float64(s.Count) * 100 / float64(len(decisions))
+		}
+		fmt.Printf("%-14s %8d %7.2f%%\n", s.Category, s.Count, percent)
+	}
+	fmt.Printf("%-14s %8d\n", "total", len(decisions))
+}
@@ -36,6 +36,8 @@ type Config struct {
 	BindIP         string   `yaml:"bind_ip"`
 	ProxyPort      int      `yaml:"proxy_port"`
 	AdminPort      int      `yaml:"admin_port"`
+	AdminUser      string   `yaml:"admin_user"`
+	AdminPassword  string   `yaml:"admin_password"`
 	LogPath        string   `yaml:"log_path"`
 	LogLevel       string   `yaml:"log_level"`
 	LogSQL         string   `yaml:"log_sql"`
@@ -43,12 +45,95 @@ type Config struct {
 	AllowIps       []string `yaml:"allow_ips"`
 	Charset        string   `yaml:"charset"`
 	AllowKillQuery bool     `yaml:"allow_kill_query"`
+	// ServerID identify proxy in UUID_SHORT() and ids of snowflake sequence, should be unique among proxies, 0 ~ 255.
+	ServerID int `yaml:"server_id"`
+	// AdminSecondaryPassword is also accepted for admin user in password rotation, empty is disabled.
+	AdminSecondaryPassword string `yaml:"admin_secondary_password"`
+
+	// SchemaDriftCheckTime is time 'HH:MM' to check schema drift across shards everyday, empty is disabled.
+	SchemaDriftCheckTime string `yaml:"schema_drift_check_time"`
+	// SchemaDriftWebhook is url to post report as json if drifted, optional.
+	SchemaDriftWebhook string `yaml:"schema_drift_webhook"`
+
+	// Webhooks to post critical events, such as node down and migration step completed.
+	Webhooks []WebhookConfig `yaml:"webhooks"`
+
+	// IdempotencyRetention is hours to keep idempotency keys of writes, default is 24.
+	IdempotencyRetention int `yaml:"idempotency_retention"`
+
+	// FrontendPollers is count of pollers to wait idle client connections, instead of a goroutine per connection.
+	// Default 0 is disabled, only supported on linux.
+	FrontendPollers int `yaml:"frontend_pollers"`
+
+	// RowPassthroughDisabled disable forwarding raw row packets of single node's result set, which needs no modification.
+	RowPassthroughDisabled bool `yaml:"row_passthrough_disabled"`
+
+	// LocalInfileDisabled refuse local file requested by backend in LOAD DATA LOCAL INFILE of unsharded schema,
+	// instead of relayed from client.
+	LocalInfileDisabled bool `yaml:"local_infile_disabled"`
+
+	// Compress advertise compressed protocol to clients, then it's used if requested by client.
+	Compress bool `yaml:"compress"`
+
+	// DrainTimeout is seconds to wait transaction of draining session finished before closing it, default is 30.
+	DrainTimeout int `yaml:"drain_timeout"`
+
+	// StickyUsers are users whose sessions are pinned to master conn of each node, reads aren't routed to slaves,
+	// for session state proxy can't model, such as temporary tables and user variables in stored programs.
+	StickyUsers []string `yaml:"sticky_users"`
+
+	// MaxAllowedPacket is max bytes of packet from clients of proxy port, advertised as max_allowed_packet,
+	// default is 64MB. max_allowed_packet of backends should be the same or bigger.
+	MaxAllowedPacket int `yaml:"max_allowed_packet"`
+	// AdminMaxAllowedPacket is max bytes of packet from clients of admin port, default is 4MB.
+	AdminMaxAllowedPacket int `yaml:"admin_max_allowed_packet"`
+	// AdminHTTPPort is port of admin HTTP API in JSON, authenticated by admin user in basic auth, 0 is disabled.
+	AdminHTTPPort int `yaml:"admin_http_port"`
+	// PlanPinFile is file of plan pins by admin, default is plan_pins.json in log_path, pins are lost on restart if neither is set.
+	PlanPinFile string `yaml:"plan_pin_file"`
+
+	// UserReplicaRoles is role of slaves that reads of user are routed to, such as 'report_user: reporting'.
+	UserReplicaRoles map[string]string `yaml:"user_replica_roles"`
+
+	// ClientPolicy is policy of clients allowed to connect, checked in handshake.
+	ClientPolicy ClientPolicyConfig `yaml:"client_policy"`
+
+	// ParserLimits is limits of statement parsed by proxy.
+	ParserLimits ParserLimitsConfig `yaml:"parser_limits"`
+
+	// SlowLog is log of statements slower than slow_log_time in format of MySQL slow log.
+	SlowLog SlowLogConfig `yaml:"slow_log"`
+
+	// Audit is log of DML and DDL in schemas of audit enabled, for compliance.
+	Audit AuditConfig `yaml:"audit"`
+
+	// ResultCache is cache of select results in proxy, enabled by result_cache_ttl of schemas or tables.
+	ResultCache ResultCacheConfig `yaml:"result_cache"`
+
+	// TLS of client connections to proxy.
+	TLS TLSConfig `yaml:"tls"`
+
+	// XA is config of distributed transaction.
+	XA XAConfig `yaml:"xa"`
+
+	// Directory is lookup table of shard key to data node, in directory shard algorithm.
+	Directory DirectoryConfig `yaml:"directory"`
+	// GlobalIndex is index table of global_indexes of sharded tables, value of column to data nodes of rows.
+	GlobalIndex GlobalIndexConfig `yaml:"global_index"`
+	// Sequence is generator of ids of auto_increment columns of tables, unique across nodes.
+	Sequence SequenceConfig `yaml:"sequence"`
 
 	Hosts   []HostConfig   `yaml:"hosts"`
 	Nodes   []NodeConfig   `yaml:"nodes"`
 	Schemas []SchemaConfig `yaml:"schemas"`
 
-	nodes map[string]*NodeConfig
+	nodes    map[string]*NodeConfig
+	fileName string
+}
+
+// FileName is file config parsed from, empty if parsed from data.
+func (config *Config) FileName() string {
+	return config.fileName
 }
 
 // GetNodes from Nodes
@@ -65,6 +150,243 @@ func (config *Config) GetNodes() map[string]*NodeConfig {
 	return config.nodes
 }
 
+// Reload get config applied by reload from newConfig, such as hosts, nodes, schemas and users,
+// others need restart are kept, such as ports, TLS, XA, directory and global index.
+func (config *Config) Reload(newConfig *Config) *Config {
+	reloaded := *config
+	reloaded.LogSQL = newConfig.LogSQL
+	reloaded.SlowLogTime = newConfig.SlowLogTime
+	reloaded.AllowIps = newConfig.AllowIps
+	reloaded.AllowKillQuery = newConfig.AllowKillQuery
+	reloaded.IdempotencyRetention = newConfig.IdempotencyRetention
+	reloaded.RowPassthroughDisabled = newConfig.RowPassthroughDisabled
+	reloaded.LocalInfileDisabled = newConfig.LocalInfileDisabled
+	reloaded.Compress = newConfig.Compress
+	reloaded.DrainTimeout = newConfig.DrainTimeout
+	reloaded.StickyUsers = newConfig.StickyUsers
+	reloaded.MaxAllowedPacket = newConfig.MaxAllowedPacket
+	reloaded.UserReplicaRoles = newConfig.UserReplicaRoles
+	reloaded.SchemaDriftCheckTime = newConfig.SchemaDriftCheckTime
+	reloaded.SchemaDriftWebhook = newConfig.SchemaDriftWebhook
+	reloaded.Hosts = newConfig.Hosts
+	reloaded.Nodes = newConfig.Nodes
+	reloaded.Schemas = newConfig.Schemas
+	reloaded.nodes = nil
+	reloaded.GetNodes()
+	return &reloaded
+}
+
+// IsStickyUser is true if sessions of user are pinned to master conns.
+func (config *Config) IsStickyUser(user string) bool {
+	for _, stickyUser := range config.StickyUsers {
+		if stickyUser == user {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientPolicyConfig is policy of clients allowed to connect, checked in handshake before auth.
+type ClientPolicyConfig struct {
+	// RequiredCapabilities are capability flags client must advertise, such as [plugin_auth, connect_attrs].
+	RequiredCapabilities []string `yaml:"required_capabilities"`
+	// RejectOldAuth reject clients of pre-4.1 auth, or with auth plugin mysql_old_password or mysql_clear_password.
+	RejectOldAuth bool `yaml:"reject_old_auth"`
+	// DeniedClients are patterns of connection attributes to deny clients, such as '_client_version=5.1.*'.
+	DeniedClients []string `yaml:"denied_clients"`
+}
+
+// SlowLogConfig is config of slow log, statements slower than slow_log_time are written in format of MySQL slow log,
+// with sql rewritten and backend of each data node.
+type SlowLogConfig struct {
+	// Output of slow log [file|syslog], empty is disabled.
+	Output string `yaml:"output"`
+	// File of file output, default is slow.log in log_path.
+	File string `yaml:"file"`
+	// SyslogTag of syslog output, default is saashard.
+	SyslogTag string `yaml:"syslog_tag"`
+}
+
+// ResultCacheConfig is config of result cache, shared by all schemas.
+type ResultCacheConfig struct {
+	// Size is max bytes of cached results, least recently used ones are evicted, default is 64MB.
+	Size int64 `yaml:"size"`
+	// MaxEntrySize is max bytes of a cached result, larger ones are not cached, default is 1MB.
+	MaxEntrySize int64 `yaml:"max_entry_size"`
+}
+
+// AuditConfig is config of audit log, events are written to sink asynchronously.
+type AuditConfig struct {
+	// Sink of audit log [file|syslog|kafka], or registered by audit.RegisterSink, empty is disabled.
+	Sink string `yaml:"sink"`
+	// File of file sink, default is audit.log in log_path.
+	File string `yaml:"file"`
+	// SyslogTag of syslog sink, default is saashard-audit.
+	SyslogTag string `yaml:"syslog_tag"`
+	// Kafka is config of kafka sink.
+	Kafka AuditKafkaConfig `yaml:"kafka"`
+	// QueueSize is max count of events waiting to write, new events are dropped if full, default is 10000.
+	QueueSize int `yaml:"queue_size"`
+}
+
+// AuditKafkaConfig is config of kafka sink of audit log, events are produced to a partition of topic,
+// acknowledged by all in-sync replicas.
+type AuditKafkaConfig struct {
+	// Brokers are addresses of bootstrap brokers, leader of the partition is found by metadata of them.
+	Brokers []string `yaml:"brokers"`
+	Topic   string   `yaml:"topic"`
+	// Partition of topic, default is 0.
+	Partition int `yaml:"partition"`
+	// Timeout of request in milliseconds, default is 5000.
+	Timeout int `yaml:"timeout"`
+}
+
+// ParserLimitsConfig is limits of statement parsed by proxy, 0 is unlimited.
+type ParserLimitsConfig struct {
+	// MaxStatementLength is max bytes of statement.
+	MaxStatementLength int `yaml:"max_statement_length"`
+	// MaxInListItems is max items of IN list.
+	MaxInListItems int `yaml:"max_in_list_items"`
+	// MaxNestingDepth is max nesting depth of parentheses, in expressions and subqueries.
+	MaxNestingDepth int `yaml:"max_nesting_depth"`
+}
+
+// XAConfig is config of distributed transaction.
+type XAConfig struct {
+	// Namespace is prefix of gtrid, to identify transactions of this proxy, should be unique per proxy.
+	Namespace string `yaml:"namespace"`
+	// LogStorage of transaction decisions [file|table], default is file.
+	LogStorage string `yaml:"log_storage"`
+	// LogFile of file storage, default is xa.log in log_path. Recovery resolves nothing without it.
+	LogFile string `yaml:"log_file"`
+	// LogMaxSize in MB of file storage to rotate and compact, default is 64.
+	LogMaxSize int `yaml:"log_max_size"`
+	// LogNode is data node of table storage, log table is created in its database.
+	LogNode string `yaml:"log_node"`
+	// LogTable of table storage, default is saashard_xa_log.
+	LogTable string `yaml:"log_table"`
+	// RecoverDisabled disable recovery of dangling prepared transactions on startup.
+	RecoverDisabled bool `yaml:"recover_disabled"`
+	// TransMode of transaction across nodes [off|xa|best_effort], default is off that transaction is in a node.
+	TransMode string `yaml:"trans_mode"`
+	// FallbackBestEffort commit branch of node by best effort if XA START is failed in xa mode, otherwise it's error.
+	FallbackBestEffort bool `yaml:"fallback_best_effort"`
+	// EnlistPolicy in xa mode [eager|lazy], default is eager that each branch is started by 'XA START'.
+	EnlistPolicy string `yaml:"enlist_policy"`
+}
+
+// DirectoryConfig is config of lookup table in directory shard algorithm, shared by schemas.
+type DirectoryConfig struct {
+	// Node is data node of lookup table, lookup table is created in its database.
+	Node string `yaml:"node"`
+	// Table of lookup, default is saashard_directory.
+	Table string `yaml:"table"`
+	// ShardMapTable keeps staged and switched shard maps in shard migration, shared by proxies,
+	// default is saashard_shard_map.
+	ShardMapTable string `yaml:"shard_map_table"`
+	// CacheTTL is seconds to cache node of shard key in memory, default is 60.
+	CacheTTL int `yaml:"cache_ttl"`
+}
+
+// GetCacheTTL get seconds to cache node of shard key, default is 60.
+func (d *DirectoryConfig) GetCacheTTL() int {
+	if d.CacheTTL <= 0 {
+		return 60
+	}
+	return d.CacheTTL
+}
+
+// GlobalIndexConfig is config of index table of global secondary indexes, shared by schemas.
+type GlobalIndexConfig struct {
+	// Node is data node of index table, index table is created in its database.
+	Node string `yaml:"node"`
+	// Table of index, default is saashard_global_index.
+	Table string `yaml:"table"`
+}
+
+// Types of sequence.
+const (
+	SequenceTypeSnowflake = "snowflake"
+	SequenceTypeSegment   = "segment"
+)
+
+// SequenceConfig is config of sequences generating ids of auto_increment columns and SAASHARD_NEXTVAL('name').
+type SequenceConfig struct {
+	// Type of sequence [snowflake|segment], default is snowflake, ids of which are unique by server_id of proxies.
+	Type string `yaml:"type"`
+	// Node is data node of sequence table in segment type, segments of ids are allocated from it.
+	Node string `yaml:"node"`
+	// Table of sequences in segment type, default is saashard_sequence.
+	Table string `yaml:"table"`
+	// Step is ids of a segment allocated at once, default is 1000.
+	Step int `yaml:"step"`
+}
+
+// GetType get type of sequence, default is snowflake.
+func (s *SequenceConfig) GetType() string {
+	if len(s.Type) == 0 {
+		return SequenceTypeSnowflake
+	}
+	return strings.ToLower(s.Type)
+}
+
+// GetStep get ids of a segment, default is 1000.
+func (s *SequenceConfig) GetStep() int {
+	if s.Step <= 0 {
+		return 1000
+	}
+	return s.Step
+}
+
+// Modes of transaction across nodes.
+const (
+	TransModeOff        = "off"
+	TransModeXA         = "xa"
+	TransModeBestEffort = "best_effort"
+)
+
+// Policies of enlisting branch in xa mode.
+const (
+	EnlistPolicyEager = "eager"
+	EnlistPolicyLazy  = "lazy"
+)
+
+// DefaultXANamespace is default prefix of gtrid.
+const DefaultXANamespace = "saashard"
+
+// GetNamespace get prefix of gtrid.
+func (xa *XAConfig) GetNamespace() string {
+	if len(xa.Namespace) == 0 {
+		return DefaultXANamespace
+	}
+	return xa.Namespace
+}
+
+// GetLogStorage get storage of transaction log.
+func (xa *XAConfig) GetLogStorage() string {
+	if len(xa.LogStorage) == 0 {
+		return "file"
+	}
+	return strings.ToLower(xa.LogStorage)
+}
+
+// GetTransMode get mode of transaction across nodes.
+func (xa *XAConfig) GetTransMode() string {
+	switch mode := strings.ToLower(xa.TransMode); mode {
+	case TransModeXA, TransModeBestEffort:
+		return mode
+	}
+	return TransModeOff
+}
+
+// GetEnlistPolicy get policy of enlisting branch in xa mode.
+func (xa *XAConfig) GetEnlistPolicy() string {
+	if strings.ToLower(xa.EnlistPolicy) == EnlistPolicyLazy {
+		return EnlistPolicyLazy
+	}
+	return EnlistPolicyEager
+}
+
 // HostConfig is a config of data host.
 type HostConfig struct {
 	Name             string   `yaml:"name"`
@@ -75,6 +397,118 @@ type HostConfig struct {
 	Password         string   `yaml:"password"`
 	Master           string   `yaml:"master"`
 	Slaves           []string `yaml:"slaves"`
+
+	// Limit concurrent queries of each mysql server, independent of max_conn_num.
+	MaxConcurrentQueries int `yaml:"max_concurrent_queries"`
+	MaxQueueSize         int `yaml:"max_queue_size"`
+	QueueTimeout         int `yaml:"queue_timeout"` // ms
+
+	// TLS of connections to mysql servers of host.
+	TLS BackendTLSConfig `yaml:"tls"`
+	// Compress connections to mysql servers of host by compressed protocol, if it's supported by server.
+	Compress bool `yaml:"compress"`
+
+	// Balance is policy to choose slave for reads [round_robin|least_conn|latency], default is round_robin by weight.
+	Balance string `yaml:"balance"`
+
+	// Pool of connections to each mysql server, max_conn_num is max opened.
+	MaxIdleConns      int `yaml:"max_idle_conns"`
+	MinIdleConns      int `yaml:"min_idle_conns"`
+	ConnMaxLifetime   int `yaml:"conn_max_lifetime"` // s
+	ConnIdleTimeout   int `yaml:"conn_idle_timeout"` // s
+	ConnWaitQueueSize int `yaml:"conn_wait_queue_size"`
+	ConnWaitTimeout   int `yaml:"conn_wait_timeout"` // ms
+
+	// FailureThreshold is count of failed pings in a row to mark mysql server down.
+	FailureThreshold int `yaml:"failure_threshold"`
+	// Failover of master when it's down.
+	Failover FailoverConfig `yaml:"failover"`
+
+	// DelayedSlaves are slaves delayed intentionally for disaster recovery, excluded from reads and failover.
+	// Sessions are routed to them by admin for data rescue.
+	DelayedSlaves []string `yaml:"delayed_slaves"`
+	// SlaveRoles are slaves tagged by role, such as reporting and backup. They serve only reads of the role,
+	// and are excluded from other reads and failover.
+	SlaveRoles map[string][]string `yaml:"slave_roles"`
+}
+
+// FailoverConfig is a config of master failover of data host.
+type FailoverConfig struct {
+	// Candidate is address of slave promoted to master when master is down, no promotion if empty.
+	// The most up-to-date slave by gtid executed is promoted if it's auto.
+	Candidate string `yaml:"candidate"`
+	// TopologyFile has address of master maintained by external tool, followed instead of promoting candidate.
+	TopologyFile string `yaml:"topology_file"`
+	// Failback to master configured once it recovers [manual|immediate|healthy], default is manual.
+	Failback string `yaml:"failback"`
+	// FailbackAfter is minutes of master configured healthy before failed back, for failback healthy.
+	FailbackAfter int `yaml:"failback_after"`
+	// FailbackCatchUp is seconds to wait for master configured to catch up with current master when failed back,
+	// default is 5.
+	FailbackCatchUp int `yaml:"failback_catch_up"`
+	// RequireNoDataLoss refuse to promote candidate if data loss is possible, that master was not semi-sync
+	// or candidate misses transactions known, it must be failed over by admin then.
+	RequireNoDataLoss bool `yaml:"require_no_data_loss"`
+}
+
+// FailoverCandidateAuto choose the most up-to-date slave as candidate.
+const FailoverCandidateAuto = "auto"
+
+// Failback policies of master.
+const (
+	// FailbackManual fail back by admin only, it's default.
+	FailbackManual = "manual"
+	// FailbackImmediate fail back once master configured is up.
+	FailbackImmediate = "immediate"
+	// FailbackHealthy fail back after master configured is up for failback_after minutes.
+	FailbackHealthy = "healthy"
+)
+
+// GetFailback get failback policy.
+func (failoverCfg *FailoverConfig) GetFailback() string {
+	switch failback := strings.ToLower(failoverCfg.Failback); failback {
+	case FailbackImmediate, FailbackHealthy:
+		return failback
+	}
+	return FailbackManual
+}
+
+// DefaultFailbackCatchUp is default seconds to wait for master configured to catch up when failed back.
+const DefaultFailbackCatchUp = 5
+
+// GetFailbackCatchUp get seconds to wait for master configured to catch up when failed back.
+func (failoverCfg *FailoverConfig) GetFailbackCatchUp() int {
+	if failoverCfg.FailbackCatchUp <= 0 {
+		return DefaultFailbackCatchUp
+	}
+	return failoverCfg.FailbackCatchUp
+}
+
+// Policies of choosing slave for reads.
+const (
+	BalanceRoundRobin = "round_robin"
+	BalanceLeastConn  = "least_conn"
+	BalanceLatency    = "latency"
+)
+
+// GetBalance get policy of choosing slave for reads.
+func (hostCfg *HostConfig) GetBalance() string {
+	switch balance := strings.ToLower(hostCfg.Balance); balance {
+	case BalanceLeastConn, BalanceLatency:
+		return balance
+	}
+	return BalanceRoundRobin
+}
+
+// WebhookConfig is a config of webhook to alert.
+type WebhookConfig struct {
+	URL string `yaml:"url"`
+	// Format [json|slack|pagerduty], default is json.
+	Format string `yaml:"format"`
+	// RoutingKey is integration key of pagerduty.
+	RoutingKey string `yaml:"routing_key"`
+	// Events subscribed, default is all.
+	Events []string `yaml:"events"`
 }
 
 // NodeConfig is a config of data node.
@@ -95,10 +529,52 @@ type SchemaConfig struct {
 	Nodes              []string      `yaml:"nodes"`
 	CheckTableDisabled bool          `yaml:"check_table_disabled"`
 	Tables             []TableConfig `yaml:"tables"`
+	// DualWrite mode in shard migration [off|sync|async], default is off.
+	DualWrite string `yaml:"dual_write"`
+	// DarkReadRate is sample rate of reads mirrored to the other location in shard migration, 0 ~ 1.
+	DarkReadRate float64 `yaml:"dark_read_rate"`
+	// GreenNodes is green group of blue/green deployment, nodes is blue group.
+	// Node of green group replaces node of blue group at the same position when switched.
+	GreenNodes []string `yaml:"green_nodes"`
+	// CalcFoundRows is how to get FOUND_ROWS() of select with SQL_CALC_FOUND_ROWS [keep|count], default is keep.
+	CalcFoundRows string `yaml:"calc_found_rows"`
+	// SecondaryPassword is also accepted in password rotation, as dual password of MySQL 8, empty is disabled.
+	SecondaryPassword string `yaml:"secondary_password"`
+	// InListChunkSize is max values of IN list of shard key in each statement sent to node, 0 is unlimited.
+	InListChunkSize int `yaml:"in_list_chunk_size"`
+	// InListTempTableThreshold is max values of IN list sent to node as it is, IN list of more values is rewritten
+	// to subquery of a temporary table filled with the values in backend, 0 is disabled.
+	InListTempTableThreshold int `yaml:"in_list_temp_table_threshold"`
+	// MultiGet coalesces point selects by shard key in a multi-statement query into per-node IN queries.
+	MultiGet bool `yaml:"multi_get"`
+	// ShardReplicas is virtual nodes of each data node in consistent_hash shard algorithm, default is 160.
+	ShardReplicas int `yaml:"shard_replicas"`
+	// ShardRanges is ranges of shard key in data nodes, in range shard algorithm.
+	ShardRanges []ShardRangeConfig `yaml:"shard_ranges"`
+	// SQLMode is default sql_mode of sessions in schema, set to backend conns, empty is default of server.
+	SQLMode string `yaml:"sql_mode"`
+	// RequiredSQLModes are modes that sessions can't remove from sql_mode, such as STRICT_TRANS_TABLES.
+	RequiredSQLModes []string `yaml:"required_sql_modes"`
+	// Audit writes DML and DDL of schema to audit log, with user, client ip, value of shard key and nodes.
+	Audit bool `yaml:"audit"`
+	// ResultCacheTTL is milliseconds that results of select are cached in proxy, 0 is disabled.
+	ResultCacheTTL int `yaml:"result_cache_ttl"`
+	// ForeignKeyPolicy is policy of foreign keys in DDL of sharded schema [co_located|strip|reject], default is co_located.
+	ForeignKeyPolicy string `yaml:"foreign_key_policy"`
+	// UniqueKeyPolicy is policy of unique keys without shard key in DDL of sharded tables [warn|reject], default is warn.
+	UniqueKeyPolicy string `yaml:"unique_key_policy"`
 
 	tables map[string]*TableConfig
 }
 
+// ShardRangeConfig is range of shard key in a data node, [Min, Max), empty is unbounded.
+// Compare as integer if both are integers, or compare as string, such as date '2024-01-01'.
+type ShardRangeConfig struct {
+	Node string `yaml:"node"`
+	Min  string `yaml:"min"`
+	Max  string `yaml:"max"`
+}
+
 // GetTables from Tables
 func (schema *SchemaConfig) GetTables() map[string]*TableConfig {
 	if len(schema.tables) == 0 && len(schema.Tables) > 0 {
@@ -113,14 +589,181 @@ func (schema *SchemaConfig) GetTables() map[string]*TableConfig {
 	return schema.tables
 }
 
+// GetSQLMode get default sql_mode of sessions, normalized as modes in upper case separated by comma.
+func (schema *SchemaConfig) GetSQLMode() string {
+	return NormalizeSQLMode(schema.SQLMode)
+}
+
+// MissingSQLModes get required modes missing in sql_mode.
+func (schema *SchemaConfig) MissingSQLModes(sqlMode string) []string {
+	modes := make(map[string]bool)
+	for _, mode := range strings.Split(NormalizeSQLMode(sqlMode), ",") {
+		modes[mode] = true
+	}
+	var missing []string
+	for _, mode := range schema.RequiredSQLModes {
+		if mode = NormalizeSQLMode(mode); !modes[mode] {
+			missing = append(missing, mode)
+		}
+	}
+	return missing
+}
+
+// NormalizeSQLMode normalize sql_mode as modes in upper case separated by comma.
+func NormalizeSQLMode(sqlMode string) string {
+	var modes []string
+	for _, mode := range strings.Split(sqlMode, ",") {
+		if mode = strings.ToUpper(strings.TrimSpace(mode)); len(mode) > 0 {
+			modes = append(modes, mode)
+		}
+	}
+	return strings.Join(modes, ",")
+}
+
+// GetShardKey get column that rows of table are routed by, join key of child table, or shard key of schema.
+func (schema *SchemaConfig) GetShardKey(tableName string) string {
+	if table := schema.GetTables()[tableName]; table != nil && table.GetType() == TableTypeSharded {
+		if joinKey := table.GetJoinKey(schema.ShardKey); len(joinKey) > 0 {
+			return joinKey
+		}
+	}
+	return schema.ShardKey
+}
+
 // ShardEnabled to use shard func.
 func (schema *SchemaConfig) ShardEnabled() bool {
 	return schema.ShardKey != ""
 }
 
+// Dual-write modes in shard migration.
+const (
+	// DualWriteOff not write to staged shard location, it's default.
+	DualWriteOff = "off"
+	// DualWriteSync write to staged shard location in client session, after write to current location.
+	DualWriteSync = "sync"
+	// DualWriteAsync write to staged shard location in background.
+	DualWriteAsync = "async"
+)
+
+// GetDualWrite get dual-write mode, default is off.
+func (schema *SchemaConfig) GetDualWrite() string {
+	switch strings.ToLower(schema.DualWrite) {
+	case DualWriteSync:
+		return DualWriteSync
+	case DualWriteAsync:
+		return DualWriteAsync
+	default:
+		return DualWriteOff
+	}
+}
+
+// Ways to get FOUND_ROWS() of select with SQL_CALC_FOUND_ROWS.
+const (
+	// CalcFoundRowsKeep pass the option to backend, and get FOUND_ROWS() after select, it's default.
+	CalcFoundRowsKeep = "keep"
+	// CalcFoundRowsCount remove the option, and count rows by a separate COUNT(*) query without limit.
+	CalcFoundRowsCount = "count"
+)
+
+// GetCalcFoundRows get way to get FOUND_ROWS() of select with SQL_CALC_FOUND_ROWS, default is keep.
+func (schema *SchemaConfig) GetCalcFoundRows() string {
+	if strings.ToLower(schema.CalcFoundRows) == CalcFoundRowsCount {
+		return CalcFoundRowsCount
+	}
+	return CalcFoundRowsKeep
+}
+
+// Policies of foreign keys in DDL of sharded schema.
+const (
+	// ForeignKeyCoLocated allow foreign key whose referenced rows are in the same node, reject others, it's default.
+	ForeignKeyCoLocated = "co_located"
+	// ForeignKeyStrip strip foreign key whose referenced rows may be in other node, with warning logged.
+	ForeignKeyStrip = "strip"
+	// ForeignKeyReject reject all foreign keys.
+	ForeignKeyReject = "reject"
+)
+
+// GetForeignKeyPolicy get policy of foreign keys in DDL, default is co_located.
+func (schema *SchemaConfig) GetForeignKeyPolicy() string {
+	switch strings.ToLower(schema.ForeignKeyPolicy) {
+	case ForeignKeyStrip:
+		return ForeignKeyStrip
+	case ForeignKeyReject:
+		return ForeignKeyReject
+	default:
+		return ForeignKeyCoLocated
+	}
+}
+
+// Policies of unique keys without shard key in DDL of sharded tables, as uniqueness is only checked in each node.
+const (
+	// UniqueKeyWarn allow unique key without shard key, with warning logged, it's default.
+	UniqueKeyWarn = "warn"
+	// UniqueKeyReject reject unique key without shard key.
+	UniqueKeyReject = "reject"
+)
+
+// GetUniqueKeyPolicy get policy of unique keys without shard key in DDL, default is warn.
+func (schema *SchemaConfig) GetUniqueKeyPolicy() string {
+	if strings.ToLower(schema.UniqueKeyPolicy) == UniqueKeyReject {
+		return UniqueKeyReject
+	}
+	return UniqueKeyWarn
+}
+
+// Table types in sharded schema.
+const (
+	// TableTypeSharded table is sharded by schema's shard key, it's default.
+	TableTypeSharded = "sharded"
+	// TableTypeSingle table is only in one node, not sharded.
+	TableTypeSingle = "single"
+	// TableTypeGlobal table has same data in all nodes, read from any node and write to all nodes.
+	TableTypeGlobal = "global"
+)
+
 // TableConfig is a config of table
 type TableConfig struct {
 	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	// Node is data node of single table, default is first node of schema.
+	Node string `yaml:"node"`
+	// VersionColumn is increased by UPDATE for optimistic concurrency, and checked if expected version is in hint.
+	VersionColumn string `yaml:"version_column"`
+	// ResultCacheTTL is milliseconds that results of select of table are cached, 0 is of schema, negative is disabled.
+	ResultCacheTTL int `yaml:"result_cache_ttl"`
+	// GlobalIndexes are columns of sharded table indexed in global index, to route queries on them to nodes of rows.
+	GlobalIndexes []string `yaml:"global_indexes"`
+	// Parent is sharded table that rows of this child table are in the node of, such as orders of order_items,
+	// so that they're joined in a node by join key of child and shard key of parent.
+	Parent string `yaml:"parent"`
+	// JoinKey is column of child table referencing shard key of parent, child is routed by it, default is shard key.
+	JoinKey string `yaml:"join_key"`
+	// AutoIncrement is column filled with id of sequence 'schema.table' by proxy, if omitted or NULL in INSERT and REPLACE,
+	// instead of AUTO_INCREMENT of each node.
+	AutoIncrement string `yaml:"auto_increment"`
+}
+
+// GetJoinKey get column of child table referencing shard key of parent in lower case, empty if not a child.
+func (table *TableConfig) GetJoinKey(shardKey string) string {
+	if len(table.Parent) == 0 {
+		return ""
+	}
+	if joinKey := strings.Trim(strings.ToLower(table.JoinKey), "`"); len(joinKey) > 0 {
+		return joinKey
+	}
+	return shardKey
+}
+
+// GetType get table type, default is sharded.
+func (table *TableConfig) GetType() string {
+	switch strings.ToLower(table.Type) {
+	case TableTypeSingle:
+		return TableTypeSingle
+	case TableTypeGlobal:
+		return TableTypeGlobal
+	default:
+		return TableTypeSharded
+	}
 }
 
 // ParseConfigData is to parse config data.
@@ -129,6 +772,10 @@ func ParseConfigData(data []byte) (*Config, error) {
 	if err := yaml.Unmarshal([]byte(data), &cfg); err != nil {
 		return nil, err
 	}
+	// server id is truncated to a byte in UUID_SHORT(), so out of range ids collide.
+	if cfg.ServerID < 0 || cfg.ServerID > 255 {
+		return nil, fmt.Errorf("server_id %d should be 0 ~ 255", cfg.ServerID)
+	}
 
 	// parse nodes
 	newNodes := make([]NodeConfig, 0, len(cfg.Nodes))
@@ -168,32 +815,11 @@ func ParseConfigData(data []byte) (*Config, error) {
 	newSchemas := make([]SchemaConfig, 0, len(cfg.Schemas))
 	for i := range cfg.Schemas {
 		originalSchema := cfg.Schemas[i]
-		newNodeNames := make([]string, 0, len(originalSchema.Nodes))
-		for j := range originalSchema.Nodes {
-			nodeName := originalSchema.Nodes[j]
-			nodeNameLen := len(nodeName)
-			splitCharIndex := strings.LastIndex(nodeName, "$")
-			if splitCharIndex > 0 && splitCharIndex < nodeNameLen-3 {
-				seqStr := nodeName[splitCharIndex+1 : nodeNameLen]
-				nodeNamePrefix := nodeName[:splitCharIndex]
-				seqArr := strings.Split(seqStr, "-")
-				if len(seqArr) == 2 {
-					startSeq, err1 := strconv.Atoi(seqArr[0])
-					endSeq, err2 := strconv.Atoi(seqArr[1])
-					if err1 == nil && err2 == nil &&
-						startSeq >= 0 && endSeq > startSeq && endSeq < 100 {
-						for seq := startSeq; seq <= endSeq; seq++ {
-							newNodeName := fmt.Sprintf("%s%d", nodeNamePrefix, seq)
-							newNodeNames = append(newNodeNames, newNodeName)
-						}
-					}
-				}
-			} else {
-				newNodeNames = append(newNodeNames, nodeName)
-			}
-		}
 		newSchema := originalSchema
-		newSchema.Nodes = newNodeNames
+		newSchema.Nodes = expandNodeNames(originalSchema.Nodes)
+		if len(originalSchema.GreenNodes) > 0 {
+			newSchema.GreenNodes = expandNodeNames(originalSchema.GreenNodes)
+		}
 		newSchemas = append(newSchemas, newSchema)
 	}
 	cfg.Schemas = newSchemas
@@ -202,6 +828,35 @@ func ParseConfigData(data []byte) (*Config, error) {
 	return &cfg, nil
 }
 
+// expandNodeNames expand scope config of node names, 'node$0-99' mean node0,node1, ... node99.
+func expandNodeNames(nodeNames []string) []string {
+	newNodeNames := make([]string, 0, len(nodeNames))
+	for j := range nodeNames {
+		nodeName := nodeNames[j]
+		nodeNameLen := len(nodeName)
+		splitCharIndex := strings.LastIndex(nodeName, "$")
+		if splitCharIndex > 0 && splitCharIndex < nodeNameLen-3 {
+			seqStr := nodeName[splitCharIndex+1 : nodeNameLen]
+			nodeNamePrefix := nodeName[:splitCharIndex]
+			seqArr := strings.Split(seqStr, "-")
+			if len(seqArr) == 2 {
+				startSeq, err1 := strconv.Atoi(seqArr[0])
+				endSeq, err2 := strconv.Atoi(seqArr[1])
+				if err1 == nil && err2 == nil &&
+					startSeq >= 0 && endSeq > startSeq && endSeq < 100 {
+					for seq := startSeq; seq <= endSeq; seq++ {
+						newNodeName := fmt.Sprintf("%s%d", nodeNamePrefix, seq)
+						newNodeNames = append(newNodeNames, newNodeName)
+					}
+				}
+			}
+		} else {
+			newNodeNames = append(newNodeNames, nodeName)
+		}
+	}
+	return newNodeNames
+}
+
 // ParseConfigFile is to parse config file.
 func ParseConfigFile(fileName string) (*Config, error) {
 	data, err := ioutil.ReadFile(fileName)
@@ -209,7 +864,12 @@ func ParseConfigFile(fileName string) (*Config, error) {
 		return nil, err
 	}
 
-	return ParseConfigData(data)
+	cfg, err := ParseConfigData(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg.fileName = fileName
+	return cfg, nil
 }
 
 // WriteConfigFile is to write to config file.
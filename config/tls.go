@@ -0,0 +1,141 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+)
+
+// TLSConfig is TLS of client connections to proxy, SSL is requested by client in handshake.
+type TLSConfig struct {
+	// Cert and Key are certificate of proxy in PEM, TLS is disabled if empty.
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+	// CA to verify certificates of clients, client certificate is not required if empty.
+	CA string `yaml:"ca"`
+	// Required rejects clients not connected by TLS.
+	Required bool `yaml:"required"`
+}
+
+// Enabled is true if certificate is configured.
+func (c *TLSConfig) Enabled() bool {
+	return len(c.Cert) > 0 && len(c.Key) > 0
+}
+
+// BackendTLSConfig is TLS of connections from proxy to mysql servers of host.
+type BackendTLSConfig struct {
+	// Mode [disabled|preferred|required], default is disabled. preferred uses TLS if supported by mysql server.
+	Mode string `yaml:"mode"`
+	// CA to verify certificate of mysql server, not verified if empty.
+	CA string `yaml:"ca"`
+	// Cert and Key are client certificate in PEM, if required by mysql server.
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+	// ServerName to verify certificate of mysql server, host of address if empty.
+	ServerName string `yaml:"server_name"`
+}
+
+// Backend TLS modes.
+const (
+	// BackendTLSDisabled not use TLS, it's default.
+	BackendTLSDisabled = "disabled"
+	// BackendTLSPreferred use TLS if supported by mysql server.
+	BackendTLSPreferred = "preferred"
+	// BackendTLSRequired fail to connect if TLS is not supported by mysql server.
+	BackendTLSRequired = "required"
+)
+
+// GetMode get TLS mode, default is disabled.
+func (c *BackendTLSConfig) GetMode() string {
+	switch strings.ToLower(c.Mode) {
+	case BackendTLSPreferred:
+		return BackendTLSPreferred
+	case BackendTLSRequired:
+		return BackendTLSRequired
+	default:
+		return BackendTLSDisabled
+	}
+}
+
+// ServerConfig load certificates to TLS config of proxy.
+func (c *TLSConfig) ServerConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if len(c.CA) > 0 {
+		if tlsConfig.ClientCAs, err = loadCertPool(c.CA); err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// ClientConfig load certificates to TLS config of connections to mysql server of addr, nil if disabled.
+func (c *BackendTLSConfig) ClientConfig(addr string) (*tls.Config, error) {
+	if c.GetMode() == BackendTLSDisabled {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{ServerName: c.ServerName, MinVersion: tls.VersionTLS12}
+	if len(tlsConfig.ServerName) == 0 {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			tlsConfig.ServerName = host
+		}
+	}
+	var err error
+	if len(c.CA) > 0 {
+		if tlsConfig.RootCAs, err = loadCertPool(c.CA); err != nil {
+			return nil, err
+		}
+	} else {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if len(c.Cert) > 0 && len(c.Key) > 0 {
+		cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// loadCertPool load CA certificates in PEM file.
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificate in CA file '%s'", caFile)
+	}
+	return pool, nil
+}
@@ -0,0 +1,94 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package directory
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berkaroad/saashard/backend"
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+)
+
+// DefaultShardMapTable is default table name of shard map states.
+var DefaultShardMapTable = "saashard_shard_map"
+
+const sqlCreateShardMapTable = "CREATE TABLE IF NOT EXISTS `%s` (" +
+	"`schema_name` VARCHAR(64) NOT NULL, `state` TEXT NOT NULL, `time` BIGINT NOT NULL, " +
+	"PRIMARY KEY (`schema_name`)) ENGINE=InnoDB"
+
+// ShardMapTable is store of shard map states in mysql table of data node of directory, a row per sharded schema.
+// It's shared by proxies of the same directory, so that they route the same in shard migration.
+type ShardMapTable struct {
+	node  *backend.DataNode
+	table string
+}
+
+// OpenShardMapTable create table of shard map states in master of data node if not exists.
+func OpenShardMapTable(node *backend.DataNode, table string) (*ShardMapTable, error) {
+	t := new(ShardMapTable)
+	t.node = node
+	t.table = strings.Replace(table, "`", "", -1)
+	if len(t.table) == 0 {
+		t.table = DefaultShardMapTable
+	}
+	err := execInNode(node, func(conn *mysqlBackend.Conn) error {
+		_, err := conn.Query(fmt.Sprintf(sqlCreateShardMapTable, t.table))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Load states of shard maps by schema.
+func (t *ShardMapTable) Load() (map[string][]byte, error) {
+	states := make(map[string][]byte)
+	err := execInNode(t.node, func(conn *mysqlBackend.Conn) error {
+		result, err := conn.Query(fmt.Sprintf("SELECT `schema_name`, `state` FROM `%s`", t.table))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < result.RowNumber(); i++ {
+			schemaName, _ := result.GetString(i, 0)
+			state, _ := result.GetString(i, 1)
+			states[schemaName] = []byte(state)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// Save state of shard map of schema.
+func (t *ShardMapTable) Save(schemaName string, state []byte) error {
+	return execInNode(t.node, func(conn *mysqlBackend.Conn) error {
+		_, err := conn.Query(fmt.Sprintf("INSERT INTO `%s` (`schema_name`, `state`, `time`) VALUES (%s, %s, %d) "+
+			"ON DUPLICATE KEY UPDATE `state` = VALUES(`state`), `time` = VALUES(`time`)",
+			t.table, mysqlBackend.QuoteString(schemaName), mysqlBackend.QuoteString(string(state)), time.Now().Unix()))
+		return err
+	})
+}
@@ -0,0 +1,227 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package directory is lookup table of shard key to data node in directory shard algorithm, such as tenant to shard.
+package directory
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berkaroad/saashard/backend"
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/route"
+)
+
+// DefaultTable is default table name of lookup table.
+var DefaultTable = "saashard_directory"
+
+const sqlCreateTable = "CREATE TABLE IF NOT EXISTS `%s` (" +
+	"`schema_name` VARCHAR(64) NOT NULL, `shard_key` VARCHAR(255) NOT NULL, `node` VARCHAR(64) NOT NULL, " +
+	"`time` BIGINT NOT NULL, PRIMARY KEY (`schema_name`, `shard_key`)) ENGINE=InnoDB"
+
+type cacheEntry struct {
+	node   string
+	expire time.Time
+}
+
+// Table is lookup table of shard key to data node in mysql table of a data node, a row per shard key of schema.
+// Node of shard key is cached in memory until TTL expired, or invalidated when moved by this process.
+// Shard key not in table is assigned by hash of nodes and inserted on insert, so that it's kept when nodes changed.
+type Table struct {
+	sync.RWMutex
+	node   *backend.DataNode
+	table  string
+	ttl    time.Duration
+	cache  map[string]map[string]cacheEntry
+	purged time.Time
+}
+
+// OpenTable create lookup table in master of data node if not exists.
+func OpenTable(node *backend.DataNode, table string, ttl time.Duration) (*Table, error) {
+	t := new(Table)
+	t.node = node
+	t.table = strings.Replace(table, "`", "", -1)
+	if len(t.table) == 0 {
+		t.table = DefaultTable
+	}
+	t.ttl = ttl
+	t.cache = make(map[string]map[string]cacheEntry)
+	t.purged = time.Now()
+	err := t.exec(func(conn *mysqlBackend.Conn) error {
+		_, err := conn.Query(fmt.Sprintf(sqlCreateTable, t.table))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *Table) exec(f func(conn *mysqlBackend.Conn) error) error {
+	return execInNode(t.node, f)
+}
+
+// execInNode execute in connection of master of data node, in database of it.
+func execInNode(node *backend.DataNode, f func(conn *mysqlBackend.Conn) error) error {
+	conn, err := node.DataHost.GetMaster().GetConnection(node.Database)
+	if err != nil {
+		return err
+	}
+	defer conn.ReturnConnection()
+	mysqlConn := conn.(*mysqlBackend.Conn)
+	if err = mysqlConn.UseDB(node.Database); err != nil {
+		return err
+	}
+	return f(mysqlConn)
+}
+
+// Lookup data node of shard key in schema, from cache or lookup table.
+// Shard key not in table is assigned to one of nodes by hash on insert, or ErrNotInDirectoryShard on others,
+// such as select of unknown key doesn't add it to table. It's error if table is nil (not configured).
+func (t *Table) Lookup(schemaName string, key string, nodes []string, insert bool) (string, error) {
+	if t == nil {
+		return "", errors.ErrNoDirectoryInDirectoryShard
+	}
+	if node, ok := t.Cached(schemaName, key); ok {
+		return node, nil
+	}
+	var node string
+	err := t.exec(func(conn *mysqlBackend.Conn) error {
+		var err error
+		if node, err = t.get(conn, schemaName, key); err != nil || len(node) > 0 {
+			return err
+		}
+		if !insert {
+			return errors.ErrNotInDirectoryShard
+		}
+		if len(nodes) == 0 {
+			return errors.ErrNoDirectoryInDirectoryShard
+		}
+		index, _ := route.HashShardAlgo(key, len(nodes))
+		// ignored if inserted by other proxy, and read it again.
+		if _, err = conn.Query(fmt.Sprintf("INSERT IGNORE INTO `%s` (`schema_name`, `shard_key`, `node`, `time`) VALUES (%s, %s, %s, %d)",
+			t.table, mysqlBackend.QuoteString(schemaName), mysqlBackend.QuoteString(key), mysqlBackend.QuoteString(nodes[index]),
+			time.Now().Unix())); err != nil {
+			return err
+		}
+		node, err = t.get(conn, schemaName, key)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	t.put(schemaName, key, node)
+	return node, nil
+}
+
+// Get data node of shard key in lookup table, empty if not exists.
+func (t *Table) Get(schemaName string, key string) (string, error) {
+	var node string
+	err := t.exec(func(conn *mysqlBackend.Conn) error {
+		var err error
+		node, err = t.get(conn, schemaName, key)
+		return err
+	})
+	return node, err
+}
+
+func (t *Table) get(conn *mysqlBackend.Conn, schemaName string, key string) (string, error) {
+	result, err := conn.Query(fmt.Sprintf("SELECT `node` FROM `%s` WHERE `schema_name` = %s AND `shard_key` = %s",
+		t.table, mysqlBackend.QuoteString(schemaName), mysqlBackend.QuoteString(key)))
+	if err != nil || result.RowNumber() == 0 {
+		return "", err
+	}
+	return result.GetString(0, 0)
+}
+
+// Move shard key of schema to data node in lookup table, and invalidate cache of it.
+// Rows of shard key should be copied to the node before, other proxies see it after TTL of their cache.
+func (t *Table) Move(schemaName string, key string, node string) error {
+	err := t.exec(func(conn *mysqlBackend.Conn) error {
+		_, err := conn.Query(fmt.Sprintf("INSERT INTO `%s` (`schema_name`, `shard_key`, `node`, `time`) VALUES (%s, %s, %s, %d) "+
+			"ON DUPLICATE KEY UPDATE `node` = VALUES(`node`), `time` = VALUES(`time`)",
+			t.table, mysqlBackend.QuoteString(schemaName), mysqlBackend.QuoteString(key), mysqlBackend.QuoteString(node),
+			time.Now().Unix()))
+		return err
+	})
+	t.Invalidate(schemaName, key)
+	return err
+}
+
+// Cached get data node of shard key in cache, not expired.
+func (t *Table) Cached(schemaName string, key string) (string, bool) {
+	t.RLock()
+	entry, ok := t.cache[schemaName][key]
+	t.RUnlock()
+	if !ok || time.Now().After(entry.expire) {
+		return "", false
+	}
+	return entry.node, true
+}
+
+func (t *Table) put(schemaName string, key string, node string) {
+	now := time.Now()
+	t.Lock()
+	defer t.Unlock()
+	// remove expired entries, at most once per TTL.
+	if now.Sub(t.purged) > t.ttl {
+		for _, keys := range t.cache {
+			for k, entry := range keys {
+				if now.After(entry.expire) {
+					delete(keys, k)
+				}
+			}
+		}
+		t.purged = now
+	}
+	keys := t.cache[schemaName]
+	if keys == nil {
+		keys = make(map[string]cacheEntry)
+		t.cache[schemaName] = keys
+	}
+	keys[key] = cacheEntry{node: node, expire: now.Add(t.ttl)}
+}
+
+// Invalidate cache of shard key in schema, all keys of schema if key is empty, all schemas if schema is empty.
+// Return count of keys invalidated.
+func (t *Table) Invalidate(schemaName string, key string) int {
+	t.Lock()
+	defer t.Unlock()
+	count := 0
+	for name, keys := range t.cache {
+		if len(schemaName) > 0 && name != schemaName {
+			continue
+		}
+		if len(key) == 0 {
+			count += len(keys)
+			delete(t.cache, name)
+		} else if _, ok := keys[key]; ok {
+			count++
+			delete(keys, key)
+		}
+	}
+	return count
+}
@@ -36,6 +36,10 @@ var (
 	ErrNoDatabase    = errors.New("no database")
 	ErrNoIdleConn    = errors.New("exceed max conn num")
 
+	ErrTooManyQueries    = errors.New("exceed max concurrent queries and queue size")
+	ErrQueryQueueTimeout = errors.New("wait in query queue timeout")
+	ErrConnWaitTimeout   = errors.New("wait for connection timeout")
+
 	ErrNoDataHost = errors.New("no data host")
 	ErrNoDataNode = errors.New("no data node")
 	ErrNoSchema   = errors.New("no schema")
@@ -55,6 +59,7 @@ var (
 	ErrConnIsNil     = errors.New("connection is nil")
 	ErrBadConn       = errors.New("connection was bad")
 	ErrIgnoreSQL     = errors.New("ignore this sql")
+	ErrPingTimeout   = errors.New("ping timeout")
 
 	ErrAddressNull     = errors.New("address is nil")
 	ErrInvalidArgument = errors.New("argument is invalid")
@@ -71,6 +76,10 @@ var (
 	ErrWhereOrJoinOnKey = errors.New("no shard key or key has different values in where or join on expression")
 
 	ErrMustPositiveIntegerInModShard = errors.New("shard key must positive integer when use mod shard algorithm")
+	ErrNoRangeInRangeShard           = errors.New("shard key not in any range when use range shard algorithm")
+	ErrNoDirectoryInDirectoryShard   = errors.New("no lookup table of shard key when use directory shard algorithm")
+	ErrNotInDirectoryShard           = errors.New("shard key not in lookup table when use directory shard algorithm")
+	ErrNoGlobalIndex                 = errors.New("no index table of global index when table has global indexes")
 
 	ErrStmtConvert      = errors.New("statement fail to convert")
 	ErrExprConvert      = errors.New("expr fail to convert")
@@ -0,0 +1,188 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package globalindex is global secondary index of columns in sharded tables, value of column to data nodes of rows.
+package globalindex
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/berkaroad/saashard/backend"
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/route"
+)
+
+// DefaultTable is default table name of index table.
+var DefaultTable = "saashard_global_index"
+
+const sqlCreateTable = "CREATE TABLE IF NOT EXISTS `%s` (" +
+	"`schema_name` VARCHAR(64) NOT NULL, `table_name` VARCHAR(64) NOT NULL, `column_name` VARCHAR(64) NOT NULL, " +
+	"`value` VARCHAR(255) NOT NULL, `node` VARCHAR(64) NOT NULL, " +
+	"PRIMARY KEY (`schema_name`, `table_name`, `column_name`, `value`, `node`)) ENGINE=InnoDB"
+
+// Table is index table of values of columns to data nodes in mysql table of a data node, a row per value and node.
+// Values are not cached, as they're added by writes of all proxies.
+type Table struct {
+	node  *backend.DataNode
+	table string
+}
+
+// OpenTable create index table in master of data node if not exists.
+func OpenTable(node *backend.DataNode, table string) (*Table, error) {
+	t := new(Table)
+	t.node = node
+	t.table = strings.Replace(table, "`", "", -1)
+	if len(t.table) == 0 {
+		t.table = DefaultTable
+	}
+	err := t.exec(func(conn *mysqlBackend.Conn) error {
+		_, err := conn.Query(fmt.Sprintf(sqlCreateTable, t.table))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *Table) exec(f func(conn *mysqlBackend.Conn) error) error {
+	conn, err := t.node.DataHost.GetMaster().GetConnection(t.node.Database)
+	if err != nil {
+		return err
+	}
+	defer conn.ReturnConnection()
+	mysqlConn := conn.(*mysqlBackend.Conn)
+	if err = mysqlConn.UseDB(t.node.Database); err != nil {
+		return err
+	}
+	return f(mysqlConn)
+}
+
+// Lookup data nodes of rows whose column is any of values. It's error if table is nil (not configured).
+func (t *Table) Lookup(schemaName, tableName, column string, values []string) ([]string, error) {
+	if t == nil {
+		return nil, errors.ErrNoGlobalIndex
+	}
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = mysqlBackend.QuoteString(value)
+	}
+	var nodes []string
+	err := t.exec(func(conn *mysqlBackend.Conn) error {
+		result, err := conn.Query(fmt.Sprintf("SELECT DISTINCT `node` FROM `%s` WHERE `schema_name` = %s AND `table_name` = %s "+
+			"AND `column_name` = %s AND `value` IN (%s)",
+			t.table, mysqlBackend.QuoteString(schemaName), mysqlBackend.QuoteString(tableName), mysqlBackend.QuoteString(column),
+			strings.Join(quoted, ", ")))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < result.RowNumber(); i++ {
+			node, err := result.GetString(i, 0)
+			if err != nil {
+				return err
+			}
+			nodes = append(nodes, node)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// Add values of columns in data nodes, ignored if exists. It's error if table is nil (not configured).
+func (t *Table) Add(schemaName, tableName string, entries []route.GlobalIndexEntry) error {
+	if t == nil {
+		return errors.ErrNoGlobalIndex
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	rows := make([]string, len(entries))
+	for i, entry := range entries {
+		rows[i] = fmt.Sprintf("(%s, %s, %s, %s, %s)",
+			mysqlBackend.QuoteString(schemaName), mysqlBackend.QuoteString(tableName), mysqlBackend.QuoteString(entry.Column),
+			mysqlBackend.QuoteString(entry.Value), mysqlBackend.QuoteString(entry.Node))
+	}
+	return t.exec(func(conn *mysqlBackend.Conn) error {
+		_, err := conn.Query(fmt.Sprintf("INSERT IGNORE INTO `%s` (`schema_name`, `table_name`, `column_name`, `value`, `node`) VALUES %s",
+			t.table, strings.Join(rows, ", ")))
+		return err
+	})
+}
+
+// Entries list entries of column after value and node, ordered by value and node, at most limit.
+// It's error if table is nil (not configured).
+func (t *Table) Entries(schemaName, tableName, column string, after route.GlobalIndexEntry, limit int) ([]route.GlobalIndexEntry, error) {
+	if t == nil {
+		return nil, errors.ErrNoGlobalIndex
+	}
+	var entries []route.GlobalIndexEntry
+	err := t.exec(func(conn *mysqlBackend.Conn) error {
+		result, err := conn.Query(fmt.Sprintf("SELECT `value`, `node` FROM `%s` WHERE `schema_name` = %s AND `table_name` = %s "+
+			"AND `column_name` = %s AND (`value` > %s OR (`value` = %s AND `node` > %s)) ORDER BY `value`, `node` LIMIT %d",
+			t.table, mysqlBackend.QuoteString(schemaName), mysqlBackend.QuoteString(tableName), mysqlBackend.QuoteString(column),
+			mysqlBackend.QuoteString(after.Value), mysqlBackend.QuoteString(after.Value), mysqlBackend.QuoteString(after.Node), limit))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < result.RowNumber(); i++ {
+			entry := route.GlobalIndexEntry{Column: column}
+			if entry.Value, err = result.GetString(i, 0); err != nil {
+				return err
+			}
+			if entry.Node, err = result.GetString(i, 1); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Remove values of columns in data nodes, such as orphans whose rows not exist. It's error if table is nil (not configured).
+func (t *Table) Remove(schemaName, tableName string, entries []route.GlobalIndexEntry) error {
+	if t == nil {
+		return errors.ErrNoGlobalIndex
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	rows := make([]string, len(entries))
+	for i, entry := range entries {
+		rows[i] = fmt.Sprintf("(%s, %s, %s)", mysqlBackend.QuoteString(entry.Column), mysqlBackend.QuoteString(entry.Value),
+			mysqlBackend.QuoteString(entry.Node))
+	}
+	return t.exec(func(conn *mysqlBackend.Conn) error {
+		_, err := conn.Query(fmt.Sprintf("DELETE FROM `%s` WHERE `schema_name` = %s AND `table_name` = %s "+
+			"AND (`column_name`, `value`, `node`) IN (%s)",
+			t.table, mysqlBackend.QuoteString(schemaName), mysqlBackend.QuoteString(tableName), strings.Join(rows, ", ")))
+		return err
+	})
+}
@@ -0,0 +1,210 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mysql
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/berkaroad/saashard/errors"
+)
+
+// Auth plugins.
+const (
+	AUTH_NATIVE_PASSWORD       = "mysql_native_password"
+	AUTH_CACHING_SHA2_PASSWORD = "caching_sha2_password"
+)
+
+// Packets of auth exchange.
+const (
+	AUTH_MORE_DATA_HEADER   byte = 0x01
+	AUTH_SWITCH_HEADER      byte = 0xfe
+	CACHING_SHA2_FAST_AUTH  byte = 0x03 // fast auth success, followed by OK.
+	CACHING_SHA2_FULL_AUTH  byte = 0x04 // perform full auth, password is sent encrypted by public key or over TLS.
+	CACHING_SHA2_PUBLIC_KEY byte = 0x02 // request public key of server.
+)
+
+// CalcCachingSha2Password calc scramble of caching_sha2_password,
+// XOR(SHA256(password), SHA256(SHA256(SHA256(password)), scramble)).
+func CalcCachingSha2Password(scramble, password []byte) []byte {
+	if len(password) == 0 {
+		return nil
+	}
+
+	crypt := sha256.New()
+	crypt.Write(password)
+	stage1 := crypt.Sum(nil)
+
+	crypt.Reset()
+	crypt.Write(stage1)
+	stage1Hash := crypt.Sum(nil)
+
+	crypt.Reset()
+	crypt.Write(stage1Hash)
+	crypt.Write(scramble)
+	scrambleHash := crypt.Sum(nil)
+
+	for i := range scrambleHash {
+		scrambleHash[i] ^= stage1[i]
+	}
+	return scrambleHash
+}
+
+// calcAuthResponse calc auth response of plugin, native password is used if plugin not supported.
+func calcAuthResponse(plugin string, scramble []byte, password string) ([]byte, string) {
+	if plugin == AUTH_CACHING_SHA2_PASSWORD {
+		return CalcCachingSha2Password(scramble, []byte(password)), plugin
+	}
+	return CalcPassword(scramble, []byte(password)), AUTH_NATIVE_PASSWORD
+}
+
+// encryptPassword encrypt password by RSA public key of server, for full auth of caching_sha2_password without TLS.
+func encryptPassword(password string, scramble []byte, pemData []byte) ([]byte, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("invalid public key of server")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key of server is not RSA")
+	}
+	plain := make([]byte, len(password)+1)
+	copy(plain, password)
+	for i := range plain {
+		plain[i] ^= scramble[i%len(scramble)]
+	}
+	return rsa.EncryptOAEP(sha1.New(), rand.Reader, rsaPub, plain, nil)
+}
+
+// ReadAuthResult read result of auth handshake to server, with auth switch and more data of caching_sha2_password.
+func (p *PacketIO) ReadAuthResult(capability uint32, status *uint16, password string, salt []byte, authPlugin string) error {
+	for {
+		data, err := p.ReadPacket()
+		if err != nil {
+			return err
+		}
+		switch data[0] {
+		case OK_HEADER:
+			_, err = p.handleOKPacket(capability, status, data)
+			return err
+		case ERR_HEADER:
+			return p.handleErrorPacket(capability, data)
+		case AUTH_SWITCH_HEADER:
+			// plugin name [null terminated string], auth data.
+			end := bytes.IndexByte(data[1:], 0)
+			if end < 0 {
+				return errors.ErrMalformPacket
+			}
+			authPlugin = string(data[1 : 1+end])
+			if authPlugin != AUTH_NATIVE_PASSWORD && authPlugin != AUTH_CACHING_SHA2_PASSWORD {
+				return NewError(ER_NOT_SUPPORTED_AUTH_MODE, fmt.Sprintf("auth plugin '%s' not supported", authPlugin))
+			}
+			salt = append([]byte(nil), data[2+end:]...)
+			if n := len(salt); n > 0 && salt[n-1] == 0 {
+				salt = salt[:n-1]
+			}
+			auth, _ := calcAuthResponse(authPlugin, salt, password)
+			if err = p.WritePacket(append(make([]byte, 4, 4+len(auth)), auth...)); err != nil {
+				return err
+			}
+		case AUTH_MORE_DATA_HEADER:
+			if authPlugin != AUTH_CACHING_SHA2_PASSWORD || len(data) < 2 {
+				return errors.ErrMalformPacket
+			}
+			switch data[1] {
+			case CACHING_SHA2_FAST_AUTH:
+			case CACHING_SHA2_FULL_AUTH:
+				// password is sent in clear text over TLS, or encrypted by public key of server.
+				if p.IsTLS() {
+					data := append(make([]byte, 4, 4+len(password)+1), password...)
+					if err = p.WritePacket(append(data, 0)); err != nil {
+						return err
+					}
+					break
+				}
+				if err = p.WritePacket([]byte{0, 0, 0, 0, CACHING_SHA2_PUBLIC_KEY}); err != nil {
+					return err
+				}
+			default:
+				// public key of server.
+				encrypted, err := encryptPassword(password, salt, data[1:])
+				if err != nil {
+					return err
+				}
+				if err = p.WritePacket(append(make([]byte, 4, 4+len(encrypted)), encrypted...)); err != nil {
+					return err
+				}
+			}
+		default:
+			return errors.ErrMalformPacket
+		}
+	}
+}
+
+// checkAuth check auth response of client by plugin and passwords.
+// caching_sha2_password is verified by fast auth, as password is known by server;
+// other plugins are switched to mysql_native_password.
+func (p *PacketIO) checkAuth(authPlugin string, auth []byte, salt []byte, passwords []string) (bool, error) {
+	if authPlugin != "" && authPlugin != AUTH_NATIVE_PASSWORD && authPlugin != AUTH_CACHING_SHA2_PASSWORD {
+		data := make([]byte, 4, 4+1+len(AUTH_NATIVE_PASSWORD)+1+len(salt)+1)
+		data = append(data, AUTH_SWITCH_HEADER)
+		data = append(data, AUTH_NATIVE_PASSWORD...)
+		data = append(data, 0)
+		data = append(data, salt...)
+		data = append(data, 0)
+		if err := p.WritePacket(data); err != nil {
+			return false, err
+		}
+		var err error
+		if auth, err = p.ReadPacket(); err != nil {
+			return false, err
+		}
+		authPlugin = AUTH_NATIVE_PASSWORD
+	}
+	for i, password := range passwords {
+		if i > 0 && len(password) == 0 {
+			continue
+		}
+		expected, _ := calcAuthResponse(authPlugin, salt, password)
+		if !bytes.Equal(auth, expected) {
+			continue
+		}
+		if authPlugin == AUTH_CACHING_SHA2_PASSWORD {
+			if err := p.WritePacket([]byte{0, 0, 0, 0, AUTH_MORE_DATA_HEADER, CACHING_SHA2_FAST_AUTH}); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
@@ -48,6 +48,8 @@ const (
 	CLIENT_CAN_HANDLE_EXPIRED_PASSWORDS
 	CLIENT_SESSION_TRACK
 	CLIENT_DEPRECATE_EOF
+	CLIENT_OPTIONAL_RESULTSET_METADATA
+	CLIENT_ZSTD_COMPRESSION_ALGORITHM
 )
 
 // DEFAULT_CAPABILITY default server capability.
@@ -55,3 +57,39 @@ var DEFAULT_CAPABILITY uint32 = CLIENT_LONG_PASSWORD | CLIENT_LONG_FLAG |
 	CLIENT_CONNECT_WITH_DB | CLIENT_PROTOCOL_41 |
 	CLIENT_MULTI_STATEMENTS | CLIENT_MULTI_RESULTS |
 	CLIENT_TRANSACTIONS | CLIENT_SECURE_CONNECTION
+
+// BACKEND_CAPABILITY capability used to connect to backend mysql server.
+// Session state tracking is only negotiated with backends, multi results of stmt and auth plugins also with clients by proxy.
+// Local files of LOAD DATA LOCAL INFILE are relayed from client, or refused.
+// Compressed protocol is requested only if it's on in config of host, see PacketIO.Compress.
+var BACKEND_CAPABILITY = DEFAULT_CAPABILITY | CLIENT_PS_MULTI_RESULTS | CLIENT_SESSION_TRACK | CLIENT_PLUGIN_AUTH | CLIENT_LOCAL_FILES
+
+// CapabilityNames is capability flags by lower case name without 'client_' prefix, such as 'ssl' and 'plugin_auth'.
+var CapabilityNames = map[string]uint32{
+	"long_password":                  CLIENT_LONG_PASSWORD,
+	"found_rows":                     CLIENT_FOUND_ROWS,
+	"long_flag":                      CLIENT_LONG_FLAG,
+	"connect_with_db":                CLIENT_CONNECT_WITH_DB,
+	"no_schema":                      CLIENT_NO_SCHEMA,
+	"compress":                       CLIENT_COMPRESS,
+	"odbc":                           CLIENT_ODBC,
+	"local_files":                    CLIENT_LOCAL_FILES,
+	"ignore_space":                   CLIENT_IGNORE_SPACE,
+	"protocol_41":                    CLIENT_PROTOCOL_41,
+	"interactive":                    CLIENT_INTERACTIVE,
+	"ssl":                            CLIENT_SSL,
+	"ignore_sigpipe":                 CLIENT_IGNORE_SIGPIPE,
+	"transactions":                   CLIENT_TRANSACTIONS,
+	"secure_connection":              CLIENT_SECURE_CONNECTION,
+	"multi_statements":               CLIENT_MULTI_STATEMENTS,
+	"multi_results":                  CLIENT_MULTI_RESULTS,
+	"ps_multi_results":               CLIENT_PS_MULTI_RESULTS,
+	"plugin_auth":                    CLIENT_PLUGIN_AUTH,
+	"connect_attrs":                  CLIENT_CONNECT_ATTRS,
+	"plugin_auth_lenenc_client_data": CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA,
+	"can_handle_expired_passwords":   CLIENT_CAN_HANDLE_EXPIRED_PASSWORDS,
+	"session_track":                  CLIENT_SESSION_TRACK,
+	"deprecate_eof":                  CLIENT_DEPRECATE_EOF,
+	"optional_resultset_metadata":    CLIENT_OPTIONAL_RESULTSET_METADATA,
+	"zstd_compression_algorithm":     CLIENT_ZSTD_COMPRESSION_ALGORITHM,
+}
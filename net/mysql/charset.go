@@ -336,6 +336,7 @@ var Collations = map[CollationID]string{
 	245: "utf8mb4_croatian_ci",
 	246: "utf8mb4_unicode_520_ci",
 	247: "utf8mb4_vietnamese_ci",
+	255: "utf8mb4_0900_ai_ci",
 }
 
 // CollationNames key is collation name, value is collation id.
@@ -559,6 +560,7 @@ var CollationNames = map[string]CollationID{
 	"utf8mb4_croatian_ci":      245,
 	"utf8mb4_unicode_520_ci":   246,
 	"utf8mb4_vietnamese_ci":    247,
+	"utf8mb4_0900_ai_ci":       255,
 }
 
 var (
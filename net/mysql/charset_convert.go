@@ -0,0 +1,250 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mysql
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// BINARY_COLLATION_ID is collation id of binary charset, value of this charset is not converted.
+const BINARY_COLLATION_ID CollationID = 63
+
+// CharsetConverter convert string from one charset to another.
+type CharsetConverter func(src []byte) []byte
+
+// charsetCodec decode string of charset to utf8, or encode utf8 to charset.
+// Unmappable character is replaced by '?', same as mysql.
+type charsetCodec struct {
+	decode func(src []byte) []byte
+	encode func(src []byte) []byte
+}
+
+var charsetCodecs = map[string]*charsetCodec{
+	"utf8mb4": {decode: identity, encode: identity},
+	"utf8":    {decode: identity, encode: encodeUTF8MB3},
+	"ascii":   {decode: identity, encode: encodeASCII},
+	"latin1":  {decode: decodeLatin1, encode: encodeLatin1},
+}
+
+// latin1 of mysql is cp1252, 0x80 ~ 0x9f are different from iso-8859-1.
+var latin1HighRunes = [32]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+}
+
+var latin1Bytes = func() map[rune]byte {
+	m := make(map[rune]byte, len(latin1HighRunes))
+	for i, r := range latin1HighRunes {
+		m[r] = byte(0x80 + i)
+	}
+	return m
+}()
+
+// CollationCharset get charset name of collation, empty if unknown.
+func CollationCharset(id CollationID) string {
+	name, ok := Collations[id]
+	if !ok {
+		return ""
+	}
+	if pos := strings.Index(name, "_"); pos > 0 {
+		return name[:pos]
+	}
+	return name
+}
+
+// IsCharsetConvertible the charset could be converted by proxy or not.
+func IsCharsetConvertible(charset string) bool {
+	if charset == "binary" {
+		return true
+	}
+	_, ok := charsetCodecs[charset]
+	return ok
+}
+
+// NewCharsetConverter create converter from charset to another, return nil if not need to convert.
+func NewCharsetConverter(from, to string) (CharsetConverter, error) {
+	from, to = strings.ToLower(from), strings.ToLower(to)
+	if from == to || len(from) == 0 || len(to) == 0 || from == "binary" || to == "binary" {
+		return nil, nil
+	}
+	fromCodec, ok := charsetCodecs[from]
+	if !ok {
+		return nil, fmt.Errorf("convert from charset '%s' is not supported", from)
+	}
+	toCodec, ok := charsetCodecs[to]
+	if !ok {
+		return nil, fmt.Errorf("convert to charset '%s' is not supported", to)
+	}
+	return func(src []byte) []byte {
+		// all charsets supported are compatible with ascii.
+		if isASCII(src) {
+			return src
+		}
+		return toCodec.encode(fromCodec.decode(src))
+	}, nil
+}
+
+// ConvertCharset convert string values and field names of result set, and change collation of fields.
+func (r *Resultset) ConvertCharset(convert CharsetConverter, collationID CollationID) {
+	if convert == nil || r == nil {
+		return
+	}
+	if err := r.Decode(); err != nil {
+		return
+	}
+	for i, row := range r.Rows {
+		row.ConvertCharset(convert)
+		if i < len(r.Values) {
+			r.Values[i] = row.fieldValues
+		}
+	}
+	// fields may be shared, such as result built by proxy.
+	fields := make([]*Field, len(r.Fields))
+	for i, f := range r.Fields {
+		newField := *f
+		newField.ConvertCharset(convert, collationID)
+		fields[i] = &newField
+	}
+	r.Fields = fields
+}
+
+// ConvertCharset convert names of field, and change collation if it's not binary.
+func (f *Field) ConvertCharset(convert CharsetConverter, collationID CollationID) {
+	if convert == nil {
+		return
+	}
+	f.Schema = convert(f.Schema)
+	f.Table = convert(f.Table)
+	f.OrgTable = convert(f.OrgTable)
+	f.Name = convert(f.Name)
+	f.OrgName = convert(f.OrgName)
+	if CollationID(f.Charset) != BINARY_COLLATION_ID {
+		f.Charset = uint16(collationID)
+	}
+	f.Data = nil
+}
+
+// ConvertCharset convert string values in row, value of binary charset is not converted.
+func (r *Row) ConvertCharset(convert CharsetConverter) {
+	if convert == nil {
+		return
+	}
+	for i, f := range r.fields {
+		if i >= len(r.fieldValues) || r.fieldValues[i] == nil || CollationID(f.Charset) == BINARY_COLLATION_ID {
+			continue
+		}
+		var v []byte
+		switch val := r.fieldValues[i].(type) {
+		case string:
+			v = convert([]byte(val))
+			r.fieldValues[i] = string(v)
+		case []byte:
+			v = convert(val)
+			r.fieldValues[i] = v
+		default:
+			continue
+		}
+		r.fieldValuesCache[i] = StringToLenencStr(v)
+	}
+	r.Data = nil
+}
+
+func identity(src []byte) []byte {
+	return src
+}
+
+func isASCII(src []byte) bool {
+	for _, b := range src {
+		if b >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func encodeUTF8MB3(src []byte) []byte {
+	dst := make([]byte, 0, len(src))
+	for len(src) > 0 {
+		r, size := utf8.DecodeRune(src)
+		if (r == utf8.RuneError && size == 1) || size > 3 {
+			dst = append(dst, '?')
+		} else {
+			dst = append(dst, src[:size]...)
+		}
+		src = src[size:]
+	}
+	return dst
+}
+
+func encodeASCII(src []byte) []byte {
+	dst := make([]byte, 0, len(src))
+	for len(src) > 0 {
+		r, size := utf8.DecodeRune(src)
+		if r >= utf8.RuneSelf {
+			r = '?'
+		}
+		dst = append(dst, byte(r))
+		src = src[size:]
+	}
+	return dst
+}
+
+func decodeLatin1(src []byte) []byte {
+	dst := make([]byte, 0, len(src)*2)
+	for _, b := range src {
+		switch {
+		case b < 0x80:
+			dst = append(dst, b)
+		case b < 0xA0:
+			dst = append(dst, string(latin1HighRunes[b-0x80])...)
+		default:
+			dst = append(dst, string(rune(b))...)
+		}
+	}
+	return dst
+}
+
+func encodeLatin1(src []byte) []byte {
+	dst := make([]byte, 0, len(src))
+	for len(src) > 0 {
+		r, size := utf8.DecodeRune(src)
+		src = src[size:]
+		switch {
+		case r < 0x80:
+			dst = append(dst, byte(r))
+		case r >= 0xA0 && r <= 0xFF:
+			dst = append(dst, byte(r))
+		default:
+			if b, ok := latin1Bytes[r]; ok {
+				dst = append(dst, b)
+			} else {
+				dst = append(dst, '?')
+			}
+		}
+	}
+	return dst
+}
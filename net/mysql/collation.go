@@ -0,0 +1,192 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mysql
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// collation compare strings as mysql, to sort rows merged from shards.
+// Only common collations of utf8, utf8mb4 and latin1 are exactly same as mysql,
+// others are compared by upper case and without accents.
+type collation struct {
+	// padSpace trailing spaces are ignored, all collations except binary and '_0900_' are PAD SPACE.
+	padSpace bool
+	// decode string to utf8, nil if it's utf8 already.
+	decode func(src []byte) []byte
+	// weight append sort weights of character, nil means comparing bytes.
+	weight func(dst []rune, r rune) []rune
+}
+
+// unaccentRunes upper case letter with accents to base letter.
+var unaccentRunes = func() map[rune]rune {
+	m := make(map[rune]rune, 64)
+	for _, letters := range []string{"AÀÁÂÃÄÅĀĂĄ", "CÇĆĈĊČ", "DÐĎĐ", "EÈÉÊËĒĔĖĘĚ", "GĜĞĠĢ", "HĤĦ", "IÌÍÎÏĨĪĬĮİ",
+		"JĴ", "KĶ", "LĹĻĽĿŁ", "NÑŃŅŇ", "OÒÓÔÕÖØŌŎŐ", "RŔŖŘ", "SŚŜŞŠ", "TŢŤŦ", "UÙÚÛÜŨŪŬŮŰŲ", "WŴ", "YÝŶŸ", "ZŹŻŽ"} {
+		base, size := utf8.DecodeRuneInString(letters)
+		for _, r := range letters[size:] {
+			m[r] = base
+		}
+	}
+	return m
+}()
+
+// swedishRunes is sort order of latin1_swedish_ci, 'Å' < 'Ä' = 'Æ' < 'Ö' = 'Ø' are after 'Z', and 'Ü' = 'Y'.
+var swedishRunes = map[rune]rune{
+	'Å': '[', 'Ä': '\\', 'Æ': '\\', 'Ö': ']', 'Ø': ']', 'Ü': 'Y',
+}
+
+// generalWeight is weight of '_general_ci', upper case without accents, 'ß' = 'S',
+// and all supplementary characters are equal.
+func generalWeight(dst []rune, r rune) []rune {
+	if r > 0xFFFF {
+		return append(dst, 0xFFFD)
+	}
+	if r == 'ß' {
+		return append(dst, 'S')
+	}
+	r = unicode.ToUpper(r)
+	if base, ok := unaccentRunes[r]; ok {
+		r = base
+	}
+	return append(dst, r)
+}
+
+// unicodeWeight is weight of '_unicode_ci' and '_0900_ai_ci', upper case without accents,
+// and ligatures are expanded, such as 'ß' = 'SS'.
+func unicodeWeight(dst []rune, r rune) []rune {
+	switch r {
+	case 'ß':
+		return append(dst, 'S', 'S')
+	case 'æ', 'Æ':
+		return append(dst, 'A', 'E')
+	case 'œ', 'Œ':
+		return append(dst, 'O', 'E')
+	}
+	r = unicode.ToUpper(r)
+	if base, ok := unaccentRunes[r]; ok {
+		r = base
+	}
+	return append(dst, r)
+}
+
+// upperWeight is weight of accent sensitive '_ci', such as latin1_general_ci.
+func upperWeight(dst []rune, r rune) []rune {
+	return append(dst, unicode.ToUpper(r))
+}
+
+// swedishWeight is weight of latin1_swedish_ci.
+func swedishWeight(dst []rune, r rune) []rune {
+	r = unicode.ToUpper(r)
+	if w, ok := swedishRunes[r]; ok {
+		return append(dst, w)
+	}
+	if base, ok := unaccentRunes[r]; ok {
+		r = base
+	}
+	return append(dst, r)
+}
+
+var collations = func() map[CollationID]*collation {
+	m := make(map[CollationID]*collation, len(Collations))
+	for id, name := range Collations {
+		m[id] = newCollation(id, name)
+	}
+	return m
+}()
+
+func newCollation(id CollationID, name string) *collation {
+	c := new(collation)
+	if id == BINARY_COLLATION_ID {
+		return c
+	}
+	c.padSpace = !strings.Contains(name, "_0900_")
+	if strings.HasSuffix(name, "_bin") || strings.HasSuffix(name, "_cs") {
+		// order of utf8 bytes is same as code points.
+		return c
+	}
+
+	charset := name
+	if pos := strings.Index(name, "_"); pos > 0 {
+		charset = name[:pos]
+	}
+	if charset == "latin1" {
+		c.decode = decodeLatin1
+	}
+
+	switch {
+	case name == "latin1_swedish_ci":
+		c.weight = swedishWeight
+	case name == "latin1_general_ci" || name == "latin1_spanish_ci":
+		c.weight = upperWeight
+	case strings.Contains(name, "_unicode_") || strings.Contains(name, "_0900_"):
+		c.weight = unicodeWeight
+	default:
+		c.weight = generalWeight
+	}
+	return c
+}
+
+func getCollation(id CollationID) *collation {
+	if c, ok := collations[id]; ok {
+		return c
+	}
+	return collations[CharsetIds[DEFAULT_CHARSET]]
+}
+
+// compare two strings, return -1, 0 or 1.
+func (c *collation) compare(a, b []byte) int {
+	if c.padSpace {
+		a = bytes.TrimRight(a, " ")
+		b = bytes.TrimRight(b, " ")
+	}
+	if c.weight == nil {
+		return bytes.Compare(a, b)
+	}
+	wa, wb := c.sortKey(a), c.sortKey(b)
+	for i := 0; i < len(wa) && i < len(wb); i++ {
+		if wa[i] != wb[i] {
+			if wa[i] < wb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return compareInt(int64(len(wa)), int64(len(wb)))
+}
+
+func (c *collation) sortKey(s []byte) []rune {
+	if c.decode != nil {
+		s = c.decode(s)
+	}
+	key := make([]rune, 0, len(s))
+	for len(s) > 0 {
+		r, size := utf8.DecodeRune(s)
+		key = c.weight(key, r)
+		s = s[size:]
+	}
+	return key
+}
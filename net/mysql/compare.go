@@ -0,0 +1,374 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mysql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SortKey is a column to sort rows of result set.
+type SortKey struct {
+	Column int
+	Desc   bool
+}
+
+// CompareValue compare two values of the field, return -1, 0 or 1.
+// It's used to sort and merge rows from shards, NULL is less than any value same as mysql.
+//   - BIT is compared as unsigned number of big endian bytes.
+//   - YEAR and other integer types are compared as number.
+//   - DECIMAL is compared exactly as decimal number, FLOAT and DOUBLE as float.
+//   - GEOMETRY and binary strings are compared byte by byte.
+//   - ENUM and SET are compared as string by the collation of field.
+//     It's a known limitation: mysql orders them by index of member, which is not in the column definition,
+//     so rows of ENUM or SET are merged in order of string, not in the order of each shard.
+//   - Other strings are compared by the collation of field, see collation.
+func CompareValue(f *Field, a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	switch f.ColumnType {
+	case MYSQL_TYPE_BIT:
+		return compareUint(bitValue(a), bitValue(b))
+	case MYSQL_TYPE_TINY, MYSQL_TYPE_SHORT, MYSQL_TYPE_LONG, MYSQL_TYPE_INT24,
+		MYSQL_TYPE_LONGLONG, MYSQL_TYPE_YEAR:
+		if f.Flags&UNSIGNED_FLAG > 0 {
+			return compareUint(uintValue(a), uintValue(b))
+		}
+		return compareInt(intValue(a), intValue(b))
+	case MYSQL_TYPE_FLOAT, MYSQL_TYPE_DOUBLE:
+		return compareFloat(floatValue(a), floatValue(b))
+	case MYSQL_TYPE_DECIMAL, MYSQL_TYPE_NEWDECIMAL:
+		return decimalValue(a).Cmp(decimalValue(b))
+	case MYSQL_TYPE_GEOMETRY:
+		return bytes.Compare(bytesValue(a), bytesValue(b))
+	case MYSQL_TYPE_DATE, MYSQL_TYPE_NEWDATE, MYSQL_TYPE_DATETIME, MYSQL_TYPE_TIMESTAMP:
+		return bytes.Compare(bytesValue(a), bytesValue(b))
+	case MYSQL_TYPE_TIME:
+		return compareTime(bytesValue(a), bytesValue(b))
+	default:
+		return compareString(CollationID(f.Charset), bytesValue(a), bytesValue(b))
+	}
+}
+
+// CompareLiteral compare value of the field with literal in sql, as mysql compares column with it in where.
+// Return false if it couldn't be compared exactly:
+//   - Numeric field is compared as number with number, or string of number.
+//   - String field is compared with string by the collation of field, such as PAD SPACE and case insensitive.
+//   - Others, such as temporal and BIT, or string field with number (compared as double by mysql), aren't comparable.
+func CompareLiteral(f *Field, v interface{}, literal []byte, isString bool) (int, bool) {
+	if v == nil {
+		return -1, true
+	}
+	switch f.ColumnType {
+	case MYSQL_TYPE_TINY, MYSQL_TYPE_SHORT, MYSQL_TYPE_LONG, MYSQL_TYPE_INT24, MYSQL_TYPE_LONGLONG, MYSQL_TYPE_YEAR,
+		MYSQL_TYPE_DECIMAL, MYSQL_TYPE_NEWDECIMAL:
+		n, ok := new(big.Rat).SetString(strings.TrimSpace(string(literal)))
+		if !ok {
+			return 0, false
+		}
+		return decimalValue(v).Cmp(n), true
+	case MYSQL_TYPE_FLOAT, MYSQL_TYPE_DOUBLE:
+		n, err := strconv.ParseFloat(strings.TrimSpace(string(literal)), 64)
+		if err != nil {
+			return 0, false
+		}
+		return compareFloat(floatValue(v), n), true
+	case MYSQL_TYPE_VARCHAR, MYSQL_TYPE_VAR_STRING, MYSQL_TYPE_STRING, MYSQL_TYPE_ENUM, MYSQL_TYPE_SET,
+		MYSQL_TYPE_TINY_BLOB, MYSQL_TYPE_MEDIUM_BLOB, MYSQL_TYPE_LONG_BLOB, MYSQL_TYPE_BLOB:
+		if !isString {
+			return 0, false
+		}
+		return compareString(CollationID(f.Charset), bytesValue(v), literal), true
+	}
+	return 0, false
+}
+
+// SortRows sort rows and values of result set by keys, it's stable.
+func (r *Resultset) SortRows(keys []SortKey) {
+	if r.Decode() != nil || len(keys) == 0 || len(r.Values) < 2 {
+		return
+	}
+	sorter := &rowSorter{resultset: r, keys: keys}
+	sort.Stable(sorter)
+}
+
+type rowSorter struct {
+	resultset *Resultset
+	keys      []SortKey
+}
+
+func (s *rowSorter) Len() int {
+	return len(s.resultset.Values)
+}
+
+func (s *rowSorter) Less(i, j int) bool {
+	return s.resultset.CompareRows(i, j, s.keys) < 0
+}
+
+func (s *rowSorter) Swap(i, j int) {
+	r := s.resultset
+	r.Values[i], r.Values[j] = r.Values[j], r.Values[i]
+	if len(r.Rows) == len(r.Values) {
+		r.Rows[i], r.Rows[j] = r.Rows[j], r.Rows[i]
+	}
+}
+
+// CompareRows compare two rows of result set by keys.
+func (r *Resultset) CompareRows(i, j int, keys []SortKey) int {
+	for _, key := range keys {
+		if key.Column < 0 || key.Column >= len(r.Fields) {
+			continue
+		}
+		c := CompareValue(r.Fields[key.Column], r.Values[i][key.Column], r.Values[j][key.Column])
+		if c != 0 {
+			if key.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// AppendValue append value of text row, formatted by the type of field.
+func (r *Row) AppendValue(v interface{}) {
+	if len(r.fieldValues) >= len(r.fields) {
+		return
+	}
+	if v == nil {
+		r.AppendNullValue()
+		return
+	}
+	f := r.fields[len(r.fieldValues)]
+	data := FormatValue(f, v)
+	r.fieldValues = append(r.fieldValues, v)
+	r.fieldValuesCache = append(r.fieldValuesCache, StringToLenencStr(data))
+}
+
+// FormatValue format value as text protocol of the field.
+//   - BIT is big endian bytes of column length.
+//   - YEAR is 4 digits.
+//   - Integer with ZEROFILL is padded to column length.
+//   - Others are as it is.
+func FormatValue(f *Field, v interface{}) []byte {
+	switch f.ColumnType {
+	case MYSQL_TYPE_BIT:
+		if data, ok := v.([]byte); ok {
+			return data
+		}
+		size := int(f.ColumnLength+7) / 8
+		if size == 0 || size > 8 {
+			size = 8
+		}
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint64(data, bitValue(v))
+		return data[8-size:]
+	case MYSQL_TYPE_YEAR:
+		year := uintValue(v)
+		if f.ColumnLength == 2 {
+			return []byte(fmt.Sprintf("%02d", year%100))
+		}
+		return []byte(fmt.Sprintf("%04d", year))
+	case MYSQL_TYPE_TINY, MYSQL_TYPE_SHORT, MYSQL_TYPE_LONG, MYSQL_TYPE_INT24, MYSQL_TYPE_LONGLONG:
+		var str string
+		if f.Flags&UNSIGNED_FLAG > 0 {
+			str = strconv.FormatUint(uintValue(v), 10)
+		} else {
+			str = strconv.FormatInt(intValue(v), 10)
+		}
+		if f.Flags&ZERO_FILL_FLAG > 0 && len(str) < int(f.ColumnLength) {
+			str = strings.Repeat("0", int(f.ColumnLength)-len(str)) + str
+		}
+		return []byte(str)
+	case MYSQL_TYPE_FLOAT, MYSQL_TYPE_DOUBLE, MYSQL_TYPE_DECIMAL, MYSQL_TYPE_NEWDECIMAL:
+		if val, ok := v.(float64); ok {
+			// decimals 31 means not fixed.
+			if f.Decimals < 31 {
+				return []byte(strconv.FormatFloat(val, 'f', int(f.Decimals), 64))
+			}
+			return []byte(strconv.FormatFloat(val, 'g', -1, 64))
+		}
+	}
+	return bytesValue(v)
+}
+
+func bytesValue(v interface{}) []byte {
+	switch val := v.(type) {
+	case []byte:
+		return val
+	case string:
+		return []byte(val)
+	case int64:
+		return []byte(strconv.FormatInt(val, 10))
+	case uint64:
+		return []byte(strconv.FormatUint(val, 10))
+	case float64:
+		return []byte(strconv.FormatFloat(val, 'g', -1, 64))
+	default:
+		return []byte(fmt.Sprintf("%v", val))
+	}
+}
+
+// bitValue of BIT(M), it's big endian bytes in both text and binary protocol.
+func bitValue(v interface{}) uint64 {
+	switch val := v.(type) {
+	case []byte:
+		var n uint64
+		for _, b := range val {
+			n = n<<8 | uint64(b)
+		}
+		return n
+	case string:
+		return bitValue([]byte(val))
+	default:
+		return uintValue(v)
+	}
+}
+
+func uintValue(v interface{}) uint64 {
+	switch val := v.(type) {
+	case uint64:
+		return val
+	case int64:
+		return uint64(val)
+	case float64:
+		return uint64(val)
+	default:
+		n, _ := strconv.ParseUint(string(bytesValue(v)), 10, 64)
+		return n
+	}
+}
+
+func intValue(v interface{}) int64 {
+	switch val := v.(type) {
+	case int64:
+		return val
+	case uint64:
+		return int64(val)
+	case float64:
+		return int64(val)
+	default:
+		n, _ := strconv.ParseInt(string(bytesValue(v)), 10, 64)
+		return n
+	}
+}
+
+func floatValue(v interface{}) float64 {
+	switch val := v.(type) {
+	case float64:
+		return val
+	case int64:
+		return float64(val)
+	case uint64:
+		return float64(val)
+	default:
+		n, _ := strconv.ParseFloat(string(bytesValue(v)), 64)
+		return n
+	}
+}
+
+// decimalValue parse value of decimal exactly, it's string of digits in both text and binary row.
+func decimalValue(v interface{}) *big.Rat {
+	n := new(big.Rat)
+	switch val := v.(type) {
+	case int64:
+		n.SetInt64(val)
+	case uint64:
+		n.SetUint64(val)
+	case float64:
+		n.SetFloat64(val)
+	default:
+		if _, ok := n.SetString(string(bytesValue(v))); !ok {
+			return new(big.Rat)
+		}
+	}
+	return n
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareTime compare TIME formatted as '[-]hhh:mm:ss[.ffffff]'.
+func compareTime(a, b []byte) int {
+	negA := len(a) > 0 && a[0] == '-'
+	negB := len(b) > 0 && b[0] == '-'
+	switch {
+	case negA && !negB:
+		return -1
+	case !negA && negB:
+		return 1
+	case negA && negB:
+		return -compareTime(a[1:], b[1:])
+	}
+	// hours could be more than 2 digits.
+	if hourA, hourB := bytes.IndexByte(a, ':'), bytes.IndexByte(b, ':'); hourA != hourB {
+		return compareInt(int64(hourA), int64(hourB))
+	}
+	return bytes.Compare(a, b)
+}
+
+// compareString compare by collation.
+func compareString(collation CollationID, a, b []byte) int {
+	return getCollation(collation).compare(a, b)
+}
@@ -0,0 +1,254 @@
+package mysql
+
+import (
+	"bytes"
+	"testing"
+)
+
+// textRow build row data as the backend sent in text protocol, nil is NULL.
+func textRow(values ...[]byte) RowData {
+	data := make([]byte, 0, 64)
+	for _, v := range values {
+		if v == nil {
+			data = append(data, 0xfb)
+		} else {
+			data = append(data, StringToLenencStr(v)...)
+		}
+	}
+	return data
+}
+
+func parseTextRows(t *testing.T, fields []*Field, rows ...RowData) *Resultset {
+	rs := new(Resultset)
+	rs.Fields = fields
+	for _, data := range rows {
+		row, err := data.Parse(false, fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs.Rows = append(rs.Rows, row)
+		rs.Values = append(rs.Values, row.fieldValues)
+	}
+	return rs
+}
+
+func TestSortRowsBitYearSetEnumGeometry(t *testing.T) {
+	fields := []*Field{
+		// b BIT(9)
+		{Name: []byte("b"), ColumnType: MYSQL_TYPE_BIT, ColumnLength: 9, Charset: uint16(BINARY_COLLATION_ID), Flags: UNSIGNED_FLAG},
+		// y YEAR(4)
+		{Name: []byte("y"), ColumnType: MYSQL_TYPE_YEAR, ColumnLength: 4, Charset: uint16(BINARY_COLLATION_ID), Flags: UNSIGNED_FLAG | ZERO_FILL_FLAG},
+		// s SET('a','b','c'), utf8_general_ci
+		{Name: []byte("s"), ColumnType: MYSQL_TYPE_STRING, ColumnLength: 15, Charset: 33, Flags: SET_FLAG},
+		// e ENUM('x','y'), utf8_bin
+		{Name: []byte("e"), ColumnType: MYSQL_TYPE_STRING, ColumnLength: 3, Charset: 83, Flags: ENUM_FLAG},
+		// g POINT, WKB with srid
+		{Name: []byte("g"), ColumnType: MYSQL_TYPE_GEOMETRY, Charset: uint16(BINARY_COLLATION_ID), Flags: BLOB_FLAG | BINARY_FLAG},
+	}
+	point := func(x byte) []byte {
+		return []byte{0, 0, 0, 0, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xf0 | x, 0x3f, 0, 0, 0, 0, 0, 0, 0, 0}
+	}
+	rs := parseTextRows(t, fields,
+		textRow([]byte{0x01, 0x00}, []byte("2016"), []byte("a,b"), []byte("y"), point(1)),
+		textRow([]byte{0x00, 0x05}, []byte("1999"), []byte("A"), []byte("X"), point(2)),
+		textRow(nil, []byte("2155"), []byte("b "), nil, nil),
+		textRow([]byte{0x00, 0xff}, []byte("0000"), []byte("a,c"), []byte("x"), point(0)),
+	)
+
+	cases := []struct {
+		keys []SortKey
+		want []int // order of bit value or year
+	}{
+		{[]SortKey{{Column: 0}}, []int{-1, 5, 255, 256}},
+		{[]SortKey{{Column: 0, Desc: true}}, []int{256, 255, 5, -1}},
+		{[]SortKey{{Column: 1}}, []int{0, 1999, 2016, 2155}},
+	}
+	for _, c := range cases {
+		rs.SortRows(c.keys)
+		for i, want := range c.want {
+			v := rs.Values[i][c.keys[0].Column]
+			var got int
+			switch {
+			case v == nil:
+				got = -1
+			case c.keys[0].Column == 0:
+				got = int(bitValue(v))
+			default:
+				got = int(uintValue(v))
+			}
+			if got != want {
+				t.Fatalf("sort by %v, row %d is %d, want %d", c.keys, i, got, want)
+			}
+			if !bytes.Equal(rs.Rows[i].fieldValuesCache[c.keys[0].Column], textRow(bytesOrNil(v, fields[c.keys[0].Column]))) {
+				t.Fatalf("sort by %v, row %d is not moved with values", c.keys, i)
+			}
+		}
+	}
+
+	// SET is case-insensitive and ignore trailing spaces by utf8_general_ci.
+	if c := CompareValue(fields[2], "A", "a"); c != 0 {
+		t.Errorf("compare SET 'A' and 'a' is %d, want 0", c)
+	}
+	if c := CompareValue(fields[2], "b ", "a,c"); c != 1 {
+		t.Errorf("compare SET 'b ' and 'a,c' is %d, want 1", c)
+	}
+	// ENUM is case-sensitive by utf8_bin.
+	if c := CompareValue(fields[3], "X", "x"); c != -1 {
+		t.Errorf("compare ENUM 'X' and 'x' is %d, want -1", c)
+	}
+	// GEOMETRY is binary.
+	if c := CompareValue(fields[4], point(2), point(1)); c != 1 {
+		t.Errorf("compare GEOMETRY is %d, want 1", c)
+	}
+	// NULL is the smallest.
+	if c := CompareValue(fields[3], nil, ""); c != -1 {
+		t.Errorf("compare NULL and '' is %d, want -1", c)
+	}
+}
+
+func bytesOrNil(v interface{}, f *Field) []byte {
+	if v == nil {
+		return nil
+	}
+	return FormatValue(f, v)
+}
+
+func TestCompareBinaryRow(t *testing.T) {
+	fields := []*Field{
+		{Name: []byte("b"), ColumnType: MYSQL_TYPE_BIT, ColumnLength: 16, Charset: uint16(BINARY_COLLATION_ID), Flags: UNSIGNED_FLAG},
+		{Name: []byte("y"), ColumnType: MYSQL_TYPE_YEAR, ColumnLength: 4, Charset: uint16(BINARY_COLLATION_ID), Flags: UNSIGNED_FLAG | ZERO_FILL_FLAG},
+	}
+	// OK header, null bitmap, BIT as length encoded string, YEAR as 2 bytes little endian.
+	row1, err := RowData([]byte{0x00, 0x00, 0x02, 0x01, 0x00, 0xe0, 0x07}).Parse(true, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row2, err := RowData([]byte{0x00, 0x00, 0x02, 0x00, 0xff, 0xcf, 0x07}).Parse(true, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := CompareValue(fields[0], row1.fieldValues[0], row2.fieldValues[0]); c != 1 {
+		t.Errorf("compare BIT 0x0100 and 0x00ff is %d, want 1", c)
+	}
+	if c := CompareValue(fields[1], row1.fieldValues[1], row2.fieldValues[1]); c != 1 {
+		t.Errorf("compare YEAR 2016 and 2000 is %d, want 1", c)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	cases := []struct {
+		field *Field
+		value interface{}
+		want  []byte
+	}{
+		{&Field{ColumnType: MYSQL_TYPE_BIT, ColumnLength: 9}, uint64(256), []byte{0x01, 0x00}},
+		{&Field{ColumnType: MYSQL_TYPE_BIT, ColumnLength: 1}, []byte{0x01}, []byte{0x01}},
+		{&Field{ColumnType: MYSQL_TYPE_YEAR, ColumnLength: 4}, uint64(0), []byte("0000")},
+		{&Field{ColumnType: MYSQL_TYPE_YEAR, ColumnLength: 4}, int64(2016), []byte("2016")},
+		{&Field{ColumnType: MYSQL_TYPE_LONG, ColumnLength: 5, Flags: ZERO_FILL_FLAG | UNSIGNED_FLAG}, uint64(42), []byte("00042")},
+		{&Field{ColumnType: MYSQL_TYPE_STRING, Flags: SET_FLAG}, "a,b", []byte("a,b")},
+		{&Field{ColumnType: MYSQL_TYPE_NEWDECIMAL, Decimals: 2}, float64(1.5), []byte("1.50")},
+	}
+	for _, c := range cases {
+		if got := FormatValue(c.field, c.value); !bytes.Equal(got, c.want) {
+			t.Errorf("format %v of type %d is %q, want %q", c.value, c.field.ColumnType, got, c.want)
+		}
+	}
+
+	row := NewTextRow([]*Field{{ColumnType: MYSQL_TYPE_YEAR, ColumnLength: 4}, {ColumnType: MYSQL_TYPE_BIT, ColumnLength: 8}})
+	row.AppendValue(int64(1999))
+	row.AppendValue(nil)
+	if got, want := row.Dump(), textRow([]byte("1999"), nil); !bytes.Equal(got, want) {
+		t.Errorf("dump row is %v, want %v", got, want)
+	}
+}
+
+func TestCompareCollation(t *testing.T) {
+	latin1 := func(s string) []byte {
+		return encodeLatin1([]byte(s))
+	}
+	cases := []struct {
+		collation string
+		a, b      []byte
+		want      int
+	}{
+		{"utf8mb4_general_ci", []byte("abc"), []byte("ABC"), 0},
+		{"utf8mb4_general_ci", []byte("é"), []byte("E"), 0},
+		{"utf8mb4_general_ci", []byte("a "), []byte("a"), 0},
+		{"utf8mb4_general_ci", []byte("ß"), []byte("s"), 0},
+		{"utf8mb4_general_ci", []byte("a"), []byte("_"), -1}, // weight of 'a' is 'A'
+		{"utf8mb4_general_ci", []byte("😀"), []byte("😁"), 0},
+		{"utf8mb4_unicode_ci", []byte("ß"), []byte("ss"), 0},
+		{"utf8mb4_unicode_ci", []byte("Æ"), []byte("ae"), 0},
+		{"utf8mb4_0900_ai_ci", []byte("a "), []byte("a"), 1}, // NO PAD
+		{"utf8mb4_0900_ai_ci", []byte("Ö"), []byte("o"), 0},
+		{"utf8mb4_bin", []byte("a"), []byte("B"), 1},
+		{"utf8mb4_bin", []byte("a "), []byte("a"), 0},
+		{"utf8_bin", []byte("é"), []byte("z"), 1},
+		{"latin1_swedish_ci", latin1("Å"), latin1("Z"), 1},
+		{"latin1_swedish_ci", latin1("å"), latin1("ä"), -1},
+		{"latin1_swedish_ci", latin1("ä"), latin1("Æ"), 0},
+		{"latin1_swedish_ci", latin1("ö"), latin1("ø"), 0},
+		{"latin1_swedish_ci", latin1("ü"), latin1("y"), 0},
+		{"latin1_swedish_ci", latin1("é"), latin1("e"), 0},
+		{"latin1_general_ci", latin1("é"), latin1("e"), 1},
+		{"latin1_general_ci", latin1("É"), latin1("é"), 0},
+		{"latin1_bin", latin1("a"), latin1("A"), 1},
+		{"binary", []byte("a "), []byte("a"), 1},
+	}
+	for _, c := range cases {
+		f := &Field{ColumnType: MYSQL_TYPE_VAR_STRING, Charset: uint16(CollationNames[c.collation])}
+		if c.collation == "binary" {
+			f.Charset = uint16(BINARY_COLLATION_ID)
+		}
+		if got := CompareValue(f, c.a, c.b); got != c.want {
+			t.Errorf("compare %q and %q by %s is %d, want %d", c.a, c.b, c.collation, got, c.want)
+		}
+	}
+}
+
+func TestSortRowsNullOrder(t *testing.T) {
+	fields := []*Field{{Name: []byte("name"), ColumnType: MYSQL_TYPE_VAR_STRING, Charset: uint16(CollationNames["utf8mb4_general_ci"])}}
+	rs := parseTextRows(t, fields,
+		textRow([]byte("b")),
+		textRow(nil),
+		textRow([]byte("A")),
+		textRow([]byte("a")),
+	)
+	// NULL is first on ASC, and stable for equal values.
+	rs.SortRows([]SortKey{{Column: 0}})
+	want := []interface{}{nil, "A", "a", "b"}
+	for i := range want {
+		if rs.Values[i][0] != want[i] {
+			t.Fatalf("ASC row %d is %v, want %v", i, rs.Values[i][0], want[i])
+		}
+	}
+	// NULL is last on DESC.
+	rs.SortRows([]SortKey{{Column: 0, Desc: true}})
+	want = []interface{}{"b", "A", "a", nil}
+	for i := range want {
+		if rs.Values[i][0] != want[i] {
+			t.Fatalf("DESC row %d is %v, want %v", i, rs.Values[i][0], want[i])
+		}
+	}
+}
+
+func TestCompareDecimal(t *testing.T) {
+	field := &Field{Name: []byte("d"), ColumnType: MYSQL_TYPE_NEWDECIMAL, ColumnLength: 40, Decimals: 2}
+	// differ beyond precision of float64.
+	rs := parseTextRows(t, []*Field{field},
+		textRow([]byte("9007199254740993.01")),
+		textRow([]byte("9007199254740992.99")),
+		textRow([]byte("-0.01")),
+	)
+	rs.SortRows([]SortKey{{Column: 0}})
+	want := []string{"-0.01", "9007199254740992.99", "9007199254740993.01"}
+	for i := range want {
+		if v := string(rs.Values[i][0].([]byte)); v != want[i] {
+			t.Fatalf("row %d is %s, want %s", i, v, want[i])
+		}
+	}
+	if c := CompareValue(field, []byte("1.50"), float64(1.5)); c != 0 {
+		t.Errorf("compare DECIMAL 1.50 and 1.5 is %d, want 0", c)
+	}
+}
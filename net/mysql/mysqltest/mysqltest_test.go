@@ -0,0 +1,330 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mysqltest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+type client struct {
+	conn       net.Conn
+	pkg        *mysql.PacketIO
+	capability uint32
+	status     uint16
+}
+
+func connect(t *testing.T, addr, user, password string) (*client, error) {
+	return connectTLS(t, addr, user, password, nil)
+}
+
+// connectTLS connect by TLS if tlsConfig is set and server supports.
+func connectTLS(t *testing.T, addr, user, password string, tlsConfig *tls.Config) (*client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &client{conn: conn, pkg: mysql.NewPacketIO(conn)}
+	c.pkg.TLSConfig = tlsConfig
+	var salt []byte
+	var collationID mysql.CollationID
+	var authPlugin string
+	if c.capability, c.status, collationID, _, authPlugin, err = c.pkg.ReadInitialHandshake(&salt); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.pkg.WriteAuthHandshake(&c.capability, user, password, "db1", salt, collationID, authPlugin); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.pkg.ReadAuthResult(c.capability, &c.status, password, salt, authPlugin); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *client) query(t *testing.T, query string) string {
+	result, err := c.pkg.Query(c.capability, &c.status, query)
+	if err != nil {
+		return err.Error()
+	}
+	if result.Resultset == nil {
+		return "ok"
+	}
+	value, err := result.GetString(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func (c *client) close() {
+	c.pkg.WriteCommand(mysql.COM_QUIT)
+	c.conn.Close()
+}
+
+func newScriptedServer(t *testing.T) *Server {
+	s, err := NewServer("root", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.On("select @@version", NewResult([]string{"@@version"}, [][]interface{}{{"5.7.0-fake"}}))
+	s.On("begin", nil)
+	s.OnError("select * from t1", mysql.NewDefaultError(mysql.ER_NO_SUCH_TABLE, "db1", "t1"))
+	return s
+}
+
+func TestServerScript(t *testing.T) {
+	s := newScriptedServer(t)
+	defer s.Close()
+
+	if _, err := connect(t, s.Addr(), "root", "wrong"); err == nil {
+		t.Fatal("expect access denied")
+	} else if sqlErr, ok := err.(*errors.SqlError); !ok || sqlErr.Code != mysql.ER_ACCESS_DENIED_ERROR {
+		t.Fatalf("expect access denied, got %v", err)
+	}
+
+	c, err := connect(t, s.Addr(), "root", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT @@version", "5.7.0-fake"},
+		{" begin; ", "ok"},
+		{"select * from t1", "ERROR 1146 (42S02): Table 'db1.t1' doesn't exist"},
+		{"select 2", "ERROR 1105 (HY000): mysqltest: unexpected query 'select 2'"},
+	}
+	for _, tc := range cases {
+		if got := c.query(t, tc.query); got != tc.want {
+			t.Errorf("query %q: expect %q, got %q", tc.query, tc.want, got)
+		}
+	}
+	if got := len(s.Queries()); got != len(cases) {
+		t.Errorf("expect %d queries received, got %d", len(cases), got)
+	}
+}
+
+func TestServerSecondaryPassword(t *testing.T) {
+	s := newScriptedServer(t)
+	defer s.Close()
+	s.Lock()
+	s.SecondaryPassword = "old-secret"
+	s.Unlock()
+
+	for _, password := range []string{"secret", "old-secret"} {
+		c, err := connect(t, s.Addr(), "root", password)
+		if err != nil {
+			t.Fatalf("password %q: %v", password, err)
+		}
+		c.close()
+	}
+	if _, err := connect(t, s.Addr(), "root", "wrong"); err == nil {
+		t.Fatal("expect access denied")
+	}
+	s.Lock()
+	s.SecondaryPassword = ""
+	s.Unlock()
+	if _, err := connect(t, s.Addr(), "root", "old-secret"); err == nil {
+		t.Fatal("expect access denied after secondary password discarded")
+	}
+	if _, err := connect(t, s.Addr(), "root", ""); err == nil {
+		t.Fatal("expect access denied by empty password")
+	}
+}
+
+func TestServerCachingSha2Password(t *testing.T) {
+	s := newScriptedServer(t)
+	defer s.Close()
+	s.Lock()
+	s.Capability |= mysql.CLIENT_PLUGIN_AUTH
+	s.AuthPlugin = mysql.AUTH_CACHING_SHA2_PASSWORD
+	s.Unlock()
+
+	c, err := connect(t, s.Addr(), "root", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.query(t, "select @@version"); got != "5.7.0-fake" {
+		t.Errorf("expect query after fast auth, got %q", got)
+	}
+	c.close()
+	if _, err := connect(t, s.Addr(), "root", "wrong"); err == nil {
+		t.Fatal("expect access denied")
+	}
+}
+
+// selfSignedTLSConfig is server TLS config of a self-signed certificate.
+func selfSignedTLSConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+func TestServerTLS(t *testing.T) {
+	s := newScriptedServer(t)
+	defer s.Close()
+	tlsConfig := selfSignedTLSConfig(t)
+	s.Lock()
+	s.TLSConfig = tlsConfig
+	s.Capability |= mysql.CLIENT_PLUGIN_AUTH
+	s.AuthPlugin = mysql.AUTH_CACHING_SHA2_PASSWORD
+	s.Unlock()
+
+	c, err := connectTLS(t, s.Addr(), "root", "secret", &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.pkg.IsTLS() || c.capability&mysql.CLIENT_SSL == 0 {
+		t.Error("expect connection upgraded to TLS")
+	}
+	if got := c.query(t, "select @@version"); got != "5.7.0-fake" {
+		t.Errorf("expect query over TLS, got %q", got)
+	}
+	c.close()
+
+	// SSL is not requested by client without TLS config.
+	c, err = connect(t, s.Addr(), "root", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.pkg.IsTLS() {
+		t.Error("expect connection not upgraded to TLS")
+	}
+	c.close()
+}
+
+func TestServerHandler(t *testing.T) {
+	s := newScriptedServer(t)
+	defer s.Close()
+	s.OnCommand(func(command byte, data []byte) (*mysql.Result, error) {
+		if command == mysql.COM_QUERY {
+			return NewResult([]string{"q"}, [][]interface{}{{string(data[1:])}}), nil
+		}
+		return nil, mysql.NewDefaultError(mysql.ER_UNKNOWN_COM_ERROR)
+	})
+	c, err := connect(t, s.Addr(), "root", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+	if got := c.query(t, "select 2"); got != "select 2" {
+		t.Errorf("expect handled by handler, got %q", got)
+	}
+	if err := c.pkg.Ping(c.capability, &c.status); err != nil {
+		t.Errorf("ping: %v", err)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	s := newScriptedServer(t)
+	defer s.Close()
+	recorder, err := NewRecorder(s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := []string{"select @@version", "begin", "select * from t1"}
+	run := func(addr string, queries []string) []string {
+		c, err := connect(t, addr, "root", "secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.close()
+		results := make([]string, len(queries))
+		for i, query := range queries {
+			results[i] = c.query(t, query)
+		}
+		return results
+	}
+	recorded := run(recorder.Addr(), queries)
+	recorder.Close()
+
+	recordings := recorder.Recordings()
+	if len(recordings) != 1 {
+		t.Fatalf("expect 1 recording, got %d", len(recordings))
+	}
+	var buf bytes.Buffer
+	if err := recordings[0].Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadRecording(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Packets) != len(recordings[0].Packets) {
+		t.Fatalf("expect %d packets loaded, got %d", len(recordings[0].Packets), len(loaded.Packets))
+	}
+
+	// same conversation is replayed without the server.
+	replayServer, err := NewReplayServer(loaded, loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := run(replayServer.Addr(), queries)
+	for i := range queries {
+		if replayed[i] != recorded[i] {
+			t.Errorf("query %q: expect %q replayed, got %q", queries[i], recorded[i], replayed[i])
+		}
+	}
+
+	// different query is reported as mismatched.
+	c, err := connect(t, replayServer.Addr(), "root", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.query(t, "select 1")
+	c.close()
+	replayServer.Close()
+	if errs := replayServer.Errors(); len(errs) != 1 {
+		t.Errorf("expect 1 mismatch error, got %v", errs)
+	}
+}
@@ -0,0 +1,225 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mysqltest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Packet on wire, data includes 4 bytes header.
+type Packet struct {
+	FromServer bool
+	Data       []byte
+}
+
+// Recording is packets of one connection, in order.
+type Recording struct {
+	Packets []*Packet
+}
+
+// Save recording as text, one packet per line, such as 'S 0a000000...' from server and 'C ...' from client.
+func (r *Recording) Save(w io.Writer) error {
+	for _, p := range r.Packets {
+		direction := "C"
+		if p.FromServer {
+			direction = "S"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", direction, hex.EncodeToString(p.Data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadRecording load recording saved by Save, empty lines and lines start with '#' are ignored.
+func LoadRecording(rd io.Reader) (*Recording, error) {
+	r := new(Recording)
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || (fields[0] != "S" && fields[0] != "C") {
+			return nil, fmt.Errorf("mysqltest: invalid recording at line %d", lineNo)
+		}
+		data, err := hex.DecodeString(fields[1])
+		if err != nil || len(data) < 4 {
+			return nil, fmt.Errorf("mysqltest: invalid packet at line %d", lineNo)
+		}
+		r.Packets = append(r.Packets, &Packet{FromServer: fields[0] == "S", Data: data})
+	}
+	return r, scanner.Err()
+}
+
+// readFrame read one packet with header from wire, without checking sequence.
+func readFrame(rd io.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(rd, header); err != nil {
+		return nil, err
+	}
+	length := int(uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16)
+	data := make([]byte, 4+length)
+	copy(data, header)
+	if _, err := io.ReadFull(rd, data[4:]); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// replay recording on connection, packets from client should be the same as recorded.
+func replay(conn net.Conn, recording *Recording) error {
+	rd := bufio.NewReader(conn)
+	for i, p := range recording.Packets {
+		if p.FromServer {
+			if _, err := conn.Write(p.Data); err != nil {
+				return fmt.Errorf("mysqltest: write packet %d: %v", i, err)
+			}
+			continue
+		}
+		data, err := readFrame(rd)
+		if err != nil {
+			return fmt.Errorf("mysqltest: read packet %d: %v", i, err)
+		}
+		if !bytes.Equal(data, p.Data) {
+			return fmt.Errorf("mysqltest: packet %d mismatched, expected %x, got %x", i, p.Data, data)
+		}
+	}
+	// wait until closed by client, such as COM_QUIT.
+	io.Copy(ioutil.Discard, rd)
+	return nil
+}
+
+// Recorder is a proxy in front of mysql server, record packets of each connection.
+type Recorder struct {
+	target   string
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	sync.Mutex
+	recordings []*Recording
+	conns      map[net.Conn]bool
+}
+
+// NewRecorder create recorder to target address, and start serving.
+func NewRecorder(target string) (*Recorder, error) {
+	r := new(Recorder)
+	r.target = target
+	r.conns = make(map[net.Conn]bool)
+	var err error
+	if r.listener, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		return nil, err
+	}
+	r.wg.Add(1)
+	go r.serve()
+	return r, nil
+}
+
+// Addr of recorder, connect to it instead of target.
+func (r *Recorder) Addr() string {
+	return r.listener.Addr().String()
+}
+
+// Recordings of connections, in order of accepted.
+func (r *Recorder) Recordings() []*Recording {
+	r.Lock()
+	defer r.Unlock()
+	recordings := make([]*Recording, len(r.recordings))
+	for i, recording := range r.recordings {
+		recordings[i] = &Recording{Packets: append([]*Packet(nil), recording.Packets...)}
+	}
+	return recordings
+}
+
+// Close recorder and its connections.
+func (r *Recorder) Close() error {
+	err := r.listener.Close()
+	r.Lock()
+	for conn := range r.conns {
+		conn.Close()
+	}
+	r.Unlock()
+	r.wg.Wait()
+	return err
+}
+
+func (r *Recorder) serve() {
+	defer r.wg.Done()
+	for {
+		client, err := r.listener.Accept()
+		if err != nil {
+			return
+		}
+		server, err := net.Dial("tcp", r.target)
+		if err != nil {
+			client.Close()
+			continue
+		}
+		recording := new(Recording)
+		r.Lock()
+		r.recordings = append(r.recordings, recording)
+		r.conns[client] = true
+		r.conns[server] = true
+		r.Unlock()
+
+		r.wg.Add(2)
+		go r.forward(recording, client, server, false)
+		go r.forward(recording, server, client, true)
+	}
+}
+
+// forward packets from src to dst, record packet before forwarding, so that order of request and response is kept.
+func (r *Recorder) forward(recording *Recording, src, dst net.Conn, fromServer bool) {
+	defer r.wg.Done()
+	defer func() {
+		src.Close()
+		dst.Close()
+		r.Lock()
+		delete(r.conns, src)
+		delete(r.conns, dst)
+		r.Unlock()
+	}()
+	rd := bufio.NewReader(src)
+	for {
+		data, err := readFrame(rd)
+		if err != nil {
+			return
+		}
+		r.Lock()
+		recording.Packets = append(recording.Packets, &Packet{FromServer: fromServer, Data: data})
+		r.Unlock()
+		if _, err := dst.Write(data); err != nil {
+			return
+		}
+	}
+}
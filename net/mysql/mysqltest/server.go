@@ -0,0 +1,318 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package mysqltest provides a scriptable fake mysql server, and record/replay of packets,
+// to test protocol features of backend connections, proxy and filters deterministically.
+package mysqltest
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+// Handler handle command packet (without header) not scripted, such as COM_STMT_PREPARE.
+// Return nil result and nil error to write OK.
+type Handler func(command byte, data []byte) (*mysql.Result, error)
+
+type response struct {
+	result *mysql.Result
+	err    error
+}
+
+// Server is a fake mysql server, listen on random port of 127.0.0.1.
+// Queries are answered by script, other commands by handler.
+// If created by NewReplayServer, connections replay recorded packets instead.
+// Server is serving once created, so exported fields should be changed under lock, read by new connections.
+type Server struct {
+	User       string
+	Password   string
+	Capability uint32
+	Status     uint16
+	// SecondaryPassword is also accepted if not empty, as password in rotation.
+	SecondaryPassword string
+	// AuthPlugin is default auth plugin sent in handshake, if Capability has CLIENT_PLUGIN_AUTH.
+	AuthPlugin string
+	// TLSConfig to upgrade connection when SSL requested by client, CLIENT_SSL is advertised if set.
+	TLSConfig *tls.Config
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	sync.Mutex
+	queries      map[string]*response
+	handler      Handler
+	received     []string
+	errs         []error
+	conns        map[net.Conn]bool
+	connectionID uint32
+	replays      []*Recording
+}
+
+// NewServer create fake server, and start serving.
+func NewServer(user, password string) (*Server, error) {
+	s := new(Server)
+	s.User = user
+	s.Password = password
+	s.Capability = mysql.DEFAULT_CAPABILITY
+	s.Status = mysql.SERVER_STATUS_AUTOCOMMIT
+	s.queries = make(map[string]*response)
+	if err := s.listen(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewReplayServer create fake server that replay recordings, the n-th connection replays the n-th recording.
+func NewReplayServer(recordings ...*Recording) (*Server, error) {
+	s := new(Server)
+	s.replays = recordings
+	if err := s.listen(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Server) listen() (err error) {
+	if s.listener, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		return
+	}
+	s.conns = make(map[net.Conn]bool)
+	s.wg.Add(1)
+	go s.serve()
+	return
+}
+
+// Addr of server, such as '127.0.0.1:3306'.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// On answer query by result, query is matched case-insensitive, ignoring spaces around.
+func (s *Server) On(query string, result *mysql.Result) {
+	s.Lock()
+	defer s.Unlock()
+	s.queries[normalizeQuery(query)] = &response{result: result}
+}
+
+// OnError answer query by error.
+func (s *Server) OnError(query string, err error) {
+	s.Lock()
+	defer s.Unlock()
+	s.queries[normalizeQuery(query)] = &response{err: err}
+}
+
+// OnCommand set handler of commands not scripted.
+func (s *Server) OnCommand(handler Handler) {
+	s.Lock()
+	defer s.Unlock()
+	s.handler = handler
+}
+
+// Queries received, in order.
+func (s *Server) Queries() []string {
+	s.Lock()
+	defer s.Unlock()
+	return append([]string(nil), s.received...)
+}
+
+// Errors of connections, such as packet mismatched in replay.
+func (s *Server) Errors() []error {
+	s.Lock()
+	defer s.Unlock()
+	return append([]error(nil), s.errs...)
+}
+
+// Close server and its connections.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) addError(err error) {
+	s.Lock()
+	defer s.Unlock()
+	s.errs = append(s.errs, err)
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.Lock()
+		s.conns[conn] = true
+		s.connectionID++
+		connectionID := s.connectionID
+		s.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				conn.Close()
+				s.Lock()
+				delete(s.conns, conn)
+				s.Unlock()
+			}()
+			if s.replays != nil {
+				if int(connectionID) > len(s.replays) {
+					s.addError(fmt.Errorf("mysqltest: no recording for connection %d", connectionID))
+					return
+				}
+				if err := replay(conn, s.replays[connectionID-1]); err != nil {
+					s.addError(err)
+				}
+				return
+			}
+			if err := s.handleConn(conn, connectionID); err != nil {
+				s.addError(err)
+			}
+		}()
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn, connectionID uint32) error {
+	s.Lock()
+	user, passwords := s.User, []string{s.Password, s.SecondaryPassword}
+	serverCapability, status, authPlugin, tlsConfig := s.Capability, s.Status, s.AuthPlugin, s.TLSConfig
+	s.Unlock()
+
+	pkg := mysql.NewPacketIO(conn)
+	pkg.TLSConfig = tlsConfig
+	salt, _ := mysql.RandomBuf(20)
+	capability := serverCapability
+	if tlsConfig != nil {
+		capability |= mysql.CLIENT_SSL
+	}
+	if err := pkg.WriteInitialHandshake(connectionID, salt, mysql.DEFAULT_COLLATION_ID, capability, status, authPlugin); err != nil {
+		return err
+	}
+	capability, _, _, _, err := pkg.ReadHandshakeResponse(func(user string) (string, error) {
+		return "", nil
+	}, conn.RemoteAddr().String(), salt, func(db string) (string, []string, error) {
+		return user, passwords, nil
+	}, nil)
+	if err != nil {
+		pkg.WriteError(serverCapability, err)
+		return nil
+	}
+	if err = pkg.WriteOK(capability, status, nil); err != nil {
+		return err
+	}
+
+	for {
+		pkg.Sequence = 0
+		data, err := pkg.ReadPacket()
+		if err != nil {
+			// closed by client.
+			return nil
+		}
+		command := data[0]
+		var result *mysql.Result
+		switch command {
+		case mysql.COM_QUIT:
+			return nil
+		case mysql.COM_PING, mysql.COM_INIT_DB:
+		case mysql.COM_QUERY:
+			query := string(data[1:])
+			s.Lock()
+			s.received = append(s.received, query)
+			resp := s.queries[normalizeQuery(query)]
+			handler := s.handler
+			s.Unlock()
+			if resp != nil {
+				result, err = resp.result, resp.err
+			} else if handler != nil {
+				result, err = handler(command, data)
+			} else {
+				err = mysql.NewError(mysql.ER_UNKNOWN_ERROR, fmt.Sprintf("mysqltest: unexpected query '%s'", query))
+			}
+		default:
+			s.Lock()
+			handler := s.handler
+			s.Unlock()
+			if handler != nil {
+				result, err = handler(command, data)
+			} else {
+				err = mysql.NewDefaultError(mysql.ER_UNKNOWN_COM_ERROR)
+			}
+		}
+
+		if err != nil {
+			err = pkg.WriteError(capability, err)
+		} else if result != nil && result.Resultset != nil && len(result.Fields) > 0 {
+			err = pkg.WriteResultSet(capability, status, result)
+		} else {
+			err = pkg.WriteOK(capability, status, result)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// NewResult create text result set of string columns, nil value is NULL.
+func NewResult(names []string, rows [][]interface{}) *mysql.Result {
+	result := new(mysql.Result)
+	result.Status = mysql.SERVER_STATUS_AUTOCOMMIT
+	result.Resultset = new(mysql.Resultset)
+	result.Fields = make([]*mysql.Field, len(names))
+	for i, name := range names {
+		result.Fields[i] = &mysql.Field{
+			Name:         []byte(name),
+			OrgName:      []byte(name),
+			Charset:      uint16(mysql.DEFAULT_COLLATION_ID),
+			ColumnLength: 1024,
+			ColumnType:   mysql.MYSQL_TYPE_VAR_STRING,
+		}
+	}
+	result.Rows = make([]*mysql.Row, len(rows))
+	for i, values := range rows {
+		row := mysql.NewTextRow(result.Fields)
+		for _, value := range values {
+			if value == nil {
+				row.AppendNullValue()
+			} else {
+				row.AppendStringValue(fmt.Sprintf("%v", value))
+			}
+		}
+		result.Rows[i] = row
+	}
+	return result
+}
+
+func normalizeQuery(query string) string {
+	return strings.ToLower(strings.TrimRight(strings.TrimSpace(query), "; \t\r\n"))
+}
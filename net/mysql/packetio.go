@@ -24,6 +24,7 @@ package mysql
 
 import (
 	"bufio"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
@@ -33,14 +34,49 @@ import (
 
 const (
 	defaultReaderSize = 8 * 1024
+
+	// writeChunkSize is size of chunk to coalesce small packets when write buffered.
+	writeChunkSize = 16 * 1024
+	// maxWriteBuffered is max bytes buffered before flushed, even not at the end of response.
+	maxWriteBuffered = 1024 * 1024
 )
 
+// ErrPacketTooLarge is error of packet read bigger than max allowed packet.
+var ErrPacketTooLarge = NewDefaultError(ER_NET_PACKET_TOO_LARGE)
+
 // PacketIO is a packet transfer on network.
 type PacketIO struct {
 	rb *bufio.Reader
 	wb io.Writer
 
+	// pending packets when write buffered, small packets are coalesced into chunks,
+	// large ones are referenced, and all of them are written by writev when flushed.
+	writeBuffered bool
+	wbufs         net.Buffers
+	chunk         []byte
+	pending       int
+
 	Sequence uint8
+
+	// TLSConfig is to upgrade connection to TLS, when SSL is requested by client or supported by server.
+	TLSConfig *tls.Config
+	// conn is replaced by TLS connection after upgraded.
+	conn net.Conn
+
+	// MaxAllowedPacket is max bytes of packet read, 0 is unlimited.
+	MaxAllowedPacket int
+
+	// LocalInfile send file requested by server in LOAD DATA LOCAL INFILE, the request is refused if nil.
+	LocalInfile func(filename []byte, server *PacketIO) error
+
+	// compressed wraps packets read and written after switched to compressed protocol.
+	compressed *compressedConn
+	// Compress is to request compressed protocol in handshake to server, if it's supported.
+	Compress bool
+
+	// BytesRead and BytesWritten are bytes of packets read and written, before compression.
+	BytesRead    uint64
+	BytesWritten uint64
 }
 
 // NewPacketIO is to create PacketIO
@@ -49,14 +85,130 @@ func NewPacketIO(conn net.Conn) *PacketIO {
 
 	p.rb = bufio.NewReaderSize(conn, defaultReaderSize)
 	p.wb = conn
+	p.conn = conn
 
 	p.Sequence = 0
 
 	return p
 }
 
-// ReadPacket is to read packet.
+// Conn is the connection, or TLS connection after upgraded.
+func (p *PacketIO) Conn() net.Conn {
+	return p.conn
+}
+
+// IsTLS is true if connection is upgraded to TLS.
+func (p *PacketIO) IsTLS() bool {
+	_, ok := p.conn.(*tls.Conn)
+	return ok
+}
+
+// upgradeTLS upgrade connection to TLS by TLSConfig, as server or client, after SSL request in handshake.
+func (p *PacketIO) upgradeTLS(server bool) error {
+	if err := p.Flush(); err != nil {
+		return err
+	}
+	// TLS handshake may be read into buffer with SSL request already.
+	conn := &bufferedConn{Conn: p.conn, rb: p.rb}
+	var tlsConn *tls.Conn
+	if server {
+		tlsConn = tls.Server(conn, p.TLSConfig)
+	} else {
+		tlsConn = tls.Client(conn, p.TLSConfig)
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	p.conn = tlsConn
+	p.rb = bufio.NewReaderSize(tlsConn, defaultReaderSize)
+	p.wb = tlsConn
+	return nil
+}
+
+// bufferedConn read from buffer of connection before upgraded to TLS.
+type bufferedConn struct {
+	net.Conn
+	rb *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.rb.Read(b)
+}
+
+// SetWriteBuffered buffer packets written until Flush, or read packet, such as response of a command to client.
+func (p *PacketIO) SetWriteBuffered(buffered bool) error {
+	p.writeBuffered = buffered
+	if !buffered {
+		return p.Flush()
+	}
+	return nil
+}
+
+// Flush packets buffered to connection.
+func (p *PacketIO) Flush() error {
+	if len(p.wbufs) == 0 {
+		return nil
+	}
+	bufs := p.wbufs
+	p.wbufs = nil
+	p.chunk = nil
+	p.pending = 0
+	if _, err := bufs.WriteTo(p.wb); err != nil {
+		return errors.ErrBadConn
+	}
+	return nil
+}
+
+// write packets to connection, or buffer them when write buffered.
+// Data should not be modified by caller after written.
+func (p *PacketIO) write(data []byte) error {
+	p.BytesWritten += uint64(len(data))
+	if !p.writeBuffered {
+		if n, err := p.wb.Write(data); err != nil {
+			return errors.ErrBadConn
+		} else if n != len(data) {
+			return errors.ErrBadConn
+		}
+		return nil
+	}
+	if len(data) >= writeChunkSize {
+		p.wbufs = append(p.wbufs, data)
+		p.chunk = nil
+	} else {
+		if p.chunk == nil || cap(p.chunk)-len(p.chunk) < len(data) {
+			p.chunk = make([]byte, 0, writeChunkSize)
+			p.wbufs = append(p.wbufs, nil)
+		}
+		p.chunk = append(p.chunk, data...)
+		p.wbufs[len(p.wbufs)-1] = p.chunk
+	}
+	p.pending += len(data)
+	if p.pending >= maxWriteBuffered {
+		return p.Flush()
+	}
+	return nil
+}
+
+// Buffered is count of bytes read from connection, but not read as packet yet.
+func (p *PacketIO) Buffered() int {
+	if p.compressed != nil {
+		return p.rb.Buffered() + p.compressed.buffered()
+	}
+	return p.rb.Buffered()
+}
+
+// ReadPacket is to read packet, ErrPacketTooLarge if it's bigger than MaxAllowedPacket.
 func (p *PacketIO) ReadPacket() ([]byte, error) {
+	return p.readPacket(0, false)
+}
+
+// readPacket read packet, or next part of it after bytes read.
+// Empty packet is allowed only if allowEmpty, such as end of local file.
+func (p *PacketIO) readPacket(read int, allowEmpty bool) ([]byte, error) {
+	// peer may wait for packets buffered before sending.
+	if err := p.Flush(); err != nil {
+		return nil, err
+	}
 	header := []byte{0, 0, 0, 0}
 
 	if _, err := io.ReadFull(p.rb, header); err != nil {
@@ -64,7 +216,7 @@ func (p *PacketIO) ReadPacket() ([]byte, error) {
 	}
 
 	length := int(uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16)
-	if length < 1 {
+	if length < 1 && read == 0 && !allowEmpty {
 		return nil, fmt.Errorf("invalid payload length %d", length)
 	}
 
@@ -76,16 +228,24 @@ func (p *PacketIO) ReadPacket() ([]byte, error) {
 
 	p.Sequence++
 
+	// payload isn't read, connection should be closed after error written.
+	if p.MaxAllowedPacket > 0 && read+length > p.MaxAllowedPacket {
+		return nil, ErrPacketTooLarge
+	}
+
 	data := make([]byte, length)
 	if _, err := io.ReadFull(p.rb, data); err != nil {
 		return nil, errors.ErrBadConn
 	}
+	p.BytesRead += uint64(4 + length)
 	if length < MaxPayloadLen {
 		return data, nil
 	}
 
-	buf, err := p.ReadPacket()
-	if err != nil {
+	buf, err := p.readPacket(read+length, allowEmpty)
+	if err == ErrPacketTooLarge {
+		return nil, err
+	} else if err != nil {
 		return nil, errors.ErrBadConn
 	}
 	data = append(data, buf...)
@@ -94,6 +254,13 @@ func (p *PacketIO) ReadPacket() ([]byte, error) {
 
 // WritePacket is to write packet.
 func (p *PacketIO) WritePacket(data []byte) error {
+	// command starts a new exchange, so does sequence of compressed packets.
+	if p.Sequence == 0 && p.compressed != nil {
+		if err := p.Flush(); err != nil {
+			return err
+		}
+		p.compressed.seq = 0
+	}
 	length := len(data) - 4
 
 	for length >= MaxPayloadLen {
@@ -104,15 +271,16 @@ func (p *PacketIO) WritePacket(data []byte) error {
 
 		data[3] = p.Sequence
 
-		if n, err := p.wb.Write(data[:4+MaxPayloadLen]); err != nil {
-			return errors.ErrBadConn
-		} else if n != (4 + MaxPayloadLen) {
-			return errors.ErrBadConn
-		} else {
-			p.Sequence++
-			length -= MaxPayloadLen
-			data = data[MaxPayloadLen:]
+		// header of next part overwrites tail of this part, so flush it.
+		if err := p.write(data[:4+MaxPayloadLen]); err != nil {
+			return err
+		}
+		if err := p.Flush(); err != nil {
+			return err
 		}
+		p.Sequence++
+		length -= MaxPayloadLen
+		data = data[MaxPayloadLen:]
 	}
 
 	data[0] = byte(length)
@@ -120,15 +288,12 @@ func (p *PacketIO) WritePacket(data []byte) error {
 	data[2] = byte(length >> 16)
 	data[3] = p.Sequence
 
-	if n, err := p.wb.Write(data); err != nil {
-		return errors.ErrBadConn
-	} else if n != len(data) {
-		return errors.ErrBadConn
-	} else {
-		p.Sequence++
-		PrintPacketData("WritePacket", data)
-		return nil
+	if err := p.write(data); err != nil {
+		return err
 	}
+	p.Sequence++
+	PrintPacketData("WritePacket", data)
+	return nil
 }
 
 // WritePacketBatch is to write packet in batch
@@ -136,12 +301,8 @@ func (p *PacketIO) WritePacketBatch(total, data []byte, direct bool) ([]byte, er
 	if data == nil {
 		//only flush the buffer
 		if direct == true {
-			n, err := p.wb.Write(total)
-			if err != nil {
-				return nil, errors.ErrBadConn
-			}
-			if n != len(total) {
-				return nil, errors.ErrBadConn
+			if err := p.write(total); err != nil {
+				return nil, err
 			}
 			PrintPacketData("WritePacketBatch", total)
 		}
@@ -172,10 +333,8 @@ func (p *PacketIO) WritePacketBatch(total, data []byte, direct bool) ([]byte, er
 	p.Sequence++
 
 	if direct {
-		if n, err := p.wb.Write(total); err != nil {
-			return nil, errors.ErrBadConn
-		} else if n != len(total) {
-			return nil, errors.ErrBadConn
+		if err := p.write(total); err != nil {
+			return nil, err
 		}
 		PrintPacketData("WritePacketBatch", total)
 	}
@@ -139,6 +139,14 @@ func (p *PacketIO) Query(capability uint32, status *uint16, query string) (*Resu
 	return result, err
 }
 
+// QueryRaw use command COM_QUERY, rows of result set are not decoded.
+func (p *PacketIO) QueryRaw(capability uint32, status *uint16, query string) (*Result, error) {
+	if err := p.WriteCommandStr(COM_QUERY, query); err != nil {
+		return nil, err
+	}
+	return p.ReadRawResultSet(capability, status, false)
+}
+
 // FieldList use command COM_FIELD_LIST
 func (p *PacketIO) FieldList(capability uint32, table string, wildcard string) ([]*Field, error) {
 	if err := p.WriteCommandStrStr(COM_FIELD_LIST, table, wildcard); err != nil {
@@ -0,0 +1,177 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mysql
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"io"
+	"net"
+
+	"github.com/berkaroad/saashard/errors"
+)
+
+const (
+	// compressedHeaderSize is length of compressed payload, sequence, and length of payload before compressed.
+	compressedHeaderSize = 7
+	// minCompressLength is min length of payload compressed, smaller one is sent as is.
+	minCompressLength = 50
+)
+
+// SetCompressed switch to compressed protocol by zlib, after OK of handshake with CLIENT_COMPRESS negotiated.
+// zstd of CLIENT_ZSTD_COMPRESSION_ALGORITHM is not supported, it's neither advertised nor requested.
+func (p *PacketIO) SetCompressed() error {
+	if err := p.Flush(); err != nil {
+		return err
+	}
+	p.compressed = &compressedConn{r: p.rb, w: p.wb}
+	p.rb = bufio.NewReaderSize(p.compressed, defaultReaderSize)
+	p.wb = p.compressed
+	return nil
+}
+
+// IsCompressed is true if switched to compressed protocol.
+func (p *PacketIO) IsCompressed() bool {
+	return p.compressed != nil
+}
+
+// compressedConn read and write packets wrapped in compressed packets.
+// Sequence of compressed packets is independent of packets in them, it's reset when a command is written,
+// and followed by compressed packets read.
+type compressedConn struct {
+	r   io.Reader
+	w   io.Writer
+	seq uint8
+
+	// payload decompressed, but not read yet.
+	rbuf []byte
+
+	zw   *zlib.Writer
+	zbuf bytes.Buffer
+}
+
+func (c *compressedConn) Read(b []byte) (int, error) {
+	for len(c.rbuf) == 0 {
+		if err := c.readCompressed(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(b, c.rbuf)
+	c.rbuf = c.rbuf[n:]
+	return n, nil
+}
+
+// readCompressed read a compressed packet, payload isn't compressed if length before compressed is 0.
+func (c *compressedConn) readCompressed() error {
+	header := make([]byte, compressedHeaderSize)
+	if _, err := io.ReadFull(c.r, header); err != nil {
+		return err
+	}
+	length := int(uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16)
+	uncompressedLength := int(uint32(header[4]) | uint32(header[5])<<8 | uint32(header[6])<<16)
+	c.seq = header[3] + 1
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return err
+	}
+	if uncompressedLength == 0 {
+		c.rbuf = payload
+		return nil
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return errors.ErrMalformPacket
+	}
+	data := make([]byte, uncompressedLength)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return errors.ErrMalformPacket
+	}
+	c.rbuf = data
+	return nil
+}
+
+// Write packets in compressed packets, each of them has no more than MaxPayloadLen before compressed.
+func (c *compressedConn) Write(data []byte) (int, error) {
+	written := 0
+	for len(data) > 0 {
+		size := len(data)
+		if size > MaxPayloadLen {
+			size = MaxPayloadLen
+		}
+		if err := c.writeCompressed(data[:size]); err != nil {
+			return written, err
+		}
+		written += size
+		data = data[size:]
+	}
+	return written, nil
+}
+
+// writeCompressed write a compressed packet, small or incompressible payload is sent as is.
+func (c *compressedConn) writeCompressed(data []byte) error {
+	payload := data
+	uncompressedLength := 0
+	if len(data) >= minCompressLength {
+		c.zbuf.Reset()
+		if c.zw == nil {
+			c.zw = zlib.NewWriter(&c.zbuf)
+		} else {
+			c.zw.Reset(&c.zbuf)
+		}
+		if _, err := c.zw.Write(data); err != nil {
+			return err
+		}
+		if err := c.zw.Close(); err != nil {
+			return err
+		}
+		if c.zbuf.Len() < len(data) {
+			payload = c.zbuf.Bytes()
+			uncompressedLength = len(data)
+		}
+	}
+
+	header := make([]byte, compressedHeaderSize)
+	header[0] = byte(len(payload))
+	header[1] = byte(len(payload) >> 8)
+	header[2] = byte(len(payload) >> 16)
+	header[3] = c.seq
+	header[4] = byte(uncompressedLength)
+	header[5] = byte(uncompressedLength >> 8)
+	header[6] = byte(uncompressedLength >> 16)
+	c.seq++
+
+	bufs := net.Buffers{header, payload}
+	_, err := bufs.WriteTo(c.w)
+	return err
+}
+
+// buffered is count of bytes read from connection, but not read by packets yet.
+func (c *compressedConn) buffered() int {
+	n := len(c.rbuf)
+	if rb, ok := c.r.(*bufio.Reader); ok {
+		n += rb.Buffered()
+	}
+	return n
+}
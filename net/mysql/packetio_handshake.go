@@ -25,9 +25,10 @@ package mysql
 import (
 	"bytes"
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/berkaroad/saashard/errors"
 )
 
 // WriteInitialHandshake write initial handshake
@@ -36,7 +37,8 @@ import (
 // collationID:
 // capability:
 // status:
-func (p *PacketIO) WriteInitialHandshake(connectionID uint32, salt []byte, collationID CollationID, capability uint32, status uint16) error {
+// authPlugin: default auth plugin, sent if capability has CLIENT_PLUGIN_AUTH.
+func (p *PacketIO) WriteInitialHandshake(connectionID uint32, salt []byte, collationID CollationID, capability uint32, status uint16, authPlugin string) error {
 	data := make([]byte, 4, 128)
 
 	//min version 10
@@ -82,12 +84,19 @@ func (p *PacketIO) WriteInitialHandshake(connectionID uint32, salt []byte, colla
 	//filter [00]
 	data = append(data, 0)
 
+	//auth-plugin name [null terminated string]
+	if capability&CLIENT_PLUGIN_AUTH > 0 {
+		data = append(data, authPlugin...)
+		data = append(data, 0)
+	}
+
 	return p.WritePacket(data)
 }
 
-// ReadInitialHandshake read initial handshake.
+// ReadInitialHandshake read initial handshake, threadID is connection id in server.
 // salt:
-func (p *PacketIO) ReadInitialHandshake(salt *[]byte) (capability uint32, status uint16, collationID CollationID, err error) {
+// authPlugin: default auth plugin of server, empty if not CLIENT_PLUGIN_AUTH.
+func (p *PacketIO) ReadInitialHandshake(salt *[]byte) (capability uint32, status uint16, collationID CollationID, threadID uint32, authPlugin string, err error) {
 	var data []byte
 	data, err = p.ReadPacket()
 	if err != nil {
@@ -104,10 +113,12 @@ func (p *PacketIO) ReadInitialHandshake(salt *[]byte) (capability uint32, status
 		return
 	}
 
-	//skip mysql version and connection id
+	//skip mysql version
 	//mysql version end with 0x00
 	//connection id length is 4
-	pos := 1 + bytes.IndexByte(data[1:], 0x00) + 1 + 4
+	pos := 1 + bytes.IndexByte(data[1:], 0x00) + 1
+	threadID = binary.LittleEndian.Uint32(data[pos : pos+4])
+	pos += 4
 
 	*salt = append(*salt, data[pos:pos+8]...)
 
@@ -141,14 +152,30 @@ func (p *PacketIO) ReadInitialHandshake(salt *[]byte) (capability uint32, status
 		// mysql-proxy also use 12
 		// which is not documented but seems to work.
 		*salt = append(*salt, data[pos:pos+12]...)
+		pos += 12 + 1
+
+		if capability&CLIENT_PLUGIN_AUTH > 0 && pos < len(data) {
+			if end := bytes.IndexByte(data[pos:], 0); end >= 0 {
+				authPlugin = string(data[pos : pos+end])
+			} else {
+				authPlugin = string(data[pos:])
+			}
+		}
 	}
 	return
 }
 
-// WriteAuthHandshake write auth handshake
-func (p *PacketIO) WriteAuthHandshake(capability *uint32, user, password, db string, salt []byte, collationID CollationID) error {
+// WriteAuthHandshake write auth handshake, auth is calculated by auth plugin of server if supported, or mysql_native_password.
+func (p *PacketIO) WriteAuthHandshake(capability *uint32, user, password, db string, salt []byte, collationID CollationID, authPlugin string) error {
 	// Adjust client capability flags based on server support
-	*capability &= DEFAULT_CAPABILITY
+	serverCapability := *capability
+	*capability &= BACKEND_CAPABILITY
+	if p.TLSConfig != nil && serverCapability&CLIENT_SSL > 0 {
+		*capability |= CLIENT_SSL
+	}
+	if p.Compress && serverCapability&CLIENT_COMPRESS > 0 {
+		*capability |= CLIENT_COMPRESS
+	}
 
 	//packet length
 	//capbility 4
@@ -161,7 +188,7 @@ func (p *PacketIO) WriteAuthHandshake(capability *uint32, user, password, db str
 	length += len(user) + 1
 
 	//we only support secure connection
-	auth := CalcPassword(salt, []byte(password))
+	auth, authPlugin := calcAuthResponse(authPlugin, salt, password)
 
 	length += 1 + len(auth)
 
@@ -171,6 +198,10 @@ func (p *PacketIO) WriteAuthHandshake(capability *uint32, user, password, db str
 		length += len(db) + 1
 	}
 
+	if *capability&CLIENT_PLUGIN_AUTH > 0 {
+		length += len(authPlugin) + 1
+	}
+
 	data := make([]byte, length+4)
 
 	//capability [32 bit]
@@ -208,25 +239,65 @@ func (p *PacketIO) WriteAuthHandshake(capability *uint32, user, password, db str
 	if len(db) > 0 {
 		pos += copy(data[pos:], db)
 		//data[pos] = 0x00
+		pos++
+	}
+
+	// auth plugin name [null terminated string]
+	if *capability&CLIENT_PLUGIN_AUTH > 0 {
+		copy(data[pos:], authPlugin)
 	}
 
+	// SSL request is the head of handshake response, then handshake response is written over TLS.
+	if *capability&CLIENT_SSL > 0 {
+		if err := p.WritePacket(append([]byte(nil), data[:4+4+4+1+23]...)); err != nil {
+			return err
+		}
+		if err := p.upgradeTLS(false); err != nil {
+			return err
+		}
+	}
 	return p.WritePacket(data)
 }
 
-// ReadHandshakeResponse read handshake response
-func (p *PacketIO) ReadHandshakeResponse(getDefaultSchemaByUser func(user string) (string, error), remoteAddr string, salt []byte, getCredentialsConfigBySchema func(db string) (user, password string, err error)) (capability uint32, collationID CollationID, user, db string, err error) {
+// HandshakeClient is what client advertised in handshake response, checked by policy before auth.
+type HandshakeClient struct {
+	Capability uint32            // capability flags of client, not negotiated with server.
+	AuthPlugin string            // auth plugin name, if CLIENT_PLUGIN_AUTH.
+	Attrs      map[string]string // connection attributes if CLIENT_CONNECT_ATTRS, such as '_client_name' and '_client_version'.
+}
+
+// ReadHandshakeResponse read handshake response, auth is passed if matches any of passwords, such as primary and secondary password in rotation.
+// checkClient is optional, to reject client by its capability and connection attributes.
+func (p *PacketIO) ReadHandshakeResponse(getDefaultSchemaByUser func(user string) (string, error), remoteAddr string, salt []byte, getCredentialsConfigBySchema func(db string) (user string, passwords []string, err error), checkClient func(client *HandshakeClient) error) (capability uint32, collationID CollationID, user, db string, err error) {
 	var data []byte
 	data, err = p.ReadPacket()
+	if err == nil && len(data) == 32 && binary.LittleEndian.Uint32(data[:4])&CLIENT_SSL > 0 {
+		// SSL request, then handshake response is read over TLS.
+		if p.TLSConfig == nil {
+			err = errors.ErrMalformPacket
+			return
+		}
+		if err = p.upgradeTLS(true); err == nil {
+			data, err = p.ReadPacket()
+		}
+	}
 
 	if err != nil {
 		return
 	}
+	if len(data) < 32 {
+		err = errors.ErrMalformPacket
+		return
+	}
 
 	pos := 0
 
 	//capability
-	capability = binary.LittleEndian.Uint32(data[:4])
-	capability = capability & DEFAULT_CAPABILITY
+	client := new(HandshakeClient)
+	client.Capability = binary.LittleEndian.Uint32(data[:4])
+	// local files are sent by client if it's on, whether relayed or not is decided by proxy.
+	// compressed protocol is requested by client only if it's advertised by proxy.
+	capability = client.Capability & (DEFAULT_CAPABILITY | CLIENT_LOCAL_FILES | CLIENT_COMPRESS)
 	pos += 4
 
 	//skip max packet size
@@ -247,21 +318,51 @@ func (p *PacketIO) ReadHandshakeResponse(getDefaultSchemaByUser func(user string
 	//auth length and auth
 	authLen := int(data[pos])
 	pos++
+	if client.Capability&CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA > 0 && authLen >= 0xfb {
+		num, n, ok := readLenencInt(data[pos-1:])
+		if !ok || num > uint64(len(data)) {
+			err = errors.ErrMalformPacket
+			return
+		}
+		authLen = int(num)
+		pos += n - 1
+	}
+	if len(data) < pos+authLen {
+		err = errors.ErrMalformPacket
+		return
+	}
 
 	auth := data[pos : pos+authLen]
 	pos += authLen
 
-	if capability&CLIENT_CONNECT_WITH_DB > 0 {
-		if len(data[pos:]) == 0 {
-			//if connect with non-name database, use default db
-			db, err = getDefaultSchemaByUser(user)
+	if capability&CLIENT_CONNECT_WITH_DB > 0 && len(data[pos:]) > 0 {
+		if end := bytes.IndexByte(data[pos:], 0); end >= 0 {
+			db = string(data[pos : pos+end])
+		} else {
+			db = string(data[pos:])
 		}
-
-		db = string(data[pos : pos+bytes.IndexByte(data[pos:], 0)])
 		pos += len(db) + 1
-
-	} else {
-		//if connect without database, use default db
+	}
+	if client.Capability&CLIENT_PLUGIN_AUTH > 0 && pos < len(data) {
+		if end := bytes.IndexByte(data[pos:], 0); end >= 0 {
+			client.AuthPlugin = string(data[pos : pos+end])
+		} else {
+			client.AuthPlugin = string(data[pos:])
+		}
+		pos += len(client.AuthPlugin) + 1
+	}
+	if client.Capability&CLIENT_CONNECT_ATTRS > 0 && pos < len(data) {
+		if client.Attrs, err = parseConnectAttrs(data[pos:]); err != nil {
+			return
+		}
+	}
+	if checkClient != nil {
+		if err = checkClient(client); err != nil {
+			return
+		}
+	}
+	if len(db) == 0 {
+		//if connect without database or with empty name, use default db
 		db, err = getDefaultSchemaByUser(user)
 	}
 	if err != nil {
@@ -269,15 +370,63 @@ func (p *PacketIO) ReadHandshakeResponse(getDefaultSchemaByUser func(user string
 	}
 	db = strings.ToLower(db)
 
-	var configUser, configPassword string
-	configUser, configPassword, err = getCredentialsConfigBySchema(db)
+	var configUser string
+	var configPasswords []string
+	configUser, configPasswords, err = getCredentialsConfigBySchema(db)
 	if err != nil {
 		return
 	}
-	checkAuth := CalcPassword(salt, []byte(configPassword))
-	if user != configUser || !bytes.Equal(auth, checkAuth) {
-		err = NewDefaultError(ER_ACCESS_DENIED_ERROR, user, remoteAddr, "Yes")
-		return
+	if user == configUser {
+		var passed bool
+		if passed, err = p.checkAuth(client.AuthPlugin, auth, salt, configPasswords); passed || err != nil {
+			return
+		}
 	}
+	err = NewDefaultError(ER_ACCESS_DENIED_ERROR, user, remoteAddr, "Yes")
 	return
 }
+
+// parseConnectAttrs parse connection attributes, lenenc_int of total length and key-value pairs of lenenc_str.
+func parseConnectAttrs(data []byte) (map[string]string, error) {
+	total, pos, ok := readLenencInt(data)
+	if !ok || uint64(len(data)-pos) < total {
+		return nil, errors.ErrMalformPacket
+	}
+	data = data[pos : pos+int(total)]
+	attrs := make(map[string]string)
+	for len(data) > 0 {
+		var pair [2]string
+		for i := range pair {
+			length, n, ok := readLenencInt(data)
+			if !ok || uint64(len(data)-n) < length {
+				return nil, errors.ErrMalformPacket
+			}
+			pair[i] = string(data[n : n+int(length)])
+			data = data[n+int(length):]
+		}
+		attrs[pair[0]] = pair[1]
+	}
+	return attrs, nil
+}
+
+// readLenencInt read lenenc_int with bounds check, NULL is not allowed.
+func readLenencInt(data []byte) (num uint64, n int, ok bool) {
+	if len(data) == 0 || data[0] == 0xfb || data[0] == 0xff {
+		return 0, 0, false
+	}
+	switch data[0] {
+	case 0xfc:
+		n = 3
+	case 0xfd:
+		n = 4
+	case 0xfe:
+		n = 9
+	default:
+		n = 1
+	}
+	if len(data) < n {
+		return 0, 0, false
+	}
+	num, _, n = LenencIntToNumber(data)
+	return num, n, true
+}
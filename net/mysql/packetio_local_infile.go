@@ -0,0 +1,64 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mysql
+
+import (
+	"github.com/berkaroad/saashard/errors"
+)
+
+// handleLocalInfilePacket send local file requested by server after LOAD DATA LOCAL INFILE,
+// or refuse it by empty packet if no LocalInfile, then server responds OK or error.
+func (p *PacketIO) handleLocalInfilePacket(data []byte) error {
+	if p.LocalInfile != nil {
+		return p.LocalInfile(data[1:], p)
+	}
+	return p.WritePacket(make([]byte, 4))
+}
+
+// RelayLocalInfile request local file from client, and relay packets of it to server,
+// until empty packet at the end of file.
+// Server connection is closed if client fails to send the file, so that partial file is not loaded.
+func (p *PacketIO) RelayLocalInfile(filename []byte, server *PacketIO) error {
+	data := make([]byte, 4, 5+len(filename))
+	data = append(data, LocalInFile_HEADER)
+	data = append(data, filename...)
+	if err := p.WritePacket(data); err != nil {
+		server.Conn().Close()
+		return err
+	}
+	for {
+		data, err := p.readPacket(0, true)
+		if err != nil {
+			server.Conn().Close()
+			return errors.ErrBadConn
+		}
+		packet := make([]byte, 4, 4+len(data))
+		packet = append(packet, data...)
+		if err = server.WritePacket(packet); err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			return server.Flush()
+		}
+	}
+}
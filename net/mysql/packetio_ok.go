@@ -107,8 +107,8 @@ func (p *PacketIO) handleOKPacket(capability uint32, status *uint16, data []byte
 		pos += 2
 
 		//todo:strict_mode, check warnings as error
-		//Warnings := binary.LittleEndian.Uint16(data[pos:])
-		//pos += 2
+		r.Warnings = binary.LittleEndian.Uint16(data[pos:])
+		pos += 2
 	} else if capability&CLIENT_TRANSACTIONS > 0 {
 		r.Status = binary.LittleEndian.Uint16(data[pos:])
 		*status = r.Status
@@ -116,5 +116,28 @@ func (p *PacketIO) handleOKPacket(capability uint32, status *uint16, data []byte
 	}
 
 	//info
+	if pos >= len(data) {
+		return r, nil
+	}
+	if capability&CLIENT_SESSION_TRACK > 0 {
+		info, _, n, err := LenencStrToString(data[pos:])
+		if err != nil {
+			return nil, err
+		}
+		r.Info = string(info)
+		pos += n
+
+		if r.Status&SERVER_SESSION_STATE_CHANGED > 0 && pos < len(data) {
+			var stateInfo []byte
+			if stateInfo, _, _, err = LenencStrToString(data[pos:]); err != nil {
+				return nil, err
+			}
+			if r.SessionState, err = ParseSessionState(stateInfo); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		r.Info = string(data[pos:])
+	}
 	return r, nil
 }
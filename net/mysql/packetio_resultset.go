@@ -28,6 +28,9 @@ import (
 	"github.com/berkaroad/saashard/errors"
 )
 
+// resultSetBatchSize is size of rows written in a batch.
+const resultSetBatchSize = 64 * 1024
+
 // WriteResultSet is to write Result Set packet.
 func (p *PacketIO) WriteResultSet(capability uint32, status uint16, r *Result) error {
 	total := make([]byte, 0, 1024)
@@ -54,6 +57,13 @@ func (p *PacketIO) WriteResultSet(capability uint32, status uint16, r *Result) e
 			if err != nil {
 				return err
 			}
+			// write rows in batches, instead of whole result set in memory.
+			if len(total) >= resultSetBatchSize {
+				if _, err = p.WritePacketBatch(total, nil, true); err != nil {
+					return err
+				}
+				total = make([]byte, 0, resultSetBatchSize+1024)
+			}
 		}
 	}
 	if capability&CLIENT_DEPRECATE_EOF > 0 {
@@ -72,6 +82,15 @@ func (p *PacketIO) WriteResultSet(capability uint32, status uint16, r *Result) e
 
 // ReadResultSet read result set.
 func (p *PacketIO) ReadResultSet(capability uint32, status *uint16, binary bool) (*Result, error) {
+	return p.readResultSet(capability, status, binary, false)
+}
+
+// ReadRawResultSet read result set, rows are kept as raw packets without decoding values.
+func (p *PacketIO) ReadRawResultSet(capability uint32, status *uint16, binary bool) (*Result, error) {
+	return p.readResultSet(capability, status, binary, true)
+}
+
+func (p *PacketIO) readResultSet(capability uint32, status *uint16, binary bool, raw bool) (*Result, error) {
 	data, err := p.ReadPacket()
 	if err != nil {
 		return nil, err
@@ -82,10 +101,13 @@ func (p *PacketIO) ReadResultSet(capability uint32, status *uint16, binary bool)
 	} else if data[0] == ERR_HEADER {
 		return nil, p.handleErrorPacket(capability, data)
 	} else if data[0] == LocalInFile_HEADER {
-		return nil, errors.ErrMalformPacket
+		if err = p.handleLocalInfilePacket(data); err != nil {
+			return nil, err
+		}
+		return p.readResultSet(capability, status, binary, raw)
 	}
 
-	return p.handleResultsetPacket(capability, status, data, binary)
+	return p.handleResultsetPacket(capability, status, data, binary, raw)
 }
 
 // WriteFieldList write fieldlist.
@@ -127,7 +149,7 @@ func (p *PacketIO) writeResultSetRowData(total []byte, r *Row) ([]byte, error) {
 	return p.WritePacketBatch(total, data, false)
 }
 
-func (p *PacketIO) handleResultsetPacket(capability uint32, status *uint16, data []byte, binary bool) (*Result, error) {
+func (p *PacketIO) handleResultsetPacket(capability uint32, status *uint16, data []byte, binary bool, raw bool) (*Result, error) {
 	result := &Result{
 		Status:       0,
 		InsertID:     0,
@@ -150,7 +172,7 @@ func (p *PacketIO) handleResultsetPacket(capability uint32, status *uint16, data
 		return nil, err
 	}
 
-	if err := p.handleResultRows(capability, status, result, binary); err != nil {
+	if err := p.handleResultRows(capability, status, result, binary, raw); err != nil {
 		return nil, err
 	}
 
@@ -194,7 +216,7 @@ func (p *PacketIO) handleResultColumns(capability uint32, status *uint16, result
 	}
 }
 
-func (p *PacketIO) handleResultRows(capability uint32, status *uint16, result *Result, isBinary bool) (err error) {
+func (p *PacketIO) handleResultRows(capability uint32, status *uint16, result *Result, isBinary bool, raw bool) (err error) {
 	var data []byte
 
 	for {
@@ -215,6 +237,10 @@ func (p *PacketIO) handleResultRows(capability uint32, status *uint16, result *R
 
 			break
 		}
+		if raw {
+			result.Rows = append(result.Rows, &Row{Data: data, isBinary: isBinary, fields: result.Fields})
+			continue
+		}
 		var row *Row
 		row, err = RowData(data).Parse(isBinary, result.Fields)
 		if err != nil {
@@ -223,6 +249,10 @@ func (p *PacketIO) handleResultRows(capability uint32, status *uint16, result *R
 		result.Rows = append(result.Rows, row)
 	}
 
+	if raw {
+		result.raw = true
+		return nil
+	}
 	result.Values = make([][]interface{}, len(result.Rows))
 
 	for i := range result.Values {
@@ -137,7 +137,7 @@ func (p *PacketIO) ReadStmtExecuteRequest(data []byte, findStmtByID func(id uint
 		nullBitmaps = data[pos : pos+nullBitmapLen]
 		pos += nullBitmapLen
 
-		//new param bound flag
+		//new param bound flag, types of last execute are reused if not bound.
 		if data[pos] == 1 {
 			pos++
 			if len(data) < (pos + (paramNum << 1)) {
@@ -146,17 +146,51 @@ func (p *PacketIO) ReadStmtExecuteRequest(data []byte, findStmtByID func(id uint
 
 			paramTypes = data[pos : pos+(paramNum<<1)]
 			pos += (paramNum << 1)
-
-			paramValues = data[pos:]
-
-			if err := p.bindStmtArgs(s, nullBitmaps, paramTypes, paramValues); err != nil {
-				return nil, err
+			s.ParamTypes = append(s.ParamTypes[:0], paramTypes...)
+		} else {
+			pos++
+			if len(s.ParamTypes) != paramNum<<1 {
+				return nil, errors.ErrMalformPacket
 			}
+			paramTypes = s.ParamTypes
+		}
+		paramValues = data[pos:]
+
+		if err := s.longDataErr; err != nil {
+			s.ResetParams()
+			return nil, err
+		}
+		if err := p.bindStmtArgs(s, nullBitmaps, paramTypes, paramValues); err != nil {
+			return nil, err
 		}
 	}
 	return s, nil
 }
 
+// ReadStmtSendLongDataRequest read COM_STMT_SEND_LONG_DATA, append value of param to stmt.
+// There's no response, error of unknown stmt is ignored, and others are reported by next execute.
+func (p *PacketIO) ReadStmtSendLongDataRequest(data []byte, findStmtByID func(id uint32) *Stmt) error {
+	if len(data) < 6 {
+		return errors.ErrMalformPacket
+	}
+	id := binary.LittleEndian.Uint32(data[0:4])
+	s := findStmtByID(id)
+	if s == nil {
+		return NewDefaultError(ER_UNKNOWN_STMT_HANDLER,
+			strconv.FormatUint(uint64(id), 10), "stmt_send_long_data")
+	}
+	s.AppendLongData(int(binary.LittleEndian.Uint16(data[4:6])), data[6:], p.MaxAllowedPacket)
+	return nil
+}
+
+// ReadStmtID read stmt id of COM_STMT_CLOSE or COM_STMT_RESET.
+func (p *PacketIO) ReadStmtID(data []byte) (uint32, error) {
+	if len(data) < 4 {
+		return 0, errors.ErrMalformPacket
+	}
+	return binary.LittleEndian.Uint32(data[0:4]), nil
+}
+
 func (p *PacketIO) bindStmtArgs(s *Stmt, nullBitmap, paramTypes, paramValues []byte) error {
 	args := s.Args
 
@@ -168,6 +202,11 @@ func (p *PacketIO) bindStmtArgs(s *Stmt, nullBitmap, paramTypes, paramValues []b
 	var err error
 
 	for i := 0; i < s.ParamNum; i++ {
+		// value of long data is not sent in execute.
+		if value, ok := s.longData[i]; ok {
+			args[i] = value
+			continue
+		}
 		if nullBitmap[i>>3]&(1<<(uint(i)%8)) > 0 {
 			args[i] = nil
 			continue
@@ -256,8 +295,7 @@ func (p *PacketIO) bindStmtArgs(s *Stmt, nullBitmap, paramTypes, paramValues []b
 			MYSQL_TYPE_BIT, MYSQL_TYPE_ENUM, MYSQL_TYPE_SET, MYSQL_TYPE_TINY_BLOB,
 			MYSQL_TYPE_MEDIUM_BLOB, MYSQL_TYPE_LONG_BLOB, MYSQL_TYPE_BLOB,
 			MYSQL_TYPE_VAR_STRING, MYSQL_TYPE_STRING, MYSQL_TYPE_GEOMETRY,
-			MYSQL_TYPE_DATE, MYSQL_TYPE_NEWDATE,
-			MYSQL_TYPE_TIMESTAMP, MYSQL_TYPE_DATETIME, MYSQL_TYPE_TIME:
+			MYSQL_TYPE_JSON:
 			if len(paramValues) < (pos + 1) {
 				return errors.ErrMalformPacket
 			}
@@ -275,6 +313,32 @@ func (p *PacketIO) bindStmtArgs(s *Stmt, nullBitmap, paramTypes, paramValues []b
 				args[i] = nil
 				continue
 			}
+		case MYSQL_TYPE_DATE, MYSQL_TYPE_NEWDATE,
+			MYSQL_TYPE_TIMESTAMP, MYSQL_TYPE_DATETIME, MYSQL_TYPE_TIME:
+			// length of value in a byte, then date, time and microseconds.
+			if len(paramValues) < (pos + 1) {
+				return errors.ErrMalformPacket
+			}
+			num := int(paramValues[pos])
+			if len(paramValues) < (pos + 1 + num) {
+				return errors.ErrMalformPacket
+			}
+			data := paramValues[pos+1 : pos+1+num]
+			switch {
+			case tp == MYSQL_TYPE_TIME:
+				v, err = FormatBinaryTime(num, data)
+			case num <= 4 && (tp == MYSQL_TYPE_DATE || tp == MYSQL_TYPE_NEWDATE):
+				v, err = FormatBinaryDate(num, data)
+			default:
+				v, err = FormatBinaryDateTime(num, data)
+			}
+			if err != nil {
+				return err
+			}
+			args[i] = v
+			pos += 1 + num
+			continue
+
 		default:
 			return fmt.Errorf("Stmt Unknown FieldType %d", tp)
 		}
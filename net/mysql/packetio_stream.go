@@ -0,0 +1,195 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mysql
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/berkaroad/saashard/errors"
+)
+
+// streamChunkSize is size of chunk to forward payload of large packet, smaller packet is read as a whole.
+const streamChunkSize = 64 * 1024
+
+// QueryStream use command COM_QUERY, and write result set to dst as it's read, see StreamResultSet.
+func (p *PacketIO) QueryStream(capability uint32, status *uint16, query string, dst *PacketIO, dstCapability uint32, dstStatus uint16) (*Result, error) {
+	if err := p.WriteCommandStr(COM_QUERY, query); err != nil {
+		return nil, err
+	}
+	return p.StreamResultSet(capability, status, false, dst, dstCapability, dstStatus)
+}
+
+// StreamResultSet read result set and write it to dst with status of dst, such as to client, without rows kept in memory.
+// Large row, such as BLOB or TEXT values, is forwarded as packet fragments in chunks, instead of assembled.
+// Result without result set, such as OK packet, isn't written to dst.
+// Rows are read as dst is written, so slow reader of dst pauses reading from p by TCP backpressure.
+func (p *PacketIO) StreamResultSet(capability uint32, status *uint16, isBinary bool, dst *PacketIO, dstCapability uint32, dstStatus uint16) (*Result, error) {
+	return p.StreamResultSetPart(capability, status, isBinary, dst, dstCapability, dstStatus, nil, true)
+}
+
+// StreamResultSetPart stream result set as a part of result set merged from several backends, see StreamResultSet.
+// Header of result set is written by the first part whose merged is nil, and end of it by the last part,
+// headers of other parts are read and discarded, and rows of them are counted in merged.
+func (p *PacketIO) StreamResultSetPart(capability uint32, status *uint16, isBinary bool, dst *PacketIO, dstCapability uint32,
+	dstStatus uint16, merged *Result, last bool) (*Result, error) {
+	data, err := p.ReadPacket()
+	if err != nil {
+		return nil, err
+	}
+
+	if data[0] == OK_HEADER {
+		return p.handleOKPacket(capability, status, data)
+	} else if data[0] == ERR_HEADER {
+		return nil, p.handleErrorPacket(capability, data)
+	} else if data[0] == LocalInFile_HEADER {
+		if err = p.handleLocalInfilePacket(data); err != nil {
+			return nil, err
+		}
+		return p.StreamResultSetPart(capability, status, isBinary, dst, dstCapability, dstStatus, merged, last)
+	}
+
+	result := &Result{Resultset: &Resultset{streamed: true}}
+	count, _, n := LenencIntToNumber(data)
+	if n-len(data) != 0 {
+		return nil, errors.ErrMalformPacket
+	}
+	result.Fields = make([]*Field, count)
+	result.FieldNames = make(map[string]int, count)
+	if err = p.handleResultColumns(capability, status, result); err != nil {
+		return nil, err
+	}
+
+	if merged == nil {
+		total := make([]byte, 0, 1024)
+		if total, err = dst.writeResultSetHeader(total, result); err != nil {
+			return nil, err
+		}
+		for _, f := range result.Fields {
+			if total, err = dst.writeResultSetField(total, f); err != nil {
+				return nil, err
+			}
+		}
+		if dstCapability&CLIENT_DEPRECATE_EOF == 0 {
+			_, err = dst.WriteEOFBatch(total, dstCapability, dstStatus, true)
+		} else {
+			_, err = dst.WritePacketBatch(total, nil, true)
+		}
+		if err != nil {
+			return nil, err
+		}
+		merged = result
+	} else if len(result.Fields) != len(merged.Fields) {
+		return nil, fmt.Errorf("result set has %d columns, but %d of others", len(result.Fields), len(merged.Fields))
+	}
+
+	for {
+		if data, err = p.forwardPacket(dst); err != nil {
+			return nil, err
+		}
+		if data == nil {
+			// large row forwarded.
+			merged.streamedRows++
+			continue
+		}
+		if p.isEOFPacket(data) {
+			if capability&CLIENT_PROTOCOL_41 > 0 {
+				merged.Status = binary.LittleEndian.Uint16(data[3:])
+				*status = merged.Status
+			}
+			break
+		}
+		if data[0] == ERR_HEADER {
+			return nil, p.handleErrorPacket(capability, data)
+		}
+		row := make([]byte, 4, 4+len(data))
+		row = append(row, data...)
+		if err = dst.WritePacket(row); err != nil {
+			return nil, err
+		}
+		merged.streamedRows++
+	}
+	if !last {
+		return merged, nil
+	}
+
+	if dstCapability&CLIENT_DEPRECATE_EOF > 0 {
+		_, err = dst.WriteOKBatch(nil, dstCapability, dstStatus, merged, true)
+	} else {
+		_, err = dst.WriteEOFBatch(nil, dstCapability, dstStatus, true)
+	}
+	return merged, err
+}
+
+// forwardPacket read packet, and write it to dst in fragments and chunks if it's large, then nil is returned.
+// Packet smaller than chunk is returned without written, such as row, EOF or error packet.
+func (p *PacketIO) forwardPacket(dst *PacketIO) ([]byte, error) {
+	for first := true; ; first = false {
+		header := []byte{0, 0, 0, 0}
+		if _, err := io.ReadFull(p.rb, header); err != nil {
+			return nil, errors.ErrBadConn
+		}
+		length := int(uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16)
+		if header[3] != p.Sequence {
+			return nil, fmt.Errorf("invalid sequence %d != %d", header[3], p.Sequence)
+		}
+		p.Sequence++
+		p.BytesRead += uint64(4 + length)
+
+		if first && length < streamChunkSize {
+			if length < 1 {
+				return nil, fmt.Errorf("invalid payload length %d", length)
+			}
+			data := make([]byte, length)
+			if _, err := io.ReadFull(p.rb, data); err != nil {
+				return nil, errors.ErrBadConn
+			}
+			return data, nil
+		}
+
+		// fragment of large packet, the same length with sequence of dst.
+		header[3] = dst.Sequence
+		if err := dst.write(header); err != nil {
+			return nil, err
+		}
+		dst.Sequence++
+		for remain := length; remain > 0; {
+			size := remain
+			if size > streamChunkSize {
+				size = streamChunkSize
+			}
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(p.rb, chunk); err != nil {
+				return nil, errors.ErrBadConn
+			}
+			if err := dst.write(chunk); err != nil {
+				return nil, err
+			}
+			remain -= size
+		}
+		if length < MaxPayloadLen {
+			return nil, nil
+		}
+	}
+}
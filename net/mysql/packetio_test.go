@@ -0,0 +1,685 @@
+package mysql
+
+import (
+	"bytes"
+	cryptorand "crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"strings"
+	"testing"
+)
+
+// recordConn record writes, and read from buffer.
+type recordConn struct {
+	net.Conn
+	rd     bytes.Buffer
+	wr     bytes.Buffer
+	writes int
+}
+
+func (c *recordConn) Read(b []byte) (int, error) {
+	return c.rd.Read(b)
+}
+
+func (c *recordConn) Write(b []byte) (int, error) {
+	c.writes++
+	return c.wr.Write(b)
+}
+
+func writeResponses(t *testing.T, p *PacketIO) {
+	fields := []*Field{{Name: []byte("c"), ColumnType: MYSQL_TYPE_VAR_STRING, Charset: uint16(DEFAULT_COLLATION_ID)}}
+	r := &Result{Resultset: &Resultset{Fields: fields}}
+	for _, value := range []string{"a", strings.Repeat("b", writeChunkSize), "c"} {
+		row := NewTextRow(fields)
+		row.AppendStringValue(value)
+		r.Rows = append(r.Rows, row)
+	}
+	// many rows to be written in batches.
+	for i := 0; i < 2000; i++ {
+		row := NewTextRow(fields)
+		row.AppendStringValue(strings.Repeat("d", 100))
+		r.Rows = append(r.Rows, row)
+	}
+	if err := p.WriteOK(CLIENT_PROTOCOL_41, SERVER_MORE_RESULTS_EXISTS, nil); err != nil {
+		t.Fatal(err)
+	}
+	p.Sequence = 0
+	if err := p.WriteResultSet(CLIENT_PROTOCOL_41, SERVER_MORE_RESULTS_EXISTS, r); err != nil {
+		t.Fatal(err)
+	}
+	p.Sequence = 0
+	if err := p.WriteOK(CLIENT_PROTOCOL_41, 0, &Result{AffectedRows: 3}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPacketIOWriteBuffered(t *testing.T) {
+	direct := new(recordConn)
+	writeResponses(t, NewPacketIO(direct))
+
+	buffered := new(recordConn)
+	p := NewPacketIO(buffered)
+	p.SetWriteBuffered(true)
+	writeResponses(t, p)
+	if buffered.wr.Len() >= direct.wr.Len() {
+		t.Fatalf("expect packets buffered until flush, written %d of %d", buffered.wr.Len(), direct.wr.Len())
+	}
+	if err := p.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffered.wr.Bytes(), direct.wr.Bytes()) {
+		t.Fatal("expect the same packets written when buffered")
+	}
+
+	// small packets are coalesced into one write.
+	buffered.writes = 0
+	for i := 0; i < 3; i++ {
+		p.Sequence = 0
+		if err := p.WriteOK(CLIENT_PROTOCOL_41, SERVER_MORE_RESULTS_EXISTS, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if buffered.writes != 1 {
+		t.Errorf("expect 1 write of small packets, got %d", buffered.writes)
+	}
+}
+
+func TestPacketIOFlushBeforeRead(t *testing.T) {
+	conn := new(recordConn)
+	conn.rd.Write([]byte{1, 0, 0, 0, COM_PING})
+	p := NewPacketIO(conn)
+	p.SetWriteBuffered(true)
+	if err := p.WriteOK(CLIENT_PROTOCOL_41, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	if conn.wr.Len() > 0 {
+		t.Fatal("expect ok packet buffered")
+	}
+	p.Sequence = 0
+	if _, err := p.ReadPacket(); err != nil {
+		t.Fatal(err)
+	}
+	if conn.wr.Len() == 0 {
+		t.Fatal("expect ok packet flushed before read")
+	}
+}
+
+func TestPacketIOBytesCounted(t *testing.T) {
+	conn := new(recordConn)
+	conn.rd.Write([]byte{5, 0, 0, 0, COM_QUERY, 's', 'q', 'l', ';'})
+	p := NewPacketIO(conn)
+	p.SetWriteBuffered(true)
+	if _, err := p.ReadPacket(); err != nil {
+		t.Fatal(err)
+	}
+	if p.BytesRead != 9 {
+		t.Fatalf("expect 9 bytes read, but %d", p.BytesRead)
+	}
+	p.Sequence = 0
+	writeResponses(t, p)
+	if err := p.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if p.BytesWritten != uint64(conn.wr.Len()) {
+		t.Fatalf("expect %d bytes written, but %d", conn.wr.Len(), p.BytesWritten)
+	}
+}
+
+func TestReadPacketTooLarge(t *testing.T) {
+	conn := new(recordConn)
+	conn.rd.Write([]byte{5, 0, 0, 0, COM_QUERY, 's', 'e', 'l', 'e'})
+	conn.rd.Write([]byte{9, 0, 0, 0, COM_QUERY, 's', 'e', 'l', 'e', 'c', 't', ' ', '1'})
+	p := NewPacketIO(conn)
+	p.MaxAllowedPacket = 8
+	if _, err := p.ReadPacket(); err != nil {
+		t.Fatal(err)
+	}
+	p.Sequence = 0
+	if _, err := p.ReadPacket(); err != ErrPacketTooLarge {
+		t.Fatalf("expect packet too large, got %v", err)
+	}
+	// error is the next packet of command.
+	if err := p.WriteError(CLIENT_PROTOCOL_41, ErrPacketTooLarge); err != nil {
+		t.Fatal(err)
+	}
+	if seq := conn.wr.Bytes()[3]; seq != 1 {
+		t.Errorf("expect sequence 1 of error, got %d", seq)
+	}
+}
+
+func TestReadRawResultSet(t *testing.T) {
+	fields := []*Field{
+		{Name: []byte("id"), ColumnType: MYSQL_TYPE_LONGLONG, Charset: uint16(BINARY_COLLATION_ID)},
+		{Name: []byte("c"), ColumnType: MYSQL_TYPE_VAR_STRING, Charset: uint16(DEFAULT_COLLATION_ID)},
+	}
+	r := &Result{Resultset: &Resultset{Fields: fields}}
+	for i, value := range []string{"a", "b", "c"} {
+		row := NewTextRow(fields)
+		row.AppendIntValue(int64(i + 1))
+		row.AppendStringValue(value)
+		r.Rows = append(r.Rows, row)
+	}
+	backend := new(recordConn)
+	if err := NewPacketIO(backend).WriteResultSet(CLIENT_PROTOCOL_41, 0, r); err != nil {
+		t.Fatal(err)
+	}
+	sent := append([]byte(nil), backend.wr.Bytes()...)
+
+	conn := new(recordConn)
+	conn.rd.Write(sent)
+	var status uint16
+	raw, err := NewPacketIO(conn).ReadRawResultSet(CLIENT_PROTOCOL_41, &status, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !raw.IsRaw() || raw.RowNumber() != 3 || raw.Values != nil {
+		t.Fatalf("expect 3 raw rows without values, got raw=%v rows=%d", raw.IsRaw(), raw.RowNumber())
+	}
+
+	// raw rows are written as is.
+	client := new(recordConn)
+	if err = NewPacketIO(client).WriteResultSet(CLIENT_PROTOCOL_41, 0, raw); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(client.wr.Bytes(), sent) {
+		t.Fatal("expect the same packets written from raw rows")
+	}
+
+	// values are decoded on demand.
+	if v, err := raw.GetValueByName(2, "c"); err != nil || v != "c" {
+		t.Fatalf("expect value 'c', got %v %v", v, err)
+	}
+	if raw.IsRaw() || len(raw.Values) != 3 {
+		t.Fatal("expect values decoded")
+	}
+}
+
+func TestStreamResultSet(t *testing.T) {
+	fields := []*Field{
+		{Name: []byte("id"), ColumnType: MYSQL_TYPE_LONGLONG, Charset: uint16(BINARY_COLLATION_ID)},
+		{Name: []byte("c"), ColumnType: MYSQL_TYPE_BLOB, Charset: uint16(BINARY_COLLATION_ID)},
+	}
+	r := &Result{Resultset: &Resultset{Fields: fields}}
+	// small row, large row in chunks, and row bigger than max payload in fragments.
+	for i, size := range []int{1, 3 * streamChunkSize, MaxPayloadLen + 10} {
+		row := NewTextRow(fields)
+		row.AppendIntValue(int64(i + 1))
+		row.AppendStringValue(strings.Repeat("b", size))
+		r.Rows = append(r.Rows, row)
+	}
+	backend := new(recordConn)
+	if err := NewPacketIO(backend).WriteResultSet(CLIENT_PROTOCOL_41, 0, r); err != nil {
+		t.Fatal(err)
+	}
+	sent := append([]byte(nil), backend.wr.Bytes()...)
+
+	conn := new(recordConn)
+	conn.rd.Write(sent)
+	client := new(recordConn)
+	dst := NewPacketIO(client)
+	dst.SetWriteBuffered(true)
+	var status uint16
+	result, err := NewPacketIO(conn).StreamResultSet(CLIENT_PROTOCOL_41, &status, false, dst, CLIENT_PROTOCOL_41, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = dst.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsStreamed() || result.RowNumber() != 3 || len(result.Rows) != 0 {
+		t.Fatalf("expect 3 rows streamed without kept, got streamed=%v rows=%d", result.IsStreamed(), result.RowNumber())
+	}
+	if !bytes.Equal(client.wr.Bytes(), sent) {
+		t.Fatal("expect the same packets written when streamed")
+	}
+}
+
+func TestStreamResultSetPart(t *testing.T) {
+	fields := []*Field{
+		{Name: []byte("id"), ColumnType: MYSQL_TYPE_LONGLONG, Charset: uint16(BINARY_COLLATION_ID)},
+	}
+	all := &Result{Resultset: &Resultset{Fields: fields}}
+	var parts [][]byte
+	for _, ids := range [][]int64{{1, 2}, {}, {3}} {
+		r := &Result{Resultset: &Resultset{Fields: fields}}
+		for _, id := range ids {
+			row := NewTextRow(fields)
+			row.AppendIntValue(id)
+			r.Rows = append(r.Rows, row)
+			all.Rows = append(all.Rows, row)
+		}
+		backend := new(recordConn)
+		if err := NewPacketIO(backend).WriteResultSet(CLIENT_PROTOCOL_41, 0, r); err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, append([]byte(nil), backend.wr.Bytes()...))
+	}
+	expected := new(recordConn)
+	if err := NewPacketIO(expected).WriteResultSet(CLIENT_PROTOCOL_41, 0, all); err != nil {
+		t.Fatal(err)
+	}
+
+	client := new(recordConn)
+	dst := NewPacketIO(client)
+	var merged *Result
+	for i, part := range parts {
+		conn := new(recordConn)
+		conn.rd.Write(part)
+		var status uint16
+		var err error
+		if merged, err = NewPacketIO(conn).StreamResultSetPart(CLIENT_PROTOCOL_41, &status, false, dst, CLIENT_PROTOCOL_41, 0,
+			merged, i == len(parts)-1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !merged.IsStreamed() || merged.RowNumber() != 3 {
+		t.Fatalf("expect 3 rows streamed of parts, got streamed=%v rows=%d", merged.IsStreamed(), merged.RowNumber())
+	}
+	if !bytes.Equal(client.wr.Bytes(), expected.wr.Bytes()) {
+		t.Fatal("expect packets of parts written as a result set")
+	}
+}
+
+func TestResultClone(t *testing.T) {
+	fields := []*Field{{Name: []byte("c"), ColumnType: MYSQL_TYPE_VAR_STRING, Charset: uint16(DEFAULT_COLLATION_ID)}}
+	r := &Result{Resultset: &Resultset{Fields: fields}}
+	for _, value := range []string{"a", "b"} {
+		row := NewTextRow(fields)
+		row.AppendStringValue(value)
+		r.Rows = append(r.Rows, row)
+		r.Values = append(r.Values, row.fieldValues)
+	}
+	origin := new(recordConn)
+	if err := NewPacketIO(origin).WriteResultSet(CLIENT_PROTOCOL_41, 0, r); err != nil {
+		t.Fatal(err)
+	}
+
+	clone := r.Clone()
+	clone.ConvertCharset(func(src []byte) []byte { return bytes.ToUpper(src) }, DEFAULT_COLLATION_ID)
+	if v, _ := clone.GetValue(1, 0); v != "B" {
+		t.Fatalf("expect value of clone converted, got %v", v)
+	}
+	if v, _ := r.GetValue(1, 0); v != "b" {
+		t.Fatalf("expect value of origin unchanged, got %v", v)
+	}
+	written := new(recordConn)
+	if err := NewPacketIO(written).WriteResultSet(CLIENT_PROTOCOL_41, 0, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written.wr.Bytes(), origin.wr.Bytes()) {
+		t.Fatal("expect packets of origin unchanged after clone converted")
+	}
+}
+
+// handshakeResponse build handshake response packet with auth plugin and connection attributes.
+func handshakeResponse(capability uint32, user string, auth []byte, db, plugin string, attrs [][2]string) []byte {
+	data := make([]byte, 4, 128)
+	data = append(data, byte(capability), byte(capability>>8), byte(capability>>16), byte(capability>>24))
+	data = append(data, make([]byte, 4)...)
+	data = append(data, byte(DEFAULT_COLLATION_ID))
+	data = append(data, make([]byte, 23)...)
+	data = append(data, user...)
+	data = append(data, 0, byte(len(auth)))
+	data = append(data, auth...)
+	data = append(data, db...)
+	data = append(data, 0)
+	data = append(data, plugin...)
+	data = append(data, 0)
+	var kvs []byte
+	for _, attr := range attrs {
+		kvs = append(kvs, StringToLenencStr([]byte(attr[0]))...)
+		kvs = append(kvs, StringToLenencStr([]byte(attr[1]))...)
+	}
+	data = append(data, NumberToLenencInt(uint64(len(kvs)))...)
+	data = append(data, kvs...)
+	length := len(data) - 4
+	data[0], data[1], data[2] = byte(length), byte(length>>8), byte(length>>16)
+	return data
+}
+
+func TestReadHandshakeResponseClient(t *testing.T) {
+	salt := []byte("12345678901234567890")
+	capability := DEFAULT_CAPABILITY | CLIENT_PLUGIN_AUTH | CLIENT_CONNECT_ATTRS
+	attrs := [][2]string{{"_client_name", "libmysql"}, {"_client_version", "5.1.73"}, {"program_name", strings.Repeat("p", 300)}}
+	packet := handshakeResponse(capability, "root", CalcPassword(salt, []byte("secret")), "db1", "mysql_native_password", attrs)
+	getDefaultSchema := func(user string) (string, error) { return "db1", nil }
+	getCredentials := func(db string) (string, []string, error) { return "root", []string{"secret"}, nil }
+
+	var client *HandshakeClient
+	conn := new(recordConn)
+	conn.rd.Write(packet)
+	_, _, user, db, err := NewPacketIO(conn).ReadHandshakeResponse(getDefaultSchema, "127.0.0.1", salt, getCredentials,
+		func(c *HandshakeClient) error {
+			client = c
+			return nil
+		})
+	if err != nil || user != "root" || db != "db1" {
+		t.Fatalf("expect auth passed, got user=%s db=%s err=%v", user, db, err)
+	}
+	if client.Capability != capability || client.AuthPlugin != "mysql_native_password" {
+		t.Fatalf("expect client capability and plugin, got %d %s", client.Capability, client.AuthPlugin)
+	}
+	for _, attr := range attrs {
+		if client.Attrs[attr[0]] != attr[1] {
+			t.Fatalf("expect attr %s=%s, got %s", attr[0], attr[1], client.Attrs[attr[0]])
+		}
+	}
+
+	// rejected by client check before auth.
+	conn = new(recordConn)
+	conn.rd.Write(packet)
+	denied := NewDefaultError(ER_NOT_SUPPORTED_AUTH_MODE)
+	_, _, _, _, err = NewPacketIO(conn).ReadHandshakeResponse(getDefaultSchema, "127.0.0.1", salt, getCredentials,
+		func(c *HandshakeClient) error { return denied })
+	if err != denied {
+		t.Fatalf("expect rejected by client check, got %v", err)
+	}
+
+	// malformed attributes.
+	truncated := append([]byte(nil), packet[:len(packet)-10]...)
+	length := len(truncated) - 4
+	truncated[0], truncated[1], truncated[2] = byte(length), byte(length>>8), byte(length>>16)
+	conn = new(recordConn)
+	conn.rd.Write(truncated)
+	if _, _, _, _, err = NewPacketIO(conn).ReadHandshakeResponse(getDefaultSchema, "127.0.0.1", salt, getCredentials, nil); err == nil {
+		t.Fatal("expect malformed packet error")
+	}
+}
+
+func TestReadStmtExecuteRequestLongData(t *testing.T) {
+	s := &Stmt{ID: 1, ParamNum: 2}
+	s.ResetParams()
+	findStmt := func(id uint32) *Stmt {
+		if id == s.ID {
+			return s
+		}
+		return nil
+	}
+	p := NewPacketIO(new(recordConn))
+	for _, chunk := range []string{"hello ", "world"} {
+		if err := p.ReadStmtSendLongDataRequest(append([]byte{1, 0, 0, 0, 1, 0}, chunk...), findStmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.ReadStmtSendLongDataRequest([]byte{2, 0, 0, 0, 1, 0, 'x'}, findStmt); err == nil {
+		t.Fatal("expect unknown stmt error")
+	}
+
+	// id, flags, iteration count, null bitmap, new params bound, types; value of long data is not sent.
+	execute := []byte{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, MYSQL_TYPE_LONGLONG, 0, MYSQL_TYPE_BLOB, 0}
+	execute = append(execute, Uint64ToBytes(42)...)
+	if _, err := p.ReadStmtExecuteRequest(execute, findStmt); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := s.Args[1].([]byte); s.Args[0] != int64(42) || !ok || string(v) != "hello world" {
+		t.Fatalf("expect args [42 hello world], got %v", s.Args)
+	}
+	s.ResetParams()
+
+	// types bound by last execute are reused.
+	execute = []byte{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0}
+	execute = append(execute, Uint64ToBytes(7)...)
+	execute = append(execute, StringToLenencStr([]byte("again"))...)
+	if _, err := p.ReadStmtExecuteRequest(execute, findStmt); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := s.Args[1].([]byte); s.Args[0] != int64(7) || !ok || string(v) != "again" {
+		t.Fatalf("expect args [7 again], got %v", s.Args)
+	}
+
+	// error of long data is reported by execute.
+	s.ResetParams()
+	if err := p.ReadStmtSendLongDataRequest([]byte{1, 0, 0, 0, 5, 0, 'x'}, findStmt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.ReadStmtExecuteRequest(execute, findStmt); err == nil {
+		t.Fatal("expect error of long data with wrong param id")
+	}
+
+	// long data is capped at max allowed packet.
+	p.MaxAllowedPacket = 8
+	for _, chunk := range []string{"hello ", "world"} {
+		if err := p.ReadStmtSendLongDataRequest(append([]byte{1, 0, 0, 0, 1, 0}, chunk...), findStmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.longData != nil {
+		t.Error("expect long data more than max allowed packet dropped")
+	}
+	if _, err := p.ReadStmtExecuteRequest(execute, findStmt); err != ErrPacketTooLarge {
+		t.Fatalf("expect packet too large, got %v", err)
+	}
+}
+
+func TestReadAuthResultFullAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(cryptorand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	salt := []byte("12345678901234567890")
+
+	// server: perform full auth, public key, then OK.
+	serverConn := new(recordConn)
+	server := NewPacketIO(serverConn)
+	server.Sequence = 2
+	for _, data := range [][]byte{{AUTH_MORE_DATA_HEADER, CACHING_SHA2_FULL_AUTH}, append([]byte{AUTH_MORE_DATA_HEADER}, pubKey...)} {
+		if err = server.WritePacket(append(make([]byte, 4), data...)); err != nil {
+			t.Fatal(err)
+		}
+		server.Sequence++
+	}
+	if err = server.WriteOK(CLIENT_PROTOCOL_41, SERVER_STATUS_AUTOCOMMIT, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := new(recordConn)
+	conn.rd.Write(serverConn.wr.Bytes())
+	client := NewPacketIO(conn)
+	client.Sequence = 2
+	var status uint16
+	if err = client.ReadAuthResult(CLIENT_PROTOCOL_41, &status, "secret", salt, AUTH_CACHING_SHA2_PASSWORD); err != nil {
+		t.Fatal(err)
+	}
+	if status != SERVER_STATUS_AUTOCOMMIT {
+		t.Fatalf("expect status of ok, got %d", status)
+	}
+
+	// client: request public key, then encrypted password.
+	written := conn.wr.Bytes()
+	if !bytes.Equal(written[:5], []byte{1, 0, 0, 3, CACHING_SHA2_PUBLIC_KEY}) {
+		t.Fatalf("expect public key requested, got %v", written[:5])
+	}
+	encrypted := written[9:]
+	plain, err := rsa.DecryptOAEP(sha1.New(), cryptorand.Reader, key, encrypted, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range plain {
+		plain[i] ^= salt[i%len(salt)]
+	}
+	if string(plain) != "secret\x00" {
+		t.Fatalf("expect password encrypted, got %q", plain)
+	}
+}
+
+func TestReadHandshakeResponseAuthSwitch(t *testing.T) {
+	salt := []byte("12345678901234567890")
+	capability := DEFAULT_CAPABILITY | CLIENT_PLUGIN_AUTH
+	conn := new(recordConn)
+	conn.rd.Write(handshakeResponse(capability, "root", []byte("cleartext"), "db1", "sha256_password", nil))
+	// auth of mysql_native_password, after switched.
+	auth := CalcPassword(salt, []byte("secret"))
+	conn.rd.Write(append([]byte{byte(len(auth)), 0, 0, 2}, auth...))
+
+	p := NewPacketIO(conn)
+	_, _, user, _, err := p.ReadHandshakeResponse(func(user string) (string, error) { return "db1", nil }, "127.0.0.1", salt,
+		func(db string) (string, []string, error) { return "root", []string{"secret"}, nil }, nil)
+	if err != nil || user != "root" {
+		t.Fatalf("expect auth passed after switch, got %v", err)
+	}
+	switchRequest := append([]byte{AUTH_SWITCH_HEADER}, AUTH_NATIVE_PASSWORD+"\x00"...)
+	if written := conn.wr.Bytes(); len(written) < 4 || !bytes.HasPrefix(written[4:], switchRequest) {
+		t.Fatalf("expect auth switch request, got %q", written)
+	}
+}
+
+func TestParseBinaryRow(t *testing.T) {
+	fields := []*Field{
+		{Name: []byte("n"), ColumnType: MYSQL_TYPE_TINY},
+		{Name: []byte("t"), ColumnType: MYSQL_TYPE_TINY},
+		{Name: []byte("s"), ColumnType: MYSQL_TYPE_SHORT},
+		{Name: []byte("l"), ColumnType: MYSQL_TYPE_LONG},
+		{Name: []byte("u"), ColumnType: MYSQL_TYPE_LONGLONG, Flags: UNSIGNED_FLAG},
+		{Name: []byte("d"), ColumnType: MYSQL_TYPE_NEWDECIMAL, Decimals: 2},
+		{Name: []byte("b"), ColumnType: MYSQL_TYPE_BIT, Flags: UNSIGNED_FLAG},
+		{Name: []byte("j"), ColumnType: MYSQL_TYPE_JSON},
+		{Name: []byte("da"), ColumnType: MYSQL_TYPE_DATE},
+		{Name: []byte("dt"), ColumnType: MYSQL_TYPE_DATETIME, Decimals: 3},
+		{Name: []byte("ti"), ColumnType: MYSQL_TYPE_TIME},
+		{Name: []byte("ts"), ColumnType: MYSQL_TYPE_TIMESTAMP, Decimals: 2},
+	}
+	raw := []byte{0x00, 0x04, 0x00}
+	raw = append(raw, 0xff)
+	raw = append(raw, 0xfe, 0xff)
+	raw = append(raw, 0xfd, 0xff, 0xff, 0xff)
+	raw = append(raw, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	raw = append(raw, 0x04, '1', '.', '5', '0')
+	raw = append(raw, 0x02, 0x01, 0x01)
+	raw = append(raw, 0x07, '{', '"', 'a', '"', ':', '1', '}')
+	raw = append(raw, 0x04, 0xe8, 0x07, 0x02, 0x1d)
+	raw = append(raw, 0x0b, 0xe8, 0x07, 0x02, 0x1d, 0x0d, 0x0e, 0x0f, 0x78, 0xe0, 0x01, 0x00)
+	raw = append(raw, 0x08, 0x01, 0x01, 0x00, 0x00, 0x00, 0x02, 0x03, 0x04)
+	raw = append(raw, 0x07, 0xe8, 0x07, 0x02, 0x1d, 0x0d, 0x0e, 0x0f)
+
+	row, err := RowData(raw).Parse(true, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{nil, int64(-1), int64(-2), int64(-3), uint64(1<<64 - 1),
+		"1.50", "\x01\x01", `{"a":1}`, "2024-02-29", "2024-02-29 13:14:15.123", "-26:03:04", "2024-02-29 13:14:15.00"}
+	for i, w := range want {
+		v := row.fieldValues[i]
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		if v != w {
+			t.Errorf("value of %s is %#v, want %#v", fields[i].Name, v, w)
+		}
+	}
+	if !bytes.Equal(row.Dump(), raw) {
+		t.Errorf("dump is %v, want %v", row.Dump(), raw)
+	}
+	if _, err := RowData(raw[:len(raw)-1]).Parse(true, fields); err == nil {
+		t.Error("parse truncated row, want error")
+	}
+}
+
+func TestRelayLocalInfile(t *testing.T) {
+	packet := func(seq byte, payload ...byte) []byte {
+		return append([]byte{byte(len(payload)), 0, 0, seq}, payload...)
+	}
+	clientConn, serverConn := new(recordConn), new(recordConn)
+	client, server := NewPacketIO(clientConn), NewPacketIO(serverConn)
+	// client sent COM_QUERY, then the file after requested.
+	client.Sequence = 1
+	clientConn.rd.Write(packet(2, []byte("1,a\n")...))
+	clientConn.rd.Write(packet(3))
+	serverConn.rd.Write(packet(1, append([]byte{LocalInFile_HEADER}, "f.csv"...)...))
+	serverConn.rd.Write(packet(4, OK_HEADER, 1, 0, 2, 0, 0, 0))
+	server.LocalInfile = func(filename []byte, s *PacketIO) error {
+		return client.RelayLocalInfile(filename, s)
+	}
+
+	var status uint16
+	r, err := server.Query(CLIENT_PROTOCOL_41, &status, "load data local infile 'f.csv' into table t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.AffectedRows != 1 {
+		t.Errorf("affected rows %d, want 1", r.AffectedRows)
+	}
+	if want := packet(1, append([]byte{LocalInFile_HEADER}, "f.csv"...)...); !bytes.Equal(clientConn.wr.Bytes(), want) {
+		t.Errorf("written to client %v, want %v", clientConn.wr.Bytes(), want)
+	}
+	written := serverConn.wr.Bytes()
+	if want := append(packet(2, []byte("1,a\n")...), packet(3)...); !bytes.HasSuffix(written, want) {
+		t.Errorf("written to server %v, want suffix %v", written, want)
+	}
+	if client.Sequence != 4 {
+		t.Errorf("sequence of client %d, want 4", client.Sequence)
+	}
+
+	// refused by empty packet without handler.
+	serverConn.rd.Reset()
+	serverConn.wr.Reset()
+	serverConn.rd.Write(packet(1, append([]byte{LocalInFile_HEADER}, "f.csv"...)...))
+	serverConn.rd.Write(packet(3, ERR_HEADER, 0x94, 0x07, '#', 'H', 'Y', '0', '0', '0', 'x'))
+	server.LocalInfile = nil
+	if _, err = server.Query(CLIENT_PROTOCOL_41, &status, "load data local infile 'f.csv' into table t"); err == nil {
+		t.Fatal("query refused local file, want error")
+	}
+	if written := serverConn.wr.Bytes(); !bytes.HasSuffix(written, packet(2)) {
+		t.Errorf("written to server %v, want suffix empty packet", written)
+	}
+}
+
+func TestCompressedPacketIO(t *testing.T) {
+	clientConn, serverConn := new(recordConn), new(recordConn)
+	client, server := NewPacketIO(clientConn), NewPacketIO(serverConn)
+	if err := client.SetCompressed(); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.SetCompressed(); err != nil {
+		t.Fatal(err)
+	}
+	query := append([]byte{0, 0, 0, 0, COM_QUERY}, "select 1"...)
+	large := append([]byte{0, 0, 0, 0}, bytes.Repeat([]byte("abcd"), 1000)...)
+	if err := client.WritePacket(query); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.WritePacket(large); err != nil {
+		t.Fatal(err)
+	}
+	written := clientConn.wr.Bytes()
+	// small packet is sent as is, large one is compressed, sequence of compressed packets is from 0.
+	if want := []byte{13, 0, 0, 0, 0, 0, 0}; !bytes.Equal(written[:7], want) {
+		t.Fatalf("header of small packet %v, want %v", written[:7], want)
+	}
+	header := written[7+13 : 7+13+7]
+	if length := int(header[0]) | int(header[1])<<8; length >= len(large) || header[3] != 1 ||
+		int(header[4])|int(header[5])<<8 != len(large) {
+		t.Fatalf("header of large packet %v", header)
+	}
+
+	serverConn.rd.Write(written)
+	for i, want := range [][]byte{query[4:], large[4:]} {
+		data, err := server.ReadPacket()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, want) {
+			t.Errorf("packet %d read %v, want %v", i, data, want)
+		}
+	}
+	if server.Buffered() != 0 {
+		t.Errorf("buffered %d, want 0", server.Buffered())
+	}
+	// response follows sequence of compressed packets read.
+	if err := server.WritePacket([]byte{0, 0, 0, 0, OK_HEADER, 0, 0, 2, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if written := serverConn.wr.Bytes(); len(written) < 7 || written[3] != 2 {
+		t.Errorf("written to client %v, want compressed sequence 2", written)
+	}
+}
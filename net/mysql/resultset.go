@@ -32,6 +32,14 @@ type Result struct {
 	Status       uint16
 	InsertID     uint64
 	AffectedRows uint64
+	Warnings     uint16
+	Info         string
+
+	// SessionState is session state changes, only when CLIENT_SESSION_TRACK is on.
+	SessionState *SessionState
+	// OutParams is OUT parameters of stored procedure, when execute 'call' by stmt.
+	OutParams *Resultset
+
 	*Resultset
 }
 
@@ -42,13 +50,90 @@ type Resultset struct {
 	Values     [][]interface{}
 
 	Rows []*Row
+
+	// raw is true if values of rows are not decoded yet.
+	raw bool
+	// streamed is true if rows are written to client as read, without kept.
+	streamed     bool
+	streamedRows int
 }
 
 // RowNumber row number
 func (r *Resultset) RowNumber() int {
+	if r.streamed {
+		return r.streamedRows
+	}
+	if r.raw {
+		return len(r.Rows)
+	}
 	return len(r.Values)
 }
 
+// IsRaw is true if rows are raw packets read by ReadRawResultSet, and values are not decoded yet.
+func (r *Resultset) IsRaw() bool {
+	return r.raw
+}
+
+// IsStreamed is true if result set is written by StreamResultSet already, without rows kept.
+func (r *Resultset) IsStreamed() bool {
+	return r.streamed
+}
+
+// Clone copy result, so that the copy could be converted and written independently of the origin, such as cached.
+// Raw packets and values are shared, as they are replaced instead of changed in place.
+func (r *Result) Clone() *Result {
+	clone := *r
+	if r.Resultset == nil {
+		return &clone
+	}
+	rs := *r.Resultset
+	rs.Fields = make([]*Field, len(r.Fields))
+	for i, f := range r.Fields {
+		field := *f
+		rs.Fields[i] = &field
+	}
+	rs.Rows = make([]*Row, len(r.Rows))
+	for i, row := range r.Rows {
+		copied := *row
+		copied.fieldValues = append([]interface{}(nil), row.fieldValues...)
+		copied.fieldValuesCache = append([][]byte(nil), row.fieldValuesCache...)
+		copied.fields = rs.Fields
+		rs.Rows[i] = &copied
+	}
+	if r.Values != nil {
+		rs.Values = make([][]interface{}, len(r.Values))
+		for i, values := range r.Values {
+			if i < len(rs.Rows) && len(rs.Rows[i].fieldValues) == len(values) {
+				rs.Values[i] = rs.Rows[i].fieldValues
+			} else {
+				rs.Values[i] = append([]interface{}(nil), values...)
+			}
+		}
+	}
+	clone.Resultset = &rs
+	return &clone
+}
+
+// Decode values of raw rows, raw packets are kept to write without encoding again.
+func (r *Resultset) Decode() error {
+	if !r.raw {
+		return nil
+	}
+	values := make([][]interface{}, len(r.Rows))
+	for i, row := range r.Rows {
+		parsed, err := row.Data.Parse(row.isBinary, r.Fields)
+		if err != nil {
+			return err
+		}
+		parsed.Data = row.Data
+		r.Rows[i] = parsed
+		values[i] = parsed.fieldValues
+	}
+	r.Values = values
+	r.raw = false
+	return nil
+}
+
 // ColumnNumber column number
 func (r *Resultset) ColumnNumber() int {
 	return len(r.Fields)
@@ -56,6 +141,9 @@ func (r *Resultset) ColumnNumber() int {
 
 // GetValue get value
 func (r *Resultset) GetValue(row, column int) (interface{}, error) {
+	if err := r.Decode(); err != nil {
+		return nil, err
+	}
 	if row >= len(r.Values) || row < 0 {
 		return nil, fmt.Errorf("invalid row index %d", row)
 	}
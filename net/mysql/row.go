@@ -23,6 +23,7 @@
 package mysql
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"math"
@@ -171,13 +172,13 @@ func parseAsTextRow(raw []byte, f []*Field) (fieldValues []interface{}, fieldVal
 		if err != nil {
 			return nil, nil, err
 		}
-		fieldValuesCache[i] = StringToLenencStr(v)
-
 		pos += n
 
 		if isNull {
 			fieldValues[i] = nil
+			fieldValuesCache[i] = []byte{0xfb}
 		} else {
+			fieldValuesCache[i] = StringToLenencStr(v)
 			isUnsigned = (f[i].Flags&UNSIGNED_FLAG > 0)
 			switch f[i].ColumnType {
 			case MYSQL_TYPE_TINY, MYSQL_TYPE_SHORT, MYSQL_TYPE_LONG, MYSQL_TYPE_INT24,
@@ -187,11 +188,12 @@ func parseAsTextRow(raw []byte, f []*Field) (fieldValues []interface{}, fieldVal
 				} else {
 					fieldValues[i], err = strconv.ParseInt(string(v), 10, 64)
 				}
-			case MYSQL_TYPE_FLOAT, MYSQL_TYPE_DOUBLE, MYSQL_TYPE_NEWDECIMAL:
+			case MYSQL_TYPE_FLOAT, MYSQL_TYPE_DOUBLE:
 				fieldValues[i], err = strconv.ParseFloat(string(v), 64)
 			case MYSQL_TYPE_VARCHAR, MYSQL_TYPE_VAR_STRING, MYSQL_TYPE_STRING:
 				fieldValues[i] = string(v)
 			default:
+				// decimal is kept as string of digits same as binary row, to be summed and compared exactly.
 				fieldValues[i] = v
 			}
 
@@ -206,12 +208,10 @@ func parseAsTextRow(raw []byte, f []*Field) (fieldValues []interface{}, fieldVal
 func parseAsBinaryRow(raw []byte, f []*Field) (fieldValues []interface{}, nullBitmap []byte, fieldValuesCache [][]byte, err error) {
 	fieldValues = make([]interface{}, len(f))
 	fieldValuesCache = make([][]byte, len(f))
-	if raw[0] != OK_HEADER {
+	pos := 1 + ((len(f) + 7 + 2) >> 3)
+	if len(raw) < pos || raw[0] != OK_HEADER {
 		return nil, nil, nil, errors.ErrMalformPacket
 	}
-	fieldValuesCache[0] = []byte{raw[0]}
-	pos := 1 + ((len(f) + 7 + 2) >> 3)
-
 	nullBitmap = raw[1:pos]
 
 	var isUnsigned bool
@@ -226,6 +226,21 @@ func parseAsBinaryRow(raw []byte, f []*Field) (fieldValues []interface{}, nullBi
 		}
 
 		isUnsigned = f[i].Flags&UNSIGNED_FLAG > 0
+		// size of fixed-length value, 0 if length-encoded.
+		size := 0
+		switch f[i].ColumnType {
+		case MYSQL_TYPE_TINY:
+			size = 1
+		case MYSQL_TYPE_SHORT, MYSQL_TYPE_YEAR:
+			size = 2
+		case MYSQL_TYPE_INT24, MYSQL_TYPE_LONG, MYSQL_TYPE_FLOAT:
+			size = 4
+		case MYSQL_TYPE_LONGLONG, MYSQL_TYPE_DOUBLE:
+			size = 8
+		}
+		if len(raw) < pos+size {
+			return nil, nil, nil, errors.ErrMalformPacket
+		}
 
 		switch f[i].ColumnType {
 		case MYSQL_TYPE_NULL:
@@ -236,31 +251,22 @@ func parseAsBinaryRow(raw []byte, f []*Field) (fieldValues []interface{}, nullBi
 			if isUnsigned {
 				fieldValues[i] = uint64(raw[pos])
 			} else {
-				fieldValues[i] = int64(raw[pos])
+				fieldValues[i] = int64(int8(raw[pos]))
 			}
-			fieldValuesCache[i] = []byte{raw[pos]}
-			pos++
-			continue
 
 		case MYSQL_TYPE_SHORT, MYSQL_TYPE_YEAR:
-			if isUnsigned {
+			if isUnsigned || f[i].ColumnType == MYSQL_TYPE_YEAR {
 				fieldValues[i] = uint64(binary.LittleEndian.Uint16(raw[pos : pos+2]))
 			} else {
-				fieldValues[i] = int64((binary.LittleEndian.Uint16(raw[pos : pos+2])))
+				fieldValues[i] = int64(int16(binary.LittleEndian.Uint16(raw[pos : pos+2])))
 			}
-			fieldValuesCache[i] = raw[pos : pos+2]
-			pos += 2
-			continue
 
 		case MYSQL_TYPE_INT24, MYSQL_TYPE_LONG:
 			if isUnsigned {
 				fieldValues[i] = uint64(binary.LittleEndian.Uint32(raw[pos : pos+4]))
 			} else {
-				fieldValues[i] = int64(binary.LittleEndian.Uint32(raw[pos : pos+4]))
+				fieldValues[i] = int64(int32(binary.LittleEndian.Uint32(raw[pos : pos+4])))
 			}
-			fieldValuesCache[i] = raw[pos : pos+4]
-			pos += 4
-			continue
 
 		case MYSQL_TYPE_LONGLONG:
 			if isUnsigned {
@@ -268,105 +274,79 @@ func parseAsBinaryRow(raw []byte, f []*Field) (fieldValues []interface{}, nullBi
 			} else {
 				fieldValues[i] = int64(binary.LittleEndian.Uint64(raw[pos : pos+8]))
 			}
-			fieldValuesCache[i] = raw[pos : pos+8]
-			pos += 8
-			continue
 
 		case MYSQL_TYPE_FLOAT:
 			fieldValues[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(raw[pos : pos+4])))
-			fieldValuesCache[i] = raw[pos : pos+4]
-			pos += 4
-			continue
 
 		case MYSQL_TYPE_DOUBLE:
 			fieldValues[i] = math.Float64frombits(binary.LittleEndian.Uint64(raw[pos : pos+8]))
-			fieldValuesCache[i] = raw[pos : pos+8]
-			pos += 8
-			continue
 
 		case MYSQL_TYPE_DECIMAL, MYSQL_TYPE_NEWDECIMAL, MYSQL_TYPE_VARCHAR,
 			MYSQL_TYPE_BIT, MYSQL_TYPE_ENUM, MYSQL_TYPE_SET, MYSQL_TYPE_TINY_BLOB,
 			MYSQL_TYPE_MEDIUM_BLOB, MYSQL_TYPE_LONG_BLOB, MYSQL_TYPE_BLOB,
-			MYSQL_TYPE_VAR_STRING, MYSQL_TYPE_STRING, MYSQL_TYPE_GEOMETRY:
+			MYSQL_TYPE_VAR_STRING, MYSQL_TYPE_STRING, MYSQL_TYPE_GEOMETRY,
+			MYSQL_TYPE_JSON, MYSQL_TYPE_VECTOR:
+			// decimal is string of digits, bit is bytes in big-endian, json is text.
 			v, isNull, n, err = LenencStrToString(raw[pos:])
 			if err != nil {
 				return nil, nil, nil, err
 			}
 			fieldValuesCache[i] = raw[pos : pos+n]
 			pos += n
-
-			if !isNull {
-				fieldValues[i] = v
-				continue
-			} else {
-				fieldValues[i] = nil
-				continue
-			}
-		case MYSQL_TYPE_DATE, MYSQL_TYPE_NEWDATE:
-			var num uint64
-			num, isNull, n = LenencIntToNumber(raw[pos:])
-			if isNull {
-				fieldValuesCache[i] = raw[pos : pos+n]
-			}
-			pos += n
-
 			if isNull {
 				fieldValues[i] = nil
-				continue
-			}
-
-			fieldValues[i], err = FormatBinaryDate(int(num), raw[pos:])
-
-			if err != nil {
-				return nil, nil, nil, err
-			}
-			fieldValuesCache[i] = raw[pos:int(num)]
-			pos += int(num)
-
-		case MYSQL_TYPE_TIMESTAMP, MYSQL_TYPE_DATETIME:
-			var num uint64
-			num, isNull, n = LenencIntToNumber(raw[pos:])
-			if isNull {
-				fieldValuesCache[i] = raw[pos : pos+n]
-			}
-			pos += n
-
-			if isNull {
-				fieldValues[i] = nil
-				continue
-			}
-
-			fieldValues[i], err = FormatBinaryDateTime(int(num), raw[pos:])
-			fieldValuesCache[i] = raw[pos : pos+int(num)]
-			pos += int(num)
-
-			if err != nil {
-				return nil, nil, nil, err
+			} else {
+				fieldValues[i] = v
 			}
+			continue
 
-		case MYSQL_TYPE_TIME:
-			var num uint64
-			num, isNull, n = LenencIntToNumber(raw[pos:])
-			if isNull {
-				fieldValuesCache[i] = raw[pos:n]
+		case MYSQL_TYPE_DATE, MYSQL_TYPE_NEWDATE, MYSQL_TYPE_TIMESTAMP, MYSQL_TYPE_DATETIME, MYSQL_TYPE_TIME:
+			// length of value in a byte, then date, time and microseconds, fields of zero are omitted at end.
+			num := int(raw[pos])
+			if len(raw) < pos+1+num {
+				return nil, nil, nil, errors.ErrMalformPacket
 			}
-			pos += n
-
-			if isNull {
-				fieldValues[i] = nil
-				continue
+			data := raw[pos+1 : pos+1+num]
+			switch f[i].ColumnType {
+			case MYSQL_TYPE_DATE, MYSQL_TYPE_NEWDATE:
+				fieldValues[i], err = FormatBinaryDate(num, data)
+			case MYSQL_TYPE_TIME:
+				fieldValues[i], err = FormatBinaryTime(num, data)
+			default:
+				fieldValues[i], err = FormatBinaryDateTime(num, data)
 			}
-
-			fieldValues[i], err = FormatBinaryTime(int(num), raw[pos:])
 			if err != nil {
 				return nil, nil, nil, err
 			}
-			fieldValuesCache[i] = raw[pos:int(num)]
-			pos += int(num)
+			fieldValues[i] = trimFraction(fieldValues[i].([]byte), f[i].Decimals)
+			fieldValuesCache[i] = raw[pos : pos+1+num]
+			pos += 1 + num
+			continue
 
 		default:
 			return nil, nil, nil, fmt.Errorf("Stmt Unknown FieldType %d %s", f[i].ColumnType, f[i].Name)
 		}
+		fieldValuesCache[i] = raw[pos : pos+size]
+		pos += size
 	}
 	return
 }
+
+// trimFraction trim microseconds of temporal value to decimals of column, as in text protocol.
+func trimFraction(v []byte, decimals uint8) []byte {
+	dot := bytes.LastIndexByte(v, '.')
+	if dot < 0 {
+		if decimals == 0 || decimals > 6 {
+			return v
+		}
+		// microseconds omitted as zero.
+		return append(append(v, '.'), bytes.Repeat([]byte{'0'}, int(decimals))...)
+	}
+	if decimals > 6 {
+		decimals = 6
+	}
+	if decimals == 0 {
+		return v[:dot]
+	}
+	return v[:dot+1+int(decimals)]
+}
@@ -0,0 +1,131 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mysql
+
+import (
+	"github.com/berkaroad/saashard/errors"
+)
+
+// Session state change types, in OK packet when CLIENT_SESSION_TRACK is on.
+// https://dev.mysql.com/doc/internals/en/packet-OK_Packet.html
+const (
+	SESSION_TRACK_SYSTEM_VARIABLES byte = iota
+	SESSION_TRACK_SCHEMA
+	SESSION_TRACK_STATE_CHANGE
+	SESSION_TRACK_GTIDS
+	SESSION_TRACK_TRANSACTION_CHARACTERISTICS
+	SESSION_TRACK_TRANSACTION_STATE
+)
+
+// SessionState is session state changes reported by server.
+type SessionState struct {
+	// SystemVariables changed system variables, name -> value.
+	SystemVariables map[string]string
+	// Schema changed current schema, empty if not changed.
+	Schema string
+	// StateChanged is true if session state is changed, such as user variables, temporary tables.
+	StateChanged bool
+	// GTIDs gtid set of last committed transaction.
+	GTIDs string
+	// TransactionCharacteristics sql statements to restart transaction with same characteristics.
+	TransactionCharacteristics string
+	// TransactionState 8 characters of transaction state.
+	TransactionState string
+}
+
+// ParseSessionState parse session state info in OK packet.
+func ParseSessionState(data []byte) (*SessionState, error) {
+	s := new(SessionState)
+	pos := 0
+	for pos < len(data) {
+		tp := data[pos]
+		pos++
+		if pos >= len(data) {
+			return nil, errors.ErrMalformPacket
+		}
+
+		entry, _, n, err := LenencStrToString(data[pos:])
+		if err != nil {
+			return nil, err
+		}
+		pos += n
+		if len(entry) == 0 {
+			return nil, errors.ErrMalformPacket
+		}
+
+		switch tp {
+		case SESSION_TRACK_SYSTEM_VARIABLES:
+			var name, value []byte
+			if name, _, n, err = LenencStrToString(entry); err != nil {
+				return nil, err
+			}
+			if n >= len(entry) {
+				return nil, errors.ErrMalformPacket
+			}
+			if value, _, _, err = LenencStrToString(entry[n:]); err != nil {
+				return nil, err
+			}
+			if s.SystemVariables == nil {
+				s.SystemVariables = make(map[string]string)
+			}
+			s.SystemVariables[string(name)] = string(value)
+		case SESSION_TRACK_SCHEMA:
+			var schema []byte
+			if schema, _, _, err = LenencStrToString(entry); err != nil {
+				return nil, err
+			}
+			s.Schema = string(schema)
+		case SESSION_TRACK_STATE_CHANGE:
+			var changed []byte
+			if changed, _, _, err = LenencStrToString(entry); err != nil {
+				return nil, err
+			}
+			s.StateChanged = string(changed) == "1"
+		case SESSION_TRACK_GTIDS:
+			// encoding specification (1 byte), then gtid set as lenenc string.
+			if len(entry) < 2 {
+				return nil, errors.ErrMalformPacket
+			}
+			var gtids []byte
+			if gtids, _, _, err = LenencStrToString(entry[1:]); err != nil {
+				return nil, err
+			}
+			s.GTIDs = string(gtids)
+		case SESSION_TRACK_TRANSACTION_CHARACTERISTICS:
+			var characteristics []byte
+			if characteristics, _, _, err = LenencStrToString(entry); err != nil {
+				return nil, err
+			}
+			s.TransactionCharacteristics = string(characteristics)
+		case SESSION_TRACK_TRANSACTION_STATE:
+			var state []byte
+			if state, _, _, err = LenencStrToString(entry); err != nil {
+				return nil, err
+			}
+			s.TransactionState = string(state)
+		default:
+			// unknown type, skip it.
+		}
+	}
+	return s, nil
+}
@@ -38,6 +38,12 @@ type Stmt struct {
 	ColumnNum int
 	Columns   []*Field
 	Args      []interface{}
+	// ParamTypes bound by execute, reused if not bound again.
+	ParamTypes []byte
+
+	longData     map[int][]byte // param values by COM_STMT_SEND_LONG_DATA.
+	longDataSize int            // bytes of long data buffered.
+	longDataErr  error          // reported by next execute, as send long data has no response.
 }
 
 // NewStmt new stmt.
@@ -54,7 +60,34 @@ func (s *Stmt) Execute(args []interface{}) (*Result, error) {
 	if err := s.pkg.StmtExecute(s.ID, args); err != nil {
 		return nil, err
 	}
-	return s.pkg.ReadResultSet(s.capability, s.status, true)
+	r, err := s.pkg.ReadResultSet(s.capability, s.status, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// 'call' returns more results: result sets of procedure, OUT parameters, then OK.
+	// The first result set of procedure is kept, others are drained to keep connection in sync, OK packets are merged.
+	if r.Status&SERVER_PS_OUT_PARAMS > 0 && r.Resultset != nil {
+		r = &Result{Status: r.Status, OutParams: r.Resultset}
+	}
+	for more := r.Status; more&SERVER_MORE_RESULTS_EXISTS > 0; {
+		var next *Result
+		if next, err = s.pkg.ReadResultSet(s.capability, s.status, true); err != nil {
+			return nil, err
+		}
+		more = next.Status
+		if next.Status&SERVER_PS_OUT_PARAMS > 0 && next.Resultset != nil {
+			r.OutParams = next.Resultset
+		} else if next.Resultset == nil {
+			r.AffectedRows += next.AffectedRows
+			r.Warnings += next.Warnings
+			if next.SessionState != nil {
+				r.SessionState = next.SessionState
+			}
+		}
+	}
+	r.Status = *s.status
+	return r, nil
 }
 
 // Close stmt.
@@ -66,7 +99,33 @@ func (s *Stmt) Close() error {
 	return nil
 }
 
-// ResetParams reset params.
+// ResetParams reset params, and long data sent.
 func (s *Stmt) ResetParams() {
 	s.Args = make([]interface{}, s.ParamNum)
+	s.longData = nil
+	s.longDataSize = 0
+	s.longDataErr = nil
+}
+
+// AppendLongData append value of param sent by COM_STMT_SEND_LONG_DATA.
+// Long data buffered more than maxSize is dropped, and ER_NET_PACKET_TOO_LARGE is reported by next execute,
+// 0 is unlimited.
+func (s *Stmt) AppendLongData(paramID int, data []byte, maxSize int) {
+	if s.longDataErr != nil {
+		return
+	}
+	if paramID >= s.ParamNum {
+		s.longDataErr = NewDefaultError(ER_WRONG_ARGUMENTS, "mysqld_stmt_send_long_data")
+		return
+	}
+	if maxSize > 0 && s.longDataSize+len(data) > maxSize {
+		s.longData = nil
+		s.longDataErr = ErrPacketTooLarge
+		return
+	}
+	s.longDataSize += len(data)
+	if s.longData == nil {
+		s.longData = make(map[int][]byte)
+	}
+	s.longData[paramID] = append(s.longData[paramID], data...)
 }
@@ -0,0 +1,182 @@
+package mysql_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/net/mysql/mysqltest"
+)
+
+// TestStmtExecuteBinaryTypes replay binary result set of temporal, decimal, json and bit columns recorded from server.
+func TestStmtExecuteBinaryTypes(t *testing.T) {
+	f, err := os.Open("testdata/stmt_binary_types.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recording, err := mysqltest.LoadRecording(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	done := make(chan error, 1)
+	go func() {
+		defer server.Close()
+		for _, p := range recording.Packets {
+			if p.FromServer {
+				if _, err := server.Write(p.Data); err != nil {
+					done <- err
+					return
+				}
+				continue
+			}
+			data := make([]byte, len(p.Data))
+			if _, err := io.ReadFull(server, data); err != nil {
+				done <- err
+				return
+			}
+			if !bytes.Equal(data, p.Data) {
+				done <- fmt.Errorf("packet from client mismatched, expect %x, got %x", p.Data, data)
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	pkg := mysql.NewPacketIO(client)
+	status := mysql.SERVER_STATUS_AUTOCOMMIT
+	if err := pkg.StmtExecute(1, []interface{}{int64(0)}); err != nil {
+		t.Fatal(err)
+	}
+	result, err := pkg.ReadResultSet(mysql.CLIENT_PROTOCOL_41, &status, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]interface{}{
+		{"2024-02-29 13:14:15.123456", "-12.34", `{"a": [1, 2]}`, "\x02\x01"},
+		{"2024-02-29 00:00:00.000000", "0.00", nil, nil},
+		{"2024-02-29 13:14:15.000000", "99999999.99", "[]", "\x00\x00"},
+	}
+	if result.RowNumber() != len(want) {
+		t.Fatalf("expect %d rows, got %d", len(want), result.RowNumber())
+	}
+	for i, values := range want {
+		for j, w := range values {
+			v, err := result.GetValue(i, j)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			if v != w {
+				t.Errorf("row %d, value of %s is %#v, want %#v", i+1, result.Fields[j].Name, v, w)
+			}
+		}
+		// binary rows are forwarded to client as they are.
+		if raw := recording.Packets[7+i].Data[4:]; !bytes.Equal(result.Rows[i].Dump(), raw) {
+			t.Errorf("row %d: dump is %x, want %x", i+1, result.Rows[i].Dump(), raw)
+		}
+	}
+}
+
+// TestStmtExecuteBinaryTypesOnServer execute the same stmt against a real server, skipped unless
+// SAASHARD_TEST_MYSQL is set as 'user:password@host:port/db'. Values of binary rows are the same as text rows.
+func TestStmtExecuteBinaryTypesOnServer(t *testing.T) {
+	dsn := os.Getenv("SAASHARD_TEST_MYSQL")
+	if len(dsn) == 0 {
+		t.Skip("SAASHARD_TEST_MYSQL is not set")
+	}
+	at := strings.LastIndex(dsn, "@")
+	slash := strings.LastIndex(dsn, "/")
+	if at < 0 || slash < at {
+		t.Fatalf("invalid SAASHARD_TEST_MYSQL '%s', expect 'user:password@host:port/db'", dsn)
+	}
+	user, password := dsn[:at], ""
+	if i := strings.Index(user, ":"); i >= 0 {
+		user, password = user[:i], user[i+1:]
+	}
+	addr, db := dsn[at+1:slash], dsn[slash+1:]
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	pkg := mysql.NewPacketIO(conn)
+	var salt []byte
+	capability, status, collation, _, authPlugin, err := pkg.ReadInitialHandshake(&salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = pkg.WriteAuthHandshake(&capability, user, password, db, salt, collation, authPlugin); err != nil {
+		t.Fatal(err)
+	}
+	if err = pkg.ReadAuthResult(capability, &status, password, salt, authPlugin); err != nil {
+		t.Fatal(err)
+	}
+	pkg.Sequence = 0
+	defer pkg.Quit(capability, &status)
+
+	for _, query := range []string{
+		"CREATE TEMPORARY TABLE t (id INT PRIMARY KEY, dt DATETIME(6), d DECIMAL(10,2), j JSON, b BIT(10))",
+		"INSERT INTO t VALUES (1, '2024-02-29 13:14:15.123456', -12.34, '{\"a\": [1, 2]}', b'1000000001'), " +
+			"(2, '2024-02-29', 0, NULL, NULL), (3, '2024-02-29 13:14:15', 99999999.99, '[]', b'0')",
+	} {
+		if _, err = pkg.Query(capability, &status, query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const query = "SELECT dt, d, j, b FROM t WHERE id > ? ORDER BY id"
+	text, err := pkg.Query(capability, &status, strings.Replace(query, "?", "0", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := mysql.NewStmt(pkg, capability, &status)
+	s.Query = query
+	if err = pkg.StmtPrepare(capability, s); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	result, err := s.Execute([]interface{}{int64(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]interface{}{
+		{"2024-02-29 13:14:15.123456", "-12.34", `{"a": [1, 2]}`, "\x02\x01"},
+		{"2024-02-29 00:00:00.000000", "0.00", nil, nil},
+		{"2024-02-29 13:14:15.000000", "99999999.99", "[]", "\x00\x00"},
+	}
+	if result.RowNumber() != len(want) || text.RowNumber() != len(want) {
+		t.Fatalf("expect %d rows, got %d of binary and %d of text", len(want), result.RowNumber(), text.RowNumber())
+	}
+	for i, values := range want {
+		for j, w := range values {
+			for _, rs := range []*mysql.Result{result, text} {
+				v, err := rs.GetValue(i, j)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if b, ok := v.([]byte); ok {
+					v = string(b)
+				}
+				if v != w {
+					t.Errorf("row %d, value of %s is %#v, want %#v", i+1, rs.Fields[j].Name, v, w)
+				}
+			}
+		}
+	}
+}
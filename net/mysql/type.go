@@ -58,3 +58,9 @@ const (
 	MYSQL_TYPE_STRING
 	MYSQL_TYPE_GEOMETRY
 )
+
+// MYSQL_TYPE_JSON is type of JSON column, MYSQL_TYPE_VECTOR is of VECTOR column since MySQL 9.0.
+const (
+	MYSQL_TYPE_VECTOR byte = 0xf2
+	MYSQL_TYPE_JSON   byte = 0xf5
+)
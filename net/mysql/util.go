@@ -285,38 +285,25 @@ func FormatBinaryDateTime(n int, data []byte) ([]byte, error) {
 	}
 }
 
-// FormatBinaryTime format binary time
+// FormatBinaryTime format binary time, hours include days, such as '-838:59:59'.
 func FormatBinaryTime(n int, data []byte) ([]byte, error) {
 	if n == 0 {
 		return []byte("00:00:00"), nil
 	}
+	if n != 8 && n != 12 {
+		return nil, fmt.Errorf("invalid time packet length %d", n)
+	}
 
-	var sign byte
+	var sign string
 	if data[0] == 1 {
-		sign = byte('-')
+		sign = "-"
 	}
-
-	switch n {
-	case 8:
-		return []byte(fmt.Sprintf(
-			"%c%02d:%02d:%02d",
-			sign,
-			uint16(data[1])*24+uint16(data[5]),
-			data[6],
-			data[7],
-		)), nil
-	case 12:
-		return []byte(fmt.Sprintf(
-			"%c%02d:%02d:%02d.%06d",
-			sign,
-			uint16(data[1])*24+uint16(data[5]),
-			data[6],
-			data[7],
-			binary.LittleEndian.Uint32(data[8:12]),
-		)), nil
-	default:
-		return nil, fmt.Errorf("invalid time packet length %d", n)
+	hours := binary.LittleEndian.Uint32(data[1:5])*24 + uint32(data[5])
+	if n == 8 {
+		return []byte(fmt.Sprintf("%s%02d:%02d:%02d", sign, hours, data[6], data[7])), nil
 	}
+	return []byte(fmt.Sprintf("%s%02d:%02d:%02d.%06d", sign, hours, data[6], data[7],
+		binary.LittleEndian.Uint32(data[8:12]))), nil
 }
 
 // Escape char in string.
@@ -0,0 +1,95 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"net"
+	"time"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/route"
+	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/utils/audit"
+)
+
+// auditKind get kind of statement in audit log, empty if not audited.
+func auditKind(stmt sqlparser.Statement) string {
+	switch stmt.(type) {
+	case *sqlparser.Insert, *sqlparser.Update, *sqlparser.Delete, *sqlparser.Replace:
+		return audit.KindDML
+	case sqlparser.DDLStatement:
+		return audit.KindDDL
+	case *sqlparser.Passthrough:
+		return audit.KindUnparsed
+	}
+	return ""
+}
+
+// auditStmts write DML and DDL to audit log if audit of schema is enabled, with nodes they are executed in.
+func (c *ClientConn) auditStmts(stmts []sqlparser.Statement, startTime time.Time, nodeNames []string, err error) {
+	if !audit.Enabled() {
+		return
+	}
+	schemaConfig := c.schemas[c.db]
+	if schemaConfig == nil || !schemaConfig.Audit {
+		return
+	}
+	clientIP, _, _ := net.SplitHostPort(c.c.RemoteAddr().String())
+	for _, stmt := range stmts {
+		kind := auditKind(stmt)
+		if len(kind) == 0 {
+			continue
+		}
+		e := &audit.Event{
+			Time:         startTime,
+			User:         c.user,
+			ClientIP:     clientIP,
+			ConnectionID: c.connectionID,
+			Schema:       c.db,
+			Kind:         kind,
+			ShardKey:     route.ShardKeyValue(schemaConfig, stmt),
+			Nodes:        nodeNames,
+			SQL:          sqlparser.String(stmt),
+		}
+		if err != nil {
+			e.Error = err.Error()
+		}
+		audit.Write(e)
+	}
+}
+
+// auditStmtExecute write executed prepared stmt to audit log, with args bound.
+func (c *ClientConn) auditStmtExecute(s *mysql.Stmt, startTime time.Time, err error) {
+	if !audit.Enabled() || len(auditKind(s.Statement)) == 0 {
+		return
+	}
+	stmt, e := sqlparser.BindArgs(s.Statement, s.Args)
+	if e != nil {
+		stmt = s.Statement
+	}
+	nodeNames := make([]string, 0, len(c.stmtShards))
+	for _, shard := range c.stmtShards {
+		nodeNames = append(nodeNames, shard.Node)
+	}
+	c.auditStmts([]sqlparser.Statement{stmt}, startTime, nodeNames, err)
+}
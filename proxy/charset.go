@@ -0,0 +1,189 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// Backend connections always use the charset of proxy (mysql.DEFAULT_CHARSET),
+// query from client is converted from character_set_client,
+// and result is converted to character_set_results in proxy.
+
+// setNames is 'SET NAMES', set character_set_client, character_set_connection and character_set_results.
+func (c *ClientConn) setNames(charset, collation string) error {
+	charset = trimCharsetName(charset)
+	if charset == "default" {
+		charset = mysql.DEFAULT_CHARSET
+	}
+	collationID, err := checkCharset(charset, trimCharsetName(collation))
+	if err != nil {
+		return err
+	}
+	c.charset = charset
+	c.resultsCharset = charset
+	c.collation = collationID
+	return nil
+}
+
+// setCharsetVariable set charset related session variable, return false if it's not charset variable.
+func (c *ClientConn) setCharsetVariable(name string, value string) (bool, error) {
+	name = strings.ToLower(strings.TrimLeft(name, "@"))
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "session."), "local.")
+	value = trimCharsetName(value)
+	if value == "default" {
+		value = mysql.DEFAULT_CHARSET
+	}
+	switch name {
+	case "character_set_client":
+		collationID, err := checkCharset(value, "")
+		if err != nil {
+			return true, err
+		}
+		c.charset = value
+		c.collation = collationID
+	case "character_set_results":
+		// NULL means no conversion.
+		if value == "null" {
+			c.resultsCharset = ""
+			return true, nil
+		}
+		if _, err := checkCharset(value, ""); err != nil {
+			return true, err
+		}
+		c.resultsCharset = value
+	case "character_set_connection":
+		// literals are interpreted by backend in charset of proxy.
+		if _, err := checkCharset(value, ""); err != nil {
+			return true, err
+		}
+	case "collation_connection":
+		collationID, ok := mysql.CollationNames[value]
+		if !ok {
+			return true, mysql.NewDefaultError(mysql.ER_UNKNOWN_COLLATION, value)
+		}
+		if _, err := checkCharset(mysql.CollationCharset(collationID), value); err != nil {
+			return true, err
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// setCharsetVariables set charset variables in 'SET' statement, and remove them from statement.
+func (c *ClientConn) setCharsetVariables(statement *sqlparser.SetVariable) error {
+	exprs := make(sqlparser.UpdateExprs, 0, len(statement.Exprs))
+	for _, expr := range statement.Exprs {
+		name := string(expr.Name.Name)
+		if len(expr.Name.Qualifier) > 0 {
+			name = string(expr.Name.Qualifier) + "." + name
+		}
+		handled, err := c.setCharsetVariable(name, sqlparser.String(expr.Expr))
+		if err != nil {
+			return err
+		}
+		if !handled {
+			exprs = append(exprs, expr)
+		}
+	}
+	statement.Exprs = exprs
+	return nil
+}
+
+// convertQuery convert query from character_set_client to charset of backend.
+func (c *ClientConn) convertQuery(sql string) string {
+	convert, err := mysql.NewCharsetConverter(c.charset, mysql.DEFAULT_CHARSET)
+	if err != nil || convert == nil {
+		return sql
+	}
+	return string(convert([]byte(sql)))
+}
+
+// writeResultSet write result set, that converted to character_set_results.
+func (c *ClientConn) writeResultSet(status uint16, result *mysql.Result) error {
+	if convert, err := mysql.NewCharsetConverter(mysql.DEFAULT_CHARSET, c.resultsCharset); err == nil && convert != nil {
+		collationID := mysql.CharsetIds[c.resultsCharset]
+		if c.resultsCharset == c.charset {
+			collationID = c.collation
+		}
+		result.ConvertCharset(convert, collationID)
+	}
+	return c.pkg.WriteResultSet(c.capability, status, result)
+}
+
+// canPassthroughRows is true if rows of statement's result could be written as raw packets of backend,
+// that is no charset conversion and no dark read to compare values.
+func (c *ClientConn) canPassthroughRows(statement sqlparser.Statement) bool {
+	if c.proxy.getConfig().RowPassthroughDisabled {
+		return false
+	}
+	if mirror := c.mirrors[statement]; mirror != nil && mirror.Read {
+		return false
+	}
+	convert, err := mysql.NewCharsetConverter(mysql.DEFAULT_CHARSET, c.resultsCharset)
+	return err == nil && convert == nil
+}
+
+// canStreamRows is true if rows could be written as raw packets, and result set could be written to client as it's read,
+// that is nothing is queried for it after, such as FOUND_ROWS().
+func (c *ClientConn) canStreamRows(statement sqlparser.Statement, countSQL string) bool {
+	if len(countSQL) > 0 || !c.canPassthroughRows(statement) || c.cacheQuery != nil {
+		return false
+	}
+	switch v := statement.(type) {
+	case *sqlparser.Select:
+		return !v.CalcFoundRows
+	case *sqlparser.SimpleSelect:
+		return !v.CalcFoundRows
+	}
+	return true
+}
+
+// checkCharset check charset and collation are valid, and could be converted from/to charset of backend.
+func checkCharset(charset, collation string) (mysql.CollationID, error) {
+	collationID, ok := mysql.CharsetIds[charset]
+	if !ok {
+		return 0, mysql.NewDefaultError(mysql.ER_UNKNOWN_CHARACTER_SET, charset)
+	}
+	if len(collation) > 0 {
+		if collationID, ok = mysql.CollationNames[collation]; !ok {
+			return 0, mysql.NewDefaultError(mysql.ER_UNKNOWN_COLLATION, collation)
+		}
+		if mysql.CollationCharset(collationID) != charset {
+			return 0, mysql.NewDefaultError(mysql.ER_COLLATION_CHARSET_MISMATCH, collation, charset)
+		}
+	}
+	if _, err := mysql.NewCharsetConverter(mysql.DEFAULT_CHARSET, charset); err != nil {
+		return 0, mysql.NewError(mysql.ER_NOT_SUPPORTED_YET, fmt.Sprintf("charset '%s' is not supported by proxy: %s", charset, err.Error()))
+	}
+	return collationID, nil
+}
+
+func trimCharsetName(name string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(name), "'\"`"))
+}
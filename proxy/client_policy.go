@@ -0,0 +1,120 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// clientPolicy check clients in handshake by capability and connection attributes, before auth.
+type clientPolicy struct {
+	capability    uint32
+	rejectOldAuth bool
+	denied        []deniedClient
+	tlsRequired   bool // reject clients not connected by TLS.
+}
+
+// deniedClient is pattern of connection attribute, such as '_client_version=5.1.*'.
+type deniedClient struct {
+	attr    string
+	pattern string
+}
+
+// insecure auth plugins rejected by reject_old_auth.
+var oldAuthPlugins = []string{"mysql_old_password", "mysql_clear_password"}
+
+// newClientPolicy parse policy, and TLS required by tls config, nil if nothing to check.
+func newClientPolicy(cfg config.ClientPolicyConfig, tlsRequired bool) (*clientPolicy, error) {
+	if len(cfg.RequiredCapabilities) == 0 && !cfg.RejectOldAuth && len(cfg.DeniedClients) == 0 && !tlsRequired {
+		return nil, nil
+	}
+	policy := new(clientPolicy)
+	policy.rejectOldAuth = cfg.RejectOldAuth
+	policy.tlsRequired = tlsRequired
+	for _, name := range cfg.RequiredCapabilities {
+		flag, ok := mysql.CapabilityNames[strings.TrimPrefix(strings.ToLower(name), "client_")]
+		if !ok {
+			return nil, fmt.Errorf("client_policy: unknown capability '%s'", name)
+		}
+		policy.capability |= flag
+	}
+	for _, denied := range cfg.DeniedClients {
+		pos := strings.IndexByte(denied, '=')
+		if pos <= 0 {
+			return nil, fmt.Errorf("client_policy: denied client '%s' should be 'attr=pattern'", denied)
+		}
+		client := deniedClient{attr: strings.TrimSpace(denied[:pos]), pattern: strings.TrimSpace(denied[pos+1:])}
+		if _, err := path.Match(client.pattern, ""); err != nil {
+			return nil, fmt.Errorf("client_policy: denied client '%s': %s", denied, err.Error())
+		}
+		policy.denied = append(policy.denied, client)
+	}
+	return policy, nil
+}
+
+// check client advertised in handshake response.
+func (policy *clientPolicy) check(client *mysql.HandshakeClient) error {
+	if policy.tlsRequired && client.Capability&mysql.CLIENT_SSL == 0 {
+		return mysql.NewError(mysql.ER_INSECURE_PLAIN_TEXT, "Connections using insecure transport are prohibited, TLS is required by saashard.")
+	}
+	if missing := policy.capability &^ client.Capability; missing != 0 {
+		return mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_AUTH_MODE)
+	}
+	if policy.rejectOldAuth {
+		if client.Capability&mysql.CLIENT_PROTOCOL_41 == 0 || client.Capability&mysql.CLIENT_SECURE_CONNECTION == 0 {
+			return mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_AUTH_MODE)
+		}
+		for _, plugin := range oldAuthPlugins {
+			if client.AuthPlugin == plugin {
+				return mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_AUTH_MODE)
+			}
+		}
+	}
+	for _, denied := range policy.denied {
+		value, ok := client.Attrs[denied.attr]
+		if !ok {
+			continue
+		}
+		if matched, _ := path.Match(denied.pattern, value); matched {
+			return mysql.NewError(mysql.ER_ACCESS_DENIED_ERROR,
+				fmt.Sprintf("client '%s=%s' access denied by saashard.", denied.attr, value))
+		}
+	}
+	return nil
+}
+
+// checkClient check client by policy in handshake.
+func (c *ClientConn) checkClient(client *mysql.HandshakeClient) error {
+	err := c.proxy.clientPolicy.check(client)
+	if err != nil {
+		simplelog.Warn("%s %s connectionID=%d,addr=%s,capability=%d,plugin=%s,attrs=%v: %s", "ClientConn", "checkClient",
+			c.connectionID, c.c.RemoteAddr().String(), client.Capability, client.AuthPlugin, client.Attrs, err.Error())
+	}
+	return err
+}
@@ -27,12 +27,17 @@ import (
 	"net"
 	"runtime"
 	"sync"
+	"sync/atomic"
 
 	"github.com/berkaroad/saashard/backend"
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
 	"github.com/berkaroad/saashard/config"
 	"github.com/berkaroad/saashard/errors"
 	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/route"
+	"github.com/berkaroad/saashard/sqlparser"
 	"github.com/berkaroad/saashard/utils/simplelog"
+	"github.com/berkaroad/saashard/utils/slowlog"
 )
 
 // ClientConn client <-> proxy
@@ -46,7 +51,8 @@ type ClientConn struct {
 	connectionID       uint32
 	status             uint16
 	collation          mysql.CollationID
-	charset            string
+	charset            string // character_set_client
+	resultsCharset     string // character_set_results, empty is NULL that not convert.
 	user               string
 	db                 string
 	salt               []byte
@@ -57,8 +63,42 @@ type ClientConn struct {
 	closed             bool
 	lastInsertID       int64
 	affectedRows       int64
+	foundRows          int64
 	stmtID             uint32
 	stmts              map[uint32]*mysql.Stmt //prepare,client -> proxy
+
+	mirrors             map[sqlparser.Statement]*route.Mirror // dual-write of executing plan.
+	idempotencyKeys     map[sqlparser.Statement]string        // idempotency keys of writes in executing plan.
+	pendingMirrorWrites []*mirrorWrite                        // dual-write after committed.
+	debug               bool                                  // log routing decisions, by 'SET saashard_debug=1'.
+	funcs               *route.LocalFuncs                     // functions evaluated by proxy, and sequences of session.
+	state               int32                                 // sessionIdle, sessionBusy or sessionClosing, accessed atomically.
+	draining            int32                                 // 1 if session should be closed after its transaction.
+	tag                 string                                // tag of session, by 'SET saashard_tag='worker-42''.
+	trans               *distTrans                            // transaction across nodes, if trans_mode of xa is not off.
+	sticky              bool                                  // pinned to master conns, user in sticky_users.
+	// sql_mode of session by 'SET sql_mode', used if sqlModeSet, or default of schema.
+	sqlMode    string
+	sqlModeSet bool
+	// delayedRead is 1 if reads routed to slaves are on delayed slaves for data rescue, set by admin.
+	delayedRead int32
+	// replicaRole is role of slaves reads of session are on, by user_replica_roles or 'SET saashard_replica_role'.
+	// queryRole is by annotation '/*replica_role=reporting*/' of query executing, prior to replicaRole.
+	replicaRole string
+	queryRole   string
+	// topology of hosts, nodes, schemas, shard maps and node groups session uses, refreshed after config reloaded.
+	topology *topology
+	// rows sent and affected by command executing, and statements executed in data nodes of prepared stmt, for slow log.
+	rowsSent     int64
+	rowsAffected int64
+	stmtShards   []slowlog.Shard
+
+	// cacheQuery is select executing to be cached, writtenTables are written in transaction, for result cache.
+	cacheQuery    *resultCacheQuery
+	writtenTables []string
+
+	// insertIDs are ids filled by sequence in insert statements executing, as insert ids of their results.
+	insertIDs map[sqlparser.Statement]uint64
 }
 
 // IsAllowConnect check ip in whitelist.
@@ -93,7 +133,22 @@ func (c *ClientConn) IsAllowConnect() bool {
 // Handshake between client and proxy.
 func (c *ClientConn) Handshake() error {
 	var err error
-	if err = c.pkg.WriteInitialHandshake(c.connectionID, c.salt, mysql.DEFAULT_COLLATION_ID, mysql.DEFAULT_CAPABILITY, c.status); err != nil {
+	// connection attributes are sent by client only if advertised, to check by client policy.
+	// auth plugin of client is verified if caching_sha2_password, or switched to mysql_native_password.
+	// OUT parameters of 'call' by stmt are sent as more results.
+	capability := mysql.DEFAULT_CAPABILITY | mysql.CLIENT_CONNECT_ATTRS | mysql.CLIENT_PLUGIN_AUTH | mysql.CLIENT_PS_MULTI_RESULTS
+	if c.pkg.TLSConfig != nil {
+		capability |= mysql.CLIENT_SSL
+	}
+	cfg := c.proxy.getConfig()
+	if !cfg.LocalInfileDisabled {
+		capability |= mysql.CLIENT_LOCAL_FILES
+	}
+	if cfg.Compress {
+		capability |= mysql.CLIENT_COMPRESS
+	}
+	if err = c.pkg.WriteInitialHandshake(c.connectionID, c.salt, mysql.DEFAULT_COLLATION_ID,
+		capability, c.status, mysql.AUTH_NATIVE_PASSWORD); err != nil {
 		simplelog.Error("%s %s %s connection id=%d,msg=%s", "server", "Handshake", err.Error(),
 			c.connectionID,
 			"send initial handshake error")
@@ -112,14 +167,20 @@ func (c *ClientConn) Handshake() error {
 		}
 		return name, nil
 	}
-	getCredentialsConfigBySchema := func(schema string) (string, string, error) {
-		schemaConfig := c.proxy.schemas[schema]
+	getCredentialsConfigBySchema := func(schema string) (string, []string, error) {
+		schemaConfig := c.proxy.GetSchemaConfig(schema)
 		if schemaConfig == nil {
-			return "", "", mysql.NewDefaultError(mysql.ER_BAD_DB_ERROR, schema)
+			return "", nil, mysql.NewDefaultError(mysql.ER_BAD_DB_ERROR, schema)
 		}
-		return schemaConfig.User, schemaConfig.Password, nil
+		return schemaConfig.User, []string{schemaConfig.Password, schemaConfig.SecondaryPassword}, nil
 	}
-	c.capability, c.collation, c.user, c.db, err = c.pkg.ReadHandshakeResponse(getDefaultSchemaByUser, c.c.RemoteAddr().String(), c.salt, getCredentialsConfigBySchema)
+	var checkClient func(client *mysql.HandshakeClient) error
+	if c.proxy.clientPolicy != nil {
+		checkClient = c.checkClient
+	}
+	c.capability, c.collation, c.user, c.db, err = c.pkg.ReadHandshakeResponse(getDefaultSchemaByUser, c.c.RemoteAddr().String(), c.salt, getCredentialsConfigBySchema, checkClient)
+	// TLS connection if SSL requested, it's not parked by frontend poller.
+	c.c = c.pkg.Conn()
 	if err != nil {
 		simplelog.Error("%s %s %s connection id=%d,msg=%s",
 			"server", "readHandshakeResponse", err.Error(),
@@ -127,10 +188,24 @@ func (c *ClientConn) Handshake() error {
 			"read Handshake Response error")
 
 		c.pkg.WriteError(c.capability, err)
+		c.pkg.Flush()
 
 		return err
 	}
-	c.schemas = c.proxy.getSchemasByUser(c.user)
+	c.topology = c.proxy.getTopology()
+	c.schemas = c.topology.schemasOfUser(c.user)
+	c.sticky = cfg.IsStickyUser(c.user)
+	c.replicaRole = cfg.UserReplicaRoles[c.user]
+	// charset of client in handshake, use default if not supported.
+	if charset := mysql.CollationCharset(c.collation); len(charset) > 0 && mysql.IsCharsetConvertible(charset) {
+		if _, err := mysql.NewCharsetConverter(mysql.DEFAULT_CHARSET, charset); err == nil {
+			c.charset = charset
+		}
+	}
+	if c.charset != mysql.CollationCharset(c.collation) {
+		c.collation = mysql.CharsetIds[c.charset]
+	}
+	c.resultsCharset = c.charset
 
 	if err := c.pkg.WriteOK(c.capability, c.status, nil); err != nil {
 		simplelog.Error("%s %s %s connection id=%d,msg=%s",
@@ -139,9 +214,19 @@ func (c *ClientConn) Handshake() error {
 			"write ok fail")
 		return err
 	}
+	if err := c.pkg.Flush(); err != nil {
+		return err
+	}
 
 	c.pkg.Sequence = 0
 
+	// compressed protocol after OK, if requested by client.
+	if c.capability&mysql.CLIENT_COMPRESS > 0 {
+		if err := c.pkg.SetCompressed(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -162,38 +247,54 @@ func (c *ClientConn) Run() {
 		c.Close()
 	}()
 
-	for {
-		data, err := c.pkg.ReadPacket()
+	for c.serveCommand() {
+	}
+}
 
-		if err != nil {
-			simplelog.Error("%s %s %s connection id=%d", "server", "Run", err.Error(), c.connectionID)
-			return
-		}
-		if err := c.dispatch(data); err != nil {
-			c.proxy.counter.IncrErrLogTotal()
-			if len(data) > 1 {
-				simplelog.Error("%s %s %s connection id=%d,sql=%s",
-					"server", "Run", err.Error(),
-					c.connectionID,
-					string(data[1:]))
-			} else {
-				simplelog.Error("%s %s %s connection id=%d",
-					"server", "Run", err.Error(),
-					c.connectionID)
-			}
+// serveCommand read and dispatch one command, return false if connection is closed.
+func (c *ClientConn) serveCommand() bool {
+	data, err := c.pkg.ReadPacket()
+	if err != nil {
+		simplelog.Error("%s %s %s connection id=%d", "server", "Run", err.Error(), c.connectionID)
+		if err == mysql.ErrPacketTooLarge {
 			c.pkg.WriteError(c.capability, err)
-			if err == errors.ErrBadConn {
-				c.Close()
-			}
+			c.pkg.Flush()
 		}
-
-		if c.closed {
-			return
+		return false
+	}
+	if !c.beginCommand() {
+		return false
+	}
+	c.refreshTopology()
+	if err := c.dispatch(data); err != nil {
+		c.proxy.counter.IncrErrLogTotal()
+		if len(data) > 1 {
+			simplelog.Error("%s %s %s connection id=%d,sql=%s",
+				"server", "Run", err.Error(),
+				c.connectionID,
+				string(data[1:]))
+		} else {
+			simplelog.Error("%s %s %s connection id=%d",
+				"server", "Run", err.Error(),
+				c.connectionID)
+		}
+		c.pkg.WriteError(c.capability, err)
+		if err == errors.ErrBadConn {
+			c.pkg.Flush()
+			c.Close()
 		}
-
-		c.pkg.Sequence = 0
-		c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
 	}
+	if c.closed {
+		return false
+	}
+	if err := c.pkg.Flush(); err != nil {
+		simplelog.Error("%s %s %s connection id=%d", "server", "Run", err.Error(), c.connectionID)
+		return false
+	}
+
+	c.pkg.Sequence = 0
+	c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
+	return c.endCommand()
 }
 
 // Close client
@@ -201,14 +302,35 @@ func (c *ClientConn) Close() error {
 	if c.closed {
 		return nil
 	}
+	atomic.StoreInt32(&c.state, sessionClosing)
+	if c.trans != nil {
+		c.rollbackBranches(c.trans.branches)
+		c.trans = nil
+	}
 	c.nodeInTrans = nil
+	c.discardMirrorWrites()
+	c.funcs.Close()
+	// maps are read by kill of other goroutines, nodes are got under lock.
+	c.Lock()
+	masterNodes := make([]*backend.DataNode, 0, len(c.backendMasterConns))
 	for node := range c.backendMasterConns {
-		c.returnMasterConn(node)
+		masterNodes = append(masterNodes, node)
 	}
+	slaveNodes := make([]*backend.DataNode, 0, len(c.backendSlaveConns))
 	for node := range c.backendSlaveConns {
+		slaveNodes = append(slaveNodes, node)
+	}
+	c.Unlock()
+	for _, node := range masterNodes {
+		c.returnMasterConn(node)
+	}
+	for _, node := range slaveNodes {
 		c.returnSlaveConn(node)
 	}
 
+	if c.proxy.poller != nil {
+		c.proxy.poller.remove(c)
+	}
 	c.c.Close()
 
 	c.closed = true
@@ -239,10 +361,10 @@ func (c *ClientConn) dispatch(data []byte) error {
 		return c.handleStmtExecute(data)
 	case mysql.COM_STMT_CLOSE:
 		return c.handleStmtClose(data)
-	// case mysql.COM_STMT_SEND_LONG_DATA:
-	// 	return c.handleStmtSendLongData(data)
-	// case mysql.COM_STMT_RESET:
-	// 	return c.handleStmtReset(data)
+	case mysql.COM_STMT_SEND_LONG_DATA:
+		return c.handleStmtSendLongData(data)
+	case mysql.COM_STMT_RESET:
+		return c.handleStmtReset(data)
 	case mysql.COM_SET_OPTION:
 		return c.pkg.WriteEOF(c.capability, 0)
 	default:
@@ -260,6 +382,37 @@ func (c *ClientConn) isAutoCommit() bool {
 	return c.status&mysql.SERVER_STATUS_AUTOCOMMIT > 0
 }
 
+// setInsertID set id filled by sequence in insert statement as insert id of its result, as auto-increment does.
+func (c *ClientConn) setInsertID(statement sqlparser.Statement, result *mysql.Result) {
+	if id, ok := c.insertIDs[statement]; ok && result != nil && result.InsertID == 0 {
+		result.InsertID = id
+	}
+}
+
+// trackResult keep session state of result, such as LAST_INSERT_ID(), FOUND_ROWS() and ROW_COUNT() answered by proxy,
+// since backend conn of next query may be another one.
+func (c *ClientConn) trackResult(result *mysql.Result) {
+	if result == nil {
+		return
+	}
+	// unchanged if no auto-increment value generated, as MySQL does.
+	if result.InsertID > 0 {
+		c.lastInsertID = int64(result.InsertID)
+	}
+	if result.Resultset == nil {
+		c.affectedRows = int64(result.AffectedRows)
+		c.rowsAffected += c.affectedRows
+	} else {
+		c.affectedRows = -1
+		c.foundRows = int64(result.RowNumber())
+		c.rowsSent += c.foundRows
+		// copied before converted and written to client.
+		if c.cacheQuery != nil && !result.IsStreamed() {
+			c.cacheQuery.result = result.Clone()
+		}
+	}
+}
+
 func (c *ClientConn) getOrCreateMasterConn(node *backend.DataNode) (conn backend.Connection, err error) {
 	defer c.Unlock()
 
@@ -274,7 +427,7 @@ func (c *ClientConn) getOrCreateMasterConn(node *backend.DataNode) (conn backend
 		}
 
 		if conn == nil || conn.IsClosed() {
-			dbHost := node.DataHost.Master
+			dbHost := node.DataHost.GetMaster()
 			if conn, err = dbHost.GetConnection(node.Database); err != nil {
 				return
 			}
@@ -283,6 +436,7 @@ func (c *ClientConn) getOrCreateMasterConn(node *backend.DataNode) (conn backend
 			c.backendMasterConns[node] = conn
 		}
 	}
+	err = conn.(*mysqlBackend.Conn).SetSQLMode(c.sessionSQLMode())
 	return
 }
 
@@ -291,7 +445,6 @@ func (c *ClientConn) returnMasterConn(node *backend.DataNode) {
 
 	c.Lock()
 	if conn := c.backendMasterConns[node]; conn != nil && !conn.IsClosed() {
-		conn.Rollback()
 		conn.ReturnConnection()
 		delete(c.backendMasterConns, node)
 	}
@@ -301,9 +454,28 @@ func (c *ClientConn) getOrCreateSlaveConn(node *backend.DataNode) (conn backend.
 	defer c.Unlock()
 
 	c.Lock()
-	if conn = c.backendSlaveConns[node]; conn == nil {
+	// slave taken offline is replaced, instead of reading from it until session closed,
+	// and so is slave cached before session is routed to delayed slave or slave of other role.
+	delayedRead := c.isDelayedRead()
+	role := c.readRole(node)
+	stale := func(conn backend.Connection) bool {
+		dbHost := conn.(*mysqlBackend.Conn).GetDBHost()
+		return dbHost.IsOffline() || dbHost.IsDelayed() != delayedRead || !delayedRead && dbHost.Role() != role
+	}
+	if conn = c.backendSlaveConns[node]; conn != nil && !conn.IsClosed() {
+		if stale(conn) {
+			for cachedNode, cachedConn := range c.backendSlaveConns {
+				if cachedConn == conn {
+					delete(c.backendSlaveConns, cachedNode)
+				}
+			}
+			conn.ReturnConnection()
+			conn = nil
+		}
+	}
+	if conn == nil {
 		for cachedNode := range c.backendSlaveConns {
-			if cachedNode.DataHost == node.DataHost {
+			if cachedNode.DataHost == node.DataHost && !stale(c.backendSlaveConns[cachedNode]) {
 				conn = c.backendSlaveConns[cachedNode]
 				c.backendSlaveConns[node] = conn
 				break
@@ -312,7 +484,13 @@ func (c *ClientConn) getOrCreateSlaveConn(node *backend.DataNode) (conn backend.
 
 		if conn == nil || conn.IsClosed() {
 			var dbHost *backend.DBHost
-			dbHost, err = node.DataHost.GetSlave()
+			if delayedRead {
+				dbHost, err = node.DataHost.GetDelayedSlave()
+			} else if len(role) > 0 {
+				dbHost, err = node.DataHost.GetRoleSlave(role)
+			} else {
+				dbHost, err = node.DataHost.GetSlave()
+			}
 			if err != nil {
 				return
 			}
@@ -322,9 +500,22 @@ func (c *ClientConn) getOrCreateSlaveConn(node *backend.DataNode) (conn backend.
 			c.backendSlaveConns[node] = conn
 		}
 	}
+	err = conn.(*mysqlBackend.Conn).SetSQLMode(c.sessionSQLMode())
 	return
 }
 
+// hasSlave reads of node could be on slave, delayed slave if session is routed to it, or slave of role.
+func (c *ClientConn) hasSlave(node *backend.DataNode) bool {
+	if c.isDelayedRead() {
+		return len(node.DataHost.DelayedSlaves) > 0
+	}
+	return len(c.readRole(node)) > 0 || node.DataHost.HasOnlineSlave()
+}
+
+func (c *ClientConn) isDelayedRead() bool {
+	return atomic.LoadInt32(&c.delayedRead) == 1
+}
+
 func (c *ClientConn) returnSlaveConn(node *backend.DataNode) {
 	defer c.Unlock()
 
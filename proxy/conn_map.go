@@ -0,0 +1,80 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"sync"
+)
+
+const connMapShards = 64
+
+// connMap is client connections by connection id, sharded to reduce lock contention of connecting and closing.
+type connMap [connMapShards]struct {
+	sync.RWMutex
+	conns map[uint32]*ClientConn
+}
+
+func newConnMap() *connMap {
+	m := new(connMap)
+	for i := range m {
+		m[i].conns = make(map[uint32]*ClientConn)
+	}
+	return m
+}
+
+func (m *connMap) get(connectionID uint32) *ClientConn {
+	shard := &m[connectionID%connMapShards]
+	shard.RLock()
+	defer shard.RUnlock()
+	return shard.conns[connectionID]
+}
+
+func (m *connMap) put(conn *ClientConn) {
+	shard := &m[conn.connectionID%connMapShards]
+	shard.Lock()
+	defer shard.Unlock()
+	shard.conns[conn.connectionID] = conn
+}
+
+func (m *connMap) remove(connectionID uint32) *ClientConn {
+	shard := &m[connectionID%connMapShards]
+	shard.Lock()
+	defer shard.Unlock()
+	conn := shard.conns[connectionID]
+	delete(shard.conns, connectionID)
+	return conn
+}
+
+// list is snapshot of all connections.
+func (m *connMap) list() []*ClientConn {
+	var conns []*ClientConn
+	for i := range m {
+		shard := &m[i]
+		shard.RLock()
+		for _, conn := range shard.conns {
+			conns = append(conns, conn)
+		}
+		shard.RUnlock()
+	}
+	return conns
+}
@@ -0,0 +1,43 @@
+package proxy
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+// BenchmarkConnMap connect, look up and close client connections concurrently.
+func BenchmarkConnMap(b *testing.B) {
+	m := newConnMap()
+	var lastID uint32
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			conn := &ClientConn{connectionID: atomic.AddUint32(&lastID, 1)}
+			m.put(conn)
+			if m.get(conn.connectionID) != conn {
+				b.Error("connection not found")
+				return
+			}
+			m.remove(conn.connectionID)
+		}
+	})
+}
+
+// BenchmarkConnMapGet look up connections concurrently, such as KILL and SHOW PROCESSLIST.
+func BenchmarkConnMapGet(b *testing.B) {
+	m := newConnMap()
+	for id := uint32(1); id <= 1024; id++ {
+		m.put(&ClientConn{connectionID: id})
+	}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		var id uint32
+		for pb.Next() {
+			id = id%1024 + 1
+			if m.get(id) == nil {
+				b.Error("connection not found")
+				return
+			}
+		}
+	})
+}
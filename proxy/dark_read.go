@@ -0,0 +1,108 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"hash/crc32"
+
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/route"
+	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// DefaultMaxDarkReads is max count of dark reads in flight, reads sampled more are not mirrored.
+var DefaultMaxDarkReads = 16
+
+// startDarkRead read from mirror location in background, and compare with result of current location.
+// It's skipped if dark reads in flight are too many, such as mirror location is slow.
+func (c *ClientConn) startDarkRead(mirror *route.Mirror, statement sqlparser.Statement, result *mysql.Result) {
+	if result.Resultset == nil {
+		return
+	}
+	select {
+	case c.proxy.darkReads <- struct{}{}:
+	default:
+		return
+	}
+	sql := sqlparser.String(statement)
+	rowCount, checksum := resultChecksum(result)
+	go func() {
+		c.proxy.execDarkRead(c.topology, c.connectionID, mirror, sql, rowCount, checksum)
+		<-c.proxy.darkReads
+	}()
+}
+
+// execDarkRead execute read in mirror locations of topology the read is routed by, mismatch is only counted and logged.
+func (p *Server) execDarkRead(t *topology, connectionID uint32, mirror *route.Mirror, sql string, rowCount int, checksum uint64) {
+	counter := t.migrationCounters[mirror.Schema]
+	for _, nodeName := range mirror.NodeNames {
+		counter.IncrDarkReads()
+		node := t.nodes[nodeName]
+		conn, err := node.DataHost.GetMaster().GetConnection(node.Database)
+		if err != nil {
+			counter.IncrDarkReadErrors()
+			simplelog.Error("%s %s %s connection id=%d,node=%s,sql=%s", "proxy", "execDarkRead", err.Error(),
+				connectionID, nodeName, sql)
+			continue
+		}
+		mysqlConn := conn.(*mysqlBackend.Conn)
+		mysqlConn.UseDB(node.Database)
+		result, err := mysqlConn.Query(sql)
+		conn.ReturnConnection()
+		if err != nil {
+			counter.IncrDarkReadErrors()
+			simplelog.Error("%s %s %s connection id=%d,node=%s,sql=%s", "proxy", "execDarkRead", err.Error(),
+				connectionID, nodeName, sql)
+			continue
+		}
+		mirrorRowCount, mirrorChecksum := resultChecksum(result)
+		if mirrorRowCount != rowCount || mirrorChecksum != checksum {
+			counter.IncrDarkReadMismatches()
+			simplelog.Warn("%s %s %s connection id=%d,node=%s,rows=%d,expected=%d,checksum=%d,expected=%d,sql=%s", "proxy", "execDarkRead", "Dark read mismatch",
+				connectionID, nodeName, mirrorRowCount, rowCount, mirrorChecksum, checksum, sql)
+		}
+	}
+}
+
+// resultChecksum get row count and checksum of rows, regardless of order of rows.
+// Row is hashed as text row of values formatted by fields, so that it's the same whatever go types of values.
+func resultChecksum(result *mysql.Result) (rowCount int, checksum uint64) {
+	if result.Resultset == nil {
+		return
+	}
+	var data []byte
+	for _, values := range result.Values {
+		data = data[:0]
+		for i, v := range values {
+			if v == nil {
+				data = append(data, 0xfb)
+				continue
+			}
+			data = append(data, mysql.StringToLenencStr(mysql.FormatValue(result.Fields[i], v))...)
+		}
+		checksum += uint64(crc32.ChecksumIEEE(data))
+	}
+	return len(result.Values), checksum
+}
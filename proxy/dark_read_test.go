@@ -0,0 +1,33 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+// TestResultChecksum checksum is of values formatted by fields, regardless of go types of values and order of rows.
+func TestResultChecksum(t *testing.T) {
+	fields := []*mysql.Field{
+		{Name: []byte("id"), ColumnType: mysql.MYSQL_TYPE_LONGLONG},
+		{Name: []byte("d"), ColumnType: mysql.MYSQL_TYPE_NEWDECIMAL, Decimals: 2},
+		{Name: []byte("s"), ColumnType: mysql.MYSQL_TYPE_VAR_STRING},
+	}
+	text := &mysql.Result{Resultset: &mysql.Resultset{Fields: fields, Values: [][]interface{}{
+		{int64(1), []byte("1.50"), "a"},
+		{int64(2), nil, []byte("")},
+	}}}
+	binary := &mysql.Result{Resultset: &mysql.Resultset{Fields: fields, Values: [][]interface{}{
+		{uint64(2), nil, ""},
+		{int64(1), 1.5, []byte("a")},
+	}}}
+	rowCount, checksum := resultChecksum(text)
+	if mirrorRowCount, mirrorChecksum := resultChecksum(binary); mirrorRowCount != rowCount || mirrorChecksum != checksum {
+		t.Errorf("expect the same checksum %d of %d rows, got %d of %d rows", checksum, rowCount, mirrorChecksum, mirrorRowCount)
+	}
+	// NULL is different from empty string.
+	binary.Values[0][2] = nil
+	if _, mirrorChecksum := resultChecksum(binary); mirrorChecksum == checksum {
+		t.Error("expect different checksum of NULL and empty string")
+	}
+}
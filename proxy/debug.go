@@ -0,0 +1,57 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// DebugVariable is session variable to log routing decisions of session's statements,
+// such as 'SET saashard_debug=1', without enabling verbose log globally.
+const DebugVariable = "saashard_debug"
+
+// setDebugVariable set debug variable in 'SET' statement, and remove it from statement.
+func (c *ClientConn) setDebugVariable(statement *sqlparser.SetVariable) error {
+	exprs := make(sqlparser.UpdateExprs, 0, len(statement.Exprs))
+	for _, expr := range statement.Exprs {
+		name := strings.ToLower(strings.TrimLeft(string(expr.Name.Name), "@"))
+		if len(expr.Name.Qualifier) > 0 || name != DebugVariable {
+			exprs = append(exprs, expr)
+			continue
+		}
+		value := strings.ToLower(strings.Trim(sqlparser.String(expr.Expr), "'\""))
+		switch value {
+		case "1", "on", "true":
+			c.debug = true
+		case "0", "off", "false", "default":
+			c.debug = false
+		default:
+			return mysql.NewDefaultError(mysql.ER_WRONG_VALUE_FOR_VAR, DebugVariable, value)
+		}
+	}
+	statement.Exprs = exprs
+	return nil
+}
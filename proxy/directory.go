@@ -0,0 +1,86 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/berkaroad/saashard/directory"
+	"github.com/berkaroad/saashard/route"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// openDirectory open lookup table of directory shard algorithm, nil if not configured.
+func (p *Server) openDirectory() (*directory.Table, error) {
+	cfg := p.getConfig()
+	if len(cfg.Directory.Node) == 0 {
+		for name, schemaConfig := range p.GetSchemaConfigs() {
+			if schemaConfig.ShardEnabled() && route.IsDirectoryShardAlgo(schemaConfig.ShardAlgo) {
+				return nil, fmt.Errorf("no data node of directory in schema '%s' with directory shard algorithm", name)
+			}
+		}
+		return nil, nil
+	}
+	node := p.GetDataNodes()[cfg.Directory.Node]
+	if node == nil {
+		return nil, fmt.Errorf("data node '%s' of directory not exists", cfg.Directory.Node)
+	}
+	return directory.OpenTable(node, cfg.Directory.Table, time.Duration(cfg.Directory.GetCacheTTL())*time.Second)
+}
+
+// Directory get lookup table of directory shard algorithm, nil if not configured.
+func (p *Server) Directory() *directory.Table {
+	return p.directory
+}
+
+// DefaultShardMapRefreshInterval is seconds between refreshes of shard maps saved by other proxies.
+const DefaultShardMapRefreshInterval = 5
+
+// openShardMapStore open store of shard map states in database of directory node, shared by proxies,
+// or shard_maps.json in log_path if directory is not configured, nil if neither.
+func (p *Server) openShardMapStore() (route.ShardMapStore, error) {
+	cfg := p.getConfig()
+	if len(cfg.Directory.Node) == 0 {
+		if len(cfg.LogPath) == 0 {
+			return nil, nil
+		}
+		return route.NewFileShardMapStore(filepath.Join(cfg.LogPath, "shard_maps.json")), nil
+	}
+	node := p.GetDataNodes()[cfg.Directory.Node]
+	if node == nil {
+		return nil, fmt.Errorf("data node '%s' of directory not exists", cfg.Directory.Node)
+	}
+	return directory.OpenShardMapTable(node, cfg.Directory.ShardMapTable)
+}
+
+// refreshShardMaps restore shard maps saved by other proxies periodically, in the shared store.
+func (p *Server) refreshShardMaps() {
+	for p.running {
+		time.Sleep(DefaultShardMapRefreshInterval * time.Second)
+		if err := p.ShardMaps().Refresh(); err != nil {
+			simplelog.Error("%s %s %s", "proxy", "refreshShardMaps", err.Error())
+		}
+	}
+}
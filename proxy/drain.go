@@ -0,0 +1,141 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"crypto/tls"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// DefaultDrainTimeout is default seconds to wait transaction of draining session.
+const DefaultDrainTimeout = 30
+
+// state of session.
+const (
+	sessionIdle int32 = iota
+	sessionBusy
+	sessionClosing
+)
+
+var sessionStateNames = []string{"idle", "busy", "closing"}
+
+// beginCommand mark session busy, return false if it's closing by drain.
+func (c *ClientConn) beginCommand() bool {
+	if atomic.CompareAndSwapInt32(&c.state, sessionIdle, sessionBusy) {
+		return true
+	}
+	return atomic.LoadInt32(&c.state) != sessionClosing
+}
+
+// endCommand mark session idle if not in transaction, and close it if draining.
+// return false if connection is closed.
+func (c *ClientConn) endCommand() bool {
+	if c.isInTransaction() {
+		return true
+	}
+	atomic.StoreInt32(&c.state, sessionIdle)
+	if atomic.LoadInt32(&c.draining) == 1 && atomic.CompareAndSwapInt32(&c.state, sessionIdle, sessionClosing) {
+		simplelog.Info("%s %s connectionID=%d,user=%s: %s", "ClientConn", "endCommand", c.connectionID, c.user, "drained")
+		c.Close()
+		return false
+	}
+	return true
+}
+
+// drain close session once its transaction is finished, or forcibly after timeout.
+// Forcibly closing only kills backend queries and shuts down reading of client socket, the session is closed by
+// its own goroutine when reading next command, as backend conns in use must not be returned by another goroutine.
+func (c *ClientConn) drain(timeout time.Duration) {
+	if !atomic.CompareAndSwapInt32(&c.draining, 0, 1) {
+		return
+	}
+	if atomic.CompareAndSwapInt32(&c.state, sessionIdle, sessionClosing) {
+		simplelog.Info("%s %s connectionID=%d,user=%s: %s", "ClientConn", "drain", c.connectionID, c.user, "drained")
+		c.Close()
+		return
+	}
+	time.AfterFunc(timeout, func() {
+		if atomic.SwapInt32(&c.state, sessionClosing) == sessionClosing {
+			return
+		}
+		simplelog.Warn("%s %s connectionID=%d,user=%s: %s", "ClientConn", "drain", c.connectionID, c.user,
+			"transaction is not finished in drain timeout, closed forcibly")
+		c.killBackendQueries()
+		c.shutdownRead()
+	})
+}
+
+// shutdownRead shut down reading of client socket, so that reading next command fails, even if it's parked in poller.
+func (c *ClientConn) shutdownRead() {
+	conn := c.c
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	if tcpConn, ok := conn.(interface{ CloseRead() error }); ok && tcpConn.CloseRead() == nil {
+		return
+	}
+	conn.SetReadDeadline(time.Now())
+}
+
+// DrainSessions drain sessions matched, return count of them.
+func (p *Server) DrainSessions(match func(c *ClientConn) bool) int {
+	timeout := p.getConfig().DrainTimeout
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+	count := 0
+	for _, c := range p.conns.list() {
+		if match(c) {
+			c.drain(time.Duration(timeout) * time.Second)
+			count++
+		}
+	}
+	return count
+}
+
+// DrainUser drain sessions of user.
+func (p *Server) DrainUser(user string) int {
+	return p.DrainSessions(func(c *ClientConn) bool {
+		return strings.EqualFold(c.user, user)
+	})
+}
+
+// DrainStaleSessions drain sessions whose user is removed, or whose schema is unassigned from user.
+// It should be called after schemas are reloaded.
+func (p *Server) DrainStaleSessions() int {
+	return p.DrainSessions(func(c *ClientConn) bool {
+		schemas := p.getSchemasByUser(c.user)
+		c.Lock()
+		db := c.db
+		c.Unlock()
+		if len(db) == 0 {
+			return len(schemas) == 0
+		}
+		_, ok := schemas[db]
+		return !ok
+	})
+}
@@ -0,0 +1,108 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/route"
+	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// mirrorWrite is a write executed in current shard location, to write to the other location.
+type mirrorWrite struct {
+	mirror       *route.Mirror
+	sql          string
+	affectedRows uint64
+}
+
+// addMirror add write to mirror if the statement is dual-write, or start dark read.
+func (c *ClientConn) addMirror(statement sqlparser.Statement, result *mysql.Result) {
+	mirror := c.mirrors[statement]
+	if mirror == nil {
+		return
+	}
+	if mirror.Read {
+		c.startDarkRead(mirror, statement, result)
+		return
+	}
+	w := new(mirrorWrite)
+	w.mirror = mirror
+	w.sql = sqlparser.String(statement)
+	w.affectedRows = result.AffectedRows
+	c.pendingMirrorWrites = append(c.pendingMirrorWrites, w)
+}
+
+// flushMirrorWrites write to mirror locations, after committed in current location.
+func (c *ClientConn) flushMirrorWrites() {
+	writes := c.pendingMirrorWrites
+	c.pendingMirrorWrites = nil
+	for _, w := range writes {
+		if w.mirror.Async {
+			go c.proxy.execMirrorWrite(c.topology, c.connectionID, w)
+		} else {
+			c.proxy.execMirrorWrite(c.topology, c.connectionID, w)
+		}
+	}
+}
+
+// discardMirrorWrites discard writes, when rollback in current location.
+func (c *ClientConn) discardMirrorWrites() {
+	for _, w := range c.pendingMirrorWrites {
+		if counter := c.topology.migrationCounters[w.mirror.Schema]; counter != nil {
+			counter.IncrDualWriteDiscarded()
+		}
+	}
+	c.pendingMirrorWrites = nil
+}
+
+// execMirrorWrite execute write in mirror locations of topology the write is routed by, by autocommit connection,
+// failure is only counted and logged, not returned to client.
+func (p *Server) execMirrorWrite(t *topology, connectionID uint32, w *mirrorWrite) {
+	counter := t.migrationCounters[w.mirror.Schema]
+	for _, nodeName := range w.mirror.NodeNames {
+		counter.IncrDualWrites()
+		node := t.nodes[nodeName]
+		conn, err := node.DataHost.GetMaster().GetConnection(node.Database)
+		if err != nil {
+			counter.IncrDualWriteErrors()
+			simplelog.Error("%s %s %s connection id=%d,node=%s,sql=%s", "proxy", "execMirrorWrite", err.Error(),
+				connectionID, nodeName, w.sql)
+			continue
+		}
+		mysqlConn := conn.(*mysqlBackend.Conn)
+		mysqlConn.UseDB(node.Database)
+		result, err := mysqlConn.Query(w.sql)
+		conn.ReturnConnection()
+		if err != nil {
+			counter.IncrDualWriteErrors()
+			simplelog.Error("%s %s %s connection id=%d,node=%s,sql=%s", "proxy", "execMirrorWrite", err.Error(),
+				connectionID, nodeName, w.sql)
+		} else if result.AffectedRows != w.affectedRows {
+			counter.IncrDualWriteMismatches()
+			simplelog.Warn("%s %s %s connection id=%d,node=%s,affected rows=%d,expected=%d,sql=%s", "proxy", "execMirrorWrite", "Dual-write mismatch",
+				connectionID, nodeName, result.AffectedRows, w.affectedRows, w.sql)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"strings"
+
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// foundRowsQuery is to get FOUND_ROWS() of select with SQL_CALC_FOUND_ROWS from the same backend conn.
+const foundRowsQuery = "SELECT FOUND_ROWS()"
+
+var errFoundRowsDistinctInMulti = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+	"SQL_CALC_FOUND_ROWS with DISTINCT across nodes")
+
+// countFoundRows remove SQL_CALC_FOUND_ROWS from select, and return sql to count rows instead,
+// if schema counts found rows by COUNT(*). Empty if not counted.
+func (c *ClientConn) countFoundRows(statement sqlparser.Statement) string {
+	sel, ok := statement.(*sqlparser.Select)
+	if !ok || !sel.CalcFoundRows {
+		return ""
+	}
+	if schemaConfig := c.schemas[c.db]; schemaConfig == nil || schemaConfig.GetCalcFoundRows() != config.CalcFoundRowsCount {
+		return ""
+	}
+	sel.CalcFoundRows = false
+	counted := *sel
+	counted.SelectExprs = foundRowsSelectExprs(sel)
+	counted.OrderBy = nil
+	counted.Limit = nil
+	counted.Lock = ""
+	// select as derived table, so that count of rows is right with group by and distinct.
+	return fmt.Sprintf("select count(*) from (%s) as saashard_found_rows", sqlparser.String(&counted))
+}
+
+// foundRowsSelectExprs is select expressions of derived table to count rows, only 1 for a row of each group,
+// or select expressions with duplicate names renamed, if rows are distinct by them, or they're referenced by
+// GROUP BY or HAVING, since duplicate names are not allowed in derived table.
+func foundRowsSelectExprs(sel *sqlparser.Select) sqlparser.SelectExprs {
+	if len(sel.Distinct) == 0 && sel.Having == nil && !groupBySelectExprs(sel) {
+		return sqlparser.SelectExprs{&sqlparser.NonStarExpr{Expr: sqlparser.NumVal("1")}}
+	}
+	selectExprs := make(sqlparser.SelectExprs, len(sel.SelectExprs))
+	names := make(map[string]bool)
+	for i, selectExpr := range sel.SelectExprs {
+		selectExprs[i] = selectExpr
+		nonStarExpr, ok := selectExpr.(*sqlparser.NonStarExpr)
+		if !ok {
+			continue
+		}
+		name := string(nonStarExpr.As)
+		if len(name) == 0 {
+			if colName, ok := nonStarExpr.Expr.(*sqlparser.ColName); ok {
+				name = string(colName.Name)
+			} else {
+				name = sqlparser.String(nonStarExpr.Expr)
+			}
+		}
+		// names of columns are case-insensitive.
+		if name = strings.ToLower(name); names[name] {
+			renamed := *nonStarExpr
+			renamed.As = []byte(fmt.Sprintf("saashard_found_rows_%d", i+1))
+			selectExprs[i] = &renamed
+			continue
+		}
+		names[name] = true
+	}
+	return selectExprs
+}
+
+// groupBySelectExprs is true if GROUP BY references select expressions, by position or alias.
+func groupBySelectExprs(sel *sqlparser.Select) bool {
+	for _, expr := range sel.GroupBy {
+		switch v := expr.(type) {
+		case sqlparser.NumVal:
+			return true
+		case *sqlparser.ColName:
+			if len(v.Qualifier) > 0 {
+				continue
+			}
+			for _, selectExpr := range sel.SelectExprs {
+				if nonStarExpr, ok := selectExpr.(*sqlparser.NonStarExpr); ok && strings.EqualFold(string(nonStarExpr.As), string(v.Name)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// queryFoundRows get FOUND_ROWS() of select with SQL_CALC_FOUND_ROWS from backend conn, by countSQL if not empty.
+// ok is false if select doesn't calc found rows.
+func queryFoundRows(conn *mysqlBackend.Conn, statement sqlparser.Statement, countSQL string) (foundRows int64, ok bool, err error) {
+	query := countSQL
+	if len(query) == 0 {
+		switch v := statement.(type) {
+		case *sqlparser.Select:
+			if v.CalcFoundRows {
+				query = foundRowsQuery
+			}
+		case *sqlparser.SimpleSelect:
+			if v.CalcFoundRows {
+				query = foundRowsQuery
+			}
+		}
+		if len(query) == 0 {
+			return 0, false, nil
+		}
+	}
+	result, err := conn.Query(query)
+	if err != nil {
+		return 0, false, err
+	}
+	foundRows, err = result.GetInt(0, 0)
+	return foundRows, err == nil, err
+}
+
+// trackFoundRows get FOUND_ROWS() of select with SQL_CALC_FOUND_ROWS, by countSQL if not empty.
+func (c *ClientConn) trackFoundRows(statement sqlparser.Statement, countSQL string, conn *mysqlBackend.Conn) error {
+	foundRows, ok, err := queryFoundRows(conn, statement, countSQL)
+	if ok {
+		c.foundRows = foundRows
+	}
+	return err
+}
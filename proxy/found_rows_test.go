@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// TestCountFoundRows rows are counted in derived table of only 1, or of select expressions without duplicate names.
+func TestCountFoundRows(t *testing.T) {
+	c := &ClientConn{db: "db1", schemas: map[string]*config.SchemaConfig{
+		"db1": {Name: "db1", CalcFoundRows: config.CalcFoundRowsCount}}}
+	cases := []struct {
+		sql      string
+		countSQL string
+	}{
+		{"select sql_calc_found_rows a.id, b.id from a join b on a.id = b.a_id order by a.id limit 10",
+			"select count(*) from (select 1 from a join b on a.id = b.a_id) as saashard_found_rows"},
+		{"select sql_calc_found_rows a, count(*) from t group by a limit 10",
+			"select count(*) from (select 1 from t group by a) as saashard_found_rows"},
+		{"select sql_calc_found_rows distinct a.id, b.id, b.ID from a join b on a.id = b.a_id limit 10",
+			"select count(*) from (select distinct a.id, b.id as saashard_found_rows_2, b.id as saashard_found_rows_3 from a join b on a.id = b.a_id) as saashard_found_rows"},
+		{"select sql_calc_found_rows a.x as k, count(*) from t group by k having count(*) > 1 limit 10",
+			"select count(*) from (select a.x as k, count(*) from t group by k having count(*) > 1) as saashard_found_rows"},
+		{"select a from t limit 10", ""},
+	}
+	for _, tc := range cases {
+		stmt, err := sqlparser.Parse(tc.sql)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if countSQL := c.countFoundRows(stmt); countSQL != tc.countSQL {
+			t.Errorf("%s: expect '%s', got '%s'", tc.sql, tc.countSQL, countSQL)
+		}
+		if sel := stmt.(*sqlparser.Select); sel.CalcFoundRows {
+			t.Errorf("%s: expect SQL_CALC_FOUND_ROWS removed", tc.sql)
+		}
+	}
+}
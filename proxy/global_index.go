@@ -0,0 +1,59 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/globalindex"
+)
+
+// openGlobalIndex open index table of global indexes, nil if not configured.
+func (p *Server) openGlobalIndex() (*globalindex.Table, error) {
+	cfg := p.getConfig()
+	if len(cfg.GlobalIndex.Node) == 0 {
+		for name, schemaConfig := range p.GetSchemaConfigs() {
+			if !schemaConfig.ShardEnabled() {
+				continue
+			}
+			for _, table := range schemaConfig.GetTables() {
+				if len(table.GlobalIndexes) > 0 && table.GetType() == config.TableTypeSharded {
+					return nil, fmt.Errorf("no data node of global index in schema '%s' with global indexes of table '%s'", name, table.Name)
+				}
+			}
+		}
+		return nil, nil
+	}
+	node := p.GetDataNodes()[cfg.GlobalIndex.Node]
+	if node == nil {
+		return nil, fmt.Errorf("data node '%s' of global index not exists", cfg.GlobalIndex.Node)
+	}
+	return globalindex.OpenTable(node, strings.TrimSpace(cfg.GlobalIndex.Table))
+}
+
+// GlobalIndex get index table of global indexes, nil if not configured.
+func (p *Server) GlobalIndex() *globalindex.Table {
+	return p.globalIndex
+}
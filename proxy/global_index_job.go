@@ -0,0 +1,388 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/berkaroad/saashard/backend"
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/route"
+	"github.com/berkaroad/saashard/utils"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// Kinds of global index job.
+const (
+	// GlobalIndexBackfill scan rows in shards, and add index entries of their values.
+	GlobalIndexBackfill = "backfill"
+	// GlobalIndexRepair backfill, then check index entries whose rows not exist in their nodes as orphans.
+	GlobalIndexRepair = "repair"
+)
+
+// Status of global index job.
+const (
+	JobRunning  = "running"
+	JobDone     = "done"
+	JobFailed   = "failed"
+	JobCanceled = "canceled"
+)
+
+const (
+	// globalIndexScanBatch is number of distinct values scanned from a shard, or index entries listed, per query.
+	globalIndexScanBatch = 1000
+	// globalIndexCheckBatch is number of index entries checked in a node per query.
+	globalIndexCheckBatch = 100
+	// maxGlobalIndexJobs is number of jobs kept, finished ones are removed from the oldest.
+	maxGlobalIndexJobs = 32
+	// maxGlobalIndexOrphans is number of orphans reported in a job, the rest are only counted.
+	maxGlobalIndexOrphans = 1000
+)
+
+// GlobalIndexJob is job to backfill or repair global index of a sharded table, run in background.
+// Counters are updated by the job atomically, others are guarded by lock of proxy.
+type GlobalIndexJob struct {
+	ID         int64
+	Kind       string
+	Schema     string
+	Table      string
+	Purge      bool
+	Status     string
+	Error      string
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	// Scanned is distinct values of index columns scanned in shards, too long ones are skipped.
+	Scanned int64
+	Skipped int64
+	// Checked is index entries checked for orphans, in repair job.
+	Checked int64
+	// Orphans are index entries whose rows not exist in their nodes, removed from index if purge.
+	Orphans     []route.GlobalIndexEntry
+	OrphanCount int64
+	Purged      int64
+
+	canceled int32
+}
+
+var globalIndexJobID int64
+
+// StartGlobalIndexJob start job of kind to backfill or repair global index of table in background.
+// Orphans are removed in repair job only if purge, as index entries are added before rows are written.
+func (p *Server) StartGlobalIndexJob(kind, schemaName, tableName string, purge bool) (*GlobalIndexJob, error) {
+	if p.globalIndex == nil {
+		return nil, errors.ErrNoGlobalIndex
+	}
+	schemaConfig := p.GetSchemaConfig(schemaName)
+	if schemaConfig == nil || !schemaConfig.ShardEnabled() {
+		return nil, fmt.Errorf("schema '%s' not exists or not sharded", schemaName)
+	}
+	tableConfig := schemaConfig.GetTables()[tableName]
+	if tableConfig == nil || tableConfig.GetType() != config.TableTypeSharded || len(tableConfig.GlobalIndexes) == 0 {
+		return nil, fmt.Errorf("table '%s' of schema '%s' not sharded with global indexes", tableName, schemaName)
+	}
+
+	p.Lock()
+	for _, job := range p.globalIndexJobs {
+		if job.Status == JobRunning && job.Schema == schemaName && job.Table == tableName {
+			p.Unlock()
+			return nil, fmt.Errorf("job %d of table '%s' of schema '%s' is running", job.ID, tableName, schemaName)
+		}
+	}
+	job := &GlobalIndexJob{
+		ID:        atomic.AddInt64(&globalIndexJobID, 1),
+		Kind:      kind,
+		Schema:    schemaName,
+		Table:     tableName,
+		Purge:     purge,
+		Status:    JobRunning,
+		StartedAt: time.Now(),
+	}
+	p.globalIndexJobs = append(p.globalIndexJobs, job)
+	for i := 0; len(p.globalIndexJobs) > maxGlobalIndexJobs && i < len(p.globalIndexJobs); {
+		if p.globalIndexJobs[i].Status == JobRunning {
+			i++
+			continue
+		}
+		p.globalIndexJobs = append(p.globalIndexJobs[:i], p.globalIndexJobs[i+1:]...)
+	}
+	p.Unlock()
+
+	go p.runGlobalIndexJob(job, tableConfig)
+	return job, nil
+}
+
+// GlobalIndexJobs get copies of global index jobs, from the oldest.
+func (p *Server) GlobalIndexJobs() []GlobalIndexJob {
+	p.Lock()
+	defer p.Unlock()
+	jobs := make([]GlobalIndexJob, len(p.globalIndexJobs))
+	for i, job := range p.globalIndexJobs {
+		jobs[i] = GlobalIndexJob{
+			ID: job.ID, Kind: job.Kind, Schema: job.Schema, Table: job.Table, Purge: job.Purge,
+			Status: job.Status, Error: job.Error, StartedAt: job.StartedAt, FinishedAt: job.FinishedAt,
+			Scanned:     atomic.LoadInt64(&job.Scanned),
+			Skipped:     atomic.LoadInt64(&job.Skipped),
+			Checked:     atomic.LoadInt64(&job.Checked),
+			Orphans:     job.Orphans,
+			OrphanCount: job.OrphanCount,
+			Purged:      atomic.LoadInt64(&job.Purged),
+		}
+	}
+	return jobs
+}
+
+// CancelGlobalIndexJob cancel running job, it stops after current batch. False if not exists or not running.
+func (p *Server) CancelGlobalIndexJob(id int64) bool {
+	p.Lock()
+	defer p.Unlock()
+	for _, job := range p.globalIndexJobs {
+		if job.ID == id && job.Status == JobRunning {
+			atomic.StoreInt32(&job.canceled, 1)
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Server) runGlobalIndexJob(job *GlobalIndexJob, tableConfig *config.TableConfig) {
+	simplelog.Info("%s %s %s id=%d,kind=%s,schema=%s,table=%s", "proxy", "runGlobalIndexJob", "Job started",
+		job.ID, job.Kind, job.Schema, job.Table)
+	nodes := p.globalIndexJobNodes(job.Schema)
+	var err error
+	for _, column := range tableConfig.GlobalIndexes {
+		column = strings.Trim(strings.ToLower(column), "`")
+		for _, nodeName := range nodes {
+			if err = p.backfillGlobalIndex(job, column, nodeName); err != nil {
+				break
+			}
+		}
+		if err == nil && job.Kind == GlobalIndexRepair {
+			err = p.checkGlobalIndexOrphans(job, column, nodes)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	p.Lock()
+	job.FinishedAt = time.Now()
+	switch {
+	case err == errGlobalIndexJobCanceled:
+		job.Status = JobCanceled
+	case err != nil:
+		job.Status = JobFailed
+		job.Error = err.Error()
+	default:
+		job.Status = JobDone
+	}
+	p.Unlock()
+	simplelog.Info("%s %s %s id=%d,status=%s,scanned=%d,orphans=%d,purged=%d,error=%s", "proxy", "runGlobalIndexJob", "Job finished",
+		job.ID, job.Status, atomic.LoadInt64(&job.Scanned), job.OrphanCount, atomic.LoadInt64(&job.Purged), job.Error)
+}
+
+var errGlobalIndexJobCanceled = fmt.Errorf("job canceled")
+
+// checkGlobalIndexJob check whether job is canceled or proxy is closed, before next batch.
+func (p *Server) checkGlobalIndexJob(job *GlobalIndexJob) error {
+	if atomic.LoadInt32(&job.canceled) == 1 || !p.running {
+		return errGlobalIndexJobCanceled
+	}
+	return nil
+}
+
+// globalIndexJobNodes get shard nodes of schema, including staged ones of shard migration.
+func (p *Server) globalIndexJobNodes(schemaName string) []string {
+	nodeNames := append([]string(nil), p.GetSchemaConfig(schemaName).Nodes...)
+	if m := p.ShardMaps().Get(schemaName); m != nil && m.Staged != nil {
+		for _, nodeName := range m.Staged.Nodes {
+			if !utils.Contains(nodeNames, nodeName) {
+				nodeNames = append(nodeNames, nodeName)
+			}
+		}
+	}
+	return nodeNames
+}
+
+// backfillGlobalIndex scan distinct values of column in table of node, ordered by value, and add index entries of them.
+func (p *Server) backfillGlobalIndex(job *GlobalIndexJob, column, nodeName string) error {
+	var last *string
+	for {
+		if err := p.checkGlobalIndexJob(job); err != nil {
+			return err
+		}
+		var values []string
+		err := p.execInNode(nodeName, func(conn *mysqlBackend.Conn) error {
+			query := fmt.Sprintf("SELECT DISTINCT `%s` FROM `%s` WHERE `%s` IS NOT NULL", column, job.Table, column)
+			if last != nil {
+				query += fmt.Sprintf(" AND `%s` > %s", column, mysqlBackend.QuoteString(*last))
+			}
+			result, err := conn.Query(query + fmt.Sprintf(" ORDER BY `%s` LIMIT %d", column, globalIndexScanBatch))
+			if err != nil {
+				return err
+			}
+			values = make([]string, result.RowNumber())
+			for i := range values {
+				if values[i], err = result.GetString(i, 0); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("scan '%s' in node '%s': %s", column, nodeName, err.Error())
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		entries := make([]route.GlobalIndexEntry, 0, len(values))
+		for _, value := range values {
+			if len(value) > route.MaxGlobalIndexValueLen {
+				atomic.AddInt64(&job.Skipped, 1)
+				continue
+			}
+			entries = append(entries, route.GlobalIndexEntry{Column: column, Value: value, Node: nodeName})
+		}
+		if err = p.globalIndex.Add(job.Schema, job.Table, entries); err != nil {
+			return err
+		}
+		atomic.AddInt64(&job.Scanned, int64(len(values)))
+		if len(values) < globalIndexScanBatch {
+			return nil
+		}
+		last = &values[len(values)-1]
+	}
+}
+
+// checkGlobalIndexOrphans list index entries of column, and check whether rows of them exist in their nodes.
+// Entries of nodes not in schema are orphans too.
+func (p *Server) checkGlobalIndexOrphans(job *GlobalIndexJob, column string, nodes []string) error {
+	var after route.GlobalIndexEntry
+	for {
+		if err := p.checkGlobalIndexJob(job); err != nil {
+			return err
+		}
+		entries, err := p.globalIndex.Entries(job.Schema, job.Table, column, after, globalIndexScanBatch)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		values := make(map[string][]string)
+		var orphans []route.GlobalIndexEntry
+		for _, entry := range entries {
+			if utils.Contains(nodes, entry.Node) {
+				values[entry.Node] = append(values[entry.Node], entry.Value)
+			} else {
+				orphans = append(orphans, entry)
+			}
+		}
+		for nodeName, nodeValues := range values {
+			for i := 0; i < len(nodeValues); i += globalIndexCheckBatch {
+				end := i + globalIndexCheckBatch
+				if end > len(nodeValues) {
+					end = len(nodeValues)
+				}
+				missing, err := p.missingValuesInNode(job.Table, column, nodeName, nodeValues[i:end])
+				if err != nil {
+					return fmt.Errorf("check '%s' in node '%s': %s", column, nodeName, err.Error())
+				}
+				for _, value := range missing {
+					orphans = append(orphans, route.GlobalIndexEntry{Column: column, Value: value, Node: nodeName})
+				}
+			}
+		}
+		atomic.AddInt64(&job.Checked, int64(len(entries)))
+
+		if len(orphans) > 0 {
+			p.Lock()
+			job.OrphanCount += int64(len(orphans))
+			for _, orphan := range orphans {
+				if len(job.Orphans) < maxGlobalIndexOrphans {
+					job.Orphans = append(job.Orphans, orphan)
+				}
+			}
+			p.Unlock()
+			if job.Purge {
+				if err = p.globalIndex.Remove(job.Schema, job.Table, orphans); err != nil {
+					return err
+				}
+				atomic.AddInt64(&job.Purged, int64(len(orphans)))
+			}
+		}
+		if len(entries) < globalIndexScanBatch {
+			return nil
+		}
+		after = entries[len(entries)-1]
+	}
+}
+
+// missingValuesInNode get values not exist in column of table in node.
+// Values are compared with column, so that conversion and collation of column are applied.
+func (p *Server) missingValuesInNode(tableName, column, nodeName string, values []string) ([]string, error) {
+	selects := make([]string, len(values))
+	for i, value := range values {
+		selects[i] = fmt.Sprintf("SELECT %s FROM DUAL WHERE NOT EXISTS (SELECT 1 FROM `%s` WHERE `%s` = %s)",
+			mysqlBackend.QuoteString(value), tableName, column, mysqlBackend.QuoteString(value))
+	}
+	var missing []string
+	err := p.execInNode(nodeName, func(conn *mysqlBackend.Conn) error {
+		result, err := conn.Query(strings.Join(selects, " UNION ALL "))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < result.RowNumber(); i++ {
+			value, err := result.GetString(i, 0)
+			if err != nil {
+				return err
+			}
+			missing = append(missing, value)
+		}
+		return nil
+	})
+	return missing, err
+}
+
+// execInNode execute in connection of master of data node.
+func (p *Server) execInNode(nodeName string, f func(conn *mysqlBackend.Conn) error) error {
+	var node *backend.DataNode
+	if node = p.GetDataNodes()[nodeName]; node == nil {
+		return fmt.Errorf("data node not exists")
+	}
+	conn, err := node.DataHost.GetMaster().GetConnection(node.Database)
+	if err != nil {
+		return err
+	}
+	defer conn.ReturnConnection()
+	mysqlConn := conn.(*mysqlBackend.Conn)
+	if err = mysqlConn.UseDB(node.Database); err != nil {
+		return err
+	}
+	return f(mysqlConn)
+}
@@ -35,13 +35,14 @@ func (c *ClientConn) handleFieldList(data []byte) error {
 	table := string(data[0:index])
 	wildcard := string(data[index+1:])
 
-	nodeName := c.schemas[c.db].Nodes[0]
-	node := c.proxy.nodes[nodeName]
+	read := !c.isInTransaction() && !c.sticky
+	nodeName := c.topology.nodeGroups.Node(c.db, c.schemas[c.db].Nodes[0], read)
+	node := c.topology.nodes[nodeName]
 
 	var err error
 	var conn backend.Connection
 	// Get backend conn from slave or master.
-	if !c.isInTransaction() && len(node.DataHost.Slaves) > 0 {
+	if read && c.hasSlave(node) {
 		if conn, err = c.getOrCreateSlaveConn(node); err != nil {
 			return err
 		}
@@ -53,7 +54,7 @@ func (c *ClientConn) handleFieldList(data []byte) error {
 	mysqlConn := conn.(*mysqlBackend.Conn)
 
 	defer func() {
-		if !c.isInTransaction() {
+		if read {
 			conn.Close()
 		}
 	}()
@@ -23,16 +23,31 @@
 package proxy
 
 import (
+	"net"
 	"strings"
 
-	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/net/mysql"
 )
 
+// handleInitDB switch logical schema of session, by COM_INIT_DB or 'use db'.
 func (c *ClientConn) handleInitDB(db string) error {
-	db = strings.ToLower(db)
-	if _, ok := c.proxy.getSchemasByUser(c.user)[db]; ok {
-		c.db = db
-		return c.pkg.WriteOK(c.capability, c.status, nil)
+	db = strings.ToLower(strings.Trim(db, "`"))
+	if len(db) == 0 {
+		return mysql.NewDefaultError(mysql.ER_NO_DB_ERROR)
 	}
-	return errors.ErrNoSchema
+
+	// Reload user's schemas, since config may be changed.
+	schemas := c.topology.schemasOfUser(c.user)
+	if _, ok := schemas[db]; !ok {
+		if c.topology.schemas[db] != nil {
+			clientHost, _, _ := net.SplitHostPort(c.c.RemoteAddr().String())
+			return mysql.NewDefaultError(mysql.ER_DBACCESS_DENIED_ERROR, c.user, clientHost, db)
+		}
+		return mysql.NewDefaultError(mysql.ER_BAD_DB_ERROR, db)
+	}
+	c.Lock()
+	c.schemas = schemas
+	c.db = db
+	c.Unlock()
+	return c.pkg.WriteOK(c.capability, c.status, nil)
 }
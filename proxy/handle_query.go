@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/berkaroad/saashard/backend"
 	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
@@ -33,6 +34,7 @@ import (
 	"github.com/berkaroad/saashard/net/mysql"
 	"github.com/berkaroad/saashard/route"
 	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/statistic"
 	"github.com/berkaroad/saashard/utils/simplelog"
 )
 
@@ -55,6 +57,8 @@ func (c *ClientConn) handleQuery(sql string) (err error) {
 		}
 	}()
 
+	received := len(sql)
+	sql = c.convertQuery(sql)
 	var plan route.Plan
 	var sqls []string
 	if c.capability&mysql.CLIENT_MULTI_STATEMENTS > 0 {
@@ -67,6 +71,18 @@ func (c *ClientConn) handleQuery(sql string) (err error) {
 	for _, sql := range sqls {
 		stmt, err := sqlparser.Parse(sql)
 		if err != nil {
+			if _, ok := err.(*sqlparser.LimitError); ok {
+				simplelog.Warn("%s %s connectionID=%d,user=%s: %s", "ClientConn", "handleQuery", c.connectionID, c.user, err.Error())
+				return mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+			}
+			// Unsharded schema, send to backend as it is.
+			if schemaConfig := c.schemas[c.db]; schemaConfig != nil && !schemaConfig.ShardEnabled() {
+				stmts = append(stmts, &sqlparser.Passthrough{SQL: []byte(sql)})
+				continue
+			}
+			if isLoadData(sql) {
+				return errLoadDataInShard
+			}
 			simplelog.Error("%s %s %s sql=%s", "proxy", "handleQuery", err.Error(), sql)
 			return mysql.NewError(mysql.ER_SYNTAX_ERROR, fmt.Sprintf("Syntax error or not supported for '%s': '%s'", sql, err.Error()))
 		}
@@ -75,13 +91,83 @@ func (c *ClientConn) handleQuery(sql string) (err error) {
 		}
 	}
 
+	// fingerprint before statements are rewritten in routing.
+	record := c.statementRecorder(c.db, statementsFingerprint(stmts), received)
+	defer func() { record(err) }()
+
 	if len(stmts) > 0 {
-		router := route.NewRouter(c.db, c.schemas, c.proxy.cfg.GetNodes(), c.connectionID, c.user, c.isInTransaction())
+		c.idempotencyKeys = nil
+		for _, stmt := range stmts {
+			if key := route.ReadIdempotencyKey(stmt); len(key) > 0 {
+				if c.idempotencyKeys == nil {
+					c.idempotencyKeys = make(map[sqlparser.Statement]string)
+				}
+				c.idempotencyKeys[stmt] = key
+			}
+		}
+		router := route.NewRouter(c.db, c.schemas, c.topology.cfg.GetNodes(), c.connectionID, c.user, c.isInTransaction())
+		router.ShardMaps = c.topology.shardMaps
+		router.NodeGroups = c.topology.nodeGroups
+		router.ReadOnly = c.proxy.readOnly
+		router.PlanPins = c.proxy.planPins
+		router.Sequence = c.proxy.sequence
+		router.Directory = c.proxy.directory
+		router.GlobalIndex = c.proxy.globalIndex
+		router.Labels = statistic.AddLabel(statistic.ParseLabels(sql), TagLabel, c.tag)
+		c.queryRole = statistic.ParseAnnotation(sql, ReplicaRoleAnnotation)
+		defer func() { c.queryRole = "" }()
+		router.Debug = c.debug
+		router.Funcs = c.funcs
+		router.Sticky = c.sticky
+		router.MaxAllowedPacket = c.pkg.MaxAllowedPacket
+		router.LastInsertID = uint64(c.lastInsertID)
+		router.FoundRows = c.foundRows
+		router.RowCount = c.affectedRows
+		if c.nodeInTrans != nil {
+			router.NodeInTrans = c.nodeInTrans.Name
+		}
+		c.rowsSent, c.rowsAffected, c.cacheQuery = 0, 0, nil
+		if handled, e := c.handleMultiGet(sql, stmts, router); handled {
+			return e
+		}
 		plan, err = router.BuildMergedPlan(stmts...)
 		if err != nil {
 			return
 		}
-		return plan.Execute(c.executePlanWithQueryCommand, c.c.RemoteAddr(), strings.ToLower(c.proxy.logSQL[c.proxy.logSQLIndex]) != "on", c.proxy.slowLogTime[c.proxy.slowLogTimeIndex], c.proxy.counter)
+		c.insertIDs = router.InsertIDs
+		defer func() { c.insertIDs = nil }()
+		c.mirrors = plan.GetMirrors()
+		if len(stmts) == 1 && len(c.mirrors) == 0 {
+			if handled, e := c.serveResultCache(stmts[0], plan.GetNodeNames(), ""); handled {
+				return e
+			}
+		}
+		var backendConnAddrs []string
+		executor := func(statements []sqlparser.Statement, results []*mysql.Result, dataNodes []string, isSlave bool,
+			queryDataNodes map[sqlparser.Statement][]string) (addrs []string, err error) {
+			addrs, err = c.executePlanWithQueryCommand(statements, results, dataNodes, isSlave, queryDataNodes)
+			backendConnAddrs = addrs
+			return
+		}
+		startTime := time.Now()
+		err = plan.Execute(executor, c.c.RemoteAddr(), strings.ToLower(c.proxy.logSQL[c.proxy.logSQLIndex]) != "on", c.proxy.slowLogTime[c.proxy.slowLogTimeIndex], c.proxy.counter)
+		c.logSlowQuery(sql, startTime, plan.OnSlave(), err, router.Labels, planShards(plan, backendConnAddrs))
+		c.auditStmts(stmts, startTime, plan.GetNodeNames(), err)
+		c.storeResultCache(err)
+		c.invalidateResultCache(stmts...)
+		c.mirrors = nil
+		// metadata of prepared stmts may be changed by DDL.
+		for _, stmt := range stmts {
+			if _, ok := stmt.(sqlparser.DDLStatement); ok {
+				c.proxy.stmtMetas.invalidate(c.db)
+				break
+			}
+		}
+		// dual-write after committed in current shard location.
+		if len(c.pendingMirrorWrites) > 0 && !c.isInTransaction() {
+			c.flushMirrorWrites()
+		}
+		return
 	}
 	return c.pkg.WriteOK(c.capability, c.status, nil)
 }
@@ -94,16 +180,20 @@ func (c *ClientConn) executePlanWithQueryCommand(statements []sqlparser.Statemen
 
 	if len(dataNodes) == 1 {
 		resultCount := len(statements)
-		node := c.proxy.nodes[dataNodes[0]]
-		// If in transaction, must exec in the same node.
-		if c.isInTransaction() && node != c.nodeInTrans {
-			err = errors.ErrTransInMulti
+		node := c.topology.nodes[dataNodes[0]]
+		// transaction across nodes is begun, committed and rolled back by coordinator.
+		var handled bool
+		if handled, err = c.handleDistTrans(statements); handled {
+			return
+		}
+		// If in transaction, must exec in the same node, except transaction across nodes.
+		if err = c.checkNodeInTrans(node); err != nil {
 			return
 		}
 
 		var conn backend.Connection
 		// Get backend conn from slave or master.
-		if isSlave && len(node.DataHost.Slaves) > 0 {
+		if isSlave && c.hasSlave(node) {
 			if conn, err = c.getOrCreateSlaveConn(node); err != nil {
 				return
 			}
@@ -113,6 +203,10 @@ func (c *ClientConn) executePlanWithQueryCommand(statements []sqlparser.Statemen
 			}
 		}
 
+		if err = c.enlistInTrans(node, conn); err != nil {
+			return
+		}
+
 		backendConnAddrs = []string{conn.GetAddr()}
 		var mysqlConn = conn.(*mysqlBackend.Conn)
 		mysqlConn.UseDB(node.Database)
@@ -128,11 +222,12 @@ func (c *ClientConn) executePlanWithQueryCommand(statements []sqlparser.Statemen
 				if results[i].Resultset == nil {
 					err = c.pkg.WriteOK(c.capability, c.status, results[i])
 				} else {
-					err = c.pkg.WriteResultSet(c.capability, c.status, results[i])
+					err = c.writeResultSet(c.status, results[i])
 				}
 				if err != nil {
 					return
 				}
+				c.trackResult(results[i])
 			} else if statements[i] != nil {
 				statement := statements[i]
 
@@ -154,6 +249,7 @@ func (c *ClientConn) executePlanWithQueryCommand(statements []sqlparser.Statemen
 					if err = mysqlConn.Commit(); err != nil {
 						return
 					}
+					c.flushMirrorWrites()
 					c.status &= ^mysql.SERVER_STATUS_IN_TRANS
 					if c.status&mysql.SERVER_STATUS_AUTOCOMMIT > 0 {
 						c.nodeInTrans = nil
@@ -165,6 +261,7 @@ func (c *ClientConn) executePlanWithQueryCommand(statements []sqlparser.Statemen
 					if err = mysqlConn.Rollback(); err != nil {
 						return
 					}
+					c.discardMirrorWrites()
 					c.status &= ^mysql.SERVER_STATUS_IN_TRANS
 					if c.status&mysql.SERVER_STATUS_AUTOCOMMIT > 0 {
 						c.nodeInTrans = nil
@@ -173,62 +270,87 @@ func (c *ClientConn) executePlanWithQueryCommand(statements []sqlparser.Statemen
 					err = c.pkg.WriteOK(c.capability, c.status, result)
 					return
 				case *sqlparser.KillQuery:
-					connID := v.GetConnectionID()
-					if c.proxy.cfg.AllowKillQuery {
-						c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
-						if connID == c.connectionID {
-							c.Close()
-						} else if specConn := c.proxy.GetConnection(connID); specConn != nil {
-							if specConn.user == c.user {
-								err = mysql.NewDefaultError(mysql.ER_QUERY_INTERRUPTED)
-								specConn.Close()
-							} else {
-								err = mysql.NewDefaultError(mysql.ER_KILL_DENIED_ERROR, connID)
-							}
-						} else {
-							err = mysql.NewDefaultError(mysql.ER_NO_SUCH_THREAD, connID)
-						}
-					} else {
-						err = mysql.NewDefaultError(mysql.ER_KILL_DENIED_ERROR, connID)
-					}
+					c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
+					err = c.handleKill(v.GetConnectionID(), true)
 					return
 				case *sqlparser.KillConnection:
-					connID := v.GetConnectionID()
-					if c.proxy.cfg.AllowKillQuery {
+					c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
+					err = c.handleKill(v.GetConnectionID(), false)
+					return
+				case *sqlparser.SetNames:
+					if err = c.setNames(v.Names, v.Collate); err != nil {
+						return
+					}
+					if moreResult {
+						c.status |= mysql.SERVER_MORE_RESULTS_EXISTS
+					} else {
 						c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
-						if connID == c.connectionID {
-							c.Close()
-						} else if specConn := c.proxy.GetConnection(connID); specConn != nil {
-							if specConn.user == c.user {
-								err = mysql.NewDefaultError(mysql.ER_QUERY_INTERRUPTED)
-								specConn.Close()
-							} else {
-								err = mysql.NewDefaultError(mysql.ER_KILL_DENIED_ERROR, connID)
-							}
-						} else {
-							err = mysql.NewDefaultError(mysql.ER_NO_SUCH_THREAD, connID)
-						}
+					}
+					err = c.pkg.WriteOK(c.capability, c.status, nil)
+				case *sqlparser.SetCharset:
+					// character_set_connection is always charset of backend.
+					if err = c.setNames(v.Charset, ""); err != nil {
+						return
+					}
+					if moreResult {
+						c.status |= mysql.SERVER_MORE_RESULTS_EXISTS
 					} else {
-						err = mysql.NewDefaultError(mysql.ER_KILL_DENIED_ERROR, connID)
+						c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
 					}
-					return
+					err = c.pkg.WriteOK(c.capability, c.status, nil)
 				case *sqlparser.SetVariable:
+					// charset, debug, tag, replica role and sql_mode variables are handled by proxy.
+					if err = c.setDebugVariable(v); err != nil {
+						return
+					}
+					if err = c.setTagVariable(v); err != nil {
+						return
+					}
+					if err = c.setReplicaRoleVariable(v); err != nil {
+						return
+					}
+					if err = c.setCharsetVariables(v); err != nil {
+						return
+					}
+					if err = c.setSQLModeVariable(v, mysqlConn); err != nil {
+						return
+					}
+					if len(v.Exprs) == 0 {
+						if moreResult {
+							c.status |= mysql.SERVER_MORE_RESULTS_EXISTS
+						} else {
+							c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
+						}
+						err = c.pkg.WriteOK(c.capability, c.status, nil)
+						break
+					}
 					sql := sqlparser.String(statement)
 					if result, err = mysqlConn.Query(sql); err != nil {
 						return
 					}
-					for _, varNameVal := range v.Exprs {
-						if string(varNameVal.Name.Name) == "autocommit" {
-							autoCommit := sqlparser.String(varNameVal.Expr)
-							if autoCommit == "0" {
-								c.status &= ^mysql.SERVER_STATUS_AUTOCOMMIT
-								c.nodeInTrans = node
-							} else {
-								c.status |= mysql.SERVER_STATUS_AUTOCOMMIT
-								c.status &= ^mysql.SERVER_STATUS_IN_TRANS
-								c.nodeInTrans = nil
+					// Prefer autocommit tracked by backend session, parse statement as fallback.
+					var autoCommit string
+					var tracked bool
+					if result.SessionState != nil {
+						autoCommit, tracked = result.SessionState.SystemVariables["autocommit"]
+					}
+					if !tracked {
+						for _, varNameVal := range v.Exprs {
+							if string(varNameVal.Name.Name) == "autocommit" {
+								autoCommit = sqlparser.String(varNameVal.Expr)
+								tracked = true
+								break
 							}
-							break
+						}
+					}
+					if tracked {
+						if autoCommit == "0" || strings.EqualFold(autoCommit, "OFF") {
+							c.status &= ^mysql.SERVER_STATUS_AUTOCOMMIT
+							c.nodeInTrans = node
+						} else {
+							c.status |= mysql.SERVER_STATUS_AUTOCOMMIT
+							c.status &= ^mysql.SERVER_STATUS_IN_TRANS
+							c.nodeInTrans = nil
 						}
 					}
 					if moreResult {
@@ -238,8 +360,10 @@ func (c *ClientConn) executePlanWithQueryCommand(statements []sqlparser.Statemen
 					}
 					err = c.pkg.WriteOK(c.capability, c.status, result)
 				default:
-					sql := sqlparser.String(statement)
-					if result, err = mysqlConn.Query(sql); err != nil {
+					countSQL := c.countFoundRows(statement)
+					sql, dropTempTables, e := c.inListSQL(mysqlConn, statement)
+					if e != nil {
+						err = e
 						return
 					}
 					if moreResult {
@@ -247,10 +371,36 @@ func (c *ClientConn) executePlanWithQueryCommand(statements []sqlparser.Statemen
 					} else {
 						c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
 					}
+					// local file of LOAD DATA LOCAL INFILE is relayed from client, refused for other statements.
+					if filename, ok := localInfileName(statement); ok {
+						mysqlConn.SetLocalInfile(c.localInfile(filename))
+					}
+					if key, ok := c.idempotencyKeys[statement]; ok {
+						result, err = c.queryIdempotent(mysqlConn, node, key, sql)
+					} else if c.canStreamRows(statement, countSQL) {
+						// result set is written to client as it's read, large rows aren't assembled in memory.
+						result, err = mysqlConn.QueryStream(sql, c.pkg, c.capability, c.status)
+					} else if c.canPassthroughRows(statement) {
+						// result is not modified, so rows are forwarded without decoding and encoding again.
+						result, err = mysqlConn.QueryRaw(sql)
+					} else {
+						result, err = mysqlConn.Query(sql)
+					}
+					mysqlConn.SetLocalInfile(nil)
+					dropTempTables()
+					if err != nil {
+						return
+					}
+					c.setInsertID(statement, result)
+					c.trackResult(result)
+					if err = c.trackFoundRows(statement, countSQL, mysqlConn); err != nil {
+						return
+					}
+					c.addMirror(statement, result)
 					if result.Resultset == nil {
 						err = c.pkg.WriteOK(c.capability, c.status, result)
-					} else {
-						err = c.pkg.WriteResultSet(c.capability, c.status, result)
+					} else if !result.IsStreamed() {
+						err = c.writeResultSet(c.status, result)
 					}
 				}
 				if err != nil {
@@ -263,17 +413,73 @@ func (c *ClientConn) executePlanWithQueryCommand(statements []sqlparser.Statemen
 		}
 	} else {
 		var result *mysql.Result
-		for _, dataNode := range dataNodes {
-			node := c.proxy.nodes[dataNode]
+		// statement rewritten for each node, such as IN list of shard key grouped by node.
+		perNode := len(statements) == len(dataNodes)
+		// rows of aggregate, GROUP BY, ORDER BY and LIMIT are merged after rows of all nodes are read.
+		var aggregation *route.Aggregation
+		var sorting *route.Sort
+		var runs []int
+		// FOUND_ROWS() of SQL_CALC_FOUND_ROWS is sum of nodes, or count of merged groups with aggregation.
+		var calcFoundRows bool
+		var foundRowsSum int64
+		if selectStmt, ok := statements[0].(*sqlparser.Select); ok && perNode {
+			if aggregation, err = route.NewAggregation(selectStmt); err != nil {
+				return
+			}
+			if sorting, err = route.NewSort(selectStmt, aggregation); err != nil {
+				return
+			}
+			calcFoundRows = selectStmt.CalcFoundRows
+			// distinct rows of nodes are not merged, so the same row in many nodes is counted more than once.
+			if calcFoundRows && aggregation == nil && len(selectStmt.Distinct) > 0 {
+				err = errFoundRowsDistinctInMulti
+				return
+			}
+		}
+		// rows of nodes are written to client node by node as they're read, if nothing is merged,
+		// so that slow client pauses reading from backends instead of rows buffered.
+		stream := perNode && aggregation == nil && sorting == nil && len(c.mirrors) == 0 && c.canStreamRows(statements[0], "")
+		if _, ok := statements[0].(*sqlparser.Select); !ok {
+			stream = false
+		}
+		if stream {
+			c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
+		}
+		// write to all nodes in autocommit, such as global table, is in an implicit transaction across nodes
+		// if trans_mode of xa is not off, so that it's written to all nodes or none.
+		var implicitTrans bool
+		switch statements[0].(type) {
+		case *sqlparser.Insert, *sqlparser.Update, *sqlparser.Delete, *sqlparser.Replace:
+			implicitTrans = !perNode && c.beginDistTrans()
+		}
+		if implicitTrans {
+			defer func() {
+				if c.trans != nil {
+					c.rollbackBranches(c.trans.branches)
+					c.endDistTrans()
+				}
+			}()
+		}
+		for i, dataNode := range dataNodes {
+			node := c.topology.nodes[dataNode]
 			statement := statements[0]
-			// If in transaction, must exec in the same node.
-			if c.isInTransaction() && node != c.nodeInTrans {
-				return nil, errors.ErrTransInMulti
+			if perNode {
+				statement = statements[i]
+			}
+			if aggregation != nil {
+				statement = aggregation.Rewrite(statement.(*sqlparser.Select))
+			}
+			if sorting != nil {
+				statement = sorting.Rewrite(statement.(*sqlparser.Select))
+			}
+			// If in transaction, must exec in the same node, except transaction across nodes.
+			if err = c.checkNodeInTrans(node); err != nil {
+				return
 			}
 
 			var conn backend.Connection
 			// Get backend conn from slave or master.
-			if isSlave && len(node.DataHost.Slaves) > 0 {
+			if isSlave && c.hasSlave(node) {
 				if conn, err = c.getOrCreateSlaveConn(node); err != nil {
 					return
 				}
@@ -283,20 +489,89 @@ func (c *ClientConn) executePlanWithQueryCommand(statements []sqlparser.Statemen
 				}
 			}
 
+			if err = c.enlistInTrans(node, conn); err != nil {
+				return
+			}
+
 			backendConnAddrs = append(backendConnAddrs, conn.GetAddr())
 			var mysqlConn = conn.(*mysqlBackend.Conn)
 			mysqlConn.UseDB(node.Database)
 
 			switch statement.(type) {
 			case sqlparser.SelectStatement:
-				err = errors.ErrCmdUnsupport
-				return
+				if !perNode {
+					err = errors.ErrCmdUnsupport
+					return
+				}
+				var countSQL string
+				if calcFoundRows && aggregation == nil {
+					countSQL = c.countFoundRows(statement)
+				}
+				sql, dropTempTables, e := c.inListSQL(mysqlConn, statement)
+				if e != nil {
+					err = e
+					return
+				}
+				if stream {
+					result, err = mysqlConn.QueryStreamPart(sql, c.pkg, c.capability, c.status, result, i == len(dataNodes)-1)
+					dropTempTables()
+					if err != nil {
+						return
+					}
+					break
+				}
+				var rs *mysql.Result
+				rs, err = mysqlConn.Query(sql)
+				dropTempTables()
+				if err != nil {
+					return
+				}
+				if rs.Resultset != nil {
+					runs = append(runs, rs.RowNumber())
+				}
+				if calcFoundRows && aggregation == nil {
+					var foundRows int64
+					if foundRows, _, err = queryFoundRows(mysqlConn, statement, countSQL); err != nil {
+						return
+					}
+					foundRowsSum += foundRows
+				}
+				result = mergeResult(result, rs)
+				c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
 			case sqlparser.DDLStatement:
 				sql := sqlparser.String(statement)
 				if result, err = mysqlConn.Query(sql); err != nil {
 					return
 				}
 				c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
+			case *sqlparser.Insert, *sqlparser.Update, *sqlparser.Delete, *sqlparser.Replace:
+				if _, ok := c.idempotencyKeys[statement]; ok {
+					err = errIdempotencyMultiNodes
+					return
+				}
+				// Write to all nodes, such as global table.
+				sql, dropTempTables, e := c.inListSQL(mysqlConn, statement)
+				if e != nil {
+					err = e
+					return
+				}
+				var rs *mysql.Result
+				rs, err = mysqlConn.Query(sql)
+				dropTempTables()
+				if err != nil {
+					return
+				}
+				if perNode {
+					result = mergeResult(result, rs)
+				} else {
+					if result != nil && result.AffectedRows != rs.AffectedRows {
+						simplelog.Warn("%s %s connectionID=%d,node=%s,sql=%s: affected rows %d differ from %d of %s, data of nodes may differ",
+							"ClientConn", "executePlanWithQueryCommand", c.connectionID, dataNode, sql,
+							rs.AffectedRows, result.AffectedRows, dataNodes[i-1])
+					}
+					result = rs
+				}
+				c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
 			default:
 				err = errors.ErrCmdUnsupport
 				return
@@ -306,10 +581,40 @@ func (c *ClientConn) executePlanWithQueryCommand(statements []sqlparser.Statemen
 			err = errors.ErrCmdUnsupport
 			return
 		}
+		if implicitTrans {
+			err = c.commitDistTrans()
+			c.endDistTrans()
+			if err != nil {
+				return
+			}
+		}
+		// FOUND_ROWS() of SQL_CALC_FOUND_ROWS with aggregation is merged rows before LIMIT, as LIMIT is removed in each node.
+		foundRows := -1
+		if calcFoundRows && aggregation == nil {
+			foundRows = int(foundRowsSum)
+		}
+		if aggregation != nil && result.Resultset != nil {
+			if err = aggregation.Merge(result.Resultset); err != nil {
+				return
+			}
+			if calcFoundRows {
+				foundRows = result.RowNumber()
+			}
+		}
+		if sorting != nil && result.Resultset != nil {
+			if err = sorting.Merge(result.Resultset, runs); err != nil {
+				return
+			}
+		}
+		c.setInsertID(statements[0], result)
+		c.trackResult(result)
+		if foundRows >= 0 {
+			c.foundRows = int64(foundRows)
+		}
 		if result.Resultset == nil {
 			err = c.pkg.WriteOK(c.capability, c.status, result)
-		} else {
-			err = c.pkg.WriteResultSet(c.capability, c.status, result)
+		} else if !result.IsStreamed() {
+			err = c.writeResultSet(c.status, result)
 		}
 	}
 	return
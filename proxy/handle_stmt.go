@@ -24,62 +24,83 @@ package proxy
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/berkaroad/saashard/backend"
 	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
 	"github.com/berkaroad/saashard/errors"
 	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/route"
 	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/utils/simplelog"
+	"github.com/berkaroad/saashard/utils/slowlog"
 )
 
 func (c *ClientConn) handleStmtPrepare(sql string) error {
 	var err error
 	s := mysql.NewStmt(c.pkg, c.capability, &c.status)
-	sql = strings.TrimRight(sql, ";")
+	sql = strings.TrimRight(c.convertQuery(sql), ";")
 
 	var statement sqlparser.Statement
 	statement, err = sqlparser.Parse(sql)
+	if _, ok := err.(*sqlparser.LimitError); ok {
+		return mysql.NewError(mysql.ER_UNKNOWN_ERROR, err.Error())
+	}
 	if err != nil {
-		return mysql.NewError(mysql.ER_SYNTAX_ERROR, fmt.Sprintf("Syntax error or not supported for '%s': '%s'", sql, err.Error()))
+		// Unsharded schema, prepared in backend as it is, such as 'call' with OUT parameters.
+		if schemaConfig := c.schemas[c.db]; schemaConfig == nil || schemaConfig.ShardEnabled() {
+			return mysql.NewError(mysql.ER_SYNTAX_ERROR, fmt.Sprintf("Syntax error or not supported for '%s': '%s'", sql, err.Error()))
+		}
+		statement = &sqlparser.Passthrough{SQL: []byte(sql)}
+	}
+	schemaConfig := c.schemas[c.db]
+	if schemaConfig == nil {
+		return mysql.NewDefaultError(mysql.ER_NO_DB_ERROR)
+	}
+	// Version column is rewritten in the prepared sql, as plan of execute only routes.
+	if err = route.ApplyVersionColumn(schemaConfig, statement); err != nil {
+		return err
 	}
 
 	s.Query = sql
 	s.Statement = statement
 
-	node := c.proxy.nodes[c.schemas[c.db].Nodes[0]]
-	// If in transaction, must exec in the same node.
-	if c.isInTransaction() && node != c.nodeInTrans {
-		return errors.ErrTransInMulti
-	}
-
-	var conn backend.Connection
-	// Get backend conn from master.
-	conn, err = c.getOrCreateMasterConn(node)
-	if err != nil {
-		return err
-	}
+	// Same sql as prepared in backend, so that stmt handle in cache can be reused.
+	query := sqlparser.String(statement)
+	meta := c.proxy.stmtMetas.get(c.db, query)
+	if meta == nil {
+		// Prepare in only one shard to get metadata, other shards are prepared lazily when execute.
+		node := c.nodeInTrans
+		if node == nil {
+			node = c.topology.nodes[c.topology.nodeGroups.Node(c.db, schemaConfig.Nodes[0], false)]
+		}
 
-	var mysqlConn = conn.(*mysqlBackend.Conn)
-	mysqlConn.UseDB(node.Database)
+		var conn backend.Connection
+		// Get backend conn from master.
+		conn, err = c.getOrCreateMasterConn(node)
+		if err != nil {
+			return err
+		}
 
-	var stmtFromBackend *mysql.Stmt
-	stmtFromBackend, err = mysqlConn.Prepare(sqlparser.String(statement))
-	if err != nil {
-		return err
-	}
-	if stmtFromBackend == nil {
-		return errors.New("prepare error no stmt from backend")
-	}
-	s.ParamNum = stmtFromBackend.ParamNum
-	s.Params = stmtFromBackend.Params
+		var mysqlConn = conn.(*mysqlBackend.Conn)
+		mysqlConn.UseDB(node.Database)
 
-	s.ColumnNum = stmtFromBackend.ColumnNum
-	s.Columns = stmtFromBackend.Columns
-	for i := 0; i < len(s.Columns); i++ {
-		s.Columns[i].Schema = []byte(c.db)
+		var stmtFromBackend *mysql.Stmt
+		// Stmt handle is kept in backend conn's cache, will be reused when execute.
+		stmtFromBackend, err = mysqlConn.PrepareCached(query)
+		if err != nil {
+			return err
+		}
+		if stmtFromBackend == nil {
+			return errors.New("prepare error no stmt from backend")
+		}
+		meta = newStmtMeta(stmtFromBackend)
+		c.proxy.stmtMetas.put(c.db, query, meta)
 	}
+	meta.apply(s, c.db)
 	atomic.AddUint32(&c.stmtID, 1)
 	s.ID = c.stmtID
 
@@ -89,65 +110,142 @@ func (c *ClientConn) handleStmtPrepare(sql string) error {
 
 	s.ResetParams()
 	c.stmts[s.ID] = s
-
-	err = stmtFromBackend.Close()
-	if err != nil {
-		return err
-	}
-	return err
+	return nil
 }
 
 func (c *ClientConn) handleStmtExecute(data []byte) error {
 	var err error
 	var s *mysql.Stmt
 	s, err = c.pkg.ReadStmtExecuteRequest(data, func(id uint32) *mysql.Stmt { return c.stmts[id] })
+	if err != nil {
+		return err
+	}
 
+	// Same sql as prepared in backend, so that stmt handle in cache can be reused.
+	query := sqlparser.String(s.Statement)
+	record := c.statementRecorder(c.db, sqlparser.Fingerprint(s.Statement), len(data))
+	startTime := time.Now()
+	c.rowsSent, c.rowsAffected, c.stmtShards, c.cacheQuery = 0, 0, nil, nil
 	switch stmt := s.Statement.(type) {
 	case *sqlparser.Select:
-		err = c.handlePrepareSelect(stmt, s.Query, s.Args)
+		err = c.handlePrepareSelect(stmt, query, s.Args)
 	case *sqlparser.Insert:
-		err = c.handlePrepareExec(s.Statement, s.Query, s.Args)
+		err = c.handlePrepareExec(s.Statement, query, s.Args)
 	case *sqlparser.Update:
-		err = c.handlePrepareExec(s.Statement, s.Query, s.Args)
+		err = c.handlePrepareExec(s.Statement, query, s.Args)
 	case *sqlparser.Delete:
-		err = c.handlePrepareExec(s.Statement, s.Query, s.Args)
+		err = c.handlePrepareExec(s.Statement, query, s.Args)
 	case *sqlparser.Replace:
-		err = c.handlePrepareExec(s.Statement, s.Query, s.Args)
+		err = c.handlePrepareExec(s.Statement, query, s.Args)
+	case *sqlparser.Passthrough:
+		err = c.handlePrepareExec(s.Statement, query, s.Args)
 	case *sqlparser.Commit:
-		err = c.handlePrepareExec(s.Statement, s.Query, s.Args)
+		var handled bool
+		if handled, err = c.handleDistTrans([]sqlparser.Statement{stmt}); !handled {
+			err = c.handlePrepareExec(s.Statement, query, s.Args)
+		}
 	default:
 		err = fmt.Errorf("command %T not supported now", stmt)
 	}
+	c.logSlowQuery(query, startTime, false, err, "", c.stmtShards)
+	c.auditStmtExecute(s, startTime, err)
+	c.storeResultCache(err)
+	c.invalidateResultCache(s.Statement)
 	s.ResetParams()
+	record(err)
 	return err
 }
 
-func (c *ClientConn) handlePrepareSelect(stmt *sqlparser.Select, sql string, args []interface{}) error {
-	var err error
-	node := c.proxy.nodes[c.schemas[c.db].Nodes[0]]
-	// If in transaction, must exec in the same node.
-	if c.isInTransaction() && node != c.nodeInTrans {
-		return errors.ErrTransInMulti
+// routeStmt get data nodes of prepared stmt, args are bound as the value of shard key may be an arg.
+func (c *ClientConn) routeStmt(stmt sqlparser.Statement, args []interface{}) ([]string, error) {
+	if _, ok := stmt.(sqlparser.TransactionStatement); ok {
+		if c.nodeInTrans != nil {
+			return []string{c.nodeInTrans.Name}, nil
+		}
+		return []string{c.topology.nodeGroups.Node(c.db, c.schemas[c.db].Nodes[0], false)}, nil
 	}
 
-	var conn backend.Connection
-	// Get backend conn from master.
-	conn, err = c.getOrCreateMasterConn(node)
-	// // Get backend conn from slave or master.
-	// if !c.isInTransaction() && len(node.DataHost.Slaves) > 0 {
-	// 	conn, err = c.getOrCreateSlaveConn(node)
-	// } else {
-	// 	conn, err = c.getOrCreateMasterConn(node)
-	// }
+	// raw sql is routed to the only node of unsharded schema, args are not bound to it.
+	boundStmt := stmt
+	if _, ok := stmt.(*sqlparser.Passthrough); !ok {
+		var err error
+		if boundStmt, err = sqlparser.BindArgs(stmt, args); err != nil {
+			return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, err.Error())
+		}
+	}
+	router := route.NewRouter(c.db, c.schemas, c.topology.cfg.GetNodes(), c.connectionID, c.user, c.isInTransaction())
+	router.ShardMaps = c.topology.shardMaps
+	router.NodeGroups = c.topology.nodeGroups
+	router.ReadOnly = c.proxy.readOnly
+	router.PlanPins = c.proxy.planPins
+	router.Directory = c.proxy.directory
+	router.GlobalIndex = c.proxy.globalIndex
+	router.Debug = c.debug
+	router.Sticky = c.sticky
+	if c.nodeInTrans != nil {
+		router.NodeInTrans = c.nodeInTrans.Name
+	}
+	plan, err := router.BuildNormalPlan(boundStmt)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// prepared statement is sent as it is, couldn't be rewritten for each node.
+	if plan.IsScatter() {
+		return nil, errors.ErrExecInMulti
+	}
+	return plan.GetNodeNames(), nil
+}
+
+// executeStmt execute prepared stmt in data nodes, stmt is prepared lazily in backend conn of the node.
+func (c *ClientConn) executeStmt(dataNodes []string, sql string, args []interface{}) (rs *mysql.Result, err error) {
+	for _, dataNode := range dataNodes {
+		node := c.topology.nodes[dataNode]
+		// If in transaction, must exec in the same node, except transaction across nodes.
+		if err = c.checkNodeInTrans(node); err != nil {
+			return nil, err
+		}
+
+		var conn backend.Connection
+		// Get backend conn from master.
+		conn, err = c.getOrCreateMasterConn(node)
+		// // Get backend conn from slave or master.
+		// if !c.isInTransaction() && len(node.DataHost.Slaves) > 0 {
+		// 	conn, err = c.getOrCreateSlaveConn(node)
+		// } else {
+		// 	conn, err = c.getOrCreateMasterConn(node)
+		// }
+		if err != nil {
+			return nil, err
+		}
+		if err = c.enlistInTrans(node, conn); err != nil {
+			return nil, err
+		}
+
+		var mysqlConn = conn.(*mysqlBackend.Conn)
+		mysqlConn.UseDB(node.Database)
+
+		c.stmtShards = append(c.stmtShards, slowlog.Shard{Node: dataNode, Backend: conn.GetAddr(), SQL: sql})
+		if rs, err = mysqlConn.ExecuteStmt(sql, args); err != nil {
+			return nil, err
+		}
 	}
+	return rs, nil
+}
 
-	var mysqlConn = conn.(*mysqlBackend.Conn)
-	mysqlConn.UseDB(node.Database)
+func (c *ClientConn) handlePrepareSelect(stmt *sqlparser.Select, sql string, args []interface{}) error {
+	dataNodes, err := c.routeStmt(stmt, args)
+	if err != nil {
+		return err
+	}
+	if len(dataNodes) != 1 {
+		return errors.ErrCmdUnsupport
+	}
+	if handled, err := c.serveResultCache(stmt, dataNodes, stmtArgsKey(args)); handled {
+		return err
+	}
 
 	var rs *mysql.Result
-	rs, err = mysqlConn.Execute(sql, args)
+	rs, err = c.executeStmt(dataNodes, sql, args)
 	if err != nil {
 		return err
 	}
@@ -156,43 +254,43 @@ func (c *ClientConn) handlePrepareSelect(stmt *sqlparser.Select, sql string, arg
 	if rs.Resultset == nil {
 		rs.Resultset = c.newEmptyResultset(stmt)
 	}
-	err = c.pkg.WriteResultSet(c.capability, status, rs)
+	c.trackResult(rs)
+	err = c.writeResultSet(status, rs)
 	return err
 }
 
 func (c *ClientConn) handlePrepareExec(stmt sqlparser.Statement, sql string, args []interface{}) error {
-	var err error
-	node := c.proxy.nodes[c.schemas[c.db].Nodes[0]]
-	// If in transaction, must exec in the same node.
-	if c.isInTransaction() && node != c.nodeInTrans {
-		return errors.ErrTransInMulti
-	}
-
-	var conn backend.Connection
-	// Get backend conn from master.
-	conn, err = c.getOrCreateMasterConn(node)
-	// // Get backend conn from slave or master.
-	// if !c.isInTransaction() && len(node.DataHost.Slaves) > 0 {
-	// 	conn, err = c.getOrCreateSlaveConn(node)
-	// } else {
-	// 	conn, err = c.getOrCreateMasterConn(node)
-	// }
+	// Write to all nodes routed, such as global table.
+	dataNodes, err := c.routeStmt(stmt, args)
 	if err != nil {
 		return err
 	}
 
-	var mysqlConn = conn.(*mysqlBackend.Conn)
-	mysqlConn.UseDB(node.Database)
-
+	// in an implicit transaction across nodes in autocommit, if trans_mode of xa is not off.
+	implicitTrans := len(dataNodes) > 1 && c.beginDistTrans()
 	var rs *mysql.Result
-	rs, err = mysqlConn.Execute(sql, args)
+	rs, err = c.executeStmt(dataNodes, sql, args)
+	if implicitTrans {
+		if err == nil {
+			err = c.commitDistTrans()
+		} else {
+			c.rollbackBranches(c.trans.branches)
+		}
+		c.endDistTrans()
+	}
 	if err != nil {
 		return err
 	}
+	if rs == nil {
+		return errors.ErrCmdUnsupport
+	}
+	c.trackResult(rs)
 
 	status := c.status | rs.Status
-	if rs.Resultset != nil {
-		err = c.pkg.WriteResultSet(c.capability, status, rs)
+	if rs.OutParams != nil && c.capability&mysql.CLIENT_PS_MULTI_RESULTS > 0 {
+		err = c.writeCallResults(status, rs)
+	} else if rs.Resultset != nil {
+		err = c.writeResultSet(status, rs)
 	} else {
 		err = c.pkg.WriteOK(c.capability, status, rs)
 	}
@@ -200,19 +298,56 @@ func (c *ClientConn) handlePrepareExec(stmt sqlparser.Statement, sql string, arg
 	return err
 }
 
+// writeCallResults write results of 'call' by stmt as more results: result set of procedure if any,
+// OUT parameters, then OK.
+func (c *ClientConn) writeCallResults(status uint16, rs *mysql.Result) error {
+	more := status | mysql.SERVER_MORE_RESULTS_EXISTS
+	if rs.Resultset != nil {
+		if err := c.writeResultSet(more, rs); err != nil {
+			return err
+		}
+	}
+	if err := c.writeResultSet(more|mysql.SERVER_PS_OUT_PARAMS, &mysql.Result{Resultset: rs.OutParams}); err != nil {
+		return err
+	}
+	return c.pkg.WriteOK(c.capability, status, &mysql.Result{Status: status, AffectedRows: rs.AffectedRows,
+		InsertID: rs.InsertID, Warnings: rs.Warnings})
+}
+
+// handleStmtClose remove stmt of client, there's no response.
+// Stmt handles in backend conns are kept in cache, to be reused by the same sql.
 func (c *ClientConn) handleStmtClose(data []byte) error {
 	mysql.PrintPacketData("handleStmtClose", data)
+	id, err := c.pkg.ReadStmtID(data)
+	if err != nil {
+		simplelog.Warn("%s %s connectionID=%d: %s", "ClientConn", "handleStmtClose", c.connectionID, err.Error())
+		return nil
+	}
+	delete(c.stmts, id)
 	return nil
 }
 
+// handleStmtSendLongData keep param value in stmt until execute, there's no response.
 func (c *ClientConn) handleStmtSendLongData(data []byte) error {
-	mysql.PrintPacketData("handleStmtSendLongData", data)
+	if err := c.pkg.ReadStmtSendLongDataRequest(data, func(id uint32) *mysql.Stmt { return c.stmts[id] }); err != nil {
+		simplelog.Warn("%s %s connectionID=%d: %s", "ClientConn", "handleStmtSendLongData", c.connectionID, err.Error())
+	}
 	return nil
 }
 
+// handleStmtReset discard long data sent of stmt.
 func (c *ClientConn) handleStmtReset(data []byte) error {
 	mysql.PrintPacketData("handleStmtReset", data)
-	return nil
+	id, err := c.pkg.ReadStmtID(data)
+	if err != nil {
+		return err
+	}
+	s := c.stmts[id]
+	if s == nil {
+		return mysql.NewDefaultError(mysql.ER_UNKNOWN_STMT_HANDLER, strconv.FormatUint(uint64(id), 10), "stmt_reset")
+	}
+	s.ResetParams()
+	return c.pkg.WriteOK(c.capability, c.status, nil)
 }
 
 func (c *ClientConn) newEmptyResultset(stmt *sqlparser.Select) *mysql.Resultset {
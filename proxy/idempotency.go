@@ -0,0 +1,196 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/berkaroad/saashard/backend"
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// IdempotencyTable is table of idempotency keys in database of each data node.
+var IdempotencyTable = "saashard_idempotency"
+
+// DefaultIdempotencyRetention is default hours to keep idempotency keys.
+const DefaultIdempotencyRetention = 24
+
+const sqlCreateIdempotencyTable = "CREATE TABLE IF NOT EXISTS `%s` (" +
+	"`idem_key` VARCHAR(128) NOT NULL, `affected_rows` BIGINT UNSIGNED NOT NULL DEFAULT 0, " +
+	"`insert_id` BIGINT UNSIGNED NOT NULL DEFAULT 0, `created_at` BIGINT NOT NULL, " +
+	"PRIMARY KEY (`idem_key`), KEY `idx_created_at` (`created_at`)) ENGINE=InnoDB"
+
+// idempotencySavepoint is savepoint of idempotent write in transaction of client.
+const idempotencySavepoint = "saashard_idempotency"
+
+var errIdempotencyMultiNodes = mysql.NewError(mysql.ER_NOT_SUPPORTED_YET, "idempotency key of write in multiple data nodes")
+
+// nodeTables is data nodes that a table is created in, such as idempotency table.
+type nodeTables struct {
+	sync.Mutex
+	sql   string // DDL to create the table if not exists.
+	nodes map[*backend.DataNode]bool
+}
+
+func newNodeTables(sql string) *nodeTables {
+	return &nodeTables{sql: sql, nodes: make(map[*backend.DataNode]bool)}
+}
+
+// ensure create table in database of data node, only once.
+// It's created by another connection, as DDL commits transaction implicitly.
+func (t *nodeTables) ensure(node *backend.DataNode) error {
+	t.Lock()
+	created := t.nodes[node]
+	t.Unlock()
+	if created {
+		return nil
+	}
+	conn, err := node.DataHost.GetMaster().GetConnection(node.Database)
+	if err != nil {
+		return err
+	}
+	mysqlConn := conn.(*mysqlBackend.Conn)
+	if err = mysqlConn.UseDB(node.Database); err == nil {
+		_, err = mysqlConn.Query(t.sql)
+	}
+	conn.ReturnConnection()
+	if err != nil {
+		return err
+	}
+	t.Lock()
+	t.nodes[node] = true
+	t.Unlock()
+	return nil
+}
+
+// queryIdempotent execute write with idempotency key, in the same transaction with the key recorded in data node.
+// If the key is recorded, the write is retried and not applied again, result of the first write is returned.
+func (c *ClientConn) queryIdempotent(conn *mysqlBackend.Conn, node *backend.DataNode, key string, sql string) (*mysql.Result, error) {
+	if len(key) > 128 {
+		return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "idempotency key is longer than 128")
+	}
+	if err := c.proxy.idempotencyTables.ensure(node); err != nil {
+		return nil, err
+	}
+
+	// in transaction of client, key is committed or rolled back with it.
+	inTrans := c.isInTransaction()
+	if !inTrans {
+		if err := conn.Begin(); err != nil {
+			return nil, err
+		}
+	}
+	if inTrans {
+		// key and write are rolled back to savepoint on error, not leaving a half in transaction of client.
+		if _, err := conn.Query("SAVEPOINT " + idempotencySavepoint); err != nil {
+			return nil, err
+		}
+	}
+	result, applied, err := c.queryIdempotentInTrans(conn, key, sql)
+	if inTrans {
+		if err != nil {
+			if _, rollbackErr := conn.Query("ROLLBACK TO SAVEPOINT " + idempotencySavepoint); rollbackErr != nil {
+				simplelog.Error("%s %s %s key=%s,node=%s", "proxy", "queryIdempotent", rollbackErr.Error(), key, node.Name)
+			}
+		} else {
+			_, err = conn.Query("RELEASE SAVEPOINT " + idempotencySavepoint)
+		}
+	} else if err != nil || applied {
+		conn.Rollback()
+	} else {
+		err = conn.Commit()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if applied {
+		simplelog.Info("%s %s %s key=%s,node=%s", "proxy", "queryIdempotent", "Retried write is not applied again", key, node.Name)
+	}
+	return result, nil
+}
+
+func (c *ClientConn) queryIdempotentInTrans(conn *mysqlBackend.Conn, key string, sql string) (result *mysql.Result, applied bool, err error) {
+	quotedKey := mysqlBackend.QuoteString(key)
+	// concurrent retry waits for lock of the key, until the first write is committed or rolled back.
+	_, err = conn.Query(fmt.Sprintf("INSERT INTO `%s` (`idem_key`, `created_at`) VALUES (%s, %d)",
+		IdempotencyTable, quotedKey, time.Now().Unix()))
+	if sqlErr, ok := err.(*errors.SqlError); ok && sqlErr.Code == mysql.ER_DUP_ENTRY {
+		var recorded *mysql.Result
+		recorded, err = conn.Query(fmt.Sprintf("SELECT `affected_rows`, `insert_id` FROM `%s` WHERE `idem_key` = %s",
+			IdempotencyTable, quotedKey))
+		if err != nil {
+			return
+		}
+		result = new(mysql.Result)
+		if recorded.RowNumber() > 0 {
+			result.AffectedRows, _ = recorded.GetUint(0, 0)
+			result.InsertID, _ = recorded.GetUint(0, 1)
+		}
+		applied = true
+		return
+	} else if err != nil {
+		return
+	}
+
+	if result, err = conn.Query(sql); err != nil {
+		return
+	}
+	_, err = conn.Query(fmt.Sprintf("UPDATE `%s` SET `affected_rows` = %d, `insert_id` = %d WHERE `idem_key` = %s",
+		IdempotencyTable, result.AffectedRows, result.InsertID, quotedKey))
+	return
+}
+
+// purgeIdempotencyKeys delete idempotency keys older than retention in data nodes periodically.
+// All data nodes are visited, as table may be created before proxy restarted; node without the table is skipped.
+func (p *Server) purgeIdempotencyKeys() {
+	retention := p.getConfig().IdempotencyRetention
+	if retention <= 0 {
+		retention = DefaultIdempotencyRetention
+	}
+	for p.running {
+		time.Sleep(10 * time.Minute)
+		expired := time.Now().Add(-time.Duration(retention) * time.Hour).Unix()
+		for _, node := range p.GetDataNodes() {
+			conn, err := node.DataHost.GetMaster().GetConnection(node.Database)
+			if err != nil {
+				simplelog.Error("%s %s %s node=%s", "proxy", "purgeIdempotencyKeys", err.Error(), node.Name)
+				continue
+			}
+			mysqlConn := conn.(*mysqlBackend.Conn)
+			if err = mysqlConn.UseDB(node.Database); err == nil {
+				_, err = mysqlConn.Query(fmt.Sprintf("DELETE FROM `%s` WHERE `created_at` < %d", IdempotencyTable, expired))
+			}
+			conn.ReturnConnection()
+			if sqlErr, ok := err.(*errors.SqlError); ok && sqlErr.Code == mysql.ER_NO_SUCH_TABLE {
+				continue
+			} else if err != nil {
+				simplelog.Error("%s %s %s node=%s", "proxy", "purgeIdempotencyKeys", err.Error(), node.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+const (
+	inListTempTablePrefix = "saashard_in_list_"
+	inListTempTableColumn = "v"
+	// inListTempTableInsertSize is max bytes of insert statement filling values, less than max_allowed_packet.
+	inListTempTableInsertSize = 1 << 20
+	// inListTempTableMaxStrLen is max bytes of string values, longer ones are not indexed, so not rewritten.
+	inListTempTableMaxStrLen = 255
+)
+
+// inListSQL get sql of statement, with IN lists of more values than in_list_temp_table_threshold rewritten
+// to subquery of temporary tables, which are created and filled with values in backend conn.
+// It avoids max_allowed_packet issues and parser blowups of backend for huge IN lists, such as of analytics tools.
+// drop must be called after statement is executed, to drop the tables.
+func (c *ClientConn) inListSQL(conn *mysqlBackend.Conn, statement sqlparser.Statement) (sql string, drop func(), err error) {
+	drop = func() {}
+	var threshold int
+	if schemaConfig := c.schemas[c.db]; schemaConfig != nil {
+		threshold = schemaConfig.InListTempTableThreshold
+	}
+	var inLists []*sqlparser.ComparisonExpr
+	if threshold > 0 {
+		inLists = sqlparser.FindLargeInLists(statement, threshold)
+	}
+	var columnTypes []string
+	for _, inList := range inLists {
+		columnType := inListColumnType(inList.Right.(sqlparser.ValTuple))
+		if len(columnType) == 0 {
+			inLists = nil
+			break
+		}
+		columnTypes = append(columnTypes, columnType)
+	}
+	if len(inLists) == 0 {
+		return sqlparser.String(statement), drop, nil
+	}
+
+	tables := make([]string, 0, len(inLists))
+	drop = func() {
+		for _, table := range tables {
+			if _, err := conn.Query("drop temporary table if exists " + table); err != nil {
+				simplelog.Warn("%s %s %s connectionID=%d,table=%s", "ClientConn", "inListSQL", err.Error(), c.connectionID, table)
+			}
+		}
+	}
+	for i, inList := range inLists {
+		table := inListTempTablePrefix + strconv.Itoa(i+1)
+		if _, err = conn.Query(fmt.Sprintf("create temporary table %s (%s %s not null, key (%s))",
+			table, inListTempTableColumn, columnTypes[i], inListTempTableColumn)); err != nil {
+			drop()
+			return "", func() {}, err
+		}
+		tables = append(tables, table)
+		if err = fillInListTempTable(conn, table, inList.Right.(sqlparser.ValTuple)); err != nil {
+			drop()
+			return "", func() {}, err
+		}
+	}
+	sql = sqlparser.FormatInListsAsSubqueries(statement, inLists, tables, inListTempTableColumn)
+	if c.debug {
+		simplelog.Debug("%s %s connectionID=%d: %d IN lists of more than %d values rewritten to temporary tables in %s",
+			"ClientConn", "inListSQL", c.connectionID, len(inLists), threshold, conn.GetAddr())
+	}
+	return sql, drop, nil
+}
+
+// inListColumnType get column type of values in temporary table, empty if couldn't be rewritten.
+func inListColumnType(values sqlparser.ValTuple) string {
+	if _, ok := values[0].(sqlparser.StrVal); ok {
+		maxLen := 1
+		for _, value := range values {
+			if n := len(value.(sqlparser.StrVal)); n > maxLen {
+				maxLen = n
+			}
+		}
+		if maxLen > inListTempTableMaxStrLen {
+			return ""
+		}
+		return fmt.Sprintf("varchar(%d)", maxLen)
+	}
+	for _, value := range values {
+		if _, err := strconv.ParseInt(string(value.(sqlparser.NumVal)), 10, 64); err != nil {
+			return "decimal(65,30)"
+		}
+	}
+	return "bigint"
+}
+
+// fillInListTempTable insert distinct values to temporary table, in batches of less than max_allowed_packet.
+func fillInListTempTable(conn *mysqlBackend.Conn, table string, values sqlparser.ValTuple) error {
+	prefix := fmt.Sprintf("insert into %s (%s) values ", table, inListTempTableColumn)
+	var buf bytes.Buffer
+	seen := make(map[string]bool, len(values))
+	for i, value := range values {
+		v := sqlparser.String(value)
+		if !seen[v] {
+			seen[v] = true
+			if buf.Len() == 0 {
+				buf.WriteString(prefix)
+			} else {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('(')
+			buf.WriteString(v)
+			buf.WriteByte(')')
+		}
+		if buf.Len() > 0 && (buf.Len() >= inListTempTableInsertSize || i == len(values)-1) {
+			if _, err := conn.Query(buf.String()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+	}
+	return nil
+}
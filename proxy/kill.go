@@ -0,0 +1,119 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/berkaroad/saashard/backend"
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// handleKill is 'KILL [CONNECTION|QUERY] id' from client, only sessions of the same user could be killed, as MySQL does.
+// KILL QUERY interrupts statement executing in backend, and keeps the session.
+func (c *ClientConn) handleKill(connID uint32, query bool) error {
+	if !c.proxy.getConfig().AllowKillQuery {
+		return mysql.NewDefaultError(mysql.ER_KILL_DENIED_ERROR, connID)
+	}
+	if connID == c.connectionID {
+		// the statement itself is interrupted.
+		if query {
+			return mysql.NewDefaultError(mysql.ER_QUERY_INTERRUPTED)
+		}
+		c.pkg.Flush()
+		c.Close()
+		return nil
+	}
+	target := c.proxy.GetConnection(connID)
+	if target == nil {
+		return mysql.NewDefaultError(mysql.ER_NO_SUCH_THREAD, connID)
+	}
+	if target.user != c.user {
+		return mysql.NewDefaultError(mysql.ER_KILL_DENIED_ERROR, connID)
+	}
+	target.kill(query)
+	return c.pkg.WriteOK(c.capability, c.status, nil)
+}
+
+// KillConnection kill client session from admin, or only query executing in it, false if not exists.
+func (p *Server) KillConnection(connID uint32, query bool) bool {
+	target := p.GetConnection(connID)
+	if target == nil {
+		return false
+	}
+	target.kill(query)
+	return true
+}
+
+// kill query executing in session, and close the session unless only query is killed.
+// Session is closed here only if idle, or by its own goroutine when reading next command after read is shut down,
+// as backend conns in use must not be returned by another goroutine, same as drain.
+func (c *ClientConn) kill(query bool) {
+	if !query && atomic.CompareAndSwapInt32(&c.state, sessionIdle, sessionClosing) {
+		c.Close()
+		return
+	}
+	c.killBackendQueries()
+	if !query {
+		atomic.StoreInt32(&c.state, sessionClosing)
+		c.shutdownRead()
+	}
+}
+
+// killBackendQueries kill queries executing in backend conns of session, by another conn to the same db host.
+func (c *ClientConn) killBackendQueries() {
+	c.Lock()
+	conns := make(map[backend.Connection]bool, len(c.backendMasterConns)+len(c.backendSlaveConns))
+	for _, conn := range c.backendMasterConns {
+		conns[conn] = true
+	}
+	for _, conn := range c.backendSlaveConns {
+		conns[conn] = true
+	}
+	c.Unlock()
+
+	for conn := range conns {
+		mysqlConn, ok := conn.(*mysqlBackend.Conn)
+		if !ok || mysqlConn.IsClosed() || mysqlConn.GetThreadID() == 0 {
+			continue
+		}
+		if err := killBackendQuery(mysqlConn); err != nil {
+			simplelog.Warn("%s %s connectionID=%d,addr=%s,threadID=%d: %s", "ClientConn", "killBackendQueries",
+				c.connectionID, mysqlConn.GetAddr(), mysqlConn.GetThreadID(), err.Error())
+		}
+	}
+}
+
+func killBackendQuery(target *mysqlBackend.Conn) error {
+	dbHost := target.GetDBHost()
+	conn, err := dbHost.GetConnection("")
+	if err != nil {
+		return err
+	}
+	defer conn.ReturnConnection()
+	_, err = conn.(*mysqlBackend.Conn).Query(fmt.Sprintf("KILL QUERY %d", target.GetThreadID()))
+	return err
+}
@@ -0,0 +1,115 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"regexp"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// loadDataRegexp matches LOAD DATA or LOAD XML, which is not parsed, so it's only sent to unsharded schema as it is.
+var loadDataRegexp = regexp.MustCompile(`(?is)^\s*(?:/\*.*?\*/\s*)*LOAD\s+(?:DATA|XML)\s`)
+
+// loadDataLocalRegexp matches LOAD DATA LOCAL INFILE and quoted file name of it.
+var loadDataLocalRegexp = regexp.MustCompile(`(?is)^\s*(?:/\*.*?\*/\s*)*LOAD\s+(?:DATA|XML)\s+(?:LOW_PRIORITY\s+|CONCURRENT\s+)?` +
+	`LOCAL\s+INFILE\s+('(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.|"")*")`)
+
+var errLoadDataInShard = mysql.NewError(mysql.ER_NOT_SUPPORTED_YET, "LOAD DATA in sharded schema, load into data nodes directly")
+
+// isLoadData is true if sql is LOAD DATA or LOAD XML.
+func isLoadData(sql string) bool {
+	return loadDataRegexp.MatchString(sql)
+}
+
+// localInfileName get file name of LOAD DATA LOCAL INFILE sent as it is, false if statement is not.
+func localInfileName(statement sqlparser.Statement) (string, bool) {
+	passthrough, ok := statement.(*sqlparser.Passthrough)
+	if !ok {
+		return "", false
+	}
+	match := loadDataLocalRegexp.FindSubmatch(passthrough.SQL)
+	if match == nil {
+		return "", false
+	}
+	return unquoteString(match[1]), true
+}
+
+// unquoteString get value of quoted string literal, with escape sequences and doubled quotes.
+func unquoteString(quoted []byte) string {
+	quote := quoted[0]
+	s := quoted[1 : len(quoted)-1]
+	value := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				value = append(value, '\n')
+			case 't':
+				value = append(value, '\t')
+			case 'r':
+				value = append(value, '\r')
+			case 'b':
+				value = append(value, '\b')
+			case '0':
+				value = append(value, 0)
+			case 'Z':
+				value = append(value, 26)
+			default:
+				value = append(value, s[i])
+			}
+		case s[i] == quote && i+1 < len(s) && s[i+1] == quote:
+			i++
+			value = append(value, quote)
+		default:
+			value = append(value, s[i])
+		}
+	}
+	return string(value)
+}
+
+// localInfile relay local file requested by backend in LOAD DATA LOCAL INFILE from client,
+// only the file named in the statement, as backend could request any file that client allows.
+// nil to refuse it if disabled or client doesn't send local files.
+func (c *ClientConn) localInfile(filename string) func(requested []byte, server *mysql.PacketIO) error {
+	if c.topology.cfg.LocalInfileDisabled || c.capability&mysql.CLIENT_LOCAL_FILES == 0 {
+		return nil
+	}
+	return func(requested []byte, server *mysql.PacketIO) error {
+		if string(requested) != filename {
+			simplelog.Warn("%s %s connectionID=%d: local file %s requested is not %s of statement", "ClientConn", "localInfile",
+				c.connectionID, requested, filename)
+			// server waits for the file, connection is closed so that nothing is loaded.
+			server.Conn().Close()
+			return mysql.NewError(mysql.ER_NOT_ALLOWED_COMMAND, "local file requested by backend is not the one of LOAD DATA LOCAL INFILE")
+		}
+		if c.debug {
+			simplelog.Debug("%s %s connectionID=%d: relay local file %s", "ClientConn", "localInfile", c.connectionID, requested)
+		}
+		return c.pkg.RelayLocalInfile(requested, server)
+	}
+}
@@ -0,0 +1,34 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// TestLocalInfileName local file is relayed only for LOAD DATA LOCAL INFILE, and only the file named in it.
+func TestLocalInfileName(t *testing.T) {
+	cases := []struct {
+		sql      string
+		filename string
+		ok       bool
+	}{
+		{"LOAD DATA LOCAL INFILE '/tmp/a.csv' INTO TABLE t", "/tmp/a.csv", true},
+		{"/* batch */ load data low_priority local infile \"C:\\\\data\\\\a.csv\" into table t", `C:\data\a.csv`, true},
+		{"LOAD XML LOCAL INFILE 'it''s.xml' INTO TABLE t", "it's.xml", true},
+		{"LOAD DATA INFILE '/tmp/a.csv' INTO TABLE t", "", false},
+		{"SELECT 'LOAD DATA LOCAL INFILE ''/etc/passwd'''", "", false},
+	}
+	for _, tc := range cases {
+		filename, ok := localInfileName(&sqlparser.Passthrough{SQL: []byte(tc.sql)})
+		if ok != tc.ok || filename != tc.filename {
+			t.Errorf("%s: expect %q %v, got %q %v", tc.sql, tc.filename, tc.ok, filename, ok)
+		}
+	}
+	if _, ok := localInfileName(&sqlparser.Select{}); ok {
+		t.Error("expect parsed statement not LOAD DATA")
+	}
+	if !isLoadData("load data infile 'a.csv' into table t") || isLoadData("select * from load_data") {
+		t.Error("expect only LOAD DATA matched")
+	}
+}
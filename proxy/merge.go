@@ -0,0 +1,58 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"strconv"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// mergeResult merge result of statement in another node, rows are appended and affected rows are summed.
+func mergeResult(merged *mysql.Result, result *mysql.Result) *mysql.Result {
+	if merged == nil {
+		return result
+	}
+	merged.AffectedRows += result.AffectedRows
+	merged.Warnings += result.Warnings
+	if merged.Resultset != nil && result.Resultset != nil {
+		merged.Rows = append(merged.Rows, result.Rows...)
+		merged.Values = append(merged.Values, result.Values...)
+	}
+	return merged
+}
+
+// limitRows keep rows in row count of LIMIT of select, after rows are merged from nodes.
+func limitRows(statement sqlparser.Statement, result *mysql.Result) {
+	selectStmt, ok := statement.(*sqlparser.Select)
+	if !ok || selectStmt.Limit == nil || result.Resultset == nil {
+		return
+	}
+	rowCount, err := strconv.Atoi(sqlparser.String(selectStmt.Limit.Rowcount))
+	if err != nil || rowCount < 0 || rowCount >= len(result.Values) {
+		return
+	}
+	result.Rows = result.Rows[:rowCount]
+	result.Values = result.Values[:rowCount]
+}
@@ -0,0 +1,281 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/berkaroad/saashard/backend"
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/route"
+	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// multiGet is point selects of the same shape by shard key in a multi-statement query,
+// such as N+1 queries of ORM, coalesced into a select by IN list of shard key, which is grouped by node.
+type multiGet struct {
+	shardKey string
+	selects  []*sqlparser.Select
+	values   []sqlparser.ValExpr // value of shard key of each select.
+}
+
+// newMultiGet recognize point selects by shard key, nil if not all statements are point selects of the same shape.
+// Point select has only 'shard_key = value' in where, and shard key should be selected to split rows.
+func newMultiGet(schemaConfig *config.SchemaConfig, stmts []sqlparser.Statement) *multiGet {
+	if len(stmts) < 2 || schemaConfig == nil || !schemaConfig.MultiGet || !schemaConfig.ShardEnabled() {
+		return nil
+	}
+	m := &multiGet{shardKey: schemaConfig.ShardKey}
+	var shape string
+	for _, stmt := range stmts {
+		selectStmt, ok := stmt.(*sqlparser.Select)
+		if !ok || selectStmt.Where == nil || len(selectStmt.Distinct) > 0 || selectStmt.CalcFoundRows ||
+			len(selectStmt.GroupBy) > 0 || selectStmt.Having != nil || len(selectStmt.OrderBy) > 0 || len(selectStmt.Lock) > 0 {
+			return nil
+		}
+		if limit := selectStmt.Limit; limit != nil && limit.Offset != nil && sqlparser.String(limit.Offset) != "0" {
+			return nil
+		}
+		value := pointValue(selectStmt.Where.Expr, m.shardKey)
+		if value == nil {
+			return nil
+		}
+		// the same shape, except value of shard key and limit.
+		shapeStmt := *selectStmt
+		shapeStmt.Where = nil
+		shapeStmt.Limit = nil
+		if len(shape) == 0 {
+			if !selectsColumn(selectStmt.SelectExprs, m.shardKey) {
+				return nil
+			}
+			for _, tableName := range sqlparser.GetTableNames(selectStmt) {
+				if len(tableName.Qualifier) > 0 {
+					return nil
+				}
+			}
+			shape = sqlparser.String(&shapeStmt)
+		} else if sqlparser.String(&shapeStmt) != shape {
+			return nil
+		}
+		m.selects = append(m.selects, selectStmt)
+		m.values = append(m.values, value)
+	}
+	return m
+}
+
+// pointValue get value of 'shard_key = value', nil if not.
+func pointValue(expr sqlparser.BoolExpr, shardKey string) sqlparser.ValExpr {
+	if paren, ok := expr.(*sqlparser.ParenBoolExpr); ok {
+		return pointValue(paren.Expr, shardKey)
+	}
+	comparison, ok := expr.(*sqlparser.ComparisonExpr)
+	if !ok || comparison.Operator != sqlparser.AST_EQ {
+		return nil
+	}
+	value := comparison.Right
+	if sqlparser.GetColName(comparison.Left) != shardKey {
+		if sqlparser.GetColName(comparison.Right) != shardKey {
+			return nil
+		}
+		value = comparison.Left
+	}
+	switch value.(type) {
+	case sqlparser.StrVal, sqlparser.NumVal:
+		return value
+	}
+	return nil
+}
+
+// selectsColumn is true if column is selected by star or its name.
+func selectsColumn(selectExprs sqlparser.SelectExprs, column string) bool {
+	for _, selectExpr := range selectExprs {
+		switch expr := selectExpr.(type) {
+		case *sqlparser.StarExpr:
+			return true
+		case *sqlparser.NonStarExpr:
+			if sqlparser.GetColName(expr.Expr) == column {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// statement coalesced select by IN list of shard key, rows are limited by max_row_count of each select.
+func (m *multiGet) statement(maxRowCount int) *sqlparser.Select {
+	coalesced := *m.selects[0]
+	values := make(sqlparser.ValTuple, 0, len(m.values))
+	seen := make(map[string]bool)
+	for _, value := range m.values {
+		if key := sqlparser.String(value); !seen[key] {
+			seen[key] = true
+			values = append(values, value)
+		}
+	}
+	coalesced.Where = sqlparser.NewWhere(sqlparser.AST_WHERE, &sqlparser.ComparisonExpr{
+		Operator: sqlparser.AST_IN,
+		Left:     &sqlparser.ColName{Name: []byte(m.shardKey)},
+		Right:    values,
+	})
+	coalesced.Limit = nil
+	if maxRowCount > 0 {
+		coalesced.Limit = &sqlparser.Limit{Offset: sqlparser.NumVal("0"), Rowcount: sqlparser.NumVal(strconv.Itoa(maxRowCount * len(values)))}
+	}
+	return &coalesced
+}
+
+// errMultiGetNotSplit is returned by split if value of shard key couldn't be compared with literal exactly,
+// the selects are executed one by one instead.
+var errMultiGetNotSplit = fmt.Errorf("rows of multi-get couldn't be split by shard key")
+
+// split rows of coalesced result to result of each select, by value of shard key.
+// Value is compared with literal of each select by type and collation of shard key field, as mysql does in where.
+func (m *multiGet) split(result *mysql.Result) ([]*mysql.Result, error) {
+	column := -1
+	for i, field := range result.Fields {
+		if strings.EqualFold(string(field.OrgName), m.shardKey) {
+			column = i
+			break
+		}
+	}
+	if column < 0 {
+		return nil, fmt.Errorf("shard key '%s' not found in result of multi-get", m.shardKey)
+	}
+	results := make([]*mysql.Result, len(m.selects))
+	for i, selectStmt := range m.selects {
+		rs := &mysql.Result{Status: result.Status, Resultset: &mysql.Resultset{Fields: result.Fields, FieldNames: result.FieldNames}}
+		literal, isStr := pointLiteral(m.values[i])
+		for row, values := range result.Values {
+			c, ok := mysql.CompareLiteral(result.Fields[column], values[column], literal, isStr)
+			if !ok {
+				return nil, errMultiGetNotSplit
+			}
+			if c == 0 {
+				rs.Rows = append(rs.Rows, result.Rows[row])
+				rs.Values = append(rs.Values, values)
+			}
+		}
+		limitRows(selectStmt, rs)
+		results[i] = rs
+	}
+	return results, nil
+}
+
+// pointLiteral get literal of value of shard key, and it's string or number.
+func pointLiteral(value sqlparser.ValExpr) ([]byte, bool) {
+	switch v := value.(type) {
+	case sqlparser.StrVal:
+		return []byte(v), true
+	case sqlparser.NumVal:
+		return []byte(v), false
+	}
+	return []byte(sqlparser.String(value)), false
+}
+
+// handleMultiGet coalesce point selects into a select by IN list of shard key, then split rows to results of each select.
+// Not handled if statements are not point selects, or in transaction, or rows couldn't be split by shard key.
+func (c *ClientConn) handleMultiGet(sql string, stmts []sqlparser.Statement, router *route.Router) (handled bool, err error) {
+	if c.isInTransaction() {
+		return false, nil
+	}
+	schemaConfig := c.schemas[c.db]
+	m := newMultiGet(schemaConfig, stmts)
+	if m == nil {
+		return false, nil
+	}
+	coalesced := m.statement(schemaConfig.MaxRowCount)
+	plan, err := router.BuildMergedPlan(coalesced)
+	if err != nil {
+		return true, err
+	}
+	if c.debug {
+		simplelog.Debug("%s %s connectionID=%d: %d point selects coalesced, sql: %s", "ClientConn", "handleMultiGet",
+			c.connectionID, len(stmts), plan.GetPlanSQL())
+	}
+
+	var result *mysql.Result
+	var addrs []string
+	executor := func(statements []sqlparser.Statement, results []*mysql.Result, dataNodes []string, isSlave bool,
+		queryDataNodes map[sqlparser.Statement][]string) (backendConnAddrs []string, err error) {
+		defer func() { addrs = backendConnAddrs }()
+		for i, statement := range statements {
+			node := c.topology.nodes[dataNodes[0]]
+			if len(dataNodes) == len(statements) {
+				node = c.topology.nodes[dataNodes[i]]
+			}
+			var conn backend.Connection
+			if isSlave && c.hasSlave(node) {
+				conn, err = c.getOrCreateSlaveConn(node)
+			} else {
+				conn, err = c.getOrCreateMasterConn(node)
+			}
+			if err != nil {
+				return
+			}
+			backendConnAddrs = append(backendConnAddrs, conn.GetAddr())
+			mysqlConn := conn.(*mysqlBackend.Conn)
+			mysqlConn.UseDB(node.Database)
+			var rs *mysql.Result
+			if rs, err = mysqlConn.Query(sqlparser.String(statement)); err != nil {
+				return
+			}
+			result = mergeResult(result, rs)
+		}
+		return
+	}
+	startTime := time.Now()
+	defer func() { c.logSlowQuery(sql, startTime, plan.OnSlave(), err, router.Labels, planShards(plan, addrs)) }()
+	if err = plan.Execute(executor, c.c.RemoteAddr(), strings.ToLower(c.proxy.logSQL[c.proxy.logSQLIndex]) != "on",
+		c.proxy.slowLogTime[c.proxy.slowLogTimeIndex], c.proxy.counter); err != nil {
+		return true, err
+	}
+	if result == nil || result.Resultset == nil {
+		return true, errors.ErrCmdUnsupport
+	}
+	results, err := m.split(result)
+	if err == errMultiGetNotSplit {
+		return false, nil
+	} else if err != nil {
+		return true, err
+	}
+	for i, rs := range results {
+		status := c.status | rs.Status
+		if i < len(results)-1 {
+			status |= mysql.SERVER_MORE_RESULTS_EXISTS
+		} else {
+			status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
+		}
+		c.trackResult(rs)
+		if err = c.writeResultSet(status, rs); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
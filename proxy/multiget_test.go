@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+func newTestMultiGet(t *testing.T, sqls ...string) *multiGet {
+	m := &multiGet{shardKey: "id"}
+	for _, sql := range sqls {
+		stmt, err := sqlparser.Parse(sql)
+		if err != nil {
+			t.Fatal(err)
+		}
+		selectStmt := stmt.(*sqlparser.Select)
+		m.selects = append(m.selects, selectStmt)
+		m.values = append(m.values, pointValue(selectStmt.Where.Expr, m.shardKey))
+	}
+	return m
+}
+
+func splitTestResult(field *mysql.Field, values ...interface{}) *mysql.Result {
+	rs := &mysql.Resultset{Fields: []*mysql.Field{field}}
+	for _, v := range values {
+		rs.Values = append(rs.Values, []interface{}{v})
+		rs.Rows = append(rs.Rows, nil)
+	}
+	return &mysql.Result{Resultset: rs}
+}
+
+// TestMultiGetSplit rows are split by value of shard key compared with literal by type and collation of field.
+func TestMultiGetSplit(t *testing.T) {
+	intField := &mysql.Field{OrgName: []byte("id"), ColumnType: mysql.MYSQL_TYPE_LONG}
+	binField := &mysql.Field{OrgName: []byte("id"), ColumnType: mysql.MYSQL_TYPE_VAR_STRING,
+		Charset: uint16(mysql.CollationNames["utf8mb4_bin"])}
+	ciField := &mysql.Field{OrgName: []byte("id"), ColumnType: mysql.MYSQL_TYPE_VAR_STRING,
+		Charset: uint16(mysql.CollationNames["utf8mb4_general_ci"])}
+	cases := []struct {
+		sqls   []string
+		result *mysql.Result
+		want   [][]interface{} // values of shard key in result of each select.
+	}{
+		{
+			[]string{"select * from t where id = 007", "select * from t where id = '5'", "select * from t where id = 1.0"},
+			splitTestResult(intField, int64(1), int64(5), int64(7)),
+			[][]interface{}{{int64(7)}, {int64(5)}, {int64(1)}},
+		},
+		{
+			[]string{"select * from t where id = 'abc'", "select * from t where id = 'ABC'"},
+			splitTestResult(binField, "abc", "ABC"),
+			[][]interface{}{{"abc"}, {"ABC"}},
+		},
+		{
+			[]string{"select * from t where id = 'abc  '", "select * from t where id = 'ÀBC'", "select * from t where id = 'abd'"},
+			splitTestResult(ciField, "abc"),
+			[][]interface{}{{"abc"}, {"abc"}, nil},
+		},
+	}
+	for _, c := range cases {
+		results, err := newTestMultiGet(t, c.sqls...).split(c.result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, rs := range results {
+			if len(rs.Values) != len(c.want[i]) {
+				t.Errorf("%s: expect %v, got %v", c.sqls[i], c.want[i], rs.Values)
+				continue
+			}
+			for j, values := range rs.Values {
+				if values[0] != c.want[i][j] {
+					t.Errorf("%s: expect %v, got %v", c.sqls[i], c.want[i], rs.Values)
+				}
+			}
+		}
+	}
+
+	// number isn't compared with string column exactly, nor literal with temporal column.
+	m := newTestMultiGet(t, "select * from t where id = 5", "select * from t where id = 6")
+	if _, err := m.split(splitTestResult(ciField, "5")); err != errMultiGetNotSplit {
+		t.Errorf("expect not split, got %v", err)
+	}
+	dateField := &mysql.Field{OrgName: []byte("id"), ColumnType: mysql.MYSQL_TYPE_DATE}
+	m = newTestMultiGet(t, "select * from t where id = '2024-01-01'", "select * from t where id = '2024-01-02'")
+	if _, err := m.split(splitTestResult(dateField, []byte("2024-01-01"))); err != errMultiGetNotSplit {
+		t.Errorf("expect not split, got %v", err)
+	}
+}
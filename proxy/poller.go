@@ -0,0 +1,77 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"errors"
+	"runtime"
+
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+var errPollerNotSupported = errors.New("frontend poller is not supported on " + runtime.GOOS)
+
+// frontendPoller wait idle client connections to be readable, then resume them in new goroutine,
+// so that idle connections don't cost goroutine stacks.
+type frontendPoller interface {
+	// park connection until readable, connection should not be touched by caller after parked.
+	park(c *ClientConn) error
+	// remove connection from poller before closed.
+	remove(c *ClientConn)
+	// parked count of connections of each poller.
+	parked() []int
+}
+
+// ParkedConnections count of idle connections parked in each frontend poller, nil if disabled.
+func (p *Server) ParkedConnections() []int {
+	if p.poller == nil {
+		return nil
+	}
+	return p.poller.parked()
+}
+
+// resumeConn serve commands of connection readable, and park it again when no more command buffered.
+func (p *Server) resumeConn(c *ClientConn) {
+	parked := false
+	defer func() {
+		if r := recover(); r != nil {
+			const size = 4096
+			buf := make([]byte, size)
+			buf = buf[:runtime.Stack(buf, false)]
+			simplelog.Error("%s %s %s connection id=%d,stack=%s", "server/proxy", "resumeConn", "error",
+				c.connectionID,
+				string(buf))
+		}
+		if !parked {
+			c.Close()
+			p.counter.DecrClientConns()
+		}
+	}()
+
+	for c.serveCommand() {
+		if c.pkg.Buffered() == 0 && p.poller.park(c) == nil {
+			parked = true
+			return
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"net"
+	"sync"
+	"syscall"
+
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// epollPollers is frontend poller by epoll, connections are distributed by connection id.
+type epollPollers []*epollPoller
+
+type epollPoller struct {
+	proxy *Server
+	epfd  int
+
+	sync.Mutex
+	conns map[int]*ClientConn // fd -> conn, registered in epoll.
+}
+
+func newFrontendPoller(p *Server, count int) (frontendPoller, error) {
+	pollers := make(epollPollers, count)
+	for i := range pollers {
+		epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+		if err != nil {
+			for _, poller := range pollers[:i] {
+				syscall.Close(poller.epfd)
+			}
+			return nil, err
+		}
+		pollers[i] = &epollPoller{proxy: p, epfd: epfd, conns: make(map[int]*ClientConn)}
+	}
+	for _, poller := range pollers {
+		go poller.run()
+	}
+	return pollers, nil
+}
+
+func (pollers epollPollers) get(c *ClientConn) *epollPoller {
+	return pollers[int(c.connectionID)%len(pollers)]
+}
+
+func (pollers epollPollers) park(c *ClientConn) error {
+	fd, err := connFd(c.c)
+	if err != nil {
+		return err
+	}
+	return pollers.get(c).park(fd, c)
+}
+
+func (pollers epollPollers) remove(c *ClientConn) {
+	if fd, err := connFd(c.c); err == nil {
+		pollers.get(c).remove(fd)
+	}
+}
+
+func (pollers epollPollers) parked() []int {
+	counts := make([]int, len(pollers))
+	for i, poller := range pollers {
+		poller.Lock()
+		counts[i] = len(poller.conns)
+		poller.Unlock()
+	}
+	return counts
+}
+
+// park register fd as one shot, so that it's resumed only once when readable.
+func (e *epollPoller) park(fd int, c *ClientConn) error {
+	e.Lock()
+	_, registered := e.conns[fd]
+	e.conns[fd] = c
+	e.Unlock()
+
+	op := syscall.EPOLL_CTL_ADD
+	if registered {
+		op = syscall.EPOLL_CTL_MOD
+	}
+	event := syscall.EpollEvent{Events: syscall.EPOLLIN | syscall.EPOLLRDHUP | syscall.EPOLLONESHOT, Fd: int32(fd)}
+	if err := syscall.EpollCtl(e.epfd, op, fd, &event); err != nil {
+		e.remove(fd)
+		return err
+	}
+	return nil
+}
+
+func (e *epollPoller) remove(fd int) {
+	e.Lock()
+	defer e.Unlock()
+	if _, ok := e.conns[fd]; ok {
+		delete(e.conns, fd)
+		syscall.EpollCtl(e.epfd, syscall.EPOLL_CTL_DEL, fd, nil)
+	}
+}
+
+func (e *epollPoller) run() {
+	events := make([]syscall.EpollEvent, 128)
+	for {
+		n, err := syscall.EpollWait(e.epfd, events, -1)
+		if err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
+			simplelog.Error("%s %s %s", "server/proxy", "epollPoller", err.Error())
+			return
+		}
+		for i := 0; i < n; i++ {
+			e.Lock()
+			c := e.conns[int(events[i].Fd)]
+			e.Unlock()
+			if c != nil {
+				go e.proxy.resumeConn(c)
+			}
+		}
+	}
+}
+
+// connFd file descriptor of connection, it's still owned by connection.
+func connFd(conn net.Conn) (int, error) {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return -1, errPollerNotSupported
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+	fd := -1
+	if err = rc.Control(func(s uintptr) { fd = int(s) }); err != nil {
+		return -1, err
+	}
+	return fd, nil
+}
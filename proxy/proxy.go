@@ -23,8 +23,11 @@
 package proxy
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
+	"path/filepath"
+	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
@@ -36,10 +39,16 @@ import (
 	// Import mysql backend
 	_ "github.com/berkaroad/saashard/backend/mysql"
 	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/directory"
 	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/globalindex"
 	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/route"
+	"github.com/berkaroad/saashard/sqlparser"
 	"github.com/berkaroad/saashard/statistic"
+	"github.com/berkaroad/saashard/utils"
 	"github.com/berkaroad/saashard/utils/simplelog"
+	"github.com/berkaroad/saashard/xa"
 )
 
 var (
@@ -49,12 +58,24 @@ var (
 // Server proxy daemon
 type Server struct {
 	sync.Mutex
-	cfg     *config.Config
-	bindIP  net.IP
-	port    int
-	hosts   map[string]*backend.DataHost
-	nodes   map[string]*backend.DataNode
-	schemas map[string]*config.SchemaConfig
+	topology atomic.Value // *topology, replaced as a whole by reload.
+	bindIP   net.IP
+	port     int
+
+	xaLog             xa.Log
+	directory         *directory.Table
+	globalIndex       *globalindex.Table
+	idempotencyTables *nodeTables
+	xaCommitMarks     *nodeTables
+	stmtMetas         *stmtMetaCache
+	resultCache       *resultCache
+	schemaDriftReport *SchemaDriftReport
+	poller            frontendPoller
+	advisoryLocks     *route.AdvisoryLocks
+	readOnly          *route.ReadOnlyMode
+	planPins          *route.PlanPins
+	sequence          route.Sequence
+	darkReads         chan struct{} // slots of dark reads in flight.
 
 	logSQLIndex      int32
 	logSQL           [2]string
@@ -62,26 +83,54 @@ type Server struct {
 	slowLogTime      [2]int
 	allowipsIndex    int32
 	allowips         [2][]net.IP
+	clientPolicy     *clientPolicy // nil if no policy.
+	tlsConfig        *tls.Config   // nil if TLS disabled.
 
 	counter  *statistic.Counter
 	listener net.Listener
 	running  bool
-	conns    map[uint32]*ClientConn
+	conns    *connMap
+
+	startTime time.Time
+
+	// reloadLock is held when config is reloaded.
+	reloadLock sync.Mutex
+	// retiredHosts are hosts removed or changed by reload, closed once their connections in use are returned.
+	retiredHosts []*backend.DataHost
+	// schemaDriftCheckTime is check time of running schema drift check, stopped by closing schemaDriftStop.
+	schemaDriftCheckTime string
+	schemaDriftStop      chan struct{}
+
+	// globalIndexJobs are backfill and repair jobs of global index started by admin, from the oldest.
+	globalIndexJobs []*GlobalIndexJob
+}
+
+// topology is config with hosts, nodes, schemas, shard maps and node groups of it, immutable once published,
+// except shard maps and node groups updated online until replaced by reload.
+type topology struct {
+	cfg               *config.Config
+	hosts             map[string]*backend.DataHost
+	nodes             map[string]*backend.DataNode
+	schemas           map[string]*config.SchemaConfig
+	shardMaps         *route.ShardMaps
+	nodeGroups        *route.NodeGroups
+	migrationCounters map[string]*statistic.MigrationCounter
+	// version is increased by reload, sessions refresh by it lazily.
+	version uint32
 }
 
 // NewServer create proxy.
 func NewServer(cfg *config.Config) (*Server, error) {
 	p := new(Server)
-	p.cfg = cfg
 	p.bindIP = net.ParseIP(cfg.BindIP)
 	p.port = cfg.ProxyPort
-	p.conns = make(map[uint32]*ClientConn)
-
-	p.hosts = make(map[string]*backend.DataHost)
-	p.nodes = make(map[string]*backend.DataNode)
-	p.schemas = make(map[string]*config.SchemaConfig)
+	p.conns = newConnMap()
+	p.startTime = time.Now()
 
 	p.counter = new(statistic.Counter)
+	p.advisoryLocks = route.NewAdvisoryLocks()
+	p.readOnly = route.NewReadOnlyMode()
+	p.darkReads = make(chan struct{}, DefaultMaxDarkReads)
 	atomic.StoreInt32(&p.logSQLIndex, 0)
 	p.logSQL[p.logSQLIndex] = cfg.LogSQL
 	atomic.StoreInt32(&p.slowLogTimeIndex, 0)
@@ -97,23 +146,74 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		mysql.DEFAULT_COLLATION_NAME = mysql.Collations[cid]
 	}
 
-	if err := p.parseHosts(); err != nil {
+	var err error
+	t := &topology{cfg: cfg}
+	if t.hosts, err = parseHosts(cfg, nil); err != nil {
 		panic(err)
 	}
 
-	if err := p.parseNodes(); err != nil {
+	if t.nodes, err = parseNodes(cfg, t.hosts, nil); err != nil {
 		panic(err)
 	}
 
-	if err := p.parseSchemas(); err != nil {
+	if t.schemas, err = parseSchemas(cfg, t.nodes); err != nil {
 		panic(err)
 	}
+	t.shardMaps = route.NewShardMaps(t.schemas)
+	t.nodeGroups = route.NewNodeGroups(t.schemas)
+	t.migrationCounters = newMigrationCounters(t.schemas, nil)
+	p.topology.Store(t)
+	p.stmtMetas = newStmtMetaCache(DefaultStmtMetaCacheSize)
+	p.resultCache = newResultCache(cfg.ResultCache)
+	p.idempotencyTables = newNodeTables(fmt.Sprintf(sqlCreateIdempotencyTable, IdempotencyTable))
+	p.xaCommitMarks = newNodeTables(xa.CreateCommitMarkTableSQL())
+	xaLog, err := p.openXALog()
+	if err != nil {
+		return nil, err
+	}
+	p.xaLog = xaLog
+	if p.directory, err = p.openDirectory(); err != nil {
+		return nil, err
+	}
+	shardMapStore, err := p.openShardMapStore()
+	if err != nil {
+		return nil, err
+	}
+	if shardMapStore != nil {
+		if err = t.shardMaps.SetStore(shardMapStore); err != nil {
+			return nil, err
+		}
+	}
+	if p.globalIndex, err = p.openGlobalIndex(); err != nil {
+		return nil, err
+	}
+	if p.planPins, err = p.loadPlanPins(); err != nil {
+		return nil, err
+	}
+	if p.sequence, err = p.openSequence(); err != nil {
+		return nil, err
+	}
 
 	if err := p.parseAllowIps(); err != nil {
 		panic(err)
 	}
+	if cfg.TLS.Enabled() {
+		if p.tlsConfig, err = cfg.TLS.ServerConfig(); err != nil {
+			return nil, err
+		}
+	}
+	if p.clientPolicy, err = newClientPolicy(cfg.ClientPolicy, p.tlsConfig != nil && cfg.TLS.Required); err != nil {
+		return nil, err
+	}
+	sqlparser.MaxStatementLength = cfg.ParserLimits.MaxStatementLength
+	sqlparser.MaxInListItems = cfg.ParserLimits.MaxInListItems
+	sqlparser.MaxNestingDepth = cfg.ParserLimits.MaxNestingDepth
+	if cfg.FrontendPollers > 0 {
+		if p.poller, err = newFrontendPoller(p, cfg.FrontendPollers); err != nil {
+			simplelog.Warn("%s %s %s, fallback to goroutine per connection", "server/proxy", "NewServer", err.Error())
+		}
+	}
 
-	var err error
 	netProto := "tcp"
 	addr := p.bindIP.String() + ":" + strconv.Itoa(p.port)
 
@@ -136,6 +236,34 @@ func (p *Server) Run() {
 	// flush counter
 	go p.flushCounter()
 
+	cfg := p.getConfig()
+
+	// schema drift check, started or stopped by reload.
+	p.reloadLock.Lock()
+	p.startSchemaDriftCheck(cfg)
+	p.reloadLock.Unlock()
+
+	// purge expired idempotency keys
+	go p.purgeIdempotencyKeys()
+
+	// follow shard maps switched by other proxies
+	if len(cfg.Directory.Node) > 0 {
+		go p.refreshShardMaps()
+	}
+
+	// resolve prepared transactions left by crash
+	if !cfg.XA.RecoverDisabled {
+		go p.recoverXAOnStartup()
+	}
+
+	// health check of backends, and close idle connections
+	p.reloadLock.Lock()
+	for _, host := range p.GetDataHosts() {
+		go p.checkHostHealth(host)
+	}
+	p.reloadLock.Unlock()
+	go p.reapIdleConns()
+
 	// proxy
 	for p.running {
 		conn, err := p.listener.Accept()
@@ -153,34 +281,202 @@ func (p *Server) Close() {
 	if p.listener != nil {
 		p.listener.Close()
 	}
+	if p.xaLog != nil {
+		p.xaLog.Close()
+	}
 }
 
-// GetConnection get connection
-func (p *Server) GetConnection(connectionID uint32) *ClientConn {
-	defer p.Unlock()
+// checkHostHealth check health of host every ping interval, until server closed or host removed by reload.
+func (p *Server) checkHostHealth(host *backend.DataHost) {
+	for p.running && p.GetDataHosts()[host.Name] == host {
+		time.Sleep(host.GetPingInterval())
+		host.CheckHealth()
+	}
+}
 
-	p.Lock()
-	if conn, ok := p.conns[connectionID]; ok {
-		return conn
+// reapIdleConns close idle connections of backends every reap interval, until server closed.
+// Hosts retired by reload are closed once their connections in use are returned.
+func (p *Server) reapIdleConns() {
+	for p.running {
+		time.Sleep(backend.ReapInterval)
+		for _, host := range p.GetDataHosts() {
+			host.ReapIdleConns()
+		}
+		p.closeRetiredHosts()
 	}
-	return nil
+}
+
+// GetConnection get connection
+func (p *Server) GetConnection(connectionID uint32) *ClientConn {
+	return p.conns.get(connectionID)
 }
 
 // RemoveConnection remove connection
 func (p *Server) RemoveConnection(connectionID uint32) {
-	defer p.Unlock()
-
-	p.Lock()
-	if conn, ok := p.conns[connectionID]; ok {
+	if conn := p.conns.remove(connectionID); conn != nil {
 		conn.Close()
-		delete(p.conns, connectionID)
 	}
 }
 
+// GetCounter get performance counter.
+func (p *Server) GetCounter() *statistic.Counter {
+	return p.counter
+}
+
+// getTopology get hosts, nodes and schemas of config last loaded.
+func (p *Server) getTopology() *topology {
+	return p.topology.Load().(*topology)
+}
+
+// getConfig get config last loaded.
+func (p *Server) getConfig() *config.Config {
+	return p.getTopology().cfg
+}
+
+// GetDataHosts get all data hosts.
+func (p *Server) GetDataHosts() map[string]*backend.DataHost {
+	return p.getTopology().hosts
+}
+
+// StartTime is time proxy started.
+func (p *Server) StartTime() time.Time {
+	return p.startTime
+}
+
+// GetDataNodes get data nodes by name.
+func (p *Server) GetDataNodes() map[string]*backend.DataNode {
+	return p.getTopology().nodes
+}
+
+// GetSchemaConfigs get config of schemas by name.
+func (p *Server) GetSchemaConfigs() map[string]*config.SchemaConfig {
+	return p.getTopology().schemas
+}
+
+// ShardMaps get versioned shard rules of sharded schemas.
+func (p *Server) ShardMaps() *route.ShardMaps {
+	return p.getTopology().shardMaps
+}
+
+// NodeGroups get blue/green node groups of schemas.
+func (p *Server) NodeGroups() *route.NodeGroups {
+	return p.getTopology().nodeGroups
+}
+
+// PlanPins get plan pins of queries by admin.
+func (p *Server) PlanPins() *route.PlanPins {
+	return p.planPins
+}
+
+// loadPlanPins load plan pins from plan_pin_file, or plan_pins.json in log_path, only in memory if neither is set.
+func (p *Server) loadPlanPins() (*route.PlanPins, error) {
+	cfg := p.getConfig()
+	path := cfg.PlanPinFile
+	if len(path) == 0 && len(cfg.LogPath) > 0 {
+		path = filepath.Join(cfg.LogPath, "plan_pins.json")
+	}
+	return route.LoadPlanPins(path)
+}
+
+// NewRouter create router of schema with current shard maps and node groups, such as to explain routing in admin.
+func (p *Server) NewRouter(schemaName string) *route.Router {
+	t := p.getTopology()
+	if t.schemas[schemaName] == nil {
+		return nil
+	}
+	router := route.NewRouter(schemaName, t.schemas, t.cfg.GetNodes(), 0, "", false)
+	router.ShardMaps = t.shardMaps
+	router.NodeGroups = t.nodeGroups
+	router.Directory = p.directory
+	router.GlobalIndex = p.globalIndex
+	router.PlanPins = p.planPins
+	return router
+}
+
+// AdvisoryLocks get advisory locks of GET_LOCK() held by sessions.
+func (p *Server) AdvisoryLocks() *route.AdvisoryLocks {
+	return p.advisoryLocks
+}
+
+// ReadOnlyMode get read-only mode of proxy and schemas.
+func (p *Server) ReadOnlyMode() *route.ReadOnlyMode {
+	return p.readOnly
+}
+
+// GetSchemaConfig get config of schema.
+func (p *Server) GetSchemaConfig(schemaName string) *config.SchemaConfig {
+	return p.getTopology().schemas[schemaName]
+}
+
+// GetMigrationCounter get shard migration counter of sharded schema.
+func (p *Server) GetMigrationCounter(schemaName string) *statistic.MigrationCounter {
+	return p.getTopology().migrationCounters[schemaName]
+}
+
+// CheckShardRule check shard rule of schema, all data nodes should exist and be alive.
+func (p *Server) CheckShardRule(schemaName string, rule *route.ShardRule) error {
+	schemaConfig := p.GetSchemaConfig(schemaName)
+	if schemaConfig == nil || !schemaConfig.ShardEnabled() {
+		return fmt.Errorf("schema '%s' not exists or not sharded", schemaName)
+	}
+	if !route.IsShardAlgorithm(rule.ShardAlgo) {
+		return fmt.Errorf("shard algorithm '%s' not supported", rule.ShardAlgo)
+	}
+	if len(rule.Nodes) == 0 {
+		return fmt.Errorf("no data node in shard rule")
+	}
+	for i, nodeName := range rule.Nodes {
+		if utils.Contains(rule.Nodes[:i], nodeName) {
+			return fmt.Errorf("duplicate data node '%s'", nodeName)
+		}
+	}
+	if err := route.CheckShardRanges(rule.ShardAlgo, rule.Ranges, rule.Nodes); err != nil {
+		return err
+	}
+	if route.IsDirectoryShardAlgo(rule.ShardAlgo) && p.directory == nil {
+		return fmt.Errorf("no data node of directory with directory shard algorithm")
+	}
+	return p.pingNodes(rule.Nodes)
+}
+
+// CheckNodeGroup check nodes of group before switched to it, all data nodes should be alive.
+func (p *Server) CheckNodeGroup(schemaName string, group string) error {
+	g := p.NodeGroups().Get(schemaName)
+	if g == nil {
+		return fmt.Errorf("schema '%s' has no green nodes", schemaName)
+	}
+	if group == route.NodeGroupGreen {
+		return p.pingNodes(g.Green)
+	}
+	return p.pingNodes(g.Blue)
+}
+
+// pingNodes ping master of data nodes.
+func (p *Server) pingNodes(nodeNames []string) error {
+	nodes := p.GetDataNodes()
+	for _, nodeName := range nodeNames {
+		node := nodes[nodeName]
+		if node == nil {
+			return fmt.Errorf("data node '%s' not exists", nodeName)
+		}
+		conn, err := node.DataHost.GetMaster().GetConnection(node.Database)
+		if err != nil {
+			return fmt.Errorf("data node '%s' is not available: %s", nodeName, err.Error())
+		}
+		err = conn.Ping()
+		conn.ReturnConnection()
+		if err != nil {
+			return fmt.Errorf("data node '%s' is not available: %s", nodeName, err.Error())
+		}
+	}
+	return nil
+}
+
 func (p *Server) onConn(c net.Conn) {
 	p.counter.IncrClientConns()
 	conn := p.newClientConn(c) //新建一个conn
 
+	parked := false
 	defer func() {
 		err := recover()
 		if err != nil {
@@ -193,13 +489,16 @@ func (p *Server) onConn(c net.Conn) {
 			)
 		}
 
-		conn.Close()
-		p.counter.DecrClientConns()
+		if !parked {
+			conn.Close()
+			p.counter.DecrClientConns()
+		}
 	}()
 
 	if allowConnect := conn.IsAllowConnect(); allowConnect == false {
 		err := mysql.NewError(mysql.ER_ACCESS_DENIED_ERROR, "ip address access denied by SaaShard.")
 		conn.pkg.WriteError(conn.capability, err)
+		conn.pkg.Flush()
 		conn.Close()
 		return
 	}
@@ -209,10 +508,14 @@ func (p *Server) onConn(c net.Conn) {
 		return
 	}
 
-	conn.schemas = p.getSchemasByUser(conn.user)
-	p.Lock()
-	p.conns[conn.connectionID] = conn // save conn
-	p.Unlock()
+	conn.topology = p.getTopology()
+	conn.schemas = conn.topology.schemasOfUser(conn.user)
+	p.conns.put(conn) // save conn
+	// idle connection is waited by poller, instead of this goroutine.
+	if p.poller != nil && conn.pkg.Buffered() == 0 && p.poller.park(conn) == nil {
+		parked = true
+		return
+	}
 	conn.Run()
 }
 
@@ -229,6 +532,10 @@ func (p *Server) newClientConn(co net.Conn) *ClientConn {
 	c.c = tcpConn
 
 	c.pkg = mysql.NewPacketIO(tcpConn)
+	c.pkg.TLSConfig = p.tlsConfig
+	c.pkg.MaxAllowedPacket = p.maxAllowedPacket()
+	// packets of a response are flushed together at the end of command.
+	c.pkg.SetWriteBuffered(true)
 	c.proxy = p
 	c.pkg.Sequence = 0
 	c.connectionID = atomic.AddUint32(&baseConnID, 1)
@@ -238,9 +545,13 @@ func (p *Server) newClientConn(co net.Conn) *ClientConn {
 	c.backendSlaveConns = make(map[*backend.DataNode]backend.Connection)
 	c.closed = false
 	c.charset = mysql.DEFAULT_CHARSET
+	c.resultsCharset = mysql.DEFAULT_CHARSET
 	c.collation = mysql.DEFAULT_COLLATION_ID
 	c.stmtID = 0
 	c.stmts = make(map[uint32]*mysql.Stmt)
+	c.funcs = route.NewLocalFuncs(uint8(p.getConfig().ServerID), c.connectionID, p.advisoryLocks)
+	c.funcs.Sequence = p.sequence
+	c.topology = p.getTopology()
 	return c
 }
 
@@ -253,7 +564,7 @@ func (p *Server) flushCounter() {
 
 func (p *Server) parseAllowIps() error {
 	atomic.StoreInt32(&p.allowipsIndex, 0)
-	cfg := p.cfg
+	cfg := p.getConfig()
 	if cfg.AllowIps == nil || len(cfg.AllowIps) == 0 {
 		return nil
 	}
@@ -266,63 +577,105 @@ func (p *Server) parseAllowIps() error {
 	return nil
 }
 
-func (p *Server) parseHosts() error {
-	cfg := p.cfg
+// parseHosts create hosts of config, host not changed is reused from old ones.
+func parseHosts(cfg *config.Config, old map[string]*backend.DataHost) (map[string]*backend.DataHost, error) {
 	if len(cfg.Hosts) == 0 {
-		return errors.ErrNoDataHost
+		return nil, errors.ErrNoDataHost
 	}
+	hosts := make(map[string]*backend.DataHost, len(cfg.Hosts))
 	for _, hostConfig := range cfg.Hosts {
 		hostCfg := hostConfig
-		if p.hosts[hostCfg.Name] == nil {
-			p.hosts[hostCfg.Name] = backend.NewDataHost(hostCfg)
+		if hosts[hostCfg.Name] == nil {
+			if host := old[hostCfg.Name]; host != nil && reflect.DeepEqual(host.Config(), hostCfg) {
+				hosts[hostCfg.Name] = host
+				continue
+			}
+			host, err := backend.NewDataHost(hostCfg)
+			if err != nil {
+				return nil, err
+			}
+			hosts[hostCfg.Name] = host
 		}
 	}
-	return nil
+	return hosts, nil
 }
 
-func (p *Server) parseNodes() error {
-	cfg := p.cfg
+// parseNodes create nodes of config, node not changed is reused from old ones.
+func parseNodes(cfg *config.Config, hosts map[string]*backend.DataHost, old map[string]*backend.DataNode) (map[string]*backend.DataNode, error) {
 	if len(cfg.Nodes) == 0 {
-		return errors.ErrNoDataNode
+		return nil, errors.ErrNoDataNode
 	}
+	nodes := make(map[string]*backend.DataNode, len(cfg.Nodes))
 	for _, nodeConfig := range cfg.Nodes {
 		nodeCfg := nodeConfig
-		if p.nodes[nodeCfg.Name] == nil {
-			if host, ok := p.hosts[nodeCfg.Host]; ok {
-				p.nodes[nodeCfg.Name] = backend.NewDataNode(nodeCfg, host)
-			} else {
-				return fmt.Errorf("data host '%s' not exists", nodeCfg.Host)
+		if nodes[nodeCfg.Name] == nil {
+			host, ok := hosts[nodeCfg.Host]
+			if !ok {
+				return nil, fmt.Errorf("data host '%s' not exists", nodeCfg.Host)
+			}
+			if node := old[nodeCfg.Name]; node != nil && node.DataHost == host && node.Database == nodeCfg.Database {
+				nodes[nodeCfg.Name] = node
+				continue
 			}
+			nodes[nodeCfg.Name] = backend.NewDataNode(nodeCfg, host)
 		}
 	}
-	return nil
+	return nodes, nil
 }
 
-func (p *Server) parseSchemas() error {
-	cfg := p.cfg
+func parseSchemas(cfg *config.Config, nodes map[string]*backend.DataNode) (map[string]*config.SchemaConfig, error) {
 	if len(cfg.Schemas) == 0 {
-		return errors.ErrNoSchema
+		return nil, errors.ErrNoSchema
 	}
+	schemas := make(map[string]*config.SchemaConfig, len(cfg.Schemas))
 	for _, schemaConfig := range cfg.Schemas {
 		schema := schemaConfig
-		if p.schemas[schema.Name] == nil {
+		if schemas[schema.Name] == nil {
 			if len(schema.Nodes) == 0 {
-				return fmt.Errorf("no data node in schema '%s'", schema.Name)
+				return nil, fmt.Errorf("no data node in schema '%s'", schema.Name)
+			}
+			if !schema.ShardEnabled() && len(schema.Nodes) > 1 {
+				return nil, fmt.Errorf("unsharded schema '%s' should have only one data node", schema.Name)
 			}
 			for _, nodeInSchema := range schema.Nodes {
-				if p.nodes[nodeInSchema] == nil {
-					return fmt.Errorf("data node '%s' not exists", nodeInSchema)
+				if nodes[nodeInSchema] == nil {
+					return nil, fmt.Errorf("data node '%s' not exists", nodeInSchema)
+				}
+			}
+			if len(schema.GreenNodes) > 0 && len(schema.GreenNodes) != len(schema.Nodes) {
+				return nil, fmt.Errorf("green nodes of schema '%s' should be as many as nodes", schema.Name)
+			}
+			for _, nodeInSchema := range schema.GreenNodes {
+				if nodes[nodeInSchema] == nil {
+					return nil, fmt.Errorf("data node '%s' not exists", nodeInSchema)
+				}
+			}
+			if err := route.CheckShardRanges(schema.ShardAlgo, schema.ShardRanges, schema.Nodes); err != nil {
+				return nil, fmt.Errorf("schema '%s': %s", schema.Name, err.Error())
+			}
+			if err := route.CheckChildTables(&schema); err != nil {
+				return nil, fmt.Errorf("schema '%s': %s", schema.Name, err.Error())
+			}
+			for _, table := range schema.GetTables() {
+				if table.GetType() == config.TableTypeSingle && len(table.Node) > 0 &&
+					!utils.Contains(schema.Nodes, table.Node) {
+					return nil, fmt.Errorf("data node '%s' of table '%s' not in schema '%s'", table.Node, table.Name, schema.Name)
 				}
 			}
-			p.schemas[schema.Name] = &schema
+			schemas[schema.Name] = &schema
 		}
 	}
-	return nil
+	return schemas, nil
 }
 
 func (p *Server) getSchemasByUser(user string) map[string]*config.SchemaConfig {
+	return p.getTopology().schemasOfUser(user)
+}
+
+// schemasOfUser get config of schemas of user in topology.
+func (t *topology) schemasOfUser(user string) map[string]*config.SchemaConfig {
 	schemas := make(map[string]*config.SchemaConfig)
-	for _, schema := range p.schemas {
+	for _, schema := range t.schemas {
 		if schema.User == strings.ToLower(user) {
 			schemas[schema.Name] = schema
 		}
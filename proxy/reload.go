@@ -0,0 +1,202 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/statistic"
+	"github.com/berkaroad/saashard/utils/alert"
+	"github.com/berkaroad/saashard/utils/audit"
+	"github.com/berkaroad/saashard/utils/simplelog"
+	"github.com/berkaroad/saashard/utils/slowlog"
+)
+
+// ReloadConfig reload config file proxy started with, without dropping client connections.
+// Hosts, nodes, schemas with their users and shard rules are swapped atomically, so are log_sql, slow_log_time,
+// allow_ips, schema drift check and other settings of session; others need restart, such as ports, TLS, XA, directory and global index.
+// Sessions use reloaded schemas and nodes from next statement out of transaction,
+// those whose user is removed or whose schema is unassigned are drained.
+func (p *Server) ReloadConfig() error {
+	fileName := p.getConfig().FileName()
+	if len(fileName) == 0 {
+		return fmt.Errorf("config is not parsed from file")
+	}
+	cfg, err := config.ParseConfigFile(fileName)
+	if err == nil {
+		err = p.reload(cfg)
+	}
+	if err != nil {
+		simplelog.Error("%s %s %s fileName=%s: %s", "server/proxy", "ReloadConfig", "failed", fileName, err.Error())
+		alert.Emit(alert.EventConfigReloadFailed, alert.SeverityWarning, fileName, err.Error(), nil)
+		return err
+	}
+	// file of slow log and audit log may be rotated.
+	if err = slowlog.Reopen(); err != nil {
+		simplelog.Error("%s %s %s: %s", "server/proxy", "ReloadConfig", "failed to reopen slow log", err.Error())
+	}
+	if err = audit.Reopen(); err != nil {
+		simplelog.Error("%s %s %s: %s", "server/proxy", "ReloadConfig", "failed to reopen audit log", err.Error())
+	}
+	drained := p.DrainStaleSessions()
+
+	simplelog.Info("%s %s %s log_sql=%s,slow_log_time=%d,allow_ips=%s,hosts=%d,nodes=%d,schemas=%d,drained=%d",
+		"server/proxy", "ReloadConfig", "reloaded",
+		cfg.LogSQL, cfg.SlowLogTime, strings.Join(cfg.AllowIps, ","), len(cfg.Hosts), len(cfg.Nodes), len(cfg.Schemas), drained)
+	return nil
+}
+
+// reload apply config, nothing is changed if hosts, nodes, schemas or shard rules are invalid.
+// New topology is published as a whole, readers see either old one or new one.
+func (p *Server) reload(newConfig *config.Config) error {
+	p.reloadLock.Lock()
+	defer p.reloadLock.Unlock()
+
+	old := p.getTopology()
+	cfg := old.cfg.Reload(newConfig)
+	hosts, err := parseHosts(cfg, old.hosts)
+	if err != nil {
+		return err
+	}
+	nodes, err := parseNodes(cfg, hosts, old.nodes)
+	if err != nil {
+		return err
+	}
+	schemas, err := parseSchemas(cfg, nodes)
+	if err != nil {
+		return err
+	}
+	shardMaps, err := old.shardMaps.Reload(schemas, cfg.GetNodes())
+	if err != nil {
+		return err
+	}
+	for name, schemaConfig := range old.schemas {
+		if !reflect.DeepEqual(schemaConfig, schemas[name]) {
+			p.stmtMetas.invalidate(name)
+		}
+	}
+
+	p.topology.Store(&topology{
+		cfg:               cfg,
+		hosts:             hosts,
+		nodes:             nodes,
+		schemas:           schemas,
+		shardMaps:         shardMaps,
+		nodeGroups:        old.nodeGroups.Reload(schemas),
+		migrationCounters: newMigrationCounters(schemas, old.migrationCounters),
+		version:           old.version + 1,
+	})
+
+	oldHosts := old.hosts
+	for name, host := range hosts {
+		if oldHosts[name] != host {
+			simplelog.Info("%s %s %s host=%s,master=%s", "server/proxy", "reload", "host added", name, host.Config().Master)
+			if p.running {
+				go p.checkHostHealth(host)
+			}
+		}
+	}
+	for name, host := range oldHosts {
+		if hosts[name] != host {
+			simplelog.Info("%s %s %s host=%s", "server/proxy", "reload", "host retired", name)
+			p.retiredHosts = append(p.retiredHosts, host)
+		}
+	}
+
+	logSQLIndex := 1 - atomic.LoadInt32(&p.logSQLIndex)
+	p.logSQL[logSQLIndex] = cfg.LogSQL
+	atomic.StoreInt32(&p.logSQLIndex, logSQLIndex)
+
+	slowLogTimeIndex := 1 - atomic.LoadInt32(&p.slowLogTimeIndex)
+	p.slowLogTime[slowLogTimeIndex] = cfg.SlowLogTime
+	atomic.StoreInt32(&p.slowLogTimeIndex, slowLogTimeIndex)
+
+	allowipsIndex := 1 - atomic.LoadInt32(&p.allowipsIndex)
+	allowips := make([]net.IP, 0, len(cfg.AllowIps))
+	for _, ip := range cfg.AllowIps {
+		allowips = append(allowips, net.ParseIP(strings.TrimSpace(ip)))
+	}
+	p.allowips[allowipsIndex] = allowips
+	atomic.StoreInt32(&p.allowipsIndex, allowipsIndex)
+
+	if p.running {
+		p.startSchemaDriftCheck(cfg)
+	}
+	return nil
+}
+
+// closeRetiredHosts close connections of hosts retired by reload, they're forgotten once no connection in use.
+func (p *Server) closeRetiredHosts() {
+	p.reloadLock.Lock()
+	defer p.reloadLock.Unlock()
+	retiredHosts := p.retiredHosts[:0]
+	for _, host := range p.retiredHosts {
+		host.Drain()
+		if host.InUse() {
+			retiredHosts = append(retiredHosts, host)
+		}
+	}
+	p.retiredHosts = retiredHosts
+}
+
+// newMigrationCounters create migration counters of sharded schemas, counters of old ones are kept.
+func newMigrationCounters(schemas map[string]*config.SchemaConfig,
+	old map[string]*statistic.MigrationCounter) map[string]*statistic.MigrationCounter {
+	counters := make(map[string]*statistic.MigrationCounter)
+	for name, schemaConfig := range schemas {
+		if schemaConfig.ShardEnabled() {
+			if counters[name] = old[name]; counters[name] == nil {
+				counters[name] = new(statistic.MigrationCounter)
+			}
+		}
+	}
+	return counters
+}
+
+// refreshTopology use reloaded topology and schemas of user, and return backend conns of nodes changed by reload.
+// It's deferred until transaction is finished, so that transaction is on the same backend conns.
+// Statement uses the topology of session as a whole, not the one published while it's executing.
+func (c *ClientConn) refreshTopology() {
+	t := c.proxy.getTopology()
+	if c.topology == t || c.isInTransaction() {
+		return
+	}
+	c.topology = t
+	c.schemas = t.schemasOfUser(c.user)
+	nodes := t.nodes
+	for node := range c.backendMasterConns {
+		if nodes[node.Name] != node {
+			c.returnMasterConn(node)
+		}
+	}
+	for node := range c.backendSlaveConns {
+		if nodes[node.Name] != node {
+			c.returnSlaveConn(node)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package proxy
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/route"
+)
+
+const reloadTestConfig = `
+hosts:
+  - {name: h1, master: 127.0.0.1:3306, user: root}
+nodes:
+  - {name: n1, host: h1, database: db1_01}
+  - {name: n2, host: h1, database: db1_02}
+schemas:
+  - {name: db1, user: u1, shard_key: id, shard_algo: mod, nodes: [n1, n2]}
+`
+
+// reloadTestConfig2 moves db1 to n3, and removes n2.
+const reloadTestConfig2 = `
+hosts:
+  - {name: h1, master: 127.0.0.1:3306, user: root}
+nodes:
+  - {name: n1, host: h1, database: db1_01}
+  - {name: n3, host: h1, database: db1_03}
+schemas:
+  - {name: db1, user: u1, shard_key: id, shard_algo: mod, nodes: [n1, n3]}
+`
+
+// newReloadTestServer create server of config, without listening.
+func newReloadTestServer(t testing.TB) *Server {
+	cfg, err := config.ParseConfigData([]byte(reloadTestConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	top := &topology{cfg: cfg}
+	if top.hosts, err = parseHosts(cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+	if top.nodes, err = parseNodes(cfg, top.hosts, nil); err != nil {
+		t.Fatal(err)
+	}
+	if top.schemas, err = parseSchemas(cfg, top.nodes); err != nil {
+		t.Fatal(err)
+	}
+	top.shardMaps = route.NewShardMaps(top.schemas)
+	top.nodeGroups = route.NewNodeGroups(top.schemas)
+	top.migrationCounters = newMigrationCounters(top.schemas, nil)
+	p := new(Server)
+	p.topology.Store(top)
+	p.stmtMetas = newStmtMetaCache(DefaultStmtMetaCacheSize)
+	return p
+}
+
+// TestReloadTopology nodes of schemas and shard maps are always in nodes of the same topology, while reloading concurrently.
+func TestReloadTopology(t *testing.T) {
+	p := newReloadTestServer(t)
+	var configs []*config.Config
+	for _, data := range []string{reloadTestConfig2, reloadTestConfig} {
+		cfg, err := config.ParseConfigData([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		configs = append(configs, cfg)
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				top := p.getTopology()
+				for _, nodeName := range top.schemas["db1"].Nodes {
+					if top.nodes[nodeName] == nil {
+						t.Errorf("version %d: node %s of schema not in topology", top.version, nodeName)
+						return
+					}
+				}
+				for _, nodeName := range top.shardMaps.Get("db1").Active.Nodes {
+					if top.nodes[nodeName] == nil {
+						t.Errorf("version %d: node %s of shard map not in topology", top.version, nodeName)
+						return
+					}
+				}
+				if top.migrationCounters["db1"] == nil {
+					t.Errorf("version %d: no migration counter of schema", top.version)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		if err := p.reload(configs[i%2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	top := p.getTopology()
+	if top.version != 100 || top.nodes["n2"] == nil || top.nodes["n3"] != nil {
+		t.Errorf("expect version 100 with nodes of first config, got version %d", top.version)
+	}
+}
+
+// BenchmarkGetTopology look up schema and node concurrently, as sessions do for each statement.
+func BenchmarkGetTopology(b *testing.B) {
+	p := newReloadTestServer(b)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if p.GetSchemaConfig("db1") == nil || p.GetDataNodes()["n1"] == nil {
+				b.Error("schema or node not found")
+				return
+			}
+		}
+	})
+}
+
+// TestReloadSchemaDriftCheck schema drift check is started, restarted and stopped by reload.
+func TestReloadSchemaDriftCheck(t *testing.T) {
+	p := newReloadTestServer(t)
+	p.running = true
+	reload := func(checkTime string) chan struct{} {
+		cfg, err := config.ParseConfigData([]byte(reloadTestConfig))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.SchemaDriftCheckTime = checkTime
+		if err = p.reload(cfg); err != nil {
+			t.Fatal(err)
+		}
+		if p.getConfig().SchemaDriftCheckTime != checkTime {
+			t.Fatalf("expect schema_drift_check_time '%s' reloaded", checkTime)
+		}
+		return p.schemaDriftStop
+	}
+	stopped := func(stop chan struct{}) bool {
+		select {
+		case <-stop:
+			return true
+		default:
+			return false
+		}
+	}
+
+	first := reload("03:00")
+	if first == nil {
+		t.Fatal("expect schema drift check started")
+	}
+	if reload("03:00") != first || stopped(first) {
+		t.Fatal("expect schema drift check kept if check time is unchanged")
+	}
+	second := reload("04:00")
+	if second == nil || second == first || !stopped(first) {
+		t.Fatal("expect schema drift check restarted if check time is changed")
+	}
+	if reload("") != nil || !stopped(second) {
+		t.Fatal("expect schema drift check stopped if disabled")
+	}
+}
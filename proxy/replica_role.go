@@ -0,0 +1,72 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"strings"
+
+	"github.com/berkaroad/saashard/backend"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/statistic"
+)
+
+// ReplicaRoleVariable is session variable of role of slaves that reads are on, such as 'SET saashard_replica_role=reporting'.
+const ReplicaRoleVariable = "saashard_replica_role"
+
+// ReplicaRoleAnnotation is annotation of role of slaves that reads of query are on, such as '/*replica_role=reporting*/'.
+const ReplicaRoleAnnotation = "replica_role"
+
+// setReplicaRoleVariable set replica role variable in 'SET' statement, and remove it from statement.
+func (c *ClientConn) setReplicaRoleVariable(statement *sqlparser.SetVariable) error {
+	exprs := make(sqlparser.UpdateExprs, 0, len(statement.Exprs))
+	for _, expr := range statement.Exprs {
+		name := strings.ToLower(strings.TrimLeft(string(expr.Name.Name), "@"))
+		if len(expr.Name.Qualifier) > 0 || name != ReplicaRoleVariable {
+			exprs = append(exprs, expr)
+			continue
+		}
+		value := strings.Trim(sqlparser.String(expr.Expr), "'\"")
+		if strings.ToLower(value) == "default" || strings.ToLower(value) == "null" {
+			value = c.proxy.getConfig().UserReplicaRoles[c.user]
+		}
+		if len(value) > 0 && !statistic.IsLabelValue(value) {
+			return mysql.NewDefaultError(mysql.ER_WRONG_VALUE_FOR_VAR, ReplicaRoleVariable, value)
+		}
+		c.replicaRole = value
+	}
+	statement.Exprs = exprs
+	return nil
+}
+
+// readRole get role of slaves that reads of node are on, empty if host has no slave of the role online.
+func (c *ClientConn) readRole(node *backend.DataNode) string {
+	role := c.queryRole
+	if len(role) == 0 {
+		role = c.replicaRole
+	}
+	if len(role) == 0 || !node.DataHost.HasRoleSlave(role) {
+		return ""
+	}
+	return role
+}
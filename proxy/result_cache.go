@@ -0,0 +1,301 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// Defaults of result cache.
+const (
+	DefaultResultCacheSize         = 64 << 20
+	DefaultResultCacheMaxEntrySize = 1 << 20
+)
+
+// funcs that results of select calling them couldn't be cached, as they're not the same by time or session.
+var uncachedFuncs = map[string]bool{
+	"now": true, "sysdate": true, "current_timestamp": true, "localtime": true, "localtimestamp": true,
+	"curdate": true, "current_date": true, "curtime": true, "current_time": true, "unix_timestamp": true,
+	"utc_date": true, "utc_time": true, "utc_timestamp": true, "rand": true, "uuid": true, "uuid_short": true,
+	"connection_id": true, "last_insert_id": true, "found_rows": true, "row_count": true, "user": true,
+	"current_user": true, "session_user": true, "system_user": true, "database": true, "schema": true,
+	"sleep": true, "benchmark": true, "get_lock": true, "release_lock": true, "is_free_lock": true,
+	"is_used_lock": true, "nextval": true,
+}
+
+// resultCache cache results of select by schema, nodes, sql and args of prepared stmt, shared by all client connections.
+// Results expire after ttl, and are invalidated by writes to their tables through proxy, or by DDL of their schema.
+type resultCache struct {
+	sync.Mutex
+	size         int64
+	maxSize      int64
+	maxEntrySize int64
+	entries      map[string]*list.Element
+	lru          *list.List
+	// versions of schemas and tables, increased by writes, so results read before are stale.
+	versions map[string]uint64
+}
+
+type resultCacheEntry struct {
+	key      string
+	result   *mysql.Result
+	size     int64
+	expires  time.Time
+	tables   []string
+	versions []uint64
+}
+
+// resultCacheQuery is select of session to be cached after executed, if not found in result cache.
+type resultCacheQuery struct {
+	key      string
+	ttl      time.Duration
+	tables   []string
+	versions []uint64
+	result   *mysql.Result // copied before written to client.
+}
+
+func newResultCache(cfg config.ResultCacheConfig) *resultCache {
+	c := new(resultCache)
+	c.maxSize = cfg.Size
+	if c.maxSize <= 0 {
+		c.maxSize = DefaultResultCacheSize
+	}
+	c.maxEntrySize = cfg.MaxEntrySize
+	if c.maxEntrySize <= 0 {
+		c.maxEntrySize = DefaultResultCacheMaxEntrySize
+	}
+	c.entries = make(map[string]*list.Element)
+	c.lru = list.New()
+	c.versions = make(map[string]uint64)
+	return c
+}
+
+// get copy of result cached, nil if not found, expired or tables written after it's read.
+func (c *resultCache) get(key string) *mysql.Result {
+	c.Lock()
+	defer c.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*resultCacheEntry)
+	if time.Now().After(entry.expires) || !c.isFresh(entry.tables, entry.versions) {
+		c.remove(elem)
+		return nil
+	}
+	c.lru.MoveToFront(elem)
+	return entry.result.Clone()
+}
+
+// tableVersions get versions of tables, before select is executed.
+func (c *resultCache) tableVersions(tables []string) []uint64 {
+	c.Lock()
+	defer c.Unlock()
+	versions := make([]uint64, len(tables))
+	for i, table := range tables {
+		versions[i] = c.versions[table]
+	}
+	return versions
+}
+
+func (c *resultCache) isFresh(tables []string, versions []uint64) bool {
+	for i, table := range tables {
+		if c.versions[table] != versions[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// put result of query, not cached if tables are written while it's executed, or it's too large.
+func (c *resultCache) put(q *resultCacheQuery) {
+	size := int64(len(q.key))
+	for _, f := range q.result.Fields {
+		size += int64(len(f.Dump()))
+	}
+	for _, row := range q.result.Rows {
+		size += int64(len(row.Dump()))
+	}
+	if size > c.maxEntrySize {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	if !c.isFresh(q.tables, q.versions) {
+		return
+	}
+	if elem, ok := c.entries[q.key]; ok {
+		c.remove(elem)
+	}
+	entry := &resultCacheEntry{key: q.key, result: q.result, size: size, expires: time.Now().Add(q.ttl),
+		tables: q.tables, versions: q.versions}
+	c.entries[q.key] = c.lru.PushFront(entry)
+	c.size += size
+	for c.size > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *resultCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*resultCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// invalidate results of schemas or tables, entries are removed lazily.
+func (c *resultCache) invalidate(tables []string) {
+	c.Lock()
+	defer c.Unlock()
+	for _, table := range tables {
+		c.versions[table]++
+	}
+}
+
+// resultCacheTable is key of table in result cache, key of schema is name of schema.
+func resultCacheTable(schema string, table *sqlparser.TableName) string {
+	if len(table.Qualifier) > 0 {
+		schema = strings.Trim(string(table.Qualifier), "`")
+	}
+	return schema + "." + strings.ToLower(strings.Trim(string(table.Name), "`"))
+}
+
+// stmtTables get keys of tables in statement, including those in subqueries.
+func (c *ClientConn) stmtTables(stmt sqlparser.Statement) []string {
+	var tables []string
+	sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if table, ok := node.(*sqlparser.TableName); ok {
+			tables = append(tables, resultCacheTable(c.db, table))
+		}
+		return true, nil
+	}, stmt)
+	return tables
+}
+
+// resultCacheTTL get ttl of select, the least result_cache_ttl of its tables, or of schema if not set for table.
+// It's 0 if any table is not cached, or select couldn't be cached,
+// such as in transaction, locking read, or calling functions not the same by time or session.
+func (c *ClientConn) resultCacheTTL(stmt sqlparser.Statement) time.Duration {
+	schemaConfig := c.schemas[c.db]
+	selectStmt, ok := stmt.(*sqlparser.Select)
+	if !ok || schemaConfig == nil || c.isInTransaction() || len(selectStmt.Lock) > 0 || selectStmt.CalcFoundRows {
+		return 0
+	}
+	tables := schemaConfig.GetTables()
+	ttl := 0
+	var uncached bool
+	sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch v := node.(type) {
+		case *sqlparser.TableName:
+			tableTTL := schemaConfig.ResultCacheTTL
+			if table := tables[strings.ToLower(strings.Trim(string(v.Name), "`"))]; table != nil && table.ResultCacheTTL != 0 {
+				tableTTL = table.ResultCacheTTL
+			}
+			if tableTTL <= 0 {
+				uncached = true
+			} else if ttl == 0 || tableTTL < ttl {
+				ttl = tableTTL
+			}
+		case *sqlparser.FuncExpr:
+			uncached = uncached || uncachedFuncs[strings.ToLower(string(v.Name))]
+		case *sqlparser.ColName:
+			name := strings.ToLower(string(v.Name))
+			// user variables, and current_timestamp without parentheses.
+			uncached = uncached || strings.HasPrefix(name, "@") || uncachedFuncs[name]
+		}
+		return !uncached, nil
+	}, stmt)
+	if uncached || ttl <= 0 {
+		return 0
+	}
+	return time.Duration(ttl) * time.Millisecond
+}
+
+// serveResultCache write result of select from result cache if found, or prepare to cache it after executed.
+// args is key of args of prepared stmt, empty for query.
+func (c *ClientConn) serveResultCache(stmt sqlparser.Statement, nodeNames []string, args string) (bool, error) {
+	c.cacheQuery = nil
+	ttl := c.resultCacheTTL(stmt)
+	if ttl <= 0 {
+		return false, nil
+	}
+	key := strings.Join([]string{c.db, strings.Join(nodeNames, ","), sqlparser.String(stmt), args}, "\x00")
+	cache := c.proxy.resultCache
+	if result := cache.get(key); result != nil {
+		if c.debug {
+			simplelog.Debug("%s %s connectionID=%d: result of %d rows served from result cache", "ClientConn",
+				"serveResultCache", c.connectionID, result.RowNumber())
+		}
+		c.trackResult(result)
+		return true, c.writeResultSet(c.status&^mysql.SERVER_MORE_RESULTS_EXISTS, result)
+	}
+	q := &resultCacheQuery{key: key, ttl: ttl, tables: append([]string{c.db}, c.stmtTables(stmt)...)}
+	q.versions = cache.tableVersions(q.tables)
+	c.cacheQuery = q
+	return false, nil
+}
+
+// storeResultCache cache result of select executed, if it's to be cached.
+func (c *ClientConn) storeResultCache(err error) {
+	q := c.cacheQuery
+	c.cacheQuery = nil
+	if q == nil || err != nil || q.result == nil {
+		return
+	}
+	c.proxy.resultCache.put(q)
+}
+
+// invalidateResultCache invalidate results of tables written by statements, or of schema by DDL,
+// once committed if in transaction.
+func (c *ClientConn) invalidateResultCache(stmts ...sqlparser.Statement) {
+	for _, stmt := range stmts {
+		switch stmt.(type) {
+		case *sqlparser.Insert, *sqlparser.Update, *sqlparser.Delete, *sqlparser.Replace:
+			c.writtenTables = append(c.writtenTables, c.stmtTables(stmt)...)
+		case sqlparser.DDLStatement, *sqlparser.Passthrough:
+			c.writtenTables = append(c.writtenTables, c.db)
+		}
+	}
+	if len(c.writtenTables) > 0 && !c.isInTransaction() {
+		c.proxy.resultCache.invalidate(c.writtenTables)
+		c.writtenTables = nil
+	}
+}
+
+// stmtArgsKey is key of args of prepared stmt in result cache.
+func stmtArgsKey(args []interface{}) string {
+	var b strings.Builder
+	b.WriteString("stmt")
+	for _, arg := range args {
+		fmt.Fprintf(&b, "\x00%T:%v", arg, arg)
+	}
+	return b.String()
+}
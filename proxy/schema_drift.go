@@ -0,0 +1,366 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/utils"
+	"github.com/berkaroad/saashard/utils/alert"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// Kinds of schema drift.
+const (
+	DriftMissingTable  = "missing_table"
+	DriftMissingColumn = "missing_column"
+	DriftExtraColumn   = "extra_column"
+	DriftColumn        = "column_definition"
+	DriftMissingIndex  = "missing_index"
+	DriftExtraIndex    = "extra_index"
+	DriftIndex         = "index_definition"
+)
+
+// SchemaDrift is a difference of table definition between a shard and the reference shard.
+type SchemaDrift struct {
+	Schema   string `json:"schema"`
+	Table    string `json:"table"`
+	Node     string `json:"node"`
+	RefNode  string `json:"ref_node"`
+	Kind     string `json:"kind"`
+	Object   string `json:"object"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// SchemaDriftReport is result of schema drift check.
+type SchemaDriftReport struct {
+	CheckedAt time.Time      `json:"checked_at"`
+	Tables    int            `json:"tables"`
+	Drifts    []*SchemaDrift `json:"drifts"`
+	Errors    []string       `json:"errors"`
+}
+
+// tableDefinition is columns and indexes of table in a shard.
+type tableDefinition struct {
+	columns     map[string]string
+	columnOrder []string
+	indexes     map[string]string
+}
+
+func newTableDefinition() *tableDefinition {
+	t := new(tableDefinition)
+	t.columns = make(map[string]string)
+	t.indexes = make(map[string]string)
+	return t
+}
+
+const (
+	sqlDriftColumns = "SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, IFNULL(COLUMN_DEFAULT, 'NULL'), EXTRA " +
+		"FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = %s ORDER BY TABLE_NAME, ORDINAL_POSITION"
+	sqlDriftIndexes = "SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, INDEX_TYPE, " +
+		"GROUP_CONCAT(COLUMN_NAME ORDER BY SEQ_IN_INDEX SEPARATOR ',') " +
+		"FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = %s GROUP BY TABLE_NAME, INDEX_NAME, NON_UNIQUE, INDEX_TYPE"
+)
+
+// GetSchemaDriftReport get report of last schema drift check, nil if never checked.
+func (p *Server) GetSchemaDriftReport() *SchemaDriftReport {
+	p.Lock()
+	defer p.Unlock()
+	return p.schemaDriftReport
+}
+
+// CheckSchemaDrift compare table definitions across all shards of sharded schemas, or the schema if specified.
+// Tables of single type are skipped, and the first shard having the table is the reference.
+func (p *Server) CheckSchemaDrift(schemaName string) *SchemaDriftReport {
+	report := new(SchemaDriftReport)
+	report.CheckedAt = time.Now()
+	report.Drifts = []*SchemaDrift{}
+	report.Errors = []string{}
+
+	schemaNames := make([]string, 0, len(p.GetSchemaConfigs()))
+	for name, schemaConfig := range p.GetSchemaConfigs() {
+		if schemaConfig.ShardEnabled() && (len(schemaName) == 0 || name == schemaName) {
+			schemaNames = append(schemaNames, name)
+		}
+	}
+	sort.Strings(schemaNames)
+	for _, name := range schemaNames {
+		p.checkSchemaDrift(name, report)
+	}
+
+	p.counter.IncrSchemaDriftChecks(len(report.Drifts), len(report.Errors))
+	p.Lock()
+	p.schemaDriftReport = report
+	p.Unlock()
+	return report
+}
+
+func (p *Server) checkSchemaDrift(schemaName string, report *SchemaDriftReport) {
+	schemaConfig := p.GetSchemaConfig(schemaName)
+	nodeNames := append([]string(nil), schemaConfig.Nodes...)
+	// staged shard location should be same too.
+	if m := p.ShardMaps().Get(schemaName); m != nil && m.Staged != nil {
+		for _, nodeName := range m.Staged.Nodes {
+			if !utils.Contains(nodeNames, nodeName) {
+				nodeNames = append(nodeNames, nodeName)
+			}
+		}
+	}
+
+	definitions := make(map[string]map[string]*tableDefinition, len(nodeNames))
+	checkedNodes := make([]string, 0, len(nodeNames))
+	for _, nodeName := range nodeNames {
+		tables, err := p.loadTableDefinitions(nodeName)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s.%s: %s", schemaName, nodeName, err.Error()))
+			continue
+		}
+		definitions[nodeName] = tables
+		checkedNodes = append(checkedNodes, nodeName)
+	}
+
+	tableNames := driftTableNames(schemaConfig, definitions)
+	report.Tables += len(tableNames)
+	for _, tableName := range tableNames {
+		var refNode string
+		var ref *tableDefinition
+		for _, nodeName := range checkedNodes {
+			if t, ok := definitions[nodeName][tableName]; ok {
+				refNode, ref = nodeName, t
+				break
+			}
+		}
+		for _, nodeName := range checkedNodes {
+			if nodeName == refNode {
+				continue
+			}
+			newDrift := func(kind, object, expected, actual string) {
+				report.Drifts = append(report.Drifts, &SchemaDrift{
+					Schema: schemaName, Table: tableName, Node: nodeName, RefNode: refNode,
+					Kind: kind, Object: object, Expected: expected, Actual: actual,
+				})
+			}
+			t, ok := definitions[nodeName][tableName]
+			if !ok {
+				newDrift(DriftMissingTable, tableName, "", "")
+				continue
+			}
+			compareDefinitions(ref.columnOrder, ref.columns, t.columns, DriftMissingColumn, DriftExtraColumn, DriftColumn, newDrift)
+			compareDefinitions(sortedKeys(ref.indexes), ref.indexes, t.indexes, DriftMissingIndex, DriftExtraIndex, DriftIndex, newDrift)
+		}
+	}
+}
+
+// driftTableNames get tables to check, configured tables except single ones, or all tables in shards if not configured.
+func driftTableNames(schemaConfig *config.SchemaConfig, definitions map[string]map[string]*tableDefinition) []string {
+	tableNames := make([]string, 0, 16)
+	if tables := schemaConfig.GetTables(); len(tables) > 0 {
+		for name, table := range tables {
+			if table.GetType() != config.TableTypeSingle {
+				tableNames = append(tableNames, strings.ToLower(name))
+			}
+		}
+	} else {
+		for _, tables := range definitions {
+			for name := range tables {
+				if !utils.Contains(tableNames, name) {
+					tableNames = append(tableNames, name)
+				}
+			}
+		}
+	}
+	sort.Strings(tableNames)
+	return tableNames
+}
+
+func compareDefinitions(refNames []string, ref, actual map[string]string, missingKind, extraKind, diffKind string,
+	newDrift func(kind, object, expected, actual string)) {
+	for _, name := range refNames {
+		if def, ok := actual[name]; !ok {
+			newDrift(missingKind, name, ref[name], "")
+		} else if def != ref[name] {
+			newDrift(diffKind, name, ref[name], def)
+		}
+	}
+	for _, name := range sortedKeys(actual) {
+		if _, ok := ref[name]; !ok {
+			newDrift(extraKind, name, "", actual[name])
+		}
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// loadTableDefinitions load columns and indexes of all tables in data node, table name is lower case.
+func (p *Server) loadTableDefinitions(nodeName string) (map[string]*tableDefinition, error) {
+	node := p.GetDataNodes()[nodeName]
+	if node == nil {
+		return nil, fmt.Errorf("data node not exists")
+	}
+	conn, err := node.DataHost.GetMaster().GetConnection(node.Database)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.ReturnConnection()
+	mysqlConn := conn.(*mysqlBackend.Conn)
+	database := mysqlBackend.QuoteString(node.Database)
+
+	tables := make(map[string]*tableDefinition)
+	table := func(name string) *tableDefinition {
+		name = strings.ToLower(name)
+		t := tables[name]
+		if t == nil {
+			t = newTableDefinition()
+			tables[name] = t
+		}
+		return t
+	}
+
+	result, err := mysqlConn.Query(fmt.Sprintf(sqlDriftColumns, database))
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < result.RowNumber(); i++ {
+		values := make([]string, 6)
+		for j := range values {
+			values[j], _ = result.GetString(i, j)
+		}
+		t := table(values[0])
+		column := strings.ToLower(values[1])
+		t.columns[column] = strings.Join(values[2:], " ")
+		t.columnOrder = append(t.columnOrder, column)
+	}
+	// position of column is part of definition.
+	for _, t := range tables {
+		for i, column := range t.columnOrder {
+			t.columns[column] = fmt.Sprintf("#%d %s", i+1, t.columns[column])
+		}
+	}
+
+	result, err = mysqlConn.Query(fmt.Sprintf(sqlDriftIndexes, database))
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < result.RowNumber(); i++ {
+		values := make([]string, 5)
+		for j := range values {
+			values[j], _ = result.GetString(i, j)
+		}
+		unique := "UNIQUE"
+		if values[2] == "1" {
+			unique = "NON_UNIQUE"
+		}
+		table(values[0]).indexes[strings.ToLower(values[1])] = fmt.Sprintf("%s %s (%s)", unique, values[3], strings.ToLower(values[4]))
+	}
+	return tables, nil
+}
+
+// startSchemaDriftCheck start schema drift check if schema_drift_check_time is changed, the running one is stopped,
+// none is started if disabled. It's called with reloadLock held.
+func (p *Server) startSchemaDriftCheck(cfg *config.Config) {
+	if cfg.SchemaDriftCheckTime == p.schemaDriftCheckTime {
+		return
+	}
+	if p.schemaDriftStop != nil {
+		close(p.schemaDriftStop)
+		p.schemaDriftStop = nil
+	}
+	p.schemaDriftCheckTime = cfg.SchemaDriftCheckTime
+	if len(cfg.SchemaDriftCheckTime) > 0 {
+		p.schemaDriftStop = make(chan struct{})
+		go p.runSchemaDriftCheck(cfg.SchemaDriftCheckTime, p.schemaDriftStop)
+	}
+}
+
+// runSchemaDriftCheck check schema drift at check time everyday, and post report to webhook of current config if drifted,
+// until stopped.
+func (p *Server) runSchemaDriftCheck(schemaDriftCheckTime string, stop chan struct{}) {
+	checkTime, err := time.Parse("15:04", schemaDriftCheckTime)
+	if err != nil {
+		simplelog.Error("%s %s %s schema_drift_check_time=%s", "proxy", "runSchemaDriftCheck", err.Error(),
+			schemaDriftCheckTime)
+		return
+	}
+	for p.running {
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), checkTime.Hour(), checkTime.Minute(), 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		cfg := p.getConfig()
+		report := p.CheckSchemaDrift("")
+		if len(report.Drifts) > 0 || len(report.Errors) > 0 {
+			alert.Emit(alert.EventSchemaDrift, alert.SeverityWarning, "schemas",
+				fmt.Sprintf("schema drift detected, %d drifts and %d errors in %d tables", len(report.Drifts), len(report.Errors), report.Tables),
+				map[string]string{"drifts": strconv.Itoa(len(report.Drifts)), "errors": strconv.Itoa(len(report.Errors))})
+			if len(cfg.SchemaDriftWebhook) > 0 {
+				if err = postWebhook(cfg.SchemaDriftWebhook, report); err != nil {
+					simplelog.Error("%s %s %s webhook=%s", "proxy", "runSchemaDriftCheck", err.Error(), cfg.SchemaDriftWebhook)
+				}
+			}
+		}
+	}
+}
+
+// postWebhook post body as json to url.
+func postWebhook(url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook response status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/route"
+	"github.com/berkaroad/saashard/sequence"
+)
+
+// openSequence open sequence of the type, snowflake by server_id, or segments allocated from table of data node.
+func (p *Server) openSequence() (route.Sequence, error) {
+	cfg := p.getConfig()
+	switch cfg.Sequence.GetType() {
+	case config.SequenceTypeSnowflake:
+		return sequence.NewSnowflake(cfg.ServerID), nil
+	case config.SequenceTypeSegment:
+		node := p.GetDataNodes()[cfg.Sequence.Node]
+		if node == nil {
+			return nil, fmt.Errorf("data node '%s' of sequence not exists", cfg.Sequence.Node)
+		}
+		return sequence.OpenSegments(node, strings.TrimSpace(cfg.Sequence.Table), cfg.Sequence.GetStep())
+	}
+	return nil, fmt.Errorf("sequence type '%s' not supported", cfg.Sequence.Type)
+}
@@ -0,0 +1,76 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// SessionInfo is state of client session.
+type SessionInfo struct {
+	ConnectionID uint32
+	User         string
+	DB           string
+	ClientAddr   string
+	Tag          string
+	State        string // idle, busy (executing or in transaction) or closing.
+	Draining     bool
+	DelayedRead  bool // reads routed to slaves are on delayed slaves.
+}
+
+// GetSessions get state of all client sessions, sorted by connection id.
+func (p *Server) GetSessions() []*SessionInfo {
+	conns := p.conns.list()
+	sessions := make([]*SessionInfo, 0, len(conns))
+	for _, c := range conns {
+		info := new(SessionInfo)
+		info.ConnectionID = c.connectionID
+		info.User = c.user
+		info.ClientAddr = c.c.RemoteAddr().String()
+		info.State = sessionStateNames[atomic.LoadInt32(&c.state)]
+		info.Draining = atomic.LoadInt32(&c.draining) == 1
+		info.DelayedRead = c.isDelayedRead()
+		c.Lock()
+		info.DB = c.db
+		info.Tag = c.tag
+		c.Unlock()
+		sessions = append(sessions, info)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ConnectionID < sessions[j].ConnectionID })
+	return sessions
+}
+
+// SetDelayedRead route reads of session to delayed slaves for data rescue, or back to slaves, false if not exists.
+func (p *Server) SetDelayedRead(connectionID uint32, on bool) bool {
+	c := p.conns.get(connectionID)
+	if c == nil {
+		return false
+	}
+	var value int32
+	if on {
+		value = 1
+	}
+	atomic.StoreInt32(&c.delayedRead, value)
+	return true
+}
@@ -0,0 +1,82 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/berkaroad/saashard/route"
+	"github.com/berkaroad/saashard/utils/simplelog"
+	"github.com/berkaroad/saashard/utils/slowlog"
+)
+
+// logSlowQuery write query to slow log if it's slower than slow_log_time, with sql and backend of each data node.
+func (c *ClientConn) logSlowQuery(sql string, startTime time.Time, onSlave bool, err error, labels string, shards []slowlog.Shard) {
+	if !slowlog.Enabled() {
+		return
+	}
+	queryTime := time.Since(startTime)
+	if queryTime < time.Duration(c.proxy.slowLogTime[c.proxy.slowLogTimeIndex])*time.Millisecond {
+		return
+	}
+	clientIP, _, _ := net.SplitHostPort(c.c.RemoteAddr().String())
+	e := &slowlog.Entry{
+		Time:         startTime,
+		User:         c.user,
+		ClientIP:     clientIP,
+		ConnectionID: c.connectionID,
+		DB:           c.db,
+		QueryTime:    queryTime,
+		RowsSent:     c.rowsSent,
+		RowsAffected: c.rowsAffected,
+		OnSlave:      onSlave,
+		Failed:       err != nil,
+		Labels:       labels,
+		Shards:       shards,
+		SQL:          sql,
+	}
+	if err := slowlog.Write(e); err != nil {
+		simplelog.Error("%s %s %s connectionID=%d", "ClientConn", "logSlowQuery", err.Error(), c.connectionID)
+	}
+}
+
+// planShards get sql and backend of each data node of plan, backend is unknown if not one per node.
+func planShards(plan route.Route, backendConnAddrs []string) []slowlog.Shard {
+	nodeNames := plan.GetNodeNames()
+	shards := make([]slowlog.Shard, 0, len(nodeNames))
+	for i, nodeName := range nodeNames {
+		shard := slowlog.Shard{Node: nodeName, SQL: plan.GetNodeSQL(nodeName)}
+		switch len(backendConnAddrs) {
+		case len(nodeNames):
+			shard.Backend = backendConnAddrs[i]
+		case 1:
+			shard.Backend = backendConnAddrs[0]
+		default:
+			shard.Backend = strings.Join(backendConnAddrs, ",")
+		}
+		shards = append(shards, shard)
+	}
+	return shards
+}
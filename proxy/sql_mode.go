@@ -0,0 +1,79 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"strings"
+
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// sessionSQLMode get sql_mode of session, set by session or default of schema.
+func (c *ClientConn) sessionSQLMode() string {
+	if c.sqlModeSet {
+		return c.sqlMode
+	}
+	if schemaConfig := c.schemas[c.db]; schemaConfig != nil {
+		if sqlMode := schemaConfig.GetSQLMode(); len(sqlMode) > 0 {
+			return sqlMode
+		}
+	}
+	return mysqlBackend.DefaultSQLMode
+}
+
+// setSQLModeVariable set sql_mode of session in 'SET' statement, and remove it from statement.
+// Value is evaluated by backend conn, and it can't remove required modes of schema.
+func (c *ClientConn) setSQLModeVariable(statement *sqlparser.SetVariable, conn *mysqlBackend.Conn) error {
+	exprs := make(sqlparser.UpdateExprs, 0, len(statement.Exprs))
+	for _, expr := range statement.Exprs {
+		name := string(expr.Name.Name)
+		if len(expr.Name.Qualifier) > 0 {
+			name = string(expr.Name.Qualifier) + "." + name
+		}
+		name = strings.ToLower(strings.TrimLeft(name, "@"))
+		name = strings.TrimPrefix(strings.TrimPrefix(name, "session."), "local.")
+		if name != "sql_mode" {
+			exprs = append(exprs, expr)
+			continue
+		}
+		sqlMode, err := conn.EvalSQLMode(sqlparser.String(expr.Expr))
+		if err != nil {
+			return err
+		}
+		if schemaConfig := c.schemas[c.db]; schemaConfig != nil {
+			if missing := schemaConfig.MissingSQLModes(sqlMode); len(missing) > 0 {
+				// restore sql_mode of backend conn.
+				conn.SetSQLMode(c.sessionSQLMode())
+				return mysql.NewError(mysql.ER_WRONG_VALUE_FOR_VAR,
+					fmt.Sprintf("Variable 'sql_mode' can't be set to the value of '%s', required modes %s are missing", sqlMode, strings.Join(missing, ",")))
+			}
+		}
+		c.sqlMode = sqlMode
+		c.sqlModeSet = true
+	}
+	statement.Exprs = exprs
+	return nil
+}
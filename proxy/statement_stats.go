@@ -0,0 +1,59 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"strings"
+	"time"
+
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// statementsFingerprint get fingerprint of statements of query joined by '; ', values replaced by '?'.
+// It's empty if no statement is parsed, such as statements passed through to unsharded schema.
+func statementsFingerprint(stmts []sqlparser.Statement) string {
+	fingerprints := make([]string, 0, len(stmts))
+	for _, stmt := range stmts {
+		if _, ok := stmt.(*sqlparser.Passthrough); ok {
+			continue
+		}
+		fingerprints = append(fingerprints, sqlparser.Fingerprint(stmt))
+	}
+	return strings.Join(fingerprints, "; ")
+}
+
+// statementRecorder record statistics of statements of fingerprint in schema when query is finished,
+// with bytes of query or args of prepared statement received, and bytes sent to client since it's created.
+func (c *ClientConn) statementRecorder(schemaName, fingerprint string, received int) func(err error) {
+	startTime := time.Now()
+	bytesWritten := c.pkg.BytesWritten
+	return func(err error) {
+		var digest string
+		if len(fingerprint) > 0 {
+			digest = sqlparser.FingerprintDigest(fingerprint)
+		}
+		execTime := float64(time.Since(startTime).Nanoseconds()) / float64(time.Millisecond)
+		c.proxy.counter.RecordStatement(schemaName, digest, fingerprint, execTime, err != nil,
+			int64(received), int64(c.pkg.BytesWritten-bytesWritten))
+	}
+}
@@ -0,0 +1,112 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+// DefaultStmtMetaCacheSize is max count of prepared stmt metadata cached by proxy.
+var DefaultStmtMetaCacheSize = 1024
+
+// stmtMeta is params and columns of prepared stmt, all shards of schema have the same.
+type stmtMeta struct {
+	paramNum  int
+	params    []*mysql.Field
+	columnNum int
+	columns   []*mysql.Field
+}
+
+// stmtMetaCache cache metadata of prepared stmt by schema and sql, shared by all client connections.
+// So that prepare from client is sent to one shard only once, and stmt is prepared in shards lazily when execute.
+type stmtMetaCache struct {
+	sync.RWMutex
+	size  int
+	metas map[string]*stmtMeta
+}
+
+func newStmtMetaCache(size int) *stmtMetaCache {
+	c := new(stmtMetaCache)
+	c.size = size
+	c.metas = make(map[string]*stmtMeta)
+	return c
+}
+
+func stmtMetaKey(schema, query string) string {
+	return schema + "\x00" + query
+}
+
+func (c *stmtMetaCache) get(schema, query string) *stmtMeta {
+	c.RLock()
+	defer c.RUnlock()
+	return c.metas[stmtMetaKey(schema, query)]
+}
+
+func (c *stmtMetaCache) put(schema, query string, meta *stmtMeta) {
+	c.Lock()
+	defer c.Unlock()
+	// simply clear all when it's full, prepared stmts are usually few.
+	if c.size > 0 && len(c.metas) >= c.size {
+		c.metas = make(map[string]*stmtMeta)
+	}
+	c.metas[stmtMetaKey(schema, query)] = meta
+}
+
+// invalidate metadata of schema, such as after DDL.
+func (c *stmtMetaCache) invalidate(schema string) {
+	c.Lock()
+	defer c.Unlock()
+	prefix := stmtMetaKey(schema, "")
+	for key := range c.metas {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.metas, key)
+		}
+	}
+}
+
+// newStmtMeta copy metadata from stmt prepared in backend.
+func newStmtMeta(s *mysql.Stmt) *stmtMeta {
+	meta := new(stmtMeta)
+	meta.paramNum = s.ParamNum
+	meta.params = s.Params
+	meta.columnNum = s.ColumnNum
+	meta.columns = s.Columns
+	return meta
+}
+
+// apply metadata to client stmt, fields are copied as schema of columns is changed.
+func (meta *stmtMeta) apply(s *mysql.Stmt, schema string) {
+	s.ParamNum = meta.paramNum
+	s.Params = meta.params
+	s.ColumnNum = meta.columnNum
+	s.Columns = make([]*mysql.Field, len(meta.columns))
+	for i, f := range meta.columns {
+		column := *f
+		column.Schema = []byte(schema)
+		column.Data = nil
+		s.Columns[i] = &column
+	}
+}
@@ -0,0 +1,395 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berkaroad/saashard/backend"
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/utils/simplelog"
+	"github.com/berkaroad/saashard/xa"
+)
+
+// transBranch is backend conn of a node enlisted in transaction across nodes,
+// it's a local transaction if xid is empty, such as in best effort mode.
+type transBranch struct {
+	node *backend.DataNode
+	conn *mysqlBackend.Conn
+	xid  xa.Xid
+}
+
+func (b *transBranch) isXA() bool {
+	return len(b.xid.Gtrid) > 0
+}
+
+// distTrans is transaction across nodes, begun by BEGIN when trans_mode of xa is not off.
+// Nodes are enlisted as branches at first touch.
+type distTrans struct {
+	mode     string
+	gtrid    string
+	fallback bool
+	lazy     bool           // the first branch is local, upgraded to XA when the second node is enlisted.
+	branches []*transBranch // in order of enlisted, a branch per backend conn.
+}
+
+// localBranches get count of local branches, not XA.
+func (t *distTrans) localBranches() int {
+	count := 0
+	for _, branch := range t.branches {
+		if !branch.isXA() {
+			count++
+		}
+	}
+	return count
+}
+
+func (t *distTrans) nodeNames(branches []*transBranch) []string {
+	names := make([]string, len(branches))
+	for i, branch := range branches {
+		names[i] = branch.node.Name
+	}
+	return names
+}
+
+// handleDistTrans begin, commit or rollback transaction across nodes, false if not handled.
+func (c *ClientConn) handleDistTrans(statements []sqlparser.Statement) (bool, error) {
+	if len(statements) != 1 {
+		return false, nil
+	}
+	var err error
+	switch statements[0].(type) {
+	case *sqlparser.Begin:
+		if !c.beginDistTrans() {
+			return false, nil
+		}
+		c.status |= mysql.SERVER_STATUS_IN_TRANS
+	case *sqlparser.Commit:
+		if c.trans == nil {
+			return false, nil
+		}
+		err = c.commitDistTrans()
+		c.endDistTrans()
+		if err == nil {
+			c.flushMirrorWrites()
+		} else {
+			c.discardMirrorWrites()
+		}
+	case *sqlparser.Rollback:
+		if c.trans == nil {
+			return false, nil
+		}
+		err = c.rollbackBranches(c.trans.branches)
+		c.endDistTrans()
+		c.discardMirrorWrites()
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+	c.status &= ^mysql.SERVER_MORE_RESULTS_EXISTS
+	return true, c.pkg.WriteOK(c.capability, c.status, nil)
+}
+
+// beginDistTrans begin transaction across nodes, false if in transaction or trans_mode of xa is off.
+func (c *ClientConn) beginDistTrans() bool {
+	xaConfig := c.proxy.getConfig().XA
+	if c.trans != nil || c.isInTransaction() || xaConfig.GetTransMode() == config.TransModeOff {
+		return false
+	}
+	c.trans = &distTrans{mode: xaConfig.GetTransMode(), fallback: xaConfig.FallbackBestEffort,
+		lazy: xaConfig.GetEnlistPolicy() == config.EnlistPolicyLazy}
+	if c.trans.mode == config.TransModeXA {
+		c.trans.gtrid = xa.NewGtrid(xaConfig.GetNamespace())
+	}
+	return true
+}
+
+// endDistTrans clear transaction across nodes.
+func (c *ClientConn) endDistTrans() {
+	c.trans = nil
+	c.nodeInTrans = nil
+	c.status &= ^mysql.SERVER_STATUS_IN_TRANS
+}
+
+// checkNodeInTrans check node could be used in transaction, any node in transaction across nodes.
+func (c *ClientConn) checkNodeInTrans(node *backend.DataNode) error {
+	if c.isInTransaction() && c.trans == nil && node != c.nodeInTrans {
+		return errors.ErrTransInMulti
+	}
+	return nil
+}
+
+// enlistInTrans enlist backend conn of node in transaction across nodes at first touch,
+// by 'XA START' in xa mode, or by 'BEGIN' in best effort mode or fallback.
+// In lazy policy the first branch is begun by 'BEGIN', and transaction is upgraded to XA when the second node
+// is enlisted, then the first branch is committed as the last resource after others prepared.
+func (c *ClientConn) enlistInTrans(node *backend.DataNode, conn backend.Connection) error {
+	t := c.trans
+	if t == nil {
+		return nil
+	}
+	mysqlConn := conn.(*mysqlBackend.Conn)
+	for _, branch := range t.branches {
+		if branch.conn == mysqlConn {
+			return nil
+		}
+	}
+	branch := &transBranch{node: node, conn: mysqlConn}
+	if t.mode == config.TransModeXA && (!t.lazy || len(t.branches) > 0) {
+		if t.lazy && len(t.branches) == 1 && c.debug {
+			simplelog.Debug("%s %s connectionID=%d: transaction upgraded to XA, gtrid=%s", "ClientConn", "enlistInTrans",
+				c.connectionID, t.gtrid)
+		}
+		xid := xa.Xid{Gtrid: t.gtrid, Bqual: node.Name, FormatID: 1}
+		if _, err := mysqlConn.Query("XA START " + xid.String()); err == nil {
+			branch.xid = xid
+		} else if !t.fallback || t.localBranches() > 0 {
+			// a single local branch is committed as the last resource, others couldn't be atomic with it.
+			return err
+		} else {
+			simplelog.Warn("%s %s connectionID=%d,node=%s,xid=%s: XA START failed, fallback to best effort: %s",
+				"ClientConn", "enlistInTrans", c.connectionID, node.Name, xid.String(), err.Error())
+		}
+	}
+	if !branch.isXA() {
+		// commit of the last resource is marked in its transaction, table is created before it's begun.
+		if t.mode == config.TransModeXA && c.proxy.xaLog != nil {
+			if err := c.proxy.xaCommitMarks.ensure(node); err != nil {
+				return err
+			}
+		}
+		if err := mysqlConn.Begin(); err != nil {
+			return err
+		}
+	}
+	t.branches = append(t.branches, branch)
+	if c.nodeInTrans == nil {
+		c.nodeInTrans = node
+	}
+	if c.debug {
+		simplelog.Debug("%s %s connectionID=%d: node %s enlisted in transaction, xid=%s", "ClientConn", "enlistInTrans",
+			c.connectionID, node.Name, branch.xid.String())
+	}
+	return nil
+}
+
+// commitDistTrans commit transaction across nodes.
+// Single XA branch is committed in one phase. Otherwise XA branches are prepared, and rolled back if any failed,
+// then the local branch is committed as the last resource, which decides the transaction. Decision is marked in
+// transaction of the local branch, so that it's durable atomically with the commit, and recovery commits prepared
+// branches by the mark. Without local branch, decision is logged before XA branches are committed.
+// In xa mode there is at most one local branch, other local branches are only in best effort mode.
+func (c *ClientConn) commitDistTrans() error {
+	t := c.trans
+	if len(t.branches) == 1 && t.branches[0].isXA() {
+		branch := t.branches[0]
+		if _, err := branch.conn.Query("XA END " + branch.xid.String()); err != nil {
+			c.rollbackBranches(t.branches)
+			return err
+		}
+		_, err := branch.conn.Query("XA COMMIT " + branch.xid.String() + " ONE PHASE")
+		return err
+	}
+
+	var xaBranches, localBranches []*transBranch
+	for _, branch := range t.branches {
+		if branch.isXA() {
+			xaBranches = append(xaBranches, branch)
+		} else {
+			localBranches = append(localBranches, branch)
+		}
+	}
+	xaLog := c.proxy.xaLog
+	if len(xaBranches) == 0 {
+		xaLog = nil
+	}
+	// owned by this process, so that prepared branches aren't resolved by recovery in progress.
+	// Node of the local branch is logged too, so that recovery checks commit mark in it.
+	if xaLog != nil {
+		if err := xaLog.Append(t.gtrid, xa.StateBegin, t.nodeNames(t.branches)); err != nil {
+			c.rollbackBranches(t.branches)
+			return err
+		}
+	}
+	for _, branch := range xaBranches {
+		_, err := branch.conn.Query("XA END " + branch.xid.String())
+		if err == nil {
+			_, err = branch.conn.Query("XA PREPARE " + branch.xid.String())
+		}
+		if err != nil {
+			c.rollbackBranches(t.branches)
+			c.appendXALog(xaLog, t.gtrid, xa.StateRollback, xa.StateDone)
+			return mysql.NewError(mysql.ER_XA_RBROLLBACK,
+				fmt.Sprintf("transaction rolled back, as branch of node %s failed to prepare: %s", branch.node.Name, err.Error()))
+		}
+	}
+
+	var committed, failed []string
+	var lastErr error
+	remaining := xaBranches
+	var lastResource *transBranch
+	if len(localBranches) > 0 {
+		lastResource = localBranches[0]
+		if err := c.commitLastResource(lastResource, xaLog); err != nil {
+			return err
+		}
+		committed = append(committed, lastResource.node.Name)
+		remaining = append(localBranches[1:], xaBranches...)
+	}
+	if xaLog != nil {
+		if err := xaLog.Append(t.gtrid, xa.StateCommit, nil); err != nil {
+			if lastResource == nil {
+				c.rollbackBranches(t.branches)
+				c.appendXALog(xaLog, t.gtrid, xa.StateRollback, xa.StateDone)
+				return err
+			}
+			// decided by commit mark of the last resource, so commit prepared branches anyway.
+			simplelog.Warn("%s %s connectionID=%d,gtrid=%s: commit decision not logged, decided by commit mark: %s",
+				"ClientConn", "commitDistTrans", c.connectionID, t.gtrid, err.Error())
+		}
+	}
+
+	xaFailed := false
+	for _, branch := range remaining {
+		var err error
+		if branch.isXA() {
+			_, err = branch.conn.Query("XA COMMIT " + branch.xid.String())
+		} else {
+			err = branch.conn.Commit()
+		}
+		if err == nil {
+			committed = append(committed, branch.node.Name)
+			continue
+		}
+		simplelog.Error("%s %s connectionID=%d,node=%s,xid=%s: %s", "ClientConn", "commitDistTrans",
+			c.connectionID, branch.node.Name, branch.xid.String(), err.Error())
+		failed = append(failed, branch.node.Name)
+		xaFailed = xaFailed || branch.isXA()
+		lastErr = err
+	}
+	if xaFailed {
+		msg := fmt.Sprintf("transaction partially committed, committed in nodes [%s], failed in nodes [%s]: %s, "+
+			"prepared branches will be committed by XA recovery", strings.Join(committed, ","), strings.Join(failed, ","),
+			lastErr.Error())
+		return mysql.NewError(mysql.ER_XAER_RMERR, msg)
+	}
+	// mark is kept until done, so that it's still found if proxy crashed before.
+	if lastResource != nil && xaLog != nil {
+		if err := xa.DeleteCommitMark(lastResource.conn, t.gtrid); err != nil {
+			simplelog.Warn("%s %s connectionID=%d,gtrid=%s: commit mark not deleted: %s", "ClientConn", "commitDistTrans",
+				c.connectionID, t.gtrid, err.Error())
+		}
+	}
+	c.appendXALog(xaLog, t.gtrid, xa.StateDone)
+	if len(failed) > 0 {
+		return mysql.NewError(mysql.ER_XAER_RMERR,
+			fmt.Sprintf("transaction partially committed, committed in nodes [%s], failed in nodes [%s]: %s",
+				strings.Join(committed, ","), strings.Join(failed, ","), lastErr.Error()))
+	}
+	return nil
+}
+
+// commitLastResource commit the local branch after XA branches prepared, with commit mark in its transaction if logged.
+// Others are rolled back if it's not committed. If outcome of commit is unknown and mark couldn't be checked,
+// prepared branches are left to recovery.
+func (c *ClientConn) commitLastResource(branch *transBranch, xaLog xa.Log) error {
+	t := c.trans
+	if xaLog != nil {
+		if _, err := branch.conn.Query(xa.CommitMarkSQL(t.gtrid, time.Now().Unix())); err != nil {
+			c.rollbackBranches(t.branches)
+			c.appendXALog(xaLog, t.gtrid, xa.StateRollback, xa.StateDone)
+			return err
+		}
+	}
+	err := branch.conn.Commit()
+	if err == nil {
+		return nil
+	}
+	simplelog.Error("%s %s connectionID=%d,node=%s: %s", "ClientConn", "commitLastResource",
+		c.connectionID, branch.node.Name, err.Error())
+	if xaLog != nil {
+		// commit may be applied though error is returned, such as connection lost, checked by mark.
+		var committed bool
+		checkErr := c.proxy.execInNode(branch.node.Name, func(conn *mysqlBackend.Conn) (err error) {
+			committed, err = xa.IsCommitMarked(conn, t.gtrid)
+			return
+		})
+		if checkErr != nil {
+			xaLog.Disown(t.gtrid)
+			return mysql.NewError(mysql.ER_XAER_RMERR,
+				fmt.Sprintf("outcome of transaction is unknown, as commit in node %s failed: %s, "+
+					"prepared branches will be resolved by XA recovery", branch.node.Name, err.Error()))
+		}
+		if committed {
+			return nil
+		}
+	}
+	c.rollbackBranches(t.branches)
+	c.appendXALog(xaLog, t.gtrid, xa.StateRollback, xa.StateDone)
+	return err
+}
+
+// rollbackBranches rollback branches, XA branch may be active or prepared.
+func (c *ClientConn) rollbackBranches(branches []*transBranch) error {
+	var firstErr error
+	for _, branch := range branches {
+		var err error
+		if branch.isXA() {
+			// failed if ended or prepared already.
+			branch.conn.Query("XA END " + branch.xid.String())
+			_, err = branch.conn.Query("XA ROLLBACK " + branch.xid.String())
+		} else {
+			err = branch.conn.Rollback()
+		}
+		if err != nil {
+			simplelog.Error("%s %s connectionID=%d,node=%s,xid=%s: %s", "ClientConn", "rollbackBranches",
+				c.connectionID, branch.node.Name, branch.xid.String(), err.Error())
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// appendXALog append states of transaction to log, if log is used.
+func (c *ClientConn) appendXALog(xaLog xa.Log, gtrid string, states ...string) {
+	if xaLog == nil {
+		return
+	}
+	for _, state := range states {
+		if err := xaLog.Append(gtrid, state, nil); err != nil {
+			simplelog.Error("%s %s connectionID=%d,gtrid=%s,state=%s: %s", "ClientConn", "appendXALog",
+				c.connectionID, gtrid, state, err.Error())
+			return
+		}
+	}
+}
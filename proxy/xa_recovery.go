@@ -0,0 +1,202 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/utils/simplelog"
+	"github.com/berkaroad/saashard/xa"
+)
+
+// openXALog open log of distributed transactions by storage of xa config.
+// File storage is log_file or xa.log in log_path, nil if neither is set.
+func (p *Server) openXALog() (xa.Log, error) {
+	cfg := p.getConfig()
+	switch storage := cfg.XA.GetLogStorage(); storage {
+	case xa.StorageFile:
+		path := cfg.XA.LogFile
+		if len(path) == 0 {
+			if len(cfg.LogPath) == 0 {
+				return nil, nil
+			}
+			path = filepath.Join(cfg.LogPath, "xa.log")
+		}
+		return xa.OpenFileLog(path, int64(cfg.XA.LogMaxSize)*1024*1024)
+	case xa.StorageTable:
+		node := p.GetDataNodes()[cfg.XA.LogNode]
+		if node == nil {
+			return nil, fmt.Errorf("data node '%s' of xa log not exists", cfg.XA.LogNode)
+		}
+		return xa.OpenTableLog(node, cfg.XA.LogTable)
+	default:
+		return nil, fmt.Errorf("xa log storage '%s' not supported", storage)
+	}
+}
+
+// XALog get log of distributed transactions, nil if not configured.
+func (p *Server) XALog() xa.Log {
+	return p.xaLog
+}
+
+// RecoverXA resolve dangling prepared transactions in namespace of this proxy, by 'XA RECOVER' in masters of all data hosts.
+// Transactions decided to commit are committed, in progress by this process are skipped, others are rolled back.
+// Transactions are marked as done in log, after all branches are resolved.
+func (p *Server) RecoverXA() []*xa.PreparedBranch {
+	namespace := p.getConfig().XA.GetNamespace()
+	hosts := p.GetDataHosts()
+	hostNames := make([]string, 0, len(hosts))
+	for name := range hosts {
+		hostNames = append(hostNames, name)
+	}
+	sort.Strings(hostNames)
+
+	// commit marks of the last resource, checked once per transaction.
+	marks := make(map[string]bool)
+	marked := func(entry *xa.Entry) (bool, error) {
+		if ok, checked := marks[entry.Gtrid]; checked {
+			return ok, nil
+		}
+		ok, err := p.isXACommitMarked(entry)
+		if err == nil {
+			marks[entry.Gtrid] = ok
+		}
+		return ok, err
+	}
+
+	var branches []*xa.PreparedBranch
+	scanned := true
+	unresolved := make(map[string]bool)
+	for _, hostName := range hostNames {
+		hostBranches, err := p.recoverXAInHost(hostName, namespace, marked)
+		if err != nil {
+			scanned = false
+			simplelog.Error("%s %s %s host=%s", "proxy", "RecoverXA", err.Error(), hostName)
+			branches = append(branches, &xa.PreparedBranch{Host: hostName, Err: err})
+			continue
+		}
+		for _, branch := range hostBranches {
+			if branch.Action == xa.ActionSkip || branch.Err != nil {
+				unresolved[branch.Xid.Gtrid] = true
+			}
+		}
+		branches = append(branches, hostBranches...)
+	}
+
+	if p.xaLog != nil && scanned {
+		for _, entry := range p.xaLog.Pending() {
+			if unresolved[entry.Gtrid] || p.xaLog.Owned(entry.Gtrid) {
+				continue
+			}
+			if marks[entry.Gtrid] {
+				p.deleteXACommitMark(entry)
+			}
+			if err := p.xaLog.Append(entry.Gtrid, xa.StateDone, nil); err != nil {
+				simplelog.Error("%s %s %s gtrid=%s", "proxy", "RecoverXA", err.Error(), entry.Gtrid)
+			}
+		}
+	}
+	return branches
+}
+
+// recoverXAInHost resolve prepared branches in namespace in master of data host.
+func (p *Server) recoverXAInHost(hostName string, namespace string,
+	marked func(entry *xa.Entry) (bool, error)) ([]*xa.PreparedBranch, error) {
+	conn, err := p.GetDataHosts()[hostName].GetMaster().GetConnection("")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.ReturnConnection()
+	mysqlConn := conn.(*mysqlBackend.Conn)
+	result, err := mysqlConn.Query("XA RECOVER")
+	if err != nil {
+		return nil, err
+	}
+	prepared, err := xa.ParseRecoverResult(hostName, result)
+	if err != nil {
+		return nil, err
+	}
+
+	branches := make([]*xa.PreparedBranch, 0, len(prepared))
+	for _, branch := range prepared {
+		if !xa.InNamespace(namespace, branch.Xid.Gtrid) {
+			continue
+		}
+		xa.Decide(p.xaLog, branch, marked)
+		switch branch.Action {
+		case xa.ActionCommit:
+			_, branch.Err = mysqlConn.Query("XA COMMIT " + branch.Xid.String())
+		case xa.ActionRollback:
+			_, branch.Err = mysqlConn.Query("XA ROLLBACK " + branch.Xid.String())
+		}
+		if branch.Err != nil {
+			simplelog.Error("%s %s %s host=%s,xid=%s,action=%s", "proxy", "recoverXAInHost", branch.Err.Error(),
+				hostName, branch.Xid.String(), branch.Action)
+		} else if branch.Action != xa.ActionSkip {
+			simplelog.Info("%s %s %s host=%s,xid=%s,action=%s", "proxy", "recoverXAInHost", "Prepared transaction resolved",
+				hostName, branch.Xid.String(), branch.Action)
+		}
+		branches = append(branches, branch)
+	}
+	return branches, nil
+}
+
+// isXACommitMarked the transaction is marked committed by the last resource in any of its nodes.
+// Only the node of local branch has the mark, but it's not distinguished in log, so all nodes are checked.
+func (p *Server) isXACommitMarked(entry *xa.Entry) (bool, error) {
+	for _, nodeName := range entry.Nodes {
+		var ok bool
+		err := p.execInNode(nodeName, func(conn *mysqlBackend.Conn) (err error) {
+			ok, err = xa.IsCommitMarked(conn, entry.Gtrid)
+			return
+		})
+		if err != nil {
+			return false, fmt.Errorf("commit mark in node %s not checked: %s", nodeName, err.Error())
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// deleteXACommitMark delete commit mark of transaction resolved, failure is only logged.
+func (p *Server) deleteXACommitMark(entry *xa.Entry) {
+	for _, nodeName := range entry.Nodes {
+		err := p.execInNode(nodeName, func(conn *mysqlBackend.Conn) error {
+			return xa.DeleteCommitMark(conn, entry.Gtrid)
+		})
+		if err != nil {
+			simplelog.Error("%s %s %s gtrid=%s,node=%s", "proxy", "deleteXACommitMark", err.Error(), entry.Gtrid, nodeName)
+		}
+	}
+}
+
+// recoverXAOnStartup resolve prepared transactions left by crash of previous process.
+func (p *Server) recoverXAOnStartup() {
+	branches := p.RecoverXA()
+	simplelog.Info("%s %s %s branches=%d", "proxy", "recoverXAOnStartup", "XA recovery completed", len(branches))
+}
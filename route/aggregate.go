@@ -0,0 +1,456 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+var errAggregateInMulti = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+	"aggregate other than COUNT, SUM, MIN, MAX and AVG, aggregate with DISTINCT, or * with aggregate across nodes")
+
+// aggregateColumn is an aggregate function in select expressions, or hidden column referenced by expressions.
+type aggregateColumn struct {
+	column int
+	name   string // count, sum, min, max or avg.
+	count  int    // hidden column of COUNT for AVG, which is rewritten to SUM.
+}
+
+// computedColumn is a select expression of aggregates, which is computed after rows are merged.
+type computedColumn struct {
+	column int
+	expr   sqlparser.Expr
+}
+
+// Aggregation merges rows of select with aggregate or GROUP BY from multi nodes,
+// COUNT and SUM are summed, MIN and MAX are compared, AVG is SUM of sums divided by SUM of counts,
+// and rows of the same GROUP BY values are merged into a row, then filtered by HAVING.
+// Expressions of aggregates in select expressions, HAVING and ORDER BY are evaluated on merged rows.
+type Aggregation struct {
+	columns    int // columns of the original select.
+	aggregates []aggregateColumn
+	groupBy    []int                 // columns of GROUP BY values, hidden columns if not in select expressions.
+	hidden     sqlparser.SelectExprs // hidden columns appended to select expressions.
+	exprs      map[string]int        // select expressions by alias or the same expression.
+	computed   []computedColumn
+	having     sqlparser.BoolExpr
+	sorts      []sqlparser.ValExpr // ORDER BY not in select expressions, appended to merged rows to sort.
+	eval       *evaluator
+}
+
+// NewAggregation analyze aggregate, GROUP BY and HAVING of select, nil if not any.
+func NewAggregation(statement *sqlparser.Select) (*Aggregation, error) {
+	if len(statement.GroupBy) == 0 && !hasAggregate(statement.SelectExprs) &&
+		(statement.Having == nil || !hasAggregate(statement.Having)) {
+		return nil, nil
+	}
+	a := &Aggregation{columns: len(statement.SelectExprs), exprs: make(map[string]int), eval: newEvaluator()}
+	for i, selectExpr := range statement.SelectExprs {
+		nonStarExpr, ok := selectExpr.(*sqlparser.NonStarExpr)
+		if !ok {
+			return nil, errAggregateInMulti
+		}
+		funcExpr, ok := nonStarExpr.Expr.(*sqlparser.FuncExpr)
+		if !ok || !aggregateFuncs[strings.ToLower(string(funcExpr.Name))] {
+			if hasAggregate(selectExpr) {
+				a.computed = append(a.computed, computedColumn{column: i, expr: nonStarExpr.Expr})
+				continue
+			}
+		} else if err := a.addAggregate(funcExpr, i); err != nil {
+			return nil, err
+		}
+		addSelectExpr(a.exprs, i, nonStarExpr)
+	}
+	// expressions of aggregates are referenced after resolved, as they're computed by other columns.
+	for _, computed := range a.computed {
+		if err := a.eval.resolve(computed.expr, a.reference); err != nil {
+			return nil, err
+		}
+	}
+	for _, computed := range a.computed {
+		addSelectExpr(a.exprs, computed.column, statement.SelectExprs[computed.column].(*sqlparser.NonStarExpr))
+	}
+	for _, expr := range statement.GroupBy {
+		// GROUP BY position or alias of select expression.
+		if num, ok := expr.(sqlparser.NumVal); ok {
+			if n, err := strconv.Atoi(string(num)); err == nil && n > 0 && n <= a.columns {
+				a.groupBy = append(a.groupBy, n-1)
+				continue
+			}
+		}
+		if colName, ok := expr.(*sqlparser.ColName); ok && len(colName.Qualifier) == 0 {
+			if i, ok := a.exprs[strings.ToLower(string(colName.Name))]; ok {
+				a.groupBy = append(a.groupBy, i)
+				continue
+			}
+		}
+		a.groupBy = append(a.groupBy, a.addHidden(expr))
+	}
+	if statement.Having != nil {
+		a.having = statement.Having.Expr
+		if err := a.eval.resolve(a.having, a.reference); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// reference column of expression in select expressions by the same expression or alias, -1 if not found,
+// aggregate or column not in select expressions is added as hidden column if leaf.
+func (a *Aggregation) reference(expr sqlparser.ValExpr, leaf bool) (int, error) {
+	if i, ok := a.exprs[exprKey(expr)]; ok {
+		return i, nil
+	}
+	if !leaf {
+		return -1, nil
+	}
+	column := a.addHidden(expr)
+	if funcExpr, ok := expr.(*sqlparser.FuncExpr); ok {
+		if err := a.addAggregate(funcExpr, column); err != nil {
+			return -1, err
+		}
+	}
+	return column, nil
+}
+
+// addSort add ORDER BY not in select expressions, which is evaluated on merged rows,
+// and appended to select expressions to sort rows.
+func (a *Aggregation) addSort(expr sqlparser.ValExpr) error {
+	if err := a.eval.resolve(expr, a.reference); err != nil {
+		return err
+	}
+	a.sorts = append(a.sorts, expr)
+	return nil
+}
+
+// addAggregate add aggregate function at column, error if it couldn't be merged.
+func (a *Aggregation) addAggregate(funcExpr *sqlparser.FuncExpr, column int) error {
+	name := strings.ToLower(string(funcExpr.Name))
+	switch name {
+	case "count", "sum", "min", "max", "avg":
+	default:
+		return errAggregateInMulti
+	}
+	if funcExpr.Distinct && name != "min" && name != "max" {
+		return errAggregateInMulti
+	}
+	aggregate := aggregateColumn{column: column, name: name, count: -1}
+	if name == "avg" {
+		aggregate.count = a.addHidden(&sqlparser.FuncExpr{Name: []byte("count"), Exprs: funcExpr.Exprs})
+	}
+	a.aggregates = append(a.aggregates, aggregate)
+	return nil
+}
+
+// addHidden add hidden column, return its column.
+func (a *Aggregation) addHidden(expr sqlparser.Expr) int {
+	a.hidden = append(a.hidden, &sqlparser.NonStarExpr{Expr: expr})
+	return a.columns + len(a.hidden) - 1
+}
+
+// Rewrite select sent to each node, AVG is rewritten to SUM with hidden COUNT,
+// GROUP BY values are selected as hidden columns, HAVING and LIMIT are removed as they're applied after rows are merged.
+func (a *Aggregation) Rewrite(statement *sqlparser.Select) *sqlparser.Select {
+	rewritten := *statement
+	rewritten.SelectExprs = make(sqlparser.SelectExprs, 0, a.columns+len(a.hidden))
+	rewritten.SelectExprs = append(rewritten.SelectExprs, statement.SelectExprs...)
+	rewritten.SelectExprs = append(rewritten.SelectExprs, a.hidden...)
+	for _, aggregate := range a.aggregates {
+		if aggregate.name == "avg" {
+			nonStarExpr := *rewritten.SelectExprs[aggregate.column].(*sqlparser.NonStarExpr)
+			funcExpr := *nonStarExpr.Expr.(*sqlparser.FuncExpr)
+			funcExpr.Name = []byte("sum")
+			nonStarExpr.Expr = &funcExpr
+			rewritten.SelectExprs[aggregate.column] = &nonStarExpr
+		}
+	}
+	rewritten.Having = nil
+	rewritten.Limit = nil
+	return &rewritten
+}
+
+// Merge rows of result set from nodes, rows are sorted by GROUP BY values and filtered by HAVING, and hidden columns are removed.
+func (a *Aggregation) Merge(rs *mysql.Resultset) error {
+	if err := rs.Decode(); err != nil {
+		return err
+	}
+	if len(rs.Fields) != a.columns+len(a.hidden) {
+		return mysql.NewDefaultError(mysql.ER_UNKNOWN_ERROR, "columns of aggregation are mismatched")
+	}
+	keys := make([]mysql.SortKey, len(a.groupBy))
+	for i, column := range a.groupBy {
+		keys[i] = mysql.SortKey{Column: column}
+	}
+	rs.SortRows(keys)
+
+	var values [][]interface{}
+	for i := range rs.Values {
+		if i > 0 && rs.CompareRows(i-1, i, keys) == 0 {
+			a.accumulate(rs.Fields, values[len(values)-1], rs.Values[i])
+			continue
+		}
+		merged := make([]interface{}, len(rs.Values[i]))
+		copy(merged, rs.Values[i])
+		values = append(values, merged)
+	}
+	// aggregate without GROUP BY is a row even if no rows.
+	if len(values) == 0 && len(a.groupBy) == 0 {
+		values = append(values, make([]interface{}, len(rs.Fields)))
+		for _, aggregate := range a.aggregates {
+			if aggregate.name == "count" {
+				values[0][aggregate.column] = int64(0)
+			}
+		}
+	}
+
+	fields := make([]*mysql.Field, len(rs.Fields))
+	copy(fields, rs.Fields)
+	for _, aggregate := range a.aggregates {
+		if aggregate.name != "avg" {
+			continue
+		}
+		// AVG of FLOAT and DOUBLE is DOUBLE, others are DECIMAL of 4 decimals more than SUM.
+		if isFloatField(fields[aggregate.column]) {
+			for _, row := range values {
+				if count := floatValue(row[aggregate.count]); row[aggregate.column] != nil && count > 0 {
+					row[aggregate.column] = floatValue(row[aggregate.column]) / count
+				} else {
+					row[aggregate.column] = nil
+				}
+			}
+			continue
+		}
+		field := *fields[aggregate.column]
+		if field.Decimals < 31 {
+			field.Decimals += 4
+			if field.Decimals > 30 {
+				field.Decimals = 30
+			}
+		}
+		field.ColumnType = mysql.MYSQL_TYPE_NEWDECIMAL
+		fields[aggregate.column] = &field
+		for _, row := range values {
+			if count, _ := decimalValue(row[aggregate.count]); row[aggregate.column] != nil && count.Sign() > 0 {
+				sum, scale := decimalValue(row[aggregate.column])
+				if field.Decimals < 31 {
+					scale = int(field.Decimals)
+				} else {
+					scale += 4
+				}
+				row[aggregate.column] = []byte(sum.Quo(sum, count).FloatString(scale))
+			} else {
+				row[aggregate.column] = nil
+			}
+		}
+	}
+	for _, computed := range a.computed {
+		for _, row := range values {
+			row[computed.column] = a.eval.value(fields, row, computed.expr).v
+		}
+	}
+	if a.having != nil {
+		filtered := values[:0]
+		for _, row := range values {
+			if a.eval.test(fields, row, a.having) == truthTrue {
+				filtered = append(filtered, row)
+			}
+		}
+		values = filtered
+	}
+	if len(a.sorts) > 0 {
+		sorted := make([][]interface{}, len(values))
+		for i, row := range values {
+			sorted[i] = make([]interface{}, a.columns, a.columns+len(a.sorts))
+			copy(sorted[i], row)
+			for _, expr := range a.sorts {
+				sorted[i] = append(sorted[i], a.eval.value(fields, row, expr).v)
+			}
+		}
+		sortFields := make([]*mysql.Field, a.columns, a.columns+len(a.sorts))
+		copy(sortFields, fields)
+		for i, expr := range a.sorts {
+			if column, ok := a.eval.refs[sqlparser.String(expr)]; ok {
+				sortFields = append(sortFields, fields[column])
+			} else {
+				sortFields = append(sortFields, computedField(sqlparser.String(expr), sorted, a.columns+i))
+			}
+		}
+		fields, values = sortFields, sorted
+	}
+
+	fields = fields[:a.columns+len(a.sorts)]
+	rs.Fields = fields
+	rs.FieldNames = make(map[string]int, len(fields))
+	for i, field := range fields {
+		rs.FieldNames[string(field.Name)] = i
+	}
+	rs.Values = make([][]interface{}, len(values))
+	rs.Rows = make([]*mysql.Row, len(values))
+	for i, row := range values {
+		rs.Values[i] = row[:len(fields)]
+		rs.Rows[i] = mysql.NewTextRow(fields)
+		for _, value := range rs.Values[i] {
+			rs.Rows[i].AppendValue(value)
+		}
+	}
+	return nil
+}
+
+// accumulate values of a row into merged row of the same group.
+func (a *Aggregation) accumulate(fields []*mysql.Field, merged []interface{}, values []interface{}) {
+	for _, aggregate := range a.aggregates {
+		columns := []int{aggregate.column}
+		if aggregate.count >= 0 {
+			columns = append(columns, aggregate.count)
+		}
+		for _, column := range columns {
+			value := values[column]
+			if value == nil {
+				continue
+			} else if merged[column] == nil {
+				merged[column] = value
+				continue
+			}
+			switch {
+			case aggregate.name == "min":
+				if mysql.CompareValue(fields[column], value, merged[column]) < 0 {
+					merged[column] = value
+				}
+			case aggregate.name == "max":
+				if mysql.CompareValue(fields[column], value, merged[column]) > 0 {
+					merged[column] = value
+				}
+			default:
+				merged[column] = addValue(fields[column], merged[column], value)
+			}
+		}
+	}
+	// aggregate without GROUP BY is a row of NULL columns in node without rows, take columns of the other node.
+	if len(a.groupBy) == 0 {
+		for column, value := range values {
+			if merged[column] == nil && value != nil {
+				merged[column] = value
+			}
+		}
+	}
+}
+
+// addValue sum values of COUNT or SUM, integers are summed as integer if not overflowed,
+// FLOAT and DOUBLE are summed as float, and others such as DECIMAL are summed exactly as string of digits.
+func addValue(field *mysql.Field, a, b interface{}) interface{} {
+	if isFloatField(field) {
+		return floatValue(a) + floatValue(b)
+	}
+	switch x := a.(type) {
+	case int64:
+		if y, ok := b.(int64); ok {
+			if sum := x + y; (y >= 0) == (sum >= x) {
+				return sum
+			}
+		}
+	case uint64:
+		if y, ok := b.(uint64); ok && x+y >= x {
+			return x + y
+		}
+	}
+	x, scale := decimalValue(a)
+	y, yScale := decimalValue(b)
+	if yScale > scale {
+		scale = yScale
+	}
+	return []byte(x.Add(x, y).FloatString(scale))
+}
+
+func isFloatField(field *mysql.Field) bool {
+	return field.ColumnType == mysql.MYSQL_TYPE_FLOAT || field.ColumnType == mysql.MYSQL_TYPE_DOUBLE
+}
+
+// decimalValue of number or string of digits exactly, and digits of its fraction.
+func decimalValue(v interface{}) (*big.Rat, int) {
+	var s string
+	switch val := v.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(val), 0
+	case uint64:
+		return new(big.Rat).SetUint64(val), 0
+	case float64:
+		s = strconv.FormatFloat(val, 'f', -1, 64)
+	case []byte:
+		s = string(val)
+	case string:
+		s = val
+	}
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return new(big.Rat), 0
+	}
+	scale := 0
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		scale = len(strings.TrimSpace(s[i+1:]))
+	}
+	return r, scale
+}
+
+func floatValue(v interface{}) float64 {
+	switch val := v.(type) {
+	case float64:
+		return val
+	case int64:
+		return float64(val)
+	case uint64:
+		return float64(val)
+	case []byte:
+		n, _ := strconv.ParseFloat(string(val), 64)
+		return n
+	case string:
+		n, _ := strconv.ParseFloat(val, 64)
+		return n
+	}
+	return 0
+}
+
+// computedField is field of values computed on merged rows, as BIGINT, DOUBLE or VARCHAR.
+func computedField(name string, values [][]interface{}, column int) *mysql.Field {
+	columnType := mysql.MYSQL_TYPE_LONGLONG
+	for _, row := range values {
+		switch row[column].(type) {
+		case nil, int64:
+		case uint64, float64:
+			if columnType == mysql.MYSQL_TYPE_LONGLONG {
+				columnType = mysql.MYSQL_TYPE_DOUBLE
+			}
+		default:
+			columnType = mysql.MYSQL_TYPE_VAR_STRING
+		}
+	}
+	return &mysql.Field{
+		Name:       []byte(name),
+		Charset:    uint16(mysql.CharsetIds[mysql.DEFAULT_CHARSET]),
+		ColumnType: columnType,
+	}
+}
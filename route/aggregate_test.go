@@ -0,0 +1,57 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// TestAggregationDecimal SUM and AVG of DECIMAL are merged exactly, and of DOUBLE as float.
+func TestAggregationDecimal(t *testing.T) {
+	stmt, err := sqlparser.Parse("select sum(d), avg(d), sum(f), avg(f) from t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewAggregation(stmt.(*sqlparser.Select))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rewritten := sqlparser.String(a.Rewrite(stmt.(*sqlparser.Select))); rewritten !=
+		"select sum(d), sum(d), sum(f), sum(f), count(d), count(f) from t" {
+		t.Fatalf("unexpected rewritten select '%s'", rewritten)
+	}
+	decimal := &mysql.Field{Name: []byte("d"), ColumnType: mysql.MYSQL_TYPE_NEWDECIMAL, Decimals: 2}
+	double := &mysql.Field{Name: []byte("f"), ColumnType: mysql.MYSQL_TYPE_DOUBLE, Decimals: 31}
+	count := &mysql.Field{Name: []byte("c"), ColumnType: mysql.MYSQL_TYPE_LONGLONG}
+	rs := &mysql.Resultset{
+		Fields: []*mysql.Field{decimal, decimal, double, double, count, count},
+		Values: [][]interface{}{
+			{[]byte("90071992547409.93"), []byte("90071992547409.93"), 0.1, 0.1, int64(2), int64(1)},
+			{[]byte("0.01"), []byte("0.01"), 0.2, 0.2, int64(1), int64(1)},
+		},
+	}
+	if err = a.Merge(rs); err != nil {
+		t.Fatal(err)
+	}
+	if len(rs.Values) != 1 {
+		t.Fatalf("expect a row, got %d", len(rs.Values))
+	}
+	row := rs.Values[0]
+	x, y := 0.1, 0.2
+	if sum := string(row[0].([]byte)); sum != "90071992547409.94" {
+		t.Errorf("expect exact sum 90071992547409.94, got %s", sum)
+	}
+	if avg := string(row[1].([]byte)); avg != "30023997515803.313333" {
+		t.Errorf("expect exact avg 30023997515803.313333, got %s", avg)
+	}
+	if rs.Fields[1].ColumnType != mysql.MYSQL_TYPE_NEWDECIMAL || rs.Fields[1].Decimals != 6 {
+		t.Errorf("expect avg of decimal as DECIMAL of 6 decimals, got type %d decimals %d", rs.Fields[1].ColumnType, rs.Fields[1].Decimals)
+	}
+	if sum, ok := row[2].(float64); !ok || sum != x+y {
+		t.Errorf("expect sum of double as float, got %v", row[2])
+	}
+	if avg, ok := row[3].(float64); !ok || avg != (x+y)/2 || rs.Fields[3].ColumnType != mysql.MYSQL_TYPE_DOUBLE {
+		t.Errorf("expect avg of double as DOUBLE, got %v of type %d", row[3], rs.Fields[3].ColumnType)
+	}
+}
@@ -0,0 +1,115 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+var (
+	errJoinNotColocated = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+		"join of sharded tables across nodes, join on shard key, or on join key of child table and shard key of parent")
+	errChildShardKeyColumn = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+		"column of child table named as shard key in join, child is routed by join key, filter on shard key of parent")
+)
+
+// CheckChildTables check parent of each child table is a sharded table in schema, and parents are not in a cycle.
+func CheckChildTables(schemaConfig *config.SchemaConfig) error {
+	tables := schemaConfig.GetTables()
+	for name, table := range tables {
+		if len(table.Parent) == 0 {
+			continue
+		}
+		if table.GetType() != config.TableTypeSharded {
+			return fmt.Errorf("%s table '%s' with parent, only sharded table could be child", table.GetType(), name)
+		}
+		ancestors := map[string]bool{name: true}
+		for child := table; len(child.Parent) > 0; {
+			parentName := strings.Trim(strings.ToLower(child.Parent), "`")
+			parent := tables[parentName]
+			if parent == nil || parent.GetType() != config.TableTypeSharded {
+				return fmt.Errorf("parent '%s' of table '%s' not a sharded table", parentName, name)
+			}
+			if ancestors[parentName] {
+				return fmt.Errorf("parent '%s' of table '%s' is in a cycle", parentName, name)
+			}
+			ancestors[parentName] = true
+			child = parent
+		}
+	}
+	return nil
+}
+
+// routingSchema get schema config to route statement. If statement is only on a child table,
+// shard key is join key of it, so that it's in the node of parent row.
+func routingSchema(schemaConfig *config.SchemaConfig, statement sqlparser.SQLNode) *config.SchemaConfig {
+	if !schemaConfig.ShardEnabled() {
+		return schemaConfig
+	}
+	var tableName string
+	for _, table := range sqlparser.GetTableNames(statement) {
+		if len(table.Qualifier) > 0 {
+			continue
+		}
+		name := strings.Trim(strings.ToLower(string(table.Name)), "`")
+		if len(tableName) > 0 && name != tableName {
+			return schemaConfig
+		}
+		tableName = name
+	}
+	shardKey := schemaConfig.GetShardKey(tableName)
+	if shardKey == schemaConfig.ShardKey {
+		return schemaConfig
+	}
+	child := *schemaConfig
+	child.ShardKey = shardKey
+	return &child
+}
+
+// checkJoinsColocated check sharded tables joined in each select of statement are co-located by shard key,
+// or by join key of child and shard key of parent, rows joined across nodes would be missing.
+func checkJoinsColocated(schemaConfig *config.SchemaConfig, statement sqlparser.SelectStatement) error {
+	cteNames := sqlparser.GetCTENames(statement)
+	return sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		sel, ok := node.(*sqlparser.Select)
+		if !ok || len(sel.From) == 0 {
+			return true, nil
+		}
+		if len(sel.From) == 1 {
+			if _, ok := sel.From[0].(*sqlparser.AliasedTableExpr); ok {
+				return true, nil
+			}
+		}
+		if colocated, err := isMultiTableColocated(schemaConfig, sel.From, sel.Where, cteNames); err != nil {
+			return false, err
+		} else if !colocated {
+			return false, errJoinNotColocated
+		}
+		return true, nil
+	}, statement)
+}
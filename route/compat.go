@@ -0,0 +1,147 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// Categories of sql compatibility.
+const (
+	CompatSingleShard = "single_shard" // Routed to one data node.
+	CompatScatter     = "scatter"      // Routed to more than one data node.
+	CompatRejected    = "rejected"     // Parsed, but rejected by router.
+	CompatParseError  = "parse_error"  // Not supported by parser.
+)
+
+// CompatCategories in order of migration effort.
+var CompatCategories = []string{CompatSingleShard, CompatScatter, CompatRejected, CompatParseError}
+
+// Category of sql compatibility.
+func (d *Decision) Category() string {
+	switch {
+	case d.parseFailed:
+		return CompatParseError
+	case d.Err != nil:
+		return CompatRejected
+	case len(d.NodeNames) > 1:
+		return CompatScatter
+	default:
+		return CompatSingleShard
+	}
+}
+
+// CompatSummary is count of statements in a category of sql compatibility.
+type CompatSummary struct {
+	Category string
+	Count    int
+	Sample   *Decision // The first statement in category.
+}
+
+// SummarizeCompat count decisions by category, all categories are returned in order of migration effort.
+func SummarizeCompat(decisions []*Decision) []*CompatSummary {
+	summaries := make([]*CompatSummary, len(CompatCategories))
+	for i, category := range CompatCategories {
+		summaries[i] = &CompatSummary{Category: category}
+	}
+	for _, d := range decisions {
+		category := d.Category()
+		for _, s := range summaries {
+			if s.Category == category {
+				s.Count++
+				if s.Sample == nil {
+					s.Sample = d
+				}
+				break
+			}
+		}
+	}
+	return summaries
+}
+
+// general log line: '[time] id command<TAB>argument'.
+var regGeneralLog = regexp.MustCompile(`^(?:\d{4}-\d{2}-\d{2}T\S+|\d{6}\s+\d{1,2}:\d{2}:\d{2})?\s+\d+\s+(Query|Execute|Init DB|Connect|Quit|Prepare|Close stmt|Field List|Reset stmt)\t?(.*)$`)
+
+// ReadSQLLog read application sql from mysql general log, or from plain sql separated by ';'.
+// 'Init DB' of general log is read as 'use db', other commands except queries are skipped.
+func ReadSQLLog(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var lines []string
+	generalLog := false
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasSuffix(line, "started with:") || regGeneralLog.MatchString(line) {
+			generalLog = true
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !generalLog {
+		var sqls []string
+		for _, sql := range sqlparser.SplitSQLStatement(strings.Join(lines, "\n")) {
+			if sql = strings.TrimSpace(sql); len(sql) > 0 {
+				sqls = append(sqls, sql)
+			}
+		}
+		return sqls, nil
+	}
+
+	var sqls []string
+	var current []string
+	flush := func() {
+		if sql := strings.TrimSpace(strings.Join(current, "\n")); len(sql) > 0 {
+			sqls = append(sqls, sql)
+		}
+		current = nil
+	}
+	inQuery := false
+	for _, line := range lines {
+		matches := regGeneralLog.FindStringSubmatch(line)
+		if matches == nil {
+			// continuation of multi-line query.
+			if inQuery {
+				current = append(current, line)
+			}
+			continue
+		}
+		flush()
+		inQuery = false
+		switch matches[1] {
+		case "Query", "Execute":
+			current = append(current, matches[2])
+			inQuery = true
+		case "Init DB":
+			current = append(current, "use "+strings.TrimSpace(matches[2]))
+		}
+	}
+	flush()
+	return sqls, nil
+}
@@ -0,0 +1,118 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultShardReplicas is default virtual nodes of each data node in consistent hash ring.
+const DefaultShardReplicas = 160
+
+// hashRing is virtual nodes sorted by hash, placed by name of data node as ketama.
+type hashRing struct {
+	points []uint32
+	owners []int // index of data node of each point.
+}
+
+// hashRings cache rings by replicas and nodes.
+var hashRings sync.Map
+
+func getHashRing(nodes []string, replicas int) *hashRing {
+	key := strconv.Itoa(replicas) + ":" + strings.Join(nodes, ",")
+	if ring, ok := hashRings.Load(key); ok {
+		return ring.(*hashRing)
+	}
+	ring, _ := hashRings.LoadOrStore(key, newHashRing(nodes, replicas))
+	return ring.(*hashRing)
+}
+
+func newHashRing(nodes []string, replicas int) *hashRing {
+	ring := new(hashRing)
+	for index, node := range nodes {
+		// 4 points from each md5 digest.
+		for i := 0; i < (replicas+3)/4; i++ {
+			digest := md5.Sum([]byte(node + "-" + strconv.Itoa(i)))
+			for j := 0; j < 4; j++ {
+				ring.points = append(ring.points, binary.LittleEndian.Uint32(digest[j*4:]))
+				ring.owners = append(ring.owners, index)
+			}
+		}
+	}
+	sort.Sort(ring)
+	return ring
+}
+
+func (ring *hashRing) Len() int {
+	return len(ring.points)
+}
+
+func (ring *hashRing) Less(i, j int) bool {
+	if ring.points[i] != ring.points[j] {
+		return ring.points[i] < ring.points[j]
+	}
+	return ring.owners[i] < ring.owners[j]
+}
+
+func (ring *hashRing) Swap(i, j int) {
+	ring.points[i], ring.points[j] = ring.points[j], ring.points[i]
+	ring.owners[i], ring.owners[j] = ring.owners[j], ring.owners[i]
+}
+
+// get index of data node, the first point clockwise from hash of key.
+func (ring *hashRing) get(key string) int {
+	digest := md5.Sum([]byte(key))
+	hash := binary.LittleEndian.Uint32(digest[:4])
+	i := sort.Search(len(ring.points), func(i int) bool { return ring.points[i] >= hash })
+	if i == len(ring.points) {
+		i = 0
+	}
+	return ring.owners[i]
+}
+
+// ConsistentHashShardAlgo consistent hash shard algorithm, params are names of data nodes and replicas of each node.
+// Virtual nodes are placed by name of data node, so adding a node only remaps the keys moved to it.
+func ConsistentHashShardAlgo(val string, dataNodeCount int, params ...interface{}) (int, error) {
+	var nodes []string
+	replicas := DefaultShardReplicas
+	if len(params) > 0 {
+		nodes, _ = params[0].([]string)
+	}
+	if len(params) > 1 {
+		if n, ok := params[1].(int); ok && n > 0 {
+			replicas = n
+		}
+	}
+	if len(nodes) != dataNodeCount {
+		nodes = make([]string, dataNodeCount)
+		for i := range nodes {
+			nodes[i] = strconv.Itoa(i)
+		}
+	}
+	return getHashRing(nodes, replicas).get(strings.Trim(val, "'")), nil
+}
@@ -31,14 +31,20 @@ func (r *Router) buildCreateTablePlan(statement *sqlparser.CreateTable) (*normal
 	schemaConfig := r.Schemas[r.SchemaName]
 	statement.Table.Qualifier = nil
 	if schemaConfig.ShardEnabled() {
+		if err := r.checkCreateTableForeignKeys(schemaConfig, statement); err != nil {
+			return nil, err
+		}
+		if err := r.checkCreateTableUniqueKeys(schemaConfig, statement); err != nil {
+			return nil, err
+		}
 	}
 	hint := ReadHint(&statement.Comments)
 
 	plan := new(normalPlan)
 	if len(hint.Nodes) > 0 {
-		plan.nodeNames = utils.StringCollectionIntersection(hint.Nodes, schemaConfig.Nodes)
+		plan.nodeNames = utils.StringCollectionIntersection(hint.Nodes, r.allShardNodes(schemaConfig))
 	} else {
-		plan.nodeNames = schemaConfig.Nodes
+		plan.nodeNames = r.allShardNodes(schemaConfig)
 	}
 	plan.Statement = statement
 	return plan, nil
@@ -48,14 +54,17 @@ func (r *Router) buildCreateIndexPlan(statement *sqlparser.CreateIndex) (*normal
 	schemaConfig := r.Schemas[r.SchemaName]
 	statement.Table.Qualifier = nil
 	if schemaConfig.ShardEnabled() {
+		if err := r.checkCreateIndexUniqueKey(schemaConfig, statement); err != nil {
+			return nil, err
+		}
 	}
 	hint := ReadHint(&statement.Comments)
 
 	plan := new(normalPlan)
 	if len(hint.Nodes) > 0 {
-		plan.nodeNames = utils.StringCollectionIntersection(hint.Nodes, schemaConfig.Nodes)
+		plan.nodeNames = utils.StringCollectionIntersection(hint.Nodes, r.allShardNodes(schemaConfig))
 	} else {
-		plan.nodeNames = schemaConfig.Nodes
+		plan.nodeNames = r.allShardNodes(schemaConfig)
 	}
 	plan.Statement = statement
 	return plan, nil
@@ -65,14 +74,20 @@ func (r *Router) buildAlterTable(statement *sqlparser.AlterTable) (*normalPlan,
 	schemaConfig := r.Schemas[r.SchemaName]
 	statement.Table.Qualifier = nil
 	if schemaConfig.ShardEnabled() {
+		if err := r.checkAlterTableForeignKeys(schemaConfig, statement); err != nil {
+			return nil, err
+		}
+		if err := r.checkAlterTableUniqueKeys(schemaConfig, statement); err != nil {
+			return nil, err
+		}
 	}
 	hint := ReadHint(&statement.Comments)
 
 	plan := new(normalPlan)
 	if len(hint.Nodes) > 0 {
-		plan.nodeNames = utils.StringCollectionIntersection(hint.Nodes, schemaConfig.Nodes)
+		plan.nodeNames = utils.StringCollectionIntersection(hint.Nodes, r.allShardNodes(schemaConfig))
 	} else {
-		plan.nodeNames = schemaConfig.Nodes
+		plan.nodeNames = r.allShardNodes(schemaConfig)
 	}
 	plan.Statement = statement
 	return plan, nil
@@ -88,9 +103,9 @@ func (r *Router) buildRenameTablePlan(statement *sqlparser.RenameTable) (*normal
 
 	plan := new(normalPlan)
 	if len(hint.Nodes) > 0 {
-		plan.nodeNames = utils.StringCollectionIntersection(hint.Nodes, schemaConfig.Nodes)
+		plan.nodeNames = utils.StringCollectionIntersection(hint.Nodes, r.allShardNodes(schemaConfig))
 	} else {
-		plan.nodeNames = schemaConfig.Nodes
+		plan.nodeNames = r.allShardNodes(schemaConfig)
 	}
 	plan.Statement = statement
 	return plan, nil
@@ -105,9 +120,9 @@ func (r *Router) buildDropTablePlan(statement *sqlparser.DropTable) (*normalPlan
 
 	plan := new(normalPlan)
 	if len(hint.Nodes) > 0 {
-		plan.nodeNames = utils.StringCollectionIntersection(hint.Nodes, schemaConfig.Nodes)
+		plan.nodeNames = utils.StringCollectionIntersection(hint.Nodes, r.allShardNodes(schemaConfig))
 	} else {
-		plan.nodeNames = schemaConfig.Nodes
+		plan.nodeNames = r.allShardNodes(schemaConfig)
 	}
 	plan.Statement = statement
 	return plan, nil
@@ -122,9 +137,9 @@ func (r *Router) buildDropIndexPlan(statement *sqlparser.DropIndex) (*normalPlan
 
 	plan := new(normalPlan)
 	if len(hint.Nodes) > 0 {
-		plan.nodeNames = utils.StringCollectionIntersection(hint.Nodes, schemaConfig.Nodes)
+		plan.nodeNames = utils.StringCollectionIntersection(hint.Nodes, r.allShardNodes(schemaConfig))
 	} else {
-		plan.nodeNames = schemaConfig.Nodes
+		plan.nodeNames = r.allShardNodes(schemaConfig)
 	}
 	plan.Statement = statement
 	return plan, nil
@@ -0,0 +1,59 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"strings"
+
+	"github.com/berkaroad/saashard/errors"
+)
+
+// Directory is lookup of data node by shard key in directory shard algorithm, such as lookup table in metadata database.
+type Directory interface {
+	// Lookup data node of shard key in schema, key not in directory is assigned to one of nodes on insert,
+	// or ErrNotInDirectoryShard on others.
+	Lookup(schemaName string, key string, nodes []string, insert bool) (string, error)
+}
+
+// DirectoryShardAlgo directory shard algorithm, node of shard key is looked up by directory of router.
+// It's always error if called directly, as no directory.
+func DirectoryShardAlgo(val string, dataNodeCount int, params ...interface{}) (int, error) {
+	return 0, errors.ErrNoDirectoryInDirectoryShard
+}
+
+// IsDirectoryShardAlgo the name is directory shard algorithm or not.
+func IsDirectoryShardAlgo(name string) bool {
+	return strings.ToLower(strings.TrimSpace(name)) == "directory"
+}
+
+// ruleNode get node by value of shard key in shard rule, looked up by directory in directory shard algorithm.
+// Only insert assigns node to shard key not in directory.
+func (r *Router) ruleNode(schemaName string, rule *ShardRule, value string, insert bool) (string, error) {
+	if !IsDirectoryShardAlgo(rule.ShardAlgo) {
+		return rule.ShardNode(value)
+	}
+	if r.Directory == nil {
+		return "", errors.ErrNoDirectoryInDirectoryShard
+	}
+	return r.Directory.Lookup(schemaName, strings.Trim(value, "'"), rule.Nodes, insert)
+}
@@ -0,0 +1,82 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+const directoryTestConfig = `
+nodes:
+  - name: n1
+  - name: n2
+schemas:
+  - name: db1
+    shard_key: tenant_id
+    shard_algo: directory
+    check_table_disabled: true
+    nodes: [n1, n2]
+    tables:
+      - {name: t}
+`
+
+// mapDirectory is directory in memory, key not in it is assigned to the last node on insert.
+type mapDirectory map[string]string
+
+func (d mapDirectory) Lookup(schemaName string, key string, nodes []string, insert bool) (string, error) {
+	if node, ok := d[key]; ok {
+		return node, nil
+	}
+	if !insert {
+		return "", errors.ErrNotInDirectoryShard
+	}
+	d[key] = nodes[len(nodes)-1]
+	return d[key], nil
+}
+
+// TestDirectoryAssignedOnInsert only insert assigns node to shard key not in directory.
+func TestDirectoryAssignedOnInsert(t *testing.T) {
+	cfg, err := config.ParseConfigData([]byte(directoryTestConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas := map[string]*config.SchemaConfig{"db1": &cfg.Schemas[0]}
+	directory := mapDirectory{"a": "n1"}
+	route := func(sql string) ([]string, error) {
+		stmt, err := sqlparser.Parse(sql)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := NewRouter("db1", schemas, cfg.GetNodes(), 0, "", false)
+		r.Directory = directory
+		plan, err := r.BuildNormalPlan(stmt)
+		if err != nil {
+			return nil, err
+		}
+		return plan.GetNodeNames(), nil
+	}
+
+	if nodes, err := route("select * from t where tenant_id = 'a'"); err != nil || len(nodes) != 1 || nodes[0] != "n1" {
+		t.Errorf("expect key in directory routed to n1, got %v %v", nodes, err)
+	}
+	for _, sql := range []string{
+		"select * from t where tenant_id = 'b'",
+		"update t set c = 1 where tenant_id = 'b'",
+		"delete from t where tenant_id = 'b'",
+	} {
+		if _, err := route(sql); err != errors.ErrNotInDirectoryShard {
+			t.Errorf("%s: expect key not in directory, got %v", sql, err)
+		}
+	}
+	if _, ok := directory["b"]; ok {
+		t.Fatal("expect key not assigned by select, update or delete")
+	}
+	if nodes, err := route("insert into t (tenant_id, c) values ('b', 1)"); err != nil || len(nodes) != 1 || nodes[0] != "n2" {
+		t.Errorf("expect new key assigned to n2 on insert, got %v %v", nodes, err)
+	}
+	if nodes, err := route("select * from t where tenant_id = 'b'"); err != nil || len(nodes) != 1 || nodes[0] != "n2" {
+		t.Errorf("expect key assigned routed to n2, got %v %v", nodes, err)
+	}
+}
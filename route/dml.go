@@ -23,172 +23,433 @@
 package route
 
 import (
+	"strconv"
 	"strings"
 
+	"github.com/berkaroad/saashard/config"
 	"github.com/berkaroad/saashard/errors"
 	"github.com/berkaroad/saashard/net/mysql"
 	"github.com/berkaroad/saashard/sqlparser"
 )
 
 func (r *Router) buildInsertPlan(statement *sqlparser.Insert) (*normalPlan, error) {
-	schemaConfig := r.Schemas[r.SchemaName]
-	statement.Table.Qualifier = nil
-	nodeIndex := 0
+	schemaConfig := routingSchema(r.Schemas[r.SchemaName], statement)
+	nodeNames := []string{schemaConfig.Nodes[0]}
+	var mirrorNodeNames []string
+	var scatter bool // INSERT ... SELECT in each node of rows selected.
+	hint := ReadHint(&statement.Comments)
 	if schemaConfig.ShardEnabled() {
-		if !schemaConfig.CheckTableDisabled {
-			// check table exists or not.
-			table := string(statement.Table.Name)
-			table = strings.Trim(strings.ToLower(table), "`")
-			tables := schemaConfig.GetTables()
-			if _, ok := tables[table]; !ok {
-				return nil, mysql.NewDefaultError(mysql.ER_NO_SUCH_TABLE, r.SchemaName, table)
+		if rows, ok := statement.Rows.(sqlparser.SelectStatement); ok {
+			if len(globalIndexColumns(schemaConfig, statement.Table)) > 0 {
+				return nil, errGlobalIndexInsertSelect
 			}
-		}
-
-		colValue, err := sqlparser.CheckColumnInInsertOrReplace(statement.Columns, statement.Rows, statement.OnDup, schemaConfig.ShardKey)
-		if err != nil {
+			tables, err := r.insertSelectRoute(schemaConfig, statement.Table, statement.Columns, rows, statement.OnDup)
+			if err != nil {
+				return nil, err
+			}
+			nodeNames = tables.nodeNames
+			scatter = !tables.global && len(nodeNames) > 1
+		} else if tables, err := r.checkTableInDML(schemaConfig, statement, statement.Table); err != nil {
 			return nil, err
-		}
-
-		algo := ParseShardAlgorithm(schemaConfig.ShardAlgo)
-		nodeIndex, err = algo(sqlparser.String(colValue), len(schemaConfig.Nodes))
-		if err != nil {
+		} else if tables.nodeNames != nil {
+			nodeNames = tables.nodeNames
+		} else if hintNodes, err := r.hintNodes(schemaConfig, hint); err != nil {
 			return nil, err
+		} else if len(hintNodes) > 1 {
+			return nil, errHintNodesInInsert
+		} else if hintNodes != nil {
+			nodeNames = hintNodes
+			if err = r.addGlobalIndexOfInsert(schemaConfig, statement.Table, statement.Columns, statement.Rows, statement.OnDup, nodeNames); err != nil {
+				return nil, err
+			}
+		} else {
+			var nodeName, mirrorNodeName string
+			colValue, err := sqlparser.CheckColumnInInsertOrReplace(statement.Columns, statement.Rows, statement.OnDup, schemaConfig.ShardKey)
+			if err == errors.ErrInsertColumnsKey {
+				// shard key not inserted, such as default or generated by trigger, is supplied by hint.
+				nodeName, mirrorNodeName, err = r.hintShardNode(schemaConfig, hint, shardInsert, err)
+			} else if err == nil {
+				nodeName, mirrorNodeName, err = r.shardWriteNode(schemaConfig, colValue, true)
+			}
+			if err != nil {
+				return nil, err
+			}
+			nodeNames = []string{nodeName}
+			if len(mirrorNodeName) > 0 {
+				mirrorNodeNames = []string{mirrorNodeName}
+			}
+			// rows are found by global index once they're written.
+			if err = r.addGlobalIndexOfInsert(schemaConfig, statement.Table, statement.Columns, statement.Rows, statement.OnDup,
+				append(nodeNames, mirrorNodeNames...)); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	ReadHint(&statement.Comments)
-
 	plan := new(normalPlan)
-	plan.nodeNames = []string{schemaConfig.Nodes[nodeIndex]}
+	plan.nodeNames = nodeNames
+	if len(mirrorNodeNames) > 0 {
+		plan.mirror = &Mirror{Schema: r.SchemaName, NodeNames: mirrorNodeNames, Async: schemaConfig.GetDualWrite() == config.DualWriteAsync}
+	}
 	plan.Statement = statement
+	if scatter {
+		plan.nodeStatements = make([]sqlparser.Statement, len(nodeNames))
+		for i := range nodeNames {
+			plan.nodeStatements[i] = statement
+		}
+	}
 	return plan, nil
 }
 
 func (r *Router) buildUpdatePlan(statement *sqlparser.Update) (*normalPlan, error) {
-	schemaConfig := r.Schemas[r.SchemaName]
-	statement.Table.Qualifier = nil
-	nodeIndex := 0
+	schemaConfig := routingSchema(r.Schemas[r.SchemaName], statement)
+	nodeNames := []string{schemaConfig.Nodes[0]}
+	var mirrorNodeNames []string
+	var inList *inListRoute
+	hint := ReadHint(&statement.Comments)
 	if schemaConfig.ShardEnabled() {
-		if !schemaConfig.CheckTableDisabled {
-			// check table exists or not.
-			table := string(statement.Table.Name)
-			table = strings.Trim(strings.ToLower(table), "`")
-			tables := schemaConfig.GetTables()
-			if _, ok := tables[table]; !ok {
-				return nil, mysql.NewDefaultError(mysql.ER_NO_SUCH_TABLE, r.SchemaName, table)
-			}
-		}
-
-		// UPDATE expression, couldn't contain shardkey.
-		for _, setExpr := range statement.Exprs {
-			colName := strings.ToLower(string(setExpr.Name.Name))
-			colName = strings.Trim(colName, "`")
-			if colName == schemaConfig.ShardKey {
-				return nil, errors.ErrUpdateKey
-			}
-		}
-
-		// WHERE expression, should contain shardkey.
-		if statement.Where == nil || statement.Where.Expr == nil {
-			return nil, errors.ErrWhereOrJoinOnKey
-		}
+		var tables *tableRoute
 		var err error
-		var colValue sqlparser.ValExpr
-		if colValue, err = sqlparser.CheckColumnInBoolExpr(statement.Where.Expr, schemaConfig.ShardKey); err != nil {
-			return nil, err
-		} else if colValue == nil {
-			return nil, errors.ErrWhereOrJoinOnKey
+		if statement.TableExprs != nil {
+			tables, err = r.checkTablesInMultiTableDML(schemaConfig, statement, statement.TableExprs, statement.Where)
+		} else {
+			tables, err = r.checkTableInDML(schemaConfig, statement, statement.Table)
 		}
-
-		algo := ParseShardAlgorithm(schemaConfig.ShardAlgo)
-		nodeIndex, err = algo(sqlparser.String(colValue), len(schemaConfig.Nodes))
 		if err != nil {
 			return nil, err
 		}
+
+		if tables.nodeNames != nil {
+			nodeNames = tables.nodeNames
+		} else {
+			// UPDATE expression, couldn't contain shardkey.
+			for _, setExpr := range statement.Exprs {
+				colName := strings.ToLower(string(setExpr.Name.Name))
+				colName = strings.Trim(colName, "`")
+				if colName == schemaConfig.ShardKey {
+					return nil, errors.ErrUpdateKey
+				}
+			}
+
+			// WHERE expression, should contain shardkey, or IN list of shardkey, or shardkey or nodes of hint.
+			var nodeName, mirrorNodeName string
+			var hintNodes []string
+			if hintNodes, err = r.hintNodes(schemaConfig, hint); err != nil {
+				return nil, err
+			} else if len(hintNodes) > 1 && statement.Limit != nil {
+				return nil, errInListLimitInMulti
+			} else if hintNodes != nil {
+				inList = &inListRoute{nodeNames: hintNodes}
+			} else if statement.TableExprs != nil {
+				if nodeName, mirrorNodeName, err = r.shardNodeInMultiTable(schemaConfig, statement.TableExprs, statement.Where); err != nil {
+					if nodeName, mirrorNodeName, err = r.hintShardNode(schemaConfig, hint, shardWrite, err); err != nil {
+						return nil, err
+					}
+				}
+			} else if nodeName, mirrorNodeName, err = r.shardNodeInWhere(schemaConfig, statement.Where); err != nil {
+				if len(hint.ShardKey) > 0 {
+					if nodeName, mirrorNodeName, err = r.hintShardNode(schemaConfig, hint, shardWrite, err); err != nil {
+						return nil, err
+					}
+				} else if inList, err = r.dmlInList(schemaConfig, statement.Table, statement.Where, statement.Limit, err); err != nil {
+					return nil, err
+				}
+			}
+			nodeNames = []string{nodeName}
+			if len(mirrorNodeName) > 0 {
+				mirrorNodeNames = []string{mirrorNodeName}
+			}
+		}
+	}
+	if err := applyVersionColumn(schemaConfig, statement, hint); err != nil {
+		return nil, err
 	}
-	ReadHint(&statement.Comments)
 
 	plan := new(normalPlan)
-	plan.nodeNames = []string{schemaConfig.Nodes[nodeIndex]}
+	plan.nodeNames = nodeNames
+	if len(mirrorNodeNames) > 0 {
+		plan.mirror = &Mirror{Schema: r.SchemaName, NodeNames: mirrorNodeNames, Async: schemaConfig.GetDualWrite() == config.DualWriteAsync}
+	}
 	plan.Statement = statement
+	if inList != nil {
+		inList.apply(plan, statement.Where, func(where *sqlparser.Where) sqlparser.Statement {
+			rewritten := *statement
+			rewritten.Where = where
+			return &rewritten
+		})
+	}
+	if schemaConfig.ShardEnabled() {
+		// values set are added to nodes of update, as rows updated are in them.
+		if err := r.addGlobalIndexOfUpdate(schemaConfig, statement,
+			append(append([]string(nil), plan.nodeNames...), mirrorNodeNames...)); err != nil {
+			return nil, err
+		}
+	}
 
 	return plan, nil
 }
 
-func (r *Router) buildDeletePlan(statement *sqlparser.Delete) (*normalPlan, error) {
-	schemaConfig := r.Schemas[r.SchemaName]
-	statement.Table.Qualifier = nil
-	nodeIndex := 0
-	if schemaConfig.ShardEnabled() {
-		if !schemaConfig.CheckTableDisabled {
-			// check table exists or not.
-			table := string(statement.Table.Name)
-			table = strings.Trim(strings.ToLower(table), "`")
-			tables := schemaConfig.GetTables()
-			if _, ok := tables[table]; !ok {
-				return nil, mysql.NewDefaultError(mysql.ER_NO_SUCH_TABLE, r.SchemaName, table)
-			}
+// ApplyVersionColumn rewrite prepared UPDATE of table with version column, as the prepared sql is sent as it is
+// when executed, not the statement of plan. Hints are kept in statement for routing when executed.
+func ApplyVersionColumn(schemaConfig *config.SchemaConfig, statement sqlparser.Statement) error {
+	update, ok := statement.(*sqlparser.Update)
+	if !ok {
+		return nil
+	}
+	comments := append(sqlparser.Comments(nil), update.Comments...)
+	return applyVersionColumn(routingSchema(schemaConfig, update), update, ReadHint(&comments))
+}
+
+// applyVersionColumn rewrite UPDATE of table with version column for optimistic concurrency:
+// 'version = version + 1' is set if not set by statement,
+// and 'version = <expected>' is added to WHERE if expected version is in hint.
+func applyVersionColumn(schemaConfig *config.SchemaConfig, statement *sqlparser.Update, hint *Hint) error {
+	if statement.Table == nil {
+		// multiple-table UPDATE, column of which table is ambiguous.
+		if len(hint.Version) > 0 {
+			return mysql.NewError(mysql.ER_NOT_SUPPORTED_YET, "expected version in multiple-table update")
+		}
+		return nil
+	}
+	var versionColumn string
+	tableName := strings.Trim(strings.ToLower(string(statement.Table.Name)), "`")
+	for name, table := range schemaConfig.GetTables() {
+		if strings.ToLower(name) == tableName {
+			versionColumn = table.VersionColumn
+			break
+		}
+	}
+	if len(versionColumn) == 0 {
+		if len(hint.Version) > 0 {
+			return mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "version column of table '"+tableName+"' is not configured")
+		}
+		return nil
+	}
+
+	set := false
+	for _, setExpr := range statement.Exprs {
+		if strings.EqualFold(strings.Trim(string(setExpr.Name.Name), "`"), versionColumn) {
+			set = true
+			break
 		}
+	}
+	if !set {
+		statement.Exprs = append(statement.Exprs, &sqlparser.UpdateExpr{
+			Name: &sqlparser.ColName{Name: []byte(versionColumn)},
+			Expr: &sqlparser.BinaryExpr{
+				Operator: sqlparser.AST_PLUS,
+				Left:     &sqlparser.ColName{Name: []byte(versionColumn)},
+				Right:    sqlparser.NumVal("1"),
+			},
+		})
+	}
 
-		// WHERE expression, should contain shardkey.
+	if len(hint.Version) > 0 {
+		if _, err := strconv.ParseInt(hint.Version, 10, 64); err != nil {
+			return mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "expected version '"+hint.Version+"' is not an integer")
+		}
+		versionExpr := &sqlparser.ComparisonExpr{
+			Operator: sqlparser.AST_EQ,
+			Left:     &sqlparser.ColName{Name: []byte(versionColumn)},
+			Right:    sqlparser.NumVal(hint.Version),
+		}
 		if statement.Where == nil || statement.Where.Expr == nil {
-			return nil, errors.ErrWhereOrJoinOnKey
+			statement.Where = sqlparser.NewWhere(sqlparser.AST_WHERE, versionExpr)
+		} else {
+			statement.Where.Expr = &sqlparser.AndExpr{Left: &sqlparser.ParenBoolExpr{Expr: statement.Where.Expr}, Right: versionExpr}
 		}
+	}
+	return nil
+}
+
+func (r *Router) buildDeletePlan(statement *sqlparser.Delete) (*normalPlan, error) {
+	schemaConfig := routingSchema(r.Schemas[r.SchemaName], statement)
+	nodeNames := []string{schemaConfig.Nodes[0]}
+	var mirrorNodeNames []string
+	var inList *inListRoute
+	hint := ReadHint(&statement.Comments)
+	if schemaConfig.ShardEnabled() {
+		var tables *tableRoute
 		var err error
-		var colValue sqlparser.ValExpr
-		if colValue, err = sqlparser.CheckColumnInBoolExpr(statement.Where.Expr, schemaConfig.ShardKey); err != nil {
-			return nil, err
-		} else if colValue == nil {
-			return nil, errors.ErrWhereOrJoinOnKey
+		if statement.TableExprs != nil {
+			tables, err = r.checkTablesInMultiTableDML(schemaConfig, statement, statement.TableExprs, statement.Where)
+		} else {
+			tables, err = r.checkTableInDML(schemaConfig, statement, statement.Table)
 		}
-
-		algo := ParseShardAlgorithm(schemaConfig.ShardAlgo)
-		nodeIndex, err = algo(sqlparser.String(colValue), len(schemaConfig.Nodes))
 		if err != nil {
 			return nil, err
 		}
+
+		if tables.nodeNames != nil {
+			nodeNames = tables.nodeNames
+		} else {
+			// WHERE expression, should contain shardkey, or IN list of shardkey, or shardkey or nodes of hint.
+			var nodeName, mirrorNodeName string
+			var hintNodes []string
+			if hintNodes, err = r.hintNodes(schemaConfig, hint); err != nil {
+				return nil, err
+			} else if len(hintNodes) > 1 && statement.Limit != nil {
+				return nil, errInListLimitInMulti
+			} else if hintNodes != nil {
+				inList = &inListRoute{nodeNames: hintNodes}
+			} else if statement.TableExprs != nil {
+				if nodeName, mirrorNodeName, err = r.shardNodeInMultiTable(schemaConfig, statement.TableExprs, statement.Where); err != nil {
+					if nodeName, mirrorNodeName, err = r.hintShardNode(schemaConfig, hint, shardWrite, err); err != nil {
+						return nil, err
+					}
+				}
+			} else if nodeName, mirrorNodeName, err = r.shardNodeInWhere(schemaConfig, statement.Where); err != nil {
+				if len(hint.ShardKey) > 0 {
+					if nodeName, mirrorNodeName, err = r.hintShardNode(schemaConfig, hint, shardWrite, err); err != nil {
+						return nil, err
+					}
+				} else if inList, err = r.dmlInList(schemaConfig, statement.Table, statement.Where, statement.Limit, err); err != nil {
+					return nil, err
+				}
+			}
+			nodeNames = []string{nodeName}
+			if len(mirrorNodeName) > 0 {
+				mirrorNodeNames = []string{mirrorNodeName}
+			}
+		}
 	}
-	ReadHint(&statement.Comments)
 
 	plan := new(normalPlan)
-	plan.nodeNames = []string{schemaConfig.Nodes[nodeIndex]}
+	plan.nodeNames = nodeNames
+	if len(mirrorNodeNames) > 0 {
+		plan.mirror = &Mirror{Schema: r.SchemaName, NodeNames: mirrorNodeNames, Async: schemaConfig.GetDualWrite() == config.DualWriteAsync}
+	}
 	plan.Statement = statement
+	if inList != nil {
+		inList.apply(plan, statement.Where, func(where *sqlparser.Where) sqlparser.Statement {
+			rewritten := *statement
+			rewritten.Where = where
+			return &rewritten
+		})
+	}
 
 	return plan, nil
 }
 
 func (r *Router) buildReplacePlan(statement *sqlparser.Replace) (*normalPlan, error) {
-	schemaConfig := r.Schemas[r.SchemaName]
-	statement.Table.Qualifier = nil
-	nodeIndex := 0
+	schemaConfig := routingSchema(r.Schemas[r.SchemaName], statement)
+	nodeNames := []string{schemaConfig.Nodes[0]}
+	var mirrorNodeNames []string
+	var scatter bool // INSERT ... SELECT in each node of rows selected.
+	hint := ReadHint(&statement.Comments)
 	if schemaConfig.ShardEnabled() {
-		if !schemaConfig.CheckTableDisabled {
-			// check table exists or not.
-			table := string(statement.Table.Name)
-			table = strings.Trim(strings.ToLower(table), "`")
-			tables := schemaConfig.GetTables()
-			if _, ok := tables[table]; !ok {
-				return nil, mysql.NewDefaultError(mysql.ER_NO_SUCH_TABLE, r.SchemaName, table)
+		if rows, ok := statement.Rows.(sqlparser.SelectStatement); ok {
+			if len(globalIndexColumns(schemaConfig, statement.Table)) > 0 {
+				return nil, errGlobalIndexInsertSelect
 			}
-		}
-
-		colValue, err := sqlparser.CheckColumnInInsertOrReplace(statement.Columns, statement.Rows, nil, schemaConfig.ShardKey)
-		if err != nil {
+			tables, err := r.insertSelectRoute(schemaConfig, statement.Table, statement.Columns, rows, nil)
+			if err != nil {
+				return nil, err
+			}
+			nodeNames = tables.nodeNames
+			scatter = !tables.global && len(nodeNames) > 1
+		} else if tables, err := r.checkTableInDML(schemaConfig, statement, statement.Table); err != nil {
 			return nil, err
-		}
-
-		algo := ParseShardAlgorithm(schemaConfig.ShardAlgo)
-		nodeIndex, err = algo(sqlparser.String(colValue), len(schemaConfig.Nodes))
-		if err != nil {
+		} else if tables.nodeNames != nil {
+			nodeNames = tables.nodeNames
+		} else if hintNodes, err := r.hintNodes(schemaConfig, hint); err != nil {
 			return nil, err
+		} else if len(hintNodes) > 1 {
+			return nil, errHintNodesInInsert
+		} else if hintNodes != nil {
+			nodeNames = hintNodes
+			if err = r.addGlobalIndexOfInsert(schemaConfig, statement.Table, statement.Columns, statement.Rows, nil, nodeNames); err != nil {
+				return nil, err
+			}
+		} else {
+			var nodeName, mirrorNodeName string
+			colValue, err := sqlparser.CheckColumnInInsertOrReplace(statement.Columns, statement.Rows, nil, schemaConfig.ShardKey)
+			if err == errors.ErrInsertColumnsKey {
+				// shard key not inserted, such as default or generated by trigger, is supplied by hint.
+				nodeName, mirrorNodeName, err = r.hintShardNode(schemaConfig, hint, shardInsert, err)
+			} else if err == nil {
+				nodeName, mirrorNodeName, err = r.shardWriteNode(schemaConfig, colValue, true)
+			}
+			if err != nil {
+				return nil, err
+			}
+			nodeNames = []string{nodeName}
+			if len(mirrorNodeName) > 0 {
+				mirrorNodeNames = []string{mirrorNodeName}
+			}
+			if err = r.addGlobalIndexOfInsert(schemaConfig, statement.Table, statement.Columns, statement.Rows, nil,
+				append(nodeNames, mirrorNodeNames...)); err != nil {
+				return nil, err
+			}
 		}
 	}
-	ReadHint(&statement.Comments)
 
 	plan := new(normalPlan)
-	plan.nodeNames = []string{schemaConfig.Nodes[nodeIndex]}
+	plan.nodeNames = nodeNames
+	if len(mirrorNodeNames) > 0 {
+		plan.mirror = &Mirror{Schema: r.SchemaName, NodeNames: mirrorNodeNames, Async: schemaConfig.GetDualWrite() == config.DualWriteAsync}
+	}
 	plan.Statement = statement
+	if scatter {
+		plan.nodeStatements = make([]sqlparser.Statement, len(nodeNames))
+		for i := range nodeNames {
+			plan.nodeStatements[i] = statement
+		}
+	}
 
 	return plan, nil
 }
+
+// checkTableInDML check table exists or not, and route by type of tables.
+func (r *Router) checkTableInDML(schemaConfig *config.SchemaConfig, statement sqlparser.SQLNode, tableName *sqlparser.TableName) (*tableRoute, error) {
+	if !schemaConfig.CheckTableDisabled {
+		table := string(tableName.Name)
+		table = strings.Trim(strings.ToLower(table), "`")
+		tables := schemaConfig.GetTables()
+		if _, ok := tables[table]; !ok {
+			return nil, mysql.NewDefaultError(mysql.ER_NO_SUCH_TABLE, r.SchemaName, table)
+		}
+	}
+	return r.routeByTables(schemaConfig, statement)
+}
+
+// shardNodeInWhere get node and mirror node by value of shard key in where expression.
+func (r *Router) shardNodeInWhere(schemaConfig *config.SchemaConfig, where *sqlparser.Where) (string, string, error) {
+	if where == nil || where.Expr == nil {
+		return "", "", errors.ErrWhereOrJoinOnKey
+	}
+	colValue, err := sqlparser.CheckColumnInBoolExpr(where.Expr, schemaConfig.ShardKey)
+	if err != nil {
+		return "", "", err
+	} else if colValue == nil {
+		return "", "", errors.ErrWhereOrJoinOnKey
+	}
+
+	return r.shardWriteNode(schemaConfig, colValue, false)
+}
+
+// ShardKeyValue get value of shard key in write statement, empty if not sharded or not only one value.
+func ShardKeyValue(schemaConfig *config.SchemaConfig, statement sqlparser.Statement) string {
+	if !schemaConfig.ShardEnabled() {
+		return ""
+	}
+	var colValue sqlparser.ValExpr
+	switch v := statement.(type) {
+	case *sqlparser.Insert:
+		colValue, _ = sqlparser.CheckColumnInInsertOrReplace(v.Columns, v.Rows, nil, schemaConfig.ShardKey)
+	case *sqlparser.Replace:
+		colValue, _ = sqlparser.CheckColumnInInsertOrReplace(v.Columns, v.Rows, nil, schemaConfig.ShardKey)
+	case *sqlparser.Update:
+		if v.Where != nil {
+			colValue, _ = sqlparser.CheckColumnInBoolExpr(v.Where.Expr, schemaConfig.ShardKey)
+		}
+	case *sqlparser.Delete:
+		if v.Where != nil {
+			colValue, _ = sqlparser.CheckColumnInBoolExpr(v.Where.Expr, schemaConfig.ShardKey)
+		}
+	}
+	if colValue == nil {
+		return ""
+	}
+	return strings.Trim(sqlparser.String(colValue), "'")
+}
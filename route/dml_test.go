@@ -0,0 +1,73 @@
+package route
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+const versionTestConfig = `
+nodes:
+  - name: n1
+  - name: n2
+schemas:
+  - name: db1
+    shard_key: id
+    shard_algo: mod
+    check_table_disabled: true
+    nodes: [n1, n2]
+    tables:
+      - {name: v, version_column: version}
+`
+
+// TestPreparedUpdateVersionColumn prepared UPDATE of table with version column is rewritten in the prepared sql,
+// and hints are kept for routing when executed.
+func TestPreparedUpdateVersionColumn(t *testing.T) {
+	cfg, err := config.ParseConfigData([]byte(versionTestConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas := map[string]*config.SchemaConfig{"db1": &cfg.Schemas[0]}
+
+	stmt, err := sqlparser.Parse("update /*!saashard version=5 */ v set a = ? where id = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ApplyVersionColumn(schemas["db1"], stmt); err != nil {
+		t.Fatal(err)
+	}
+	prepared := sqlparser.String(stmt)
+	if !strings.Contains(prepared, "version = version+1") || !strings.Contains(prepared, "version = 5") {
+		t.Fatalf("expect version column in prepared sql, got '%s'", prepared)
+	}
+	if !strings.Contains(prepared, "/*!saashard version=5 */") {
+		t.Fatalf("expect hint kept in prepared sql, got '%s'", prepared)
+	}
+
+	// Execute binds args to the prepared statement for routing.
+	bound, err := sqlparser.BindArgs(stmt, []interface{}{int64(1), int64(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouter("db1", schemas, cfg.GetNodes(), 0, "", false)
+	plan, err := r.BuildNormalPlan(bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodes := plan.GetNodeNames(); len(nodes) != 1 || nodes[0] != "n2" {
+		t.Errorf("expect routed to n2, got %v", nodes)
+	}
+	if planned := plan.GetPlanSQL(); strings.Count(planned, "version = version+1") != 1 {
+		t.Errorf("expect version increased once, got '%s'", planned)
+	}
+
+	stmt, err = sqlparser.Parse("update /*!saashard version=5 */ t set a = ? where id = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ApplyVersionColumn(schemas["db1"], stmt); err == nil {
+		t.Error("expect error of expected version on table without version column")
+	}
+}
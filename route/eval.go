@@ -0,0 +1,849 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+var errEvalInMulti = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+	"expression other than comparison, arithmetic, CASE and functions of string and number on aggregates across nodes")
+
+// truth of predicate, unknown if compared with NULL.
+type truth int
+
+const (
+	truthFalse truth = iota
+	truthTrue
+	truthUnknown
+)
+
+// operand is value of expression, field is of the column which value is from, nil if literal or computed number.
+type operand struct {
+	v     interface{}
+	field *mysql.Field
+}
+
+// evaluator evaluates expressions on merged rows, such as HAVING, ORDER BY and select expressions of aggregates,
+// aggregates and columns in expressions are referenced by columns of merged rows.
+type evaluator struct {
+	refs map[string]int // column of merged rows, by expression.
+}
+
+func newEvaluator() *evaluator {
+	return &evaluator{refs: make(map[string]int)}
+}
+
+// resolve columns referenced by expression, ref returns column of expression, -1 if not found,
+// and aggregate or column is added as hidden column if leaf.
+func (e *evaluator) resolve(node sqlparser.SQLNode, ref func(expr sqlparser.ValExpr, leaf bool) (int, error)) error {
+	switch expr := node.(type) {
+	case *sqlparser.AndExpr:
+		if err := e.resolve(expr.Left, ref); err != nil {
+			return err
+		}
+		return e.resolve(expr.Right, ref)
+	case *sqlparser.OrExpr:
+		if err := e.resolve(expr.Left, ref); err != nil {
+			return err
+		}
+		return e.resolve(expr.Right, ref)
+	case *sqlparser.NotExpr:
+		return e.resolve(expr.Expr, ref)
+	case *sqlparser.ParenBoolExpr:
+		return e.resolve(expr.Expr, ref)
+	case *sqlparser.ComparisonExpr:
+		if err := e.resolve(expr.Left, ref); err != nil {
+			return err
+		}
+		return e.resolve(expr.Right, ref)
+	case *sqlparser.RangeCond:
+		for _, operand := range []sqlparser.ValExpr{expr.Left, expr.From, expr.To} {
+			if err := e.resolve(operand, ref); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *sqlparser.NullCheck:
+		return e.resolve(expr.Expr, ref)
+	case sqlparser.ValTuple:
+		for _, operand := range expr {
+			if err := e.resolve(operand, ref); err != nil {
+				return err
+			}
+		}
+		return nil
+	case sqlparser.StrVal, sqlparser.NumVal, *sqlparser.NullVal:
+		return nil
+	case sqlparser.ValExpr:
+		key := sqlparser.String(expr)
+		if _, ok := e.refs[key]; ok {
+			return nil
+		}
+		_, leaf := expr.(*sqlparser.ColName)
+		if funcExpr, ok := expr.(*sqlparser.FuncExpr); ok && aggregateFuncs[strings.ToLower(string(funcExpr.Name))] {
+			leaf = true
+		}
+		column, err := ref(expr, leaf)
+		if err != nil {
+			return err
+		}
+		if column >= 0 {
+			e.refs[key] = column
+			return nil
+		}
+		switch expr := expr.(type) {
+		case *sqlparser.BinaryExpr:
+			if err := e.resolve(expr.Left, ref); err != nil {
+				return err
+			}
+			return e.resolve(expr.Right, ref)
+		case *sqlparser.UnaryExpr:
+			return e.resolve(expr.Expr, ref)
+		case *sqlparser.CaseExpr:
+			if expr.Expr != nil {
+				if err := e.resolve(expr.Expr, ref); err != nil {
+					return err
+				}
+			}
+			for _, when := range expr.Whens {
+				if err := e.resolve(when.Cond, ref); err != nil {
+					return err
+				}
+				if err := e.resolve(when.Val, ref); err != nil {
+					return err
+				}
+			}
+			if expr.Else != nil {
+				return e.resolve(expr.Else, ref)
+			}
+			return nil
+		case *sqlparser.FuncExpr:
+			f, ok := scalarFuncs[strings.ToLower(string(expr.Name))]
+			if !ok || expr.Distinct || len(expr.Exprs) < f.min || (f.max >= 0 && len(expr.Exprs) > f.max) {
+				return errEvalInMulti
+			}
+			for _, arg := range expr.Exprs {
+				if err := e.resolve(arg, ref); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return errEvalInMulti
+}
+
+// test predicate on merged row.
+func (e *evaluator) test(fields []*mysql.Field, row []interface{}, expr sqlparser.BoolExpr) truth {
+	switch expr := expr.(type) {
+	case *sqlparser.AndExpr:
+		return and(e.test(fields, row, expr.Left), e.test(fields, row, expr.Right))
+	case *sqlparser.OrExpr:
+		left, right := e.test(fields, row, expr.Left), e.test(fields, row, expr.Right)
+		switch {
+		case left == truthTrue || right == truthTrue:
+			return truthTrue
+		case left == truthUnknown || right == truthUnknown:
+			return truthUnknown
+		}
+		return truthFalse
+	case *sqlparser.NotExpr:
+		return not(e.test(fields, row, expr.Expr))
+	case *sqlparser.ParenBoolExpr:
+		return e.test(fields, row, expr.Expr)
+	case *sqlparser.NullCheck:
+		isNull := e.value(fields, row, expr.Expr).v == nil
+		if expr.Operator == sqlparser.AST_IS_NOT_NULL {
+			isNull = !isNull
+		}
+		return truthOf(isNull)
+	case *sqlparser.RangeCond:
+		left := e.value(fields, row, expr.Left)
+		from, to := e.value(fields, row, expr.From), e.value(fields, row, expr.To)
+		result := and(compareTruth(left, from, func(c int) bool { return c >= 0 }),
+			compareTruth(left, to, func(c int) bool { return c <= 0 }))
+		if expr.Operator == sqlparser.AST_NOT_BETWEEN {
+			return not(result)
+		}
+		return result
+	case *sqlparser.ComparisonExpr:
+		left := e.value(fields, row, expr.Left)
+		switch expr.Operator {
+		case sqlparser.AST_IN, sqlparser.AST_NOT_IN:
+			result := truthFalse
+			if tuple, ok := expr.Right.(sqlparser.ValTuple); ok {
+				for _, item := range tuple {
+					switch compareTruth(left, e.value(fields, row, item), func(c int) bool { return c == 0 }) {
+					case truthTrue:
+						result = truthTrue
+					case truthUnknown:
+						if result == truthFalse {
+							result = truthUnknown
+						}
+					}
+				}
+			}
+			if expr.Operator == sqlparser.AST_NOT_IN {
+				return not(result)
+			}
+			return result
+		case sqlparser.AST_NSE:
+			right := e.value(fields, row, expr.Right)
+			if left.v == nil || right.v == nil {
+				return truthOf(left.v == nil && right.v == nil)
+			}
+			return truthOf(compareOperands(left, right) == 0)
+		case sqlparser.AST_LIKE, sqlparser.AST_NOT_LIKE:
+			right := e.value(fields, row, expr.Right)
+			if left.v == nil || right.v == nil {
+				return truthUnknown
+			}
+			if expr.Operator == sqlparser.AST_NOT_LIKE {
+				return not(truthOf(like(left, right)))
+			}
+			return truthOf(like(left, right))
+		}
+		right := e.value(fields, row, expr.Right)
+		return compareTruth(left, right, func(c int) bool {
+			switch expr.Operator {
+			case sqlparser.AST_EQ:
+				return c == 0
+			case sqlparser.AST_LT:
+				return c < 0
+			case sqlparser.AST_GT:
+				return c > 0
+			case sqlparser.AST_LE:
+				return c <= 0
+			case sqlparser.AST_GE:
+				return c >= 0
+			}
+			return c != 0
+		})
+	}
+	return truthUnknown
+}
+
+// value of expression on merged row.
+func (e *evaluator) value(fields []*mysql.Field, row []interface{}, expr sqlparser.Expr) operand {
+	if i, ok := e.refs[sqlparser.String(expr)]; ok {
+		return operand{v: row[i], field: fields[i]}
+	}
+	switch expr := expr.(type) {
+	case sqlparser.NumVal:
+		return operand{v: numberValue(string(expr))}
+	case sqlparser.StrVal:
+		return operand{v: string(expr)}
+	case *sqlparser.BinaryExpr:
+		left, right := e.value(fields, row, expr.Left), e.value(fields, row, expr.Right)
+		return operand{v: arithmetic(expr.Operator, left.v, right.v)}
+	case *sqlparser.UnaryExpr:
+		v := e.value(fields, row, expr.Expr).v
+		switch {
+		case v == nil:
+			return operand{}
+		case expr.Operator == sqlparser.AST_UMINUS:
+			return operand{v: arithmetic(sqlparser.AST_MINUS, int64(0), v)}
+		case expr.Operator == sqlparser.AST_TILDA:
+			return operand{v: ^uintValue(v)}
+		}
+		return operand{v: v}
+	case *sqlparser.CaseExpr:
+		var subject operand
+		if expr.Expr != nil {
+			subject = e.value(fields, row, expr.Expr)
+		}
+		for _, when := range expr.Whens {
+			t := e.test(fields, row, when.Cond)
+			if expr.Expr != nil {
+				// simple CASE compares with truth of WHEN.
+				if t == truthUnknown || compareTruth(subject, operand{v: int64(t)}, func(c int) bool { return c == 0 }) != truthTrue {
+					continue
+				}
+			} else if t != truthTrue {
+				continue
+			}
+			return e.value(fields, row, when.Val)
+		}
+		if expr.Else != nil {
+			return e.value(fields, row, expr.Else)
+		}
+		return operand{}
+	case *sqlparser.FuncExpr:
+		f, ok := scalarFuncs[strings.ToLower(string(expr.Name))]
+		if !ok {
+			return operand{}
+		}
+		args := make([]operand, len(expr.Exprs))
+		var field *mysql.Field
+		for i, arg := range expr.Exprs {
+			args[i] = e.value(fields, row, arg)
+			if field == nil {
+				field = args[i].field
+			}
+		}
+		return operand{v: f.call(args), field: field}
+	}
+	return operand{}
+}
+
+// arithmetic of numbers, integers are computed as integer except division, NULL if divided by zero.
+func arithmetic(operator byte, a, b interface{}) interface{} {
+	if a == nil || b == nil {
+		return nil
+	}
+	switch operator {
+	case sqlparser.AST_BITAND:
+		return uintValue(a) & uintValue(b)
+	case sqlparser.AST_BITOR:
+		return uintValue(a) | uintValue(b)
+	case sqlparser.AST_BITXOR:
+		return uintValue(a) ^ uintValue(b)
+	case sqlparser.AST_DIV:
+		y := floatValue(b)
+		if y == 0 {
+			return nil
+		}
+		return floatValue(a) / y
+	}
+	if x, ok := intValue(a); ok {
+		if y, ok := intValue(b); ok {
+			switch operator {
+			case sqlparser.AST_PLUS:
+				return x + y
+			case sqlparser.AST_MINUS:
+				return x - y
+			case sqlparser.AST_MULT:
+				return x * y
+			case sqlparser.AST_MOD:
+				if y == 0 {
+					return nil
+				}
+				return x % y
+			}
+		}
+	}
+	x, y := floatValue(a), floatValue(b)
+	switch operator {
+	case sqlparser.AST_PLUS:
+		return x + y
+	case sqlparser.AST_MINUS:
+		return x - y
+	case sqlparser.AST_MULT:
+		return x * y
+	case sqlparser.AST_MOD:
+		if y == 0 {
+			return nil
+		}
+		return math.Mod(x, y)
+	}
+	return nil
+}
+
+// compareOperands compare as number if any is number, or as string by collation of field.
+func compareOperands(a, b operand) int {
+	if isNumber(a.v) || isNumber(b.v) {
+		x, y := floatValue(a.v), floatValue(b.v)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	if field := collationField(a, b); field != nil {
+		return mysql.CompareValue(field, a.v, b.v)
+	}
+	return bytes.Compare([]byte(stringValue(a.v)), []byte(stringValue(b.v)))
+}
+
+func compareTruth(a, b operand, test func(c int) bool) truth {
+	if a.v == nil || b.v == nil {
+		return truthUnknown
+	}
+	return truthOf(test(compareOperands(a, b)))
+}
+
+// collationField is a string field of collation of any operand, nil if both are literals.
+func collationField(a, b operand) *mysql.Field {
+	field := a.field
+	if field == nil {
+		field = b.field
+	}
+	if field == nil {
+		return nil
+	}
+	return &mysql.Field{Charset: field.Charset, ColumnType: mysql.MYSQL_TYPE_VAR_STRING}
+}
+
+// like matches pattern of LIKE by collation, % is any string, _ is any character, and \ escapes.
+func like(value, pattern operand) bool {
+	field := collationField(value, pattern)
+	equal := func(a, b rune) bool {
+		if field == nil {
+			return a == b
+		}
+		return mysql.CompareValue(field, string(a), string(b)) == 0
+	}
+	return likeMatch([]rune(stringValue(value.v)), []rune(stringValue(pattern.v)), equal)
+}
+
+func likeMatch(s, p []rune, equal func(a, b rune) bool) bool {
+	for len(p) > 0 {
+		switch p[0] {
+		case '%':
+			for len(p) > 0 && p[0] == '%' {
+				p = p[1:]
+			}
+			if len(p) == 0 {
+				return true
+			}
+			for i := range s {
+				if likeMatch(s[i:], p, equal) {
+					return true
+				}
+			}
+			return false
+		case '_':
+			if len(s) == 0 {
+				return false
+			}
+		default:
+			if p[0] == '\\' && len(p) > 1 {
+				p = p[1:]
+			}
+			if len(s) == 0 || !equal(s[0], p[0]) {
+				return false
+			}
+		}
+		s, p = s[1:], p[1:]
+	}
+	return len(s) == 0
+}
+
+// scalarFunc is a function evaluated on merged rows, with arguments from min to max, max is -1 if unlimited.
+type scalarFunc struct {
+	min, max int
+	call     func(args []operand) interface{}
+}
+
+var scalarFuncs = map[string]scalarFunc{
+	"concat":           {1, -1, concatFunc},
+	"concat_ws":        {2, -1, concatWSFunc},
+	"upper":            {1, 1, stringFunc(strings.ToUpper)},
+	"ucase":            {1, 1, stringFunc(strings.ToUpper)},
+	"lower":            {1, 1, stringFunc(strings.ToLower)},
+	"lcase":            {1, 1, stringFunc(strings.ToLower)},
+	"trim":             {1, 1, stringFunc(func(s string) string { return strings.Trim(s, " ") })},
+	"ltrim":            {1, 1, stringFunc(func(s string) string { return strings.TrimLeft(s, " ") })},
+	"rtrim":            {1, 1, stringFunc(func(s string) string { return strings.TrimRight(s, " ") })},
+	"reverse":          {1, 1, stringFunc(reverseString)},
+	"length":           {1, 1, lengthFunc},
+	"octet_length":     {1, 1, lengthFunc},
+	"char_length":      {1, 1, charLengthFunc},
+	"character_length": {1, 1, charLengthFunc},
+	"substring":        {2, 3, substringFunc},
+	"substr":           {2, 3, substringFunc},
+	"mid":              {2, 3, substringFunc},
+	"locate":           {2, 3, locateFunc},
+	"replace":          {3, 3, replaceFunc},
+	"repeat":           {2, 2, repeatFunc},
+	"lpad":             {3, 3, padFunc(true)},
+	"rpad":             {3, 3, padFunc(false)},
+	"abs":              {1, 1, absFunc},
+	"sign":             {1, 1, signFunc},
+	"ceil":             {1, 1, roundFunc(math.Ceil)},
+	"ceiling":          {1, 1, roundFunc(math.Ceil)},
+	"floor":            {1, 1, roundFunc(math.Floor)},
+	"round":            {1, 2, roundToFunc},
+	"mod":              {2, 2, modFunc},
+	"ifnull":           {2, 2, coalesceFunc},
+	"coalesce":         {1, -1, coalesceFunc},
+	"nullif":           {2, 2, nullIfFunc},
+	"greatest":         {2, -1, extremeFunc(1)},
+	"least":            {2, -1, extremeFunc(-1)},
+}
+
+func hasNull(args []operand) bool {
+	for _, arg := range args {
+		if arg.v == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func concatFunc(args []operand) interface{} {
+	if hasNull(args) {
+		return nil
+	}
+	var b strings.Builder
+	for _, arg := range args {
+		b.WriteString(stringValue(arg.v))
+	}
+	return b.String()
+}
+
+// concatWSFunc concat with separator, NULL arguments are skipped.
+func concatWSFunc(args []operand) interface{} {
+	if args[0].v == nil {
+		return nil
+	}
+	values := make([]string, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		if arg.v != nil {
+			values = append(values, stringValue(arg.v))
+		}
+	}
+	return strings.Join(values, stringValue(args[0].v))
+}
+
+func stringFunc(f func(s string) string) func(args []operand) interface{} {
+	return func(args []operand) interface{} {
+		if hasNull(args) {
+			return nil
+		}
+		return f(stringValue(args[0].v))
+	}
+}
+
+func reverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+func lengthFunc(args []operand) interface{} {
+	if hasNull(args) {
+		return nil
+	}
+	return int64(len(stringValue(args[0].v)))
+}
+
+func charLengthFunc(args []operand) interface{} {
+	if hasNull(args) {
+		return nil
+	}
+	return int64(utf8.RuneCountInString(stringValue(args[0].v)))
+}
+
+// substringFunc is SUBSTRING(str, pos[, len]), pos is from 1, or from the end if negative.
+func substringFunc(args []operand) interface{} {
+	if hasNull(args) {
+		return nil
+	}
+	runes := []rune(stringValue(args[0].v))
+	pos, _ := intValue(args[1].v)
+	switch {
+	case pos > 0:
+		pos--
+	case pos < 0:
+		pos += int64(len(runes))
+	default:
+		return ""
+	}
+	if pos < 0 || pos >= int64(len(runes)) {
+		return ""
+	}
+	end := int64(len(runes))
+	if len(args) > 2 {
+		n, _ := intValue(args[2].v)
+		if n <= 0 {
+			return ""
+		}
+		if pos+n < end {
+			end = pos + n
+		}
+	}
+	return string(runes[pos:end])
+}
+
+// locateFunc is LOCATE(substr, str[, pos]), position of substr from 1, 0 if not found.
+func locateFunc(args []operand) interface{} {
+	if hasNull(args) {
+		return nil
+	}
+	runes := []rune(stringValue(args[1].v))
+	pos := int64(1)
+	if len(args) > 2 {
+		pos, _ = intValue(args[2].v)
+	}
+	if pos < 1 || pos > int64(len(runes))+1 {
+		return int64(0)
+	}
+	i := strings.Index(string(runes[pos-1:]), stringValue(args[0].v))
+	if i < 0 {
+		return int64(0)
+	}
+	return pos + int64(utf8.RuneCountInString(string(runes[pos-1:])[:i]))
+}
+
+func replaceFunc(args []operand) interface{} {
+	if hasNull(args) {
+		return nil
+	}
+	return strings.ReplaceAll(stringValue(args[0].v), stringValue(args[1].v), stringValue(args[2].v))
+}
+
+func repeatFunc(args []operand) interface{} {
+	if hasNull(args) {
+		return nil
+	}
+	n, _ := intValue(args[1].v)
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat(stringValue(args[0].v), int(n))
+}
+
+// padFunc is LPAD or RPAD, string longer than length is truncated.
+func padFunc(left bool) func(args []operand) interface{} {
+	return func(args []operand) interface{} {
+		if hasNull(args) {
+			return nil
+		}
+		runes, pad := []rune(stringValue(args[0].v)), []rune(stringValue(args[2].v))
+		n, _ := intValue(args[1].v)
+		switch {
+		case n < 0:
+			return nil
+		case n <= int64(len(runes)):
+			return string(runes[:n])
+		case len(pad) == 0:
+			return nil
+		}
+		padding := make([]rune, 0, n-int64(len(runes)))
+		for int64(len(padding)) < n-int64(len(runes)) {
+			padding = append(padding, pad[len(padding)%len(pad)])
+		}
+		if left {
+			return string(padding) + string(runes)
+		}
+		return string(runes) + string(padding)
+	}
+}
+
+func absFunc(args []operand) interface{} {
+	switch v := args[0].v.(type) {
+	case nil:
+		return nil
+	case int64:
+		if v < 0 {
+			return -v
+		}
+		return v
+	case uint64:
+		return v
+	}
+	return math.Abs(floatValue(args[0].v))
+}
+
+func signFunc(args []operand) interface{} {
+	if hasNull(args) {
+		return nil
+	}
+	switch x := floatValue(args[0].v); {
+	case x > 0:
+		return int64(1)
+	case x < 0:
+		return int64(-1)
+	}
+	return int64(0)
+}
+
+// roundFunc is CEIL or FLOOR, integer is as it is.
+func roundFunc(f func(x float64) float64) func(args []operand) interface{} {
+	return func(args []operand) interface{} {
+		switch v := args[0].v.(type) {
+		case nil:
+			return nil
+		case int64, uint64:
+			return v
+		}
+		return integral(f(floatValue(args[0].v)))
+	}
+}
+
+// roundToFunc is ROUND(x[, d]), half away from zero.
+func roundToFunc(args []operand) interface{} {
+	if hasNull(args) {
+		return nil
+	}
+	var d int64
+	if len(args) > 1 {
+		d, _ = intValue(args[1].v)
+	}
+	if x, ok := intValue(args[0].v); ok {
+		if d >= 0 {
+			return x
+		}
+		p := math.Pow10(int(-d))
+		return integral(math.Round(float64(x)/p) * p)
+	}
+	p := math.Pow10(int(d))
+	x := math.Round(floatValue(args[0].v)*p) / p
+	if d <= 0 {
+		return integral(x)
+	}
+	return x
+}
+
+func modFunc(args []operand) interface{} {
+	return arithmetic(sqlparser.AST_MOD, args[0].v, args[1].v)
+}
+
+func coalesceFunc(args []operand) interface{} {
+	for _, arg := range args {
+		if arg.v != nil {
+			return arg.v
+		}
+	}
+	return nil
+}
+
+func nullIfFunc(args []operand) interface{} {
+	if compareTruth(args[0], args[1], func(c int) bool { return c == 0 }) == truthTrue {
+		return nil
+	}
+	return args[0].v
+}
+
+// extremeFunc is GREATEST if sign is 1, or LEAST if sign is -1.
+func extremeFunc(sign int) func(args []operand) interface{} {
+	return func(args []operand) interface{} {
+		if hasNull(args) {
+			return nil
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			if compareOperands(arg, result)*sign > 0 {
+				result = arg
+			}
+		}
+		return result.v
+	}
+}
+
+// integral is float as integer if it's in range of int64.
+func integral(x float64) interface{} {
+	if x >= math.MinInt64 && x < math.MaxInt64 {
+		return int64(x)
+	}
+	return x
+}
+
+// numberValue of literal, as int64, uint64 or float64.
+func numberValue(s string) interface{} {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return n
+	}
+	n, _ := strconv.ParseFloat(s, 64)
+	return n
+}
+
+// intValue is integer value, false if not integer.
+func intValue(v interface{}) (int64, bool) {
+	switch val := v.(type) {
+	case int64:
+		return val, true
+	case uint64:
+		if val <= math.MaxInt64 {
+			return int64(val), true
+		}
+	}
+	return 0, false
+}
+
+func uintValue(v interface{}) uint64 {
+	switch val := v.(type) {
+	case uint64:
+		return val
+	case int64:
+		return uint64(val)
+	}
+	return uint64(int64(floatValue(v)))
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int64, uint64, float64:
+		return true
+	}
+	return false
+}
+
+func stringValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case uint64:
+		return strconv.FormatUint(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	return ""
+}
+
+func truthOf(b bool) truth {
+	if b {
+		return truthTrue
+	}
+	return truthFalse
+}
+
+func not(t truth) truth {
+	switch t {
+	case truthTrue:
+		return truthFalse
+	case truthFalse:
+		return truthTrue
+	}
+	return truthUnknown
+}
+
+func and(a, b truth) truth {
+	switch {
+	case a == truthFalse || b == truthFalse:
+		return truthFalse
+	case a == truthUnknown || b == truthUnknown:
+		return truthUnknown
+	}
+	return truthTrue
+}
@@ -0,0 +1,142 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+var (
+	errForeignKeyRejected     = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET, "foreign key in sharded schema")
+	errForeignKeyNotColocated = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET, "foreign key referencing rows in other node")
+)
+
+// checkCreateTableForeignKeys apply foreign key policy of schema to foreign keys in CREATE TABLE.
+// REFERENCES of column is not checked, as it's ignored by mysql.
+func (r *Router) checkCreateTableForeignKeys(schemaConfig *config.SchemaConfig, statement *sqlparser.CreateTable) error {
+	defs := make(sqlparser.CreateDefinitions, 0, len(statement.CreateDefs))
+	for _, def := range statement.CreateDefs {
+		if fk, ok := def.(*sqlparser.CreateForeignKeyDefinition); ok {
+			keep, err := r.checkForeignKey(schemaConfig, statement.Table, fk.IndexColumns, fk.ReferenceDef)
+			if err != nil {
+				return err
+			}
+			if !keep {
+				continue
+			}
+		}
+		defs = append(defs, def)
+	}
+	statement.CreateDefs = defs
+	return nil
+}
+
+// checkAlterTableForeignKeys apply foreign key policy of schema to foreign keys added by ALTER TABLE.
+func (r *Router) checkAlterTableForeignKeys(schemaConfig *config.SchemaConfig, statement *sqlparser.AlterTable) error {
+	specs := make(sqlparser.AlterSpecifications, 0, len(statement.AlterSpecs))
+	for _, spec := range statement.AlterSpecs {
+		if fk, ok := spec.(*sqlparser.AddForeignKeySpec); ok {
+			keep, err := r.checkForeignKey(schemaConfig, statement.Table, fk.IndexColumns, fk.ReferenceDef)
+			if err != nil {
+				return err
+			}
+			if !keep {
+				continue
+			}
+		}
+		specs = append(specs, spec)
+	}
+	statement.AlterSpecs = specs
+	return nil
+}
+
+// checkForeignKey check foreign key of table by policy, keep is false if it should be stripped.
+func (r *Router) checkForeignKey(schemaConfig *config.SchemaConfig, table *sqlparser.TableName,
+	columns sqlparser.IndexColNames, ref *sqlparser.ReferenceDefinition) (keep bool, err error) {
+	policy := schemaConfig.GetForeignKeyPolicy()
+	if policy == config.ForeignKeyReject {
+		return false, errForeignKeyRejected
+	}
+	if foreignKeyColocated(schemaConfig, table, columns, ref) {
+		r.debugf("foreign key of %s %s is co-located", table.Name, sqlparser.String(ref))
+		return true, nil
+	}
+	if policy == config.ForeignKeyStrip {
+		simplelog.Warn("%s %s connectionID=%d,schema=%s: foreign key of %s %s is stripped, referenced rows may be in other node",
+			"route", "checkForeignKey", r.ConnectionID, r.SchemaName, table.Name, sqlparser.String(ref))
+		return false, nil
+	}
+	return false, errForeignKeyNotColocated
+}
+
+// foreignKeyColocated check rows referenced by foreign key are in the same node as rows of table:
+// parent is global table, both are single tables in the same node,
+// or both are sharded and shard key of table references shard key of parent, such as join key of child.
+func foreignKeyColocated(schemaConfig *config.SchemaConfig, table *sqlparser.TableName,
+	columns sqlparser.IndexColNames, ref *sqlparser.ReferenceDefinition) bool {
+	tableType, tableNode := tablePlacement(schemaConfig, table.Name)
+	parentType, parentNode := tablePlacement(schemaConfig, ref.Table.Name)
+	switch {
+	case parentType == config.TableTypeGlobal:
+		return true
+	case tableType == config.TableTypeSingle && parentType == config.TableTypeSingle:
+		return tableNode == parentNode
+	case tableType == config.TableTypeSharded && parentType == config.TableTypeSharded:
+		for i, column := range columns {
+			if i < len(ref.Columns) && isShardKey(tableShardKey(schemaConfig, table), column) &&
+				isShardKey(tableShardKey(schemaConfig, ref.Table), ref.Columns[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tablePlacement get type of table and node of single table, table not configured is sharded.
+func tablePlacement(schemaConfig *config.SchemaConfig, name []byte) (tableType string, node string) {
+	tableConfig := schemaConfig.GetTables()[strings.Trim(strings.ToLower(string(name)), "`")]
+	if tableConfig == nil {
+		return config.TableTypeSharded, ""
+	}
+	tableType = tableConfig.GetType()
+	if tableType == config.TableTypeSingle {
+		if node = tableConfig.Node; len(node) == 0 {
+			node = schemaConfig.Nodes[0]
+		}
+	}
+	return
+}
+
+func isShardKey(shardKey string, column *sqlparser.IndexColName) bool {
+	return column.ColumnName != nil && strings.EqualFold(strings.Trim(string(column.ColumnName.Name), "`"), shardKey)
+}
+
+// tableShardKey get shard key of table, join key of child table.
+func tableShardKey(schemaConfig *config.SchemaConfig, table *sqlparser.TableName) string {
+	return schemaConfig.GetShardKey(strings.Trim(strings.ToLower(string(table.Name)), "`"))
+}
@@ -0,0 +1,311 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// Functions evaluated by proxy.
+const (
+	FuncUUID        = "uuid"
+	FuncUUIDShort   = "uuid_short"
+	FuncSaashardSeq = "saashard_seq"
+	FuncNextVal     = "saashard_nextval"
+
+	FuncGetLock         = "get_lock"
+	FuncReleaseLock     = "release_lock"
+	FuncReleaseAllLocks = "release_all_locks"
+	FuncIsFreeLock      = "is_free_lock"
+	FuncIsUsedLock      = "is_used_lock"
+)
+
+var localFuncNames = map[string]bool{
+	FuncUUID:            true,
+	FuncUUIDShort:       true,
+	FuncSaashardSeq:     true,
+	FuncNextVal:         true,
+	FuncGetLock:         true,
+	FuncReleaseLock:     true,
+	FuncReleaseAllLocks: true,
+	FuncIsFreeLock:      true,
+	FuncIsUsedLock:      true,
+}
+
+// IsLocalFunc is true if function is evaluated by proxy.
+func IsLocalFunc(name string) bool {
+	return localFuncNames[strings.ToLower(name)]
+}
+
+var uuidField = &mysql.Field{Schema: []byte(""),
+	Table:        []byte(""),
+	OrgTable:     []byte(""),
+	OrgName:      []byte(""),
+	Charset:      uint16(mysql.DEFAULT_COLLATION_ID),
+	ColumnLength: 108,
+	ColumnType:   mysql.MYSQL_TYPE_VAR_STRING,
+	Flags:        mysql.NOT_NULL_FLAG,
+	Decimals:     31}
+
+var uint64Field = &mysql.Field{Schema: []byte(""),
+	Table:        []byte(""),
+	OrgTable:     []byte(""),
+	OrgName:      []byte(""),
+	Charset:      uint16(mysql.BINARY_COLLATION_ID),
+	ColumnLength: 20,
+	ColumnType:   mysql.MYSQL_TYPE_LONGLONG,
+	Flags:        mysql.NOT_NULL_FLAG | mysql.UNSIGNED_FLAG | mysql.BINARY_FLAG,
+	Decimals:     0}
+
+// lockResultField is nullable int field, result of lock functions.
+var lockResultField = &mysql.Field{Schema: []byte(""),
+	Table:        []byte(""),
+	OrgTable:     []byte(""),
+	OrgName:      []byte(""),
+	Charset:      uint16(mysql.BINARY_COLLATION_ID),
+	ColumnLength: 21,
+	ColumnType:   mysql.MYSQL_TYPE_LONGLONG,
+	Flags:        mysql.BINARY_FLAG,
+	Decimals:     0}
+
+// LocalFuncs evaluates UUID(), UUID_SHORT(), SAASHARD_SEQ('name'), SAASHARD_NEXTVAL('name') and advisory lock
+// functions in proxy, without a round trip to backend.
+// Sequences of SAASHARD_SEQ are temporary, scoped to the session and dropped when it's closed.
+// Sequences of SAASHARD_NEXTVAL are distributed, unique across proxies and data nodes.
+// Advisory locks of GET_LOCK() are shared by sessions of the proxy, instead of backend of each shard.
+type LocalFuncs struct {
+	ServerID     uint8 // Server id in UUID_SHORT(), should be unique among proxies.
+	ConnectionID uint32
+	sequences    map[string]uint64
+	locks        *AdvisoryLocks
+	closed       chan struct{}
+	closeOnce    sync.Once
+
+	// Sequence of SAASHARD_NEXTVAL('name'), nil if not supported.
+	Sequence Sequence
+}
+
+// NewLocalFuncs create local functions of a session.
+func NewLocalFuncs(serverID uint8, connectionID uint32, locks *AdvisoryLocks) *LocalFuncs {
+	f := new(LocalFuncs)
+	f.ServerID = serverID
+	f.ConnectionID = connectionID
+	f.sequences = make(map[string]uint64)
+	f.locks = locks
+	f.closed = make(chan struct{})
+	return f
+}
+
+// Close release advisory locks of the session, and stop waiting for lock.
+func (f *LocalFuncs) Close() {
+	f.closeOnce.Do(func() {
+		close(f.closed)
+		if f.locks != nil {
+			f.locks.ReleaseAll(f.ConnectionID)
+		}
+	})
+}
+
+// eval function call in proxy, ok is false if it's not a local function, and nil value is NULL.
+func (f *LocalFuncs) eval(expr sqlparser.ValExpr) (value interface{}, field *mysql.Field, ok bool, err error) {
+	funcExpr, isFunc := expr.(*sqlparser.FuncExpr)
+	if !isFunc || !IsLocalFunc(string(funcExpr.Name)) {
+		return
+	}
+	name := strings.ToLower(string(funcExpr.Name))
+	argCount := map[string]int{
+		FuncUUID:            0,
+		FuncUUIDShort:       0,
+		FuncSaashardSeq:     1,
+		FuncNextVal:         1,
+		FuncGetLock:         2,
+		FuncReleaseLock:     1,
+		FuncReleaseAllLocks: 0,
+		FuncIsFreeLock:      1,
+		FuncIsUsedLock:      1,
+	}[name]
+	if len(funcExpr.Exprs) != argCount {
+		return nil, nil, true, mysql.NewDefaultError(mysql.ER_WRONG_PARAMCOUNT_TO_NATIVE_FCT, name)
+	}
+	var argName string
+	if argCount > 0 {
+		strVal, isStr := funcExpr.Exprs[0].(sqlparser.StrVal)
+		if !isStr || len(strVal) == 0 {
+			return nil, nil, true, mysql.NewDefaultError(mysql.ER_WRONG_ARGUMENTS, name)
+		}
+		argName = string(strVal)
+	}
+
+	switch name {
+	case FuncUUID:
+		return NewUUID(), uuidField, true, nil
+	case FuncUUIDShort:
+		return NewUUIDShort(f.ServerID), uint64Field, true, nil
+	case FuncSaashardSeq:
+		f.sequences[argName]++
+		return f.sequences[argName], uint64Field, true, nil
+	case FuncNextVal:
+		if f.Sequence == nil {
+			return nil, nil, true, mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET, name)
+		}
+		ids, err := f.Sequence.Next(argName, 1)
+		if err != nil {
+			return nil, nil, true, mysql.NewError(mysql.ER_UNKNOWN_ERROR, "sequence '"+argName+"': "+err.Error())
+		}
+		return ids[0], uint64Field, true, nil
+	}
+
+	if f.locks == nil {
+		return nil, nil, true, mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET, name)
+	}
+	switch name {
+	case FuncGetLock:
+		var timeout float64
+		if timeout, err = numArg(funcExpr.Exprs[1]); err != nil {
+			return nil, nil, true, mysql.NewDefaultError(mysql.ER_WRONG_ARGUMENTS, name)
+		}
+		if f.locks.Get(argName, f.ConnectionID, time.Duration(timeout*float64(time.Second)), f.closed) {
+			value = int64(1)
+		} else {
+			value = int64(0)
+		}
+	case FuncReleaseLock:
+		if released, exists := f.locks.Release(argName, f.ConnectionID); exists {
+			value = int64(0)
+			if released {
+				value = int64(1)
+			}
+		}
+	case FuncReleaseAllLocks:
+		value = int64(f.locks.ReleaseAll(f.ConnectionID))
+	case FuncIsFreeLock:
+		value = int64(1)
+		if _, used := f.locks.Owner(argName); used {
+			value = int64(0)
+		}
+	case FuncIsUsedLock:
+		if owner, used := f.locks.Owner(argName); used {
+			value = int64(owner)
+		}
+	}
+	return value, lockResultField, true, nil
+}
+
+// numArg get number argument, such as timeout of GET_LOCK().
+func numArg(expr sqlparser.ValExpr) (float64, error) {
+	switch v := expr.(type) {
+	case sqlparser.NumVal:
+		return strconv.ParseFloat(string(v), 64)
+	case *sqlparser.UnaryExpr:
+		if num, ok := v.Expr.(sqlparser.NumVal); ok && v.Operator == sqlparser.AST_UMINUS {
+			n, err := strconv.ParseFloat(string(num), 64)
+			return -n, err
+		}
+	}
+	return 0, fmt.Errorf("'%s' is not a number", sqlparser.String(expr))
+}
+
+// replace function call by its value, used in statements forwarded to backend.
+func (f *LocalFuncs) replace(expr sqlparser.ValExpr) (sqlparser.ValExpr, error) {
+	value, _, ok, err := f.eval(expr)
+	if !ok || err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case string:
+		return sqlparser.StrVal(v), nil
+	case uint64:
+		return sqlparser.NumVal(strconv.FormatUint(v, 10)), nil
+	case int64:
+		return sqlparser.NumVal(strconv.FormatInt(v, 10)), nil
+	}
+	return &sqlparser.NullVal{}, nil
+}
+
+// uuid state is shared by all sessions, as MySQL does.
+var (
+	uuidMutex     sync.Mutex
+	uuidLastTime  uint64
+	uuidClockSeq  uint16
+	uuidNode      []byte
+	uuidShortBase = uint64(time.Now().Unix()) << 24
+	uuidShortSeq  uint64
+)
+
+// gregorianOffset is count of 100ns from 1582-10-15 to 1970-01-01.
+const gregorianOffset = 0x01B21DD213814000
+
+func init() {
+	// random node with multicast bit, instead of mac address (RFC 4122 4.5).
+	seed := make([]byte, 8)
+	rand.Read(seed)
+	uuidNode = seed[:6]
+	uuidNode[0] |= 0x01
+	uuidClockSeq = binary.BigEndian.Uint16(seed[6:]) & 0x3fff
+}
+
+// NewUUID generate version 1 uuid, such as '6ccd780c-baba-1026-9564-5b8c656024db'.
+func NewUUID() string {
+	uuidMutex.Lock()
+	now := uint64(time.Now().UnixNano()/100) + gregorianOffset
+	if now <= uuidLastTime {
+		now = uuidLastTime + 1
+	}
+	uuidLastTime = now
+	uuidMutex.Unlock()
+
+	uuid := make([]byte, 16)
+	binary.BigEndian.PutUint32(uuid[0:], uint32(now))
+	binary.BigEndian.PutUint16(uuid[4:], uint16(now>>32))
+	binary.BigEndian.PutUint16(uuid[6:], uint16(now>>48)&0x0fff|0x1000)
+	binary.BigEndian.PutUint16(uuid[8:], uuidClockSeq|0x8000)
+	copy(uuid[10:], uuidNode)
+
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:])
+	return string(buf)
+}
+
+// NewUUIDShort generate 64-bit unique integer as MySQL, (server_id & 255) << 56 + (startup_time << 24) + increment.
+func NewUUIDShort(serverID uint8) uint64 {
+	return uint64(serverID)<<56 + uuidShortBase + atomic.AddUint64(&uuidShortSeq, 1)
+}
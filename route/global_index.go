@@ -0,0 +1,265 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// MaxGlobalIndexValueLen is max length of value in index table, longer values are not indexed.
+const MaxGlobalIndexValueLen = 255
+
+var (
+	errGlobalIndexValue = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+		"value of global index column not a constant")
+	errGlobalIndexValueTooLong = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+		"value of global index column longer than 255")
+	errGlobalIndexInsertSelect = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+		"INSERT or REPLACE ... SELECT into table with global indexes")
+	errGlobalIndexMultiTable = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+		"multiple-table update of global index column")
+)
+
+// GlobalIndex is global secondary index of columns in sharded tables, value of column to data nodes of rows.
+type GlobalIndex interface {
+	// Lookup data nodes of rows whose column is any of values.
+	Lookup(schemaName, table, column string, values []string) ([]string, error)
+	// Add values of columns in data nodes, before rows are written.
+	Add(schemaName, table string, entries []GlobalIndexEntry) error
+}
+
+// GlobalIndexEntry is value of column in a data node.
+type GlobalIndexEntry struct {
+	Column string
+	Value  string
+	Node   string
+}
+
+// globalIndexColumns get global index columns of sharded table, in lower case.
+func globalIndexColumns(schemaConfig *config.SchemaConfig, table *sqlparser.TableName) []string {
+	if table == nil || len(table.Qualifier) > 0 {
+		return nil
+	}
+	tableConfig := schemaConfig.GetTables()[tableNameOf(table)]
+	if tableConfig == nil || tableConfig.GetType() != config.TableTypeSharded {
+		return nil
+	}
+	columns := make([]string, len(tableConfig.GlobalIndexes))
+	for i, column := range tableConfig.GlobalIndexes {
+		columns[i] = strings.Trim(strings.ToLower(column), "`")
+	}
+	return columns
+}
+
+func tableNameOf(table *sqlparser.TableName) string {
+	return strings.Trim(strings.ToLower(string(table.Name)), "`")
+}
+
+// globalIndexValue get value of constant in index table, null is not indexed as it's never equal.
+func globalIndexValue(expr sqlparser.ValExpr) (value string, null bool, err error) {
+	switch v := expr.(type) {
+	case sqlparser.StrVal:
+		value = string(v)
+	case sqlparser.NumVal:
+		value = string(v)
+	case *sqlparser.NullVal:
+		return "", true, nil
+	case *sqlparser.UnaryExpr:
+		num, ok := v.Expr.(sqlparser.NumVal)
+		if !ok || v.Operator != sqlparser.AST_UMINUS {
+			return "", false, errGlobalIndexValue
+		}
+		value = "-" + string(num)
+	default:
+		return "", false, errGlobalIndexValue
+	}
+	if len(value) > MaxGlobalIndexValueLen {
+		return "", false, errGlobalIndexValueTooLong
+	}
+	return value, false, nil
+}
+
+// routeByGlobalIndex route by value or IN list of global index column in where, return nil if not found.
+// Statement is sent as it is to nodes of rows, or the first node if no row.
+func (r *Router) routeByGlobalIndex(schemaConfig *config.SchemaConfig, table *sqlparser.TableName, where *sqlparser.Where) (*inListRoute, error) {
+	if where == nil || where.Expr == nil {
+		return nil, nil
+	}
+	for _, column := range globalIndexColumns(schemaConfig, table) {
+		var exprs []sqlparser.ValExpr
+		if colValue, err := sqlparser.CheckColumnInBoolExpr(where.Expr, column); err == nil && colValue != nil {
+			exprs = []sqlparser.ValExpr{colValue}
+		} else if inList := sqlparser.FindInList(where, column); inList != nil {
+			exprs = inList.Right.(sqlparser.ValTuple)
+		} else {
+			continue
+		}
+		values := make([]string, 0, len(exprs))
+		for _, expr := range exprs {
+			value, null, err := globalIndexValue(expr)
+			if err != nil {
+				values = nil
+				break
+			}
+			if !null {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if r.GlobalIndex == nil {
+			return nil, errors.ErrNoGlobalIndex
+		}
+		nodeNames, err := r.GlobalIndex.Lookup(r.SchemaName, tableNameOf(table), column, values)
+		if err != nil {
+			return nil, err
+		}
+		r.debugf("global index %s.%s of %d values in nodes %s", table.Name, column, len(values), strings.Join(nodeNames, ","))
+		if len(nodeNames) == 0 {
+			nodeNames = r.shardNodes(schemaConfig)[:1]
+		}
+		return &inListRoute{nodeNames: nodeNames}, nil
+	}
+	return nil, nil
+}
+
+// singleTable get the only table of select, nil if many tables or subqueries.
+func singleTable(statement *sqlparser.Select) *sqlparser.TableName {
+	tableNames := sqlparser.GetTableNames(statement)
+	if len(tableNames) != 1 {
+		return nil
+	}
+	return tableNames[0]
+}
+
+// addGlobalIndexOfInsert add values of global index columns in rows inserted to nodes, and in ON DUPLICATE KEY UPDATE.
+func (r *Router) addGlobalIndexOfInsert(schemaConfig *config.SchemaConfig, table *sqlparser.TableName, columns sqlparser.Columns,
+	rows sqlparser.InsertRows, onDup sqlparser.OnDup, nodeNames []string) error {
+	indexColumns := globalIndexColumns(schemaConfig, table)
+	if len(indexColumns) == 0 {
+		return nil
+	}
+	values, ok := rows.(sqlparser.Values)
+	if !ok {
+		return errGlobalIndexInsertSelect
+	}
+	var entries []GlobalIndexEntry
+	add := func(column string, expr sqlparser.ValExpr) error {
+		value, null, err := globalIndexValue(expr)
+		if err != nil || null {
+			return err
+		}
+		for _, nodeName := range nodeNames {
+			entries = append(entries, GlobalIndexEntry{Column: column, Value: value, Node: nodeName})
+		}
+		return nil
+	}
+	for i, columnExpr := range columns {
+		nonStar, ok := columnExpr.(*sqlparser.NonStarExpr)
+		if !ok {
+			continue
+		}
+		colName, ok := nonStar.Expr.(*sqlparser.ColName)
+		if !ok || !isGlobalIndexColumn(indexColumns, colName) {
+			continue
+		}
+		for _, row := range values {
+			if tuple, ok := row.(sqlparser.ValTuple); ok && i < len(tuple) {
+				if err := add(strings.Trim(strings.ToLower(string(colName.Name)), "`"), tuple[i]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, updateExpr := range onDup {
+		if isGlobalIndexColumn(indexColumns, updateExpr.Name) {
+			if err := add(strings.Trim(strings.ToLower(string(updateExpr.Name.Name)), "`"), updateExpr.Expr); err != nil {
+				return err
+			}
+		}
+	}
+	return r.addGlobalIndex(table, entries)
+}
+
+// addGlobalIndexOfUpdate add values of global index columns set by update to nodes of update.
+func (r *Router) addGlobalIndexOfUpdate(schemaConfig *config.SchemaConfig, statement *sqlparser.Update, nodeNames []string) error {
+	if statement.Table == nil {
+		for _, table := range sqlparser.GetTableNames(statement) {
+			indexColumns := globalIndexColumns(schemaConfig, table)
+			for _, updateExpr := range statement.Exprs {
+				if isGlobalIndexColumn(indexColumns, updateExpr.Name) {
+					return errGlobalIndexMultiTable
+				}
+			}
+		}
+		return nil
+	}
+	indexColumns := globalIndexColumns(schemaConfig, statement.Table)
+	var entries []GlobalIndexEntry
+	for _, updateExpr := range statement.Exprs {
+		if !isGlobalIndexColumn(indexColumns, updateExpr.Name) {
+			continue
+		}
+		value, null, err := globalIndexValue(updateExpr.Expr)
+		if err != nil {
+			return err
+		} else if null {
+			continue
+		}
+		for _, nodeName := range nodeNames {
+			entries = append(entries, GlobalIndexEntry{Column: strings.Trim(strings.ToLower(string(updateExpr.Name.Name)), "`"),
+				Value: value, Node: nodeName})
+		}
+	}
+	return r.addGlobalIndex(statement.Table, entries)
+}
+
+func (r *Router) addGlobalIndex(table *sqlparser.TableName, entries []GlobalIndexEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if r.GlobalIndex == nil {
+		return errors.ErrNoGlobalIndex
+	}
+	r.debugf("global index of %s add %d values", table.Name, len(entries))
+	return r.GlobalIndex.Add(r.SchemaName, tableNameOf(table), entries)
+}
+
+func isGlobalIndexColumn(indexColumns []string, colName *sqlparser.ColName) bool {
+	if len(indexColumns) == 0 || colName == nil {
+		return false
+	}
+	name := strings.Trim(strings.ToLower(string(colName.Name)), "`")
+	for _, column := range indexColumns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// Decision is routing decision of a sql statement.
+type Decision struct {
+	SQL         string
+	Schema      string   // Schema to route, may be changed by 'USE db'.
+	NodeNames   []string // Data nodes to execute.
+	OnSlave     bool
+	PlanSQL     string   // Rewritten sql to execute in data nodes.
+	MirrorNodes []string // Data nodes of dual-write in shard migration.
+	Err         error
+
+	parseFailed bool
+}
+
+// String format decision as 'nodes[:slave] <- plan sql', or error.
+func (d *Decision) String() string {
+	if d.Err != nil {
+		return "error: " + d.Err.Error()
+	}
+	s := strings.Join(d.NodeNames, ",")
+	if d.OnSlave {
+		s += ":slave"
+	}
+	if len(d.MirrorNodes) > 0 {
+		s += "+" + strings.Join(d.MirrorNodes, ",")
+	}
+	return s + " <- " + d.PlanSQL
+}
+
+// RouteStatements route sql statements in order by shard rules of config, without live backends.
+// Routing is deterministic, so that shard config can be tested in CI, such as:
+//
+//	cfg, _ := config.ParseConfigFile("ss.yaml")
+//	decisions, _ := route.RouteStatements(cfg, "db1", []string{"select * from table1 where tenantid = 1"})
+func RouteStatements(cfg *config.Config, schemaName string, sqls []string) ([]*Decision, error) {
+	schemas := make(map[string]*config.SchemaConfig, len(cfg.Schemas))
+	for i := range cfg.Schemas {
+		schemas[cfg.Schemas[i].Name] = &cfg.Schemas[i]
+	}
+	if schemas[schemaName] == nil {
+		return nil, fmt.Errorf("schema '%s' not exists", schemaName)
+	}
+	for _, schemaConfig := range schemas {
+		if len(schemaConfig.Nodes) == 0 {
+			return nil, fmt.Errorf("no data node in schema '%s'", schemaConfig.Name)
+		}
+	}
+	r := NewRouter(schemaName, schemas, cfg.GetNodes(), 0, "", false)
+	return r.RouteStatements(sqls), nil
+}
+
+// RouteStatements route sql statements in order without executing them, 'USE db' changes schema of later statements,
+// and BEGIN or COMMIT changes transaction of later statements.
+// Dark reads are not included, as they are sampled randomly.
+func (r *Router) RouteStatements(sqls []string) []*Decision {
+	defer func(schemaName string) { r.SchemaName = schemaName }(r.SchemaName)
+	defer func(inTrans bool) { r.InTrans = inTrans }(r.InTrans)
+	decisions := make([]*Decision, len(sqls))
+	for i, sql := range sqls {
+		decisions[i] = r.routeStatement(strings.TrimSpace(sql))
+	}
+	return decisions
+}
+
+func (r *Router) routeStatement(sql string) *Decision {
+	d := new(Decision)
+	d.SQL = sql
+	d.Schema = r.SchemaName
+	statement, err := sqlparser.Parse(sql)
+	if err != nil {
+		// same as proxy, unsharded schema send the sql not supported by parser to backend as it is.
+		if schemaConfig := r.Schemas[r.SchemaName]; schemaConfig == nil || schemaConfig.ShardEnabled() {
+			d.Err = err
+			d.parseFailed = true
+			return d
+		}
+		statement = &sqlparser.Passthrough{SQL: []byte(sql)}
+	}
+	if statement == nil {
+		d.Err = fmt.Errorf("empty statement")
+		return d
+	}
+	if useDB, ok := statement.(*sqlparser.UseDB); ok {
+		db := strings.Trim(useDB.DB, "`")
+		if r.Schemas[db] == nil {
+			d.Err = fmt.Errorf("schema '%s' not exists", db)
+			return d
+		}
+		r.SchemaName = db
+		d.Schema = db
+	}
+	plan, err := r.BuildNormalPlan(statement)
+	if err != nil {
+		d.Err = err
+		return d
+	}
+	// transaction of session is tracked as proxy, later statements are routed in it.
+	if beginsTrans(statement) {
+		r.InTrans = true
+	} else if endsTrans(statement) {
+		r.InTrans = false
+	}
+	d.NodeNames = plan.GetNodeNames()
+	d.OnSlave = plan.OnSlave()
+	d.PlanSQL = plan.GetPlanSQL()
+	for _, mirror := range plan.GetMirrors() {
+		if !mirror.Read {
+			d.MirrorNodes = append(d.MirrorNodes, mirror.NodeNames...)
+		}
+	}
+	return d
+}
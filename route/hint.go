@@ -23,21 +23,32 @@
 package route
 
 import (
+	"strconv"
 	"strings"
 
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/net/mysql"
 	"github.com/berkaroad/saashard/sqlparser"
 	"github.com/berkaroad/saashard/utils"
 )
 
 var hintPrefix = "/*!saashard "
 var hintNodesPrefix = "nodes="
+var hintNodePrefix = "node="
+var hintShardKeyPrefix = "shardkey="
+var hintIdempotencyKeyPrefix = "idempotency_key="
+var hintVersionPrefix = "version="
 
 // Hint to extra process.
-// Nodes: /*!saashard nodes=node1,node2 */
+// Nodes: /*!saashard nodes=node1,node2 */ or /*!saashard node=node1 */, statement is forced to the nodes.
 // OnMaster: /*!saashard master */
+// Version: /*!saashard version=5 */, expected version of UPDATE on table with version column.
+// ShardKey: /*!saashard shardkey=123 */ or /* shardkey=123 */, value of shard key if it's not in statement, case sensitive.
 type Hint struct {
 	OnMaster bool
 	Nodes    []string
+	Version  string
+	ShardKey string
 }
 
 // ReadHint read hint from comments
@@ -47,21 +58,115 @@ func ReadHint(comments *sqlparser.Comments) *Hint {
 		commentStr := string(comment)
 		if strings.HasPrefix(commentStr, hintPrefix) {
 			commentStr = strings.TrimSuffix(strings.TrimPrefix(commentStr, hintPrefix), "*/")
-			commentStr = strings.ToLower(strings.TrimSpace(commentStr))
+			rawStr := strings.TrimSpace(commentStr)
+			commentStr = strings.ToLower(rawStr)
 			if commentStr == "master" {
 				hint.OnMaster = true
-			} else if strings.HasPrefix(commentStr, hintNodesPrefix) {
-				nodesStr := strings.TrimPrefix(commentStr, hintNodesPrefix)
+			} else if strings.HasPrefix(commentStr, hintNodesPrefix) || strings.HasPrefix(commentStr, hintNodePrefix) {
+				nodesStr := commentStr[strings.Index(commentStr, "=")+1:]
 				for _, nodeStr := range strings.Split(nodesStr, ",") {
 					node := strings.TrimSpace(nodeStr)
-					if !utils.Contains(hint.Nodes, node) {
+					if len(node) > 0 && !utils.Contains(hint.Nodes, node) {
 						hint.Nodes = append(hint.Nodes, node)
 					}
 				}
+			} else if strings.HasPrefix(commentStr, hintVersionPrefix) {
+				hint.Version = strings.TrimSpace(strings.TrimPrefix(commentStr, hintVersionPrefix))
+			} else if strings.HasPrefix(commentStr, hintShardKeyPrefix) {
+				hint.ShardKey = strings.TrimSpace(rawStr[len(hintShardKeyPrefix):])
 			}
 			([][]byte)(*comments)[i] = []byte("")
+		} else if plainStr := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(commentStr, "/*"), "*/")); strings.HasPrefix(strings.ToLower(plainStr), hintShardKeyPrefix) {
+			hint.ShardKey = strings.TrimSpace(plainStr[len(hintShardKeyPrefix):])
+			([][]byte)(*comments)[i] = []byte("")
 		}
 	}
 	//fmt.Printf("Hints.Nodes='%s'; Hints.OnMaster='%v'\n", strings.Join(hint.Nodes, ","), hint.OnMaster)
 	return hint
 }
+
+// shardKeyValue get value of shard key in hint, number or string, nil if not in hint.
+func (hint *Hint) shardKeyValue() sqlparser.ValExpr {
+	if len(hint.ShardKey) == 0 {
+		return nil
+	}
+	value := hint.ShardKey
+	if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') && value[len(value)-1] == value[0] {
+		return sqlparser.StrVal(value[1 : len(value)-1])
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return sqlparser.NumVal(value)
+	}
+	return sqlparser.StrVal(value)
+}
+
+// hintNodes get nodes of hint to force statement on sharded tables to them, nil if not in hint.
+// It's error if any node is not of schema.
+func (r *Router) hintNodes(schemaConfig *config.SchemaConfig, hint *Hint) ([]string, error) {
+	if len(hint.Nodes) == 0 {
+		return nil, nil
+	}
+	shardNodes := r.allShardNodes(schemaConfig)
+	for _, node := range hint.Nodes {
+		if !utils.Contains(shardNodes, node) {
+			return nil, mysql.NewError(mysql.ER_WRONG_ARGUMENTS, "data node '"+node+"' of hint not in schema '"+r.SchemaName+"'")
+		}
+	}
+	r.debugf("forced to nodes %s by hint", strings.Join(hint.Nodes, ","))
+	return hint.Nodes, nil
+}
+
+// shardAccess is how rows of shard key are accessed.
+type shardAccess int
+
+const (
+	shardRead shardAccess = iota
+	shardWrite
+	// shardInsert assigns node to new shard key in directory shard algorithm.
+	shardInsert
+)
+
+// hintShardNode get node and mirror node by shard key of hint, or return the error if not in hint.
+func (r *Router) hintShardNode(schemaConfig *config.SchemaConfig, hint *Hint, access shardAccess, err error) (string, string, error) {
+	colValue := hint.shardKeyValue()
+	if colValue == nil {
+		return "", "", err
+	}
+	r.debugf("shard key %s of hint", hint.ShardKey)
+	if access == shardRead {
+		return r.shardReadNode(schemaConfig, colValue)
+	}
+	return r.shardWriteNode(schemaConfig, colValue, access == shardInsert)
+}
+
+// ReadIdempotencyKey read and remove idempotency key of write statement, key is case sensitive.
+// IdempotencyKey: /*idempotency_key=key1*/ or /*!saashard idempotency_key=key1 */
+func ReadIdempotencyKey(statement sqlparser.Statement) string {
+	var comments *sqlparser.Comments
+	switch v := statement.(type) {
+	case *sqlparser.Insert:
+		comments = &v.Comments
+	case *sqlparser.Update:
+		comments = &v.Comments
+	case *sqlparser.Delete:
+		comments = &v.Comments
+	case *sqlparser.Replace:
+		comments = &v.Comments
+	default:
+		return ""
+	}
+	var key string
+	for i, comment := range *comments {
+		commentStr := strings.TrimSpace(strings.TrimSuffix(string(comment), "*/"))
+		if strings.HasPrefix(commentStr, hintPrefix) {
+			commentStr = strings.TrimSpace(strings.TrimPrefix(commentStr, hintPrefix))
+		} else {
+			commentStr = strings.TrimSpace(strings.TrimPrefix(commentStr, "/*"))
+		}
+		if strings.HasPrefix(strings.ToLower(commentStr), hintIdempotencyKeyPrefix) {
+			key = strings.TrimSpace(commentStr[len(hintIdempotencyKeyPrefix):])
+			([][]byte)(*comments)[i] = []byte("")
+		}
+	}
+	return key
+}
@@ -0,0 +1,201 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+var errInListInMulti = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+	"DISTINCT, SQL_CALC_FOUND_ROWS or window function with IN list or range of shard key across nodes")
+var errInListLimitInMulti = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+	"LIMIT of update or delete with IN list or range of shard key across nodes")
+var errHintNodesInUnion = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET, "UNION in many nodes of hint")
+var errHintNodesInInsert = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET, "INSERT or REPLACE in many nodes of hint")
+
+var aggregateFuncs = map[string]bool{
+	"avg": true, "bit_and": true, "bit_or": true, "bit_xor": true, "count": true, "group_concat": true,
+	"max": true, "min": true, "std": true, "stddev": true, "sum": true, "variance": true,
+}
+
+// inListRoute is values of IN list of shard key grouped by node, and chunked by in_list_chunk_size.
+// inList is nil if routed by range of shard key, then the statement is sent to each node as it is.
+type inListRoute struct {
+	inList    *sqlparser.ComparisonExpr
+	nodeNames []string
+	values    []sqlparser.ValTuple // same order as nodeNames, a node is repeated if chunked.
+}
+
+// routeByInList route by IN list of shard key in where, return nil if not found.
+func (r *Router) routeByInList(schemaConfig *config.SchemaConfig, where *sqlparser.Where) (*inListRoute, error) {
+	inList := sqlparser.FindInList(where, schemaConfig.ShardKey)
+	if inList == nil {
+		return nil, nil
+	}
+	var nodeNames []string
+	groups := make(map[string]sqlparser.ValTuple)
+	seen := make(map[string]bool)
+	for _, value := range inList.Right.(sqlparser.ValTuple) {
+		key := sqlparser.String(value)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		nodeName, err := r.shardNode(schemaConfig, value, false)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := groups[nodeName]; !ok {
+			nodeNames = append(nodeNames, nodeName)
+		}
+		groups[nodeName] = append(groups[nodeName], value)
+	}
+
+	route := &inListRoute{inList: inList}
+	chunkSize := schemaConfig.InListChunkSize
+	for _, nodeName := range nodeNames {
+		values := groups[nodeName]
+		for len(values) > 0 {
+			chunk := values
+			if chunkSize > 0 && len(chunk) > chunkSize {
+				chunk = values[:chunkSize]
+			}
+			values = values[len(chunk):]
+			route.nodeNames = append(route.nodeNames, nodeName)
+			route.values = append(route.values, chunk)
+		}
+	}
+	r.debugf("IN list of shard key %s has %d values, grouped by nodes %s", schemaConfig.ShardKey, len(seen),
+		strings.Join(nodeNames, ","))
+	return route, nil
+}
+
+// apply set nodes of plan, and statement rewritten by rewrite func with IN list of the node.
+func (route *inListRoute) apply(plan *normalPlan, where *sqlparser.Where, rewrite func(*sqlparser.Where) sqlparser.Statement) {
+	plan.nodeNames = route.nodeNames
+	statements := make([]sqlparser.Statement, len(route.nodeNames))
+	for i := range route.nodeNames {
+		if route.inList == nil {
+			statements[i] = rewrite(where)
+			continue
+		}
+		statements[i] = rewrite(sqlparser.ReplaceInList(where, route.inList, route.values[i]))
+	}
+	if len(statements) == 1 {
+		plan.Statement = statements[0]
+		return
+	}
+	plan.nodeStatements = statements
+}
+
+// selectInList route select by IN list or range of shard key if not routed by shard key, or return the error.
+// Rows of select in multi nodes are merged by aggregate, GROUP BY, HAVING, ORDER BY and LIMIT.
+func (r *Router) selectInList(schemaConfig *config.SchemaConfig, statement *sqlparser.Select, err error) (*inListRoute, error) {
+	route, inErr := r.routeByInList(schemaConfig, statement.Where)
+	if inErr != nil {
+		return nil, inErr
+	} else if route == nil {
+		if route = r.routeByRange(schemaConfig, statement.Where); route == nil {
+			if route, inErr = r.routeByGlobalIndex(schemaConfig, singleTable(statement), statement.Where); inErr != nil {
+				return nil, inErr
+			} else if route == nil {
+				return nil, err
+			}
+		}
+	}
+	if len(route.nodeNames) > 1 {
+		if err := checkSelectInMulti(statement); err != nil {
+			return nil, err
+		}
+	}
+	return route, nil
+}
+
+// checkSelectInMulti check rows of select in multi nodes could be merged.
+func checkSelectInMulti(statement *sqlparser.Select) error {
+	if len(statement.Distinct) > 0 || statement.CalcFoundRows || hasWindowFunc(statement) {
+		return errInListInMulti
+	}
+	aggregation, err := NewAggregation(statement)
+	if err != nil {
+		return err
+	}
+	_, err = NewSort(statement, aggregation)
+	return err
+}
+
+// dmlInList route update or delete by IN list or range of shard key, or global index of table,
+// if not routed by shard key, or return the error.
+// Not routed by IN list in dual-write, as mirror of multi nodes is not supported.
+func (r *Router) dmlInList(schemaConfig *config.SchemaConfig, table *sqlparser.TableName, where *sqlparser.Where, limit *sqlparser.Limit, err error) (*inListRoute, error) {
+	if schemaConfig.GetDualWrite() != config.DualWriteOff {
+		return nil, err
+	}
+	route, inErr := r.routeByInList(schemaConfig, where)
+	if inErr != nil {
+		return nil, inErr
+	} else if route == nil {
+		if route = r.routeByRange(schemaConfig, where); route == nil {
+			if route, inErr = r.routeByGlobalIndex(schemaConfig, table, where); inErr != nil {
+				return nil, inErr
+			} else if route == nil {
+				return nil, err
+			}
+		}
+	}
+	if len(route.nodeNames) > 1 && limit != nil {
+		return nil, errInListLimitInMulti
+	}
+	return route, nil
+}
+
+// hasAggregate is true if any aggregate function in select expressions or HAVING, except window functions.
+func hasAggregate(node sqlparser.SQLNode) bool {
+	found := false
+	sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if _, ok := node.(*sqlparser.WindowFuncExpr); ok {
+			return false, nil
+		}
+		if funcExpr, ok := node.(*sqlparser.FuncExpr); ok && aggregateFuncs[strings.ToLower(string(funcExpr.Name))] {
+			found = true
+		}
+		return !found, nil
+	}, node)
+	return found
+}
+
+// hasWindowFunc is true if any window function in statement, it's evaluated over rows of a node.
+func hasWindowFunc(node sqlparser.SQLNode) bool {
+	found := false
+	sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if _, ok := node.(*sqlparser.WindowFuncExpr); ok {
+			found = true
+		}
+		return !found, nil
+	}, node)
+	return found
+}
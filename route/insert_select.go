@@ -0,0 +1,146 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+var errInsertSelectAcrossNodes = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+	"INSERT ... SELECT across nodes, rows selected should be inserted in the same node")
+
+// insertSelectRoute route INSERT or REPLACE ... SELECT in sharded schema, rows selected should be inserted in the same node.
+// Shard key inserted is a value in the node of select, or shard key of the sharded table selected,
+// then it's executed in each node of select, as rows are in the node of their shard key.
+func (r *Router) insertSelectRoute(schemaConfig *config.SchemaConfig, table *sqlparser.TableName, columns sqlparser.Columns,
+	rows sqlparser.SelectStatement, onDup sqlparser.OnDup) (*tableRoute, error) {
+	target, err := r.checkTableInDML(schemaConfig, table, table)
+	if err != nil {
+		return nil, err
+	}
+	if !schemaConfig.CheckTableDisabled {
+		if err = sqlparser.CheckTableExprsInSelect(rows, schemaConfig.GetTables()); err != nil {
+			return nil, err
+		}
+	}
+	source, err := r.routeByTables(schemaConfig, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// global table is inserted by global tables in each node, single table is inserted by rows in its node.
+	if target.nodeNames != nil {
+		if target.global {
+			if source.global {
+				return target, nil
+			}
+			return nil, errInsertSelectAcrossNodes
+		}
+		if err = r.checkSelectNode(schemaConfig, source, rows, target.nodeNames[0]); err != nil {
+			return nil, err
+		}
+		return target, nil
+	}
+
+	colValue, err := sqlparser.CheckColumnInInsertOrReplace(columns, rows, onDup, schemaConfig.ShardKey)
+	if err != nil {
+		return nil, err
+	}
+	route := new(tableRoute)
+	if colName, ok := colValue.(*sqlparser.ColName); ok {
+		if source.nodeNames != nil || sqlparser.GetColName(colName) != schemaConfig.ShardKey {
+			return nil, errInsertSelectAcrossNodes
+		}
+		if nodeName, _, err := r.shardNodeInSelect(schemaConfig, rows); err == nil {
+			route.nodeNames = []string{nodeName}
+			return route, nil
+		}
+		// LIMIT or aggregate without GROUP BY in each node is different from it in all rows.
+		if r.shardedTables(schemaConfig, rows) > 1 || !isColocated(rows) {
+			return nil, errInsertSelectAcrossNodes
+		}
+		route.nodeNames = r.shardNodes(schemaConfig)
+		return route, nil
+	}
+	switch colValue.(type) {
+	case sqlparser.StrVal, sqlparser.NumVal:
+	default:
+		return nil, errInsertSelectAcrossNodes
+	}
+	nodeName, err := r.shardNode(schemaConfig, colValue, true)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.checkSelectNode(schemaConfig, source, rows, nodeName); err != nil {
+		return nil, err
+	}
+	route.nodeNames = []string{nodeName}
+	return route, nil
+}
+
+// checkSelectNode check rows selected are in the node, global tables are in all nodes.
+func (r *Router) checkSelectNode(schemaConfig *config.SchemaConfig, source *tableRoute, rows sqlparser.SelectStatement, nodeName string) error {
+	if source.nodeNames != nil {
+		if source.global || source.nodeNames[0] == nodeName {
+			return nil
+		}
+		return errInsertSelectAcrossNodes
+	}
+	selectNodeName, _, err := r.shardNodeInSelect(schemaConfig, rows)
+	if err != nil {
+		return err
+	}
+	if selectNodeName != nodeName {
+		return errInsertSelectAcrossNodes
+	}
+	return nil
+}
+
+// shardedTables count sharded tables in statement, not configured table is as sharded.
+func (r *Router) shardedTables(schemaConfig *config.SchemaConfig, node sqlparser.SQLNode) int {
+	tables := schemaConfig.GetTables()
+	count := 0
+	for _, tableName := range sqlparser.GetTableNames(node) {
+		if len(tableName.Qualifier) > 0 {
+			continue
+		}
+		tableConfig := tables[strings.Trim(strings.ToLower(string(tableName.Name)), "`")]
+		if tableConfig == nil || tableConfig.GetType() == config.TableTypeSharded {
+			count++
+		}
+	}
+	return count
+}
+
+// isColocated rows selected in each node are the same as rows of all nodes in the node, without LIMIT or aggregate without GROUP BY.
+func isColocated(rows sqlparser.SelectStatement) bool {
+	selectStmt, ok := rows.(*sqlparser.Select)
+	if !ok {
+		return false
+	}
+	return selectStmt.Limit == nil && (len(selectStmt.GroupBy) > 0 || !hasAggregate(selectStmt.SelectExprs))
+}
@@ -0,0 +1,137 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"sync"
+	"time"
+)
+
+// AdvisoryLocks are named locks of GET_LOCK() shared by sessions of proxy, so that sessions in different shards
+// are mutually exclusive. A session may hold many locks, and get the same lock again.
+type AdvisoryLocks struct {
+	sync.Mutex
+	locks map[string]*advisoryLock
+}
+
+type advisoryLock struct {
+	owner    uint32 // connection id of session.
+	count    int
+	released chan struct{} // closed when released.
+}
+
+// NewAdvisoryLocks create advisory locks.
+func NewAdvisoryLocks() *AdvisoryLocks {
+	l := new(AdvisoryLocks)
+	l.locks = make(map[string]*advisoryLock)
+	return l
+}
+
+// Get lock by name, wait until timeout if held by another session, negative timeout is infinite.
+// False if timeout or canceled.
+func (l *AdvisoryLocks) Get(name string, owner uint32, timeout time.Duration, cancel <-chan struct{}) bool {
+	var expired <-chan time.Time
+	if timeout >= 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for {
+		l.Lock()
+		lock := l.locks[name]
+		if lock == nil {
+			l.locks[name] = &advisoryLock{owner: owner, count: 1, released: make(chan struct{})}
+			l.Unlock()
+			return true
+		}
+		if lock.owner == owner {
+			lock.count++
+			l.Unlock()
+			return true
+		}
+		released := lock.released
+		l.Unlock()
+
+		select {
+		case <-released:
+		case <-expired:
+			return false
+		case <-cancel:
+			return false
+		}
+	}
+}
+
+// Release lock by name once, released is false if held by another session, exists is false if not held.
+func (l *AdvisoryLocks) Release(name string, owner uint32) (released bool, exists bool) {
+	l.Lock()
+	defer l.Unlock()
+	lock := l.locks[name]
+	if lock == nil {
+		return false, false
+	}
+	if lock.owner != owner {
+		return false, true
+	}
+	if lock.count--; lock.count == 0 {
+		delete(l.locks, name)
+		close(lock.released)
+	}
+	return true, true
+}
+
+// ReleaseAll release all locks of session, return count of locks released.
+func (l *AdvisoryLocks) ReleaseAll(owner uint32) int {
+	l.Lock()
+	defer l.Unlock()
+	count := 0
+	for name, lock := range l.locks {
+		if lock.owner == owner {
+			count += lock.count
+			delete(l.locks, name)
+			close(lock.released)
+		}
+	}
+	return count
+}
+
+// Owner get connection id of session holding the lock.
+func (l *AdvisoryLocks) Owner(name string) (uint32, bool) {
+	l.Lock()
+	defer l.Unlock()
+	if lock := l.locks[name]; lock != nil {
+		return lock.owner, true
+	}
+	return 0, false
+}
+
+// Locks get owners of locks held, by name.
+func (l *AdvisoryLocks) Locks() map[string]uint32 {
+	l.Lock()
+	defer l.Unlock()
+	owners := make(map[string]uint32, len(l.locks))
+	for name, lock := range l.locks {
+		owners[name] = lock.owner
+	}
+	return owners
+}
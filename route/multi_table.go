@@ -0,0 +1,212 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+var errMultiTableNotColocated = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+	"multiple-table UPDATE or DELETE across nodes, shard key of each sharded table should be the same value")
+
+// checkTablesInMultiTableDML check tables of multiple-table UPDATE or DELETE, and route by type of them.
+func (r *Router) checkTablesInMultiTableDML(schemaConfig *config.SchemaConfig, statement sqlparser.SQLNode,
+	tabExprs sqlparser.TableExprs, where *sqlparser.Where) (*tableRoute, error) {
+	if !schemaConfig.CheckTableDisabled {
+		if err := sqlparser.CheckTableExprsInSelect(&sqlparser.Select{From: tabExprs, Where: where}, schemaConfig.GetTables()); err != nil {
+			return nil, err
+		}
+		if deleteStmt, ok := statement.(*sqlparser.Delete); ok {
+			// db of targets is removed as tables.
+			for _, target := range deleteStmt.Targets {
+				if !sqlparser.IsSystemDB(strings.ToLower(string(target.Qualifier))) {
+					target.Qualifier = nil
+				}
+			}
+		}
+	}
+	return r.routeByTables(schemaConfig, statement)
+}
+
+// shardNodeInMultiTable get node and mirror node of multiple-table UPDATE or DELETE, tables should be in the same node.
+// Sharded tables are co-located, if shard key of each one is equal to the value,
+// or to shard key of another co-located one, in where and join expression.
+func (r *Router) shardNodeInMultiTable(schemaConfig *config.SchemaConfig, tabExprs sqlparser.TableExprs, where *sqlparser.Where) (string, string, error) {
+	colValue, err := sqlparser.CheckColumnInSelect(&sqlparser.Select{From: tabExprs, Where: where}, schemaConfig.ShardKey)
+	if err != nil {
+		return "", "", err
+	} else if colValue == nil {
+		return "", "", errors.ErrWhereOrJoinOnKey
+	}
+	if colocated, err := isMultiTableColocated(schemaConfig, tabExprs, where, nil); err != nil {
+		return "", "", err
+	} else if !colocated {
+		return "", "", errMultiTableNotColocated
+	}
+	return r.shardWriteNode(schemaConfig, colValue, false)
+}
+
+// isMultiTableColocated check each sharded table is co-located by shard key, in where and join expression.
+// Shard key of child table is its join key, so child is co-located with parent joined on it.
+// Error if column of child named as shard key of schema is compared to value, as it's not routed by it.
+// Common table expressions in cteNames are not tables, they're checked by select.
+func isMultiTableColocated(schemaConfig *config.SchemaConfig, tabExprs sqlparser.TableExprs, where *sqlparser.Where,
+	cteNames map[string]bool) (bool, error) {
+	// names or aliases of sharded tables to shard keys of them, and conditions in where and join expression.
+	sharded := make(map[string]string)
+	var conds []sqlparser.BoolExpr
+	if where != nil {
+		conds = appendAndConds(conds, where.Expr)
+	}
+	tables := schemaConfig.GetTables()
+	sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.AliasedTableExpr:
+			tableName, ok := n.Expr.(*sqlparser.TableName)
+			if !ok || len(tableName.Qualifier) > 0 || sqlparser.IsCTE(tableName, cteNames) {
+				// derived table is in node of shard key's value in it, checked by select.
+				return false, nil
+			}
+			name := strings.Trim(strings.ToLower(string(tableName.Name)), "`")
+			tableConfig := tables[name]
+			if tableConfig == nil || tableConfig.GetType() == config.TableTypeSharded {
+				shardKey := schemaConfig.GetShardKey(name)
+				if n.As != nil {
+					name = strings.Trim(strings.ToLower(string(n.As)), "`")
+				}
+				sharded[name] = shardKey
+			}
+			return false, nil
+		case *sqlparser.JoinTableExpr:
+			conds = appendAndConds(conds, n.On)
+		case *sqlparser.Subquery:
+			return false, nil
+		}
+		return true, nil
+	}, tabExprs)
+
+	// shard key equal to value, or to shard key of another table.
+	colocated := make(map[string]bool)
+	joined := make(map[string][]string)
+	qualifier := func(colName *sqlparser.ColName) string {
+		if len(colName.Qualifier) == 0 && len(sharded) == 1 {
+			for name := range sharded {
+				return name
+			}
+		}
+		return strings.Trim(strings.ToLower(string(colName.Qualifier)), "`")
+	}
+	// isTableShardKey check column is shard key of its table, name of it is empty if not a sharded table.
+	isTableShardKey := func(expr sqlparser.ValExpr) (string, bool) {
+		colName, ok := expr.(*sqlparser.ColName)
+		if !ok {
+			return "", false
+		}
+		name := qualifier(colName)
+		shardKey, ok := sharded[name]
+		return name, ok && sqlparser.GetColName(colName) == shardKey
+	}
+	isValue := func(expr sqlparser.ValExpr) bool {
+		switch expr.(type) {
+		case sqlparser.StrVal, sqlparser.NumVal:
+			return true
+		}
+		return false
+	}
+	// isChildColumn check column is named as shard key of schema, but table of it is child routed by join key.
+	isChildColumn := func(expr sqlparser.ValExpr) bool {
+		colName, ok := expr.(*sqlparser.ColName)
+		if !ok || sqlparser.GetColName(colName) != schemaConfig.ShardKey {
+			return false
+		}
+		shardKey, ok := sharded[qualifier(colName)]
+		return ok && shardKey != schemaConfig.ShardKey
+	}
+	for _, cond := range conds {
+		comparison, ok := cond.(*sqlparser.ComparisonExpr)
+		if !ok || comparison.Operator != sqlparser.AST_EQ {
+			continue
+		}
+		leftName, leftKey := isTableShardKey(comparison.Left)
+		rightName, rightKey := isTableShardKey(comparison.Right)
+		switch {
+		case leftKey && rightKey:
+			joined[leftName] = append(joined[leftName], rightName)
+			joined[rightName] = append(joined[rightName], leftName)
+		case leftKey && isValue(comparison.Right):
+			colocated[leftName] = true
+		case rightKey && isValue(comparison.Left):
+			colocated[rightName] = true
+		case isChildColumn(comparison.Left) && isValue(comparison.Right), isChildColumn(comparison.Right) && isValue(comparison.Left):
+			return false, errChildShardKeyColumn
+		}
+	}
+	// tables joined on shard key are in the same node, even if value of it is not in statement.
+	if len(colocated) == 0 {
+		for name := range sharded {
+			colocated[name] = true
+			break
+		}
+	}
+	pending := make([]string, 0, len(colocated))
+	for name := range colocated {
+		pending = append(pending, name)
+	}
+	for len(pending) > 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, other := range joined[name] {
+			if !colocated[other] {
+				colocated[other] = true
+				pending = append(pending, other)
+			}
+		}
+	}
+
+	for name := range sharded {
+		if !colocated[name] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// appendAndConds append conditions of AND expression.
+func appendAndConds(conds []sqlparser.BoolExpr, expr sqlparser.BoolExpr) []sqlparser.BoolExpr {
+	switch boolExpr := expr.(type) {
+	case nil:
+	case *sqlparser.AndExpr:
+		conds = appendAndConds(conds, boolExpr.Left)
+		conds = appendAndConds(conds, boolExpr.Right)
+	case *sqlparser.ParenBoolExpr:
+		conds = appendAndConds(conds, boolExpr.Expr)
+	default:
+		conds = append(conds, boolExpr)
+	}
+	return conds
+}
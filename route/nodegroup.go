@@ -0,0 +1,249 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// Node groups of blue/green deployment.
+const (
+	// NodeGroupBlue is nodes of schema, it's active by default.
+	NodeGroupBlue = "blue"
+	// NodeGroupGreen is green nodes of schema.
+	NodeGroupGreen = "green"
+)
+
+// Scopes of traffic to switch node group.
+const (
+	SwitchReads  = "reads"
+	SwitchWrites = "writes"
+	SwitchAll    = "all"
+)
+
+// NodeGroup is blue/green node groups of schema, and the active group of reads and writes.
+// It's immutable, replaced when switched.
+type NodeGroup struct {
+	Schema string
+	Blue   []string
+	Green  []string
+	Read   string
+	Write  string
+}
+
+// node of the group at the same position as the blue node, others are not changed.
+func (g *NodeGroup) node(nodeName string, group string) string {
+	if group != NodeGroupGreen {
+		return nodeName
+	}
+	for i, blueNode := range g.Blue {
+		if blueNode == nodeName {
+			return g.Green[i]
+		}
+	}
+	return nodeName
+}
+
+// blueNode get blue node of green node, others are not changed.
+func (g *NodeGroup) blueNode(nodeName string) string {
+	for i, greenNode := range g.Green {
+		if greenNode == nodeName {
+			return g.Blue[i]
+		}
+	}
+	return nodeName
+}
+
+// NodeGroups is blue/green node groups of schemas.
+// Readers get immutable snapshot without lock, writers replace it atomically.
+type NodeGroups struct {
+	sync.Mutex
+	groups atomic.Value // map[string]*NodeGroup
+	// next is node groups replacing this by reload, switches are forwarded to it, so that no switch is lost.
+	next *NodeGroups
+}
+
+// NewNodeGroups create node groups of schemas which have green nodes, blue is active.
+func NewNodeGroups(schemas map[string]*config.SchemaConfig) *NodeGroups {
+	groups := make(map[string]*NodeGroup)
+	for name, schemaConfig := range schemas {
+		if len(schemaConfig.GreenNodes) == 0 {
+			continue
+		}
+		groups[name] = newNodeGroup(name, schemaConfig)
+	}
+	s := new(NodeGroups)
+	s.groups.Store(groups)
+	return s
+}
+
+// newNodeGroup create node group of schema, blue is active.
+func newNodeGroup(name string, schemaConfig *config.SchemaConfig) *NodeGroup {
+	g := new(NodeGroup)
+	g.Schema = name
+	g.Blue = schemaConfig.Nodes
+	g.Green = schemaConfig.GreenNodes
+	g.Read = NodeGroupBlue
+	g.Write = NodeGroupBlue
+	return g
+}
+
+// Reload create node groups by schemas of reloaded config to replace this.
+// Group whose nodes are not changed keeps its active groups, others are reset to blue.
+func (s *NodeGroups) Reload(schemas map[string]*config.SchemaConfig) *NodeGroups {
+	s = s.latest()
+	defer s.Unlock()
+	groups := s.groups.Load().(map[string]*NodeGroup)
+	newGroups := make(map[string]*NodeGroup)
+	for name, schemaConfig := range schemas {
+		if len(schemaConfig.GreenNodes) == 0 {
+			continue
+		}
+		g := groups[name]
+		if g == nil || !reflect.DeepEqual(g.Blue, schemaConfig.Nodes) || !reflect.DeepEqual(g.Green, schemaConfig.GreenNodes) {
+			g = newNodeGroup(name, schemaConfig)
+		}
+		newGroups[name] = g
+	}
+	next := new(NodeGroups)
+	next.groups.Store(newGroups)
+	s.next = next
+	return next
+}
+
+// latest lock node groups not replaced by reload, following this.
+func (s *NodeGroups) latest() *NodeGroups {
+	s.Lock()
+	for s.next != nil {
+		next := s.next
+		s.Unlock()
+		s = next
+		s.Lock()
+	}
+	return s
+}
+
+// Get node group of schema.
+func (s *NodeGroups) Get(schemaName string) *NodeGroup {
+	return s.groups.Load().(map[string]*NodeGroup)[schemaName]
+}
+
+// List all node groups.
+func (s *NodeGroups) List() []*NodeGroup {
+	groups := s.groups.Load().(map[string]*NodeGroup)
+	list := make([]*NodeGroup, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, g)
+	}
+	return list
+}
+
+// Node get node of active group for reads or writes, that at the same position as the blue node.
+func (s *NodeGroups) Node(schemaName string, nodeName string, read bool) string {
+	g := s.Get(schemaName)
+	if g == nil {
+		return nodeName
+	}
+	if read {
+		return g.node(nodeName, g.Read)
+	}
+	return g.node(nodeName, g.Write)
+}
+
+// Switch reads, writes or all traffic of schema to the group.
+// Switch reads first to verify the new group, then writes.
+func (s *NodeGroups) Switch(schemaName string, group string, scope string) (*NodeGroup, error) {
+	if group != NodeGroupBlue && group != NodeGroupGreen {
+		return nil, fmt.Errorf("node group '%s' not supported", group)
+	}
+	s = s.latest()
+	defer s.Unlock()
+	groups := s.groups.Load().(map[string]*NodeGroup)
+	g, ok := groups[schemaName]
+	if !ok {
+		return nil, fmt.Errorf("schema '%s' has no green nodes", schemaName)
+	}
+	newGroup := *g
+	switch scope {
+	case SwitchReads:
+		newGroup.Read = group
+	case SwitchWrites:
+		newGroup.Write = group
+	case SwitchAll:
+		newGroup.Read = group
+		newGroup.Write = group
+	default:
+		return nil, fmt.Errorf("switch scope '%s' not supported", scope)
+	}
+	newGroups := make(map[string]*NodeGroup, len(groups))
+	for name, item := range groups {
+		newGroups[name] = item
+	}
+	newGroups[schemaName] = &newGroup
+	s.groups.Store(newGroups)
+	return &newGroup, nil
+}
+
+// applyNodeGroup replace nodes of plan by the active node group.
+// Reads out of transaction use read group, others use write group.
+// In transaction, keep in the group of node in transaction, even if switched.
+func (r *Router) applyNodeGroup(plan *normalPlan, nodeInTrans string) {
+	if r.NodeGroups == nil || plan == nil {
+		return
+	}
+	g := r.NodeGroups.Get(r.SchemaName)
+	if g == nil {
+		return
+	}
+	group := g.Write
+	if _, ok := plan.Statement.(sqlparser.SelectStatement); ok && !r.InTrans && !r.Sticky {
+		group = g.Read
+	}
+	if r.InTrans && len(nodeInTrans) > 0 {
+		group = NodeGroupBlue
+		for _, greenNode := range g.Green {
+			if greenNode == nodeInTrans {
+				group = NodeGroupGreen
+			}
+		}
+	}
+	nodeNames := make([]string, len(plan.nodeNames))
+	for i, nodeName := range plan.nodeNames {
+		nodeNames[i] = g.node(nodeName, group)
+	}
+	plan.nodeNames = nodeNames
+	if plan.mirror != nil {
+		mirror := *plan.mirror
+		mirror.NodeNames = make([]string, len(plan.mirror.NodeNames))
+		for i, nodeName := range plan.mirror.NodeNames {
+			mirror.NodeNames[i] = g.node(nodeName, group)
+		}
+		plan.mirror = &mirror
+	}
+}
@@ -0,0 +1,41 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// buildPassthroughPlan send raw sql to the only node of unsharded schema, always on master.
+func (r *Router) buildPassthroughPlan(statement *sqlparser.Passthrough) (*normalPlan, error) {
+	schemaConfig := r.Schemas[r.SchemaName]
+	if schemaConfig.ShardEnabled() {
+		return nil, errors.ErrNoPlan
+	}
+
+	plan := new(normalPlan)
+	plan.nodeNames = []string{schemaConfig.Nodes[0]}
+	plan.Statement = statement
+	return plan, nil
+}
@@ -0,0 +1,271 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+var (
+	errPlanPinStatement = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+		"plan pin of statement not select, insert, replace, update or delete")
+	errPlanPinOption = mysql.NewError(mysql.ER_WRONG_ARGUMENTS,
+		"plan pin option should be MASTER, NODE=<node> or FORCE_INDEX=<table>.<index>")
+)
+
+// PlanPin is routing and execution choices pinned to statements of a fingerprint in schema,
+// overriding automatic decisions, such as for a problematic query.
+type PlanPin struct {
+	Schema      string `json:"schema"`
+	Digest      string `json:"digest"`
+	Fingerprint string `json:"fingerprint"`
+	// OnMaster read on master, as hint 'master'.
+	OnMaster bool `json:"on_master,omitempty"`
+	// Node is the only node to execute in, as hint 'node=...'.
+	Node string `json:"node,omitempty"`
+	// ForceIndexes are 'table.index' injected as FORCE INDEX of the table, not to prepared statements.
+	ForceIndexes []string  `json:"force_indexes,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Hits is statements the pin applied to, since started.
+	Hits int64 `json:"-"`
+}
+
+// PlanPins is plan pins by schema and digest of fingerprint, saved to file as json if path is set.
+type PlanPins struct {
+	sync.RWMutex
+	path string
+	pins map[string]*PlanPin
+}
+
+// LoadPlanPins load plan pins saved in file, pins are only in memory if path is empty.
+func LoadPlanPins(path string) (*PlanPins, error) {
+	pins := new(PlanPins)
+	pins.path = path
+	pins.pins = make(map[string]*PlanPin)
+	if len(path) == 0 {
+		return pins, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return pins, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var list []*PlanPin
+	if err = json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for _, pin := range list {
+		pins.pins[planPinKey(pin.Schema, pin.Digest)] = pin
+	}
+	return pins, nil
+}
+
+func planPinKey(schemaName, digest string) string {
+	return schemaName + "/" + digest
+}
+
+// Pin choices to fingerprint in schema, replace the existing one, and save.
+func (pins *PlanPins) Pin(pin *PlanPin) error {
+	pins.Lock()
+	defer pins.Unlock()
+	key := planPinKey(pin.Schema, pin.Digest)
+	old := pins.pins[key]
+	pins.pins[key] = pin
+	if err := pins.save(); err != nil {
+		if old != nil {
+			pins.pins[key] = old
+		} else {
+			delete(pins.pins, key)
+		}
+		return err
+	}
+	return nil
+}
+
+// Unpin remove pin of digest in schema and save, false if not exists.
+func (pins *PlanPins) Unpin(schemaName, digest string) (bool, error) {
+	pins.Lock()
+	defer pins.Unlock()
+	key := planPinKey(schemaName, digest)
+	pin := pins.pins[key]
+	if pin == nil {
+		return false, nil
+	}
+	delete(pins.pins, key)
+	if err := pins.save(); err != nil {
+		pins.pins[key] = pin
+		return false, err
+	}
+	return true, nil
+}
+
+// List plan pins, ordered by schema and digest.
+func (pins *PlanPins) List() []*PlanPin {
+	pins.RLock()
+	defer pins.RUnlock()
+	list := make([]*PlanPin, 0, len(pins.pins))
+	for _, pin := range pins.pins {
+		list = append(list, pin)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return planPinKey(list[i].Schema, list[i].Digest) < planPinKey(list[j].Schema, list[j].Digest)
+	})
+	return list
+}
+
+// save write pins to temp file and rename it as file, so that file is not truncated on failure.
+func (pins *PlanPins) save() error {
+	if len(pins.path) == 0 {
+		return nil
+	}
+	list := make([]*PlanPin, 0, len(pins.pins))
+	for _, pin := range pins.pins {
+		list = append(list, pin)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return planPinKey(list[i].Schema, list[i].Digest) < planPinKey(list[j].Schema, list[j].Digest)
+	})
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := pins.path + ".tmp"
+	if err = ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, pins.path)
+}
+
+// get pin of statement in schema, nil if not pinned.
+func (pins *PlanPins) get(schemaName string, statement sqlparser.Statement) *PlanPin {
+	pins.RLock()
+	defer pins.RUnlock()
+	if len(pins.pins) == 0 {
+		return nil
+	}
+	return pins.pins[planPinKey(schemaName, sqlparser.FingerprintDigest(sqlparser.Fingerprint(statement)))]
+}
+
+// applyPlanPin apply pin of statement's fingerprint, by hints in comments and index hints of tables.
+func (r *Router) applyPlanPin(statement sqlparser.Statement) {
+	if r.PlanPins == nil {
+		return
+	}
+	comments := statementComments(statement)
+	if comments == nil {
+		return
+	}
+	pin := r.PlanPins.get(r.SchemaName, statement)
+	if pin == nil {
+		return
+	}
+	atomic.AddInt64(&pin.Hits, 1)
+	r.debugf("plan pinned by digest %s", pin.Digest)
+	if pin.OnMaster {
+		*comments = append(*comments, []byte(hintPrefix+"master */"))
+	}
+	if len(pin.Node) > 0 {
+		*comments = append(*comments, []byte(hintPrefix+hintNodePrefix+pin.Node+" */"))
+	}
+	for _, forceIndex := range pin.ForceIndexes {
+		dot := strings.Index(forceIndex, ".")
+		if dot <= 0 {
+			continue
+		}
+		tableName, indexName := forceIndex[:dot], forceIndex[dot+1:]
+		sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			if tableExpr, ok := node.(*sqlparser.AliasedTableExpr); ok && tableExpr.Hints == nil {
+				if name, ok := tableExpr.Expr.(*sqlparser.TableName); ok &&
+					strings.EqualFold(strings.Trim(string(name.Name), "`"), tableName) {
+					tableExpr.Hints = &sqlparser.IndexHints{Type: sqlparser.AST_FORCE, Indexes: [][]byte{[]byte(indexName)}}
+				}
+			}
+			return true, nil
+		}, statement)
+	}
+}
+
+// statementComments get comments of statement which hints are read from, nil if no comments.
+func statementComments(statement sqlparser.SQLNode) *sqlparser.Comments {
+	switch stmt := statement.(type) {
+	case *sqlparser.Select:
+		return &stmt.Comments
+	case *sqlparser.SimpleSelect:
+		return &stmt.Comments
+	case *sqlparser.Union:
+		return statementComments(stmt.Left)
+	case *sqlparser.Insert:
+		return &stmt.Comments
+	case *sqlparser.Replace:
+		return &stmt.Comments
+	case *sqlparser.Update:
+		return &stmt.Comments
+	case *sqlparser.Delete:
+		return &stmt.Comments
+	}
+	return nil
+}
+
+// NewPlanPin create pin of fingerprint of sql in schema, with options 'master', 'node=<node>' and
+// 'force_index=<table>.<index>'.
+func NewPlanPin(schemaName, sql string, options []string) (*PlanPin, error) {
+	statement, err := sqlparser.Parse(sql)
+	if err != nil {
+		return nil, mysql.NewError(mysql.ER_SYNTAX_ERROR, err.Error())
+	}
+	if statementComments(statement) == nil {
+		return nil, errPlanPinStatement
+	}
+	pin := &PlanPin{Schema: schemaName, CreatedAt: time.Now()}
+	pin.Fingerprint = sqlparser.Fingerprint(statement)
+	pin.Digest = sqlparser.FingerprintDigest(pin.Fingerprint)
+	for _, option := range options {
+		lower := strings.ToLower(option)
+		switch {
+		case lower == "master":
+			pin.OnMaster = true
+		case strings.HasPrefix(lower, hintNodePrefix) && len(option) > len(hintNodePrefix):
+			pin.Node = option[len(hintNodePrefix):]
+		case strings.HasPrefix(lower, "force_index=") && strings.Index(option, ".") > len("force_index="):
+			pin.ForceIndexes = append(pin.ForceIndexes, option[len("force_index="):])
+		default:
+			return nil, errPlanPinOption
+		}
+	}
+	if !pin.OnMaster && len(pin.Node) == 0 && len(pin.ForceIndexes) == 0 {
+		return nil, errPlanPinOption
+	}
+	return pin, nil
+}
@@ -0,0 +1,186 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/utils"
+)
+
+// RangeShardAlgo range shard algorithm, params are names of data nodes, replicas and ranges of shard key.
+// Value is in the node of the first range containing it.
+func RangeShardAlgo(val string, dataNodeCount int, params ...interface{}) (int, error) {
+	var nodes []string
+	var ranges []config.ShardRangeConfig
+	if len(params) > 0 {
+		nodes, _ = params[0].([]string)
+	}
+	if len(params) > 2 {
+		ranges, _ = params[2].([]config.ShardRangeConfig)
+	}
+	val = strings.Trim(val, "'")
+	for _, kr := range ranges {
+		if (len(kr.Min) > 0 && compareKey(kr.Min, val) > 0) || (len(kr.Max) > 0 && compareKey(val, kr.Max) >= 0) {
+			continue
+		}
+		for i, node := range nodes {
+			if node == kr.Node && i < dataNodeCount {
+				return i, nil
+			}
+		}
+		break
+	}
+	return 0, errors.ErrNoRangeInRangeShard
+}
+
+// CheckShardRanges check ranges of shard key in range shard algorithm, nodes of ranges should be in nodes.
+func CheckShardRanges(shardAlgo string, ranges []config.ShardRangeConfig, nodes []string) error {
+	if strings.ToLower(strings.TrimSpace(shardAlgo)) != "range" {
+		return nil
+	}
+	if len(ranges) == 0 {
+		return fmt.Errorf("no shard range in range shard algorithm")
+	}
+	for _, kr := range ranges {
+		if !utils.Contains(nodes, kr.Node) {
+			return fmt.Errorf("data node '%s' of shard range not in shard rule", kr.Node)
+		}
+		if len(kr.Min) > 0 && len(kr.Max) > 0 && compareKey(kr.Min, kr.Max) >= 0 {
+			return fmt.Errorf("invalid shard range [%s, %s) of data node '%s'", kr.Min, kr.Max, kr.Node)
+		}
+	}
+	return nil
+}
+
+// keyBound is lower or upper bound of shard key value.
+type keyBound struct {
+	value     string
+	inclusive bool
+}
+
+// keyBounds is bounds of shard key value by range conditions, nil is unbounded.
+type keyBounds struct {
+	min, max *keyBound
+}
+
+func (b *keyBounds) lower(value string, inclusive bool) {
+	if b.min == nil {
+		b.min = &keyBound{value: value, inclusive: inclusive}
+	} else if c := compareKey(value, b.min.value); c > 0 || (c == 0 && !inclusive) {
+		b.min = &keyBound{value: value, inclusive: inclusive}
+	}
+}
+
+func (b *keyBounds) upper(value string, inclusive bool) {
+	if b.max == nil {
+		b.max = &keyBound{value: value, inclusive: inclusive}
+	} else if c := compareKey(value, b.max.value); c < 0 || (c == 0 && !inclusive) {
+		b.max = &keyBound{value: value, inclusive: inclusive}
+	}
+}
+
+// overlap with range [Min, Max) or not.
+func (b *keyBounds) overlap(kr config.ShardRangeConfig) bool {
+	if b.min != nil && len(kr.Max) > 0 && compareKey(b.min.value, kr.Max) >= 0 {
+		return false
+	}
+	if b.max != nil && len(kr.Min) > 0 {
+		if c := compareKey(b.max.value, kr.Min); c < 0 || (c == 0 && !b.max.inclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+// routeByRange route by range conditions of shard key in where, such as >, < and BETWEEN,
+// to nodes of ranges overlapped with them in range shard algorithm, return nil if not found.
+func (r *Router) routeByRange(schemaConfig *config.SchemaConfig, where *sqlparser.Where) *inListRoute {
+	rule := &ShardRule{ShardAlgo: schemaConfig.ShardAlgo, Nodes: schemaConfig.Nodes, Ranges: schemaConfig.ShardRanges}
+	if r.ShardMaps != nil {
+		if m := r.ShardMaps.Get(schemaConfig.Name); m != nil {
+			// ranges of shard key are in different rules when partially switched.
+			if m.Staged != nil && len(m.Ranges) > 0 {
+				return nil
+			}
+			rule = m.Active
+		}
+	}
+	if strings.ToLower(strings.TrimSpace(rule.ShardAlgo)) != "range" {
+		return nil
+	}
+	conds := sqlparser.FindRangeConds(where, schemaConfig.ShardKey)
+	if len(conds) == 0 {
+		return nil
+	}
+	var bounds keyBounds
+	for _, cond := range conds {
+		switch cond := cond.(type) {
+		case *sqlparser.ComparisonExpr:
+			value := boundValue(cond.Right)
+			switch cond.Operator {
+			case sqlparser.AST_EQ:
+				bounds.lower(value, true)
+				bounds.upper(value, true)
+			case sqlparser.AST_GT:
+				bounds.lower(value, false)
+			case sqlparser.AST_GE:
+				bounds.lower(value, true)
+			case sqlparser.AST_LT:
+				bounds.upper(value, false)
+			case sqlparser.AST_LE:
+				bounds.upper(value, true)
+			}
+		case *sqlparser.RangeCond:
+			bounds.lower(boundValue(cond.From), true)
+			bounds.upper(boundValue(cond.To), true)
+		}
+	}
+
+	var nodeNames []string
+	for _, kr := range rule.Ranges {
+		if bounds.overlap(kr) && utils.Contains(rule.Nodes, kr.Node) && !utils.Contains(nodeNames, kr.Node) {
+			nodeNames = append(nodeNames, kr.Node)
+		}
+	}
+	// no row in any range, but result is still from a node.
+	if len(nodeNames) == 0 {
+		nodeNames = rule.Nodes[:1]
+	}
+	r.debugf("range of shard key %s is routed to nodes %s", schemaConfig.ShardKey, strings.Join(nodeNames, ","))
+	return &inListRoute{nodeNames: nodeNames}
+}
+
+func boundValue(expr sqlparser.ValExpr) string {
+	switch v := expr.(type) {
+	case sqlparser.StrVal:
+		return string(v)
+	case sqlparser.NumVal:
+		return string(v)
+	}
+	return ""
+}
@@ -0,0 +1,130 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+var errReadOnly = mysql.NewDefaultError(mysql.ER_OPTION_PREVENTS_STATEMENT, "--read-only")
+
+// readPassthroughKeywords are leading keywords of raw sql which don't write.
+var readPassthroughKeywords = []string{"select", "show", "desc", "describe", "explain", "set", "use", "help"}
+
+// ReadOnlyMode is read-only mode of whole proxy or schemas, writes are rejected in it,
+// such as during maintenance, failover, or billing suspension of tenant.
+type ReadOnlyMode struct {
+	sync.RWMutex
+	all     bool
+	schemas map[string]bool
+}
+
+// NewReadOnlyMode create read-only mode, all are writable.
+func NewReadOnlyMode() *ReadOnlyMode {
+	m := new(ReadOnlyMode)
+	m.schemas = make(map[string]bool)
+	return m
+}
+
+// Set read-only mode of schema, or whole proxy if schema is empty.
+func (m *ReadOnlyMode) Set(schemaName string, readOnly bool) {
+	m.Lock()
+	defer m.Unlock()
+	if len(schemaName) == 0 {
+		m.all = readOnly
+	} else if readOnly {
+		m.schemas[schemaName] = true
+	} else {
+		delete(m.schemas, schemaName)
+	}
+}
+
+// IsReadOnly whole proxy or the schema is read-only.
+func (m *ReadOnlyMode) IsReadOnly(schemaName string) bool {
+	m.RLock()
+	defer m.RUnlock()
+	return m.all || m.schemas[schemaName]
+}
+
+// List read-only mode of whole proxy and schemas in read-only mode.
+func (m *ReadOnlyMode) List() (all bool, schemas []string) {
+	m.RLock()
+	defer m.RUnlock()
+	schemas = make([]string, 0, len(m.schemas))
+	for schemaName := range m.schemas {
+		schemas = append(schemas, schemaName)
+	}
+	sort.Strings(schemas)
+	return m.all, schemas
+}
+
+// checkReadOnly reject writes to schema in read-only mode.
+func (r *Router) checkReadOnly(statement sqlparser.Statement) error {
+	if r.ReadOnly == nil || !isWriteStatement(statement) || !r.ReadOnly.IsReadOnly(r.SchemaName) {
+		return nil
+	}
+	r.debugf("%T rejected, schema '%s' is read-only", statement, r.SchemaName)
+	return errReadOnly
+}
+
+// isWriteStatement statement writes data or changes schema, raw sql is write unless it begins with keyword of reads.
+func isWriteStatement(statement sqlparser.Statement) bool {
+	switch v := statement.(type) {
+	case *sqlparser.Insert, *sqlparser.Update, *sqlparser.Delete, *sqlparser.Replace, sqlparser.DDLStatement:
+		return true
+	case *sqlparser.Passthrough:
+		sql := skipLeadingComments(v.SQL)
+		for _, keyword := range readPassthroughKeywords {
+			if len(sql) >= len(keyword) && bytes.EqualFold(sql[:len(keyword)], []byte(keyword)) &&
+				(len(sql) == len(keyword) || !isIdentChar(sql[len(keyword)])) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// skipLeadingComments skip spaces and comments at the beginning of sql, executable comments '/*!' are kept.
+func skipLeadingComments(sql []byte) []byte {
+	for {
+		sql = bytes.TrimLeft(sql, " \t\r\n")
+		if !bytes.HasPrefix(sql, []byte("/*")) || bytes.HasPrefix(sql, []byte("/*!")) {
+			return sql
+		}
+		end := bytes.Index(sql[2:], []byte("*/"))
+		if end < 0 {
+			return sql
+		}
+		sql = sql[end+4:]
+	}
+}
+
+func isIdentChar(ch byte) bool {
+	return ch == '_' || ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
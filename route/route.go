@@ -23,6 +23,7 @@
 package route
 
 import (
+	"fmt"
 	"net"
 	"strings"
 	"time"
@@ -32,6 +33,7 @@ import (
 	"github.com/berkaroad/saashard/net/mysql"
 	"github.com/berkaroad/saashard/sqlparser"
 	"github.com/berkaroad/saashard/statistic"
+	"github.com/berkaroad/saashard/utils"
 	"github.com/berkaroad/saashard/utils/simplelog"
 )
 
@@ -40,6 +42,20 @@ type Route interface {
 	GetPlanSQL() string
 	GetNodeNames() []string
 	OnSlave() bool
+	// GetMirrors get dual-write or dark read of statements in shard migration.
+	GetMirrors() map[sqlparser.Statement]*Mirror
+	// IsScatter is true if statement is rewritten for each node, such as IN list of shard key grouped by node.
+	IsScatter() bool
+	// GetNodeSQL get sql executed in node, such as rewritten for it.
+	GetNodeSQL(nodeName string) string
+}
+
+// Mirror is dual-write or dark read of statement in shard migration, execute in nodes of the other shard rule.
+type Mirror struct {
+	Schema    string
+	NodeNames []string
+	Async     bool
+	Read      bool // dark read to compare result, always async.
 }
 
 // Plan to execute.
@@ -61,11 +77,23 @@ type normalPlan struct {
 	Result         *mysql.Result // If has result then get it, or execute plan.
 	nodeNames      []string
 	queryNodeNames []string
-	onSlave        bool // Execute at slave or master.
-	anyNode        bool // Can execute at any node or not.
+	onSlave        bool    // Execute at slave or master.
+	anyNode        bool    // Can execute at any node or not.
+	mirror         *Mirror // Dual-write or dark read in shard migration.
+	labels         string  // Label set of annotations.
+
+	// nodeStatements is statement rewritten for each node in nodeNames, nil if the same statement in all nodes.
+	nodeStatements []sqlparser.Statement
 }
 
 func (plan *normalPlan) GetPlanSQL() string {
+	if plan.nodeStatements != nil {
+		planSQL := ""
+		for _, statement := range plan.nodeStatements {
+			planSQL += sqlparser.String(statement) + "; "
+		}
+		return planSQL
+	}
 	return sqlparser.String(plan.Statement)
 }
 
@@ -77,6 +105,42 @@ func (plan *normalPlan) OnSlave() bool {
 	return plan.onSlave
 }
 
+func (plan *normalPlan) GetMirrors() map[sqlparser.Statement]*Mirror {
+	if plan.mirror == nil {
+		return nil
+	}
+	return map[sqlparser.Statement]*Mirror{plan.Statement: plan.mirror}
+}
+
+func (plan *normalPlan) IsScatter() bool {
+	return plan.nodeStatements != nil
+}
+
+func (plan *normalPlan) GetNodeSQL(nodeName string) string {
+	if plan.nodeStatements != nil {
+		for i, node := range plan.nodeNames {
+			if node == nodeName {
+				return sqlparser.String(plan.nodeStatements[i])
+			}
+		}
+		return ""
+	}
+	return sqlparser.String(plan.Statement)
+}
+
+// executeArgs get statements, results and query nodes of statements to execute.
+func (plan *normalPlan) executeArgs() ([]sqlparser.Statement, []*mysql.Result, map[sqlparser.Statement][]string) {
+	if plan.nodeStatements != nil {
+		queryNodeNames := make(map[sqlparser.Statement][]string)
+		for i, statement := range plan.nodeStatements {
+			queryNodeNames[statement] = []string{plan.nodeNames[i]}
+		}
+		return plan.nodeStatements, make([]*mysql.Result, len(plan.nodeStatements)), queryNodeNames
+	}
+	return []sqlparser.Statement{plan.Statement}, []*mysql.Result{plan.Result},
+		map[sqlparser.Statement][]string{plan.Statement: plan.queryNodeNames}
+}
+
 // Execute the plan
 func (plan *normalPlan) Execute(executor func(statements []sqlparser.Statement, results []*mysql.Result,
 	dataNodes []string, isSlave bool,
@@ -84,9 +148,8 @@ func (plan *normalPlan) Execute(executor func(statements []sqlparser.Statement,
 	if executor != nil {
 		var state string
 		startTime := time.Now().UnixNano()
-		backendConnAddrs, err := executor([]sqlparser.Statement{plan.Statement}, []*mysql.Result{plan.Result},
-			plan.nodeNames, plan.onSlave,
-			map[sqlparser.Statement][]string{plan.Statement: plan.queryNodeNames})
+		statements, results, queryNodeNames := plan.executeArgs()
+		backendConnAddrs, err := executor(statements, results, plan.nodeNames, plan.onSlave, queryNodeNames)
 		execTime := float64(time.Now().UnixNano()-startTime) / float64(time.Millisecond)
 		if err != nil {
 			state = "ERROR"
@@ -94,16 +157,18 @@ func (plan *normalPlan) Execute(executor func(statements []sqlparser.Statement,
 			state = "OK"
 		}
 		planSQL := plan.GetPlanSQL()
-		if logSQLEnabled &&
-			execTime > float64(slowLogTime) {
+		slow := execTime > float64(slowLogTime)
+		counter.RecordLabels(plan.labels, execTime, err != nil, slow)
+		if logSQLEnabled && slow {
 			counter.IncrSlowLogTotal()
-			simplelog.Verbose("%s %.1fms - %s->%s(%s):OnSlave=%v:%s",
+			simplelog.Verbose("%s %.1fms - %s->%s(%s):OnSlave=%v%s:%s",
 				state,
 				execTime,
 				clientAddr,
 				strings.Join(plan.nodeNames, ","),
 				strings.Join(backendConnAddrs, ","),
 				plan.onSlave,
+				labelsLog(plan.labels),
 				planSQL,
 			)
 		}
@@ -119,9 +184,8 @@ func (plan *normalPlan) ExecuteWithStmtPrepare(executor func(statements []sqlpar
 	if executor != nil {
 		var state string
 		startTime := time.Now().UnixNano()
-		stmt, err := executor([]sqlparser.Statement{plan.Statement}, []*mysql.Result{plan.Result},
-			plan.nodeNames, plan.onSlave,
-			map[sqlparser.Statement][]string{plan.Statement: plan.queryNodeNames})
+		statements, results, queryNodeNames := plan.executeArgs()
+		stmt, err := executor(statements, results, plan.nodeNames, plan.onSlave, queryNodeNames)
 		execTime := float64(time.Now().UnixNano()-startTime) / float64(time.Millisecond)
 		if err != nil {
 			state = "ERROR"
@@ -129,15 +193,17 @@ func (plan *normalPlan) ExecuteWithStmtPrepare(executor func(statements []sqlpar
 			state = "OK"
 		}
 		planSQL := plan.GetPlanSQL()
-		if logSQLEnabled &&
-			execTime > float64(slowLogTime) {
+		slow := execTime > float64(slowLogTime)
+		counter.RecordLabels(plan.labels, execTime, err != nil, slow)
+		if logSQLEnabled && slow {
 			counter.IncrSlowLogTotal()
-			simplelog.Verbose("%s %.1fms - %s->%s:OnSlave=%v:%s",
+			simplelog.Verbose("%s %.1fms - %s->%s:OnSlave=%v%s:%s",
 				state,
 				execTime,
 				clientAddr,
 				strings.Join(plan.nodeNames, ","),
 				plan.onSlave,
+				labelsLog(plan.labels),
 				planSQL,
 			)
 		}
@@ -146,6 +212,22 @@ func (plan *normalPlan) ExecuteWithStmtPrepare(executor func(statements []sqlpar
 	return nil, nil
 }
 
+// labelsLog format label set in slow log, empty if not annotated.
+func labelsLog(labels string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	return ":Labels=" + labels
+}
+
+// mirrorLog format mirror in debug log.
+func mirrorLog(mirror *Mirror) string {
+	if mirror == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%s(read=%v,async=%v)", strings.Join(mirror.NodeNames, ","), mirror.Read, mirror.Async)
+}
+
 // mergedPlan from plan array.
 type mergedPlan struct {
 	Statements     []sqlparser.Statement
@@ -154,6 +236,9 @@ type mergedPlan struct {
 	queryNodeNames map[sqlparser.Statement][]string // select or union will use.
 	onSlave        bool                             // Execute at slave or master.
 	anyNode        bool                             // Can execute at any node or not.
+	mirrors        map[sqlparser.Statement]*Mirror  // Dual-write or dark read in shard migration.
+	labels         string                           // Label set of annotations.
+	scatter        bool                             // Statements are rewritten for each node.
 }
 
 func (plan *mergedPlan) GetPlanSQL() string {
@@ -172,6 +257,24 @@ func (plan *mergedPlan) OnSlave() bool {
 	return plan.onSlave
 }
 
+func (plan *mergedPlan) GetMirrors() map[sqlparser.Statement]*Mirror {
+	return plan.mirrors
+}
+
+func (plan *mergedPlan) IsScatter() bool {
+	return plan.scatter
+}
+
+func (plan *mergedPlan) GetNodeSQL(nodeName string) string {
+	planSQL := ""
+	for _, statement := range plan.Statements {
+		if nodes := plan.queryNodeNames[statement]; len(nodes) == 0 || utils.Contains(nodes, nodeName) {
+			planSQL += sqlparser.String(statement) + "; "
+		}
+	}
+	return planSQL
+}
+
 // Execute the plan
 func (plan *mergedPlan) Execute(executor func(statements []sqlparser.Statement, results []*mysql.Result,
 	dataNodes []string, isSlave bool,
@@ -188,16 +291,18 @@ func (plan *mergedPlan) Execute(executor func(statements []sqlparser.Statement,
 			state = "OK"
 		}
 		planSQL := plan.GetPlanSQL()
-		if logSQLEnabled &&
-			execTime > float64(slowLogTime) {
+		slow := execTime > float64(slowLogTime)
+		counter.RecordLabels(plan.labels, execTime, err != nil, slow)
+		if logSQLEnabled && slow {
 			counter.IncrSlowLogTotal()
-			simplelog.Verbose("%s %.1fms - %s->%s(%s):OnSlave=%v:%s",
+			simplelog.Verbose("%s %.1fms - %s->%s(%s):OnSlave=%v%s:%s",
 				state,
 				execTime,
 				clientAddr,
 				strings.Join(plan.nodeNames, ","),
 				strings.Join(backendConnAddrs, ","),
 				plan.onSlave,
+				labelsLog(plan.labels),
 				planSQL,
 			)
 		}
@@ -222,15 +327,17 @@ func (plan *mergedPlan) ExecuteWithStmtPrepare(executor func(statements []sqlpar
 			state = "OK"
 		}
 		planSQL := plan.GetPlanSQL()
-		if logSQLEnabled &&
-			execTime > float64(slowLogTime) {
+		slow := execTime > float64(slowLogTime)
+		counter.RecordLabels(plan.labels, execTime, err != nil, slow)
+		if logSQLEnabled && slow {
 			counter.IncrSlowLogTotal()
-			simplelog.Verbose("%s %.1fms - %s->%s:OnSlave=%v:%s",
+			simplelog.Verbose("%s %.1fms - %s->%s:OnSlave=%v%s:%s",
 				state,
 				execTime,
 				clientAddr,
 				strings.Join(plan.nodeNames, ","),
 				plan.onSlave,
+				labelsLog(plan.labels),
 				planSQL,
 			)
 		}
@@ -246,7 +353,38 @@ type Router struct {
 	Nodes        map[string]*config.NodeConfig
 	ConnectionID uint32
 	User         string
-	InTrans      bool
+	InTrans      bool        // Session in transaction, reads are routed to master as writes, which is read-after-write.
+	NodeInTrans  string      // Data node in transaction, if InTrans.
+	ShardMaps    *ShardMaps  // Versioned shard rules, use schema config if nil.
+	NodeGroups   *NodeGroups // Blue/green node groups, use nodes of schema if nil.
+	Directory    Directory   // Lookup of shard key in directory shard algorithm, nil if not configured.
+	GlobalIndex  GlobalIndex // Index of global_indexes of sharded tables, nil if not configured.
+	Labels       string      // Label set of annotations, such as 'app=checkout,endpoint=create_order'.
+	Debug        bool        // Log routing decisions of session, by 'SET saashard_debug=1'.
+	Sticky       bool        // Session pinned to master conns, all statements are routed to master as writes.
+	Funcs        *LocalFuncs // Functions evaluated by proxy, nil if statement is not rewritten, such as prepared stmt.
+	LastInsertID uint64      // LAST_INSERT_ID() of session.
+	FoundRows    int64       // FOUND_ROWS() of session.
+	RowCount     int64       // ROW_COUNT() of session.
+
+	// MaxAllowedPacket is max_allowed_packet of proxy advertised to client, 0 is of backend.
+	MaxAllowedPacket int
+	// ReadOnly mode of proxy and schemas, writes are rejected in it.
+	ReadOnly *ReadOnlyMode
+	// PlanPins is routing and index hints pinned to statements by fingerprint, nil if none.
+	PlanPins *PlanPins
+	// Sequence generates ids of auto_increment columns of tables, nil if statement is not rewritten.
+	Sequence Sequence
+	// InsertIDs are the first ids filled by sequence in insert statements, as their LAST_INSERT_ID().
+	InsertIDs map[sqlparser.Statement]uint64
+}
+
+// debugf log routing decision if debug.
+func (r *Router) debugf(format string, args ...interface{}) {
+	if r.Debug {
+		simplelog.Debug("%s %s connectionID=%d,schema=%s: "+format,
+			append([]interface{}{"route", "Router", r.ConnectionID, r.SchemaName}, args...)...)
+	}
 }
 
 // NewRouter to create router.
@@ -268,6 +406,7 @@ func (r *Router) BuildMergedPlan(statements ...sqlparser.Statement) (plan Plan,
 		return nil, errors.ErrNoStatement
 	}
 	plans := make([]*normalPlan, len(statements))
+	defer func(inTrans bool) { r.InTrans = inTrans }(r.InTrans)
 	for i, stmt := range statements {
 		statement := stmt
 		var thePlan Plan
@@ -276,9 +415,29 @@ func (r *Router) BuildMergedPlan(statements ...sqlparser.Statement) (plan Plan,
 			return
 		}
 		plans[i] = thePlan.(*normalPlan)
+		// later statements in the same query are in transaction begun.
+		if beginsTrans(statement) {
+			r.InTrans = true
+		}
 	}
 	planCount := len(plans)
 
+	// statement rewritten for each node, couldn't merge with other statements.
+	for _, currentPlan := range plans {
+		if currentPlan.nodeStatements != nil && planCount > 1 {
+			return nil, errors.ErrExecInMulti
+		}
+	}
+	if plans[0].nodeStatements != nil {
+		mergedPlan := new(mergedPlan)
+		mergedPlan.Statements, mergedPlan.Results, mergedPlan.queryNodeNames = plans[0].executeArgs()
+		mergedPlan.nodeNames = plans[0].nodeNames
+		mergedPlan.onSlave = plans[0].onSlave
+		mergedPlan.labels = r.Labels
+		mergedPlan.scatter = true
+		return mergedPlan, nil
+	}
+
 	mergedPlan := new(mergedPlan)
 	mergedPlan.Statements = make([]sqlparser.Statement, planCount)
 	mergedPlan.Results = make([]*mysql.Result, planCount)
@@ -287,8 +446,17 @@ func (r *Router) BuildMergedPlan(statements ...sqlparser.Statement) (plan Plan,
 	mergedPlan.queryNodeNames = make(map[sqlparser.Statement][]string)
 	mergedPlan.onSlave = firstNormalPlan.onSlave
 	mergedPlan.anyNode = firstNormalPlan.anyNode
+	mergedPlan.labels = r.Labels
 	mergedPlan.Results[0] = firstNormalPlan.Result
 	mergedPlan.Statements[0] = firstNormalPlan.Statement
+	for _, currentPlan := range plans {
+		if currentPlan.mirror != nil {
+			if mergedPlan.mirrors == nil {
+				mergedPlan.mirrors = make(map[sqlparser.Statement]*Mirror)
+			}
+			mergedPlan.mirrors[currentPlan.Statement] = currentPlan.mirror
+		}
+	}
 
 	if planCount > 1 {
 		for i, currentPlan := range plans[1:] {
@@ -328,6 +496,44 @@ func (r *Router) BuildMergedPlan(statements ...sqlparser.Statement) (plan Plan,
 // BuildNormalPlan to build plan
 func (r *Router) BuildNormalPlan(statement sqlparser.Statement) (plan Plan, err error) {
 	var realPlan *normalPlan
+	switch statement.(type) {
+	case sqlparser.SelectStatement, *sqlparser.Insert, *sqlparser.Update, *sqlparser.Delete, *sqlparser.Replace:
+		// route by schema of tables, restore current schema after built.
+		var schemaName string
+		if schemaName, err = r.resolveSchema(statement); err != nil {
+			return
+		}
+		if schemaName != r.SchemaName {
+			defer func(currentSchemaName string) { r.SchemaName = currentSchemaName }(r.SchemaName)
+			r.SchemaName = schemaName
+		}
+	}
+	if err = r.checkReadOnly(statement); err != nil {
+		return
+	}
+	r.applyPlanPin(statement)
+	// route by blue nodes, then replace by the active node group.
+	if r.NodeGroups != nil && len(r.NodeInTrans) > 0 {
+		if g := r.NodeGroups.Get(r.SchemaName); g != nil {
+			defer func(nodeInTrans string) { r.NodeInTrans = nodeInTrans }(r.NodeInTrans)
+			r.NodeInTrans = g.blueNode(r.NodeInTrans)
+		}
+	}
+	nodeInTrans := r.NodeInTrans
+
+	// replace functions evaluated by proxy by values, before routed by value of shard key.
+	if r.Funcs != nil {
+		switch statement.(type) {
+		case *sqlparser.Select, *sqlparser.Union, *sqlparser.Insert, *sqlparser.Update, *sqlparser.Delete, *sqlparser.Replace:
+			if err = sqlparser.ReplaceValExprs(statement, r.Funcs.replace); err != nil {
+				return
+			}
+		}
+	}
+	if err = r.fillAutoIncrement(statement); err != nil {
+		return
+	}
+
 	switch v := statement.(type) {
 	case *sqlparser.UseDB:
 		realPlan, err = r.buildUseDBPlan(v)
@@ -434,9 +640,27 @@ func (r *Router) BuildNormalPlan(statement sqlparser.Statement) (plan Plan, err
 	case *sqlparser.KillQuery:
 		realPlan, err = r.buildKillQuery(v)
 
+	case *sqlparser.Passthrough:
+		realPlan, err = r.buildPassthroughPlan(v)
+
 	default:
 		realPlan, err = nil, errors.ErrNoPlan
 	}
+	if err != nil {
+		r.debugf("%T not routed: %s", statement, err.Error())
+		return
+	}
+	if realPlan != nil && r.Sticky {
+		realPlan.onSlave = false
+	}
+	r.applyNodeGroup(realPlan, nodeInTrans)
+	if realPlan != nil {
+		realPlan.labels = r.Labels
+		if r.Debug {
+			r.debugf("%T routed to nodes %s, on slave %v, mirror %s, sql: %s", statement,
+				strings.Join(realPlan.nodeNames, ","), realPlan.onSlave, mirrorLog(realPlan.mirror), realPlan.GetPlanSQL())
+		}
+	}
 	plan = realPlan
 	return
 }
@@ -0,0 +1,111 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+var errCrossSchema = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET, "cross schema statement on sharded schemas or different data hosts")
+
+// resolveSchema resolve logical schema of tables in statement, return schema name to route.
+// Unqualified table belongs to current schema.
+// Qualified table 'schema.table' is supported, if the schema is managed by proxy:
+//  1. Only one schema in statement, route by it, and remove qualifier.
+//  2. More than one schema, all of them should be unsharded and in same data host,
+//     then qualifier will be replaced by the database of node.
+func (r *Router) resolveSchema(statement sqlparser.Statement) (string, error) {
+	tableNames := sqlparser.GetTableNames(statement)
+	if len(tableNames) == 0 {
+		return r.SchemaName, nil
+	}
+
+	schemaNames := make([]string, 0, 2)
+	addSchemaName := func(name string) {
+		for _, schemaName := range schemaNames {
+			if schemaName == name {
+				return
+			}
+		}
+		schemaNames = append(schemaNames, name)
+	}
+	for _, tableName := range tableNames {
+		if len(tableName.Qualifier) == 0 {
+			addSchemaName(r.SchemaName)
+			continue
+		}
+		db := strings.Trim(strings.ToLower(string(tableName.Qualifier)), "`")
+		if sqlparser.IsSystemDB(db) {
+			continue
+		}
+		if _, ok := r.Schemas[db]; !ok {
+			return "", mysql.NewDefaultError(mysql.ER_BAD_DB_ERROR, db)
+		}
+		addSchemaName(db)
+	}
+
+	switch len(schemaNames) {
+	case 0:
+		return r.SchemaName, nil
+	case 1:
+		for _, tableName := range tableNames {
+			if strings.Trim(strings.ToLower(string(tableName.Qualifier)), "`") == schemaNames[0] {
+				tableName.Qualifier = nil
+			}
+		}
+		return schemaNames[0], nil
+	}
+
+	// cross schema
+	var dataHost string
+	databases := make(map[string]string, len(schemaNames))
+	for _, schemaName := range schemaNames {
+		schemaConfig := r.Schemas[schemaName]
+		if schemaConfig.ShardEnabled() || len(schemaConfig.Nodes) != 1 {
+			return "", errCrossSchema
+		}
+		node := r.Nodes[schemaConfig.Nodes[0]]
+		if node == nil {
+			return "", errCrossSchema
+		}
+		if len(dataHost) == 0 {
+			dataHost = node.Host
+		} else if dataHost != node.Host {
+			return "", errCrossSchema
+		}
+		databases[schemaName] = node.Database
+	}
+	for _, tableName := range tableNames {
+		db := strings.Trim(strings.ToLower(string(tableName.Qualifier)), "`")
+		if len(db) == 0 {
+			db = r.SchemaName
+		}
+		if database, ok := databases[db]; ok {
+			tableName.Qualifier = []byte(database)
+		}
+	}
+	return schemaNames[0], nil
+}
@@ -23,8 +23,10 @@
 package route
 
 import (
+	"strconv"
 	"strings"
 
+	"github.com/berkaroad/saashard/config"
 	"github.com/berkaroad/saashard/errors"
 	"github.com/berkaroad/saashard/net/mysql"
 	"github.com/berkaroad/saashard/sqlparser"
@@ -63,6 +65,16 @@ var connectionIDField = &mysql.Field{Schema: []byte(""),
 	Flags:        mysql.NOT_NULL_FLAG | mysql.BINARY_FLAG,
 	Decimals:     0}
 
+var maxAllowedPacketField = &mysql.Field{Schema: []byte(""),
+	Table:        []byte(""),
+	OrgTable:     []byte(""),
+	OrgName:      []byte(""),
+	Charset:      uint16(mysql.BINARY_COLLATION_ID),
+	ColumnLength: 20,
+	ColumnType:   mysql.MYSQL_TYPE_LONGLONG,
+	Flags:        mysql.UNSIGNED_FLAG | mysql.BINARY_FLAG,
+	Decimals:     0}
+
 var databaseField = &mysql.Field{Schema: []byte(""),
 	Table:        []byte(""),
 	OrgTable:     []byte(""),
@@ -74,51 +86,157 @@ var databaseField = &mysql.Field{Schema: []byte(""),
 	Flags:        mysql.NOT_NULL_FLAG,
 	Decimals:     31}
 
+var lastInsertIDField = &mysql.Field{Schema: []byte(""),
+	Table:        []byte(""),
+	OrgTable:     []byte(""),
+	Name:         []byte("LAST_INSERT_ID()"),
+	OrgName:      []byte(""),
+	Charset:      uint16(mysql.DEFAULT_COLLATION_ID),
+	ColumnLength: 20,
+	ColumnType:   mysql.MYSQL_TYPE_LONGLONG,
+	Flags:        mysql.NOT_NULL_FLAG | mysql.UNSIGNED_FLAG | mysql.BINARY_FLAG,
+	Decimals:     0}
+
+var foundRowsField = &mysql.Field{Schema: []byte(""),
+	Table:        []byte(""),
+	OrgTable:     []byte(""),
+	Name:         []byte("FOUND_ROWS()"),
+	OrgName:      []byte(""),
+	Charset:      uint16(mysql.DEFAULT_COLLATION_ID),
+	ColumnLength: 21,
+	ColumnType:   mysql.MYSQL_TYPE_LONGLONG,
+	Flags:        mysql.NOT_NULL_FLAG | mysql.BINARY_FLAG,
+	Decimals:     0}
+
+var rowCountField = &mysql.Field{Schema: []byte(""),
+	Table:        []byte(""),
+	OrgTable:     []byte(""),
+	Name:         []byte("ROW_COUNT()"),
+	OrgName:      []byte(""),
+	Charset:      uint16(mysql.DEFAULT_COLLATION_ID),
+	ColumnLength: 21,
+	ColumnType:   mysql.MYSQL_TYPE_LONGLONG,
+	Flags:        mysql.NOT_NULL_FLAG | mysql.BINARY_FLAG,
+	Decimals:     0}
+
 func (r *Router) buildSimpleSelectPlan(statement *sqlparser.SimpleSelect) (*normalPlan, error) {
 	schemaConfig := r.Schemas[r.SchemaName]
 	supportedFieldNames := map[string]*mysql.Field{
-		"current_user()":  currentUserField,
-		"version()":       versionField,
-		"connection_id()": connectionIDField,
-		"database()":      databaseField}
+		"current_user()":   currentUserField,
+		"version()":        versionField,
+		"connection_id()":  connectionIDField,
+		"database()":       databaseField,
+		"last_insert_id()": lastInsertIDField,
+		"found_rows()":     foundRowsField,
+		"row_count()":      rowCountField}
 
 	supportedFieldValues := map[string]func(*mysql.Row){
-		"current_user()":  func(row *mysql.Row) { row.AppendStringValue(r.User) },
-		"version()":       func(row *mysql.Row) { row.AppendStringValue(mysql.ServerVersion) },
-		"connection_id()": func(row *mysql.Row) { row.AppendUIntValue(uint64(r.ConnectionID)) },
-		"database()":      func(row *mysql.Row) { row.AppendStringValue(r.SchemaName) }}
+		"current_user()":   func(row *mysql.Row) { row.AppendStringValue(r.User) },
+		"version()":        func(row *mysql.Row) { row.AppendStringValue(mysql.ServerVersion) },
+		"connection_id()":  func(row *mysql.Row) { row.AppendUIntValue(uint64(r.ConnectionID)) },
+		"database()":       func(row *mysql.Row) { row.AppendStringValue(r.SchemaName) },
+		"last_insert_id()": func(row *mysql.Row) { row.AppendUIntValue(r.LastInsertID) },
+		"found_rows()":     func(row *mysql.Row) { row.AppendIntValue(r.FoundRows) },
+		"row_count()":      func(row *mysql.Row) { row.AppendIntValue(r.RowCount) }}
+	if r.MaxAllowedPacket > 0 {
+		// max_allowed_packet of proxy, packet from client is limited by it.
+		for _, name := range []string{"@@max_allowed_packet", "@@session.max_allowed_packet"} {
+			field := *maxAllowedPacketField
+			field.Name = []byte(name)
+			supportedFieldNames[name] = &field
+			supportedFieldValues[name] = func(row *mysql.Row) { row.AppendUIntValue(uint64(r.MaxAllowedPacket)) }
+		}
+	}
 
 	allFieldsSupported := true
 	for _, fieldExpr := range statement.SelectExprs {
-		fieldName := strings.ToLower(sqlparser.String(fieldExpr))
-		if _, ok := supportedFieldNames[fieldName]; !ok {
+		fieldName, _ := simpleFieldName(fieldExpr)
+		if _, ok := supportedFieldNames[fieldName]; !ok && !r.isLocalFunc(fieldExpr) {
 			allFieldsSupported = false
 			break
 		}
 	}
+	if !allFieldsSupported {
+		if r.Funcs != nil {
+			if err := sqlparser.ReplaceValExprs(statement, r.Funcs.replace); err != nil {
+				return nil, err
+			}
+		}
+		if err := sqlparser.ReplaceValExprs(statement, r.replaceSessionFunc); err != nil {
+			return nil, err
+		}
+	}
 	hint := ReadHint(&statement.Comments)
 
 	plan := new(normalPlan)
 
 	plan.nodeNames = []string{schemaConfig.Nodes[0]}
-	plan.onSlave = true && !hint.OnMaster && !r.InTrans
+	// LAST_INSERT_ID(expr) sets value in session of backend, it's on master as the insert.
+	plan.onSlave = true && !hint.OnMaster && !r.InTrans && !hasFunc(statement, "last_insert_id")
 	plan.Statement = statement
 	plan.anyNode = true
+	// forced to node of hint, such as to read variables of the node.
+	if schemaConfig.ShardEnabled() {
+		hintNodes, err := r.hintNodes(schemaConfig, hint)
+		if err != nil {
+			return nil, err
+		} else if len(hintNodes) > 0 {
+			plan.nodeNames = hintNodes[:1]
+			plan.anyNode = false
+		}
+	}
 
 	if allFieldsSupported {
 		result := new(mysql.Result)
 		result.Status = mysql.SERVER_STATUS_AUTOCOMMIT
 		result.Resultset = new(mysql.Resultset)
 		result.Resultset.Fields = make([]*mysql.Field, len(statement.SelectExprs))
+		values := make([]interface{}, len(statement.SelectExprs))
+		local := make([]bool, len(statement.SelectExprs))
 		for i, fieldExpr := range statement.SelectExprs {
-			fieldName := strings.ToLower(sqlparser.String(fieldExpr))
-			result.Resultset.Fields[i] = supportedFieldNames[fieldName]
+			fieldName, as := simpleFieldName(fieldExpr)
+			if field, ok := supportedFieldNames[fieldName]; ok {
+				if len(as) > 0 {
+					namedField := *field
+					namedField.Name = as
+					field = &namedField
+				}
+				result.Resultset.Fields[i] = field
+				continue
+			}
+			// local function, such as 'uuid() as id'.
+			nonStarExpr := fieldExpr.(*sqlparser.NonStarExpr)
+			value, field, _, err := r.Funcs.eval(nonStarExpr.Expr.(sqlparser.ValExpr))
+			if err != nil {
+				return nil, err
+			}
+			namedField := *field
+			namedField.Name = nonStarExpr.As
+			if len(namedField.Name) == 0 {
+				namedField.Name = []byte(sqlparser.String(nonStarExpr.Expr))
+			}
+			result.Resultset.Fields[i] = &namedField
+			values[i] = value
+			local[i] = true
 		}
 		result.Rows = make([]*mysql.Row, 1)
 		row := mysql.NewTextRow(result.Resultset.Fields)
-		for _, fieldExpr := range statement.SelectExprs {
-			fieldName := strings.ToLower(sqlparser.String(fieldExpr))
-			supportedFieldValues[fieldName](row)
+		for i, fieldExpr := range statement.SelectExprs {
+			if !local[i] {
+				fieldName, _ := simpleFieldName(fieldExpr)
+				supportedFieldValues[fieldName](row)
+				continue
+			}
+			switch v := values[i].(type) {
+			case string:
+				row.AppendStringValue(v)
+			case uint64:
+				row.AppendUIntValue(v)
+			case int64:
+				row.AppendIntValue(v)
+			default:
+				row.AppendNullValue()
+			}
 		}
 		result.Rows[0] = row
 		plan.Result = result
@@ -126,13 +244,67 @@ func (r *Router) buildSimpleSelectPlan(statement *sqlparser.SimpleSelect) (*norm
 	return plan, nil
 }
 
+// simpleFieldName get lower case expression of select expression to be answered by proxy, and its alias.
+func simpleFieldName(fieldExpr sqlparser.SelectExpr) (string, []byte) {
+	if nonStarExpr, ok := fieldExpr.(*sqlparser.NonStarExpr); ok {
+		return strings.ToLower(sqlparser.String(nonStarExpr.Expr)), nonStarExpr.As
+	}
+	return strings.ToLower(sqlparser.String(fieldExpr)), nil
+}
+
+// replaceSessionFunc replace LAST_INSERT_ID(), FOUND_ROWS() and ROW_COUNT() by values of session,
+// in select not answered by proxy, as backend conn of it may be not the one of last statement.
+func (r *Router) replaceSessionFunc(expr sqlparser.ValExpr) (sqlparser.ValExpr, error) {
+	funcExpr, ok := expr.(*sqlparser.FuncExpr)
+	if !ok || len(funcExpr.Exprs) > 0 {
+		return nil, nil
+	}
+	switch strings.ToLower(string(funcExpr.Name)) {
+	case "last_insert_id":
+		return sqlparser.NumVal(strconv.FormatUint(r.LastInsertID, 10)), nil
+	case "found_rows":
+		return sqlparser.NumVal(strconv.FormatInt(r.FoundRows, 10)), nil
+	case "row_count":
+		return sqlparser.NumVal(strconv.FormatInt(r.RowCount, 10)), nil
+	}
+	return nil, nil
+}
+
+// hasFunc is true if function of name is called in statement.
+func hasFunc(statement sqlparser.SQLNode, name string) bool {
+	found := false
+	sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if funcExpr, ok := node.(*sqlparser.FuncExpr); ok && strings.EqualFold(string(funcExpr.Name), name) {
+			found = true
+		}
+		return !found, nil
+	}, statement)
+	return found
+}
+
+// isLocalFunc is true if select expression is a function evaluated by proxy.
+func (r *Router) isLocalFunc(fieldExpr sqlparser.SelectExpr) bool {
+	if r.Funcs == nil {
+		return false
+	}
+	nonStarExpr, ok := fieldExpr.(*sqlparser.NonStarExpr)
+	if !ok {
+		return false
+	}
+	funcExpr, ok := nonStarExpr.Expr.(*sqlparser.FuncExpr)
+	return ok && IsLocalFunc(string(funcExpr.Name))
+}
+
 func (r *Router) buildSelectPlan(statement *sqlparser.Select) (*normalPlan, error) {
-	schemaConfig := r.Schemas[r.SchemaName]
+	schemaConfig := routingSchema(r.Schemas[r.SchemaName], statement)
 	isOnlySystemDB := false
-	nodeIndex := 0
+	nodeName := schemaConfig.Nodes[0]
+	var mirrorNodeName string
+	var inList *inListRoute
 	sqlparser.SetLimitInSelect(statement, schemaConfig.MaxRowCount)
+	hint := ReadHint(&statement.Comments)
 	if schemaConfig.ShardEnabled() {
-		if isOnlySystemDB = sqlparser.IsOnlySystemDBInTableExprs(statement.From); !isOnlySystemDB {
+		if isOnlySystemDB = statement.With == nil && sqlparser.IsOnlySystemDBInTableExprs(statement.From); !isOnlySystemDB {
 			var err error
 			if !schemaConfig.CheckTableDisabled {
 				if err = sqlparser.CheckTableExprsInSelect(statement, schemaConfig.GetTables()); err != nil {
@@ -140,37 +312,69 @@ func (r *Router) buildSelectPlan(statement *sqlparser.Select) (*normalPlan, erro
 				}
 			}
 
-			var colValue sqlparser.ValExpr
-			if colValue, err = sqlparser.CheckColumnInSelect(statement, schemaConfig.ShardKey); err != nil {
+			var tables *tableRoute
+			if tables, err = r.routeByTables(schemaConfig, statement); err != nil {
 				return nil, err
-			} else if colValue == nil {
-				return nil, errors.ErrWhereOrJoinOnKey
 			}
-
-			algo := ParseShardAlgorithm(schemaConfig.ShardAlgo)
-			nodeIndex, err = algo(sqlparser.String(colValue), len(schemaConfig.Nodes))
-			if err != nil {
+			var hintNodes []string
+			if tables.nodeNames != nil {
+				nodeName = tables.readNode(r.NodeInTrans)
+			} else if hintNodes, err = r.hintNodes(schemaConfig, hint); err != nil {
+				return nil, err
+			} else if hintNodes != nil {
+				if len(hintNodes) > 1 {
+					if err = checkSelectInMulti(statement); err != nil {
+						return nil, err
+					}
+				}
+				inList = &inListRoute{nodeNames: hintNodes}
+			} else if err = checkJoinsColocated(schemaConfig, statement); err != nil {
 				return nil, err
+			} else if nodeName, mirrorNodeName, err = r.shardNodeInSelect(schemaConfig, statement); err != nil {
+				if len(hint.ShardKey) > 0 {
+					if nodeName, mirrorNodeName, err = r.hintShardNode(schemaConfig, hint, shardRead, err); err != nil {
+						return nil, err
+					}
+				} else if inList, err = r.selectInList(schemaConfig, statement, err); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
-	hint := ReadHint(&statement.Comments)
 
 	plan := new(normalPlan)
 
-	plan.nodeNames = []string{schemaConfig.Nodes[nodeIndex]}
+	plan.nodeNames = []string{nodeName}
 	plan.onSlave = true && !hint.OnMaster && !r.InTrans
 	if isOnlySystemDB {
 		plan.anyNode = true
 	}
+	if len(mirrorNodeName) > 0 {
+		plan.mirror = &Mirror{Schema: r.SchemaName, NodeNames: []string{mirrorNodeName}, Async: true, Read: true}
+	}
 	plan.Statement = statement
+	if inList != nil {
+		inList.apply(plan, statement.Where, func(where *sqlparser.Where) sqlparser.Statement {
+			rewritten := *statement
+			rewritten.Where = where
+			return &rewritten
+		})
+	}
 
 	return plan, nil
 }
 
 func (r *Router) buildUnionPlan(statement *sqlparser.Union) (*normalPlan, error) {
-	schemaConfig := r.Schemas[r.SchemaName]
-	nodeIndex := 0
+	schemaConfig := routingSchema(r.Schemas[r.SchemaName], statement)
+	nodeName := schemaConfig.Nodes[0]
+	var mirrorNodeName string
+	hint := new(Hint)
+	switch left := statement.Left.(type) {
+	case *sqlparser.SimpleSelect:
+		hint = ReadHint(&left.Comments)
+	case *sqlparser.Select:
+		hint = ReadHint(&left.Comments)
+	}
 	if schemaConfig.ShardEnabled() {
 		var err error
 		if !schemaConfig.CheckTableDisabled {
@@ -179,34 +383,47 @@ func (r *Router) buildUnionPlan(statement *sqlparser.Union) (*normalPlan, error)
 			}
 		}
 
-		var colValue sqlparser.ValExpr
-		if colValue, err = sqlparser.CheckColumnInSelect(statement, schemaConfig.ShardKey); err != nil {
+		var tables *tableRoute
+		if tables, err = r.routeByTables(schemaConfig, statement); err != nil {
 			return nil, err
-		} else if colValue == nil {
-			return nil, errors.ErrWhereOrJoinOnKey
 		}
-
-		algo := ParseShardAlgorithm(schemaConfig.ShardAlgo)
-		nodeIndex, err = algo(sqlparser.String(colValue), len(schemaConfig.Nodes))
-		if err != nil {
+		var hintNodes []string
+		if tables.nodeNames != nil {
+			nodeName = tables.readNode(r.NodeInTrans)
+		} else if hintNodes, err = r.hintNodes(schemaConfig, hint); err != nil {
+			return nil, err
+		} else if len(hintNodes) > 1 {
+			return nil, errHintNodesInUnion
+		} else if len(hintNodes) == 1 {
+			nodeName = hintNodes[0]
+		} else if err = checkJoinsColocated(schemaConfig, statement); err != nil {
 			return nil, err
+		} else if nodeName, mirrorNodeName, err = r.shardNodeInSelect(schemaConfig, statement); err != nil {
+			if nodeName, mirrorNodeName, err = r.hintShardNode(schemaConfig, hint, shardRead, err); err != nil {
+				return nil, err
+			}
 		}
 	}
-	var hint *Hint
-	switch left := statement.Left.(type) {
-	case *sqlparser.SimpleSelect:
-		hint = ReadHint(&left.Comments)
-	case *sqlparser.Select:
-		hint = ReadHint(&left.Comments)
-	}
 
 	plan := new(normalPlan)
-	plan.nodeNames = []string{schemaConfig.Nodes[nodeIndex]}
-	plan.onSlave = true && !r.InTrans
-	if hint != nil {
-		plan.onSlave = plan.onSlave && !hint.OnMaster
+	plan.nodeNames = []string{nodeName}
+	plan.onSlave = true && !r.InTrans && !hint.OnMaster
+	if len(mirrorNodeName) > 0 {
+		plan.mirror = &Mirror{Schema: r.SchemaName, NodeNames: []string{mirrorNodeName}, Async: true, Read: true}
 	}
 	plan.Statement = statement
 
 	return plan, nil
 }
+
+// shardNodeInSelect get node and dark read node by value of shard key in where and join expression.
+func (r *Router) shardNodeInSelect(schemaConfig *config.SchemaConfig, statement sqlparser.SelectStatement) (string, string, error) {
+	colValue, err := sqlparser.CheckColumnInSelect(statement, schemaConfig.ShardKey)
+	if err != nil {
+		return "", "", err
+	} else if colValue == nil {
+		return "", "", errors.ErrWhereOrJoinOnKey
+	}
+
+	return r.shardReadNode(schemaConfig, colValue)
+}
@@ -0,0 +1,103 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+// TestSimpleSelectLastInsertID LAST_INSERT_ID() is answered by proxy with alias as field name,
+// or replaced by value of session if forwarded to backend.
+func TestSimpleSelectLastInsertID(t *testing.T) {
+	r := newTransTestRouter(t, false)
+	r.LastInsertID = 42
+	cases := []struct {
+		sql     string
+		name    string // field name if answered by proxy.
+		planSQL string // sql forwarded to backend.
+		onSlave bool
+	}{
+		{sql: "select LAST_INSERT_ID()", name: "LAST_INSERT_ID()"},
+		{sql: "select last_insert_id() as id", name: "id"},
+		{sql: "select last_insert_id(), 1", planSQL: "select 42 as `last_insert_id()`, 1", onSlave: true},
+		{sql: "select last_insert_id(5), 1", planSQL: "select last_insert_id(5), 1"},
+	}
+	for _, tc := range cases {
+		stmt, err := sqlparser.Parse(tc.sql)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan, err := r.BuildNormalPlan(stmt)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.sql, err)
+		}
+		normal := plan.(*normalPlan)
+		if len(tc.name) > 0 {
+			if normal.Result == nil {
+				t.Fatalf("%s: expect answered by proxy", tc.sql)
+			}
+			if name := string(normal.Result.Fields[0].Name); name != tc.name {
+				t.Errorf("%s: expect field name '%s', got '%s'", tc.sql, tc.name, name)
+			}
+			if row := string(normal.Result.Rows[0].Dump()); row != "\x0242" {
+				t.Errorf("%s: expect 42, got %q", tc.sql, row)
+			}
+			continue
+		}
+		if normal.Result != nil {
+			t.Fatalf("%s: expect forwarded to backend", tc.sql)
+		}
+		if planSQL := plan.GetPlanSQL(); planSQL != tc.planSQL {
+			t.Errorf("%s: expect '%s', got '%s'", tc.sql, tc.planSQL, planSQL)
+		}
+		if plan.OnSlave() != tc.onSlave {
+			t.Errorf("%s: expect on slave %v, got %v", tc.sql, tc.onSlave, plan.OnSlave())
+		}
+	}
+}
+
+// TestSimpleSelectFoundRows FOUND_ROWS() and ROW_COUNT() are values of session, not of random node.
+func TestSimpleSelectFoundRows(t *testing.T) {
+	r := newTransTestRouter(t, false)
+	r.FoundRows = 7
+	r.RowCount = -1
+	cases := []struct {
+		sql     string
+		name    string // field name if answered by proxy.
+		row     string
+		planSQL string // sql forwarded to backend.
+	}{
+		{sql: "SELECT FOUND_ROWS() AS total", name: "total", row: "\x017"},
+		{sql: "select row_count() as n", name: "n", row: "\x02-1"},
+		{sql: "select found_rows() + 1, row_count()", planSQL: "select 7+1, -1 as `row_count()`"},
+	}
+	for _, tc := range cases {
+		stmt, err := sqlparser.Parse(tc.sql)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan, err := r.BuildNormalPlan(stmt)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.sql, err)
+		}
+		normal := plan.(*normalPlan)
+		if len(tc.name) > 0 {
+			if normal.Result == nil {
+				t.Fatalf("%s: expect answered by proxy", tc.sql)
+			}
+			if name := string(normal.Result.Fields[0].Name); name != tc.name {
+				t.Errorf("%s: expect field name '%s', got '%s'", tc.sql, tc.name, name)
+			}
+			if row := string(normal.Result.Rows[0].Dump()); row != tc.row {
+				t.Errorf("%s: expect row %q, got %q", tc.sql, tc.row, row)
+			}
+			continue
+		}
+		if normal.Result != nil {
+			t.Fatalf("%s: expect forwarded to backend", tc.sql)
+		}
+		if planSQL := plan.GetPlanSQL(); planSQL != tc.planSQL {
+			t.Errorf("%s: expect '%s', got '%s'", tc.sql, tc.planSQL, planSQL)
+		}
+	}
+}
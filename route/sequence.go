@@ -0,0 +1,137 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+var errSequenceInsertSelect = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+	"INSERT ... SELECT omitting auto_increment column of sequence")
+
+// Sequence generates ids of distributed sequences, unique across proxies and data nodes.
+type Sequence interface {
+	// Next get count ids of sequence, ascending.
+	Next(name string, count int) ([]uint64, error)
+}
+
+// SequenceName get name of sequence of auto_increment column of table, 'schema.table'.
+func SequenceName(schemaName, tableName string) string {
+	return schemaName + "." + tableName
+}
+
+// fillAutoIncrement fill auto_increment column of table omitted or NULL in rows of INSERT or REPLACE by ids of sequence,
+// added to columns if omitted. The first id is kept as insert id of statement, as LAST_INSERT_ID() of auto-increment.
+func (r *Router) fillAutoIncrement(statement sqlparser.Statement) error {
+	if r.Sequence == nil {
+		return nil
+	}
+	var table *sqlparser.TableName
+	var columns *sqlparser.Columns
+	var rows sqlparser.InsertRows
+	switch v := statement.(type) {
+	case *sqlparser.Insert:
+		table, columns, rows = v.Table, &v.Columns, v.Rows
+	case *sqlparser.Replace:
+		table, columns, rows = v.Table, &v.Columns, v.Rows
+	default:
+		return nil
+	}
+	schemaConfig := r.Schemas[r.SchemaName]
+	if schemaConfig == nil || table == nil {
+		return nil
+	}
+	tableName := tableNameOf(table)
+	tableConfig := schemaConfig.GetTables()[tableName]
+	if tableConfig == nil || len(tableConfig.AutoIncrement) == 0 || len(*columns) == 0 {
+		// values of all columns in order, auto_increment column is given.
+		return nil
+	}
+	pos := autoIncrementPos(tableConfig, *columns)
+	values, ok := rows.(sqlparser.Values)
+	if !ok {
+		if pos < 0 {
+			return errSequenceInsertSelect
+		}
+		return nil
+	}
+
+	// rows to fill, all if column is omitted.
+	var fills []int
+	for i, row := range values {
+		tuple, ok := row.(sqlparser.ValTuple)
+		if !ok {
+			continue
+		}
+		if pos < 0 {
+			fills = append(fills, i)
+		} else if pos < len(tuple) {
+			if _, isNull := tuple[pos].(*sqlparser.NullVal); isNull {
+				fills = append(fills, i)
+			}
+		}
+	}
+	if len(fills) == 0 {
+		return nil
+	}
+	ids, err := r.Sequence.Next(SequenceName(r.SchemaName, tableName), len(fills))
+	if err != nil {
+		return mysql.NewError(mysql.ER_UNKNOWN_ERROR, "sequence of table '"+tableName+"': "+err.Error())
+	}
+	if pos < 0 {
+		*columns = append(*columns, &sqlparser.NonStarExpr{Expr: &sqlparser.ColName{Name: []byte(tableConfig.AutoIncrement)}})
+	}
+	for i, rowIndex := range fills {
+		id := sqlparser.NumVal(strconv.FormatUint(ids[i], 10))
+		tuple := values[rowIndex].(sqlparser.ValTuple)
+		if pos < 0 {
+			values[rowIndex] = append(tuple, id)
+		} else {
+			tuple[pos] = id
+		}
+	}
+	if r.InsertIDs == nil {
+		r.InsertIDs = make(map[sqlparser.Statement]uint64)
+	}
+	r.InsertIDs[statement] = ids[0]
+	r.debugf("filled %s of %d rows by sequence", tableConfig.AutoIncrement, len(fills))
+	return nil
+}
+
+// autoIncrementPos get position of auto_increment column in columns, -1 if omitted.
+func autoIncrementPos(tableConfig *config.TableConfig, columns sqlparser.Columns) int {
+	for i, column := range columns {
+		if nonStarExpr, ok := column.(*sqlparser.NonStarExpr); ok {
+			if colName, ok := nonStarExpr.Expr.(*sqlparser.ColName); ok &&
+				strings.EqualFold(strings.Trim(string(colName.Name), "`"), tableConfig.AutoIncrement) {
+				return i
+			}
+		}
+	}
+	return -1
+}
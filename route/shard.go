@@ -24,10 +24,13 @@ package route
 
 import (
 	"hash/crc32"
+	"math/rand"
 	"strconv"
 	"strings"
 
+	"github.com/berkaroad/saashard/config"
 	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/sqlparser"
 )
 
 // ShardAlgorithm shard algorithm
@@ -41,12 +44,27 @@ func ParseShardAlgorithm(name string) ShardAlgorithm {
 	switch name {
 	case "mod":
 		algo = ModShardAlgo
+	case "consistent_hash":
+		algo = ConsistentHashShardAlgo
+	case "range":
+		algo = RangeShardAlgo
+	case "directory":
+		algo = DirectoryShardAlgo
 	default:
 		algo = HashShardAlgo
 	}
 	return algo
 }
 
+// IsShardAlgorithm the name is supported shard algorithm or not.
+func IsShardAlgorithm(name string) bool {
+	switch strings.TrimSpace(strings.ToLower(name)) {
+	case "", "hash", "mod", "consistent_hash", "range", "directory":
+		return true
+	}
+	return false
+}
+
 // HashShardAlgo hash shard algorithm
 func HashShardAlgo(val string, dataNodeCount int, params ...interface{}) (int, error) {
 	val = strings.Trim(val, "'")
@@ -67,3 +85,94 @@ func ModShardAlgo(val string, dataNodeCount int, params ...interface{}) (int, er
 	index := (num - 1) % dataNodeCount
 	return index, nil
 }
+
+// shardNode get node by value of shard key, by versioned shard map if exists.
+// insert is true if routing insert, that assigns node to new shard key in directory shard algorithm.
+func (r *Router) shardNode(schemaConfig *config.SchemaConfig, colValue sqlparser.ValExpr, insert bool) (string, error) {
+	value := sqlparser.String(colValue)
+	if r.ShardMaps != nil {
+		if m := r.ShardMaps.Get(schemaConfig.Name); m != nil {
+			rule := m.Rule(strings.Trim(value, "'"))
+			nodeName, err := r.ruleNode(schemaConfig.Name, rule, value, insert)
+			r.debugf("shard key %s=%s, shard map version %d, shard algo %s, node %s", schemaConfig.ShardKey, value,
+				rule.Version, rule.ShardAlgo, nodeName)
+			return nodeName, err
+		}
+	}
+	rule := &ShardRule{ShardAlgo: schemaConfig.ShardAlgo, Nodes: schemaConfig.Nodes, Replicas: schemaConfig.ShardReplicas,
+		Ranges: schemaConfig.ShardRanges}
+	nodeName, err := r.ruleNode(schemaConfig.Name, rule, value, insert)
+	if err != nil {
+		return "", err
+	}
+	r.debugf("shard key %s=%s, shard algo %s, node %s", schemaConfig.ShardKey, value,
+		schemaConfig.ShardAlgo, nodeName)
+	return nodeName, nil
+}
+
+// shardWriteNode get node to write by value of shard key,
+// and mirror node in the other shard rule, if dual-write in shard migration.
+func (r *Router) shardWriteNode(schemaConfig *config.SchemaConfig, colValue sqlparser.ValExpr, insert bool) (nodeName, mirrorNodeName string, err error) {
+	if nodeName, err = r.shardNode(schemaConfig, colValue, insert); err != nil {
+		return
+	}
+	if schemaConfig.GetDualWrite() != config.DualWriteOff {
+		mirrorNodeName = r.mirrorNode(schemaConfig, colValue, nodeName, insert)
+	}
+	return
+}
+
+// shardReadNode get node to read by value of shard key,
+// and mirror node in the other shard rule, if sampled as dark read in shard migration.
+func (r *Router) shardReadNode(schemaConfig *config.SchemaConfig, colValue sqlparser.ValExpr) (nodeName, mirrorNodeName string, err error) {
+	if nodeName, err = r.shardNode(schemaConfig, colValue, false); err != nil {
+		return
+	}
+	// uncommitted data in transaction is invisible to mirror.
+	if !r.InTrans && schemaConfig.DarkReadRate > 0 && rand.Float64() < schemaConfig.DarkReadRate {
+		mirrorNodeName = r.mirrorNode(schemaConfig, colValue, nodeName, false)
+	}
+	return
+}
+
+// mirrorNode get node by value of shard key in the other shard rule, when validated shard rule is staged.
+// Return empty if not in shard migration or the same node.
+func (r *Router) mirrorNode(schemaConfig *config.SchemaConfig, colValue sqlparser.ValExpr, nodeName string, insert bool) string {
+	if r.ShardMaps == nil {
+		return ""
+	}
+	m := r.ShardMaps.Get(schemaConfig.Name)
+	if m == nil || m.Staged == nil || !m.Validated {
+		return ""
+	}
+	value := sqlparser.String(colValue)
+	other := m.Staged
+	if m.Rule(strings.Trim(value, "'")) == m.Staged {
+		other = m.Active
+	}
+	mirrorNodeName, err := r.ruleNode(schemaConfig.Name, other, value, insert)
+	if err != nil || mirrorNodeName == nodeName {
+		return ""
+	}
+	return mirrorNodeName
+}
+
+// shardNodes get nodes owning all rows of sharded schema, by versioned shard map if exists.
+func (r *Router) shardNodes(schemaConfig *config.SchemaConfig) []string {
+	if r.ShardMaps != nil {
+		if m := r.ShardMaps.Get(schemaConfig.Name); m != nil {
+			return m.Nodes(schemaConfig.GetDualWrite() != config.DualWriteOff)
+		}
+	}
+	return schemaConfig.Nodes
+}
+
+// allShardNodes get nodes of sharded schema, with nodes of staged rule when partially switched.
+func (r *Router) allShardNodes(schemaConfig *config.SchemaConfig) []string {
+	if r.ShardMaps != nil {
+		if m := r.ShardMaps.Get(schemaConfig.Name); m != nil {
+			return m.AllNodes()
+		}
+	}
+	return schemaConfig.Nodes
+}
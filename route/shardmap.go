@@ -0,0 +1,430 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/utils"
+)
+
+// ShardRule is one version of shard rule in sharded schema.
+type ShardRule struct {
+	Version   int
+	ShardAlgo string
+	Nodes     []string
+	// Replicas is virtual nodes of each data node in consistent hash.
+	Replicas int
+	// Ranges is ranges of shard key in data nodes in range shard algorithm.
+	Ranges []config.ShardRangeConfig
+}
+
+// ShardNode get node by value of shard key.
+func (rule *ShardRule) ShardNode(value string) (string, error) {
+	algo := ParseShardAlgorithm(rule.ShardAlgo)
+	nodeIndex, err := algo(value, len(rule.Nodes), rule.Nodes, rule.Replicas, rule.Ranges)
+	if err != nil {
+		return "", err
+	}
+	return rule.Nodes[nodeIndex], nil
+}
+
+// KeyRange is range of shard key value, [Min, Max].
+// Compare as integer if both bounds and value are integers, or compare as string.
+type KeyRange struct {
+	Min string
+	Max string
+}
+
+// ParseKeyRange parse key range like '100-199' or '100' (single key).
+func ParseKeyRange(s string) (KeyRange, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 {
+		return KeyRange{}, fmt.Errorf("empty key range")
+	}
+	// '-' in first char is sign of integer.
+	if pos := strings.Index(s[1:], "-"); pos >= 0 {
+		kr := KeyRange{Min: strings.TrimSpace(s[:pos+1]), Max: strings.TrimSpace(s[pos+2:])}
+		if len(kr.Min) == 0 || len(kr.Max) == 0 || compareKey(kr.Min, kr.Max) > 0 {
+			return KeyRange{}, fmt.Errorf("invalid key range '%s'", s)
+		}
+		return kr, nil
+	}
+	return KeyRange{Min: s, Max: s}, nil
+}
+
+// Contains the value in range or not.
+func (kr KeyRange) Contains(value string) bool {
+	return compareKey(kr.Min, value) <= 0 && compareKey(value, kr.Max) <= 0
+}
+
+func (kr KeyRange) String() string {
+	if kr.Min == kr.Max {
+		return kr.Min
+	}
+	return kr.Min + "-" + kr.Max
+}
+
+func compareKey(a, b string) int {
+	if x, err := strconv.ParseInt(a, 10, 64); err == nil {
+		if y, err := strconv.ParseInt(b, 10, 64); err == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// ShardMap is versioned shard rules of one schema, it's immutable.
+type ShardMap struct {
+	Schema   string
+	Active   *ShardRule
+	Previous *ShardRule // used to rollback after switched.
+	Staged   *ShardRule
+	// Validated staged rule can be switched.
+	Validated bool
+	// Ranges of shard key are switched to staged rule, others still use active rule.
+	Ranges []KeyRange
+
+	// configured is rule in config last loaded, to find rule changed by reload.
+	configured *ShardRule
+}
+
+// Rule get shard rule by value of shard key.
+func (m *ShardMap) Rule(value string) *ShardRule {
+	if m.Staged != nil {
+		for _, kr := range m.Ranges {
+			if kr.Contains(value) {
+				return m.Staged
+			}
+		}
+	}
+	return m.Active
+}
+
+// Nodes to read or write all rows until switch is committed. If mirrored by dual-write, ranges partially switched are
+// still in nodes of active rule, and reading staged rule's too returns them twice, so it's active rule's.
+// Otherwise rows of ranges switched are only in nodes of staged rule, so it's all nodes.
+func (m *ShardMap) Nodes(mirrored bool) []string {
+	if mirrored {
+		return m.Active.Nodes
+	}
+	return m.AllNodes()
+}
+
+// AllNodes of active rule, and staged rule's when partially switched, such as to run DDL.
+func (m *ShardMap) AllNodes() []string {
+	if m.Staged == nil || len(m.Ranges) == 0 {
+		return m.Active.Nodes
+	}
+	nodes := make([]string, 0, len(m.Active.Nodes)+len(m.Staged.Nodes))
+	nodes = append(nodes, m.Active.Nodes...)
+	for _, node := range m.Staged.Nodes {
+		if !utils.Contains(nodes, node) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// maxVersion of rules in shard map.
+func (m *ShardMap) maxVersion() int {
+	version := m.Active.Version
+	for _, rule := range []*ShardRule{m.Previous, m.Staged} {
+		if rule != nil && rule.Version > version {
+			version = rule.Version
+		}
+	}
+	return version
+}
+
+func (m *ShardMap) clone() *ShardMap {
+	newMap := *m
+	newMap.Ranges = append([]KeyRange(nil), m.Ranges...)
+	return &newMap
+}
+
+// ShardMaps is shard maps of sharded schemas.
+// Readers get immutable snapshot without lock, writers replace it atomically.
+// Shard map updated is saved in store before replaced, if store is set.
+type ShardMaps struct {
+	sync.Mutex
+	maps  atomic.Value // map[string]*ShardMap
+	store ShardMapStore
+	// next is shard maps replacing this by reload, writers are forwarded to it, so that no update is lost.
+	next *ShardMaps
+}
+
+// NewShardMaps create shard maps, version 1 of each sharded schema is from config.
+func NewShardMaps(schemas map[string]*config.SchemaConfig) *ShardMaps {
+	maps := make(map[string]*ShardMap, len(schemas))
+	for name, schemaConfig := range schemas {
+		if !schemaConfig.ShardEnabled() {
+			continue
+		}
+		maps[name] = newShardMap(name, schemaConfig)
+	}
+	s := new(ShardMaps)
+	s.maps.Store(maps)
+	return s
+}
+
+// newShardMap create shard map of sharded schema, version 1 is from config.
+func newShardMap(name string, schemaConfig *config.SchemaConfig) *ShardMap {
+	m := new(ShardMap)
+	m.Schema = name
+	m.Active = newConfigRule(schemaConfig, 1)
+	m.configured = m.Active
+	return m
+}
+
+func newConfigRule(schemaConfig *config.SchemaConfig, version int) *ShardRule {
+	return &ShardRule{Version: version, ShardAlgo: schemaConfig.ShardAlgo, Nodes: schemaConfig.Nodes, Replicas: schemaConfig.ShardReplicas,
+		Ranges: schemaConfig.ShardRanges}
+}
+
+// sameRule is true if rules route shard keys the same, regardless of version.
+func sameRule(a, b *ShardRule) bool {
+	return a.ShardAlgo == b.ShardAlgo && a.Replicas == b.Replicas &&
+		reflect.DeepEqual(a.Nodes, b.Nodes) && reflect.DeepEqual(a.Ranges, b.Ranges)
+}
+
+// Reload create shard maps by sharded schemas of reloaded config to replace this, nodes are data nodes of it.
+// Rule changed in config is activated as a new version, and the active one is kept as previous to rollback;
+// maps not changed in config are kept, with rules staged or switched online, error if their nodes are removed.
+// It's published with schemas of the config as a whole, readers of this see maps of the old config until then.
+func (s *ShardMaps) Reload(schemas map[string]*config.SchemaConfig, nodes map[string]*config.NodeConfig) (*ShardMaps, error) {
+	s = s.latest()
+	defer s.Unlock()
+	exists := func(rule *ShardRule) bool {
+		for _, node := range rule.Nodes {
+			if nodes[node] == nil {
+				return false
+			}
+		}
+		return true
+	}
+	maps := s.maps.Load().(map[string]*ShardMap)
+	newMaps := make(map[string]*ShardMap, len(schemas))
+	for name, schemaConfig := range schemas {
+		if !schemaConfig.ShardEnabled() {
+			continue
+		}
+		m := maps[name]
+		rule := newConfigRule(schemaConfig, 1)
+		switch {
+		case m == nil:
+			m = newShardMap(name, schemaConfig)
+		case !sameRule(m.configured, rule):
+			m = m.clone()
+			rule.Version = m.maxVersion() + 1
+			m.Previous = m.Active
+			if !exists(m.Previous) {
+				m.Previous = nil
+			}
+			m.Active = rule
+			m.Staged = nil
+			m.Validated = false
+			m.Ranges = nil
+			m.configured = rule
+		default:
+			for _, rule := range []*ShardRule{m.Active, m.Staged} {
+				if rule != nil && !exists(rule) {
+					return nil, fmt.Errorf("shard rule version %d of schema '%s' has data node removed", rule.Version, name)
+				}
+			}
+			if m.Previous != nil && !exists(m.Previous) {
+				m = m.clone()
+				m.Previous = nil
+			}
+		}
+		newMaps[name] = m
+	}
+	next := &ShardMaps{store: s.store}
+	next.maps.Store(newMaps)
+	s.next = next
+	return next, nil
+}
+
+// latest lock shard maps not replaced by reload, following this.
+func (s *ShardMaps) latest() *ShardMaps {
+	s.Lock()
+	for s.next != nil {
+		next := s.next
+		s.Unlock()
+		s = next
+		s.Lock()
+	}
+	return s
+}
+
+// Get shard map of schema.
+func (s *ShardMaps) Get(schemaName string) *ShardMap {
+	return s.maps.Load().(map[string]*ShardMap)[schemaName]
+}
+
+// List all shard maps.
+func (s *ShardMaps) List() []*ShardMap {
+	maps := s.maps.Load().(map[string]*ShardMap)
+	list := make([]*ShardMap, 0, len(maps))
+	for _, m := range maps {
+		list = append(list, m)
+	}
+	return list
+}
+
+// Stage a new version of shard rule, it should be validated before switch.
+func (s *ShardMaps) Stage(schemaName string, shardAlgo string, nodes []string) (*ShardRule, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no data node in shard rule")
+	}
+	return s.update(schemaName, func(m *ShardMap) (*ShardRule, error) {
+		if m.Staged != nil && len(m.Ranges) > 0 {
+			return nil, fmt.Errorf("shard map of schema '%s' is switching, rollback or switch all first", schemaName)
+		}
+		m.Staged = &ShardRule{Version: m.maxVersion() + 1, ShardAlgo: shardAlgo, Nodes: nodes, Replicas: m.Active.Replicas,
+			Ranges: m.Active.Ranges}
+		m.Validated = false
+		return m.Staged, nil
+	})
+}
+
+// Validate staged shard rule by check func.
+func (s *ShardMaps) Validate(schemaName string, check func(rule *ShardRule) error) (*ShardRule, error) {
+	m := s.Get(schemaName)
+	if m == nil {
+		return nil, fmt.Errorf("no shard map of schema '%s'", schemaName)
+	}
+	if m.Staged == nil {
+		return nil, fmt.Errorf("no staged shard rule of schema '%s'", schemaName)
+	}
+	staged := m.Staged
+	// check outside of lock, it may access backend.
+	if err := check(staged); err != nil {
+		return nil, err
+	}
+	return s.update(schemaName, func(m *ShardMap) (*ShardRule, error) {
+		if m.Staged != staged {
+			return nil, fmt.Errorf("staged shard rule of schema '%s' is changed", schemaName)
+		}
+		m.Validated = true
+		return m.Staged, nil
+	})
+}
+
+// Switch routing to validated staged rule atomically.
+// If ranges is not empty, only these ranges of shard key are switched, and could switch more ranges later.
+func (s *ShardMaps) Switch(schemaName string, ranges []KeyRange) (*ShardRule, error) {
+	return s.update(schemaName, func(m *ShardMap) (*ShardRule, error) {
+		if m.Staged == nil || !m.Validated {
+			return nil, fmt.Errorf("no validated shard rule of schema '%s'", schemaName)
+		}
+		if len(ranges) > 0 {
+			m.Ranges = append(m.Ranges, ranges...)
+			return m.Staged, nil
+		}
+		m.Previous = m.Active
+		m.Active = m.Staged
+		m.Staged = nil
+		m.Validated = false
+		m.Ranges = nil
+		return m.Active, nil
+	})
+}
+
+// Rollback partial switch, or rollback to previous version after switched.
+func (s *ShardMaps) Rollback(schemaName string) (*ShardRule, error) {
+	return s.update(schemaName, func(m *ShardMap) (*ShardRule, error) {
+		switch {
+		case len(m.Ranges) > 0:
+			m.Ranges = nil
+		case m.Previous != nil:
+			m.Active, m.Staged = m.Previous, m.Active
+			m.Previous = nil
+			m.Validated = true
+		default:
+			return nil, fmt.Errorf("nothing to rollback in shard map of schema '%s'", schemaName)
+		}
+		return m.Active, nil
+	})
+}
+
+// Discard staged shard rule, that not switched.
+func (s *ShardMaps) Discard(schemaName string) (*ShardRule, error) {
+	return s.update(schemaName, func(m *ShardMap) (*ShardRule, error) {
+		if m.Staged == nil {
+			return nil, fmt.Errorf("no staged shard rule of schema '%s'", schemaName)
+		}
+		if len(m.Ranges) > 0 {
+			return nil, fmt.Errorf("shard map of schema '%s' is switching, rollback first", schemaName)
+		}
+		staged := m.Staged
+		m.Staged = nil
+		m.Validated = false
+		return staged, nil
+	})
+}
+
+// update shard map by copy on write.
+func (s *ShardMaps) update(schemaName string, f func(m *ShardMap) (*ShardRule, error)) (*ShardRule, error) {
+	s = s.latest()
+	defer s.Unlock()
+
+	maps := s.maps.Load().(map[string]*ShardMap)
+	m := maps[schemaName]
+	if m == nil {
+		return nil, fmt.Errorf("no shard map of schema '%s'", schemaName)
+	}
+	m = m.clone()
+	rule, err := f(m)
+	if err != nil {
+		return nil, err
+	}
+	if s.store != nil {
+		state, err := m.state()
+		if err == nil {
+			err = s.store.Save(schemaName, state)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("shard map of schema '%s' not saved: %s", schemaName, err.Error())
+		}
+	}
+	newMaps := make(map[string]*ShardMap, len(maps))
+	for name, v := range maps {
+		newMaps[name] = v
+	}
+	newMaps[schemaName] = m
+	s.maps.Store(newMaps)
+	return rule, nil
+}
@@ -0,0 +1,188 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package route
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// ShardMapStore keeps staged and switched state of shard maps out of process, so that routing in shard migration
+// survives restart and is shared by proxies of the same store.
+type ShardMapStore interface {
+	// Load states of shard maps by schema.
+	Load() (map[string][]byte, error)
+	// Save state of shard map of schema.
+	Save(schemaName string, state []byte) error
+}
+
+// shardMapState is state of shard map saved in store.
+// Configured is rule in config when it's saved, state is ignored if config is changed since then.
+type shardMapState struct {
+	Active     *ShardRule
+	Previous   *ShardRule
+	Staged     *ShardRule
+	Validated  bool
+	Ranges     []KeyRange
+	Configured *ShardRule
+}
+
+func (m *ShardMap) state() ([]byte, error) {
+	return json.Marshal(&shardMapState{Active: m.Active, Previous: m.Previous, Staged: m.Staged, Validated: m.Validated,
+		Ranges: m.Ranges, Configured: m.configured})
+}
+
+// restore shard map from state saved, nil if config is changed since saved or state is the same.
+func (m *ShardMap) restore(data []byte) (*ShardMap, error) {
+	var state shardMapState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	if state.Active == nil || state.Configured == nil || !sameRule(state.Configured, m.configured) {
+		return nil, nil
+	}
+	// compared after decoded, as formatting of stored data may be different.
+	saved, err := json.Marshal(&state)
+	if err != nil {
+		return nil, err
+	}
+	if current, err := m.state(); err == nil && string(current) == string(saved) {
+		return nil, nil
+	}
+	newMap := m.clone()
+	newMap.Active = state.Active
+	newMap.Previous = state.Previous
+	newMap.Staged = state.Staged
+	newMap.Validated = state.Validated
+	newMap.Ranges = state.Ranges
+	return newMap, nil
+}
+
+// SetStore set store of shard maps, and restore states saved in it.
+func (s *ShardMaps) SetStore(store ShardMapStore) error {
+	s = s.latest()
+	s.store = store
+	s.Unlock()
+	return s.Refresh()
+}
+
+// Refresh restore states of shard maps saved in store by this or other proxies, nothing if no store.
+// State saved with a different config is ignored, as rule changed in config is activated by reload.
+func (s *ShardMaps) Refresh() error {
+	s = s.latest()
+	defer s.Unlock()
+	if s.store == nil {
+		return nil
+	}
+	states, err := s.store.Load()
+	if err != nil {
+		return err
+	}
+	maps := s.maps.Load().(map[string]*ShardMap)
+	var newMaps map[string]*ShardMap
+	for name, data := range states {
+		m := maps[name]
+		if m == nil {
+			continue
+		}
+		restored, err := m.restore(data)
+		if err != nil {
+			return err
+		}
+		if restored == nil {
+			continue
+		}
+		if newMaps == nil {
+			newMaps = make(map[string]*ShardMap, len(maps))
+			for name, v := range maps {
+				newMaps[name] = v
+			}
+		}
+		newMaps[name] = restored
+	}
+	if newMaps != nil {
+		s.maps.Store(newMaps)
+	}
+	return nil
+}
+
+// FileShardMapStore is store of shard maps in a json file, survives restart of a proxy.
+type FileShardMapStore struct {
+	sync.Mutex
+	path string
+}
+
+// NewFileShardMapStore create store of shard maps in json file.
+func NewFileShardMapStore(path string) *FileShardMapStore {
+	return &FileShardMapStore{path: path}
+}
+
+// Load states of shard maps by schema, empty if file not exists.
+func (f *FileShardMapStore) Load() (map[string][]byte, error) {
+	f.Lock()
+	defer f.Unlock()
+	return f.load()
+}
+
+func (f *FileShardMapStore) load() (map[string][]byte, error) {
+	states := make(map[string]json.RawMessage)
+	data, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return map[string][]byte{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &states); err != nil {
+		return nil, err
+	}
+	result := make(map[string][]byte, len(states))
+	for name, state := range states {
+		result[name] = state
+	}
+	return result, nil
+}
+
+// Save state of shard map of schema, file is replaced by rename.
+func (f *FileShardMapStore) Save(schemaName string, state []byte) error {
+	f.Lock()
+	defer f.Unlock()
+	states, err := f.load()
+	if err != nil {
+		return err
+	}
+	states[schemaName] = state
+	raw := make(map[string]json.RawMessage, len(states))
+	for name, state := range states {
+		raw[name] = state
+	}
+	data, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.path + ".tmp"
+	if err = ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, f.path)
+}
@@ -0,0 +1,395 @@
+package route
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/berkaroad/saashard/config"
+)
+
+// parseShardMapConfig get schemas and data nodes of config.
+func parseShardMapConfig(t testing.TB, data string) (map[string]*config.SchemaConfig, map[string]*config.NodeConfig) {
+	cfg, err := config.ParseConfigData([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas := make(map[string]*config.SchemaConfig)
+	for i := range cfg.Schemas {
+		schemas[cfg.Schemas[i].Name] = &cfg.Schemas[i]
+	}
+	return schemas, cfg.GetNodes()
+}
+
+const shardMapTestConfig = `
+nodes:
+  - name: n1
+  - name: n2
+  - name: n3
+schemas:
+  - name: db1
+    shard_key: id
+    shard_algo: mod
+    nodes: [n1, n2]
+`
+
+func newTestShardMaps(t testing.TB) *ShardMaps {
+	schemas, _ := parseShardMapConfig(t, shardMapTestConfig)
+	return NewShardMaps(schemas)
+}
+
+func TestParseKeyRange(t *testing.T) {
+	cases := []struct {
+		s       string
+		want    KeyRange
+		wantErr bool
+	}{
+		{"100-199", KeyRange{"100", "199"}, false},
+		{" 100 - 199 ", KeyRange{"100", "199"}, false},
+		{"100", KeyRange{"100", "100"}, false},
+		{"-5", KeyRange{"-5", "-5"}, false},
+		{"-5--1", KeyRange{"-5", "-1"}, false},
+		{"a-c", KeyRange{"a", "c"}, false},
+		{"99-100", KeyRange{"99", "100"}, false},
+		{"", KeyRange{}, true},
+		{"100-", KeyRange{}, true},
+		{"199-100", KeyRange{}, true},
+		{"c-a", KeyRange{}, true},
+	}
+	for _, tc := range cases {
+		kr, err := ParseKeyRange(tc.s)
+		if tc.wantErr != (err != nil) {
+			t.Errorf("%q: expect error %v, got %v", tc.s, tc.wantErr, err)
+			continue
+		}
+		if kr != tc.want {
+			t.Errorf("%q: expect %v, got %v", tc.s, tc.want, kr)
+		}
+	}
+}
+
+func TestKeyRangeContains(t *testing.T) {
+	cases := []struct {
+		kr    KeyRange
+		value string
+		want  bool
+	}{
+		{KeyRange{"100", "199"}, "100", true},
+		{KeyRange{"100", "199"}, "199", true},
+		{KeyRange{"100", "199"}, "150", true},
+		{KeyRange{"100", "199"}, "99", false}, // compared as integer, not string.
+		{KeyRange{"100", "199"}, "1000", false},
+		{KeyRange{"-5", "-1"}, "-3", true},
+		{KeyRange{"a", "c"}, "b", true},
+		{KeyRange{"a", "c"}, "d", false},
+	}
+	for _, tc := range cases {
+		if got := tc.kr.Contains(tc.value); got != tc.want {
+			t.Errorf("%v contains %q: expect %v, got %v", tc.kr, tc.value, tc.want, got)
+		}
+	}
+}
+
+// versionOf rule, 0 if nil.
+func versionOf(rule *ShardRule) int {
+	if rule == nil {
+		return 0
+	}
+	return rule.Version
+}
+
+// TestShardMapsSwitch stage, validate, switch partially then all, and rollback, in order.
+func TestShardMapsSwitch(t *testing.T) {
+	s := newTestShardMaps(t)
+	initial := s.Get("db1")
+	steps := []struct {
+		name    string
+		op      func() (*ShardRule, error)
+		wantErr bool
+		// versions of active, previous and staged after step.
+		active, previous, staged int
+		validated                bool
+		ranges                   int
+		// shard key routed by staged rule, and not.
+		staging, notStaging string
+	}{
+		{name: "switch without staged", op: func() (*ShardRule, error) { return s.Switch("db1", nil) }, wantErr: true,
+			active: 1},
+		{name: "stage", op: func() (*ShardRule, error) { return s.Stage("db1", "mod", []string{"n1", "n2", "n3"}) },
+			active: 1, staged: 2},
+		{name: "switch not validated", op: func() (*ShardRule, error) { return s.Switch("db1", nil) }, wantErr: true,
+			active: 1, staged: 2},
+		{name: "validate failed", op: func() (*ShardRule, error) {
+			return s.Validate("db1", func(rule *ShardRule) error { return errors.New("node n3 is down") })
+		}, wantErr: true, active: 1, staged: 2},
+		{name: "validate", op: func() (*ShardRule, error) {
+			return s.Validate("db1", func(rule *ShardRule) error { return nil })
+		}, active: 1, staged: 2, validated: true},
+		{name: "switch partially", op: func() (*ShardRule, error) {
+			return s.Switch("db1", []KeyRange{{"1", "10"}})
+		}, active: 1, staged: 2, validated: true, ranges: 1, staging: "5", notStaging: "11"},
+		{name: "switch more ranges", op: func() (*ShardRule, error) {
+			return s.Switch("db1", []KeyRange{{"20", "29"}})
+		}, active: 1, staged: 2, validated: true, ranges: 2, staging: "25", notStaging: "15"},
+		{name: "stage when switching", op: func() (*ShardRule, error) { return s.Stage("db1", "mod", []string{"n1"}) },
+			wantErr: true, active: 1, staged: 2, validated: true, ranges: 2},
+		{name: "discard when switching", op: func() (*ShardRule, error) { return s.Discard("db1") }, wantErr: true,
+			active: 1, staged: 2, validated: true, ranges: 2},
+		{name: "rollback partial switch", op: func() (*ShardRule, error) { return s.Rollback("db1") },
+			active: 1, staged: 2, validated: true, notStaging: "5"},
+		{name: "switch all", op: func() (*ShardRule, error) { return s.Switch("db1", nil) },
+			active: 2, previous: 1},
+		{name: "rollback switched", op: func() (*ShardRule, error) { return s.Rollback("db1") },
+			active: 1, staged: 2, validated: true},
+		{name: "rollback again", op: func() (*ShardRule, error) { return s.Rollback("db1") }, wantErr: true,
+			active: 1, staged: 2, validated: true},
+		{name: "discard", op: func() (*ShardRule, error) { return s.Discard("db1") }, active: 1},
+		{name: "discard again", op: func() (*ShardRule, error) { return s.Discard("db1") }, wantErr: true, active: 1},
+		{name: "unknown schema", op: func() (*ShardRule, error) { return s.Stage("db2", "mod", []string{"n1"}) },
+			wantErr: true, active: 1},
+	}
+	for _, step := range steps {
+		_, err := step.op()
+		if step.wantErr != (err != nil) {
+			t.Fatalf("%s: expect error %v, got %v", step.name, step.wantErr, err)
+		}
+		m := s.Get("db1")
+		if got := []int{versionOf(m.Active), versionOf(m.Previous), versionOf(m.Staged)}; !reflect.DeepEqual(got,
+			[]int{step.active, step.previous, step.staged}) {
+			t.Fatalf("%s: expect versions of active, previous and staged %v, got %v", step.name,
+				[]int{step.active, step.previous, step.staged}, got)
+		}
+		if m.Validated != step.validated || len(m.Ranges) != step.ranges {
+			t.Fatalf("%s: expect validated %v and %d ranges, got %v and %v", step.name, step.validated, step.ranges,
+				m.Validated, m.Ranges)
+		}
+		if step.staging != "" && m.Rule(step.staging) != m.Staged {
+			t.Errorf("%s: expect shard key %s routed by staged rule", step.name, step.staging)
+		}
+		if step.notStaging != "" && m.Rule(step.notStaging) != m.Active {
+			t.Errorf("%s: expect shard key %s routed by active rule", step.name, step.notStaging)
+		}
+	}
+	// snapshot got by readers is not changed.
+	if initial.Staged != nil || initial.Active.Version != 1 {
+		t.Error("expect shard map updated by copy on write")
+	}
+}
+
+// TestShardMapNodes scatter to active rule's nodes until switch is committed if mirrored by dual-write,
+// or to nodes of both rules if not.
+func TestShardMapNodes(t *testing.T) {
+	s := newTestShardMaps(t)
+	if _, err := s.Stage("db1", "mod", []string{"n1", "n2", "n3"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Validate("db1", func(rule *ShardRule) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	kr, err := ParseKeyRange("1-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		name                  string
+		op                    func() (*ShardRule, error)
+		mirrored, notMirrored []string
+	}{
+		{name: "staged", op: func() (*ShardRule, error) { return nil, nil },
+			mirrored: []string{"n1", "n2"}, notMirrored: []string{"n1", "n2"}},
+		{name: "switch partially", op: func() (*ShardRule, error) { return s.Switch("db1", []KeyRange{kr}) },
+			mirrored: []string{"n1", "n2"}, notMirrored: []string{"n1", "n2", "n3"}},
+		{name: "switch all", op: func() (*ShardRule, error) { return s.Switch("db1", nil) },
+			mirrored: []string{"n1", "n2", "n3"}, notMirrored: []string{"n1", "n2", "n3"}},
+	}
+	for _, step := range steps {
+		if _, err := step.op(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		m := s.Get("db1")
+		if !reflect.DeepEqual(m.Nodes(true), step.mirrored) {
+			t.Errorf("%s: expect nodes mirrored %v, got %v", step.name, step.mirrored, m.Nodes(true))
+		}
+		if !reflect.DeepEqual(m.Nodes(false), step.notMirrored) {
+			t.Errorf("%s: expect nodes not mirrored %v, got %v", step.name, step.notMirrored, m.Nodes(false))
+		}
+	}
+}
+
+// TestShardMapsStore shard map partially switched is restored from store by another process with the same config,
+// and ignored with config changed.
+func TestShardMapsStore(t *testing.T) {
+	store := NewFileShardMapStore(filepath.Join(t.TempDir(), "shard_maps.json"))
+	s := newTestShardMaps(t)
+	if err := s.SetStore(store); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Stage("db1", "mod", []string{"n1", "n2", "n3"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Validate("db1", func(rule *ShardRule) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Switch("db1", []KeyRange{{"1", "10"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := newTestShardMaps(t)
+	if err := restarted.SetStore(store); err != nil {
+		t.Fatal(err)
+	}
+	m := restarted.Get("db1")
+	if versionOf(m.Staged) != 2 || !m.Validated || len(m.Ranges) != 1 || m.Rule("5") != m.Staged {
+		t.Errorf("expect partial switch restored, got staged version %d, validated %v, ranges %v",
+			versionOf(m.Staged), m.Validated, m.Ranges)
+	}
+	// refreshed without change, snapshot is kept.
+	if err := restarted.Refresh(); err != nil || restarted.Get("db1") != m {
+		t.Errorf("expect shard map not replaced by refresh without change, error %v", err)
+	}
+	// switched by the other proxy.
+	if _, err := s.Switch("db1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if m = restarted.Get("db1"); versionOf(m.Active) != 2 || m.Staged != nil {
+		t.Errorf("expect switch followed by refresh, got active version %d", versionOf(m.Active))
+	}
+
+	schemas, _ := parseShardMapConfig(t, strings.Replace(shardMapTestConfig, "nodes: [n1, n2]", "nodes: [n1, n3]", 1))
+	changed := NewShardMaps(schemas)
+	if err := changed.SetStore(store); err != nil {
+		t.Fatal(err)
+	}
+	if m = changed.Get("db1"); versionOf(m.Active) != 1 || m.Staged != nil || m.Previous != nil {
+		t.Errorf("expect state of old config ignored, got active version %d", versionOf(m.Active))
+	}
+}
+
+const shardMapReloadConfig = `
+nodes:
+  - name: n1
+  - name: n2
+  - name: n3
+schemas:
+  - name: db1
+    shard_key: id
+    shard_algo: mod
+    nodes: [n1, n2]
+  - name: db2
+    shard_key: id
+    shard_algo: mod
+    nodes: [n1]
+`
+
+func TestShardMapsReload(t *testing.T) {
+	cases := []struct {
+		name string
+		// stage rule of db1 before reload, if not empty.
+		stage   []string
+		config  string
+		wantErr bool
+		// versions of active, previous and staged of db1 after reload, 0 if db1 is not sharded.
+		active, previous, staged int
+		nodes                    []string
+	}{
+		{name: "not changed", config: shardMapReloadConfig, active: 1, nodes: []string{"n1", "n2"}},
+		{name: "not changed with staged", stage: []string{"n1", "n2", "n3"}, config: shardMapReloadConfig,
+			active: 1, staged: 2, nodes: []string{"n1", "n2"}},
+		{name: "staged node removed", stage: []string{"n1", "n2", "n3"},
+			config: strings.Replace(shardMapReloadConfig, "  - name: n3\n", "", 1), wantErr: true,
+			active: 1, staged: 2, nodes: []string{"n1", "n2"}},
+		{name: "changed", config: strings.Replace(shardMapReloadConfig, "nodes: [n1, n2]\n  - name: db2", "nodes: [n1, n2, n3]\n  - name: db2", 1),
+			active: 2, previous: 1, nodes: []string{"n1", "n2", "n3"}},
+		{name: "changed with staged", stage: []string{"n1", "n3"},
+			config: strings.Replace(shardMapReloadConfig, "nodes: [n1, n2]\n  - name: db2", "nodes: [n1, n2, n3]\n  - name: db2", 1),
+			active: 3, previous: 1, nodes: []string{"n1", "n2", "n3"}},
+		{name: "changed and previous node removed",
+			config: strings.Replace(strings.Replace(shardMapReloadConfig, "  - name: n2\n", "", 1),
+				"nodes: [n1, n2]\n  - name: db2", "nodes: [n1, n3]\n  - name: db2", 1),
+			active: 2, nodes: []string{"n1", "n3"}},
+		{name: "not sharded", config: strings.Replace(shardMapReloadConfig, "  - name: db1\n    shard_key: id\n", "  - name: db1\n", 1)},
+	}
+	for _, tc := range cases {
+		schemas, nodes := parseShardMapConfig(t, shardMapReloadConfig)
+		s := NewShardMaps(schemas)
+		if len(tc.stage) > 0 {
+			if _, err := s.Stage("db1", "mod", tc.stage); err != nil {
+				t.Fatal(err)
+			}
+		}
+		db2 := s.Get("db2")
+		schemas, nodes = parseShardMapConfig(t, tc.config)
+		reloaded, err := s.Reload(schemas, nodes)
+		if tc.wantErr != (err != nil) {
+			t.Errorf("%s: expect error %v, got %v", tc.name, tc.wantErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if s.Get("db2") != db2 || s.Get("db1") == nil {
+			t.Errorf("%s: expect shard maps replaced not changed", tc.name)
+		}
+		s = reloaded
+		if s.Get("db2") != db2 {
+			t.Errorf("%s: expect shard map not changed in config kept", tc.name)
+		}
+		m := s.Get("db1")
+		if tc.active == 0 {
+			if m != nil {
+				t.Errorf("%s: expect shard map removed", tc.name)
+			}
+			continue
+		}
+		if got := []int{versionOf(m.Active), versionOf(m.Previous), versionOf(m.Staged)}; !reflect.DeepEqual(got,
+			[]int{tc.active, tc.previous, tc.staged}) {
+			t.Errorf("%s: expect versions of active, previous and staged %v, got %v", tc.name,
+				[]int{tc.active, tc.previous, tc.staged}, got)
+		}
+		if !reflect.DeepEqual(m.Active.Nodes, tc.nodes) {
+			t.Errorf("%s: expect nodes of active rule %v, got %v", tc.name, tc.nodes, m.Active.Nodes)
+		}
+	}
+}
+
+// TestShardMapsReloadForward update of shard maps replaced by reload is applied to the reloaded ones, not lost.
+func TestShardMapsReloadForward(t *testing.T) {
+	schemas, nodes := parseShardMapConfig(t, shardMapReloadConfig)
+	s := NewShardMaps(schemas)
+	reloaded, err := s.Reload(schemas, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Stage("db1", "mod", []string{"n1", "n2", "n3"}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Get("db1").Staged != nil {
+		t.Error("expect shard maps replaced not changed")
+	}
+	if m := reloaded.Get("db1"); m.Staged == nil || !reflect.DeepEqual(m.Staged.Nodes, []string{"n1", "n2", "n3"}) {
+		t.Errorf("expect staged rule in reloaded shard maps, got %v", m.Staged)
+	}
+}
+
+// BenchmarkShardMapsRoute look up shard map of schema and node of shard key concurrently, as routing does.
+func BenchmarkShardMapsRoute(b *testing.B) {
+	s := newTestShardMaps(b)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			i++
+			key := strconv.Itoa(i)
+			if _, err := s.Get("db1").Rule(key).ShardNode(key); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -240,6 +240,16 @@ func (r *Router) buildShowVariablesPlan(statement *sqlparser.ShowVariables) (*no
 					row.AppendStringValue("debian-linux-gnu")
 					result.Rows[0] = row
 					plan.Result = result
+
+				case "max_allowed_packet":
+					if r.MaxAllowedPacket > 0 {
+						result.Rows = make([]*mysql.Row, 1)
+						row := mysql.NewTextRow(result.Resultset.Fields)
+						row.AppendStringValue("max_allowed_packet")
+						row.AppendUIntValue(uint64(r.MaxAllowedPacket))
+						result.Rows[0] = row
+						plan.Result = result
+					}
 				}
 			}
 		}
@@ -0,0 +1,344 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"container/heap"
+	"strconv"
+	"strings"
+
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+var errSortInMulti = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+	"LIMIT not number across nodes")
+
+// sortKey is a column of ORDER BY, hidden column is appended to select expressions.
+type sortKey struct {
+	column int // index of hidden columns if hidden.
+	hidden bool
+	desc   bool
+}
+
+// Sort merges rows of select with ORDER BY or LIMIT from multi nodes.
+// Rows of each node are sorted by the node, and merged by k-way merge,
+// then OFFSET and LIMIT are applied to merged rows, LIMIT of each node is rewritten to OFFSET + LIMIT.
+type Sort struct {
+	keys       []sortKey
+	hidden     sqlparser.SelectExprs // hidden columns appended to select expressions.
+	offset     int
+	rowCount   int  // -1 is unlimited.
+	aggregated bool // rows are merged by aggregation, they are sorted after merged.
+}
+
+// NewSort analyze ORDER BY and LIMIT of select, nil if not any.
+// aggregation is not nil if rows are merged by aggregation, then ORDER BY not in select expressions
+// is evaluated on merged rows by aggregation.
+func NewSort(statement *sqlparser.Select, aggregation *Aggregation) (*Sort, error) {
+	if len(statement.OrderBy) == 0 && statement.Limit == nil {
+		return nil, nil
+	}
+	s := &Sort{rowCount: -1, aggregated: aggregation != nil}
+	if statement.Limit != nil {
+		var err error
+		if statement.Limit.Offset != nil {
+			if s.offset, err = limitNumber(statement.Limit.Offset); err != nil {
+				return nil, err
+			}
+		}
+		if s.rowCount, err = limitNumber(statement.Limit.Rowcount); err != nil {
+			return nil, err
+		}
+	}
+
+	// columns before * are known, and others are found by hidden columns.
+	exprs := make(map[string]int)
+	aliases := make(map[string]sqlparser.ValExpr)
+	columns := len(statement.SelectExprs)
+	for i, selectExpr := range statement.SelectExprs {
+		nonStarExpr, ok := selectExpr.(*sqlparser.NonStarExpr)
+		if !ok {
+			columns = -1
+			break
+		}
+		addSelectExpr(exprs, i, nonStarExpr)
+		if expr, ok := nonStarExpr.Expr.(sqlparser.ValExpr); ok && len(nonStarExpr.As) > 0 {
+			aliases[strings.ToLower(string(nonStarExpr.As))] = expr
+		}
+	}
+	for _, order := range statement.OrderBy {
+		key := sortKey{desc: order.Direction == sqlparser.AST_DESC}
+		switch expr := order.Expr.(type) {
+		case *sqlparser.NullVal:
+			// ORDER BY NULL is not sorted.
+			continue
+		case sqlparser.NumVal:
+			// position of select expressions, others are constant.
+			if n, err := strconv.Atoi(string(expr)); err == nil {
+				if n < 1 || (columns >= 0 && n > columns) {
+					return nil, mysql.NewDefaultError(mysql.ER_BAD_FIELD_ERROR, string(expr), "order clause")
+				}
+				key.column = n - 1
+				s.keys = append(s.keys, key)
+				continue
+			}
+		}
+		if i, ok := exprs[exprKey(order.Expr)]; ok {
+			key.column = i
+		} else {
+			expr := order.Expr
+			if aggregation != nil {
+				if err := aggregation.addSort(expr); err != nil {
+					return nil, err
+				}
+			} else {
+				expr = replaceAliases(expr, aliases)
+			}
+			key.column = len(s.hidden)
+			key.hidden = true
+			s.hidden = append(s.hidden, &sqlparser.NonStarExpr{Expr: expr})
+		}
+		s.keys = append(s.keys, key)
+	}
+	return s, nil
+}
+
+// exprKey is key of expression to resolve ORDER BY, GROUP BY and HAVING by select expressions,
+// names of column are case-insensitive.
+func exprKey(expr sqlparser.Expr) string {
+	if colName, ok := expr.(*sqlparser.ColName); ok {
+		return strings.ToLower(sqlparser.String(colName))
+	}
+	return sqlparser.String(expr)
+}
+
+// addSelectExpr add select expression at column to exprs, referenced by alias, the same expression,
+// or name of column without qualifier. The first is referenced if names are the same, and alias is preferred.
+func addSelectExpr(exprs map[string]int, column int, nonStarExpr *sqlparser.NonStarExpr) {
+	if len(nonStarExpr.As) > 0 {
+		exprs[strings.ToLower(string(nonStarExpr.As))] = column
+	}
+	keys := []string{exprKey(nonStarExpr.Expr)}
+	if colName, ok := nonStarExpr.Expr.(*sqlparser.ColName); ok && len(colName.Qualifier) > 0 {
+		keys = append(keys, strings.ToLower(string(colName.Name)))
+	}
+	for _, key := range keys {
+		if _, ok := exprs[key]; !ok {
+			exprs[key] = column
+		}
+	}
+}
+
+// replaceAliases copy expression with aliases of select expressions replaced by the expressions,
+// as aliases are unknown in hidden columns of select sent to nodes.
+func replaceAliases(expr sqlparser.ValExpr, aliases map[string]sqlparser.ValExpr) sqlparser.ValExpr {
+	switch expr := expr.(type) {
+	case *sqlparser.ColName:
+		if aliased, ok := aliases[strings.ToLower(string(expr.Name))]; ok && len(expr.Qualifier) == 0 {
+			switch aliased.(type) {
+			case *sqlparser.BinaryExpr, *sqlparser.UnaryExpr:
+				return sqlparser.ValTuple{aliased}
+			}
+			return aliased
+		}
+	case *sqlparser.BinaryExpr:
+		left, lok := expr.Left.(sqlparser.ValExpr)
+		right, rok := expr.Right.(sqlparser.ValExpr)
+		if lok && rok {
+			return &sqlparser.BinaryExpr{Operator: expr.Operator, Left: replaceAliases(left, aliases),
+				Right: replaceAliases(right, aliases)}
+		}
+	case *sqlparser.UnaryExpr:
+		if operand, ok := expr.Expr.(sqlparser.ValExpr); ok {
+			return &sqlparser.UnaryExpr{Operator: expr.Operator, Expr: replaceAliases(operand, aliases)}
+		}
+	case *sqlparser.FuncExpr:
+		replaced := *expr
+		replaced.Exprs = make(sqlparser.ValExprs, len(expr.Exprs))
+		for i, arg := range expr.Exprs {
+			replaced.Exprs[i] = replaceAliases(arg, aliases)
+		}
+		return &replaced
+	}
+	return expr
+}
+
+func limitNumber(expr sqlparser.ValExpr) (int, error) {
+	num, ok := expr.(sqlparser.NumVal)
+	if !ok {
+		return 0, errSortInMulti
+	}
+	n, err := strconv.Atoi(string(num))
+	if err != nil || n < 0 {
+		return 0, errSortInMulti
+	}
+	return n, nil
+}
+
+// Rewrite select sent to each node, columns of ORDER BY are selected as hidden columns,
+// and LIMIT is rewritten to OFFSET + LIMIT.
+func (s *Sort) Rewrite(statement *sqlparser.Select) *sqlparser.Select {
+	if s.aggregated || (len(s.hidden) == 0 && s.offset == 0) {
+		return statement
+	}
+	rewritten := *statement
+	if len(s.hidden) > 0 {
+		rewritten.SelectExprs = make(sqlparser.SelectExprs, 0, len(statement.SelectExprs)+len(s.hidden))
+		rewritten.SelectExprs = append(rewritten.SelectExprs, statement.SelectExprs...)
+		rewritten.SelectExprs = append(rewritten.SelectExprs, s.hidden...)
+	}
+	if statement.Limit != nil {
+		rewritten.Limit, _ = statement.Limit.RewriteLimit()
+	}
+	return &rewritten
+}
+
+// Merge rows of result set from nodes, runs are row numbers of each node in order, which are sorted by the node.
+// Rows are sorted again if runs are not known, such as rows merged by aggregation.
+// Hidden columns are removed, and OFFSET and LIMIT are applied.
+func (s *Sort) Merge(rs *mysql.Resultset, runs []int) error {
+	if err := rs.Decode(); err != nil {
+		return err
+	}
+	columns := len(rs.Fields) - len(s.hidden)
+	if columns <= 0 {
+		return mysql.NewDefaultError(mysql.ER_UNKNOWN_ERROR, "columns of sort are mismatched")
+	}
+	keys := make([]mysql.SortKey, len(s.keys))
+	for i, key := range s.keys {
+		keys[i] = mysql.SortKey{Column: key.column, Desc: key.desc}
+		if key.hidden {
+			keys[i].Column += columns
+		} else if key.column >= columns {
+			return mysql.NewDefaultError(mysql.ER_BAD_FIELD_ERROR, strconv.Itoa(key.column+1), "order clause")
+		}
+	}
+
+	if len(keys) > 0 {
+		if s.aggregated || len(runs) == 0 {
+			rs.SortRows(keys)
+		} else if len(runs) > 1 {
+			s.mergeRuns(rs, runs, keys)
+		}
+	}
+
+	// apply OFFSET and LIMIT.
+	begin, end := s.offset, len(rs.Values)
+	if begin > end {
+		begin = end
+	}
+	if s.rowCount >= 0 && begin+s.rowCount < end {
+		end = begin + s.rowCount
+	}
+	rs.Values = rs.Values[begin:end]
+	if len(rs.Rows) >= end {
+		rs.Rows = rs.Rows[begin:end]
+	}
+
+	if len(s.hidden) > 0 {
+		fields := rs.Fields[:columns]
+		rs.Fields = fields
+		rs.FieldNames = make(map[string]int, len(fields))
+		for i, field := range fields {
+			rs.FieldNames[string(field.Name)] = i
+		}
+		rs.Rows = make([]*mysql.Row, len(rs.Values))
+		for i, values := range rs.Values {
+			rs.Values[i] = values[:columns]
+			rs.Rows[i] = mysql.NewTextRow(fields)
+			for _, value := range rs.Values[i] {
+				rs.Rows[i].AppendValue(value)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeRuns k-way merge of sorted runs, rows of the same order are kept in order of nodes.
+func (s *Sort) mergeRuns(rs *mysql.Resultset, runs []int, keys []mysql.SortKey) {
+	h := &runHeap{resultset: rs, keys: keys}
+	begin := 0
+	for _, n := range runs {
+		if n > 0 {
+			h.runs = append(h.runs, runCursor{next: begin, end: begin + n})
+		}
+		begin += n
+	}
+	heap.Init(h)
+	values := make([][]interface{}, 0, len(rs.Values))
+	rows := make([]*mysql.Row, 0, len(rs.Rows))
+	for h.Len() > 0 {
+		cursor := &h.runs[0]
+		values = append(values, rs.Values[cursor.next])
+		if len(rs.Rows) == len(rs.Values) {
+			rows = append(rows, rs.Rows[cursor.next])
+		}
+		if cursor.next++; cursor.next < cursor.end {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	rs.Values = values
+	if len(rows) == len(values) {
+		rs.Rows = rows
+	}
+}
+
+// runCursor is the next row of a sorted run.
+type runCursor struct {
+	next, end int
+}
+
+type runHeap struct {
+	resultset *mysql.Resultset
+	keys      []mysql.SortKey
+	runs      []runCursor
+}
+
+func (h *runHeap) Len() int {
+	return len(h.runs)
+}
+
+func (h *runHeap) Less(i, j int) bool {
+	c := h.resultset.CompareRows(h.runs[i].next, h.runs[j].next, h.keys)
+	if c == 0 {
+		return h.runs[i].next < h.runs[j].next
+	}
+	return c < 0
+}
+
+func (h *runHeap) Swap(i, j int) {
+	h.runs[i], h.runs[j] = h.runs[j], h.runs[i]
+}
+
+func (h *runHeap) Push(x interface{}) {
+	h.runs = append(h.runs, x.(runCursor))
+}
+
+func (h *runHeap) Pop() interface{} {
+	last := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return last
+}
@@ -0,0 +1,109 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+var errMixedTableType = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET, "statement on single table with sharded table or other node's single table")
+
+// tableRoute is route of tables in statement, by table type.
+type tableRoute struct {
+	// nodeNames is nil if any sharded table exists, should route by shard key.
+	nodeNames []string
+	// global only global tables in statement.
+	global bool
+}
+
+// routeByTables route by type of tables in statement of sharded schema.
+// Only global tables, route to all nodes; only single tables in same node, route to it;
+// global tables with sharded tables, route by shard key.
+func (r *Router) routeByTables(schemaConfig *config.SchemaConfig, statement sqlparser.SQLNode) (*tableRoute, error) {
+	route := new(tableRoute)
+	tables := schemaConfig.GetTables()
+
+	var hasSharded, hasGlobal bool
+	var singleNode string
+	tableNames := sqlparser.GetTableNames(statement)
+	for _, tableName := range tableNames {
+		if len(tableName.Qualifier) > 0 {
+			// system db
+			continue
+		}
+		tableConfig := tables[strings.Trim(strings.ToLower(string(tableName.Name)), "`")]
+		if tableConfig == nil {
+			// not configured table, such as view when check table disabled.
+			r.debugf("table %s not configured, as sharded", tableName.Name)
+			hasSharded = true
+			continue
+		}
+		r.debugf("table %s is %s", tableName.Name, tableConfig.GetType())
+		switch tableConfig.GetType() {
+		case config.TableTypeSingle:
+			node := tableConfig.Node
+			if len(node) == 0 {
+				node = schemaConfig.Nodes[0]
+			}
+			if len(singleNode) > 0 && singleNode != node {
+				return nil, errMixedTableType
+			}
+			singleNode = node
+		case config.TableTypeGlobal:
+			hasGlobal = true
+		default:
+			hasSharded = true
+		}
+	}
+
+	switch {
+	case hasSharded && len(singleNode) > 0:
+		return nil, errMixedTableType
+	case hasSharded:
+	case len(singleNode) > 0:
+		route.nodeNames = []string{singleNode}
+	case hasGlobal || len(tableNames) == 0:
+		// without table, such as only common table expressions of constants, is the same in all nodes.
+		route.nodeNames = r.shardNodes(schemaConfig)
+		route.global = true
+	}
+	return route, nil
+}
+
+// readNode choose one node to read, prefer the node in transaction.
+func (route *tableRoute) readNode(nodeInTrans string) string {
+	if route.global {
+		for _, nodeName := range route.nodeNames {
+			if nodeName == nodeInTrans {
+				return nodeName
+			}
+		}
+		return route.nodeNames[rand.Intn(len(route.nodeNames))]
+	}
+	return route.nodeNames[0]
+}
@@ -22,7 +22,11 @@
 
 package route
 
-import "github.com/berkaroad/saashard/sqlparser"
+import (
+	"strings"
+
+	"github.com/berkaroad/saashard/sqlparser"
+)
 
 func (r *Router) buildTransactionPlan(statement sqlparser.TransactionStatement) (*normalPlan, error) {
 	schemaConfig := r.Schemas[r.SchemaName]
@@ -34,3 +38,37 @@ func (r *Router) buildTransactionPlan(statement sqlparser.TransactionStatement)
 
 	return plan, nil
 }
+
+// beginsTrans the statement begins transaction of session or not, by BEGIN or SET autocommit=0.
+// Statements after it are routed in transaction: reads are sent to master connection of the session that receives writes,
+// instead of slave, dark read or coalesced multi-get, even if they're the same as reads out of transaction.
+func beginsTrans(statement sqlparser.Statement) bool {
+	switch v := statement.(type) {
+	case *sqlparser.Begin:
+		return true
+	case *sqlparser.SetVariable:
+		for _, expr := range v.Exprs {
+			if strings.ToLower(string(expr.Name.Name)) == "autocommit" {
+				value := strings.Trim(sqlparser.String(expr.Expr), "'")
+				return value == "0" || strings.EqualFold(value, "off")
+			}
+		}
+	}
+	return false
+}
+
+// endsTrans the statement ends transaction of session or not, by COMMIT, ROLLBACK or SET autocommit=1.
+func endsTrans(statement sqlparser.Statement) bool {
+	switch v := statement.(type) {
+	case *sqlparser.Commit, *sqlparser.Rollback:
+		return true
+	case *sqlparser.SetVariable:
+		for _, expr := range v.Exprs {
+			if strings.ToLower(string(expr.Name.Name)) == "autocommit" {
+				value := strings.Trim(sqlparser.String(expr.Expr), "'")
+				return value == "1" || strings.EqualFold(value, "on")
+			}
+		}
+	}
+	return false
+}
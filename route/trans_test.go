@@ -0,0 +1,131 @@
+package route
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/sqlparser"
+)
+
+const transTestConfig = `
+nodes:
+  - name: n1
+  - name: n2
+schemas:
+  - name: db1
+    shard_key: id
+    shard_algo: mod
+    check_table_disabled: true
+    dark_read_rate: 1
+    nodes: [n1, n2]
+    tables:
+      - {name: g, type: global}
+`
+
+func newTransTestRouter(t *testing.T, inTrans bool) *Router {
+	cfg, err := config.ParseConfigData([]byte(transTestConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas := make(map[string]*config.SchemaConfig)
+	for i := range cfg.Schemas {
+		schemas[cfg.Schemas[i].Name] = &cfg.Schemas[i]
+	}
+	return NewRouter("db1", schemas, cfg.GetNodes(), 0, "", inTrans)
+}
+
+// TestReadAfterWriteInTrans reads in transaction are routed to master, which receives writes of transaction.
+func TestReadAfterWriteInTrans(t *testing.T) {
+	r := newTransTestRouter(t, false)
+	cases := []struct {
+		sql     string
+		onSlave bool
+	}{
+		{"select * from t1 where id = 1", true},
+		{"begin", false},
+		{"insert into t1 (id, a) values (1, 1)", false},
+		{"select * from t1 where id = 1", false},
+		{"select * from t1 where id in (1, 3)", false},
+		{"select count(*) from t1 where id in (1, 2)", false},
+		{"select * from t1 where id = 1 union select * from t2 where id = 1", false},
+		{"select 1", false},
+		{"commit", false},
+		{"select * from t1 where id = 1", true},
+		{"set autocommit = 0", false},
+		{"select * from t1 where id = 1", false},
+		{"set autocommit = 1", false},
+		{"select * from t1 where id = 1", true},
+	}
+	decisions := r.RouteStatements(func() []string {
+		sqls := make([]string, len(cases))
+		for i, tc := range cases {
+			sqls[i] = tc.sql
+		}
+		return sqls
+	}())
+	for i, d := range decisions {
+		if d.Err != nil {
+			t.Fatalf("%s: %v", d.SQL, d.Err)
+		}
+		if d.OnSlave != cases[i].onSlave {
+			t.Errorf("%d %s: expect on slave %v, got %v", i, d.SQL, cases[i].onSlave, d.OnSlave)
+		}
+	}
+	if r.InTrans {
+		t.Error("expect transaction of router restored")
+	}
+}
+
+// TestReadInTransNoMirror reads in transaction are not mirrored as dark read, and global table is read in node of transaction.
+func TestReadInTransNoMirror(t *testing.T) {
+	r := newTransTestRouter(t, true)
+	r.NodeInTrans = "n2"
+	for i := 0; i < 10; i++ {
+		stmt, err := sqlparser.Parse("select * from g where a = 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan, err := r.BuildNormalPlan(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nodes := plan.GetNodeNames(); len(nodes) != 1 || nodes[0] != "n2" || plan.OnSlave() {
+			t.Fatalf("expect read global table on master of n2, got %v, on slave %v", nodes, plan.OnSlave())
+		}
+	}
+	stmt, err := sqlparser.Parse("select * from t1 where id = 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := r.BuildNormalPlan(stmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.GetMirrors()) > 0 || plan.OnSlave() {
+		t.Errorf("expect no dark read on master, got mirrors %v, on slave %v", plan.GetMirrors(), plan.OnSlave())
+	}
+}
+
+// TestMergedPlanAfterBegin statements after transaction begun in the same query are routed in transaction.
+func TestMergedPlanAfterBegin(t *testing.T) {
+	r := newTransTestRouter(t, false)
+	var stmts []sqlparser.Statement
+	for _, sql := range strings.Split("set autocommit = 0; select * from t1 where id = 1", ";") {
+		stmt, err := sqlparser.Parse(sql)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stmts = append(stmts, stmt)
+	}
+	plan, err := r.BuildMergedPlan(stmts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.OnSlave() {
+		t.Error("expect select after 'set autocommit = 0' on master")
+	}
+	if r.InTrans {
+		t.Error("expect transaction of router restored")
+	}
+}
@@ -0,0 +1,108 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package route
+
+import (
+	"strings"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/net/mysql"
+	"github.com/berkaroad/saashard/sqlparser"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+var errUniqueKeyWithoutShardKey = mysql.NewDefaultError(mysql.ER_NOT_SUPPORTED_YET,
+	"unique key without shard key in sharded table, as it's only unique in each node")
+
+// checkCreateTableUniqueKeys apply unique key policy of schema to unique keys in CREATE TABLE.
+func (r *Router) checkCreateTableUniqueKeys(schemaConfig *config.SchemaConfig, statement *sqlparser.CreateTable) error {
+	for _, def := range statement.CreateDefs {
+		switch def := def.(type) {
+		case *sqlparser.CreateUniqueIndexDefinition:
+			if err := r.checkUniqueKey(schemaConfig, statement.Table, def.Name, def.IndexColumns); err != nil {
+				return err
+			}
+		case *sqlparser.CreateColumnDefinition:
+			if err := r.checkUniqueColumn(schemaConfig, statement.Table, def.ColumnName, def.ColumnDef); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkCreateIndexUniqueKey apply unique key policy of schema to CREATE UNIQUE INDEX.
+func (r *Router) checkCreateIndexUniqueKey(schemaConfig *config.SchemaConfig, statement *sqlparser.CreateIndex) error {
+	if !strings.EqualFold(string(statement.IndexCategory), "unique") {
+		return nil
+	}
+	return r.checkUniqueKey(schemaConfig, statement.Table, statement.Name, statement.IndexColumns)
+}
+
+// checkAlterTableUniqueKeys apply unique key policy of schema to unique keys added by ALTER TABLE.
+func (r *Router) checkAlterTableUniqueKeys(schemaConfig *config.SchemaConfig, statement *sqlparser.AlterTable) error {
+	for _, spec := range statement.AlterSpecs {
+		var err error
+		switch spec := spec.(type) {
+		case *sqlparser.AddUniqueIndexSpec:
+			err = r.checkUniqueKey(schemaConfig, statement.Table, spec.Name, spec.IndexColumns)
+		case *sqlparser.AddOrModifyColumnSpec:
+			err = r.checkUniqueColumn(schemaConfig, statement.Table, spec.ColumnName, spec.ColumnDef)
+		case *sqlparser.ChangeColumnSpec:
+			err = r.checkUniqueColumn(schemaConfig, statement.Table, spec.ColumnName, spec.ColumnDef)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkUniqueColumn check UNIQUE of column definition, it's a unique key of the column.
+func (r *Router) checkUniqueColumn(schemaConfig *config.SchemaConfig, table *sqlparser.TableName,
+	column *sqlparser.ColName, def *sqlparser.ColumnDefinition) error {
+	if def == nil || string(def.UniqueOrKey) != "unique key" {
+		return nil
+	}
+	return r.checkUniqueKey(schemaConfig, table, column.Name, sqlparser.IndexColNames{&sqlparser.IndexColName{ColumnName: column}})
+}
+
+// checkUniqueKey check unique key of sharded table includes shard key, or warn or reject it by policy.
+func (r *Router) checkUniqueKey(schemaConfig *config.SchemaConfig, table *sqlparser.TableName,
+	name []byte, columns sqlparser.IndexColNames) error {
+	if tableType, _ := tablePlacement(schemaConfig, table.Name); tableType != config.TableTypeSharded {
+		return nil
+	}
+	shardKey := tableShardKey(schemaConfig, table)
+	for _, column := range columns {
+		if isShardKey(shardKey, column) {
+			return nil
+		}
+	}
+	if schemaConfig.GetUniqueKeyPolicy() == config.UniqueKeyReject {
+		return errUniqueKeyWithoutShardKey
+	}
+	simplelog.Warn("%s %s connectionID=%d,schema=%s: unique key %s(%s) of %s has no shard key %s, it's only unique in each node",
+		"route", "checkUniqueKey", r.ConnectionID, r.SchemaName, string(name), sqlparser.String(columns), table.Name, shardKey)
+	return nil
+}
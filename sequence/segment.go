@@ -0,0 +1,139 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package sequence
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/berkaroad/saashard/backend"
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+)
+
+// DefaultTable is default table name of sequences in segment type.
+var DefaultTable = "saashard_sequence"
+
+const sqlCreateTable = "CREATE TABLE IF NOT EXISTS `%s` (" +
+	"`name` VARCHAR(128) NOT NULL, `next_value` BIGINT UNSIGNED NOT NULL, " +
+	"PRIMARY KEY (`name`)) ENGINE=InnoDB"
+
+// Segments allocates ids of sequences in segments from mysql table of a data node, a row per sequence with the next
+// value not allocated. Ids are unique among proxies sharing the table, and ascending in a proxy, but not across
+// proxies. Ids of a segment not used are skipped when proxy is restarted.
+type Segments struct {
+	mu       sync.Mutex
+	node     *backend.DataNode
+	table    string
+	step     uint64
+	segments map[string]*segment
+}
+
+// segment is ids [next, end) allocated to proxy.
+type segment struct {
+	next uint64
+	end  uint64
+}
+
+// OpenSegments create sequence table in master of data node if not exists.
+func OpenSegments(node *backend.DataNode, table string, step int) (*Segments, error) {
+	s := new(Segments)
+	s.node = node
+	s.table = strings.Replace(table, "`", "", -1)
+	if len(s.table) == 0 {
+		s.table = DefaultTable
+	}
+	s.step = uint64(step)
+	s.segments = make(map[string]*segment)
+	err := s.exec(func(conn *mysqlBackend.Conn) error {
+		_, err := conn.Query(fmt.Sprintf(sqlCreateTable, s.table))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Segments) exec(f func(conn *mysqlBackend.Conn) error) error {
+	conn, err := s.node.DataHost.GetMaster().GetConnection(s.node.Database)
+	if err != nil {
+		return err
+	}
+	defer conn.ReturnConnection()
+	mysqlConn := conn.(*mysqlBackend.Conn)
+	if err = mysqlConn.UseDB(s.node.Database); err != nil {
+		return err
+	}
+	return f(mysqlConn)
+}
+
+// Next get count ids of sequence, ascending. A segment is allocated when ids of the current one are used up.
+func (s *Segments) Next(name string, count int) ([]uint64, error) {
+	ids := make([]uint64, 0, count)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seg := s.segments[name]
+	for len(ids) < count {
+		if seg == nil || seg.next >= seg.end {
+			step := s.step
+			if remaining := uint64(count - len(ids)); remaining > step {
+				step = remaining
+			}
+			var err error
+			if seg, err = s.allocate(name, step); err != nil {
+				return nil, err
+			}
+			s.segments[name] = seg
+		}
+		ids = append(ids, seg.next)
+		seg.next++
+	}
+	return ids, nil
+}
+
+// allocate segment of step ids by increasing next value of sequence, sequence starts from 1.
+// Next value is got by LAST_INSERT_ID(expr) of UPDATE, so that segments are not overlapped among proxies.
+func (s *Segments) allocate(name string, step uint64) (*segment, error) {
+	var end uint64
+	err := s.exec(func(conn *mysqlBackend.Conn) error {
+		if _, err := conn.Query(fmt.Sprintf("INSERT IGNORE INTO `%s` (`name`, `next_value`) VALUES (%s, 1)",
+			s.table, mysqlBackend.QuoteString(name))); err != nil {
+			return err
+		}
+		result, err := conn.Query(fmt.Sprintf("UPDATE `%s` SET `next_value` = LAST_INSERT_ID(`next_value` + %d) WHERE `name` = %s",
+			s.table, step, mysqlBackend.QuoteString(name)))
+		if err != nil {
+			return err
+		}
+		end = result.InsertID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if end < step {
+		return nil, fmt.Errorf("next value of sequence '%s' not got from table '%s'", name, s.table)
+	}
+	return &segment{next: end - step, end: end}, nil
+}
@@ -0,0 +1,76 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package sequence
+
+import (
+	"sync"
+	"time"
+)
+
+// Epoch of snowflake ids, 2020-01-01 00:00:00 UTC in milliseconds.
+const Epoch = 1577836800000
+
+const (
+	workerBits   = 10
+	sequenceBits = 12
+	maxWorkerID  = 1<<workerBits - 1
+	maxSequence  = 1<<sequenceBits - 1
+)
+
+// Snowflake generates 64-bit ids of 41-bit milliseconds since epoch, 10-bit worker id and 12-bit sequence in
+// the millisecond, shared by all sequences. Ids are unique among proxies of different worker ids, and ascending
+// in a proxy even if clock goes backward, by borrowing milliseconds from the last one.
+type Snowflake struct {
+	mu       sync.Mutex
+	workerID uint64
+	lastTime int64
+	sequence uint64
+}
+
+// NewSnowflake create snowflake of worker id, 0 ~ 1023.
+func NewSnowflake(workerID int) *Snowflake {
+	s := new(Snowflake)
+	s.workerID = uint64(workerID) & maxWorkerID
+	return s
+}
+
+// Next get count ids, ascending. Name of sequence is ignored, as ids are unique among all sequences.
+func (s *Snowflake) Next(name string, count int) ([]uint64, error) {
+	ids := make([]uint64, count)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range ids {
+		now := time.Now().UnixNano()/int64(time.Millisecond) - Epoch
+		if now > s.lastTime {
+			s.lastTime = now
+			s.sequence = 0
+		} else if s.sequence < maxSequence {
+			s.sequence++
+		} else {
+			s.lastTime++
+			s.sequence = 0
+		}
+		ids[i] = uint64(s.lastTime)<<(workerBits+sequenceBits) | s.workerID<<sequenceBits | s.sequence
+	}
+	return ids, nil
+}
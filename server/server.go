@@ -28,6 +28,9 @@ import (
 	"github.com/berkaroad/saashard/admin"
 	"github.com/berkaroad/saashard/config"
 	"github.com/berkaroad/saashard/proxy"
+	"github.com/berkaroad/saashard/utils/alert"
+	"github.com/berkaroad/saashard/utils/audit"
+	"github.com/berkaroad/saashard/utils/slowlog"
 )
 
 const (
@@ -60,8 +63,17 @@ func NewServer(cfg *config.Config) (*Server, error) {
 	atomic.StoreInt32(&s.statusIndex, 0)
 	s.status[s.statusIndex] = Online
 
-	s.proxy, err = proxy.NewServer(cfg)
-	s.admin, err = admin.NewServer(cfg)
+	alert.Init(cfg.Webhooks)
+	if err = slowlog.Init(cfg.SlowLog, cfg.LogPath); err != nil {
+		return nil, err
+	}
+	if err = audit.Init(cfg.Audit, cfg.LogPath); err != nil {
+		return nil, err
+	}
+	if s.proxy, err = proxy.NewServer(cfg); err != nil {
+		return nil, err
+	}
+	s.admin, err = admin.NewServer(cfg, s.proxy)
 	return s, err
 }
 
@@ -92,9 +104,16 @@ func (s *Server) Status() string {
 	return status
 }
 
+// Reload config of proxy without dropping client connections, such as on SIGHUP.
+func (s *Server) Reload() error {
+	return s.proxy.ReloadConfig()
+}
+
 // Close server.
 func (s *Server) Close() {
 	s.running = false
 	s.proxy.Close()
 	s.admin.Close()
+	// events of statements executed are written before exit.
+	audit.Close()
 }
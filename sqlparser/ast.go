@@ -50,8 +50,14 @@ import (
 // is the AST representation of the query.
 func Parse(sql string) (Statement, error) {
 	// yyDebug = 4
+	if err := checkLength(sql); err != nil {
+		return nil, err
+	}
 	tokenizer := NewStringTokenizer(sql)
-	if yyParse(tokenizer) != 0 {
+	if yyParse(tokenizer) != 0 || tokenizer.limitErr != nil {
+		if tokenizer.limitErr != nil {
+			return nil, tokenizer.limitErr
+		}
 		return nil, errors.New(tokenizer.LastError)
 	}
 	return tokenizer.ParseTree, nil
@@ -600,6 +606,26 @@ func (node *Subquery) Format(buf *TrackedBuffer) {
 	buf.Fprintf("(%v)", node.Select)
 }
 
+// QuantifiedExpr represents a subquery quantified by ANY, SOME or ALL in comparison.
+type QuantifiedExpr struct {
+	Quantifier string
+	Subquery   *Subquery
+}
+
+// QuantifiedExpr.Quantifier
+const (
+	AST_ANY  = "any"
+	AST_SOME = "some"
+	AST_ALL  = "all"
+)
+
+func (node *QuantifiedExpr) Format(buf *TrackedBuffer) {
+	buf.Fprintf("%s %v", node.Quantifier, node.Subquery)
+}
+
+func (*QuantifiedExpr) IExpr()    {}
+func (*QuantifiedExpr) IValExpr() {}
+
 // BinaryExpr represents a binary value expression.
 type BinaryExpr struct {
 	Operator    byte
@@ -255,7 +255,7 @@ func (node *CreateIndexDefinition) ICreateDefinition() {}
 type CreateForeignKeyDefinition struct {
 	Symbol       []byte
 	IndexColumns IndexColNames
-	ReferenceDef []byte
+	ReferenceDef *ReferenceDefinition
 }
 
 // Format CreateForeignKeyDefinition
@@ -266,22 +266,79 @@ func (node *CreateForeignKeyDefinition) Format(buf *TrackedBuffer) {
 		escape(buf, node.Symbol)
 		buf.Fprintf(" ", nil)
 	}
-	buf.Fprintf("foreign key(%v) %s", node.IndexColumns, node.ReferenceDef)
+	buf.Fprintf("foreign key(%v) %v", node.IndexColumns, node.ReferenceDef)
 }
 
 func (node *CreateForeignKeyDefinition) ICreateDefinition() {}
 
+// ReferenceDefinition reference definition of foreign key
+type ReferenceDefinition struct {
+	Table    *TableName
+	Columns  IndexColNames
+	Match    string
+	OnDelete string
+	OnUpdate string
+}
+
+// Format ReferenceDefinition
+func (node *ReferenceDefinition) Format(buf *TrackedBuffer) {
+	buf.Fprintf("references %v(%v)", node.Table, node.Columns)
+	if node.Match != "" {
+		buf.Fprintf(" %s", node.Match)
+	}
+	if node.OnDelete != "" {
+		buf.Fprintf(" on delete %s", node.OnDelete)
+	}
+	if node.OnUpdate != "" {
+		buf.Fprintf(" on update %s", node.OnUpdate)
+	}
+}
+
+// CreateCheckDefinition create check constraint definition
+type CreateCheckDefinition struct {
+	Symbol   []byte
+	Expr     BoolExpr
+	Enforced string
+}
+
+// Format CreateCheckDefinition
+func (node *CreateCheckDefinition) Format(buf *TrackedBuffer) {
+	buf.Fprintf("\t", nil)
+	node.formatConstraint(buf)
+}
+
+func (node *CreateCheckDefinition) formatConstraint(buf *TrackedBuffer) {
+	if node.Symbol != nil {
+		buf.Fprintf("constraint ", nil)
+		escape(buf, node.Symbol)
+		buf.Fprintf(" ", nil)
+	}
+	buf.Fprintf("check (%v)", node.Expr)
+	if node.Enforced != "" {
+		buf.Fprintf(" %s", node.Enforced)
+	}
+}
+
+func (node *CreateCheckDefinition) ICreateDefinition() {}
+
 // ColumnDefinition column definition
 type ColumnDefinition struct {
 	Type            *DataType
 	IsNotNull       bool
-	DefaultValue    ValExpr
+	DefaultValue    Expr
 	IsAutoIncrement bool
 	UniqueOrKey     []byte
 	ColumnComment   ValExpr
 	ColumnFormat    []byte
 	ColumnStorage   []byte
-	ReferenceDef    []byte
+	ReferenceDef    *ReferenceDefinition
+
+	// expression of generated column, and its storage as virtual or stored.
+	GeneratedExpr    Expr
+	GeneratedStorage string
+
+	// check constraint of column.
+	Check *CreateCheckDefinition
 }
 
 // Format ColumnDefinition
@@ -314,9 +371,25 @@ func (node *ColumnDefinition) Format(buf *TrackedBuffer) {
 	}
 	strReferenceDef := ""
 	if node.ReferenceDef != nil {
-		strReferenceDef = " " + string(node.ReferenceDef)
+		strReferenceDef = " " + String(node.ReferenceDef)
+	}
+	strUniqueOrKey := ""
+	if node.UniqueOrKey != nil {
+		strUniqueOrKey = " " + string(node.UniqueOrKey)
+	}
+	if node.GeneratedExpr != nil {
+		strStorage := ""
+		if node.GeneratedStorage != "" {
+			strStorage = " " + node.GeneratedStorage
+		}
+		buf.Fprintf("%v generated always as (%v)%s%s%s%s", node.Type, node.GeneratedExpr, strStorage, strNullOrNotNull, strUniqueOrKey, strComment)
+	} else {
+		buf.Fprintf("%v%s%s%s%s%s%s%s%s", node.Type, strNullOrNotNull, strDefaultValue, strAutoIncrement, strUniqueOrKey, strComment, strColumnFormat, strColumnStorage, strReferenceDef)
+	}
+	if node.Check != nil {
+		buf.Fprintf(" ", nil)
+		node.Check.formatConstraint(buf)
 	}
-	buf.Fprintf("%v%s%s%s%s%s%s%s%s", node.Type, strNullOrNotNull, strDefaultValue, strAutoIncrement, node.UniqueOrKey, strComment, strColumnFormat, strColumnStorage, strReferenceDef)
 }
 
 // DataType data type.
@@ -376,6 +449,9 @@ type IndexColName struct {
 	ColumnName *ColName
 	Length     ValExpr
 	AscOrDesc  string
+
+	// expression of functional key part, instead of column.
+	Expr Expr
 }
 
 // Format IndexColName
@@ -388,6 +464,10 @@ func (node *IndexColName) Format(buf *TrackedBuffer) {
 	if node.AscOrDesc != "" {
 		strAscOrDesc = " " + node.AscOrDesc
 	}
+	if node.Expr != nil {
+		buf.Fprintf("(%v)%s", node.Expr, strAscOrDesc)
+		return
+	}
 	buf.Fprintf("%v%s%s", node.ColumnName, strLength, strAscOrDesc)
 }
 
@@ -592,7 +672,7 @@ func (node *AddUniqueIndexSpec) IAlterSpecification() {}
 type AddForeignKeySpec struct {
 	Symbol       []byte
 	IndexColumns IndexColNames
-	ReferenceDef []byte
+	ReferenceDef *ReferenceDefinition
 }
 
 // Format AddForeignKeySpec
@@ -603,7 +683,7 @@ func (node *AddForeignKeySpec) Format(buf *TrackedBuffer) {
 		escape(buf, node.Symbol)
 		buf.Fprintf(" ", nil)
 	}
-	buf.Fprintf("foreign key(%v) %s", node.IndexColumns, node.ReferenceDef)
+	buf.Fprintf("foreign key(%v) %v", node.IndexColumns, node.ReferenceDef)
 }
 
 func (node *AddForeignKeySpec) IAlterSpecification() {}
@@ -621,6 +701,62 @@ func (node *DropForeignKeySpec) Format(buf *TrackedBuffer) {
 
 func (node *DropForeignKeySpec) IAlterSpecification() {}
 
+// AddCheckSpec add check constraint specification
+type AddCheckSpec struct {
+	Check *CreateCheckDefinition
+}
+
+// Format AddCheckSpec
+func (node *AddCheckSpec) Format(buf *TrackedBuffer) {
+	buf.Fprintf("add ", nil)
+	node.Check.formatConstraint(buf)
+}
+
+func (node *AddCheckSpec) IAlterSpecification() {}
+
+// AlterCheckSpec alter check constraint specification
+type AlterCheckSpec struct {
+	Name     []byte
+	Enforced string
+}
+
+// Format AlterCheckSpec
+func (node *AlterCheckSpec) Format(buf *TrackedBuffer) {
+	buf.Fprintf("alter check ", nil)
+	escape(buf, node.Name)
+	if node.Enforced != "" {
+		buf.Fprintf(" %s", node.Enforced)
+	}
+}
+
+func (node *AlterCheckSpec) IAlterSpecification() {}
+
+// DropCheckSpec drop check constraint specification
+type DropCheckSpec struct {
+	Name []byte
+}
+
+// Format DropCheckSpec
+func (node *DropCheckSpec) Format(buf *TrackedBuffer) {
+	buf.Fprintf("drop check ", nil)
+	escape(buf, node.Name)
+}
+
+func (node *DropCheckSpec) IAlterSpecification() {}
+
+// DropConstraintSpec drop constraint specification, of any type.
+type DropConstraintSpec struct {
+	Name []byte
+}
+
+// Format DropConstraintSpec
+func (node *DropConstraintSpec) Format(buf *TrackedBuffer) {
+	buf.Fprintf("drop constraint ", nil)
+	escape(buf, node.Name)
+}
+
+func (node *DropConstraintSpec) IAlterSpecification() {}
+
 // DisableKeysSpec disable keys specification
 type DisableKeysSpec struct {
 }
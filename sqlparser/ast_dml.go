@@ -51,9 +51,17 @@ type Update struct {
 	Where    *Where
 	OrderBy  OrderBy
 	Limit    *Limit
+	// tables of multiple-table UPDATE, Table is nil.
+	TableExprs TableExprs
 }
 
 func (node *Update) Format(buf *TrackedBuffer) {
+	if node.TableExprs != nil {
+		buf.Fprintf("update %v%v set %v%v",
+			node.Comments, node.TableExprs,
+			node.Exprs, node.Where)
+		return
+	}
 	buf.Fprintf("update %v%v set %v%v%v%v",
 		node.Comments, node.Table,
 		node.Exprs, node.Where, node.OrderBy, node.Limit)
@@ -68,9 +76,18 @@ type Delete struct {
 	Where    *Where
 	OrderBy  OrderBy
 	Limit    *Limit
+	// tables of multiple-table DELETE, rows are deleted from Targets, Table is nil.
+	Targets    TableNames
+	TableExprs TableExprs
 }
 
 func (node *Delete) Format(buf *TrackedBuffer) {
+	if node.TableExprs != nil {
+		buf.Fprintf("delete %v%v from %v%v",
+			node.Comments,
+			node.Targets, node.TableExprs, node.Where)
+		return
+	}
 	buf.Fprintf("delete %vfrom %v%v%v%v",
 		node.Comments,
 		node.Table, node.Where, node.OrderBy, node.Limit)
@@ -78,6 +95,31 @@ func (node *Delete) Format(buf *TrackedBuffer) {
 
 func (node *Delete) IStatement() {}
 
+// TableNames represents a list of table names, such as targets of multiple-table DELETE.
+type TableNames []*TableName
+
+// Format TableNames
+func (node TableNames) Format(buf *TrackedBuffer) {
+	var prefix string
+	for _, n := range node {
+		buf.Fprintf("%s%v", prefix, n)
+		prefix = ", "
+	}
+}
+
+// singleTableName get the table name if only one table without alias and index hints, or nil.
+func singleTableName(tabExprs TableExprs) *TableName {
+	if len(tabExprs) != 1 {
+		return nil
+	}
+	tabExpr, ok := tabExprs[0].(*AliasedTableExpr)
+	if !ok || tabExpr.As != nil || tabExpr.Hints != nil {
+		return nil
+	}
+	tableName, _ := tabExpr.Expr.(*TableName)
+	return tableName
+}
+
 // Replace represents an REPLACE statement.
 type Replace struct {
 	Comments Comments
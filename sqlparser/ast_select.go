@@ -36,14 +36,15 @@ type SelectStatement interface {
 
 //SimpleSelect no from and where statement.
 type SimpleSelect struct {
-	Comments    Comments
-	Distinct    string
-	SelectExprs SelectExprs
-	Limit       *Limit
+	Comments      Comments
+	CalcFoundRows bool
+	Distinct      string
+	SelectExprs   SelectExprs
+	Limit         *Limit
 }
 
 func (node *SimpleSelect) Format(buf *TrackedBuffer) {
-	buf.Fprintf("select %v%s%v%v", node.Comments, node.Distinct, node.SelectExprs, node.Limit)
+	buf.Fprintf("select %v%s%s%v%v", node.Comments, calcFoundRowsOption(node.CalcFoundRows), node.Distinct, node.SelectExprs, node.Limit)
 }
 
 func (*SimpleSelect) IStatement()       {}
@@ -52,16 +53,18 @@ func (*SimpleSelect) IInsertRows()      {}
 
 // Select represents a SELECT statement.
 type Select struct {
-	Comments    Comments
-	Distinct    string
-	SelectExprs SelectExprs
-	From        TableExprs
-	Where       *Where
-	GroupBy     GroupBy
-	Having      *Where
-	OrderBy     OrderBy
-	Limit       *Limit
-	Lock        string
+	With          *With
+	Comments      Comments
+	CalcFoundRows bool
+	Distinct      string
+	SelectExprs   SelectExprs
+	From          TableExprs
+	Where         *Where
+	GroupBy       GroupBy
+	Having        *Where
+	OrderBy       OrderBy
+	Limit         *Limit
+	Lock          string
 }
 
 // Select.Distinct
@@ -69,6 +72,22 @@ const (
 	AST_DISTINCT = "distinct "
 )
 
+// AST_SQL_CALC_FOUND_ROWS is option of select, to get count of rows without limit by FOUND_ROWS().
+const AST_SQL_CALC_FOUND_ROWS = "sql_calc_found_rows "
+
+// selectOptions are options parsed before select expressions.
+type selectOptions struct {
+	distinct      string
+	calcFoundRows bool
+}
+
+func calcFoundRowsOption(calcFoundRows bool) string {
+	if calcFoundRows {
+		return AST_SQL_CALC_FOUND_ROWS
+	}
+	return ""
+}
+
 // Select.Lock
 const (
 	AST_FOR_UPDATE = " for update"
@@ -77,8 +96,8 @@ const (
 
 // Format Select.
 func (node *Select) Format(buf *TrackedBuffer) {
-	buf.Fprintf("select %v%s%v from %v%v%v%v%v%v%s",
-		node.Comments, node.Distinct, node.SelectExprs,
+	buf.Fprintf("%vselect %v%s%s%v from %v%v%v%v%v%v%s",
+		node.With, node.Comments, calcFoundRowsOption(node.CalcFoundRows), node.Distinct, node.SelectExprs,
 		node.From, node.Where,
 		node.GroupBy, node.Having, node.OrderBy,
 		node.Limit, node.Lock)
@@ -90,6 +109,7 @@ func (node *Select) IInsertRows()      {}
 
 // Union represents a UNION statement.
 type Union struct {
+	With        *With
 	Type        string
 	Left, Right SelectStatement
 }
@@ -104,7 +124,7 @@ const (
 )
 
 func (node *Union) Format(buf *TrackedBuffer) {
-	buf.Fprintf("%v %s %v", node.Left, node.Type, node.Right)
+	buf.Fprintf("%v%v %s %v", node.With, node.Left, node.Type, node.Right)
 }
 
 func (node *Union) IStatement()       {}
@@ -1,6 +1,10 @@
 package sqlparser
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
 
 func TestParseSelect(t *testing.T) {
 	var sql string
@@ -82,3 +86,486 @@ func TestParseSelect(t *testing.T) {
 	}
 
 }
+
+func TestParseLimits(t *testing.T) {
+	defer func() { MaxStatementLength, MaxInListItems, MaxNestingDepth = 0, 0, 0 }()
+	MaxStatementLength, MaxInListItems, MaxNestingDepth = 64, 3, 2
+
+	cases := []struct {
+		sql   string
+		limit string
+	}{
+		{"select * from t1 where id in (1, 2, 3) and (a = 1 or (b = 2))", ""},
+		{"select * from t1 where id in (1, 2, 3, 4)", "max_in_list_items"},
+		{"select * from t1 where id not in (1, 2, 3, 4)", "max_in_list_items"},
+		{"select * from t1 where id in (select id from t2)", ""},
+		{"select * from t1 where (((a = 1)))", "max_nesting_depth"},
+		{"select * from t1 where a = '" + strings.Repeat("x", 64) + "'", "max_statement_length"},
+	}
+	for _, tc := range cases {
+		_, err := Parse(tc.sql)
+		limitErr, ok := err.(*LimitError)
+		if len(tc.limit) == 0 && err != nil {
+			t.Errorf("%s: expect parsed, got %v", tc.sql, err)
+		} else if len(tc.limit) > 0 && (!ok || limitErr.Limit != tc.limit) {
+			t.Errorf("%s: expect limit %s exceeded, got %v", tc.sql, tc.limit, err)
+		}
+	}
+}
+
+func TestReplaceInList(t *testing.T) {
+	cases := []struct {
+		sql      string
+		replaced string
+	}{
+		{"select * from t1 where a = 1 and (id in (1, 2, 3) and b = 2)", "select * from t1 where a = 1 and (id in (1, 3) and b = 2)"},
+		{"select * from t1 where id in (1, 2, 3) or a = 1", ""},
+		{"select * from t1 where id in (1, a, 3)", ""},
+		{"select * from t1 where id in (select id from t2)", ""},
+	}
+	for _, tc := range cases {
+		stmt, err := Parse(tc.sql)
+		if err != nil {
+			t.Fatal(err)
+		}
+		where := stmt.(*Select).Where
+		inList := FindInList(where, "id")
+		if len(tc.replaced) == 0 {
+			if inList != nil {
+				t.Errorf("%s: expect no IN list, got %s", tc.sql, String(inList))
+			}
+			continue
+		}
+		if inList == nil {
+			t.Fatalf("%s: expect IN list", tc.sql)
+		}
+		values := inList.Right.(ValTuple)
+		replaced := *stmt.(*Select)
+		replaced.Where = ReplaceInList(where, inList, ValTuple{values[0], values[2]})
+		if got := String(&replaced); got != tc.replaced {
+			t.Errorf("expect %s, got %s", tc.replaced, got)
+		}
+		if got := String(stmt); got != tc.sql {
+			t.Errorf("origin is changed to %s", got)
+		}
+	}
+}
+
+func TestFormatInListsAsSubqueries(t *testing.T) {
+	cases := []struct {
+		sql       string
+		formatted string
+	}{
+		{"select * from t1 where a in (1, 2, 3) and b not in ('x', 'y', 'z') and c in (1, 2)",
+			"select * from t1 where a in (select v from tmp1) and b not in (select v from tmp2) and c in (1, 2)"},
+		{"select * from t1 where id in (select id from t2 where a in (1, 2, 3))",
+			"select * from t1 where id in (select id from t2 where a in (select v from tmp1))"},
+		{"delete from t1 where a in (1, 2, 3)", "delete from t1 where a in (select v from tmp1)"},
+		{"select * from t1 where a in (1, 'x', 3) or b in (1, c, 3)", ""},
+	}
+	for _, tc := range cases {
+		stmt, err := Parse(tc.sql)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inLists := FindLargeInLists(stmt, 2)
+		if len(tc.formatted) == 0 {
+			if len(inLists) > 0 {
+				t.Errorf("%s: expect no IN list, got %s", tc.sql, String(inLists[0]))
+			}
+			continue
+		}
+		tables := make([]string, len(inLists))
+		for i := range inLists {
+			tables[i] = fmt.Sprintf("tmp%d", i+1)
+		}
+		if got := FormatInListsAsSubqueries(stmt, inLists, tables, "v"); got != tc.formatted {
+			t.Errorf("expect %s, got %s", tc.formatted, got)
+		}
+		if got := String(stmt); got != tc.sql {
+			t.Errorf("origin is changed to %s", got)
+		}
+	}
+}
+
+func TestFindRangeConds(t *testing.T) {
+	cases := []struct {
+		sql   string
+		conds []string
+	}{
+		{"select * from t1 where id >= 10 and (a = 1 and 20 > id)", []string{"id >= 10", "id < 20"}},
+		{"select * from t1 where id between '2024-01-01' and '2024-01-31' and b = 2", []string{"id between '2024-01-01' and '2024-01-31'"}},
+		{"select * from t1 where id > 10 or id < 5", nil},
+		{"select * from t1 where id not between 1 and 5 and id != 3 and id > a", nil},
+	}
+	for _, tc := range cases {
+		stmt, err := Parse(tc.sql)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conds := FindRangeConds(stmt.(*Select).Where, "id")
+		if len(conds) != len(tc.conds) {
+			t.Fatalf("%s: expect %d conditions, got %d", tc.sql, len(tc.conds), len(conds))
+		}
+		for i, cond := range conds {
+			if got := String(cond); got != tc.conds[i] {
+				t.Errorf("%s: expect %s, got %s", tc.sql, tc.conds[i], got)
+			}
+		}
+	}
+}
+
+func TestCheckColumnInExists(t *testing.T) {
+	cases := []struct {
+		sql   string
+		value string
+	}{
+		{"select * from t1 where exists (select 1 from t2 where t2.id = 5 and t2.a = t1.a)", "5"},
+		{"select * from t1 where t1.b = 1 and exists (select 1 from t2 where id = '5')", "'5'"},
+		{"select * from t1 where id = 5 and exists (select 1 from t2 where t2.id = t1.id)", "5"},
+		{"select * from t1 where id = 5 and not exists (select 1 from t2 where t2.id = 6)", "5"},
+		{"select * from t1 where not exists (select 1 from t2 where t2.id = 5)", ""},
+		{"select * from t1 where exists (select 1 from t2 where t2.a = t1.a)", ""},
+	}
+	for _, tc := range cases {
+		stmt, err := Parse(tc.sql)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, err := CheckColumnInSelect(stmt.(SelectStatement), "id")
+		if len(tc.value) == 0 {
+			if err == nil && value != nil {
+				t.Errorf("%s: expect not routed, got %s", tc.sql, String(value))
+			}
+			continue
+		}
+		if err != nil || value == nil || String(value) != tc.value {
+			t.Errorf("%s: expect %s, got %v, %v", tc.sql, tc.value, value, err)
+		}
+	}
+}
+
+func TestParseInsertSelect(t *testing.T) {
+	cases := []struct {
+		sql    string
+		output string
+		value  string
+	}{
+		{"insert into t1 (id, a) select id, a from t2 where id = 1",
+			"insert  into t1(id, a) select id, a from t2 where id = 1", "id"},
+		{"insert ignore into t1 (id, a) (select 5, a from t2)",
+			"insert ignore into t1(id, a) select 5, a from t2", "5"},
+		{"insert into t1 (select * from t2)", "insert  into t1 select * from t2", ""},
+		{"replace into t1 (id, a) (select t2.id, a from t2)", "replace into t1(id, a) select t2.id, a from t2", "t2.id"},
+		{"insert into t1 (id, a) select id, a from t2 union select id, b from t3",
+			"insert  into t1(id, a) select id, a from t2 union select id, b from t3", "id"},
+		{"insert into t1 (id, a) select 1, a from t2 union select 2, b from t3",
+			"insert  into t1(id, a) select 1, a from t2 union select 2, b from t3", ""},
+		{"insert into t1 (id, a) select * from t2", "insert  into t1(id, a) select * from t2", ""},
+	}
+	for _, tc := range cases {
+		stmt, err := Parse(tc.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.sql, err)
+		}
+		if output := String(stmt); output != tc.output {
+			t.Errorf("%s: expect %s, got %s", tc.sql, tc.output, output)
+		}
+		var value ValExpr
+		switch v := stmt.(type) {
+		case *Insert:
+			value, err = CheckColumnInInsertOrReplace(v.Columns, v.Rows, v.OnDup, "id")
+		case *Replace:
+			value, err = CheckColumnInInsertOrReplace(v.Columns, v.Rows, nil, "id")
+		}
+		if len(tc.value) == 0 {
+			if err == nil {
+				t.Errorf("%s: expect no shard key, got %s", tc.sql, String(value))
+			}
+		} else if err != nil || String(value) != tc.value {
+			t.Errorf("%s: expect shard key %s, got %v, %v", tc.sql, tc.value, value, err)
+		}
+	}
+}
+
+func TestParseWith(t *testing.T) {
+	cases := []struct {
+		sql    string
+		output string
+		tables string
+		value  string
+	}{
+		{"with c as (select * from t1 where id = 1) select * from c",
+			"with c as (select * from t1 where id = 1) select * from c", "t1", "1"},
+		{"WITH c (a, b) AS (SELECT id, a FROM t1 WHERE id = 2), d AS (SELECT * FROM t2 WHERE id = 2) SELECT * FROM c JOIN d ON c.a = d.a",
+			"with c(a, b) as (select id, a from t1 where id = 2), d as (select * from t2 where id = 2) select * from c join d on c.a = d.a", "t1,t2", "2"},
+		{"with recursive c (n) as (select id from t1 where id = 3 union all select n + 1 from c where n < 10) select * from c",
+			"with recursive c(n) as (select id from t1 where id = 3 union all select n+1 from c where n < 10) select * from c", "t1", "3"},
+		{"with c as (select * from t1 where id = 1) select * from c union select * from t2 where id = 1",
+			"with c as (select * from t1 where id = 1) select * from c union select * from t2 where id = 1", "t1,t2", "1"},
+		{"with c as (select * from t1 where id = 1) select * from c join t2 on c.a = t2.a where t2.id = 2",
+			"with c as (select * from t1 where id = 1) select * from c join t2 on c.a = t2.a where t2.id = 2", "t1,t2", ""},
+		{"with c as (select * from t1) select * from c",
+			"with c as (select * from t1) select * from c", "t1", ""},
+		{"select * from t1 where a in (with c as (select a from t2 where id = 1) select a from c) and id = 1",
+			"select * from t1 where a in (with c as (select a from t2 where id = 1) select a from c) and id = 1", "t1,t2", "1"},
+	}
+	for _, tc := range cases {
+		stmt, err := Parse(tc.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.sql, err)
+		}
+		if output := String(stmt); output != tc.output {
+			t.Errorf("%s: expect %s, got %s", tc.sql, tc.output, output)
+		}
+		var tables []string
+		for _, tableName := range GetTableNames(stmt) {
+			tables = append(tables, string(tableName.Name))
+		}
+		if strings.Join(tables, ",") != tc.tables {
+			t.Errorf("%s: expect tables %s, got %v", tc.sql, tc.tables, tables)
+		}
+		if err = CheckTableExprsInSelect(stmt.(SelectStatement), []string{"t1", "t2"}); err != nil {
+			t.Errorf("%s: %v", tc.sql, err)
+		}
+		value, err := CheckColumnInSelect(stmt.(SelectStatement), "id")
+		if len(tc.value) == 0 {
+			if err == nil {
+				t.Errorf("%s: expect no shard key, got %s", tc.sql, String(value))
+			}
+		} else if err != nil || String(value) != tc.value {
+			t.Errorf("%s: expect shard key %s, got %v, %v", tc.sql, tc.value, value, err)
+		}
+	}
+
+	for _, sql := range []string{
+		"with c as (select 1) select 1",
+		"with c as (select * from t1) with d as (select * from t2) select * from c",
+	} {
+		if _, err := Parse(sql); err == nil {
+			t.Errorf("%s: expect syntax error", sql)
+		}
+	}
+}
+
+func TestParseWindowFunc(t *testing.T) {
+	cases := []struct {
+		sql    string
+		output string
+	}{
+		{"select id, row_number() over (partition by a order by b desc) as rn from t1 where id = 1",
+			"select id, row_number() over (partition by a order by b desc) as rn from t1 where id = 1"},
+		{"select sum(a) over () from t1", "select sum(a) over () from t1"},
+		{"select rank() over (order by a, b) from t1", "select rank() over (order by a , b ) from t1"},
+		{"select sum(a) over (partition by b order by c rows between unbounded preceding and current row) from t1",
+			"select sum(a) over (partition by b order by c  rows between unbounded preceding and current row) from t1"},
+		{"select avg(a) over (order by c range between 2 preceding and 1 following) from t1",
+			"select avg(a) over (order by c  range between 2 preceding and 1 following) from t1"},
+		{"select count(*) over (rows unbounded preceding) from t1", "select count(*) over (rows unbounded preceding) from t1"},
+		{"select current, preceding, following from t1", "select `current`, `preceding`, `following` from t1"},
+	}
+	for _, tc := range cases {
+		stmt, err := Parse(tc.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.sql, err)
+		}
+		if output := String(stmt); output != tc.output {
+			t.Errorf("%s: expect %s, got %s", tc.sql, tc.output, output)
+		}
+	}
+}
+
+func TestParseDerivedTable(t *testing.T) {
+	cases := []struct {
+		sql    string
+		output string
+		tables string
+		value  string
+	}{
+		{"select * from (select * from t1 where id = 1) t",
+			"select * from (select * from t1 where id = 1) as t", "t1", "1"},
+		{"select * from (select * from (select * from t1 where id = 1) x) y",
+			"select * from (select * from (select * from t1 where id = 1) as x) as y", "t1", "1"},
+		{"select * from (select id from t1 where id = 1 union select id from t2 where id = 1) u",
+			"select * from (select id from t1 where id = 1 union select id from t2 where id = 1) as u", "t1,t2", "1"},
+		{"select a, count(*) from (select * from t1 where id = 1) t group by a",
+			"select a, count(*) from (select * from t1 where id = 1) as t group by a", "t1", "1"},
+		{"select * from (select * from t1) t", "select * from (select * from t1) as t", "t1", ""},
+		{"select * from (select * from t1 where id = 1) x, (select * from t2 where id = 2) y",
+			"select * from (select * from t1 where id = 1) as x, (select * from t2 where id = 2) as y", "t1,t2", ""},
+		{"select * from t1 where a = (select max(a) from t2 where id = 1) and id = 1",
+			"select * from t1 where a = (select max(a) from t2 where id = 1) and id = 1", "t1,t2", "1"},
+		{"select * from t1 where a > all (select a from t2 where t2.id = t1.id) and id = 1",
+			"select * from t1 where a > all (select a from t2 where t2.id = t1.id) and id = 1", "t1,t2", "1"},
+		{"select * from t1 where a = any (select a from t2) and id = 2",
+			"select * from t1 where a = any (select a from t2) and id = 2", "t1,t2", "2"},
+		{"select * from t1 where id = some (select id from t2)", "select * from t1 where id = some (select id from t2)", "t1,t2", ""},
+	}
+	for _, tc := range cases {
+		stmt, err := Parse(tc.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.sql, err)
+		}
+		if output := String(stmt); output != tc.output {
+			t.Errorf("%s: expect %s, got %s", tc.sql, tc.output, output)
+		}
+		var tables []string
+		for _, tableName := range GetTableNames(stmt) {
+			tables = append(tables, string(tableName.Name))
+		}
+		if strings.Join(tables, ",") != tc.tables {
+			t.Errorf("%s: expect tables %s, got %v", tc.sql, tc.tables, tables)
+		}
+		value, err := CheckColumnInSelect(stmt.(SelectStatement), "id")
+		if len(tc.value) == 0 {
+			if err == nil {
+				t.Errorf("%s: expect no shard key, got %s", tc.sql, String(value))
+			}
+		} else if err != nil || String(value) != tc.value {
+			t.Errorf("%s: expect shard key %s, got %v, %v", tc.sql, tc.value, value, err)
+		}
+	}
+
+	for _, sql := range []string{
+		"select * from t1 where a in (select a from t3) and id = 1",
+		"select (select count(*) from t3) from t1 where id = 1",
+		"select * from t1 join t3 on t1.id = t3.id where t1.id = 1",
+	} {
+		stmt, err := Parse(sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		if err = CheckTableExprsInSelect(stmt.(SelectStatement), []string{"t1", "t2"}); err == nil {
+			t.Errorf("%s: expect table t3 doesn't exist", sql)
+		}
+	}
+}
+
+func TestParseMultiTableDML(t *testing.T) {
+	cases := []struct {
+		sql    string
+		output string
+		tables string
+	}{
+		{"update t1 join t2 on t1.id = t2.id set t1.a = t2.a where t1.id = 1",
+			"update t1 join t2 on t1.id = t2.id set t1.a = t2.a where t1.id = 1", "t1,t2"},
+		{"update t1 a, t2 b set a.c = b.c where a.id = b.id and a.id = 2",
+			"update t1 as a, t2 as b set a.c = b.c where a.id = b.id and a.id = 2", "t1,t2"},
+		{"update t1 set a = 1 where id = 1", "update t1 set a = 1 where id = 1", "t1"},
+		{"delete t1 from t1 join t2 on t1.id = t2.id where t1.id = 1",
+			"delete t1 from t1 join t2 on t1.id = t2.id where t1.id = 1", "t1,t2"},
+		{"delete a, b from t1 as a join t2 as b on a.id = b.id where a.id = 3",
+			"delete a, b from t1 as a join t2 as b on a.id = b.id where a.id = 3", "t1,t2"},
+		{"delete from t1 using t1 join t2 on t1.id = t2.id where t1.id = 1",
+			"delete t1 from t1 join t2 on t1.id = t2.id where t1.id = 1", "t1,t2"},
+		{"delete from t1 where id = 1", "delete from t1 where id = 1", "t1"},
+	}
+	for _, tc := range cases {
+		stmt, err := Parse(tc.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.sql, err)
+		}
+		if output := String(stmt); output != tc.output {
+			t.Errorf("%s: expect %s, got %s", tc.sql, tc.output, output)
+		}
+		var tables []string
+		for _, tableName := range GetTableNames(stmt) {
+			tables = append(tables, string(tableName.Name))
+		}
+		if strings.Join(tables, ",") != tc.tables {
+			t.Errorf("%s: expect tables %s, got %v", tc.sql, tc.tables, tables)
+		}
+	}
+
+	if _, err := Parse("update t1 join t2 on t1.id = t2.id set t1.a = 1 where t1.id = 1 limit 1"); err == nil {
+		t.Errorf("expect error of limit in multiple-table update")
+	}
+}
+
+func TestParseGeneratedColumn(t *testing.T) {
+	cases := []struct {
+		sql    string
+		output string
+	}{
+		{"create table t1 (a int, b int generated always as (a + 1) stored not null unique key comment 'x', c varchar(10) as (concat(a, 'x')) virtual)",
+			"create  table if not exists t1\n(\n\ta int null,\n\tb int generated always as (a+1) stored not null unique key comment 'x',\n\tc varchar(10) generated always as (concat(a, 'x')) virtual null\n) "},
+		{"create table t1 (id int auto_increment primary key, stored int, key idx_a ((a * 2) desc, b))",
+			"create  table if not exists t1\n(\n\tid int null auto_increment primary key,\n\t`stored` int null,\n\tindex idx_a((a*2) desc,b)\n) "},
+		{"alter table t1 add column c int as (a > 0) stored", "alter  table t1\nadd column c int generated always as (a > 0) stored null"},
+		{"alter table t1 add index idx_f ((a + b))", "alter  table t1\nadd index idx_f((a+b))"},
+		{"create index idx_f on t1 ((lower(c)), a(4))", "create  index idx_f on t1((lower(c)),a(4))"},
+	}
+	for _, tc := range cases {
+		stmt, err := Parse(tc.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.sql, err)
+		}
+		if output := String(stmt); output != tc.output {
+			t.Errorf("%s: expect %q, got %q", tc.sql, tc.output, output)
+		}
+	}
+}
+
+func TestParseCheckAndDefaultExpr(t *testing.T) {
+	cases := []struct {
+		sql    string
+		output string
+	}{
+		{"create table t1 (id int not null check (id > 0), a int default (id + 1), u varchar(36) default (uuid()), f bool default (a > 1), constraint c1 check (a < 100) not enforced)",
+			"create  table if not exists t1\n(\n\tid int not null check (id > 0),\n\ta int null default (id+1),\n\tu varchar(36) null default (uuid()),\n\tf bool null default (a > 1),\n\tconstraint c1 check (a < 100) not enforced\n) "},
+		{"alter table t1 add constraint c2 check (a > 0), drop check c1, drop constraint c3, alter check c2 enforced",
+			"alter  table t1\nadd constraint c2 check (a > 0),\ndrop check c1,\ndrop constraint c3,\nalter check c2 enforced"},
+		{"select enforced from t1", "select `enforced` from t1"},
+	}
+	for _, tc := range cases {
+		stmt, err := Parse(tc.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.sql, err)
+		}
+		if output := String(stmt); output != tc.output {
+			t.Errorf("%s: expect %q, got %q", tc.sql, tc.output, output)
+		}
+	}
+}
+
+func TestParseForeignKey(t *testing.T) {
+	cases := []struct {
+		sql    string
+		output string
+	}{
+		{"create table c (id int, pid int, foreign key (pid) references p (id) match full on update cascade on delete set null)",
+			"create  table if not exists c\n(\n\tid int null,\n\tpid int null,\n\tforeign key(pid) references p(id) match full on delete set null on update cascade\n) "},
+		{"alter table c add constraint fk1 foreign key (id, pid) references p (id, x) on delete restrict",
+			"alter  table c\nadd constraint fk1 foreign key(id,pid) references p(id,x) on delete restrict"},
+	}
+	for _, tc := range cases {
+		stmt, err := Parse(tc.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.sql, err)
+		}
+		if output := String(stmt); output != tc.output {
+			t.Errorf("%s: expect %q, got %q", tc.sql, tc.output, output)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	cases := []struct {
+		sql         string
+		fingerprint string
+	}{
+		{"select /*!saashard master */ * from t where id = 1 and name = 'a'", "select * from t where id = ? and name = ?"},
+		{"select * from t where id in (1, 2, 3) and x = -5", "select * from t where id in (?) and x = ?"},
+		{"insert into t (id, a) values (1, 'a'), (2, 'b')", "insert  into t(id, a) values (?)"},
+		{"update t set a = a + 1 where id = ?", "update t set a = a+? where id = ?"},
+	}
+	for _, tc := range cases {
+		stmt, err := Parse(tc.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.sql, err)
+		}
+		if fingerprint := Fingerprint(stmt); fingerprint != tc.fingerprint {
+			t.Errorf("%s: expect %q, got %q", tc.sql, tc.fingerprint, fingerprint)
+		}
+	}
+}
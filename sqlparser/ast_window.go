@@ -0,0 +1,110 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package sqlparser
+
+// WindowFuncExpr represents a window function, function evaluated over a window of rows by OVER.
+type WindowFuncExpr struct {
+	Func   *FuncExpr
+	Window *WindowSpec
+}
+
+// Format WindowFuncExpr.
+func (node *WindowFuncExpr) Format(buf *TrackedBuffer) {
+	buf.Fprintf("%v over %v", node.Func, node.Window)
+}
+
+func (*WindowFuncExpr) IExpr()    {}
+func (*WindowFuncExpr) IValExpr() {}
+
+// WindowSpec represents a window specification, rows partitioned, ordered and framed.
+type WindowSpec struct {
+	PartitionBy ValExprs
+	OrderBy     OrderBy
+	Frame       *WindowFrame
+}
+
+// Format WindowSpec.
+func (node *WindowSpec) Format(buf *TrackedBuffer) {
+	buf.Fprintf("(")
+	prefix := ""
+	if len(node.PartitionBy) > 0 {
+		buf.Fprintf("partition by %v", node.PartitionBy)
+		prefix = " "
+	}
+	for i, order := range node.OrderBy {
+		if i == 0 {
+			buf.Fprintf("%sorder by %v", prefix, order)
+		} else {
+			buf.Fprintf(", %v", order)
+		}
+		prefix = " "
+	}
+	if node.Frame != nil {
+		buf.Fprintf("%s%v", prefix, node.Frame)
+	}
+	buf.Fprintf(")")
+}
+
+// WindowFrame represents a frame of window, by rows or range from start to end.
+type WindowFrame struct {
+	Unit       string
+	Start, End *FrameBound
+}
+
+// WindowFrame.Unit
+const (
+	AST_FRAME_ROWS  = "rows"
+	AST_FRAME_RANGE = "range"
+)
+
+// Format WindowFrame.
+func (node *WindowFrame) Format(buf *TrackedBuffer) {
+	if node.End == nil {
+		buf.Fprintf("%s %v", node.Unit, node.Start)
+		return
+	}
+	buf.Fprintf("%s between %v and %v", node.Unit, node.Start, node.End)
+}
+
+// FrameBound represents a bound of window frame, Expr is set for offset preceding or following.
+type FrameBound struct {
+	Type string
+	Expr ValExpr
+}
+
+// FrameBound.Type
+const (
+	AST_UNBOUNDED_PRECEDING = "unbounded preceding"
+	AST_UNBOUNDED_FOLLOWING = "unbounded following"
+	AST_CURRENT_ROW         = "current row"
+	AST_PRECEDING           = "preceding"
+	AST_FOLLOWING           = "following"
+)
+
+// Format FrameBound.
+func (node *FrameBound) Format(buf *TrackedBuffer) {
+	if node.Expr != nil {
+		buf.Fprintf("%v ", node.Expr)
+	}
+	buf.Fprintf("%s", node.Type)
+}
@@ -0,0 +1,89 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package sqlparser
+
+import "strings"
+
+// With represents a WITH clause of common table expressions, before SELECT or UNION.
+type With struct {
+	Recursive bool
+	CTEs      []*CommonTableExpr
+}
+
+// With.Recursive
+const AST_RECURSIVE = "recursive "
+
+// Format With.
+func (node *With) Format(buf *TrackedBuffer) {
+	if node == nil {
+		return
+	}
+	buf.Fprintf("with ")
+	if node.Recursive {
+		buf.Fprintf(AST_RECURSIVE)
+	}
+	for i, cte := range node.CTEs {
+		if i > 0 {
+			buf.Fprintf(", ")
+		}
+		buf.Fprintf("%v", cte)
+	}
+	buf.Fprintf(" ")
+}
+
+// CommonTableExpr represents a common table expression, named subquery referenced as table.
+type CommonTableExpr struct {
+	Name    []byte
+	Columns Columns
+	Select  SelectStatement
+}
+
+// Format CommonTableExpr.
+func (node *CommonTableExpr) Format(buf *TrackedBuffer) {
+	escape(buf, node.Name)
+	buf.Fprintf("%v as (%v)", node.Columns, node.Select)
+}
+
+// GetCTENames get names of common table expressions in statement, include those in subqueries.
+func GetCTENames(node SQLNode) map[string]bool {
+	var names map[string]bool
+	Walk(func(node SQLNode) (bool, error) {
+		if cte, ok := node.(*CommonTableExpr); ok {
+			if names == nil {
+				names = make(map[string]bool)
+			}
+			names[cteName(cte.Name)] = true
+		}
+		return true, nil
+	}, node)
+	return names
+}
+
+// IsCTE is true if table name refers to a common table expression.
+func IsCTE(tableName *TableName, cteNames map[string]bool) bool {
+	return len(tableName.Qualifier) == 0 && cteNames[cteName(tableName.Name)]
+}
+
+func cteName(name []byte) string {
+	return strings.Trim(strings.ToLower(string(name)), "`")
+}
@@ -0,0 +1,79 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package sqlparser
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+)
+
+// Fingerprint format statement with values replaced by '?', list of values as one '(?)', and without comments,
+// so that statements only different in values have the same fingerprint, such as 'select * from t where id = ?'.
+func Fingerprint(node SQLNode) string {
+	buf := NewTrackedBuffer(func(buf *TrackedBuffer, node SQLNode) {
+		switch n := node.(type) {
+		case StrVal, NumVal, ValArg:
+			buf.WriteString("?")
+			return
+		case ValTuple:
+			if isValueList(n) {
+				buf.WriteString("(?)")
+				return
+			}
+		case Values:
+			if len(n) > 0 {
+				if tuple, ok := n[0].(ValTuple); ok && isValueList(tuple) {
+					buf.WriteString("values (?)")
+					return
+				}
+			}
+		case Comments:
+			return
+		}
+		node.Format(buf)
+	})
+	buf.Fprintf("%v", node)
+	return buf.String()
+}
+
+// FingerprintDigest get digest of fingerprint, first 16 hex digits of md5.
+func FingerprintDigest(fingerprint string) string {
+	sum := md5.Sum([]byte(fingerprint))
+	return hex.EncodeToString(sum[:8])
+}
+
+// isValueList check each expression of tuple is a value, or a '?' argument.
+func isValueList(tuple ValTuple) bool {
+	for _, expr := range tuple {
+		switch v := expr.(type) {
+		case StrVal, NumVal, ValArg, *NullVal:
+		case *UnaryExpr:
+			if _, ok := v.Expr.(NumVal); !ok {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
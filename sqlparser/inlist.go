@@ -0,0 +1,192 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package sqlparser
+
+// FindInList find IN list of column in where expression, which values are all string or number.
+// Only the list in top-level AND expressions is found, nil if not found.
+func FindInList(where *Where, colName string) *ComparisonExpr {
+	if where == nil {
+		return nil
+	}
+	return findInList(where.Expr, colName)
+}
+
+func findInList(expr BoolExpr, colName string) *ComparisonExpr {
+	switch boolExpr := expr.(type) {
+	case *AndExpr:
+		if inList := findInList(boolExpr.Left, colName); inList != nil {
+			return inList
+		}
+		return findInList(boolExpr.Right, colName)
+	case *ParenBoolExpr:
+		return findInList(boolExpr.Expr, colName)
+	case *ComparisonExpr:
+		if boolExpr.Operator != AST_IN || GetColName(boolExpr.Left) != colName {
+			return nil
+		}
+		values, ok := boolExpr.Right.(ValTuple)
+		if !ok || len(values) == 0 {
+			return nil
+		}
+		for _, value := range values {
+			switch value.(type) {
+			case StrVal, NumVal:
+			default:
+				return nil
+			}
+		}
+		return boolExpr
+	}
+	return nil
+}
+
+// ReplaceInList copy where expression with values of IN list replaced.
+// Expressions in path to the IN list are copied, the others are shared with origin.
+func ReplaceInList(where *Where, inList *ComparisonExpr, values ValTuple) *Where {
+	replaced := *where
+	replaced.Expr = replaceInList(where.Expr, inList, values)
+	return &replaced
+}
+
+func replaceInList(expr BoolExpr, inList *ComparisonExpr, values ValTuple) BoolExpr {
+	switch boolExpr := expr.(type) {
+	case *AndExpr:
+		return &AndExpr{Left: replaceInList(boolExpr.Left, inList, values), Right: replaceInList(boolExpr.Right, inList, values)}
+	case *ParenBoolExpr:
+		return &ParenBoolExpr{Expr: replaceInList(boolExpr.Expr, inList, values)}
+	case *ComparisonExpr:
+		if boolExpr == inList {
+			return &ComparisonExpr{Operator: AST_IN, Left: boolExpr.Left, Right: values}
+		}
+	}
+	return expr
+}
+
+// FindLargeInLists find IN and NOT IN lists of more than threshold values in node, including those in subqueries,
+// which values are all numbers or all strings.
+func FindLargeInLists(node SQLNode, threshold int) []*ComparisonExpr {
+	var inLists []*ComparisonExpr
+	Walk(func(node SQLNode) (bool, error) {
+		boolExpr, ok := node.(*ComparisonExpr)
+		if !ok || (boolExpr.Operator != AST_IN && boolExpr.Operator != AST_NOT_IN) {
+			return true, nil
+		}
+		values, ok := boolExpr.Right.(ValTuple)
+		if !ok || len(values) <= threshold {
+			return true, nil
+		}
+		_, isStr := values[0].(StrVal)
+		for _, value := range values {
+			switch value.(type) {
+			case StrVal:
+				if !isStr {
+					return false, nil
+				}
+			case NumVal:
+				if isStr {
+					return false, nil
+				}
+			default:
+				return false, nil
+			}
+		}
+		inLists = append(inLists, boolExpr)
+		return false, nil
+	}, node)
+	return inLists
+}
+
+// FormatInListsAsSubqueries format node with values of each IN list replaced by subquery of column in table,
+// tables are in the same order as IN lists. node is not changed.
+func FormatInListsAsSubqueries(node SQLNode, inLists []*ComparisonExpr, tables []string, column string) string {
+	buf := NewTrackedBuffer(func(buf *TrackedBuffer, node SQLNode) {
+		if boolExpr, ok := node.(*ComparisonExpr); ok {
+			for i, inList := range inLists {
+				if boolExpr == inList {
+					buf.Fprintf("%v %s (select %s from %s)", boolExpr.Left, boolExpr.Operator, column, tables[i])
+					return
+				}
+			}
+		}
+		node.Format(buf)
+	})
+	buf.Fprintf("%v", node)
+	return buf.String()
+}
+
+// FindRangeConds find range conditions of column in where expression, which bounds are string or number,
+// by >, >=, <, <=, = and BETWEEN. Only conditions in top-level AND expressions are found,
+// and comparisons are with column at left, such as '10 < id' is found as 'id > 10'.
+func FindRangeConds(where *Where, colName string) []BoolExpr {
+	if where == nil {
+		return nil
+	}
+	return findRangeConds(nil, where.Expr, colName)
+}
+
+func findRangeConds(conds []BoolExpr, expr BoolExpr, colName string) []BoolExpr {
+	switch boolExpr := expr.(type) {
+	case *AndExpr:
+		conds = findRangeConds(conds, boolExpr.Left, colName)
+		return findRangeConds(conds, boolExpr.Right, colName)
+	case *ParenBoolExpr:
+		return findRangeConds(conds, boolExpr.Expr, colName)
+	case *ComparisonExpr:
+		switch boolExpr.Operator {
+		case AST_EQ, AST_LT, AST_GT, AST_LE, AST_GE:
+		default:
+			return conds
+		}
+		if GetColName(boolExpr.Left) == colName && isBound(boolExpr.Right) {
+			return append(conds, boolExpr)
+		}
+		if GetColName(boolExpr.Right) == colName && isBound(boolExpr.Left) {
+			operator := boolExpr.Operator
+			switch operator {
+			case AST_LT:
+				operator = AST_GT
+			case AST_GT:
+				operator = AST_LT
+			case AST_LE:
+				operator = AST_GE
+			case AST_GE:
+				operator = AST_LE
+			}
+			return append(conds, &ComparisonExpr{Left: boolExpr.Right, Operator: operator, Right: boolExpr.Left})
+		}
+	case *RangeCond:
+		if boolExpr.Operator == AST_BETWEEN && GetColName(boolExpr.Left) == colName &&
+			isBound(boolExpr.From) && isBound(boolExpr.To) {
+			return append(conds, boolExpr)
+		}
+	}
+	return conds
+}
+
+func isBound(expr ValExpr) bool {
+	switch expr.(type) {
+	case StrVal, NumVal:
+		return true
+	}
+	return false
+}
@@ -49,6 +49,8 @@ type Tokenizer struct {
 	LastError     string
 	posVarIndex   int
 	ParseTree     Statement
+	nesting       int   // nesting depth of parentheses.
+	limitErr      error // statement exceeds limit of parser.
 }
 
 // NewStringTokenizer creates a new Tokenizer for the
@@ -70,9 +72,24 @@ var keywords = map[string]int{
 	"by":     BY,
 	"limit":  LIMIT,
 	"for":    FOR,
+	"with":   WITH,
+
+	"recursive": RECURSIVE,
+
+	"over":      OVER,
+	"partition": PARTITION,
+	"rows":      ROWS,
+	"range":     RANGE,
+	"row":       ROW,
+	"current":   CURRENT,
+	"unbounded": UNBOUNDED,
+	"preceding": PRECEDING,
+	"following": FOLLOWING,
 
 	"union":     UNION,
 	"all":       ALL,
+	"any":       ANY,
+	"some":      SOME,
 	"minus":     MINUS,
 	"except":    EXCEPT,
 	"intersect": INTERSECT,
@@ -116,6 +133,8 @@ var keywords = map[string]int{
 	"set":       SET,
 	"lock":      LOCK,
 
+	"sql_calc_found_rows": SQL_CALC_FOUND_ROWS,
+
 	"create":   CREATE,
 	"alter":    ALTER,
 	"rename":   RENAME,
@@ -205,6 +224,14 @@ var keywords = map[string]int{
 	"enable":  ENABLE,
 	"disable": DISABLE,
 
+	"generated": GENERATED,
+	"always":    ALWAYS,
+	"virtual":   VIRTUAL,
+	"stored":    STORED,
+
+	"check":    CHECK,
+	"enforced": ENFORCED,
+
 	"using":    USING,
 	"begin":    BEGIN,
 	"rollback": ROLLBACK,
@@ -405,6 +432,12 @@ func (tkn *Tokenizer) Lex(lval *yySymType) int {
 	switch typ {
 	case ID, STRING, NUMBER, VALUE_ARG, COMMENTS:
 		lval.bytes = val
+	case '(':
+		if !tkn.enterParen() {
+			return LEX_ERROR
+		}
+	case ')':
+		tkn.nesting--
 	}
 	tkn.errorToken = val
 	return typ
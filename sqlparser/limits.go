@@ -0,0 +1,73 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package sqlparser
+
+import "fmt"
+
+// Limits of statement parsed, so that adversarial or generated sql cannot exhaust memory and cpu. 0 is unlimited.
+var (
+	// MaxStatementLength is max bytes of statement.
+	MaxStatementLength int
+	// MaxInListItems is max items of IN list, such as 'id in (1, 2, 3)'.
+	MaxInListItems int
+	// MaxNestingDepth is max nesting depth of parentheses, in expressions and subqueries.
+	MaxNestingDepth int
+)
+
+// LimitError is error of statement exceeding limit of parser.
+type LimitError struct {
+	Limit string // name of limit, such as 'max_in_list_items'.
+	Value int
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("statement exceeds parser limit %s: %d > %d", e.Limit, e.Value, e.Max)
+}
+
+// checkLength check length of statement.
+func checkLength(sql string) error {
+	if MaxStatementLength > 0 && len(sql) > MaxStatementLength {
+		return &LimitError{Limit: "max_statement_length", Value: len(sql), Max: MaxStatementLength}
+	}
+	return nil
+}
+
+// enterParen count nesting depth of parentheses, return false if exceeds limit.
+func (tkn *Tokenizer) enterParen() bool {
+	tkn.nesting++
+	if MaxNestingDepth > 0 && tkn.nesting > MaxNestingDepth {
+		tkn.limitErr = &LimitError{Limit: "max_nesting_depth", Value: tkn.nesting, Max: MaxNestingDepth}
+		return false
+	}
+	return true
+}
+
+// CheckInList check items of IN list, return false if exceeds limit.
+func CheckInList(yylex interface{}, tuple Tuple) bool {
+	if values, ok := tuple.(ValTuple); ok && MaxInListItems > 0 && len(values) > MaxInListItems {
+		yylex.(*Tokenizer).limitErr = &LimitError{Limit: "max_in_list_items", Value: len(values), Max: MaxInListItems}
+		return false
+	}
+	return true
+}
@@ -33,6 +33,7 @@ import (
 	"strings"
 
 	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/sqlparser/sqltypes"
 	"github.com/berkaroad/saashard/utils"
 )
 
@@ -105,6 +106,11 @@ func CheckColumnInBoolExpr(expr BoolExpr, colName string) (strOrNumValue ValExpr
 	case *ParenBoolExpr:
 		strOrNumValue, err = CheckColumnInBoolExpr(boolExpr.Expr, colName)
 		return
+	case *ExistsExpr:
+		// rows of co-sharded tables in subquery are in the node of shard key, so EXISTS is false in other nodes.
+		// NOT EXISTS is true in other nodes, it's not pruned.
+		strOrNumValue, err = CheckColumnInSelect(boolExpr.Subquery.Select, colName)
+		return
 	case *ComparisonExpr:
 		switch boolExpr.Operator {
 		case AST_EQ:
@@ -138,6 +144,10 @@ func CheckColumnInBoolExpr(expr BoolExpr, colName string) (strOrNumValue ValExpr
 
 // CheckTableExprs remove db and check table's name.
 func CheckTableExprs(tabExprs TableExprs, tableNames interface{}) (err error) {
+	return checkTableExprs(tabExprs, tableNames, nil)
+}
+
+func checkTableExprs(tabExprs TableExprs, tableNames interface{}, cteNames map[string]bool) (err error) {
 	for _, tabExpr := range tabExprs {
 		switch realTabExpr := tabExpr.(type) {
 		case *AliasedTableExpr:
@@ -150,7 +160,7 @@ func CheckTableExprs(tabExprs TableExprs, tableNames interface{}) (err error) {
 						simpExpr.Qualifier = nil
 					}
 				}
-				if !isSystemDB {
+				if !isSystemDB && !IsCTE(simpExpr, cteNames) {
 					tableName := strings.Trim(strings.ToLower(string(simpExpr.Name)), "`")
 					if isValid := utils.Contains(tableNames, tableName); !isValid {
 						err = &errors.SqlError{Code: 1146, Message: fmt.Sprintf("Table '%-.192s.%-.192s' doesn't exist", "", tableName), State: "42S02"}
@@ -158,16 +168,16 @@ func CheckTableExprs(tabExprs TableExprs, tableNames interface{}) (err error) {
 					}
 				}
 			case *Subquery:
-				if err = CheckTableExprsInSelect(simpExpr.Select, tableNames); err != nil {
+				if err = checkTableExprsInSelect(simpExpr.Select, tableNames, cteNames); err != nil {
 					break
 				}
 			}
 		case *ParenTableExpr:
-			err = CheckTableExprs(TableExprs{realTabExpr.Expr}, tableNames)
+			err = checkTableExprs(TableExprs{realTabExpr.Expr}, tableNames, cteNames)
 		case *JoinTableExpr:
-			err = CheckTableExprs(TableExprs{realTabExpr.LeftExpr}, tableNames)
+			err = checkTableExprs(TableExprs{realTabExpr.LeftExpr}, tableNames, cteNames)
 			if err == nil {
-				CheckTableExprs(TableExprs{realTabExpr.RightExpr}, tableNames)
+				err = checkTableExprs(TableExprs{realTabExpr.RightExpr}, tableNames, cteNames)
 			}
 		}
 	}
@@ -197,20 +207,54 @@ func IsOnlySystemDBInTableExprs(tabExprs TableExprs) bool {
 	return onlySystemDB
 }
 
-// CheckTableExprsInSelect remove db and check table's name.
+// CheckTableExprsInSelect remove db and check table's name, include tables in common table expressions.
 func CheckTableExprsInSelect(stmt SelectStatement, tableNames interface{}) (err error) {
+	return checkTableExprsInSelect(stmt, tableNames, GetCTENames(stmt))
+}
+
+func checkTableExprsInSelect(stmt SelectStatement, tableNames interface{}, cteNames map[string]bool) (err error) {
 	switch selStmt := stmt.(type) {
 	case *Select:
-		err = CheckTableExprs(selStmt.From, tableNames)
+		if err = checkTableExprsInWith(selStmt.With, tableNames, cteNames); err == nil {
+			err = checkTableExprs(selStmt.From, tableNames, cteNames)
+		}
+		if err == nil {
+			err = checkTableExprsInSubqueries(tableNames, cteNames, selStmt.SelectExprs, selStmt.Where, selStmt.Having)
+		}
 	case *Union:
-		err = CheckTableExprsInSelect(selStmt.Left, tableNames)
+		if err = checkTableExprsInWith(selStmt.With, tableNames, cteNames); err != nil {
+			break
+		}
+		err = checkTableExprsInSelect(selStmt.Left, tableNames, cteNames)
 		if err == nil {
-			err = CheckTableExprsInSelect(selStmt.Right, tableNames)
+			err = checkTableExprsInSelect(selStmt.Right, tableNames, cteNames)
 		}
 	}
 	return err
 }
 
+// checkTableExprsInSubqueries check tables in subqueries of expressions, such as scalar subquery and EXISTS.
+func checkTableExprsInSubqueries(tableNames interface{}, cteNames map[string]bool, nodes ...SQLNode) error {
+	return Walk(func(node SQLNode) (bool, error) {
+		if subquery, ok := node.(*Subquery); ok {
+			return false, checkTableExprsInSelect(subquery.Select, tableNames, cteNames)
+		}
+		return true, nil
+	}, nodes...)
+}
+
+func checkTableExprsInWith(with *With, tableNames interface{}, cteNames map[string]bool) error {
+	if with == nil {
+		return nil
+	}
+	for _, cte := range with.CTEs {
+		if err := checkTableExprsInSelect(cte.Select, tableNames, cteNames); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CheckColumnInTableExpr check shard key should exists in table expression, and has same shard key's value in it.
 func CheckColumnInTableExpr(tabExpr TableExpr, colName string) (strOrNumValue ValExpr, err error) {
 	switch realTabExpr := tabExpr.(type) {
@@ -259,12 +303,27 @@ func CheckColumnInTableExpr(tabExpr TableExpr, colName string) (strOrNumValue Va
 func CheckColumnInSelect(statement SelectStatement, colName string) (strOrNumValue ValExpr, err error) {
 	switch selStmt := statement.(type) {
 	case *Select:
+		if strOrNumValue, err = CheckColumnInWith(selStmt.With, colName); err != nil {
+			return
+		}
 		if selStmt.Where == nil {
+			// rows of derived tables and common table expressions, are in node of shard key's value in them,
+			// so they are joined in the node.
+			if isOnlyDerivedInTableExprs(selStmt.From, GetCTENames(selStmt.With), true) {
+				valInTab, errInTab := checkColumnInDerivedTables(selStmt.From, colName)
+				if strOrNumValue, err = mergeValExprAndError(strOrNumValue, err, valInTab, errInTab); err != nil {
+					return
+				}
+				if strOrNumValue != nil {
+					return
+				}
+			}
 			strOrNumValue = nil
 			err = errors.ErrWhereOrJoinOnKey
 			return
 		}
-		strOrNumValue, err = CheckColumnInBoolExpr(selStmt.Where.Expr, colName)
+		valInWhere, errInWhere := CheckColumnInBoolExpr(selStmt.Where.Expr, colName)
+		strOrNumValue, err = mergeValExprAndError(strOrNumValue, err, valInWhere, errInWhere)
 		if err != nil {
 			return
 		}
@@ -276,19 +335,135 @@ func CheckColumnInSelect(statement SelectStatement, colName string) (strOrNumVal
 			}
 		}
 	case *Union:
-		valInLeft, errInLeft := CheckColumnInSelect(selStmt.Left, colName)
-		strOrNumValue, err = mergeValExprAndError(strOrNumValue, err, valInLeft, errInLeft)
-		if err != nil {
+		if strOrNumValue, err = CheckColumnInWith(selStmt.With, colName); err != nil {
 			return
 		}
+		cteNames := GetCTENames(selStmt.With)
+		for _, sel := range unionSelects(selStmt) {
+			// select only from common table expressions without where, is in node of them.
+			if realSel, ok := sel.(*Select); ok && realSel.Where == nil && strOrNumValue != nil &&
+				isOnlyDerivedInTableExprs(realSel.From, cteNames, false) {
+				continue
+			}
+			valInSel, errInSel := CheckColumnInSelect(sel, colName)
+			strOrNumValue, err = mergeValExprAndError(strOrNumValue, err, valInSel, errInSel)
+			if err != nil {
+				return
+			}
+		}
+	}
+	return strOrNumValue, err
+}
 
-		valInRight, errInRight := CheckColumnInSelect(selStmt.Right, colName)
-		strOrNumValue, err = mergeValExprAndError(strOrNumValue, err, valInRight, errInRight)
-		if err != nil {
+// CheckColumnInWith check shard key in common table expressions, they should have same shard key's value.
+// Recursive part references the common table expression itself, it's in node of the anchor part.
+func CheckColumnInWith(with *With, colName string) (strOrNumValue ValExpr, err error) {
+	if with == nil {
+		return
+	}
+	for _, cte := range with.CTEs {
+		selects := []SelectStatement{cte.Select}
+		if with.Recursive {
+			selects = anchorSelects(cte.Select, cteName(cte.Name))
+		}
+		for _, sel := range selects {
+			valInCTE, errInCTE := CheckColumnInSelect(sel, colName)
+			strOrNumValue, err = mergeValExprAndError(strOrNumValue, err, valInCTE, errInCTE)
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// unionSelects get selects of union, in order. Union with its own common table expressions is a select.
+func unionSelects(union *Union) []SelectStatement {
+	var selects []SelectStatement
+	for _, sel := range []SelectStatement{union.Left, union.Right} {
+		if subUnion, ok := sel.(*Union); ok && subUnion.With == nil {
+			selects = append(selects, unionSelects(subUnion)...)
+		} else {
+			selects = append(selects, sel)
+		}
+	}
+	return selects
+}
+
+// anchorSelects get selects of union not referencing the common table expression of name.
+func anchorSelects(stmt SelectStatement, name string) []SelectStatement {
+	selects := []SelectStatement{stmt}
+	if union, ok := stmt.(*Union); ok && union.With == nil {
+		selects = unionSelects(union)
+	}
+	anchors := make([]SelectStatement, 0, len(selects))
+	for _, sel := range selects {
+		recursive := false
+		for _, tableName := range GetTableNames(sel) {
+			if IsCTE(tableName, map[string]bool{name: true}) {
+				recursive = true
+				break
+			}
+		}
+		if !recursive {
+			anchors = append(anchors, sel)
+		}
+	}
+	return anchors
+}
+
+// checkColumnInDerivedTables check shard key in derived tables, they should have same shard key's value.
+func checkColumnInDerivedTables(tabExprs TableExprs, colName string) (strOrNumValue ValExpr, err error) {
+	for _, tabExpr := range tabExprs {
+		var valInTab ValExpr
+		var errInTab error
+		switch realTabExpr := tabExpr.(type) {
+		case *AliasedTableExpr:
+			if subquery, ok := realTabExpr.Expr.(*Subquery); ok {
+				valInTab, errInTab = CheckColumnInSelect(subquery.Select, colName)
+			}
+		case *ParenTableExpr:
+			valInTab, errInTab = checkColumnInDerivedTables(TableExprs{realTabExpr.Expr}, colName)
+		case *JoinTableExpr:
+			valInTab, errInTab = checkColumnInDerivedTables(TableExprs{realTabExpr.LeftExpr, realTabExpr.RightExpr}, colName)
+		}
+		if strOrNumValue, err = mergeValExprAndError(strOrNumValue, err, valInTab, errInTab); err != nil {
 			return
 		}
 	}
-	return strOrNumValue, err
+	return
+}
+
+// isOnlyDerivedInTableExprs only common table expressions in table exprs, or derived tables if subquery.
+func isOnlyDerivedInTableExprs(tabExprs TableExprs, cteNames map[string]bool, subquery bool) bool {
+	for _, tabExpr := range tabExprs {
+		switch realTabExpr := tabExpr.(type) {
+		case *AliasedTableExpr:
+			switch simpExpr := realTabExpr.Expr.(type) {
+			case *TableName:
+				if !IsCTE(simpExpr, cteNames) {
+					return false
+				}
+			case *Subquery:
+				if !subquery {
+					return false
+				}
+			default:
+				return false
+			}
+		case *ParenTableExpr:
+			if !isOnlyDerivedInTableExprs(TableExprs{realTabExpr.Expr}, cteNames, subquery) {
+				return false
+			}
+		case *JoinTableExpr:
+			if !isOnlyDerivedInTableExprs(TableExprs{realTabExpr.LeftExpr, realTabExpr.RightExpr}, cteNames, subquery) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
 }
 
 // SetLimitInSelect set limit expression in select statement.
@@ -307,6 +482,7 @@ func SetLimitInSelect(statement SelectStatement, maxRowCount int) {
 }
 
 // CheckColumnInInsertOrReplace check shard key should exists in columns, not in dup expers, has same shard key's value in rows.
+// Rows of INSERT ... SELECT return select expression of shard key, it's value or column.
 func CheckColumnInInsertOrReplace(columns Columns, insertRows InsertRows, onDup OnDup, colName string) (strOrNumValue ValExpr, err error) {
 	var colValue ValExpr
 	// insert statement must contain shardkey column.
@@ -364,6 +540,10 @@ func CheckColumnInInsertOrReplace(columns Columns, insertRows InsertRows, onDup
 				}
 			}
 		}
+	case SelectStatement:
+		if colValue, err = selectExprAt(values, shardKeyPos, len(columns)); err != nil {
+			return nil, err
+		}
 	}
 	if colValue == nil {
 		return nil, errors.ErrInsertValuesKey
@@ -371,6 +551,41 @@ func CheckColumnInInsertOrReplace(columns Columns, insertRows InsertRows, onDup
 	return colValue, nil
 }
 
+// selectExprAt get select expression at position of select, nil if not the same in selects of union or '*' selected.
+func selectExprAt(stmt SelectStatement, pos int, count int) (ValExpr, error) {
+	var selectExprs SelectExprs
+	switch selStmt := stmt.(type) {
+	case *Select:
+		selectExprs = selStmt.SelectExprs
+	case *SimpleSelect:
+		selectExprs = selStmt.SelectExprs
+	case *Union:
+		left, err := selectExprAt(selStmt.Left, pos, count)
+		if err != nil || left == nil {
+			return nil, err
+		}
+		right, err := selectExprAt(selStmt.Right, pos, count)
+		if err != nil || right == nil || String(left) != String(right) {
+			return nil, err
+		}
+		return left, nil
+	}
+	for _, selectExpr := range selectExprs {
+		if _, ok := selectExpr.(*StarExpr); ok {
+			return nil, nil
+		}
+	}
+	if len(selectExprs) != count {
+		return nil, errors.ErrColsLenNotMatch
+	}
+	if nonStarExpr, ok := selectExprs[pos].(*NonStarExpr); ok {
+		if valExpr, ok := nonStarExpr.Expr.(ValExpr); ok {
+			return valExpr, nil
+		}
+	}
+	return nil, nil
+}
+
 // GetColName returns the column name, only if
 // it's a simple expression. Otherwise, it returns "".
 func GetColName(node Expr) string {
@@ -413,3 +628,143 @@ func mergeValExprAndError(val1 ValExpr, err1 error, val2 ValExpr, err2 error) (s
 	}
 	return
 }
+
+// GetTableNames get all table names in select or dml statement, include tables in subqueries
+// and common table expressions, except references of common table expressions.
+func GetTableNames(node SQLNode) []*TableName {
+	tableNames := make([]*TableName, 0, 4)
+	cteNames := GetCTENames(node)
+	Walk(func(node SQLNode) (bool, error) {
+		if tableName, ok := node.(*TableName); ok && !IsCTE(tableName, cteNames) {
+			tableNames = append(tableNames, tableName)
+		}
+		return true, nil
+	}, node)
+	return tableNames
+}
+
+// ReplaceValExprs replace value expressions in select or dml statement, such as functions evaluated by proxy.
+// replace returns nil to keep the expression, name of select expression is kept by alias if replaced.
+func ReplaceValExprs(node SQLNode, replace func(ValExpr) (ValExpr, error)) error {
+	return Walk(func(node SQLNode) (bool, error) {
+		var err error
+		switch n := node.(type) {
+		case *NonStarExpr:
+			if v, ok := n.Expr.(ValExpr); ok {
+				var newExpr ValExpr
+				if newExpr, err = replace(v); err == nil && newExpr != nil {
+					if len(n.As) == 0 {
+						n.As = []byte(String(v))
+					}
+					n.Expr = newExpr
+				}
+			}
+		case ValExprs:
+			for i, e := range n {
+				if n[i], err = replaceValExpr(e, replace); err != nil {
+					break
+				}
+			}
+		case GroupBy:
+			for i, e := range n {
+				if n[i], err = replaceValExpr(e, replace); err != nil {
+					break
+				}
+			}
+		case *ComparisonExpr:
+			if n.Left, err = replaceValExpr(n.Left, replace); err == nil {
+				n.Right, err = replaceValExpr(n.Right, replace)
+			}
+		case *RangeCond:
+			if n.Left, err = replaceValExpr(n.Left, replace); err == nil {
+				if n.From, err = replaceValExpr(n.From, replace); err == nil {
+					n.To, err = replaceValExpr(n.To, replace)
+				}
+			}
+		case *NullCheck:
+			n.Expr, err = replaceValExpr(n.Expr, replace)
+		case *BinaryExpr:
+			if n.Left, err = replaceExpr(n.Left, replace); err == nil {
+				n.Right, err = replaceExpr(n.Right, replace)
+			}
+		case *UnaryExpr:
+			n.Expr, err = replaceExpr(n.Expr, replace)
+		case *CaseExpr:
+			if n.Expr, err = replaceValExpr(n.Expr, replace); err == nil {
+				n.Else, err = replaceValExpr(n.Else, replace)
+			}
+		case *When:
+			n.Val, err = replaceValExpr(n.Val, replace)
+		case *UpdateExpr:
+			n.Expr, err = replaceValExpr(n.Expr, replace)
+		}
+		return err == nil, err
+	}, node)
+}
+
+func replaceExpr(expr Expr, replace func(ValExpr) (ValExpr, error)) (Expr, error) {
+	if v, ok := expr.(ValExpr); ok {
+		return replaceValExpr(v, replace)
+	}
+	return expr, nil
+}
+
+func replaceValExpr(expr ValExpr, replace func(ValExpr) (ValExpr, error)) (ValExpr, error) {
+	if isNilNode(expr) {
+		return expr, nil
+	}
+	newExpr, err := replace(expr)
+	if err != nil || newExpr == nil {
+		return expr, err
+	}
+	return newExpr, nil
+}
+
+// BindArgs replace '?' in statement by args in order, and parse it as a new statement.
+// It's used to route prepared statement by the value of shard key.
+func BindArgs(statement Statement, args []interface{}) (Statement, error) {
+	var pos int
+	var bindErr error
+	buf := NewTrackedBuffer(func(buf *TrackedBuffer, node SQLNode) {
+		arg, ok := node.(ValArg)
+		if !ok || string(arg) != "?" {
+			node.Format(buf)
+			return
+		}
+		if pos >= len(args) {
+			bindErr = fmt.Errorf("missing argument %d", pos+1)
+			return
+		}
+		v, err := sqltypes.BuildValue(bindArgValue(args[pos]))
+		if err != nil && bindErr == nil {
+			bindErr = err
+		}
+		v.EncodeSQL(buf)
+		pos++
+	})
+	buf.Fprintf("%v", statement)
+	if bindErr != nil {
+		return nil, bindErr
+	}
+	if pos != len(args) {
+		return nil, fmt.Errorf("expect %d arguments, but got %d", pos, len(args))
+	}
+	return Parse(buf.String())
+}
+
+// bindArgValue convert value of stmt execute request to type supported by sqltypes.
+func bindArgValue(arg interface{}) interface{} {
+	switch v := arg.(type) {
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case uint8:
+		return uint64(v)
+	case uint16:
+		return uint64(v)
+	case float32:
+		return float64(v)
+	}
+	return arg
+}
@@ -0,0 +1,205 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package sqlparser
+
+import "reflect"
+
+// Visit is called for each node in Walk.
+// If kontinue is false, the children of node will not be walked.
+type Visit func(node SQLNode) (kontinue bool, err error)
+
+// Walk calls visit on every node, in depth-first order.
+// Only select, dml statements and their expressions are walked into.
+func Walk(visit Visit, nodes ...SQLNode) error {
+	for _, node := range nodes {
+		if isNilNode(node) {
+			continue
+		}
+		kontinue, err := visit(node)
+		if err != nil {
+			return err
+		}
+		if kontinue {
+			if err = walkChildren(visit, node); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func walkChildren(visit Visit, node SQLNode) error {
+	switch n := node.(type) {
+	case *SimpleSelect:
+		return Walk(visit, n.SelectExprs, n.Limit)
+	case *Select:
+		return Walk(visit, n.With, n.SelectExprs, n.From, n.Where, n.GroupBy, n.Having, n.OrderBy, n.Limit)
+	case *Union:
+		return Walk(visit, n.With, n.Left, n.Right)
+	case *With:
+		for _, cte := range n.CTEs {
+			if err := Walk(visit, cte); err != nil {
+				return err
+			}
+		}
+	case *CommonTableExpr:
+		return Walk(visit, n.Columns, n.Select)
+	case *Insert:
+		return Walk(visit, n.Table, n.Columns, n.Rows, n.OnDup)
+	case *Update:
+		return Walk(visit, n.Table, n.TableExprs, n.Exprs, n.Where, n.OrderBy, n.Limit)
+	case *Delete:
+		// targets of multiple-table DELETE are names or aliases of tables in TableExprs.
+		return Walk(visit, n.Table, n.TableExprs, n.Where, n.OrderBy, n.Limit)
+	case *Replace:
+		return Walk(visit, n.Table, n.Columns, n.Rows)
+	case SelectExprs:
+		for _, e := range n {
+			if err := Walk(visit, e); err != nil {
+				return err
+			}
+		}
+	case Columns:
+		for _, e := range n {
+			if err := Walk(visit, e); err != nil {
+				return err
+			}
+		}
+	case *NonStarExpr:
+		return Walk(visit, n.Expr)
+	case TableExprs:
+		for _, e := range n {
+			if err := Walk(visit, e); err != nil {
+				return err
+			}
+		}
+	case *AliasedTableExpr:
+		return Walk(visit, n.Expr)
+	case *ParenTableExpr:
+		return Walk(visit, n.Expr)
+	case *JoinTableExpr:
+		return Walk(visit, n.LeftExpr, n.RightExpr, n.On)
+	case *Where:
+		return Walk(visit, n.Expr)
+	case *WhereExpr:
+		return Walk(visit, n.Expr)
+	case *LikeExpr:
+		return Walk(visit, n.Expr)
+	case *AndExpr:
+		return Walk(visit, n.Left, n.Right)
+	case *OrExpr:
+		return Walk(visit, n.Left, n.Right)
+	case *NotExpr:
+		return Walk(visit, n.Expr)
+	case *ParenBoolExpr:
+		return Walk(visit, n.Expr)
+	case *ComparisonExpr:
+		return Walk(visit, n.Left, n.Right)
+	case *RangeCond:
+		return Walk(visit, n.Left, n.From, n.To)
+	case *NullCheck:
+		return Walk(visit, n.Expr)
+	case *ExistsExpr:
+		return Walk(visit, n.Subquery)
+	case *Subquery:
+		return Walk(visit, n.Select)
+	case *QuantifiedExpr:
+		return Walk(visit, n.Subquery)
+	case ValTuple:
+		return Walk(visit, ValExprs(n))
+	case ValExprs:
+		for _, e := range n {
+			if err := Walk(visit, e); err != nil {
+				return err
+			}
+		}
+	case *BinaryExpr:
+		return Walk(visit, n.Left, n.Right)
+	case *UnaryExpr:
+		return Walk(visit, n.Expr)
+	case *FuncExpr:
+		return Walk(visit, n.Exprs)
+	case *WindowFuncExpr:
+		return Walk(visit, n.Func, n.Window)
+	case *WindowSpec:
+		return Walk(visit, n.PartitionBy, n.OrderBy)
+	case *CaseExpr:
+		if err := Walk(visit, n.Expr); err != nil {
+			return err
+		}
+		for _, when := range n.Whens {
+			if err := Walk(visit, when); err != nil {
+				return err
+			}
+		}
+		return Walk(visit, n.Else)
+	case *When:
+		return Walk(visit, n.Cond, n.Val)
+	case Values:
+		for _, e := range n {
+			if err := Walk(visit, e); err != nil {
+				return err
+			}
+		}
+	case GroupBy:
+		for _, e := range n {
+			if err := Walk(visit, e); err != nil {
+				return err
+			}
+		}
+	case OrderBy:
+		for _, e := range n {
+			if err := Walk(visit, e); err != nil {
+				return err
+			}
+		}
+	case *Order:
+		return Walk(visit, n.Expr)
+	case *Limit:
+		return Walk(visit, n.Offset, n.Rowcount)
+	case UpdateExprs:
+		for _, e := range n {
+			if err := Walk(visit, e); err != nil {
+				return err
+			}
+		}
+	case OnDup:
+		return Walk(visit, UpdateExprs(n))
+	case *UpdateExpr:
+		return Walk(visit, n.Name, n.Expr)
+	}
+	return nil
+}
+
+// isNilNode check nil interface, nil pointer and nil slice.
+func isNilNode(node SQLNode) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
@@ -1,5 +1,6 @@
-//line yacc.y:2
+// Code generated by goyacc -o yacc.go yacc.y. DO NOT EDIT.
 
+//line yacc.y:2
 // Copyright 2012, Google Inc. All rights reserved.
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
@@ -31,6 +32,7 @@ package sqlparser
 import __yyfmt__ "fmt"
 
 //line yacc.y:28
+
 import "bytes"
 
 // SetParseTree to build ast.
@@ -77,6 +79,7 @@ type yySymType struct {
 	tableExpr   TableExpr
 	smTableExpr SimpleTableExpr
 	tableName   *TableName
+	tableNames  TableNames
 	indexHints  *IndexHints
 	expr        Expr
 	boolExpr    BoolExpr
@@ -106,6 +109,14 @@ type yySymType struct {
 	fiOAfCol    *FirstOrAfterColumn
 	alterSpecs  AlterSpecifications
 	alterSpec   AlterSpecification
+	selOpts     selectOptions
+	cte         *CommonTableExpr
+	ctes        []*CommonTableExpr
+	windowSpec  *WindowSpec
+	frame       *WindowFrame
+	frameBound  *FrameBound
+	checkDef    *CreateCheckDefinition
+	refDef      *ReferenceDefinition
 }
 
 const LEX_ERROR = 57346
@@ -123,306 +134,326 @@ const LIMIT = 57357
 const FOR = 57358
 const ALL = 57359
 const DISTINCT = 57360
-const AS = 57361
-const EXISTS = 57362
-const NULL = 57363
-const ASC = 57364
-const DESC = 57365
-const VALUES = 57366
-const INTO = 57367
-const DUPLICATE = 57368
-const KEY = 57369
-const DEFAULT = 57370
-const SET = 57371
-const LOCK = 57372
-const SHOW = 57373
-const EXPLAIN = 57374
-const DESCRIBE = 57375
-const ID = 57376
-const STRING = 57377
-const NUMBER = 57378
-const VALUE_ARG = 57379
-const COMMENTS = 57380
-const UNION = 57381
-const MINUS = 57382
-const EXCEPT = 57383
-const INTERSECT = 57384
-const FULL = 57385
-const JOIN = 57386
-const STRAIGHT_JOIN = 57387
-const LEFT = 57388
-const RIGHT = 57389
-const INNER = 57390
-const OUTER = 57391
-const CROSS = 57392
-const NATURAL = 57393
-const USE = 57394
-const FORCE = 57395
-const ON = 57396
-const OR = 57397
-const AND = 57398
-const NOT = 57399
-const BETWEEN = 57400
-const CASE = 57401
-const WHEN = 57402
-const THEN = 57403
-const ELSE = 57404
-const LE = 57405
-const GE = 57406
-const NE = 57407
-const NULL_SAFE_EQUAL = 57408
-const IS = 57409
-const LIKE = 57410
-const IN = 57411
-const UNARY = 57412
-const END = 57413
-const BEGIN = 57414
-const START = 57415
-const TRANSACTION = 57416
-const COMMIT = 57417
-const ROLLBACK = 57418
-const ISOLATION = 57419
-const LEVEL = 57420
-const READ = 57421
-const COMMITTED = 57422
-const UNCOMMITTED = 57423
-const REPEATABLE = 57424
-const SERIALIZABLE = 57425
-const NAMES = 57426
-const CHARSET = 57427
-const CHARACTER = 57428
-const COLLATION = 57429
-const ARMSCII8 = 57430
-const ASCII = 57431
-const BIG5 = 57432
-const BINARY = 57433
-const CP1250 = 57434
-const CP1251 = 57435
-const CP1256 = 57436
-const CP1257 = 57437
-const CP850 = 57438
-const CP852 = 57439
-const CP866 = 57440
-const CP932 = 57441
-const DEC8 = 57442
-const EUCJPMS = 57443
-const EUCKR = 57444
-const GB2312 = 57445
-const GBK = 57446
-const GEOSTD8 = 57447
-const GREEK = 57448
-const HEBREW = 57449
-const HP8 = 57450
-const KEYBCS2 = 57451
-const KOI8R = 57452
-const KOI8U = 57453
-const LATIN1 = 57454
-const LATIN2 = 57455
-const LATIN5 = 57456
-const LATIN7 = 57457
-const MACCE = 57458
-const MACROMAN = 57459
-const SJIS = 57460
-const SWE7 = 57461
-const TIS620 = 57462
-const UCS2 = 57463
-const UJIS = 57464
-const UTF16 = 57465
-const UTF16LE = 57466
-const UTF32 = 57467
-const UTF8 = 57468
-const UTF8MB4 = 57469
-const ARMSCII8_GENERAL_CI = 57470
-const ARMSCII8_BIN = 57471
-const ASCII_GENERAL_CI = 57472
-const ASCII_BIN = 57473
-const BIG5_CHINESE_CI = 57474
-const BIG5_BIN = 57475
-const CP1250_GENERAL_CI = 57476
-const CP1250_BIN = 57477
-const CP1251_GENERAL_CI = 57478
-const CP1251_GENERAL_CS = 57479
-const CP1251_BIN = 57480
-const CP1256_GENERAL_CI = 57481
-const CP1256_BIN = 57482
-const CP1257_GENERAL_CI = 57483
-const CP1257_BIN = 57484
-const CP850_GENERAL_CI = 57485
-const CP850_BIN = 57486
-const CP852_GENERAL_CI = 57487
-const CP852_BIN = 57488
-const CP866_GENERAL_CI = 57489
-const CP866_BIN = 57490
-const CP932_JAPANESE_CI = 57491
-const CP932_BIN = 57492
-const DEC8_SWEDISH_CI = 57493
-const DEC8_BIN = 57494
-const EUCJPMS_JAPANESE_CI = 57495
-const EUCJPMS_BIN = 57496
-const EUCKR_KOREAN_CI = 57497
-const EUCKR_BIN = 57498
-const GB2312_CHINESE_CI = 57499
-const GB2312_BIN = 57500
-const GBK_CHINESE_CI = 57501
-const GBK_BIN = 57502
-const GEOSTD8_GENERAL_CI = 57503
-const GEOSTD8_BIN = 57504
-const GREEK_GENERAL_CI = 57505
-const GREEK_BIN = 57506
-const HEBREW_GENERAL_CI = 57507
-const HEBREW_BIN = 57508
-const HP8_ENGLISH_CI = 57509
-const HP8_BIN = 57510
-const KEYBCS2_GENERAL_CI = 57511
-const KEYBCS2_BIN = 57512
-const KOI8R_GENERAL_CI = 57513
-const KOI8R_BIN = 57514
-const KOI8U_GENERAL_CI = 57515
-const KOI8U_BIN = 57516
-const LATIN1_GENERAL_CI = 57517
-const LATIN1_GENERAL_CS = 57518
-const LATIN1_BIN = 57519
-const LATIN2_GENERAL_CI = 57520
-const LATIN2_BIN = 57521
-const LATIN5_TURKISH_CI = 57522
-const LATIN5_BIN = 57523
-const LATIN7_GENERAL_CI = 57524
-const LATIN7_GENERAL_CS = 57525
-const LATIN7_BIN = 57526
-const MACCE_GENERAL_CI = 57527
-const MACCE_BIN = 57528
-const MACROMAN_GENERAL_CI = 57529
-const MACROMAN_BIN = 57530
-const SJIS_JAPANESE_CI = 57531
-const SJIS_BIN = 57532
-const SWE7_SWEDISH_CI = 57533
-const SWE7_BIN = 57534
-const TIS620_THAI_CI = 57535
-const TIS620_BIN = 57536
-const UCS2_GENERAL_CI = 57537
-const UCS2_UNICODE_CI = 57538
-const UCS2_BIN = 57539
-const UJIS_JAPANESE_CI = 57540
-const UJIS_BIN = 57541
-const UTF16_GENERAL_CI = 57542
-const UTF16_UNICODE_CI = 57543
-const UTF16_BIN = 57544
-const UTF16LE_GENERAL_CI = 57545
-const UTF16LE_BIN = 57546
-const UTF32_GENERAL_CI = 57547
-const UTF32_UNICODE_CI = 57548
-const UTF32_BIN = 57549
-const UTF8_GENERAL_CI = 57550
-const UTF8_UNICODE_CI = 57551
-const UTF8_BIN = 57552
-const UTF8MB4_GENERAL_CI = 57553
-const UTF8MB4_UNICODE_CI = 57554
-const UTF8MB4_BIN = 57555
-const SESSION = 57556
-const GLOBAL = 57557
-const VARIABLES = 57558
-const STATUS = 57559
-const DATABASES = 57560
-const SCHEMAS = 57561
-const DATABASE = 57562
-const STORAGE = 57563
-const ENGINES = 57564
-const TABLES = 57565
-const COLUMNS = 57566
-const FIELDS = 57567
-const PROCEDURE = 57568
-const FUNCTION = 57569
-const INDEXES = 57570
-const KEYS = 57571
-const TRIGGER = 57572
-const TRIGGERS = 57573
-const PLUGINS = 57574
-const PROCESSLIST = 57575
-const SLAVE = 57576
-const PROFILES = 57577
-const REPLACE = 57578
-const OFFSET = 57579
-const COLLATE = 57580
-const CREATE = 57581
-const ALTER = 57582
-const DROP = 57583
-const RENAME = 57584
-const TABLE = 57585
-const INDEX = 57586
-const VIEW = 57587
-const TO = 57588
-const IGNORE = 57589
-const IF = 57590
-const UNIQUE = 57591
-const FULLTEXT = 57592
-const USING = 57593
-const BTREE = 57594
-const HASH = 57595
-const BIT = 57596
-const TINYINT = 57597
-const BOOL = 57598
-const BOOLEAN = 57599
-const SMALLINT = 57600
-const MEDIUMINT = 57601
-const INT = 57602
-const INTEGER = 57603
-const BIGINT = 57604
-const REAL = 57605
-const DOUBLE = 57606
-const FLOAT = 57607
-const DECIMAL = 57608
-const DATE = 57609
-const TIME = 57610
-const TIMESTAMP = 57611
-const DATETIME = 57612
-const YEAR = 57613
-const CHAR = 57614
-const NCHAR = 57615
-const VARCHAR = 57616
-const NVARCHAR = 57617
-const TINYTEXT = 57618
-const TEXT = 57619
-const MEDIUMTEXT = 57620
-const LONGTEXT = 57621
-const VARBINARY = 57622
-const TINYBLOB = 57623
-const BLOB = 57624
-const MEDIUMBLOB = 57625
-const LONGBLOB = 57626
-const ENUM = 57627
-const AUTO_INCREMENT = 57628
-const ENGINE = 57629
-const PRIMARY = 57630
-const REFERENCES = 57631
-const COMMENT = 57632
-const COLUMN_FORMAT = 57633
-const FIXED = 57634
-const DYNAMIC = 57635
-const DISK = 57636
-const MEMORY = 57637
-const MATCH = 57638
-const PARTIAL = 57639
-const SIMPLE = 57640
-const RESTRICT = 57641
-const CASCADE = 57642
-const NO = 57643
-const ACTION = 57644
-const UNSIGNED = 57645
-const ZEROFILL = 57646
-const CONSTRAINT = 57647
-const FOREIGN = 57648
-const FIRST = 57649
-const AFTER = 57650
-const ADD = 57651
-const COLUMN = 57652
-const CHANGE = 57653
-const MODIFY = 57654
-const ENABLE = 57655
-const DISABLE = 57656
-const KILL = 57657
-const QUERY = 57658
-const CONNECTION = 57659
-const POSITION = 57660
+const SQL_CALC_FOUND_ROWS = 57361
+const AS = 57362
+const EXISTS = 57363
+const NULL = 57364
+const ASC = 57365
+const DESC = 57366
+const VALUES = 57367
+const INTO = 57368
+const DUPLICATE = 57369
+const KEY = 57370
+const DEFAULT = 57371
+const SET = 57372
+const LOCK = 57373
+const SHOW = 57374
+const EXPLAIN = 57375
+const DESCRIBE = 57376
+const RECURSIVE = 57377
+const ANY = 57378
+const SOME = 57379
+const OVER = 57380
+const PARTITION = 57381
+const ROWS = 57382
+const RANGE = 57383
+const ROW = 57384
+const CURRENT = 57385
+const UNBOUNDED = 57386
+const PRECEDING = 57387
+const FOLLOWING = 57388
+const ID = 57389
+const STRING = 57390
+const NUMBER = 57391
+const VALUE_ARG = 57392
+const COMMENTS = 57393
+const WITH = 57394
+const UNION = 57395
+const MINUS = 57396
+const EXCEPT = 57397
+const INTERSECT = 57398
+const FULL = 57399
+const JOIN = 57400
+const STRAIGHT_JOIN = 57401
+const LEFT = 57402
+const RIGHT = 57403
+const INNER = 57404
+const OUTER = 57405
+const CROSS = 57406
+const NATURAL = 57407
+const USE = 57408
+const FORCE = 57409
+const ON = 57410
+const OR = 57411
+const AND = 57412
+const NOT = 57413
+const BETWEEN = 57414
+const CASE = 57415
+const WHEN = 57416
+const THEN = 57417
+const ELSE = 57418
+const LE = 57419
+const GE = 57420
+const NE = 57421
+const NULL_SAFE_EQUAL = 57422
+const IS = 57423
+const LIKE = 57424
+const IN = 57425
+const UNARY = 57426
+const END = 57427
+const BEGIN = 57428
+const START = 57429
+const TRANSACTION = 57430
+const COMMIT = 57431
+const ROLLBACK = 57432
+const ISOLATION = 57433
+const LEVEL = 57434
+const READ = 57435
+const COMMITTED = 57436
+const UNCOMMITTED = 57437
+const REPEATABLE = 57438
+const SERIALIZABLE = 57439
+const NAMES = 57440
+const CHARSET = 57441
+const CHARACTER = 57442
+const COLLATION = 57443
+const ARMSCII8 = 57444
+const ASCII = 57445
+const BIG5 = 57446
+const BINARY = 57447
+const CP1250 = 57448
+const CP1251 = 57449
+const CP1256 = 57450
+const CP1257 = 57451
+const CP850 = 57452
+const CP852 = 57453
+const CP866 = 57454
+const CP932 = 57455
+const DEC8 = 57456
+const EUCJPMS = 57457
+const EUCKR = 57458
+const GB2312 = 57459
+const GBK = 57460
+const GEOSTD8 = 57461
+const GREEK = 57462
+const HEBREW = 57463
+const HP8 = 57464
+const KEYBCS2 = 57465
+const KOI8R = 57466
+const KOI8U = 57467
+const LATIN1 = 57468
+const LATIN2 = 57469
+const LATIN5 = 57470
+const LATIN7 = 57471
+const MACCE = 57472
+const MACROMAN = 57473
+const SJIS = 57474
+const SWE7 = 57475
+const TIS620 = 57476
+const UCS2 = 57477
+const UJIS = 57478
+const UTF16 = 57479
+const UTF16LE = 57480
+const UTF32 = 57481
+const UTF8 = 57482
+const UTF8MB4 = 57483
+const ARMSCII8_GENERAL_CI = 57484
+const ARMSCII8_BIN = 57485
+const ASCII_GENERAL_CI = 57486
+const ASCII_BIN = 57487
+const BIG5_CHINESE_CI = 57488
+const BIG5_BIN = 57489
+const CP1250_GENERAL_CI = 57490
+const CP1250_BIN = 57491
+const CP1251_GENERAL_CI = 57492
+const CP1251_GENERAL_CS = 57493
+const CP1251_BIN = 57494
+const CP1256_GENERAL_CI = 57495
+const CP1256_BIN = 57496
+const CP1257_GENERAL_CI = 57497
+const CP1257_BIN = 57498
+const CP850_GENERAL_CI = 57499
+const CP850_BIN = 57500
+const CP852_GENERAL_CI = 57501
+const CP852_BIN = 57502
+const CP866_GENERAL_CI = 57503
+const CP866_BIN = 57504
+const CP932_JAPANESE_CI = 57505
+const CP932_BIN = 57506
+const DEC8_SWEDISH_CI = 57507
+const DEC8_BIN = 57508
+const EUCJPMS_JAPANESE_CI = 57509
+const EUCJPMS_BIN = 57510
+const EUCKR_KOREAN_CI = 57511
+const EUCKR_BIN = 57512
+const GB2312_CHINESE_CI = 57513
+const GB2312_BIN = 57514
+const GBK_CHINESE_CI = 57515
+const GBK_BIN = 57516
+const GEOSTD8_GENERAL_CI = 57517
+const GEOSTD8_BIN = 57518
+const GREEK_GENERAL_CI = 57519
+const GREEK_BIN = 57520
+const HEBREW_GENERAL_CI = 57521
+const HEBREW_BIN = 57522
+const HP8_ENGLISH_CI = 57523
+const HP8_BIN = 57524
+const KEYBCS2_GENERAL_CI = 57525
+const KEYBCS2_BIN = 57526
+const KOI8R_GENERAL_CI = 57527
+const KOI8R_BIN = 57528
+const KOI8U_GENERAL_CI = 57529
+const KOI8U_BIN = 57530
+const LATIN1_GENERAL_CI = 57531
+const LATIN1_GENERAL_CS = 57532
+const LATIN1_BIN = 57533
+const LATIN2_GENERAL_CI = 57534
+const LATIN2_BIN = 57535
+const LATIN5_TURKISH_CI = 57536
+const LATIN5_BIN = 57537
+const LATIN7_GENERAL_CI = 57538
+const LATIN7_GENERAL_CS = 57539
+const LATIN7_BIN = 57540
+const MACCE_GENERAL_CI = 57541
+const MACCE_BIN = 57542
+const MACROMAN_GENERAL_CI = 57543
+const MACROMAN_BIN = 57544
+const SJIS_JAPANESE_CI = 57545
+const SJIS_BIN = 57546
+const SWE7_SWEDISH_CI = 57547
+const SWE7_BIN = 57548
+const TIS620_THAI_CI = 57549
+const TIS620_BIN = 57550
+const UCS2_GENERAL_CI = 57551
+const UCS2_UNICODE_CI = 57552
+const UCS2_BIN = 57553
+const UJIS_JAPANESE_CI = 57554
+const UJIS_BIN = 57555
+const UTF16_GENERAL_CI = 57556
+const UTF16_UNICODE_CI = 57557
+const UTF16_BIN = 57558
+const UTF16LE_GENERAL_CI = 57559
+const UTF16LE_BIN = 57560
+const UTF32_GENERAL_CI = 57561
+const UTF32_UNICODE_CI = 57562
+const UTF32_BIN = 57563
+const UTF8_GENERAL_CI = 57564
+const UTF8_UNICODE_CI = 57565
+const UTF8_BIN = 57566
+const UTF8MB4_GENERAL_CI = 57567
+const UTF8MB4_UNICODE_CI = 57568
+const UTF8MB4_BIN = 57569
+const SESSION = 57570
+const GLOBAL = 57571
+const VARIABLES = 57572
+const STATUS = 57573
+const DATABASES = 57574
+const SCHEMAS = 57575
+const DATABASE = 57576
+const STORAGE = 57577
+const ENGINES = 57578
+const TABLES = 57579
+const COLUMNS = 57580
+const FIELDS = 57581
+const PROCEDURE = 57582
+const FUNCTION = 57583
+const INDEXES = 57584
+const KEYS = 57585
+const TRIGGER = 57586
+const TRIGGERS = 57587
+const PLUGINS = 57588
+const PROCESSLIST = 57589
+const SLAVE = 57590
+const PROFILES = 57591
+const REPLACE = 57592
+const OFFSET = 57593
+const COLLATE = 57594
+const CREATE = 57595
+const ALTER = 57596
+const DROP = 57597
+const RENAME = 57598
+const TABLE = 57599
+const INDEX = 57600
+const VIEW = 57601
+const TO = 57602
+const IGNORE = 57603
+const IF = 57604
+const UNIQUE = 57605
+const FULLTEXT = 57606
+const USING = 57607
+const BTREE = 57608
+const HASH = 57609
+const BIT = 57610
+const TINYINT = 57611
+const BOOL = 57612
+const BOOLEAN = 57613
+const SMALLINT = 57614
+const MEDIUMINT = 57615
+const INT = 57616
+const INTEGER = 57617
+const BIGINT = 57618
+const REAL = 57619
+const DOUBLE = 57620
+const FLOAT = 57621
+const DECIMAL = 57622
+const DATE = 57623
+const TIME = 57624
+const TIMESTAMP = 57625
+const DATETIME = 57626
+const YEAR = 57627
+const CHAR = 57628
+const NCHAR = 57629
+const VARCHAR = 57630
+const NVARCHAR = 57631
+const TINYTEXT = 57632
+const TEXT = 57633
+const MEDIUMTEXT = 57634
+const LONGTEXT = 57635
+const VARBINARY = 57636
+const TINYBLOB = 57637
+const BLOB = 57638
+const MEDIUMBLOB = 57639
+const LONGBLOB = 57640
+const ENUM = 57641
+const AUTO_INCREMENT = 57642
+const ENGINE = 57643
+const PRIMARY = 57644
+const REFERENCES = 57645
+const COMMENT = 57646
+const COLUMN_FORMAT = 57647
+const FIXED = 57648
+const DYNAMIC = 57649
+const DISK = 57650
+const MEMORY = 57651
+const MATCH = 57652
+const PARTIAL = 57653
+const SIMPLE = 57654
+const RESTRICT = 57655
+const CASCADE = 57656
+const NO = 57657
+const ACTION = 57658
+const UNSIGNED = 57659
+const ZEROFILL = 57660
+const CONSTRAINT = 57661
+const FOREIGN = 57662
+const FIRST = 57663
+const AFTER = 57664
+const ADD = 57665
+const COLUMN = 57666
+const CHANGE = 57667
+const MODIFY = 57668
+const ENABLE = 57669
+const DISABLE = 57670
+const GENERATED = 57671
+const ALWAYS = 57672
+const VIRTUAL = 57673
+const STORED = 57674
+const CHECK = 57675
+const ENFORCED = 57676
+const KILL = 57677
+const QUERY = 57678
+const CONNECTION = 57679
+const POSITION = 57680
 
 var yyToknames = [...]string{
 	"$end",
@@ -443,6 +474,7 @@ var yyToknames = [...]string{
 	"FOR",
 	"ALL",
 	"DISTINCT",
+	"SQL_CALC_FOUND_ROWS",
 	"AS",
 	"EXISTS",
 	"NULL",
@@ -458,6 +490,18 @@ var yyToknames = [...]string{
 	"SHOW",
 	"EXPLAIN",
 	"DESCRIBE",
+	"RECURSIVE",
+	"ANY",
+	"SOME",
+	"OVER",
+	"PARTITION",
+	"ROWS",
+	"RANGE",
+	"ROW",
+	"CURRENT",
+	"UNBOUNDED",
+	"PRECEDING",
+	"FOLLOWING",
 	"ID",
 	"STRING",
 	"NUMBER",
@@ -465,6 +509,7 @@ var yyToknames = [...]string{
 	"COMMENTS",
 	"'('",
 	"'~'",
+	"WITH",
 	"UNION",
 	"MINUS",
 	"EXCEPT",
@@ -754,12 +799,19 @@ var yyToknames = [...]string{
 	"MODIFY",
 	"ENABLE",
 	"DISABLE",
+	"GENERATED",
+	"ALWAYS",
+	"VIRTUAL",
+	"STORED",
+	"CHECK",
+	"ENFORCED",
 	"KILL",
 	"QUERY",
 	"CONNECTION",
 	"POSITION",
 	"')'",
 }
+
 var yyStatenames = [...]string{}
 
 const yyEofCode = 1
@@ -767,253 +819,327 @@ const yyErrCode = 2
 const yyInitialStackSize = 16
 
 //line yacctab:1
-var yyExca = [...]int{
+var yyExca = [...]int16{
 	-1, 1,
 	1, -1,
 	-2, 0,
+	-1, 290,
+	59, 42,
+	280, 42,
+	-2, 163,
+	-1, 654,
+	348, 581,
+	-2, 460,
+	-1, 655,
+	20, 472,
+	-2, 536,
 }
 
-const yyNprod = 560
 const yyPrivate = 57344
 
-var yyTokenNames []string
-var yyStates []string
+const yyLast = 2240
 
-const yyLast = 1640
-
-var yyAct = [...]int{
-
-	153, 446, 1063, 424, 885, 170, 1064, 142, 1024, 740,
-	747, 837, 902, 874, 637, 256, 826, 748, 436, 749,
-	163, 976, 887, 567, 290, 499, 351, 143, 573, 930,
-	884, 779, 429, 144, 154, 412, 428, 562, 501, 337,
-	77, 415, 354, 137, 392, 907, 291, 3, 261, 339,
-	42, 43, 44, 45, 1056, 955, 116, 955, 116, 265,
-	264, 273, 272, 275, 276, 277, 278, 279, 274, 455,
-	456, 457, 458, 459, 423, 460, 461, 60, 955, 1043,
-	1041, 955, 955, 955, 955, 955, 955, 388, 83, 167,
-	152, 755, 955, 162, 1040, 955, 1039, 211, 115, 939,
-	119, 938, 955, 168, 149, 150, 151, 955, 294, 157,
-	937, 955, 936, 935, 933, 955, 166, 972, 972, 972,
-	439, 116, 116, 955, 929, 944, 944, 928, 116, 927,
-	255, 160, 921, 920, 926, 572, 919, 262, 382, 918,
-	148, 152, 917, 916, 162, 915, 497, 155, 156, 386,
-	472, 515, 514, 861, 135, 149, 150, 151, 621, 141,
-	157, 889, 890, 246, 247, 610, 80, 838, 382, 382,
-	252, 382, 757, 512, 533, 306, 1102, 977, 908, 1055,
-	140, 750, 160, 295, 287, 289, 425, 449, 506, 507,
-	254, 775, 441, 440, 249, 122, 773, 620, 155, 156,
-	134, 124, 125, 771, 609, 165, 769, 767, 765, 118,
-	346, 763, 761, 734, 759, 622, 756, 751, 1067, 733,
-	519, 732, 611, 116, 753, 250, 828, 251, 753, 116,
-	116, 128, 129, 116, 116, 116, 116, 116, 116, 116,
-	116, 116, 116, 1105, 334, 116, 167, 127, 931, 116,
-	307, 344, 116, 439, 116, 522, 752, 521, 116, 310,
-	503, 359, 114, 133, 360, 313, 336, 168, 1025, 781,
-	1028, 320, 321, 166, 751, 324, 325, 326, 327, 328,
-	329, 330, 331, 332, 333, 217, 218, 335, 52, 51,
-	242, 342, 355, 231, 345, 230, 347, 227, 395, 53,
-	561, 364, 54, 167, 79, 862, 359, 303, 361, 362,
-	76, 526, 525, 752, 546, 548, 116, 116, 116, 736,
-	116, 556, 387, 263, 390, 441, 440, 305, 168, 260,
-	166, 78, 161, 213, 243, 380, 353, 274, 78, 559,
-	560, 167, 167, 383, 1100, 442, 1086, 116, 433, 1022,
-	116, 813, 635, 262, 116, 79, 549, 634, 399, 400,
-	401, 418, 402, 393, 405, 406, 407, 1085, 166, 420,
-	1082, 1081, 1058, 1057, 1051, 1050, 411, 223, 224, 225,
-	463, 1020, 414, 161, 1015, 409, 417, 226, 355, 444,
-	452, 1014, 451, 438, 437, 78, 1009, 443, 633, 466,
-	1008, 462, 158, 385, 1007, 511, 974, 973, 971, 783,
-	22, 167, 954, 474, 946, 945, 430, 531, 431, 432,
-	435, 434, 167, 925, 571, 148, 152, 554, 492, 162,
-	216, 490, 219, 220, 221, 496, 478, 480, 166, 168,
-	149, 150, 151, 530, 141, 157, 529, 491, 509, 500,
-	495, 489, 518, 158, 116, 116, 417, 476, 473, 608,
-	381, 82, 81, 827, 527, 140, 504, 160, 79, 513,
-	757, 510, 447, 448, 450, 757, 781, 524, 442, 523,
-	536, 537, 757, 155, 156, 757, 757, 757, 355, 355,
-	757, 757, 502, 757, 304, 757, 517, 750, 167, 1065,
-	1066, 750, 829, 612, 613, 614, 116, 565, 394, 1106,
-	1107, 167, 618, 619, 520, 167, 167, 167, 516, 627,
-	628, 547, 356, 117, 630, 570, 438, 437, 564, 79,
-	443, 78, 79, 808, 797, 636, 597, 343, 617, 79,
-	78, 78, 623, 624, 625, 616, 308, 168, 615, 1026,
-	1027, 311, 312, 504, 750, 264, 236, 314, 22, 215,
-	780, 318, 239, 240, 322, 323, 241, 91, 90, 89,
-	265, 264, 367, 167, 148, 152, 558, 358, 162, 237,
-	482, 238, 370, 483, 484, 366, 365, 78, 168, 149,
-	150, 151, 353, 141, 157, 1113, 79, 126, 746, 393,
-	500, 479, 164, 743, 745, 275, 276, 277, 278, 279,
-	274, 795, 1112, 302, 140, 1104, 160, 277, 278, 279,
-	274, 371, 806, 214, 167, 563, 782, 781, 265, 264,
-	812, 563, 155, 156, 505, 788, 789, 790, 791, 814,
-	79, 816, 349, 540, 88, 538, 802, 398, 541, 815,
-	539, 810, 731, 811, 403, 404, 455, 456, 457, 458,
-	459, 408, 460, 461, 730, 544, 319, 215, 161, 543,
-	542, 758, 760, 762, 764, 766, 768, 770, 772, 774,
-	272, 275, 276, 277, 278, 279, 274, 799, 800, 315,
-	215, 222, 215, 803, 804, 97, 21, 92, 93, 575,
-	576, 577, 578, 579, 580, 581, 582, 583, 584, 585,
-	586, 587, 588, 589, 590, 591, 592, 593, 594, 595,
-	596, 603, 604, 605, 606, 598, 599, 600, 601, 602,
-	607, 214, 79, 485, 486, 487, 488, 302, 158, 924,
-	923, 79, 79, 922, 338, 87, 338, 382, 79, 42,
-	43, 44, 45, 819, 214, 739, 214, 569, 508, 830,
-	832, 835, 817, 825, 509, 807, 796, 833, 831, 257,
-	840, 818, 842, 820, 844, 259, 846, 410, 848, 453,
-	850, 302, 852, 1096, 854, 340, 856, 341, 79, 79,
-	1019, 1018, 1006, 741, 742, 341, 1005, 879, 880, 152,
-	875, 875, 167, 963, 962, 258, 948, 876, 895, 896,
-	957, 947, 897, 149, 150, 151, 892, 161, 891, 900,
-	883, 882, 903, 903, 903, 881, 823, 822, 464, 886,
-	821, 801, 793, 901, 899, 904, 905, 792, 787, 898,
-	786, 785, 912, 784, 914, 778, 777, 911, 776, 913,
-	754, 294, 421, 299, 552, 298, 297, 296, 46, 273,
-	272, 275, 276, 277, 278, 279, 274, 1013, 993, 877,
-	878, 273, 272, 275, 276, 277, 278, 279, 274, 991,
-	893, 894, 167, 167, 167, 990, 989, 158, 869, 868,
-	167, 167, 167, 167, 934, 867, 956, 866, 167, 288,
-	940, 941, 942, 943, 865, 863, 860, 167, 859, 886,
-	886, 886, 951, 952, 953, 858, 857, 958, 959, 886,
-	886, 967, 960, 961, 855, 886, 853, 975, 966, 980,
-	851, 982, 849, 847, 166, 845, 864, 843, 995, 841,
-	839, 836, 870, 871, 872, 873, 994, 631, 167, 167,
-	131, 130, 1000, 978, 949, 950, 167, 979, 1011, 981,
-	1012, 725, 397, 167, 167, 996, 969, 997, 998, 999,
-	964, 965, 932, 1023, 10, 886, 886, 632, 1003, 1004,
-	970, 528, 245, 886, 9, 138, 212, 8, 169, 910,
-	886, 886, 909, 1016, 1017, 1033, 1034, 1035, 1036, 1037,
-	1038, 1029, 824, 1031, 1042, 63, 167, 167, 983, 984,
-	985, 986, 987, 988, 1054, 64, 809, 992, 62, 167,
-	167, 805, 7, 1062, 15, 798, 1030, 1061, 1032, 794,
-	14, 1048, 1049, 886, 886, 465, 1052, 1053, 1068, 13,
-	1070, 292, 629, 626, 738, 293, 886, 886, 12, 1059,
-	1060, 6, 1069, 61, 1071, 71, 116, 244, 5, 4,
-	301, 70, 22, 1087, 121, 834, 1084, 741, 742, 532,
-	69, 1088, 22, 1090, 1076, 1077, 1078, 1079, 1089, 68,
-	1091, 416, 67, 470, 1092, 1093, 1094, 1095, 152, 66,
-	65, 162, 1097, 422, 1098, 348, 1080, 167, 1083, 1001,
-	1002, 168, 149, 150, 151, 86, 294, 157, 84, 259,
-	1110, 1111, 728, 493, 309, 413, 1116, 1117, 727, 535,
-	338, 1109, 1108, 1115, 886, 317, 101, 1099, 316, 160,
-	235, 1072, 1073, 1074, 234, 1075, 22, 26, 27, 28,
-	48, 22, 233, 232, 229, 155, 156, 228, 120, 1114,
-	1044, 1045, 1046, 1047, 741, 742, 1021, 906, 138, 357,
-	23, 888, 24, 30, 25, 744, 363, 574, 426, 368,
-	369, 427, 372, 373, 374, 375, 376, 377, 378, 379,
-	95, 94, 96, 498, 445, 1103, 39, 152, 1101, 481,
-	162, 1010, 123, 248, 384, 253, 419, 384, 389, 384,
-	168, 149, 150, 151, 396, 294, 157, 968, 273, 272,
-	275, 276, 277, 278, 279, 274, 566, 726, 35, 36,
-	534, 37, 38, 22, 26, 27, 28, 477, 160, 475,
-	273, 272, 275, 276, 277, 278, 279, 274, 469, 300,
-	146, 391, 147, 145, 155, 156, 159, 23, 494, 24,
-	266, 25, 139, 545, 352, 273, 272, 275, 276, 277,
-	278, 279, 274, 454, 350, 47, 467, 468, 273, 272,
-	275, 276, 277, 278, 279, 274, 136, 455, 456, 457,
-	458, 459, 471, 460, 461, 132, 85, 729, 384, 49,
-	50, 55, 56, 57, 58, 59, 41, 72, 73, 74,
-	75, 20, 79, 11, 19, 18, 17, 16, 2, 92,
-	93, 1, 0, 98, 99, 0, 0, 0, 100, 103,
-	104, 105, 106, 108, 109, 0, 110, 0, 112, 113,
-	161, 0, 0, 0, 111, 268, 270, 0, 102, 107,
-	0, 280, 281, 282, 283, 284, 285, 286, 271, 269,
-	267, 273, 272, 275, 276, 277, 278, 279, 274, 0,
-	0, 0, 0, 0, 550, 551, 0, 0, 0, 553,
-	0, 0, 0, 0, 0, 555, 0, 0, 0, 557,
-	0, 0, 29, 0, 0, 31, 32, 34, 33, 0,
-	0, 0, 0, 568, 0, 0, 0, 0, 0, 0,
-	158, 79, 0, 0, 0, 0, 0, 0, 0, 0,
+var yyAct = [...]int16{
+	176, 1188, 526, 933, 1147, 843, 1049, 1189, 831, 948,
+	923, 1024, 503, 165, 997, 723, 1000, 852, 653, 908,
+	985, 166, 200, 516, 193, 996, 844, 647, 159, 303,
+	489, 335, 494, 507, 581, 508, 1095, 579, 842, 160,
+	584, 80, 135, 349, 191, 876, 167, 642, 459, 636,
+	1075, 103, 310, 309, 502, 310, 309, 139, 22, 132,
+	1180, 132, 132, 1167, 402, 289, 3, 318, 317, 320,
+	321, 322, 323, 324, 319, 278, 279, 280, 281, 282,
+	1075, 283, 284, 43, 44, 45, 46, 1165, 1164, 83,
+	1163, 84, 85, 81, 1156, 1136, 83, 63, 84, 85,
+	198, 310, 309, 1058, 1057, 197, 1075, 23, 94, 1056,
+	1055, 177, 43, 44, 45, 46, 131, 22, 1075, 143,
+	1054, 22, 27, 28, 29, 1075, 196, 1052, 1048, 1047,
+	241, 1046, 1075, 43, 44, 45, 46, 1040, 286, 132,
+	421, 1075, 132, 1039, 1038, 132, 24, 1037, 25, 31,
+	26, 132, 1036, 302, 1075, 83, 1075, 84, 85, 81,
+	286, 1035, 1034, 922, 138, 544, 23, 189, 1075, 1011,
+	23, 693, 140, 946, 597, 599, 1075, 43, 44, 45,
+	46, 43, 44, 45, 46, 40, 598, 910, 596, 1075,
+	585, 103, 972, 197, 1075, 1075, 1075, 949, 1075, 290,
+	195, 854, 294, 332, 334, 1198, 1199, 619, 299, 1063,
+	1063, 1002, 1003, 1045, 350, 827, 827, 36, 37, 827,
+	38, 39, 355, 1234, 1096, 652, 463, 1025, 1179, 872,
+	448, 463, 846, 448, 703, 870, 587, 588, 504, 448,
+	301, 145, 296, 134, 463, 448, 91, 147, 148, 414,
+	140, 488, 603, 132, 847, 529, 151, 152, 293, 132,
+	132, 849, 156, 132, 132, 132, 132, 132, 132, 132,
+	132, 132, 132, 702, 383, 132, 197, 132, 132, 849,
+	868, 866, 864, 340, 847, 359, 862, 860, 399, 1151,
+	487, 704, 486, 132, 132, 82, 132, 196, 412, 132,
+	385, 132, 82, 297, 693, 132, 848, 298, 422, 858,
+	362, 856, 1237, 150, 1192, 853, 369, 370, 1050, 593,
+	373, 374, 375, 376, 377, 378, 379, 380, 381, 382,
+	935, 821, 384, 608, 1103, 405, 848, 1071, 356, 407,
+	130, 607, 1148, 55, 54, 1232, 142, 418, 272, 423,
+	424, 406, 197, 410, 56, 422, 413, 57, 415, 430,
+	261, 82, 253, 254, 255, 132, 132, 132, 400, 132,
+	247, 248, 256, 196, 454, 1214, 457, 260, 691, 257,
+	973, 30, 1023, 446, 32, 33, 35, 34, 140, 140,
+	86, 87, 88, 89, 878, 90, 285, 86, 87, 88,
+	89, 1213, 90, 635, 140, 132, 465, 396, 398, 197,
+	197, 932, 352, 1210, 243, 132, 140, 690, 132, 79,
+	1209, 630, 469, 470, 471, 102, 472, 1183, 354, 479,
+	350, 196, 638, 307, 499, 692, 1182, 273, 475, 476,
+	477, 555, 287, 491, 556, 557, 136, 492, 693, 1175,
+	482, 1174, 498, 633, 634, 319, 86, 87, 88, 89,
+	461, 90, 909, 1143, 197, 83, 41, 84, 85, 81,
+	493, 1138, 524, 496, 497, 531, 576, 583, 536, 404,
+	401, 197, 197, 532, 1137, 553, 1145, 546, 291, 1131,
+	1130, 1128, 83, 1074, 84, 85, 198, 602, 915, 721,
+	552, 197, 350, 196, 1065, 1064, 565, 550, 1044, 854,
+	829, 828, 562, 566, 826, 854, 140, 720, 906, 571,
+	651, 639, 580, 567, 564, 628, 577, 246, 548, 249,
+	250, 251, 590, 83, 545, 84, 85, 81, 535, 462,
+	447, 601, 575, 519, 594, 496, 563, 846, 93, 92,
+	595, 97, 464, 527, 528, 530, 308, 573, 606, 604,
+	854, 854, 854, 600, 592, 846, 854, 854, 419, 643,
+	643, 643, 605, 626, 880, 877, 850, 846, 1238, 1239,
+	934, 1149, 1150, 694, 695, 288, 696, 132, 719, 854,
+	617, 854, 197, 700, 701, 854, 641, 705, 706, 197,
+	197, 197, 411, 711, 712, 616, 714, 715, 1190, 1191,
+	716, 612, 611, 699, 397, 640, 347, 644, 645, 936,
+	707, 708, 709, 698, 245, 585, 266, 433, 521, 520,
+	591, 615, 269, 270, 368, 245, 271, 610, 722, 609,
+	432, 431, 878, 878, 697, 107, 106, 105, 353, 267,
+	186, 268, 460, 197, 551, 364, 245, 357, 460, 820,
+	133, 1092, 360, 361, 322, 323, 324, 319, 363, 252,
+	245, 82, 367, 847, 580, 371, 372, 309, 855, 857,
+	859, 861, 863, 865, 867, 869, 871, 837, 824, 841,
+	838, 436, 892, 310, 309, 840, 834, 351, 82, 632,
+	310, 309, 244, 1245, 904, 1244, 1236, 637, 197, 637,
+	586, 417, 351, 244, 914, 390, 835, 485, 900, 484,
+	391, 879, 582, 386, 387, 848, 911, 913, 394, 912,
+	885, 886, 887, 888, 244, 388, 917, 149, 393, 82,
+	389, 392, 916, 437, 918, 832, 833, 292, 244, 897,
+	898, 43, 44, 45, 46, 901, 902, 320, 321, 322,
+	323, 324, 319, 905, 613, 1043, 86, 87, 88, 89,
+	275, 90, 1042, 533, 1041, 108, 109, 468, 448, 830,
+	649, 522, 304, 585, 473, 474, 589, 1226, 306, 403,
+	47, 478, 403, 86, 87, 88, 89, 293, 90, 276,
+	22, 104, 1142, 624, 318, 317, 320, 321, 322, 323,
+	324, 319, 318, 317, 320, 321, 322, 323, 324, 319,
+	318, 317, 320, 321, 322, 323, 324, 319, 930, 518,
+	517, 22, 305, 523, 86, 87, 88, 89, 276, 90,
+	1141, 351, 481, 919, 22, 1127, 921, 832, 833, 23,
+	940, 495, 409, 920, 190, 113, 1126, 1084, 931, 1083,
+	1067, 590, 175, 943, 480, 951, 1066, 953, 1032, 955,
+	938, 957, 1010, 959, 408, 961, 1077, 963, 1135, 965,
+	23, 967, 937, 939, 558, 559, 560, 561, 172, 173,
+	174, 929, 945, 23, 990, 991, 944, 1005, 1004, 995,
+	197, 994, 992, 986, 986, 539, 1008, 1009, 899, 896,
+	890, 987, 889, 884, 883, 1020, 1021, 882, 881, 875,
+	874, 998, 318, 317, 320, 321, 322, 323, 324, 319,
+	1013, 1012, 873, 851, 338, 1016, 993, 825, 1014, 650,
+	570, 1028, 569, 1030, 568, 341, 988, 989, 500, 1015,
+	278, 279, 280, 281, 282, 333, 283, 284, 1006, 1007,
+	318, 317, 320, 321, 322, 323, 324, 319, 547, 318,
+	317, 320, 321, 322, 323, 324, 319, 1029, 451, 1031,
+	534, 344, 1053, 343, 342, 1033, 192, 21, 1059, 1060,
+	1061, 1062, 1114, 197, 1112, 197, 197, 1111, 1110, 980,
+	979, 978, 977, 197, 197, 197, 197, 1076, 976, 974,
+	971, 197, 970, 969, 998, 968, 998, 998, 1070, 966,
+	1072, 1073, 175, 197, 1079, 1080, 998, 998, 1078, 1088,
+	1081, 1082, 998, 1099, 964, 1101, 1087, 100, 962, 960,
+	1098, 958, 1100, 956, 196, 1068, 1069, 1093, 172, 173,
+	174, 954, 161, 1104, 1105, 1106, 1107, 1108, 1109, 952,
+	950, 1102, 1113, 1085, 1086, 947, 1115, 197, 197, 717,
+	154, 153, 975, 1094, 1116, 1097, 197, 1121, 981, 982,
+	983, 984, 811, 467, 197, 197, 1019, 1133, 998, 998,
+	1134, 1129, 1124, 1125, 1017, 1018, 543, 998, 1117, 339,
+	1118, 1119, 1120, 1146, 51, 998, 998, 818, 819, 1139,
+	1140, 1153, 10, 1155, 1157, 1158, 1159, 1160, 1161, 1162,
+	336, 1051, 9, 1166, 337, 1090, 8, 197, 197, 1172,
+	1173, 718, 614, 7, 15, 1152, 1178, 1154, 14, 346,
+	1091, 13, 197, 197, 66, 1181, 1187, 12, 998, 998,
+	242, 1186, 1176, 1177, 67, 6, 199, 1193, 65, 1195,
+	1027, 5, 4, 998, 998, 64, 74, 1184, 1185, 1026,
+	73, 928, 907, 72, 903, 1204, 1205, 1206, 1207, 71,
+	132, 1200, 1201, 1202, 895, 1203, 1208, 70, 1215, 1212,
+	1194, 891, 1196, 69, 68, 1216, 713, 1218, 710, 1217,
+	358, 1219, 823, 1221, 274, 144, 832, 833, 942, 501,
+	1222, 1223, 1224, 1225, 618, 894, 540, 416, 941, 1227,
+	348, 1228, 99, 98, 1229, 188, 187, 197, 95, 306,
+	83, 814, 84, 85, 81, 627, 572, 1211, 490, 813,
+	621, 1231, 403, 1242, 1243, 1241, 1240, 1247, 998, 1248,
+	1249, 366, 1230, 365, 265, 264, 263, 1122, 1123, 1246,
+	262, 161, 420, 259, 258, 1144, 1022, 425, 49, 1001,
+	434, 435, 836, 438, 439, 440, 441, 442, 443, 444,
+	445, 655, 654, 1220, 505, 22, 317, 320, 321, 322,
+	323, 324, 319, 83, 449, 84, 85, 198, 449, 456,
+	449, 171, 175, 506, 578, 185, 525, 1233, 1235, 466,
+	1168, 1169, 1170, 1171, 839, 1197, 554, 455, 1132, 146,
+	845, 175, 295, 83, 185, 84, 85, 198, 172, 173,
+	174, 300, 164, 180, 23, 1089, 646, 812, 620, 549,
+	345, 817, 83, 816, 84, 85, 198, 172, 173, 174,
+	429, 338, 180, 194, 163, 175, 183, 83, 185, 84,
+	85, 198, 542, 450, 169, 458, 999, 170, 168, 427,
+	428, 182, 178, 179, 574, 183, 83, 426, 84, 85,
+	198, 172, 173, 174, 311, 338, 180, 162, 537, 538,
+	395, 178, 179, 453, 318, 317, 320, 321, 322, 323,
+	324, 319, 137, 277, 541, 155, 171, 175, 101, 183,
+	185, 449, 50, 83, 96, 84, 85, 81, 42, 20,
+	11, 19, 138, 18, 17, 178, 179, 16, 83, 2,
+	84, 85, 158, 172, 173, 174, 82, 164, 180, 1,
+	0, 0, 0, 171, 175, 0, 0, 185, 278, 279,
+	280, 281, 282, 0, 283, 284, 0, 0, 483, 163,
+	893, 183, 0, 0, 0, 83, 0, 84, 85, 198,
+	172, 173, 174, 0, 164, 180, 0, 178, 179, 157,
+	0, 0, 0, 0, 0, 0, 0, 141, 0, 622,
+	623, 0, 0, 0, 0, 625, 163, 0, 183, 82,
+	0, 0, 0, 629, 0, 0, 0, 631, 0, 0,
+	0, 0, 0, 0, 178, 179, 0, 0, 0, 175,
+	0, 83, 185, 84, 85, 81, 0, 0, 648, 82,
+	0, 86, 87, 88, 89, 0, 90, 0, 0, 0,
+	926, 925, 84, 85, 198, 172, 173, 174, 82, 338,
+	180, 0, 0, 0, 0, 0, 83, 184, 84, 85,
+	81, 0, 0, 82, 0, 0, 83, 0, 84, 85,
+	198, 0, 924, 183, 0, 0, 184, 0, 48, 0,
+	815, 0, 82, 449, 0, 0, 0, 0, 822, 178,
+	179, 0, 0, 0, 86, 87, 88, 89, 0, 90,
+	0, 0, 0, 52, 53, 58, 59, 60, 61, 62,
+	184, 75, 76, 77, 78, 0, 0, 0, 0, 82,
+	0, 0, 0, 0, 86, 87, 88, 89, 0, 90,
+	0, 0, 0, 181, 82, 0, 519, 0, 0, 0,
+	0, 0, 0, 86, 87, 88, 89, 0, 90, 0,
+	0, 0, 181, 452, 0, 0, 0, 0, 86, 87,
+	88, 89, 184, 90, 22, 0, 0, 0, 0, 0,
+	175, 82, 0, 185, 0, 0, 0, 86, 87, 88,
+	89, 175, 90, 0, 185, 0, 181, 0, 0, 0,
+	0, 926, 925, 84, 85, 198, 172, 173, 174, 184,
+	338, 180, 83, 689, 84, 85, 198, 172, 173, 174,
+	0, 338, 180, 23, 86, 87, 88, 89, 0, 90,
+	0, 521, 520, 0, 183, 0, 0, 82, 0, 86,
+	87, 88, 89, 0, 90, 183, 0, 0, 181, 0,
+	178, 179, 0, 0, 0, 0, 82, 0, 0, 0,
+	0, 178, 179, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 82, 0, 0, 0, 86, 87, 88, 89,
+	449, 90, 82, 927, 184, 181, 0, 0, 0, 0,
+	0, 0, 175, 0, 0, 185, 648, 0, 0, 22,
+	27, 28, 29, 678, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 83, 0, 84, 85, 198, 172, 173,
+	174, 0, 338, 180, 24, 0, 25, 0, 26, 0,
+	0, 0, 86, 87, 88, 89, 0, 90, 0, 0,
+	117, 0, 0, 0, 0, 0, 183, 0, 23, 0,
+	0, 86, 87, 88, 89, 0, 90, 0, 0, 0,
+	181, 0, 178, 179, 0, 0, 0, 86, 87, 88,
+	89, 0, 90, 0, 0, 0, 0, 86, 87, 88,
+	89, 0, 90, 0, 522, 0, 510, 513, 0, 0,
+	927, 0, 0, 0, 111, 110, 112, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 82, 0, 0,
+	0, 336, 0, 0, 0, 0, 0, 0, 82, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 161,
+	0, 0, 518, 517, 0, 184, 523, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 184, 0, 0, 0,
+	0, 0, 0, 0, 0, 509, 0, 511, 512, 515,
+	514, 0, 0, 0, 0, 161, 656, 657, 658, 659,
+	660, 661, 662, 663, 664, 665, 666, 667, 668, 669,
+	670, 671, 672, 673, 674, 675, 676, 677, 684, 685,
+	686, 687, 679, 680, 681, 682, 683, 688, 161, 0,
+	0, 0, 86, 87, 88, 89, 0, 90, 0, 0,
+	0, 181, 0, 86, 87, 88, 89, 0, 90, 82,
+	0, 0, 181, 108, 109, 0, 0, 114, 115, 0,
+	0, 0, 116, 119, 120, 121, 122, 124, 125, 0,
+	126, 0, 128, 129, 0, 0, 0, 184, 127, 0,
+	0, 0, 118, 123, 0, 0, 0, 0, 927, 30,
+	313, 315, 32, 33, 35, 34, 325, 326, 327, 328,
+	329, 330, 331, 316, 314, 312, 318, 317, 320, 321,
+	322, 323, 324, 319, 0, 0, 0, 730, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 735, 0, 0, 0, 0, 0, 737, 0,
-	0, 40, 0, 644, 0, 0, 0, 0, 0, 29,
-	0, 0, 31, 32, 34, 33, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 158,
-	638, 639, 640, 641, 642, 643, 645, 646, 647, 648,
-	649, 650, 651, 652, 653, 654, 655, 656, 657, 658,
-	659, 660, 661, 662, 663, 664, 665, 666, 667, 668,
-	669, 670, 671, 672, 673, 674, 675, 676, 677, 678,
-	679, 680, 681, 682, 683, 684, 685, 686, 687, 688,
-	689, 690, 691, 692, 693, 694, 695, 696, 697, 698,
-	699, 700, 701, 702, 703, 704, 705, 706, 707, 708,
-	709, 710, 711, 712, 713, 714, 715, 716, 717, 718,
-	719, 720, 721, 722, 723, 724, 171, 172, 173, 174,
-	175, 176, 177, 178, 179, 180, 181, 182, 183, 184,
-	185, 186, 187, 188, 189, 190, 191, 192, 193, 194,
-	195, 196, 197, 198, 199, 200, 201, 202, 203, 204,
-	205, 206, 207, 208, 209, 210, 0, 0, 384, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 568,
+	0, 0, 0, 0, 86, 87, 88, 89, 0, 90,
+	0, 0, 0, 181, 724, 725, 726, 727, 728, 729,
+	731, 732, 733, 734, 735, 736, 737, 738, 739, 740,
+	741, 742, 743, 744, 745, 746, 747, 748, 749, 750,
+	751, 752, 753, 754, 755, 756, 757, 758, 759, 760,
+	761, 762, 763, 764, 765, 766, 767, 768, 769, 770,
+	771, 772, 773, 774, 775, 776, 777, 778, 779, 780,
+	781, 782, 783, 784, 785, 786, 787, 788, 789, 790,
+	791, 792, 793, 794, 795, 796, 797, 798, 799, 800,
+	801, 802, 803, 804, 805, 806, 807, 808, 809, 810,
+	201, 202, 203, 204, 205, 206, 207, 208, 209, 210,
+	211, 212, 213, 214, 215, 216, 217, 218, 219, 220,
+	221, 222, 223, 224, 225, 226, 227, 228, 229, 230,
+	231, 232, 233, 234, 235, 236, 237, 238, 239, 240,
 }
-var yyPact = [...]int{
 
-	1131, -1000, -1000, 708, -1000, -1000, -1000, -1000, -1000, -1000,
+var yyPact = [...]int16{
+	116, -1000, -1000, 696, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, 739, -1000, 1069, -1000, 93, -1000, -1000, -1000, -1000,
+	-1000, 1784, -1000, -1000, -1000, -1000, -1000, 316, -1000, -1000,
+	1513, 197, 839, 1211, -1000, -1000, -1000, -1000, 1204, -1000,
+	1513, -1000, 532, 1770, -1000, 89, -1000, -1000, 1513, -33,
+	1370, 1478, 1179, 696, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -31, -33, 41, -16, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, 820, -1000, -1000, 52, -1000, -1000, -1000, -1000, -1000,
-	1218, -1000, -1000, -1000, -1000, -1000, 221, -1000, -1000, 361,
-	130, 1136, 1091, -1000, -1000, -1000, -1000, 1087, -1000, 468,
-	1080, -1000, 25, -1000, -1000, 361, -53, 361, 1139, 1039,
-	708, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -63, -53, -11, -27, -1000, -1000, -1000, -1000,
-	-1000, 915, 914, -1000, -1000, 120, -1000, 820, 513, 959,
-	1483, 1483, -1000, -1000, 957, 549, 549, 54, 549, 549,
-	682, 139, 65, 1138, 1135, 63, 61, 1134, 1133, 1125,
-	1121, 321, -1000, 58, -1000, -1000, 250, 1032, -1000, 953,
-	361, 361, -69, -34, -1000, -1000, -31, 361, -73, 361,
-	-1000, -1000, 760, -1000, -1000, 245, 304, 570, 1275, -1000,
-	554, 405, -1000, -1000, -1000, 1166, -1000, -1000, 818, -1000,
-	-1000, -1000, -1000, 817, -1000, -1000, -1000, -1000, 816, 814,
-	1166, -1000, -1000, 692, 215, -1000, 428, -1000, 243, 1483,
+	-1000, -1000, 1022, 1021, -1000, -1000, 1385, -1000, 1208, 1206,
+	739, 795, -1000, 934, 1250, 1126, 2083, 2083, -1000, -1000,
+	1120, 614, 614, 125, 614, 614, 660, 110, 133, 1255,
+	1254, 131, 114, 1251, 1247, 1246, 1245, 377, -1000, 102,
+	-1000, -1000, 339, 1178, -1000, 740, 889, 422, 112, -1000,
+	-1000, 1513, 738, -1000, 1513, -35, 30, -1000, -1000, 35,
+	1513, -37, 1513, -1000, -1000, 773, -1000, -1000, 335, 422,
+	628, 1976, -1000, 1422, 1280, -1000, -1000, -1000, 1760, 1061,
+	-1000, 893, -1000, -1000, -1000, -1000, 932, -1000, -1000, -1000,
+	-1000, 931, 929, 1760, -1000, -1000, -1000, -1000, -1000, 696,
+	1513, 1200, 1523, 653, 306, -1000, 568, -1000, 330, 2083,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -3, 549, -1000, 1166, 554, -1000, 549, 549, -1000,
-	-1000, -1000, 361, 680, 1119, 1116, -1000, 657, 361, 361,
-	549, 549, 361, 361, 361, 361, 361, 361, 361, 361,
-	361, 361, -1000, 361, 361, 294, 1110, 756, 361, 477,
-	361, 361, -51, 361, 1075, 585, -1000, 297, 120, 1166,
-	497, -1000, -1000, 361, 554, 554, 1166, 812, 511, 1166,
-	1166, 561, 1166, 1166, 1166, 1166, 1166, 1166, 1166, 1166,
-	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 1275, 1,
-	126, 9, 1275, -1000, 1067, -1000, 1136, 69, 1166, 1166,
-	300, 1192, 294, 205, 1166, 361, -1000, 927, -1000, 1192,
-	570, -1000, -1000, 549, -1000, 361, 361, 361, -1000, 361,
-	549, 549, -1000, -1000, 1110, 1110, 1110, 549, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, 748, 736, 1102, 554, 1057,
-	294, 294, 813, 1073, -80, 92, 361, 158, -1000, 361,
-	734, 609, 304, 553, -1000, -1000, -1000, 783, -1000, -1000,
-	-1000, -1000, 496, 1192, -1000, 812, 1166, 1166, 1192, 1179,
-	-1000, 1062, 527, 603, -1000, 537, 537, 254, 254, 254,
-	-1000, -1000, 1166, -1000, 1192, -1000, -184, 124, 1166, 1154,
-	123, 536, -1000, 554, -1000, 486, 1192, -1000, -1000, 549,
-	549, 549, 549, -1000, -1000, -1000, -1000, -1000, -1000, 1057,
-	294, 1102, 1094, 1099, 570, -1000, 812, 708, 692, 101,
-	-1000, 233, -1000, 577, -1000, -79, -1000, 713, -1000, 225,
-	146, -173, -174, 193, 13, 11, -1000, 413, 411, 211,
-	952, 380, 377, 351, -1000, -1000, -1000, -1000, -1000, 1048,
-	-143, -1000, 1108, 297, 297, -1000, -1000, 598, 596, 623,
-	622, 618, 259, 22, 1166, 1166, -1000, 1192, 795, 1166,
-	-1000, 1192, -1000, -1000, 93, 1166, -1000, 235, -1000, 1166,
-	512, -1000, 244, 206, -1000, -1000, -1000, -1000, -1000, 574,
-	568, 1094, -1000, 1166, 712, -1000, -1000, 294, 90, -1000,
-	430, -99, 361, 361, 361, 361, -1000, -1000, 92, -1000,
-	294, 361, 361, -106, 294, 294, 294, 1016, 361, 361,
-	1015, -1000, -1000, 361, 911, 948, 332, 291, 286, 1483,
-	1357, 926, -1000, -1000, 1106, 1098, 609, 1230, -1000, 617,
-	-1000, 605, -1000, -1000, -1000, -1000, -38, -40, -46, -1000,
-	1192, 1192, 1166, 1192, -1000, -15, -1000, 1192, 1166, -1000,
-	-1000, -1000, -1000, 1018, -1000, -1000, 710, -1000, 1132, 812,
-	-1000, 225, 233, -1000, 196, 811, 177, -1000, -1000, 175,
-	173, 172, 169, 168, 167, 164, 157, 152, -1000, 809,
-	807, 806, -1000, 521, 370, 804, 802, 801, 799, -1000,
-	-1000, -1000, -1000, 163, 163, 163, 163, 798, 793, 1002,
-	507, 998, -80, -80, -1000, 792, -1000, 430, -80, -80,
-	994, 506, 989, 294, 430, -1000, -1000, -1000, -1000, 361,
-	-1000, -1000, 285, 1483, 1357, 1483, -1000, -1000, -1000, -1000,
+	-1000, 71, 614, -1000, 1760, 1422, -1000, 614, 614, -1000,
+	-1000, -1000, 1513, 646, 1244, 1242, -1000, 625, 1513, 1513,
+	614, 614, 1513, 1513, 1513, 1513, 1513, 1513, 1513, 1513,
+	1513, 1513, -1000, 1513, 1513, 1523, 1370, 1370, -1000, -1000,
+	674, 654, 680, 677, 667, 338, -1000, 1513, 14, 126,
+	1232, 199, 1370, 1513, 822, 1513, 528, 1513, 1513, -26,
+	1513, 1196, 640, -1000, 1370, 1385, 1760, 46, -1000, 1422,
+	1422, 1333, 882, 552, 1760, 1760, 669, 1760, 1760, 1760,
+	1760, 1760, 1760, 1760, 1760, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, 1976, 29, 186, 1976, -1000, 1659, 926,
+	-1000, 839, 1299, 1760, 1760, 581, 1304, -1000, 893, 185,
+	-1000, 1523, 299, 1760, 1513, -1000, 1035, -1000, 1304, 628,
+	-1000, -1000, 614, -1000, 1513, 1513, 1513, -1000, 1513, 614,
+	614, -1000, -1000, 1232, 1232, 1232, 614, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, 812, 782, 889, 1387, -1000, 658,
+	-1000, 656, -1000, -1000, -1000, -1000, 19, 17, -22, -1000,
+	-1000, -1000, 1225, 1422, 1370, 779, -1000, 826, 53, 1523,
+	896, 1188, -42, 1607, 1513, 225, -1000, 1513, 779, -1000,
+	714, -1000, -1000, -1000, 604, 1304, 893, -1000, -1000, -1000,
+	-1000, 882, 1760, 1760, 1304, 832, -1000, 1194, 665, 1195,
+	-1000, 570, 570, 358, 358, 358, -1000, -1000, 1760, 1304,
+	-1000, 1057, -1000, -189, 180, 1760, 879, 174, 575, -1000,
+	1422, -1000, -1000, 1523, -1000, 333, 1304, -1000, -1000, 614,
+	614, 614, 614, -1000, -1000, -1000, -1000, -1000, -1000, 826,
+	53, 1523, 1225, 1422, -1000, -1000, 892, 890, 888, 1214,
+	1222, 628, 779, -1000, -1000, 882, 696, 122, 172, 653,
+	449, -1000, 639, -1000, -45, -1000, 727, -1000, 514, 291,
+	-160, -153, -164, 224, 83, 75, -1000, 559, 557, 497,
+	1102, 551, 525, 510, -1000, -1000, -1000, -1000, -1000, 1192,
+	-124, -1000, 1229, 1760, 1760, -1000, -1000, 1304, 730, 1760,
+	-1000, 1304, 1225, 1221, -1000, -1000, 171, 1760, -1000, 321,
+	-1000, 1760, 621, -1000, -1000, 344, 295, -1000, -1000, -1000,
+	-1000, -1000, 636, 78, 167, 638, 1214, 628, 1513, 1513,
+	1513, -1000, 1760, -1000, 721, -1000, -1000, 887, 166, -1000,
+	1673, 100, 1513, 1513, -1000, 1513, 1513, -1000, -1000, 1607,
+	-1000, 1523, 1513, 1513, -44, -1000, 1513, 1513, 1523, 1523,
+	1523, 1170, 1513, 1513, 1168, 1513, 1513, -1000, -1000, 1513,
+	1020, 1101, 508, 437, 419, 2083, 1957, 1034, -1000, -1000,
+	1227, 1217, 1304, 1304, 1760, 1304, 1067, 1760, -1000, -23,
+	-1000, 1304, 1760, -1000, -1000, -1000, -1000, 1175, 636, 885,
+	-1000, -1000, 160, -1000, 157, 156, 720, -1000, 722, 882,
+	839, 514, 449, -1000, -144, 232, 881, 263, -1000, -1000,
+	259, 257, 235, 234, 230, 229, 228, 183, 177, -1000,
+	880, 868, 867, -1000, 523, 522, 866, 865, 862, 861,
+	-1000, -1000, -1000, -1000, 274, 274, 274, 274, 860, 858,
+	1163, 1187, 1156, 857, -42, -42, -1000, 856, -1000, 1673,
+	-42, -42, 1146, 490, 1144, 113, 113, 1523, 1673, -1000,
+	-1000, -1000, -1000, 1513, -1000, -1000, -1000, -1000, 418, 2083,
+	1957, 2083, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
@@ -1022,150 +1148,166 @@ var yyPact = [...]int{
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, 1102, 554, 1166, 554,
-	-1000, -1000, 791, 788, 787, 1192, -1000, 1192, 975, 1166,
-	-1000, -1000, -1000, -1000, -1000, 225, -1000, 199, 200, 253,
-	-1000, -1000, 1044, 778, 905, -152, 904, -1000, -152, 903,
-	-152, 901, -152, 899, -152, 897, -152, 896, -152, 894,
-	-152, 890, -152, 888, -152, 880, 879, 872, 870, 204,
-	869, -1000, 204, 868, 861, 859, 853, 852, 204, 204,
-	204, 204, 778, 778, -80, -80, 361, 361, 786, 782,
-	781, 294, -161, 779, 777, -80, -80, 361, 361, 773,
-	430, -161, -1000, 1483, -1000, -1000, -1000, 1094, 570, 702,
-	570, 361, 361, 361, 1150, -1000, -127, 965, -1000, 962,
-	199, -120, 199, -120, -1000, -1000, -189, -1000, -1000, -191,
-	-1000, -192, -1000, -195, -1000, -198, -1000, -201, -1000, -202,
-	-1000, 698, -1000, 695, -1000, 694, -1000, 89, -205, -207,
-	-210, -5, 943, -220, -5, -221, -222, -224, -233, -235,
-	-5, -5, -5, -5, 81, -1000, 80, 772, 767, -80,
-	-80, 294, 294, 294, 78, -1000, 771, -1000, -1000, 294,
-	294, 294, 294, 765, 764, -80, -80, 294, -161, -1000,
-	-1000, 950, 74, -1000, 73, 72, 294, -129, 918, -1000,
-	-1000, -127, 199, -127, 199, -1000, -146, -146, -146, -146,
-	-146, -146, 850, 849, 843, -146, 832, -1000, -1000, -1000,
-	-1000, 1357, 1483, 163, -1000, 163, 163, 163, -1000, -1000,
-	-1000, -1000, -1000, -1000, 778, 204, 204, 294, 294, 757,
-	753, 70, 66, 62, -80, 294, -1000, 831, -1000, -1000,
-	57, 50, 294, 294, 752, 751, 47, -1000, -1000, 1149,
-	274, -1000, 361, -1000, -1000, 692, 32, 242, -1000, -129,
-	-127, -129, -127, -152, -152, -152, -152, -152, -152, -238,
-	-240, -254, -152, -255, -1000, -1000, 204, 204, 204, 204,
-	-1000, -5, -5, 41, 40, 294, 294, -125, -1000, -1000,
-	-1000, -1000, -1000, -280, -1000, -1000, 39, 38, 294, 294,
-	-125, -1000, 361, -1000, -125, 190, -1000, -1000, -1000, 32,
-	-129, 32, -129, -1000, -1000, -1000, -1000, -1000, -1000, -146,
-	-146, -146, -1000, -146, -5, -5, -5, -5, -1000, -1000,
-	-127, -1000, 37, 36, -1000, 361, 1045, -1000, -1000, 33,
-	12, -1000, 361, -1000, -1000, -1000, -1000, -1000, -125, 32,
-	-125, 32, -152, -152, -152, -152, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, 744, -1000, -1000, -1000, -1000, -1000, -125,
-	-1000, -125, -1000, -1000, -1000, -1000, 294, -1000, -1000, 10,
-	-135, 558, 197, -1000, 1114, -1000, -1000, -1000, 158, 158,
-	555, 538, 1142, 1115, 158, 158, -1000, -1000,
+	-1000, -1000, 1225, 1422, 1760, 1304, -191, 1497, -1000, -1000,
+	719, -1000, 1304, 1143, -1000, 839, -1000, 1513, -1000, -1000,
+	1760, -1000, -1000, -1000, -1000, 57, -1000, 514, -1000, -1000,
+	-1000, -177, 302, 250, 262, 1198, -1000, -1000, 1186, 840,
+	-172, 1016, -136, 1011, -1000, -136, 1010, -136, 1002, -136,
+	994, -136, 992, -136, 990, -136, 989, -136, 985, -136,
+	970, -136, 966, 964, 963, 961, 265, 960, -1000, 265,
+	959, 953, 952, 951, 950, 265, 265, 265, 265, 1000,
+	1000, -42, -42, 1513, 1513, 850, 1422, 849, 847, 1314,
+	-125, 846, 845, -42, -42, 1513, 1513, 820, -1000, -1000,
+	-180, -1000, 1673, -125, -1000, 2083, -1000, -1000, -1000, 1214,
+	628, 719, -1000, -1000, 1648, 1049, 1044, 870, 1259, 28,
+	-1000, -1000, -1000, -92, 1141, -1000, 1132, 302, -83, 302,
+	-83, 816, -1000, -1000, -1000, 1280, -1000, -192, -1000, -1000,
+	-193, -1000, -202, -1000, -207, -1000, -210, -1000, -211, -1000,
+	-217, -1000, 715, -1000, 713, -1000, 706, -1000, 154, -223,
+	-225, -226, 51, 1091, -227, 51, -234, -244, -245, -250,
+	-251, 51, 51, 51, 51, 151, -1000, 150, 814, 808,
+	-42, -42, 1314, -17, 1314, 1314, 139, -1000, 824, 1422,
+	-1000, -1000, 1523, 1523, 1314, 1314, 807, 805, -42, -42,
+	1314, -1000, -125, -1000, -1000, 1109, 588, -1000, -1000, -1000,
+	-1000, -1000, 1523, 636, -96, 1027, -1000, -1000, -92, 302,
+	-92, 302, 1422, -20, -1000, -131, -131, -131, -131, -131,
+	-131, 949, 948, 945, -131, 943, -1000, -1000, -1000, -1000,
+	1957, 2083, 274, -1000, 274, 274, 274, -1000, -1000, -1000,
+	-1000, -1000, -1000, 1000, 265, 265, 1314, 1314, 804, 793,
+	137, 113, 136, 135, -42, 1314, -1000, 829, -259, -1000,
+	-1000, 130, 117, 1314, 1314, 788, 750, 109, -1000, -1000,
+	1258, 397, 1648, 653, -1000, 92, 260, -1000, -96, -92,
+	-96, -92, -260, -1000, -136, -136, -136, -136, -136, -136,
+	-264, -266, -267, -136, -291, -1000, -1000, 265, 265, 265,
+	265, -1000, 51, 51, 97, 95, 1314, 1314, -90, -1000,
+	-1000, -1000, -1000, -1000, -1000, -294, 1183, -1000, -1000, 82,
+	73, 1314, 1314, -90, -1000, 1513, -1000, -90, 285, -1000,
+	-1000, -1000, 92, -96, 92, -96, -141, -1000, -1000, -1000,
+	-1000, -1000, -1000, -131, -131, -131, -1000, -131, 51, 51,
+	51, 51, -1000, -1000, -92, -1000, 66, 59, -1000, 1513,
+	1183, -1000, -1000, -1000, 47, 21, -1000, 1513, -1000, -1000,
+	-1000, -1000, -1000, -90, 92, -90, 92, 651, -1000, -1000,
+	-136, -136, -136, -136, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, 735, -1000, -1000, -1000, -1000, -1000, -90, -1000, -90,
+	302, -1000, -1000, -1000, -1000, -1000, 1314, -1000, -1000, -92,
+	-9, -1000, -102, 635, 252, -1000, 1238, -1000, -1000, -1000,
+	225, 225, 634, 632, 1252, 1239, 225, 225, -1000, -1000,
 }
-var yyPgo = [...]int{
 
-	0, 1311, 1308, 46, 1059, 1058, 1051, 1048, 1039, 1030,
-	1024, 1022, 987, 984, 974, 1307, 1306, 1305, 1304, 1303,
-	1301, 1265, 696, 1296, 1286, 523, 1285, 263, 48, 1276,
-	1264, 26, 1263, 1254, 42, 1253, 12, 39, 43, 1252,
-	1250, 41, 7, 899, 33, 24, 1248, 1246, 34, 1243,
-	27, 1242, 1241, 44, 1240, 1239, 1227, 1220, 1217, 35,
-	1216, 23, 9, 15, 1207, 49, 1196, 37, 20, 205,
-	333, 1195, 1193, 1192, 3, 74, 1191, 4, 30, 0,
-	5, 14, 1189, 644, 19, 45, 16, 21, 8, 6,
-	2, 1188, 1185, 1, 1184, 153, 29, 25, 1183, 36,
-	1171, 1168, 13, 17, 10, 91, 11, 31, 28, 1167,
-	38, 18, 32, 1165, 1161, 22, 1140,
+var yyPgo = [...]int16{
+	0, 1439, 1429, 65, 1162, 1161, 1155, 1147, 1141, 1138,
+	1134, 1133, 1126, 1122, 1112, 1427, 1424, 1423, 1421, 1420,
+	1419, 1578, 987, 1418, 551, 1414, 1412, 1408, 425, 660,
+	1405, 262, 396, 28, 42, 446, 1403, 1402, 57, 346,
+	1390, 47, 64, 39, 1387, 1384, 1377, 32, 13, 955,
+	46, 31, 1374, 1371, 111, 1368, 21, 1367, 1365, 48,
+	1364, 1363, 1362, 1343, 1341, 10, 1340, 1339, 1338, 1337,
+	30, 1336, 27, 8, 29, 1335, 44, 43, 49, 24,
+	200, 414, 1331, 1322, 1320, 1319, 12, 54, 1318, 14,
+	25, 0, 22, 15, 1316, 801, 26, 11, 3, 36,
+	4, 1315, 19, 40, 1314, 7, 1, 1308, 1307, 2,
+	1306, 192, 6, 37, 1304, 33, 1303, 1284, 20, 5,
+	1283, 38, 17, 9, 45, 18, 1282, 1281, 34, 23,
+	35, 1272, 1269, 16, 1268,
 }
-var yyR1 = [...]int{
 
+var yyR1 = [...]uint8{
 	0, 1, 2, 2, 2, 2, 2, 2, 2, 2,
 	2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
-	2, 3, 3, 3, 11, 11, 14, 14, 12, 13,
-	19, 19, 19, 19, 19, 19, 19, 19, 19, 19,
-	19, 19, 4, 4, 4, 4, 4, 4, 15, 15,
-	16, 17, 18, 20, 20, 20, 7, 7, 8, 9,
-	10, 10, 5, 5, 5, 5, 5, 5, 5, 5,
+	2, 3, 3, 3, 3, 26, 26, 27, 27, 28,
+	11, 11, 11, 11, 14, 14, 14, 14, 12, 13,
+	13, 13, 39, 39, 19, 19, 19, 19, 19, 19,
+	19, 19, 19, 19, 19, 19, 4, 4, 4, 4,
+	4, 4, 15, 15, 16, 17, 18, 20, 20, 20,
+	7, 7, 8, 9, 10, 10, 5, 5, 5, 5,
 	5, 5, 5, 5, 5, 5, 5, 5, 5, 5,
 	5, 5, 5, 5, 5, 5, 5, 5, 5, 5,
-	5, 5, 5, 5, 5, 5, 5, 5, 6, 116,
-	21, 22, 22, 23, 23, 23, 23, 23, 24, 24,
-	26, 26, 27, 27, 27, 29, 29, 28, 28, 28,
-	30, 30, 31, 31, 31, 32, 32, 32, 32, 32,
-	32, 32, 32, 32, 33, 33, 34, 34, 35, 35,
-	35, 35, 36, 36, 102, 102, 37, 37, 38, 38,
-	38, 38, 38, 39, 39, 39, 39, 39, 39, 39,
-	39, 39, 39, 40, 40, 40, 40, 40, 40, 40,
-	41, 41, 46, 46, 44, 44, 48, 45, 45, 43,
-	43, 43, 43, 43, 43, 43, 43, 43, 43, 43,
-	43, 43, 43, 54, 54, 54, 54, 54, 54, 47,
-	47, 49, 49, 49, 51, 55, 55, 52, 52, 53,
-	56, 56, 50, 50, 42, 42, 42, 42, 57, 57,
-	58, 58, 59, 59, 60, 60, 61, 62, 62, 62,
-	63, 63, 63, 63, 64, 64, 64, 65, 65, 66,
-	66, 67, 67, 68, 68, 69, 71, 71, 72, 72,
-	25, 25, 73, 73, 73, 78, 78, 77, 77, 75,
-	75, 74, 74, 76, 76, 79, 79, 80, 80, 80,
-	80, 80, 80, 80, 80, 80, 80, 80, 80, 80,
-	80, 80, 80, 80, 80, 80, 80, 80, 80, 80,
-	80, 80, 80, 80, 80, 80, 80, 80, 80, 80,
-	80, 80, 80, 80, 80, 80, 80, 81, 81, 81,
-	81, 81, 81, 81, 81, 81, 81, 81, 81, 81,
-	81, 81, 81, 81, 81, 81, 81, 81, 81, 81,
-	81, 81, 81, 81, 81, 81, 81, 81, 81, 81,
-	81, 81, 81, 81, 81, 81, 81, 81, 81, 81,
-	81, 81, 81, 81, 81, 81, 81, 81, 81, 81,
-	81, 81, 81, 81, 81, 81, 81, 81, 81, 81,
-	81, 81, 81, 81, 81, 81, 81, 81, 81, 81,
-	81, 81, 81, 81, 81, 81, 81, 81, 81, 81,
-	81, 81, 81, 81, 82, 82, 82, 82, 83, 83,
-	83, 70, 70, 70, 98, 98, 97, 97, 97, 97,
-	97, 97, 97, 97, 108, 108, 108, 108, 108, 109,
-	109, 109, 109, 109, 109, 109, 109, 109, 109, 109,
-	109, 109, 109, 109, 109, 109, 109, 109, 109, 109,
-	109, 109, 109, 109, 109, 109, 109, 109, 109, 109,
-	109, 109, 109, 109, 109, 109, 109, 109, 109, 109,
-	109, 109, 109, 109, 109, 109, 109, 109, 109, 109,
-	109, 103, 103, 84, 104, 104, 86, 86, 86, 86,
-	86, 85, 85, 87, 87, 87, 87, 88, 88, 88,
-	88, 90, 90, 89, 91, 91, 91, 91, 92, 92,
-	92, 92, 92, 94, 94, 93, 93, 93, 93, 105,
-	105, 106, 106, 107, 107, 95, 95, 96, 96, 110,
-	110, 113, 113, 112, 112, 111, 111, 111, 111, 111,
-	111, 111, 111, 111, 101, 101, 100, 100, 99, 99,
-	99, 99, 99, 99, 99, 99, 99, 99, 99, 99,
-	99, 99, 99, 99, 99, 99, 115, 115, 114, 114,
+	5, 5, 5, 5, 5, 5, 5, 5, 5, 5,
+	5, 5, 6, 134, 21, 22, 22, 23, 23, 23,
+	23, 23, 25, 25, 25, 24, 24, 30, 30, 31,
+	31, 31, 33, 33, 32, 32, 32, 34, 34, 35,
+	35, 35, 36, 36, 36, 36, 36, 36, 36, 36,
+	36, 37, 37, 38, 38, 40, 40, 40, 40, 41,
+	41, 118, 118, 42, 42, 43, 43, 43, 43, 43,
+	44, 44, 44, 44, 44, 44, 44, 44, 44, 44,
+	44, 45, 45, 45, 45, 45, 45, 45, 46, 46,
+	46, 47, 47, 52, 52, 50, 50, 54, 51, 51,
+	49, 49, 49, 49, 49, 49, 49, 49, 49, 49,
+	49, 49, 49, 49, 49, 60, 60, 60, 60, 60,
+	60, 61, 62, 62, 63, 63, 63, 64, 64, 65,
+	65, 65, 65, 65, 53, 53, 55, 55, 55, 57,
+	66, 66, 58, 58, 59, 67, 67, 56, 56, 48,
+	48, 48, 48, 68, 68, 69, 69, 70, 70, 71,
+	71, 72, 73, 73, 73, 74, 74, 74, 74, 75,
+	75, 75, 76, 76, 77, 77, 78, 78, 79, 79,
+	80, 82, 82, 83, 83, 29, 29, 85, 85, 85,
+	90, 90, 89, 89, 89, 87, 87, 86, 86, 88,
+	88, 91, 91, 91, 91, 91, 91, 91, 91, 91,
+	91, 92, 92, 92, 92, 92, 92, 92, 92, 92,
+	92, 92, 92, 92, 92, 92, 92, 92, 92, 92,
+	92, 92, 92, 92, 92, 92, 92, 92, 92, 92,
+	92, 92, 92, 92, 92, 92, 92, 92, 92, 92,
+	92, 93, 93, 93, 93, 93, 93, 93, 93, 93,
+	93, 93, 93, 93, 93, 93, 93, 93, 93, 93,
+	93, 93, 93, 93, 93, 93, 93, 93, 93, 93,
+	93, 93, 93, 93, 93, 93, 93, 93, 93, 93,
+	93, 93, 93, 93, 93, 93, 93, 93, 93, 93,
+	93, 93, 93, 93, 93, 93, 93, 93, 93, 93,
+	93, 93, 93, 93, 93, 93, 93, 93, 93, 93,
+	93, 93, 93, 93, 93, 93, 93, 93, 93, 93,
+	93, 93, 93, 93, 93, 93, 93, 93, 94, 94,
+	94, 94, 95, 95, 95, 81, 81, 81, 114, 114,
+	113, 113, 113, 113, 113, 113, 113, 113, 113, 103,
+	104, 104, 102, 102, 102, 125, 126, 126, 126, 126,
+	126, 126, 84, 84, 101, 101, 101, 127, 127, 127,
+	127, 127, 127, 127, 127, 127, 127, 127, 127, 127,
+	127, 127, 127, 127, 127, 127, 127, 127, 127, 127,
+	127, 127, 127, 127, 127, 127, 127, 127, 127, 127,
+	127, 127, 127, 127, 127, 127, 127, 127, 127, 127,
+	127, 127, 127, 127, 127, 127, 127, 127, 127, 119,
+	119, 120, 120, 96, 96, 96, 121, 121, 98, 98,
+	98, 98, 98, 97, 97, 99, 99, 99, 99, 100,
+	100, 100, 100, 106, 106, 105, 108, 108, 108, 108,
+	107, 107, 107, 107, 107, 110, 110, 109, 109, 109,
+	109, 122, 122, 123, 123, 124, 124, 111, 111, 112,
+	112, 128, 128, 131, 131, 130, 130, 129, 129, 129,
+	129, 129, 129, 129, 129, 129, 117, 117, 116, 116,
+	115, 115, 115, 115, 115, 115, 115, 115, 115, 115,
+	115, 115, 115, 115, 115, 115, 115, 115, 115, 115,
+	115, 115, 115, 133, 133, 132, 132,
 }
-var yyR2 = [...]int{
 
+var yyR2 = [...]int8{
 	0, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	1, 5, 12, 3, 8, 8, 6, 6, 8, 7,
-	2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
-	2, 2, 4, 5, 4, 4, 6, 7, 1, 2,
-	1, 1, 2, 2, 3, 3, 9, 12, 6, 6,
-	6, 6, 5, 4, 4, 5, 5, 4, 4, 4,
-	6, 5, 7, 5, 7, 6, 6, 7, 7, 5,
-	5, 6, 6, 6, 6, 5, 5, 5, 5, 5,
-	5, 3, 4, 4, 2, 3, 2, 2, 3, 0,
-	2, 0, 2, 1, 2, 1, 1, 1, 0, 1,
-	1, 3, 1, 3, 2, 1, 1, 0, 1, 2,
-	1, 3, 3, 3, 5, 1, 1, 2, 3, 2,
-	3, 2, 2, 2, 1, 1, 1, 3, 0, 5,
-	5, 5, 1, 3, 1, 3, 0, 2, 1, 3,
-	3, 2, 3, 3, 3, 4, 3, 4, 5, 6,
-	3, 4, 2, 1, 1, 1, 1, 1, 1, 1,
-	2, 1, 1, 3, 3, 1, 3, 1, 3, 1,
-	1, 1, 3, 3, 3, 3, 3, 3, 3, 3,
-	2, 1, 1, 3, 4, 4, 5, 6, 4, 1,
-	1, 1, 1, 1, 5, 0, 1, 1, 2, 4,
-	0, 2, 1, 3, 1, 1, 1, 1, 0, 3,
-	0, 2, 0, 3, 1, 3, 2, 0, 1, 1,
-	0, 2, 4, 4, 0, 2, 4, 0, 3, 1,
-	3, 0, 5, 1, 3, 3, 0, 2, 0, 3,
-	0, 1, 0, 1, 1, 1, 3, 2, 5, 0,
-	1, 2, 2, 0, 1, 1, 1, 1, 1, 1,
+	1, 5, 12, 3, 4, 0, 1, 1, 3, 4,
+	8, 9, 12, 8, 6, 7, 10, 6, 8, 7,
+	6, 7, 1, 3, 2, 2, 2, 2, 2, 2,
+	2, 2, 2, 2, 2, 2, 4, 5, 4, 4,
+	6, 7, 1, 2, 1, 1, 2, 2, 3, 3,
+	9, 12, 6, 6, 6, 6, 5, 4, 4, 5,
+	5, 4, 4, 4, 6, 5, 7, 5, 7, 6,
+	6, 7, 7, 5, 5, 6, 6, 6, 6, 5,
+	5, 5, 5, 5, 5, 3, 4, 4, 2, 3,
+	2, 2, 3, 0, 2, 0, 2, 1, 2, 1,
+	1, 1, 1, 2, 2, 0, 1, 1, 3, 1,
+	3, 2, 1, 1, 0, 1, 2, 1, 3, 3,
+	3, 5, 1, 1, 2, 3, 2, 3, 2, 2,
+	2, 1, 1, 1, 3, 0, 5, 5, 5, 1,
+	3, 1, 3, 0, 2, 1, 3, 3, 2, 3,
+	3, 4, 3, 4, 3, 4, 5, 6, 3, 4,
+	2, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 2, 1, 1, 3, 3, 1, 3, 1, 3,
+	1, 1, 1, 3, 3, 3, 3, 3, 3, 3,
+	3, 2, 1, 3, 1, 3, 4, 4, 5, 6,
+	4, 5, 0, 3, 0, 2, 5, 1, 1, 2,
+	2, 2, 2, 2, 1, 1, 1, 1, 1, 5,
+	0, 1, 1, 2, 4, 0, 2, 1, 3, 1,
+	1, 1, 1, 0, 3, 0, 2, 0, 3, 1,
+	3, 2, 0, 1, 1, 0, 2, 4, 4, 0,
+	2, 4, 0, 3, 1, 3, 0, 5, 1, 3,
+	3, 0, 2, 0, 3, 0, 1, 0, 1, 1,
+	1, 3, 2, 5, 4, 0, 1, 2, 2, 0,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
@@ -1178,281 +1320,311 @@ var yyR2 = [...]int{
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	1, 1, 1, 1, 2, 2, 2, 1, 0, 1,
-	1, 0, 2, 2, 1, 3, 2, 8, 6, 6,
-	7, 8, 8, 7, 7, 8, 8, 9, 9, 1,
-	4, 3, 6, 1, 1, 3, 6, 3, 6, 3,
-	6, 3, 6, 3, 6, 3, 8, 3, 8, 3,
-	8, 3, 6, 8, 1, 1, 4, 1, 4, 1,
-	4, 1, 4, 4, 7, 7, 7, 7, 1, 4,
-	4, 1, 1, 1, 1, 4, 4, 4, 4, 6,
-	6, 1, 2, 2, 0, 1, 0, 1, 2, 1,
-	2, 0, 2, 0, 2, 2, 2, 0, 2, 2,
-	2, 0, 1, 7, 0, 2, 2, 2, 0, 3,
-	3, 6, 6, 0, 1, 1, 1, 2, 2, 0,
-	1, 0, 1, 0, 1, 0, 3, 0, 2, 0,
-	2, 0, 1, 1, 2, 3, 3, 5, 4, 4,
-	3, 4, 3, 3, 0, 1, 1, 3, 1, 5,
-	7, 7, 8, 8, 9, 9, 8, 6, 5, 3,
-	3, 3, 3, 4, 2, 2, 0, 1, 2, 2,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 2, 2,
+	2, 1, 0, 1, 1, 0, 2, 2, 1, 3,
+	2, 8, 6, 6, 7, 8, 8, 7, 1, 6,
+	0, 1, 0, 1, 2, 2, 7, 8, 8, 9,
+	9, 10, 0, 2, 0, 1, 1, 1, 4, 3,
+	6, 1, 1, 3, 6, 3, 6, 3, 6, 3,
+	6, 3, 6, 3, 8, 3, 8, 3, 8, 3,
+	6, 8, 1, 1, 4, 1, 4, 1, 4, 1,
+	4, 4, 7, 7, 7, 7, 1, 4, 4, 1,
+	1, 1, 1, 4, 4, 4, 4, 6, 6, 1,
+	2, 0, 1, 2, 2, 4, 0, 1, 0, 1,
+	2, 1, 2, 0, 2, 0, 2, 2, 2, 0,
+	2, 2, 2, 0, 1, 7, 0, 2, 2, 2,
+	0, 3, 3, 6, 6, 0, 1, 1, 1, 2,
+	2, 0, 1, 0, 1, 0, 1, 0, 3, 0,
+	2, 0, 2, 0, 1, 1, 2, 3, 3, 5,
+	4, 4, 3, 4, 3, 3, 0, 1, 1, 3,
+	1, 5, 7, 7, 8, 8, 9, 9, 8, 2,
+	4, 4, 6, 5, 3, 3, 3, 3, 4, 3,
+	3, 2, 2, 0, 1, 2, 2,
 }
-var yyChk = [...]int{
 
+var yyChk = [...]int16{
 	-1000, -1, -2, -3, -4, -5, -6, -11, -12, -13,
 	-14, -19, -7, -8, -9, -10, -15, -16, -17, -18,
-	-20, -22, 5, 29, 31, 33, 6, 7, 8, 251,
-	32, 254, 255, 257, 256, 87, 88, 90, 91, 55,
-	330, -23, 41, 42, 43, 44, 38, -21, -116, -21,
-	-21, 237, 236, 247, 250, -21, -21, -21, -21, -21,
-	-3, -11, -12, -14, -13, -4, -5, -6, -7, -8,
-	-9, -10, -21, -21, -21, -21, 89, -79, 34, 235,
-	36, 332, 331, -3, 17, -24, 18, -22, -83, 101,
-	100, 99, 229, 230, 101, 100, 102, -83, 233, 234,
-	238, 46, 258, 239, 240, 241, 242, 259, 243, 244,
-	246, 254, 248, 249, 237, -34, -79, -25, 262, -34,
-	9, 25, 258, -73, 264, 265, -25, 258, 258, 259,
-	36, 36, -26, -27, 80, 34, -29, -38, -43, -39,
-	60, 39, -42, -50, -44, -49, -54, -51, 20, 35,
-	36, 37, 21, -79, -48, 78, 79, 40, 333, -47,
-	62, 263, 24, -68, 89, -69, -50, -79, 34, 29,
-	-80, 103, 104, 105, 106, 107, 108, 109, 110, 111,
-	112, 113, 114, 115, 116, 117, 118, 119, 120, 121,
-	122, 123, 124, 125, 126, 127, 128, 129, 130, 131,
-	132, 133, 134, 135, 136, 137, 138, 139, 140, 141,
-	142, -80, 29, -70, 74, 10, -70, 231, 232, -70,
-	-70, -70, 9, 238, 239, 240, 248, 232, 9, 9,
-	232, 232, 9, 9, 9, 9, 235, 258, 260, 241,
-	242, 245, 232, 84, 25, 29, -34, -34, -72, 263,
-	259, 258, -34, -71, 263, -79, -63, 9, 45, 15,
-	84, -28, -79, 19, 59, 58, -40, 75, 60, 74,
-	61, 73, 77, 76, 83, 78, 79, 80, 81, 82,
-	66, 67, 68, 69, 70, 71, 72, -38, -43, -38,
-	-45, -3, -43, -43, 39, -48, 39, 39, 39, 39,
-	-55, -43, 45, 92, 66, 84, -80, 253, -70, -43,
-	-38, -70, -70, -34, -70, 9, 9, 9, -70, 9,
-	-34, -34, -70, -70, -34, -34, -34, -34, -34, -34,
-	-34, -34, -34, -34, -79, -34, -68, -37, 10, -65,
-	29, 39, -34, 60, -79, -34, 261, -34, 20, 57,
-	-30, -31, -33, 39, -34, -48, -27, -43, 80, -79,
-	-79, -38, -38, -43, -44, 75, 74, 61, -43, -43,
-	21, 60, -43, -43, -43, -43, -43, -43, -43, -43,
-	334, 334, 45, 334, -43, 334, 80, -45, 18, -43,
-	-45, -52, -53, 63, -69, 93, -43, 35, -70, -34,
-	-34, -34, -34, -70, -70, -37, -37, -37, -70, -65,
-	29, -37, -59, 13, -38, -41, 24, -3, -68, -66,
-	-50, 39, 20, -75, -74, 266, -101, -100, -99, -112,
-	324, 326, 327, 256, 329, 328, -111, 302, 301, 28,
-	101, 100, 253, 305, -34, -94, -93, 314, 315, 29,
-	316, -34, -37, 45, -32, 47, 48, 49, 50, 51,
-	53, 54, -28, -31, 45, 252, -44, -43, -43, 59,
-	21, -43, 334, 334, -45, 75, 334, -56, -53, 65,
-	-38, -82, 94, 97, 98, -70, -70, -70, -70, -41,
-	-68, -59, -63, 14, -46, -44, 334, 45, -98, -97,
-	-50, -110, 259, 27, 320, 57, 267, 268, 45, -111,
-	325, 259, 27, -110, 325, 325, 325, 303, 259, 27,
-	321, 244, 244, 66, 66, 101, 100, 253, 29, 66,
-	66, 66, 21, 317, -57, 11, -31, -31, 47, 52,
-	47, 52, 47, 47, 47, -35, 55, 262, 56, 334,
-	-43, -43, 59, -43, 334, -43, 86, -43, 64, 95,
-	96, 94, -67, 57, -67, -63, -60, -61, -43, 45,
-	-50, 334, 45, -108, -109, 269, 270, 271, 272, 273,
-	274, 275, 276, 277, 278, 279, 280, 281, 282, 283,
-	284, 285, 286, 287, 288, 289, 290, 106, 295, 296,
-	297, 298, 299, 291, 292, 293, 294, 300, 29, 303,
-	264, 321, -79, -79, -79, -34, -99, -50, -79, -79,
-	303, 264, 321, -50, -50, -50, 27, -79, -79, 27,
-	-79, 36, 29, 66, 66, 66, -80, -81, 143, 144,
-	145, 146, 147, 148, 106, 149, 150, 151, 152, 153,
-	154, 155, 156, 157, 158, 159, 160, 161, 162, 163,
-	164, 165, 166, 167, 168, 169, 170, 171, 172, 173,
-	174, 175, 176, 177, 178, 179, 180, 181, 182, 183,
-	184, 185, 186, 187, 188, 189, 190, 191, 192, 193,
-	194, 195, 196, 197, 198, 199, 200, 201, 202, 203,
-	204, 205, 206, 207, 208, 209, 210, 211, 212, 213,
-	214, 215, 216, 217, 218, 219, 220, 221, 222, 223,
-	224, 225, 226, 227, 228, 35, -58, 12, 14, 57,
-	47, 47, 259, 259, 259, -43, 334, -43, 26, 45,
-	-62, 22, 23, -44, -113, -112, -97, -104, -103, -84,
-	301, 21, 60, 28, 39, -105, 39, 318, -105, 39,
-	-105, 39, -105, 39, -105, 39, -105, 39, -105, 39,
-	-105, 39, -105, 39, -105, 39, 39, 39, 39, -107,
-	39, 106, -107, 39, 39, 39, 39, 39, -107, -107,
-	-107, -107, 39, 39, 27, -79, 259, 27, 27, -75,
-	-75, 39, -108, -75, -75, 27, -79, 259, 27, 27,
-	-50, -108, -79, 66, -80, -81, -80, -59, -38, -45,
-	-38, 39, 39, 39, 27, -61, -86, 264, 27, 303,
-	-104, -84, -104, -103, 21, -42, 36, -106, 319, 36,
-	-106, 36, -106, 36, -106, 36, -106, 36, -106, 36,
-	-106, 36, -106, 36, -106, 36, -106, 36, 36, 36,
-	36, -95, 101, 36, -95, 36, 36, 36, 36, 36,
-	-95, -95, -95, -95, -102, -42, -102, -75, -75, -79,
-	-79, 39, 39, 39, -78, -77, -50, -115, -114, 322,
-	323, 39, 39, -75, -75, -79, -79, 39, -108, -115,
-	-80, -63, -36, -79, -36, -36, 7, -85, 305, 27,
-	27, -86, -104, -86, -104, 334, 334, 334, 334, 334,
-	334, 334, 45, 45, 45, 334, 45, 334, 334, 334,
-	-96, 253, 29, 334, -96, 334, 334, 334, 334, 334,
-	-96, -96, -96, -96, 45, 334, 334, 39, 39, -75,
-	-75, -78, -78, -78, 334, 45, -62, 39, -50, -50,
-	-78, -78, 39, 39, -75, -75, -78, -115, -64, 16,
-	30, 334, 45, 334, 334, -68, -87, 306, 35, -85,
-	-86, -85, -86, -105, -105, -105, -105, -105, -105, 36,
-	36, 36, -105, 36, -81, -80, -107, -107, -107, -107,
-	-42, -95, -95, -78, -78, 39, 39, 334, 334, 334,
-	-76, -74, -77, 36, 334, 334, -78, -78, 39, 39,
-	334, 7, 75, -79, -88, 236, 307, 308, 28, -87,
-	-85, -87, -85, -106, -106, -106, -106, -106, -106, 334,
-	334, 334, -106, 334, -95, -95, -95, -95, -96, -96,
-	334, 334, -78, -78, -89, 304, 334, 334, 334, -78,
-	-78, -89, -79, -90, -89, 309, 310, 28, -88, -87,
-	-88, -87, -105, -105, -105, -105, -96, -96, -96, -96,
-	-85, 334, 334, -34, -62, 334, 334, -79, -90, -88,
-	-90, -88, -106, -106, -106, -106, 39, -90, -90, -78,
-	334, -91, 311, -92, 57, 46, 312, 313, 8, 7,
-	-93, -93, 57, 57, 7, 8, -93, -93,
+	-20, -22, 5, 54, 30, 32, 34, 6, 7, 8,
+	265, 33, 268, 269, 271, 270, 101, 102, 104, 105,
+	69, 350, -23, 55, 56, 57, 58, 51, -21, -134,
+	-26, 35, -21, -21, 251, 250, 261, 264, -21, -21,
+	-21, -21, -21, -3, -11, -12, -14, -13, -4, -5,
+	-6, -7, -8, -9, -10, -21, -21, -21, -21, 103,
+	-91, 47, 249, 43, 45, 46, 344, 345, 346, 347,
+	349, 49, 352, 351, -3, 17, -25, -24, 19, 18,
+	-22, -27, -28, -91, -95, 115, 114, 113, 243, 244,
+	115, 114, 116, -95, 247, 248, 252, 60, 272, 253,
+	254, 255, 256, 273, 257, 258, 260, 268, 262, 263,
+	251, -38, -91, -29, 276, -34, -35, -37, 52, -38,
+	-54, 9, -39, -38, 26, 272, -85, 278, 279, -29,
+	272, 272, 273, 49, 49, -30, -31, 94, 47, -33,
+	-43, -49, -44, 74, 52, -48, -56, -50, -55, -60,
+	-57, 21, 48, 49, 50, 22, -91, -54, 92, 93,
+	53, 353, -53, 76, 277, 25, -24, 18, 19, -3,
+	59, -76, 52, -79, 103, -80, -56, -91, 47, 30,
+	-92, 117, 118, 119, 120, 121, 122, 123, 124, 125,
+	126, 127, 128, 129, 130, 131, 132, 133, 134, 135,
+	136, 137, 138, 139, 140, 141, 142, 143, 144, 145,
+	146, 147, 148, 149, 150, 151, 152, 153, 154, 155,
+	156, -92, 30, -81, 88, 10, -81, 245, 246, -81,
+	-81, -81, 9, 252, 253, 254, 262, 246, 9, 9,
+	246, 246, 9, 9, 9, 9, 249, 272, 274, 255,
+	256, 259, 246, 98, 26, 30, 59, -36, 61, 62,
+	63, 64, 65, 67, 68, -32, -91, 20, -35, -3,
+	-38, -39, 9, 59, -38, -83, 277, 273, 272, -38,
+	-82, 277, -91, -74, 9, 59, 15, 98, -32, 73,
+	72, -45, 89, 74, 88, 75, 87, 91, 90, 97,
+	92, 93, 94, 95, 96, 80, 81, 82, 83, 84,
+	85, 86, -43, -49, -43, -51, -49, -49, 52, 38,
+	-54, 52, 52, 52, 52, -66, -49, -28, 20, -77,
+	-56, 59, 106, 80, 98, -92, 267, -81, -49, -43,
+	-81, -81, -38, -81, 9, 9, 9, -81, 9, -38,
+	-38, -81, -81, -38, -38, -38, -38, -38, -38, -38,
+	-38, -38, -38, -91, -38, -79, -35, -35, 61, 66,
+	61, 66, 61, 61, 61, -40, 69, 276, 70, -91,
+	354, 354, -42, 10, 280, -34, -38, -76, 52, 30,
+	-38, 74, -91, -38, 275, -38, 21, 71, -34, -31,
+	-49, 94, -91, -43, -43, -49, -46, 36, 37, 17,
+	-50, 89, 88, 75, -49, -49, 22, 74, -49, -49,
+	-49, -49, -49, -49, -49, -49, 354, 354, 59, -49,
+	-61, 52, 354, 94, -51, 18, -49, -51, -58, -59,
+	77, -54, 354, 59, -80, 107, -49, 48, -81, -38,
+	-38, -38, -38, -81, -81, -42, -42, -42, -81, -76,
+	52, 30, -42, 71, 61, 61, 273, 273, 273, -70,
+	13, -43, -34, -42, -47, 25, -3, -3, -77, -79,
+	52, 21, -87, -86, 280, -117, -116, -115, -130, 338,
+	269, 340, 341, 270, 343, 342, -129, 316, 315, 29,
+	115, 114, 267, 319, -38, -110, -109, 328, 329, 30,
+	330, -38, -42, 59, 266, -54, -50, -49, -49, 73,
+	22, -49, -62, 39, 354, 354, -51, 89, 354, -67,
+	-59, 79, -43, -56, -94, 108, 111, 112, -81, -81,
+	-81, -81, -47, -3, -77, -79, -70, -43, 52, 52,
+	52, -74, 14, -42, -52, -50, 354, 354, -114, -113,
+	-56, -128, 273, 28, -103, 334, 71, 281, 282, 59,
+	-129, 339, 273, 28, -128, -103, 348, 334, 339, 339,
+	339, 317, 273, 28, 335, 348, 334, 258, 258, 80,
+	80, 115, 114, 267, 30, 80, 80, 80, 22, 331,
+	-68, 11, -49, -49, 73, -49, -70, 14, 354, -49,
+	100, -49, 78, 109, 110, 108, -78, 71, 354, 354,
+	-78, -74, -41, -91, -41, -41, -71, -72, -49, 59,
+	52, 354, 59, -125, -126, -127, 283, 284, 285, 286,
+	287, 288, 289, 290, 291, 292, 293, 294, 295, 296,
+	297, 298, 299, 300, 301, 302, 303, 304, 120, 309,
+	310, 311, 312, 313, 305, 306, 307, 308, 314, 30,
+	317, 278, 335, 348, -91, -91, -91, -38, -115, -56,
+	-91, -91, 317, 278, 335, -91, -91, -56, -56, -56,
+	28, -91, -91, 28, -91, -91, -91, 49, 30, 80,
+	80, 80, -92, -93, 157, 158, 159, 160, 161, 162,
+	120, 163, 164, 165, 166, 167, 168, 169, 170, 171,
+	172, 173, 174, 175, 176, 177, 178, 179, 180, 181,
+	182, 183, 184, 185, 186, 187, 188, 189, 190, 191,
+	192, 193, 194, 195, 196, 197, 198, 199, 200, 201,
+	202, 203, 204, 205, 206, 207, 208, 209, 210, 211,
+	212, 213, 214, 215, 216, 217, 218, 219, 220, 221,
+	222, 223, 224, 225, 226, 227, 228, 229, 230, 231,
+	232, 233, 234, 235, 236, 237, 238, 239, 240, 241,
+	242, 48, -69, 12, 14, -49, -63, -64, 40, 41,
+	-51, 354, -49, 27, -78, 52, 354, 59, 354, 354,
+	59, -73, 23, 24, -50, -3, -131, -130, -113, -104,
+	-103, -128, -121, -119, -96, -84, 315, 22, 74, 29,
+	344, 52, -122, 52, 332, -122, 52, -122, 52, -122,
+	52, -122, 52, -122, 52, -122, 52, -122, 52, -122,
+	52, -122, 52, 52, 52, 52, -124, 52, 120, -124,
+	52, 52, 52, 52, 52, -124, -124, -124, -124, 52,
+	52, 28, -91, 273, 28, 28, 52, -87, -87, 52,
+	-125, -87, -87, 28, -91, 273, 28, 28, -102, 349,
+	74, -102, -56, -125, -91, 80, -92, -93, -92, -70,
+	-43, -51, 354, -65, 75, 44, 43, -49, 28, -3,
+	-91, -72, 354, -98, 278, 28, 317, -121, -96, -121,
+	-119, 20, 22, -48, -50, 52, 345, 49, -123, 333,
+	49, -123, 49, -123, 49, -123, 49, -123, 49, -123,
+	49, -123, 49, -123, 49, -123, 49, -123, 49, 49,
+	49, 49, -111, 115, 49, -111, 49, 49, 49, 49,
+	49, -111, -111, -111, -111, -118, -48, -118, -87, -87,
+	-91, -91, 52, -43, 52, 52, -90, -89, -56, 52,
+	-133, -132, 336, 337, 52, 52, -87, -87, -91, -91,
+	52, 349, -125, -133, -92, -74, -65, 45, 46, 42,
+	45, 46, 7, 354, -97, 319, 28, 28, -98, -121,
+	-98, -121, 52, -43, 354, 354, 354, 354, 354, 354,
+	354, 59, 59, 59, 354, 59, 354, 354, 354, -112,
+	267, 30, 354, -112, 354, 354, 354, 354, 354, -112,
+	-112, -112, -112, 59, 354, 354, 52, 52, -87, -87,
+	-90, 354, -90, -90, 354, 59, -73, 52, -33, -56,
+	-56, -90, -90, 52, 52, -87, -87, -90, -133, -75,
+	16, 31, 73, -79, -78, -99, 320, 48, -97, -98,
+	-97, -98, -33, 354, -122, -122, -122, -122, -122, -122,
+	49, 49, 49, -122, 49, -93, -92, -124, -124, -124,
+	-124, -48, -111, -111, -90, -90, 52, 52, 354, -102,
+	354, 354, -88, -86, -89, 49, 354, 354, 354, -90,
+	-90, 52, 52, 354, 7, 89, -65, -100, 250, 321,
+	322, 29, -99, -97, -99, -97, 354, -123, -123, -123,
+	-123, -123, -123, 354, 354, 354, -123, 354, -111, -111,
+	-111, -111, -112, -112, 354, 354, -90, -90, -105, 318,
+	354, -73, 354, 354, -90, -90, -105, -91, -106, -105,
+	323, 324, 29, -100, -99, -100, -99, -101, 346, 347,
+	-122, -122, -122, -122, -112, -112, -112, -112, -97, 354,
+	354, -38, -73, 354, 354, -91, -106, -100, -106, -100,
+	-120, -119, -123, -123, -123, -123, 52, -106, -106, -98,
+	-90, -97, 354, -108, 325, -107, 71, 60, 326, 327,
+	8, 7, -109, -109, 71, 71, 7, 8, -109, -109,
 }
-var yyDef = [...]int{
 
-	101, -2, 1, 2, 3, 4, 5, 6, 7, 8,
+var yyDef = [...]int16{
+	115, -2, 1, 2, 3, 4, 5, 6, 7, 8,
 	9, 10, 11, 12, 13, 14, 15, 16, 17, 18,
-	19, 20, 99, 99, 99, 99, 99, 99, 99, 99,
-	0, 99, 99, 99, 99, 48, 0, 50, 51, 0,
-	0, 0, 103, 105, 106, 107, 102, 108, 101, 398,
-	398, 94, 0, 96, 97, 0, 250, 0, 0, 0,
-	30, 31, 32, 33, 34, 35, 36, 37, 38, 39,
-	40, 41, 252, 250, 0, 0, 49, 52, 265, 266,
-	53, 0, 0, 23, 104, 0, 109, 100, 0, 0,
-	0, 0, 399, 400, 0, 401, 401, 0, 401, 401,
-	401, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 91, 0, 95, 98, 136, 0, 251, 0,
-	0, 0, 248, 0, 253, 254, 0, 0, 246, 0,
-	54, 55, 230, 110, 112, 265, 117, 115, 116, 148,
-	0, 0, 179, 180, 181, 0, 191, 192, 0, 214,
-	215, 216, 217, 212, 175, 201, 202, 203, 0, 0,
-	205, 199, 200, 42, 0, 243, 0, 212, 265, 0,
-	44, 267, 268, 269, 270, 271, 272, 273, 274, 275,
-	276, 277, 278, 279, 280, 281, 282, 283, 284, 285,
-	286, 287, 288, 289, 290, 291, 292, 293, 294, 295,
-	296, 297, 298, 299, 300, 301, 302, 303, 304, 305,
-	306, 45, 401, 63, 0, 0, 64, 401, 401, 67,
-	68, 69, 0, 401, 0, 0, 92, 401, 0, 0,
-	401, 401, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 93, 0, 0, 0, 146, 237, 0, 0,
-	0, 0, 0, 0, 0, 0, 21, 0, 0, 0,
-	0, 114, 118, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	163, 164, 165, 166, 167, 168, 169, 151, 0, 0,
-	0, 0, 177, 190, 0, 162, 0, 0, 0, 0,
-	0, 206, 0, 0, 0, 0, 43, 0, 62, 402,
-	403, 65, 66, 401, 71, 0, 0, 0, 73, 0,
-	401, 401, 79, 80, 146, 146, 146, 401, 85, 86,
-	87, 88, 89, 90, 137, 237, 146, 222, 0, 0,
-	0, 0, 0, 0, 259, 534, 0, 503, 247, 0,
-	146, 120, 117, 0, 134, 135, 111, 231, 113, 213,
-	119, 149, 150, 153, 154, 0, 0, 0, 156, 0,
-	160, 0, 182, 183, 184, 185, 186, 187, 188, 189,
-	152, 174, 0, 176, 177, 193, 0, 0, 0, 0,
-	0, 210, 207, 0, 244, 0, 245, 46, 70, 401,
-	401, 401, 401, 75, 76, 81, 82, 83, 84, 0,
-	0, 222, 230, 0, 147, 26, 0, 171, 27, 0,
-	239, 519, 249, 0, 260, 0, 58, 535, 536, 538,
-	519, 0, 0, 0, 0, 0, 523, 0, 0, 0,
-	0, 0, 0, 0, 59, 60, 504, 505, 506, 0,
-	0, 61, 218, 0, 0, 125, 126, 0, 0, 0,
-	0, 0, 138, 0, 0, 0, 155, 157, 0, 0,
-	161, 178, 194, 195, 0, 0, 198, 0, 208, 0,
-	0, 47, 0, 0, 397, 72, 77, 78, 74, 241,
-	241, 230, 29, 0, 170, 172, 238, 0, 0, 404,
-	0, 0, 0, 0, 0, 0, 261, 262, 0, 524,
+	19, 20, 113, 25, 113, 113, 113, 113, 113, 113,
+	113, 0, 113, 113, 113, 113, 62, 0, 64, 65,
+	0, 0, 0, 117, 119, 120, 121, 116, 125, 115,
+	0, 26, 442, 442, 108, 0, 110, 111, 0, 285,
+	0, 0, 0, 44, 45, 46, 47, 48, 49, 50,
+	51, 52, 53, 54, 55, 287, 285, 0, 0, 63,
+	66, 301, 302, 303, 304, 305, 306, 307, 308, 309,
+	310, 67, 0, 0, 23, 118, 0, 122, 125, 126,
+	114, 0, 27, 272, 0, 0, 0, 0, 443, 444,
+	0, 445, 445, 0, 445, 445, 445, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 105, 0,
+	109, 112, 153, 0, 286, 0, 137, 134, 0, 151,
+	152, 0, 0, 42, 0, 283, 0, 288, 289, 0,
+	0, 281, 0, 68, 69, 265, 127, 129, 301, 134,
+	132, 133, 165, 0, 0, 200, 201, 202, 0, 212,
+	214, 0, 249, 250, 251, 252, 247, 196, 236, 237,
+	238, 0, 0, 240, 234, 235, 123, 126, 124, 24,
+	0, 0, 0, 56, 0, 278, 0, 247, 301, 0,
+	58, 311, 312, 313, 314, 315, 316, 317, 318, 319,
+	320, 321, 322, 323, 324, 325, 326, 327, 328, 329,
+	330, 331, 332, 333, 334, 335, 336, 337, 338, 339,
+	340, 341, 342, 343, 344, 345, 346, 347, 348, 349,
+	350, 59, 445, 77, 0, 0, 78, 445, 445, 81,
+	82, 83, 0, 445, 0, 0, 106, 445, 0, 0,
+	445, 445, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 107, 0, 0, 0, 0, 0, 142, 143,
+	0, 0, 0, 0, 0, 155, 135, 0, 0, 0,
+	-2, 0, 0, 0, 272, 0, 0, 0, 0, 0,
+	0, 0, 0, 21, 0, 0, 0, 0, 131, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 554, 555, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 507, 508, 220, 0, 121, 0, 127, 0,
-	129, 0, 131, 132, 133, 122, 0, 0, 0, 123,
-	232, 233, 0, 158, 196, 0, 204, 211, 0, 394,
-	395, 396, 24, 0, 25, 28, 223, 224, 227, 0,
-	240, 521, 519, 406, 474, 419, 509, 423, 424, 509,
-	509, 509, 509, 509, 509, 509, 509, 509, 444, 445,
-	447, 449, 451, 513, 513, 0, 0, 458, 0, 461,
-	462, 463, 464, 513, 513, 513, 513, 0, 0, 0,
-	0, 0, 259, 259, 520, 0, 537, 0, 259, 259,
-	0, 0, 0, 0, 0, 549, 550, 551, 552, 0,
-	525, 526, 0, 0, 0, 0, 530, 532, 307, 308,
-	309, 310, 311, 312, 313, 314, 315, 316, 317, 318,
-	319, 320, 321, 322, 323, 324, 325, 326, 327, 328,
-	329, 330, 331, 332, 333, 334, 335, 336, 337, 338,
-	339, 340, 341, 342, 343, 344, 345, 346, 347, 348,
-	349, 350, 351, 352, 353, 354, 355, 356, 357, 358,
-	359, 360, 361, 362, 363, 364, 365, 366, 367, 368,
-	369, 370, 371, 372, 373, 374, 375, 376, 377, 378,
-	379, 380, 381, 382, 383, 384, 385, 386, 387, 388,
-	389, 390, 391, 392, 393, 533, 222, 0, 0, 0,
-	128, 130, 0, 0, 0, 159, 197, 209, 0, 0,
-	226, 228, 229, 173, 56, 522, 405, 476, 474, 474,
-	475, 471, 0, 0, 0, 511, 0, 510, 511, 0,
-	511, 0, 511, 0, 511, 0, 511, 0, 511, 0,
-	511, 0, 511, 0, 511, 0, 0, 0, 0, 515,
-	0, 514, 515, 0, 0, 0, 0, 0, 515, 515,
-	515, 515, 0, 0, 259, 259, 0, 0, 0, 0,
-	0, 0, 556, 0, 0, 259, 259, 0, 0, 0,
-	0, 556, 553, 0, 529, 531, 528, 230, 221, 219,
-	124, 0, 0, 0, 0, 225, 481, 477, 479, 0,
-	476, 474, 476, 474, 472, 473, 0, 421, 512, 0,
-	425, 0, 427, 0, 429, 0, 431, 0, 433, 0,
-	435, 0, 437, 0, 439, 0, 441, 0, 0, 0,
-	0, 517, 0, 0, 517, 0, 0, 0, 0, 0,
-	517, 517, 517, 517, 0, 144, 0, 0, 0, 259,
-	259, 0, 0, 0, 0, 255, 227, 539, 557, 0,
-	0, 0, 0, 0, 0, 259, 259, 0, 556, 548,
-	527, 234, 0, 142, 0, 0, 0, 483, 0, 478,
-	480, 481, 476, 481, 476, 420, 509, 509, 509, 509,
-	509, 509, 0, 0, 0, 509, 0, 446, 448, 450,
-	452, 0, 0, 513, 453, 513, 513, 513, 459, 460,
-	465, 466, 467, 468, 0, 515, 515, 0, 0, 0,
-	0, 0, 0, 0, 263, 0, 257, 0, 558, 559,
-	0, 0, 0, 0, 0, 0, 0, 547, 22, 0,
-	0, 139, 0, 140, 141, 242, 487, 0, 482, 483,
-	481, 483, 481, 511, 511, 511, 511, 511, 511, 0,
-	0, 0, 511, 0, 518, 516, 515, 515, 515, 515,
-	145, 517, 517, 0, 0, 0, 0, 0, 408, 409,
-	57, 264, 256, 0, 540, 541, 0, 0, 0, 0,
-	0, 235, 0, 143, 491, 0, 484, 485, 486, 487,
-	483, 487, 483, 422, 426, 428, 430, 432, 434, 509,
-	509, 509, 442, 509, 517, 517, 517, 517, 469, 470,
-	481, 410, 0, 0, 413, 0, 227, 542, 543, 0,
-	0, 546, 0, 414, 492, 488, 489, 490, 491, 487,
-	491, 487, 511, 511, 511, 511, 454, 455, 456, 457,
-	407, 411, 412, 0, 258, 544, 545, 236, 415, 491,
-	416, 491, 436, 438, 440, 443, 0, 417, 418, 0,
-	494, 498, 0, 493, 0, 495, 496, 497, 0, 0,
-	499, 500, 0, 0, 0, 0, 502, 501,
+	0, 0, 0, 0, 0, 181, 182, 183, 184, 185,
+	186, 187, 168, 0, 0, 0, 198, 211, 0, 0,
+	180, 0, 0, 0, 0, 0, 241, 28, 0, 0,
+	274, 0, 0, 0, 0, 57, 0, 76, 446, 447,
+	79, 80, 445, 85, 0, 0, 0, 87, 0, 445,
+	445, 93, 94, 163, 163, 163, 445, 99, 100, 101,
+	102, 103, 104, 154, 272, 163, 138, 0, 144, 0,
+	146, 0, 148, 149, 150, 139, 0, 0, 0, 136,
+	140, 197, 257, 0, 0, 163, 43, 0, 0, 0,
+	0, 0, 295, 596, 0, 565, 282, 0, 163, 128,
+	266, 130, 248, 166, 167, 170, 0, 188, 189, 190,
+	172, 0, 0, 0, 174, 0, 178, 0, 203, 204,
+	205, 206, 207, 208, 209, 210, 169, 195, 0, 198,
+	213, 222, 215, 0, 0, 0, 0, 0, 245, 242,
+	0, 29, 273, 0, 279, 0, 280, 60, 84, 445,
+	445, 445, 445, 89, 90, 95, 96, 97, 98, 0,
+	0, 0, 257, 0, 145, 147, 0, 0, 0, 265,
+	0, 164, 163, 40, 34, 0, 192, 0, 0, 37,
+	581, 284, 0, 296, 0, 72, 597, 598, 600, 581,
+	0, 0, 0, 0, 0, 0, 585, 0, 0, 0,
+	0, 0, 0, 0, 73, 74, 566, 567, 568, 0,
+	0, 75, 253, 0, 0, 171, 173, 175, 0, 0,
+	179, 199, 257, 0, 216, 217, 0, 0, 220, 0,
+	243, 0, 0, 275, 61, 0, 0, 441, 86, 91,
+	92, 88, 276, 0, 0, 276, 265, 141, 0, 0,
+	0, 39, 0, 41, 191, 193, 35, 273, 0, 448,
+	0, 0, 0, 0, 458, 0, 0, 297, 298, 0,
+	586, 0, 0, 0, 0, 609, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 621, 622, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 569, 570,
+	255, 0, 267, 268, 0, 176, 224, 0, 218, 0,
+	239, 246, 0, 438, 439, 440, 30, 0, 276, 273,
+	33, 38, 0, 159, 0, 0, 258, 259, 262, 0,
+	0, 583, 581, 450, -2, -2, 477, 571, 481, 482,
+	571, 571, 571, 571, 571, 571, 571, 571, 571, 502,
+	503, 505, 507, 509, 575, 575, 0, 0, 516, 0,
+	519, 520, 521, 522, 575, 575, 575, 575, 0, 0,
+	0, 0, 0, 0, 295, 295, 582, 0, 599, 0,
+	295, 295, 0, 0, 0, 462, 462, 0, 0, 614,
+	615, 616, 617, 0, 619, 620, 587, 588, 0, 0,
+	0, 0, 592, 594, 351, 352, 353, 354, 355, 356,
+	357, 358, 359, 360, 361, 362, 363, 364, 365, 366,
+	367, 368, 369, 370, 371, 372, 373, 374, 375, 376,
+	377, 378, 379, 380, 381, 382, 383, 384, 385, 386,
+	387, 388, 389, 390, 391, 392, 393, 394, 395, 396,
+	397, 398, 399, 400, 401, 402, 403, 404, 405, 406,
+	407, 408, 409, 410, 411, 412, 413, 414, 415, 416,
+	417, 418, 419, 420, 421, 422, 423, 424, 425, 426,
+	427, 428, 429, 430, 431, 432, 433, 434, 435, 436,
+	437, 595, 257, 0, 0, 177, 0, 0, 227, 228,
+	223, 219, 244, 0, 31, 0, 156, 0, 157, 158,
+	0, 261, 263, 264, 194, 0, 70, 584, 449, 465,
+	461, 0, 538, 536, 536, 0, 537, 529, 0, 0,
+	0, 0, 573, 0, 572, 573, 0, 573, 0, 573,
+	0, 573, 0, 573, 0, 573, 0, 573, 0, 573,
+	0, 573, 0, 0, 0, 0, 577, 0, 576, 577,
+	0, 0, 0, 0, 0, 577, 577, 577, 577, 0,
+	0, 295, 295, 0, 0, 0, 0, 0, 0, 0,
+	623, 0, 0, 295, 295, 0, 0, 0, 610, 463,
+	0, 611, 0, 623, 618, 0, 591, 593, 590, 265,
+	256, 254, 221, 225, 0, 0, 303, 0, 0, 0,
+	160, 260, 36, 543, 539, 541, 0, 538, 536, 538,
+	536, 0, 530, 533, 534, 0, 473, 0, 479, 574,
+	0, 483, 0, 485, 0, 487, 0, 489, 0, 491,
+	0, 493, 0, 495, 0, 497, 0, 499, 0, 0,
+	0, 0, 579, 0, 0, 579, 0, 0, 0, 0,
+	0, 579, 579, 579, 579, 0, 161, 0, 0, 0,
+	295, 295, 0, 0, 0, 0, 0, 290, 262, 0,
+	601, 624, 0, 0, 0, 0, 0, 0, 295, 295,
+	0, 464, 623, 613, 589, 269, 0, 229, 230, 231,
+	232, 233, 0, 276, 545, 0, 540, 542, 543, 538,
+	543, 538, 0, 0, 478, 571, 571, 571, 571, 571,
+	571, 0, 0, 0, 571, 0, 504, 506, 508, 510,
+	0, 0, 575, 511, 575, 575, 575, 517, 518, 523,
+	524, 525, 526, 0, 577, 577, 0, 0, 0, 0,
+	0, 462, 0, 0, 299, 0, 292, 0, 0, 625,
+	626, 0, 0, 0, 0, 0, 0, 0, 612, 22,
+	0, 0, 0, 277, 32, 549, 0, 544, 545, 543,
+	545, 543, 0, 535, 573, 573, 573, 573, 573, 573,
+	0, 0, 0, 573, 0, 580, 578, 577, 577, 577,
+	577, 162, 579, 579, 0, 0, 0, 0, 0, 459,
+	452, 453, 71, 300, 291, 0, 262, 602, 603, 0,
+	0, 0, 0, 0, 270, 0, 226, 553, 0, 546,
+	547, 548, 549, 545, 549, 545, 474, 480, 484, 486,
+	488, 490, 492, 571, 571, 571, 500, 571, 579, 579,
+	579, 579, 527, 528, 543, 454, 0, 0, 457, 0,
+	262, 294, 604, 605, 0, 0, 608, 0, 466, 554,
+	550, 551, 552, 553, 549, 553, 549, 531, 475, 476,
+	573, 573, 573, 573, 512, 513, 514, 515, 451, 455,
+	456, 0, 293, 606, 607, 271, 467, 553, 468, 553,
+	538, 532, 494, 496, 498, 501, 0, 469, 470, 543,
+	0, 471, 556, 560, 0, 555, 0, 557, 558, 559,
+	0, 0, 561, 562, 0, 0, 0, 0, 564, 563,
 }
-var yyTok1 = [...]int{
 
+var yyTok1 = [...]int16{
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 82, 77, 3,
-	39, 334, 80, 78, 45, 79, 84, 81, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 96, 91, 3,
+	52, 354, 94, 92, 59, 93, 98, 95, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	67, 66, 68, 3, 3, 3, 3, 3, 3, 3,
+	81, 80, 82, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 83, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 97, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 76, 3, 40,
+	3, 3, 3, 3, 90, 3, 53,
 }
-var yyTok2 = [...]int{
 
+var yyTok2 = [...]int16{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
-	32, 33, 34, 35, 36, 37, 38, 41, 42, 43,
-	44, 46, 47, 48, 49, 50, 51, 52, 53, 54,
-	55, 56, 57, 58, 59, 60, 61, 62, 63, 64,
-	65, 69, 70, 71, 72, 73, 74, 75, 85, 86,
-	87, 88, 89, 90, 91, 92, 93, 94, 95, 96,
-	97, 98, 99, 100, 101, 102, 103, 104, 105, 106,
+	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
+	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
+	54, 55, 56, 57, 58, 60, 61, 62, 63, 64,
+	65, 66, 67, 68, 69, 70, 71, 72, 73, 74,
+	75, 76, 77, 78, 79, 83, 84, 85, 86, 87,
+	88, 89, 99, 100, 101, 102, 103, 104, 105, 106,
 	107, 108, 109, 110, 111, 112, 113, 114, 115, 116,
 	117, 118, 119, 120, 121, 122, 123, 124, 125, 126,
 	127, 128, 129, 130, 131, 132, 133, 134, 135, 136,
@@ -1471,7 +1643,8 @@ var yyTok2 = [...]int{
 	257, 258, 259, 260, 261, 262, 263, 264, 265, 266,
 	267, 268, 269, 270, 271, 272,
 }
-var yyTok3 = [...]int{
+
+var yyTok3 = [...]uint16{
 	57600, 273, 57601, 274, 57602, 275, 57603, 276, 57604, 277,
 	57605, 278, 57606, 279, 57607, 280, 57608, 281, 57609, 282,
 	57610, 283, 57611, 284, 57612, 285, 57613, 286, 57614, 287,
@@ -1484,7 +1657,11 @@ var yyTok3 = [...]int{
 	57645, 318, 57646, 319, 57647, 320, 57648, 321, 57649, 322,
 	57650, 323, 57651, 324, 57652, 325, 57653, 326, 57654, 327,
 	57655, 328, 57656, 329, 57657, 330, 57658, 331, 57659, 332,
-	57660, 333, 0,
+	57660, 333, 57661, 334, 57662, 335, 57663, 336, 57664, 337,
+	57665, 338, 57666, 339, 57667, 340, 57668, 341, 57669, 342,
+	57670, 343, 57671, 344, 57672, 345, 57673, 346, 57674, 347,
+	57675, 348, 57676, 349, 57677, 350, 57678, 351, 57679, 352,
+	57680, 353, 0,
 }
 
 var yyErrorMessages = [...]struct {
@@ -1565,9 +1742,9 @@ func yyErrorMessage(state, lookAhead int) string {
 	expected := make([]int, 0, 4)
 
 	// Look for shiftable tokens.
-	base := yyPact[state]
+	base := int(yyPact[state])
 	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
-		if n := base + tok; n >= 0 && n < yyLast && yyChk[yyAct[n]] == tok {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
 			if len(expected) == cap(expected) {
 				return res
 			}
@@ -1577,13 +1754,13 @@ func yyErrorMessage(state, lookAhead int) string {
 
 	if yyDef[state] == -2 {
 		i := 0
-		for yyExca[i] != -1 || yyExca[i+1] != state {
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
 			i += 2
 		}
 
 		// Look for tokens that we accept or reduce.
 		for i += 2; yyExca[i] >= 0; i += 2 {
-			tok := yyExca[i]
+			tok := int(yyExca[i])
 			if tok < TOKSTART || yyExca[i+1] == 0 {
 				continue
 			}
@@ -1614,30 +1791,30 @@ func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
 	token = 0
 	char = lex.Lex(lval)
 	if char <= 0 {
-		token = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		token = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			token = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		token = yyTok3[i+0]
+		token = int(yyTok3[i+0])
 		if token == char {
-			token = yyTok3[i+1]
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
 	if token == 0 {
-		token = yyTok2[1] /* unknown char */
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
 		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
@@ -1692,7 +1869,7 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
@@ -1703,8 +1880,8 @@ yynewstate:
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yytoken { /* valid shift */
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
 		yyrcvr.char = -1
 		yytoken = -1
 		yyVAL = yyrcvr.lval
@@ -1717,7 +1894,7 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
 		if yyrcvr.char < 0 {
 			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
@@ -1726,18 +1903,18 @@ yydefault:
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
+			yyn = int(yyExca[xi+0])
 			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -1759,10 +1936,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -1798,7 +1975,7 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
 	// yyp is now the index of $0. Perform the default action. Iff the
 	// reduced production is ε, $1 is possibly out of range.
 	if yyp+1 >= len(yyS) {
@@ -1809,16 +1986,16 @@ yydefault:
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
@@ -1826,91 +2003,157 @@ yydefault:
 
 	case 1:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:294
+//line yacc.y:322
 		{
 			SetParseTree(yylex, yyDollar[1].statement)
 		}
 	case 2:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:300
+//line yacc.y:328
 		{
 			yyVAL.statement = yyDollar[1].selStmt
 		}
 	case 3:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:302
+//line yacc.y:330
 		{
 			yyVAL.statement = yyDollar[1].setStmt
 		}
 	case 4:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:304
+//line yacc.y:332
 		{
 			yyVAL.statement = yyDollar[1].showStmt
 		}
 	case 5:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:306
+//line yacc.y:334
 		{
 			yyVAL.statement = yyDollar[1].showStmt
 		}
 	case 11:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:313
+//line yacc.y:341
 		{
 			yyVAL.statement = yyDollar[1].ddlStmt
 		}
 	case 12:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:315
+//line yacc.y:343
 		{
 			yyVAL.statement = yyDollar[1].ddlStmt
 		}
 	case 13:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:317
+//line yacc.y:345
 		{
 			yyVAL.statement = yyDollar[1].ddlStmt
 		}
 	case 14:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:319
+//line yacc.y:347
 		{
 			yyVAL.statement = yyDollar[1].ddlStmt
 		}
 	case 20:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:326
+//line yacc.y:354
 		{
 			yyVAL.statement = nil
 		}
 	case 21:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:330
+//line yacc.y:358
 		{
-			yyVAL.selStmt = &SimpleSelect{Comments: Comments(yyDollar[2].bytes2), Distinct: yyDollar[3].str, SelectExprs: yyDollar[4].selectExprs, Limit: yyDollar[5].limit}
+			yyVAL.selStmt = &SimpleSelect{Comments: Comments(yyDollar[2].bytes2), CalcFoundRows: yyDollar[3].selOpts.calcFoundRows, Distinct: yyDollar[3].selOpts.distinct, SelectExprs: yyDollar[4].selectExprs, Limit: yyDollar[5].limit}
 		}
 	case 22:
 		yyDollar = yyS[yypt-12 : yypt+1]
-		//line yacc.y:334
+//line yacc.y:362
 		{
-			yyVAL.selStmt = &Select{Comments: Comments(yyDollar[2].bytes2), Distinct: yyDollar[3].str, SelectExprs: yyDollar[4].selectExprs, From: yyDollar[6].tableExprs, Where: NewWhere(AST_WHERE, yyDollar[7].boolExpr), GroupBy: GroupBy(yyDollar[8].valExprs), Having: NewWhere(AST_HAVING, yyDollar[9].boolExpr), OrderBy: yyDollar[10].orderBy, Limit: yyDollar[11].limit, Lock: yyDollar[12].str}
+			yyVAL.selStmt = &Select{Comments: Comments(yyDollar[2].bytes2), CalcFoundRows: yyDollar[3].selOpts.calcFoundRows, Distinct: yyDollar[3].selOpts.distinct, SelectExprs: yyDollar[4].selectExprs, From: yyDollar[6].tableExprs, Where: NewWhere(AST_WHERE, yyDollar[7].boolExpr), GroupBy: GroupBy(yyDollar[8].valExprs), Having: NewWhere(AST_HAVING, yyDollar[9].boolExpr), OrderBy: yyDollar[10].orderBy, Limit: yyDollar[11].limit, Lock: yyDollar[12].str}
 		}
 	case 23:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:338
+//line yacc.y:366
 		{
 			yyVAL.selStmt = &Union{Type: yyDollar[2].str, Left: yyDollar[1].selStmt, Right: yyDollar[3].selStmt}
 		}
 	case 24:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line yacc.y:370
+		{
+			with := &With{Recursive: yyDollar[2].boolean, CTEs: yyDollar[3].ctes}
+			switch sel := yyDollar[4].selStmt.(type) {
+			case *Select:
+				if sel.With != nil {
+					yylex.Error("duplicate with")
+					return 1
+				}
+				sel.With = with
+			case *Union:
+				if sel.With != nil {
+					yylex.Error("duplicate with")
+					return 1
+				}
+				sel.With = with
+			default:
+				yylex.Error("expecting from")
+				return 1
+			}
+			yyVAL.selStmt = yyDollar[4].selStmt
+		}
+	case 25:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line yacc.y:393
+		{
+			yyVAL.boolean = false
+		}
+	case 26:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:397
+		{
+			yyVAL.boolean = true
+		}
+	case 27:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:403
+		{
+			yyVAL.ctes = []*CommonTableExpr{yyDollar[1].cte}
+		}
+	case 28:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line yacc.y:407
+		{
+			yyVAL.ctes = append(yyDollar[1].ctes, yyDollar[3].cte)
+		}
+	case 29:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line yacc.y:413
+		{
+			yyVAL.cte = &CommonTableExpr{Name: yyDollar[1].bytes, Columns: yyDollar[2].columns, Select: yyDollar[4].subquery.Select}
+		}
+	case 30:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:344
+//line yacc.y:419
 		{
 			yyVAL.statement = &Insert{Comments: Comments(yyDollar[2].bytes2), Ignore: yyDollar[3].str, Table: yyDollar[5].tableName, Columns: yyDollar[6].columns, Rows: yyDollar[7].insRows, OnDup: OnDup(yyDollar[8].updateExprs)}
 		}
-	case 25:
+	case 31:
+		yyDollar = yyS[yypt-9 : yypt+1]
+//line yacc.y:423
+		{
+			yyVAL.statement = &Insert{Comments: Comments(yyDollar[2].bytes2), Ignore: yyDollar[3].str, Table: yyDollar[5].tableName, Rows: yyDollar[7].selStmt, OnDup: OnDup(yyDollar[9].updateExprs)}
+		}
+	case 32:
+		yyDollar = yyS[yypt-12 : yypt+1]
+//line yacc.y:427
+		{
+			yyVAL.statement = &Insert{Comments: Comments(yyDollar[2].bytes2), Ignore: yyDollar[3].str, Table: yyDollar[5].tableName, Columns: yyDollar[7].columns, Rows: yyDollar[10].selStmt, OnDup: OnDup(yyDollar[12].updateExprs)}
+		}
+	case 33:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:348
+//line yacc.y:431
 		{
 			cols := make(Columns, 0, len(yyDollar[7].updateExprs))
 			vals := make(ValTuple, 0, len(yyDollar[7].updateExprs))
@@ -1920,15 +2163,27 @@ yydefault:
 			}
 			yyVAL.statement = &Insert{Comments: Comments(yyDollar[2].bytes2), Ignore: yyDollar[3].str, Table: yyDollar[5].tableName, Columns: cols, Rows: Values{vals}, OnDup: OnDup(yyDollar[8].updateExprs)}
 		}
-	case 26:
+	case 34:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:360
+//line yacc.y:443
 		{
 			yyVAL.statement = &Replace{Comments: Comments(yyDollar[2].bytes2), Table: yyDollar[4].tableName, Columns: yyDollar[5].columns, Rows: yyDollar[6].insRows}
 		}
-	case 27:
+	case 35:
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line yacc.y:447
+		{
+			yyVAL.statement = &Replace{Comments: Comments(yyDollar[2].bytes2), Table: yyDollar[4].tableName, Rows: yyDollar[6].selStmt}
+		}
+	case 36:
+		yyDollar = yyS[yypt-10 : yypt+1]
+//line yacc.y:451
+		{
+			yyVAL.statement = &Replace{Comments: Comments(yyDollar[2].bytes2), Table: yyDollar[4].tableName, Columns: yyDollar[6].columns, Rows: yyDollar[9].selStmt}
+		}
+	case 37:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:364
+//line yacc.y:455
 		{
 			cols := make(Columns, 0, len(yyDollar[6].updateExprs))
 			vals := make(ValTuple, 0, len(yyDollar[6].updateExprs))
@@ -1938,93 +2193,125 @@ yydefault:
 			}
 			yyVAL.statement = &Replace{Comments: Comments(yyDollar[2].bytes2), Table: yyDollar[4].tableName, Columns: cols, Rows: Values{vals}}
 		}
-	case 28:
+	case 38:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:376
+//line yacc.y:467
 		{
-			yyVAL.statement = &Update{Comments: Comments(yyDollar[2].bytes2), Table: yyDollar[3].tableName, Exprs: yyDollar[5].updateExprs, Where: NewWhere(AST_WHERE, yyDollar[6].boolExpr), OrderBy: yyDollar[7].orderBy, Limit: yyDollar[8].limit}
+			update := &Update{Comments: Comments(yyDollar[2].bytes2), Exprs: yyDollar[5].updateExprs, Where: NewWhere(AST_WHERE, yyDollar[6].boolExpr), OrderBy: yyDollar[7].orderBy, Limit: yyDollar[8].limit}
+			if update.Table = singleTableName(yyDollar[3].tableExprs); update.Table == nil {
+				if yyDollar[7].orderBy != nil || yyDollar[8].limit != nil {
+					yylex.Error("order by or limit in multiple-table update")
+					return 1
+				}
+				update.TableExprs = yyDollar[3].tableExprs
+			}
+			yyVAL.statement = update
 		}
-	case 29:
+	case 39:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:382
+//line yacc.y:481
 		{
 			yyVAL.statement = &Delete{Comments: Comments(yyDollar[2].bytes2), Table: yyDollar[4].tableName, Where: NewWhere(AST_WHERE, yyDollar[5].boolExpr), OrderBy: yyDollar[6].orderBy, Limit: yyDollar[7].limit}
 		}
-	case 30:
+	case 40:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line yacc.y:485
+		{
+			yyVAL.statement = &Delete{Comments: Comments(yyDollar[2].bytes2), Targets: yyDollar[3].tableNames, TableExprs: yyDollar[5].tableExprs, Where: NewWhere(AST_WHERE, yyDollar[6].boolExpr)}
+		}
+	case 41:
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line yacc.y:489
+		{
+			yyVAL.statement = &Delete{Comments: Comments(yyDollar[2].bytes2), Targets: yyDollar[4].tableNames, TableExprs: yyDollar[6].tableExprs, Where: NewWhere(AST_WHERE, yyDollar[7].boolExpr)}
+		}
+	case 42:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:495
+		{
+			yyVAL.tableNames = TableNames{yyDollar[1].tableName}
+		}
+	case 43:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line yacc.y:499
+		{
+			yyVAL.tableNames = append(yyVAL.tableNames, yyDollar[3].tableName)
+		}
+	case 44:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:388
+//line yacc.y:505
 		{
 			yyVAL.statement = &Explain{Statement: yyDollar[2].selStmt}
 		}
-	case 31:
+	case 45:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:392
+//line yacc.y:509
 		{
 			yyVAL.statement = &Explain{Statement: yyDollar[2].statement}
 		}
-	case 32:
+	case 46:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:396
+//line yacc.y:513
 		{
 			yyVAL.statement = &Explain{Statement: yyDollar[2].statement}
 		}
-	case 33:
+	case 47:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:400
+//line yacc.y:517
 		{
 			yyVAL.statement = &Explain{Statement: yyDollar[2].statement}
 		}
-	case 34:
+	case 48:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:404
+//line yacc.y:521
 		{
 			yyVAL.statement = &Explain{Statement: yyDollar[2].statement}
 		}
-	case 35:
+	case 49:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:408
+//line yacc.y:525
 		{
 			yyVAL.statement = &Explain{Statement: yyDollar[2].setStmt}
 		}
-	case 36:
+	case 50:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:412
+//line yacc.y:529
 		{
 			yyVAL.statement = &Explain{Statement: yyDollar[2].showStmt}
 		}
-	case 37:
+	case 51:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:416
+//line yacc.y:533
 		{
 			yyVAL.statement = &Explain{Statement: yyDollar[2].showStmt}
 		}
-	case 38:
+	case 52:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:420
+//line yacc.y:537
 		{
 			yyVAL.statement = &Explain{Statement: yyDollar[2].ddlStmt}
 		}
-	case 39:
+	case 53:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:424
+//line yacc.y:541
 		{
 			yyVAL.statement = &Explain{Statement: yyDollar[2].ddlStmt}
 		}
-	case 40:
+	case 54:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:428
+//line yacc.y:545
 		{
 			yyVAL.statement = &Explain{Statement: yyDollar[2].ddlStmt}
 		}
-	case 41:
+	case 55:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:432
+//line yacc.y:549
 		{
 			yyVAL.statement = &Explain{Statement: yyDollar[2].ddlStmt}
 		}
-	case 42:
+	case 56:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:438
+//line yacc.y:555
 		{
 			yyVAL.setStmt = &SetVariable{
 				Comments: Comments(yyDollar[2].bytes2),
@@ -2032,36 +2319,36 @@ yydefault:
 				Exprs:    yyDollar[4].updateExprs,
 			}
 		}
-	case 43:
+	case 57:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:446
+//line yacc.y:563
 		{
 			yyVAL.setStmt = &SetCharset{
 				Comments: Comments(yyDollar[2].bytes2),
 				Charset:  string(yyDollar[5].bytes),
 			}
 		}
-	case 44:
+	case 58:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:453
+//line yacc.y:570
 		{
 			yyVAL.setStmt = &SetCharset{
 				Comments: Comments(yyDollar[2].bytes2),
 				Charset:  string(yyDollar[4].bytes),
 			}
 		}
-	case 45:
+	case 59:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:460
+//line yacc.y:577
 		{
 			yyVAL.setStmt = &SetNames{
 				Comments: Comments(yyDollar[2].bytes2),
 				Names:    string(yyDollar[4].bytes),
 			}
 		}
-	case 46:
+	case 60:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:467
+//line yacc.y:584
 		{
 			yyVAL.setStmt = &SetNames{
 				Comments: Comments(yyDollar[2].bytes2),
@@ -2069,9 +2356,9 @@ yydefault:
 				Collate:  string(yyDollar[6].bytes),
 			}
 		}
-	case 47:
+	case 61:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:475
+//line yacc.y:592
 		{
 			yyVAL.setStmt = &SetTransactionIsolationLevel{
 				Comments:       Comments(yyDollar[2].bytes2),
@@ -2079,856 +2366,904 @@ yydefault:
 				IsolationLevel: string(yyDollar[7].bytes),
 			}
 		}
-	case 48:
+	case 62:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:485
+//line yacc.y:602
 		{
 			yyVAL.statement = &Begin{}
 		}
-	case 49:
+	case 63:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:489
+//line yacc.y:606
 		{
 			yyVAL.statement = &Begin{}
 		}
-	case 50:
+	case 64:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:495
+//line yacc.y:612
 		{
 			yyVAL.statement = &Commit{}
 		}
-	case 51:
+	case 65:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:501
+//line yacc.y:618
 		{
 			yyVAL.statement = &Rollback{}
 		}
-	case 52:
+	case 66:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:507
+//line yacc.y:624
 		{
 			yyVAL.statement = &UseDB{DB: string(yyDollar[2].bytes)}
 		}
-	case 53:
+	case 67:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:514
+//line yacc.y:631
 		{
 			yyVAL.statement = &KillConnection{ConnectionID: NumVal(yyDollar[2].bytes)}
 		}
-	case 54:
+	case 68:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:518
+//line yacc.y:635
 		{
 			yyVAL.statement = &KillConnection{ConnectionID: NumVal(yyDollar[3].bytes)}
 		}
-	case 55:
+	case 69:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:522
+//line yacc.y:639
 		{
 			yyVAL.statement = &KillQuery{ConnectionID: NumVal(yyDollar[3].bytes)}
 		}
-	case 56:
+	case 70:
 		yyDollar = yyS[yypt-9 : yypt+1]
-		//line yacc.y:528
+//line yacc.y:645
 		{
 			yyVAL.ddlStmt = &CreateTable{Comments: Comments(yyDollar[2].bytes2), Table: yyDollar[5].tableName, CreateDefs: yyDollar[7].createDefs, TableOptions: yyDollar[9].optKeyVals}
 		}
-	case 57:
+	case 71:
 		yyDollar = yyS[yypt-12 : yypt+1]
-		//line yacc.y:532
+//line yacc.y:649
 		{
 			yyVAL.ddlStmt = &CreateIndex{Comments: Comments(yyDollar[2].bytes2), IndexCategory: yyDollar[3].bytes, Name: yyDollar[5].bytes, IndexType: yyDollar[6].bytes, Table: yyDollar[8].tableName, IndexColumns: yyDollar[10].idxColNames, IndexOption: yyDollar[12].bytes}
 		}
-	case 58:
+	case 72:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:538
+//line yacc.y:655
 		{
 			yyVAL.ddlStmt = &AlterTable{Comments: Comments(yyDollar[2].bytes2), Ignore: yyDollar[3].str, Table: yyDollar[5].tableName, AlterSpecs: yyDollar[6].alterSpecs}
 		}
-	case 59:
+	case 73:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:544
+//line yacc.y:661
 		{
 			yyVAL.ddlStmt = &RenameTable{Comments: Comments(yyDollar[2].bytes2), OldName: yyDollar[4].tableName, NewName: yyDollar[6].tableName}
 		}
-	case 60:
+	case 74:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:550
+//line yacc.y:667
 		{
 			yyVAL.ddlStmt = &DropTable{Comments: Comments(yyDollar[2].bytes2), Name: yyDollar[5].tableName, RefOption: yyDollar[6].bytes}
 		}
-	case 61:
+	case 75:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:554
+//line yacc.y:671
 		{
 			yyVAL.ddlStmt = &DropIndex{Comments: Comments(yyDollar[2].bytes2), Name: yyDollar[4].bytes, Table: yyDollar[6].tableName}
 		}
-	case 62:
+	case 76:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:560
+//line yacc.y:677
 		{
 			yyVAL.showStmt = &ShowCharset{Comments: Comments(yyDollar[2].bytes2), LikeOrWhere: yyDollar[5].expr}
 		}
-	case 63:
+	case 77:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:564
+//line yacc.y:681
 		{
 			yyVAL.showStmt = &ShowCharset{Comments: Comments(yyDollar[2].bytes2), LikeOrWhere: yyDollar[4].expr}
 		}
-	case 64:
+	case 78:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:568
+//line yacc.y:685
 		{
 			yyVAL.showStmt = &ShowCollation{Comments: Comments(yyDollar[2].bytes2), LikeOrWhere: yyDollar[4].expr}
 		}
-	case 65:
+	case 79:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:572
+//line yacc.y:689
 		{
 			yyVAL.showStmt = &ShowVariables{Comments: Comments(yyDollar[2].bytes2), Scope: string(yyDollar[3].bytes), LikeOrWhere: yyDollar[5].expr}
 		}
-	case 66:
+	case 80:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:576
+//line yacc.y:693
 		{
 			yyVAL.showStmt = &ShowStatus{Comments: Comments(yyDollar[2].bytes2), Scope: string(yyDollar[3].bytes), LikeOrWhere: yyDollar[5].expr}
 		}
-	case 67:
+	case 81:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:580
+//line yacc.y:697
 		{
 			yyVAL.showStmt = &ShowDatabases{Comments: Comments(yyDollar[2].bytes2), LikeOrWhere: yyDollar[4].expr}
 		}
-	case 68:
+	case 82:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:584
+//line yacc.y:701
 		{
 			yyVAL.showStmt = &ShowDatabases{Comments: Comments(yyDollar[2].bytes2), LikeOrWhere: yyDollar[4].expr}
 		}
-	case 69:
+	case 83:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:588
+//line yacc.y:705
 		{
 			yyVAL.showStmt = &ShowTables{Comments: Comments(yyDollar[2].bytes2), LikeOrWhere: yyDollar[4].expr}
 		}
-	case 70:
+	case 84:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:592
+//line yacc.y:709
 		{
 			yyVAL.showStmt = &ShowTables{Comments: Comments(yyDollar[2].bytes2), From: yyDollar[5].tableName, LikeOrWhere: yyDollar[6].expr}
 		}
-	case 71:
+	case 85:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:596
+//line yacc.y:713
 		{
 			yyVAL.showStmt = &ShowFullTables{Comments: Comments(yyDollar[2].bytes2), LikeOrWhere: yyDollar[5].expr}
 		}
-	case 72:
+	case 86:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:600
+//line yacc.y:717
 		{
 			yyVAL.showStmt = &ShowFullTables{Comments: Comments(yyDollar[2].bytes2), From: yyDollar[6].tableName, LikeOrWhere: yyDollar[7].expr}
 		}
-	case 73:
+	case 87:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:604
+//line yacc.y:721
 		{
 			yyVAL.showStmt = &ShowTableStatus{Comments: Comments(yyDollar[2].bytes2), LikeOrWhere: yyDollar[5].expr}
 		}
-	case 74:
+	case 88:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:608
+//line yacc.y:725
 		{
 			yyVAL.showStmt = &ShowTableStatus{Comments: Comments(yyDollar[2].bytes2), From: yyDollar[6].tableName, LikeOrWhere: yyDollar[7].expr}
 		}
-	case 75:
+	case 89:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:612
+//line yacc.y:729
 		{
 			yyVAL.showStmt = &ShowColumns{Comments: Comments(yyDollar[2].bytes2), From: yyDollar[5].tableName, LikeOrWhere: yyDollar[6].expr}
 		}
-	case 76:
+	case 90:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:616
+//line yacc.y:733
 		{
 			yyVAL.showStmt = &ShowColumns{Comments: Comments(yyDollar[2].bytes2), From: yyDollar[5].tableName, LikeOrWhere: yyDollar[6].expr}
 		}
-	case 77:
+	case 91:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:620
+//line yacc.y:737
 		{
 			yyVAL.showStmt = &ShowFullColumns{Comments: Comments(yyDollar[2].bytes2), From: yyDollar[6].tableName, LikeOrWhere: yyDollar[7].expr}
 		}
-	case 78:
+	case 92:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:624
+//line yacc.y:741
 		{
 			yyVAL.showStmt = &ShowFullColumns{Comments: Comments(yyDollar[2].bytes2), From: yyDollar[6].tableName, LikeOrWhere: yyDollar[7].expr}
 		}
-	case 79:
+	case 93:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:628
+//line yacc.y:745
 		{
 			yyVAL.showStmt = &ShowProcedureStatus{Comments: Comments(yyDollar[2].bytes2), LikeOrWhere: yyDollar[5].expr}
 		}
-	case 80:
+	case 94:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:632
+//line yacc.y:749
 		{
 			yyVAL.showStmt = &ShowFunctionStatus{Comments: Comments(yyDollar[2].bytes2), LikeOrWhere: yyDollar[5].expr}
 		}
-	case 81:
+	case 95:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:636
+//line yacc.y:753
 		{
 			yyVAL.showStmt = &ShowIndex{Comments: Comments(yyDollar[2].bytes2), From: yyDollar[5].tableName, Where: yyDollar[6].boolExpr}
 		}
-	case 82:
+	case 96:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:640
+//line yacc.y:757
 		{
 			yyVAL.showStmt = &ShowIndex{Comments: Comments(yyDollar[2].bytes2), From: yyDollar[5].tableName, Where: yyDollar[6].boolExpr}
 		}
-	case 83:
+	case 97:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:644
+//line yacc.y:761
 		{
 			yyVAL.showStmt = &ShowIndex{Comments: Comments(yyDollar[2].bytes2), From: yyDollar[5].tableName, Where: yyDollar[6].boolExpr}
 		}
-	case 84:
+	case 98:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:648
+//line yacc.y:765
 		{
 			yyVAL.showStmt = &ShowTriggers{Comments: Comments(yyDollar[2].bytes2), From: yyDollar[5].tableName, LikeOrWhere: yyDollar[6].expr}
 		}
-	case 85:
+	case 99:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:652
+//line yacc.y:769
 		{
 			yyVAL.showStmt = &ShowCreateDatabase{Comments: Comments(yyDollar[2].bytes2), Name: yyDollar[5].tableName}
 		}
-	case 86:
+	case 100:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:656
+//line yacc.y:773
 		{
 			yyVAL.showStmt = &ShowCreateTable{Comments: Comments(yyDollar[2].bytes2), Name: yyDollar[5].tableName}
 		}
-	case 87:
+	case 101:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:660
+//line yacc.y:777
 		{
 			yyVAL.showStmt = &ShowCreateView{Comments: Comments(yyDollar[2].bytes2), Name: yyDollar[5].tableName}
 		}
-	case 88:
+	case 102:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:664
+//line yacc.y:781
 		{
 			yyVAL.showStmt = &ShowCreateProcedure{Comments: Comments(yyDollar[2].bytes2), Name: yyDollar[5].tableName}
 		}
-	case 89:
+	case 103:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:668
+//line yacc.y:785
 		{
 			yyVAL.showStmt = &ShowCreateFunction{Comments: Comments(yyDollar[2].bytes2), Name: yyDollar[5].tableName}
 		}
-	case 90:
+	case 104:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:672
+//line yacc.y:789
 		{
 			yyVAL.showStmt = &ShowCreateTrigger{Comments: Comments(yyDollar[2].bytes2), Name: yyDollar[5].tableName}
 		}
-	case 91:
+	case 105:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:676
+//line yacc.y:793
 		{
 			yyVAL.showStmt = &ShowProcessList{Comments: Comments(yyDollar[2].bytes2)}
 		}
-	case 92:
+	case 106:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:680
+//line yacc.y:797
 		{
 			yyVAL.showStmt = &ShowFullProcessList{Comments: Comments(yyDollar[2].bytes2)}
 		}
-	case 93:
+	case 107:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:684
+//line yacc.y:801
 		{
 			yyVAL.showStmt = &ShowSlaveStatus{Comments: Comments(yyDollar[2].bytes2)}
 		}
-	case 94:
+	case 108:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:688
+//line yacc.y:805
 		{
 			yyVAL.showStmt = &ShowEngines{}
 		}
-	case 95:
+	case 109:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:692
+//line yacc.y:809
 		{
 			yyVAL.showStmt = &ShowEngines{}
 		}
-	case 96:
+	case 110:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:696
+//line yacc.y:813
 		{
 			yyVAL.showStmt = &ShowPlugins{}
 		}
-	case 97:
+	case 111:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:700
+//line yacc.y:817
 		{
 			yyVAL.showStmt = &ShowProfiles{}
 		}
-	case 98:
+	case 112:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:706
+//line yacc.y:823
 		{
 			yyVAL.showStmt = &ShowColumns{Comments: Comments(yyDollar[2].bytes2), From: yyDollar[3].tableName}
 		}
-	case 99:
+	case 113:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:711
+//line yacc.y:828
 		{
 			SetAllowComments(yylex, true)
 		}
-	case 100:
+	case 114:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:715
+//line yacc.y:832
 		{
 			yyVAL.bytes2 = yyDollar[2].bytes2
 			SetAllowComments(yylex, false)
 		}
-	case 101:
+	case 115:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:721
+//line yacc.y:838
 		{
 			yyVAL.bytes2 = nil
 		}
-	case 102:
+	case 116:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:725
+//line yacc.y:842
 		{
 			yyVAL.bytes2 = append(yyDollar[1].bytes2, yyDollar[2].bytes)
 		}
-	case 103:
+	case 117:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:731
+//line yacc.y:848
 		{
 			yyVAL.str = AST_UNION
 		}
-	case 104:
+	case 118:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:735
+//line yacc.y:852
 		{
 			yyVAL.str = AST_UNION_ALL
 		}
-	case 105:
+	case 119:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:739
+//line yacc.y:856
 		{
 			yyVAL.str = AST_SET_MINUS
 		}
-	case 106:
+	case 120:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:743
+//line yacc.y:860
 		{
 			yyVAL.str = AST_EXCEPT
 		}
-	case 107:
+	case 121:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:747
+//line yacc.y:864
 		{
 			yyVAL.str = AST_INTERSECT
 		}
-	case 108:
-		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:752
+	case 122:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:870
 		{
-			yyVAL.str = ""
+			yyVAL.selOpts = selectOptions{distinct: yyDollar[1].str}
 		}
-	case 109:
-		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:756
+	case 123:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line yacc.y:874
 		{
-			yyVAL.str = AST_DISTINCT
+			yyVAL.selOpts = selectOptions{distinct: yyDollar[2].str, calcFoundRows: true}
 		}
-	case 110:
-		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:762
+	case 124:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line yacc.y:878
 		{
-			yyVAL.selectExprs = SelectExprs{yyDollar[1].selectExpr}
+			yyVAL.selOpts = selectOptions{distinct: AST_DISTINCT, calcFoundRows: true}
 		}
-	case 111:
+	case 125:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line yacc.y:883
+		{
+			yyVAL.str = ""
+		}
+	case 126:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:887
+		{
+			yyVAL.str = AST_DISTINCT
+		}
+	case 127:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:893
+		{
+			yyVAL.selectExprs = SelectExprs{yyDollar[1].selectExpr}
+		}
+	case 128:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:766
+//line yacc.y:897
 		{
 			yyVAL.selectExprs = append(yyVAL.selectExprs, yyDollar[3].selectExpr)
 		}
-	case 112:
+	case 129:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:772
+//line yacc.y:903
 		{
 			yyVAL.selectExpr = &StarExpr{}
 		}
-	case 113:
+	case 130:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:776
+//line yacc.y:907
 		{
 			yyVAL.selectExpr = &StarExpr{TableName: bytes.ToLower(yyDollar[1].bytes)}
 		}
-	case 114:
+	case 131:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:780
+//line yacc.y:911
 		{
 			yyVAL.selectExpr = &NonStarExpr{Expr: yyDollar[1].expr, As: yyDollar[2].bytes}
 		}
-	case 115:
+	case 132:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:786
+//line yacc.y:917
 		{
 			yyVAL.expr = yyDollar[1].boolExpr
 		}
-	case 116:
+	case 133:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:790
+//line yacc.y:921
 		{
 			yyVAL.expr = yyDollar[1].valExpr
 		}
-	case 117:
+	case 134:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:795
+//line yacc.y:926
 		{
 			yyVAL.bytes = nil
 		}
-	case 118:
+	case 135:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:799
+//line yacc.y:930
 		{
 			yyVAL.bytes = yyDollar[1].bytes
 		}
-	case 119:
+	case 136:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:803
+//line yacc.y:934
 		{
 			yyVAL.bytes = yyDollar[2].bytes
 		}
-	case 120:
+	case 137:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:809
+//line yacc.y:940
 		{
 			yyVAL.tableExprs = TableExprs{yyDollar[1].tableExpr}
 		}
-	case 121:
+	case 138:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:813
+//line yacc.y:944
 		{
 			yyVAL.tableExprs = append(yyVAL.tableExprs, yyDollar[3].tableExpr)
 		}
-	case 122:
+	case 139:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:819
+//line yacc.y:950
 		{
 			yyVAL.tableExpr = &AliasedTableExpr{Expr: yyDollar[1].smTableExpr, As: yyDollar[2].bytes, Hints: yyDollar[3].indexHints}
 		}
-	case 123:
+	case 140:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:823
+//line yacc.y:954
 		{
 			yyVAL.tableExpr = &ParenTableExpr{Expr: yyDollar[2].tableExpr}
 		}
-	case 124:
+	case 141:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:827
+//line yacc.y:958
 		{
 			yyVAL.tableExpr = &JoinTableExpr{LeftExpr: yyDollar[1].tableExpr, Join: yyDollar[2].str, RightExpr: yyDollar[3].tableExpr, On: yyDollar[5].boolExpr}
 		}
-	case 125:
+	case 142:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:833
+//line yacc.y:964
 		{
 			yyVAL.str = AST_JOIN
 		}
-	case 126:
+	case 143:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:837
+//line yacc.y:968
 		{
 			yyVAL.str = AST_STRAIGHT_JOIN
 		}
-	case 127:
+	case 144:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:841
+//line yacc.y:972
 		{
 			yyVAL.str = AST_LEFT_JOIN
 		}
-	case 128:
+	case 145:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:845
+//line yacc.y:976
 		{
 			yyVAL.str = AST_LEFT_JOIN
 		}
-	case 129:
+	case 146:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:849
+//line yacc.y:980
 		{
 			yyVAL.str = AST_RIGHT_JOIN
 		}
-	case 130:
+	case 147:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:853
+//line yacc.y:984
 		{
 			yyVAL.str = AST_RIGHT_JOIN
 		}
-	case 131:
+	case 148:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:857
+//line yacc.y:988
 		{
 			yyVAL.str = AST_JOIN
 		}
-	case 132:
+	case 149:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:861
+//line yacc.y:992
 		{
 			yyVAL.str = AST_CROSS_JOIN
 		}
-	case 133:
+	case 150:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:865
+//line yacc.y:996
 		{
 			yyVAL.str = AST_NATURAL_JOIN
 		}
-	case 134:
+	case 151:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:871
+//line yacc.y:1002
 		{
 			yyVAL.smTableExpr = yyDollar[1].tableName
 		}
-	case 135:
+	case 152:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:875
+//line yacc.y:1006
 		{
 			yyVAL.smTableExpr = yyDollar[1].subquery
 		}
-	case 136:
+	case 153:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:881
+//line yacc.y:1012
 		{
 			yyVAL.tableName = &TableName{Name: yyDollar[1].bytes}
 		}
-	case 137:
+	case 154:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:885
+//line yacc.y:1016
 		{
 			yyVAL.tableName = &TableName{Qualifier: yyDollar[1].bytes, Name: yyDollar[3].bytes}
 		}
-	case 138:
+	case 155:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:890
+//line yacc.y:1021
 		{
 			yyVAL.indexHints = nil
 		}
-	case 139:
+	case 156:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:894
+//line yacc.y:1025
 		{
 			yyVAL.indexHints = &IndexHints{Type: AST_USE, Indexes: yyDollar[4].bytes2}
 		}
-	case 140:
+	case 157:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:898
+//line yacc.y:1029
 		{
 			yyVAL.indexHints = &IndexHints{Type: AST_IGNORE, Indexes: yyDollar[4].bytes2}
 		}
-	case 141:
+	case 158:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:902
+//line yacc.y:1033
 		{
 			yyVAL.indexHints = &IndexHints{Type: AST_FORCE, Indexes: yyDollar[4].bytes2}
 		}
-	case 142:
+	case 159:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:908
+//line yacc.y:1039
 		{
 			yyVAL.bytes2 = [][]byte{yyDollar[1].bytes}
 		}
-	case 143:
+	case 160:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:912
+//line yacc.y:1043
 		{
 			yyVAL.bytes2 = append(yyDollar[1].bytes2, yyDollar[3].bytes)
 		}
-	case 144:
+	case 161:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:918
+//line yacc.y:1049
 		{
 			yyVAL.valExprs = ValExprs{yyDollar[1].valExpr}
 		}
-	case 145:
+	case 162:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:922
+//line yacc.y:1053
 		{
 			yyVAL.valExprs = append(yyDollar[1].valExprs, yyDollar[3].valExpr)
 		}
-	case 146:
+	case 163:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:927
+//line yacc.y:1058
 		{
 			yyVAL.boolExpr = nil
 		}
-	case 147:
+	case 164:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:931
+//line yacc.y:1062
 		{
 			yyVAL.boolExpr = yyDollar[2].boolExpr
 		}
-	case 149:
+	case 166:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:938
+//line yacc.y:1069
 		{
 			yyVAL.boolExpr = &AndExpr{Left: yyDollar[1].boolExpr, Right: yyDollar[3].boolExpr}
 		}
-	case 150:
+	case 167:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:942
+//line yacc.y:1073
 		{
 			yyVAL.boolExpr = &OrExpr{Left: yyDollar[1].boolExpr, Right: yyDollar[3].boolExpr}
 		}
-	case 151:
+	case 168:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:946
+//line yacc.y:1077
 		{
 			yyVAL.boolExpr = &NotExpr{Expr: yyDollar[2].boolExpr}
 		}
-	case 152:
+	case 169:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:950
+//line yacc.y:1081
 		{
 			yyVAL.boolExpr = &ParenBoolExpr{Expr: yyDollar[2].boolExpr}
 		}
-	case 153:
+	case 170:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:956
+//line yacc.y:1087
 		{
 			yyVAL.boolExpr = &ComparisonExpr{Left: yyDollar[1].valExpr, Operator: yyDollar[2].str, Right: yyDollar[3].valExpr}
 		}
-	case 154:
+	case 171:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line yacc.y:1091
+		{
+			yyVAL.boolExpr = &ComparisonExpr{Left: yyDollar[1].valExpr, Operator: yyDollar[2].str, Right: &QuantifiedExpr{Quantifier: yyDollar[3].str, Subquery: yyDollar[4].subquery}}
+		}
+	case 172:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:960
+//line yacc.y:1095
 		{
+			if !CheckInList(yylex, yyDollar[3].tuple) {
+				return 1
+			}
 			yyVAL.boolExpr = &ComparisonExpr{Left: yyDollar[1].valExpr, Operator: AST_IN, Right: yyDollar[3].tuple}
 		}
-	case 155:
+	case 173:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:964
+//line yacc.y:1102
 		{
+			if !CheckInList(yylex, yyDollar[4].tuple) {
+				return 1
+			}
 			yyVAL.boolExpr = &ComparisonExpr{Left: yyDollar[1].valExpr, Operator: AST_NOT_IN, Right: yyDollar[4].tuple}
 		}
-	case 156:
+	case 174:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:968
+//line yacc.y:1109
 		{
 			yyVAL.boolExpr = &ComparisonExpr{Left: yyDollar[1].valExpr, Operator: AST_LIKE, Right: yyDollar[3].valExpr}
 		}
-	case 157:
+	case 175:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:972
+//line yacc.y:1113
 		{
 			yyVAL.boolExpr = &ComparisonExpr{Left: yyDollar[1].valExpr, Operator: AST_NOT_LIKE, Right: yyDollar[4].valExpr}
 		}
-	case 158:
+	case 176:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:976
+//line yacc.y:1117
 		{
 			yyVAL.boolExpr = &RangeCond{Left: yyDollar[1].valExpr, Operator: AST_BETWEEN, From: yyDollar[3].valExpr, To: yyDollar[5].valExpr}
 		}
-	case 159:
+	case 177:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:980
+//line yacc.y:1121
 		{
 			yyVAL.boolExpr = &RangeCond{Left: yyDollar[1].valExpr, Operator: AST_NOT_BETWEEN, From: yyDollar[4].valExpr, To: yyDollar[6].valExpr}
 		}
-	case 160:
+	case 178:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:984
+//line yacc.y:1125
 		{
 			yyVAL.boolExpr = &NullCheck{Operator: AST_IS_NULL, Expr: yyDollar[1].valExpr}
 		}
-	case 161:
+	case 179:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:988
+//line yacc.y:1129
 		{
 			yyVAL.boolExpr = &NullCheck{Operator: AST_IS_NOT_NULL, Expr: yyDollar[1].valExpr}
 		}
-	case 162:
+	case 180:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:992
+//line yacc.y:1133
 		{
 			yyVAL.boolExpr = &ExistsExpr{Subquery: yyDollar[2].subquery}
 		}
-	case 163:
+	case 181:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:998
+//line yacc.y:1139
 		{
 			yyVAL.str = AST_EQ
 		}
-	case 164:
+	case 182:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1002
+//line yacc.y:1143
 		{
 			yyVAL.str = AST_LT
 		}
-	case 165:
+	case 183:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1006
+//line yacc.y:1147
 		{
 			yyVAL.str = AST_GT
 		}
-	case 166:
+	case 184:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1010
+//line yacc.y:1151
 		{
 			yyVAL.str = AST_LE
 		}
-	case 167:
+	case 185:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1014
+//line yacc.y:1155
 		{
 			yyVAL.str = AST_GE
 		}
-	case 168:
+	case 186:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1018
+//line yacc.y:1159
 		{
 			yyVAL.str = AST_NE
 		}
-	case 169:
+	case 187:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1022
+//line yacc.y:1163
 		{
 			yyVAL.str = AST_NSE
 		}
-	case 170:
+	case 188:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:1169
+		{
+			yyVAL.str = AST_ANY
+		}
+	case 189:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:1173
+		{
+			yyVAL.str = AST_SOME
+		}
+	case 190:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:1177
+		{
+			yyVAL.str = AST_ALL
+		}
+	case 191:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1028
+//line yacc.y:1183
 		{
 			yyVAL.insRows = yyDollar[2].values
 		}
-	case 171:
+	case 192:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1032
+//line yacc.y:1187
 		{
 			yyVAL.insRows = yyDollar[1].selStmt
 		}
-	case 172:
+	case 193:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1038
+//line yacc.y:1193
 		{
 			yyVAL.values = Values{yyDollar[1].tuple}
 		}
-	case 173:
+	case 194:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1042
+//line yacc.y:1197
 		{
 			yyVAL.values = append(yyDollar[1].values, yyDollar[3].tuple)
 		}
-	case 174:
+	case 195:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1048
+//line yacc.y:1203
 		{
 			yyVAL.tuple = ValTuple(yyDollar[2].valExprs)
 		}
-	case 175:
+	case 196:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1052
+//line yacc.y:1207
 		{
 			yyVAL.tuple = yyDollar[1].subquery
 		}
-	case 176:
+	case 197:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1058
+//line yacc.y:1213
 		{
 			yyVAL.subquery = &Subquery{yyDollar[2].selStmt}
 		}
-	case 177:
+	case 198:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1064
+//line yacc.y:1219
 		{
 			yyVAL.valExprs = ValExprs{yyDollar[1].valExpr}
 		}
-	case 178:
+	case 199:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1068
+//line yacc.y:1223
 		{
 			yyVAL.valExprs = append(yyDollar[1].valExprs, yyDollar[3].valExpr)
 		}
-	case 179:
+	case 200:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1074
+//line yacc.y:1229
 		{
 			yyVAL.valExpr = yyDollar[1].valExpr
 		}
-	case 180:
+	case 201:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1078
+//line yacc.y:1233
 		{
 			yyVAL.valExpr = yyDollar[1].colName
 		}
-	case 181:
+	case 202:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1082
+//line yacc.y:1237
 		{
 			yyVAL.valExpr = yyDollar[1].tuple
 		}
-	case 182:
+	case 203:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1086
+//line yacc.y:1241
 		{
 			yyVAL.valExpr = &BinaryExpr{Left: yyDollar[1].valExpr, Operator: AST_BITAND, Right: yyDollar[3].valExpr}
 		}
-	case 183:
+	case 204:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1090
+//line yacc.y:1245
 		{
 			yyVAL.valExpr = &BinaryExpr{Left: yyDollar[1].valExpr, Operator: AST_BITOR, Right: yyDollar[3].valExpr}
 		}
-	case 184:
+	case 205:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1094
+//line yacc.y:1249
 		{
 			yyVAL.valExpr = &BinaryExpr{Left: yyDollar[1].valExpr, Operator: AST_BITXOR, Right: yyDollar[3].valExpr}
 		}
-	case 185:
+	case 206:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1098
+//line yacc.y:1253
 		{
 			yyVAL.valExpr = &BinaryExpr{Left: yyDollar[1].valExpr, Operator: AST_PLUS, Right: yyDollar[3].valExpr}
 		}
-	case 186:
+	case 207:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1102
+//line yacc.y:1257
 		{
 			yyVAL.valExpr = &BinaryExpr{Left: yyDollar[1].valExpr, Operator: AST_MINUS, Right: yyDollar[3].valExpr}
 		}
-	case 187:
+	case 208:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1106
+//line yacc.y:1261
 		{
 			yyVAL.valExpr = &BinaryExpr{Left: yyDollar[1].valExpr, Operator: AST_MULT, Right: yyDollar[3].valExpr}
 		}
-	case 188:
+	case 209:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1110
+//line yacc.y:1265
 		{
 			yyVAL.valExpr = &BinaryExpr{Left: yyDollar[1].valExpr, Operator: AST_DIV, Right: yyDollar[3].valExpr}
 		}
-	case 189:
+	case 210:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1114
+//line yacc.y:1269
 		{
 			yyVAL.valExpr = &BinaryExpr{Left: yyDollar[1].valExpr, Operator: AST_MOD, Right: yyDollar[3].valExpr}
 		}
-	case 190:
+	case 211:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1118
+//line yacc.y:1273
 		{
 			if num, ok := yyDollar[2].valExpr.(NumVal); ok {
 				switch yyDollar[1].byt {
@@ -2943,279 +3278,363 @@ yydefault:
 				yyVAL.valExpr = &UnaryExpr{Operator: yyDollar[1].byt, Expr: yyDollar[2].valExpr}
 			}
 		}
-	case 191:
+	case 212:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1133
+//line yacc.y:1288
 		{
 			yyVAL.valExpr = yyDollar[1].funcExpr
 		}
-	case 192:
+	case 213:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line yacc.y:1292
+		{
+			yyVAL.valExpr = &WindowFuncExpr{Func: yyDollar[1].funcExpr, Window: yyDollar[3].windowSpec}
+		}
+	case 214:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1137
+//line yacc.y:1296
 		{
 			yyVAL.valExpr = yyDollar[1].caseExpr
 		}
-	case 193:
+	case 215:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1143
+//line yacc.y:1302
 		{
 			yyVAL.funcExpr = &FuncExpr{Name: yyDollar[1].bytes}
 		}
-	case 194:
+	case 216:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:1147
+//line yacc.y:1306
 		{
 			yyVAL.funcExpr = &FuncExpr{Name: yyDollar[1].bytes, Exprs: ValExprs{&ColName{Name: []byte("*")}}}
 		}
-	case 195:
+	case 217:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:1151
+//line yacc.y:1310
 		{
 			yyVAL.funcExpr = &FuncExpr{Name: yyDollar[1].bytes, Exprs: yyDollar[3].valExprs}
 		}
-	case 196:
+	case 218:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:1155
+//line yacc.y:1314
 		{
 			yyVAL.funcExpr = &FuncExpr{Name: yyDollar[1].bytes, Distinct: true, Exprs: yyDollar[4].valExprs}
 		}
-	case 197:
+	case 219:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:1159
+//line yacc.y:1318
 		{
 			yyVAL.funcExpr = &FuncExpr{Name: []byte("locate"), Exprs: ValExprs{yyDollar[3].valExpr, yyDollar[5].valExpr}}
 		}
-	case 198:
+	case 220:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:1163
+//line yacc.y:1322
 		{
 			yyVAL.funcExpr = &FuncExpr{Name: yyDollar[1].bytes, Exprs: yyDollar[3].valExprs}
 		}
-	case 199:
+	case 221:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line yacc.y:1328
+		{
+			yyVAL.windowSpec = &WindowSpec{PartitionBy: yyDollar[2].valExprs, OrderBy: yyDollar[3].orderBy, Frame: yyDollar[4].frame}
+		}
+	case 222:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line yacc.y:1333
+		{
+			yyVAL.valExprs = nil
+		}
+	case 223:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line yacc.y:1337
+		{
+			yyVAL.valExprs = yyDollar[3].valExprs
+		}
+	case 224:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line yacc.y:1342
+		{
+			yyVAL.frame = nil
+		}
+	case 225:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line yacc.y:1346
+		{
+			yyVAL.frame = &WindowFrame{Unit: yyDollar[1].str, Start: yyDollar[2].frameBound}
+		}
+	case 226:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line yacc.y:1350
+		{
+			yyVAL.frame = &WindowFrame{Unit: yyDollar[1].str, Start: yyDollar[3].frameBound, End: yyDollar[5].frameBound}
+		}
+	case 227:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:1356
+		{
+			yyVAL.str = AST_FRAME_ROWS
+		}
+	case 228:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:1360
+		{
+			yyVAL.str = AST_FRAME_RANGE
+		}
+	case 229:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line yacc.y:1366
+		{
+			yyVAL.frameBound = &FrameBound{Type: AST_UNBOUNDED_PRECEDING}
+		}
+	case 230:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line yacc.y:1370
+		{
+			yyVAL.frameBound = &FrameBound{Type: AST_UNBOUNDED_FOLLOWING}
+		}
+	case 231:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line yacc.y:1374
+		{
+			yyVAL.frameBound = &FrameBound{Type: AST_CURRENT_ROW}
+		}
+	case 232:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line yacc.y:1378
+		{
+			yyVAL.frameBound = &FrameBound{Type: AST_PRECEDING, Expr: yyDollar[1].valExpr}
+		}
+	case 233:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line yacc.y:1382
+		{
+			yyVAL.frameBound = &FrameBound{Type: AST_FOLLOWING, Expr: yyDollar[1].valExpr}
+		}
+	case 234:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1169
+//line yacc.y:1388
 		{
 			yyVAL.bytes = IF_BYTES
 		}
-	case 200:
+	case 235:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1173
+//line yacc.y:1392
 		{
 			yyVAL.bytes = VALUES_BYTES
 		}
-	case 201:
+	case 236:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1179
+//line yacc.y:1398
 		{
 			yyVAL.byt = AST_UPLUS
 		}
-	case 202:
+	case 237:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1183
+//line yacc.y:1402
 		{
 			yyVAL.byt = AST_UMINUS
 		}
-	case 203:
+	case 238:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1187
+//line yacc.y:1406
 		{
 			yyVAL.byt = AST_TILDA
 		}
-	case 204:
+	case 239:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:1193
+//line yacc.y:1412
 		{
 			yyVAL.caseExpr = &CaseExpr{Expr: yyDollar[2].valExpr, Whens: yyDollar[3].whens, Else: yyDollar[4].valExpr}
 		}
-	case 205:
+	case 240:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1198
+//line yacc.y:1417
 		{
 			yyVAL.valExpr = nil
 		}
-	case 206:
+	case 241:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1202
+//line yacc.y:1421
 		{
 			yyVAL.valExpr = yyDollar[1].valExpr
 		}
-	case 207:
+	case 242:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1208
+//line yacc.y:1427
 		{
 			yyVAL.whens = []*When{yyDollar[1].when}
 		}
-	case 208:
+	case 243:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1212
+//line yacc.y:1431
 		{
 			yyVAL.whens = append(yyDollar[1].whens, yyDollar[2].when)
 		}
-	case 209:
+	case 244:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:1218
+//line yacc.y:1437
 		{
 			yyVAL.when = &When{Cond: yyDollar[2].boolExpr, Val: yyDollar[4].valExpr}
 		}
-	case 210:
+	case 245:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1223
+//line yacc.y:1442
 		{
 			yyVAL.valExpr = nil
 		}
-	case 211:
+	case 246:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1227
+//line yacc.y:1446
 		{
 			yyVAL.valExpr = yyDollar[2].valExpr
 		}
-	case 212:
+	case 247:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1233
+//line yacc.y:1452
 		{
 			yyVAL.colName = &ColName{Name: yyDollar[1].bytes}
 		}
-	case 213:
+	case 248:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1237
+//line yacc.y:1456
 		{
 			yyVAL.colName = &ColName{Qualifier: bytes.ToLower(yyDollar[1].bytes), Name: yyDollar[3].bytes}
 		}
-	case 214:
+	case 249:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1243
+//line yacc.y:1462
 		{
 			yyVAL.valExpr = StrVal(yyDollar[1].bytes)
 		}
-	case 215:
+	case 250:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1247
+//line yacc.y:1466
 		{
 			yyVAL.valExpr = NumVal(yyDollar[1].bytes)
 		}
-	case 216:
+	case 251:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1251
+//line yacc.y:1470
 		{
 			yyVAL.valExpr = ValArg(yyDollar[1].bytes)
 		}
-	case 217:
+	case 252:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1255
+//line yacc.y:1474
 		{
 			yyVAL.valExpr = &NullVal{}
 		}
-	case 218:
+	case 253:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1260
+//line yacc.y:1479
 		{
 			yyVAL.valExprs = nil
 		}
-	case 219:
+	case 254:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1264
+//line yacc.y:1483
 		{
 			yyVAL.valExprs = yyDollar[3].valExprs
 		}
-	case 220:
+	case 255:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1269
+//line yacc.y:1488
 		{
 			yyVAL.boolExpr = nil
 		}
-	case 221:
+	case 256:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1273
+//line yacc.y:1492
 		{
 			yyVAL.boolExpr = yyDollar[2].boolExpr
 		}
-	case 222:
+	case 257:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1278
+//line yacc.y:1497
 		{
 			yyVAL.orderBy = nil
 		}
-	case 223:
+	case 258:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1282
+//line yacc.y:1501
 		{
 			yyVAL.orderBy = yyDollar[3].orderBy
 		}
-	case 224:
+	case 259:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1288
+//line yacc.y:1507
 		{
 			yyVAL.orderBy = OrderBy{yyDollar[1].order}
 		}
-	case 225:
+	case 260:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1292
+//line yacc.y:1511
 		{
 			yyVAL.orderBy = append(yyDollar[1].orderBy, yyDollar[3].order)
 		}
-	case 226:
+	case 261:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1298
+//line yacc.y:1517
 		{
 			yyVAL.order = &Order{Expr: yyDollar[1].valExpr, Direction: yyDollar[2].str}
 		}
-	case 227:
+	case 262:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1303
+//line yacc.y:1522
 		{
 			yyVAL.str = ""
 		}
-	case 228:
+	case 263:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1307
+//line yacc.y:1526
 		{
 			yyVAL.str = AST_ASC
 		}
-	case 229:
+	case 264:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1311
+//line yacc.y:1530
 		{
 			yyVAL.str = AST_DESC
 		}
-	case 230:
+	case 265:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1316
+//line yacc.y:1535
 		{
 			yyVAL.limit = nil
 		}
-	case 231:
+	case 266:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1320
+//line yacc.y:1539
 		{
 			yyVAL.limit = &Limit{Rowcount: yyDollar[2].valExpr}
 		}
-	case 232:
+	case 267:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:1324
+//line yacc.y:1543
 		{
 			yyVAL.limit = &Limit{Offset: yyDollar[2].valExpr, Rowcount: yyDollar[4].valExpr}
 		}
-	case 233:
+	case 268:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:1328
+//line yacc.y:1547
 		{
 			yyVAL.limit = &Limit{Offset: yyDollar[4].valExpr, Rowcount: yyDollar[2].valExpr}
 		}
-	case 234:
+	case 269:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1333
+//line yacc.y:1552
 		{
 			yyVAL.str = ""
 		}
-	case 235:
+	case 270:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1337
+//line yacc.y:1556
 		{
 			yyVAL.str = AST_FOR_UPDATE
 		}
-	case 236:
+	case 271:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:1341
+//line yacc.y:1560
 		{
 			if !bytes.Equal(yyDollar[3].bytes, SHARE) {
 				yylex.Error("expecting share")
@@ -3227,1071 +3646,1174 @@ yydefault:
 			}
 			yyVAL.str = AST_SHARE_MODE
 		}
-	case 237:
+	case 272:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1354
+//line yacc.y:1573
 		{
 			yyVAL.columns = nil
 		}
-	case 238:
+	case 273:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1358
+//line yacc.y:1577
 		{
 			yyVAL.columns = yyDollar[2].columns
 		}
-	case 239:
+	case 274:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1364
+//line yacc.y:1583
 		{
 			yyVAL.columns = Columns{&NonStarExpr{Expr: yyDollar[1].colName}}
 		}
-	case 240:
+	case 275:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1368
+//line yacc.y:1587
 		{
 			yyVAL.columns = append(yyVAL.columns, &NonStarExpr{Expr: yyDollar[3].colName})
 		}
-	case 241:
+	case 276:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1373
+//line yacc.y:1592
 		{
 			yyVAL.updateExprs = nil
 		}
-	case 242:
+	case 277:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:1377
+//line yacc.y:1596
 		{
 			yyVAL.updateExprs = yyDollar[5].updateExprs
 		}
-	case 243:
+	case 278:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1383
+//line yacc.y:1602
 		{
 			yyVAL.updateExprs = UpdateExprs{yyDollar[1].updateExpr}
 		}
-	case 244:
+	case 279:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1387
+//line yacc.y:1606
 		{
 			yyVAL.updateExprs = append(yyDollar[1].updateExprs, yyDollar[3].updateExpr)
 		}
-	case 245:
+	case 280:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1393
+//line yacc.y:1612
 		{
 			yyVAL.updateExpr = &UpdateExpr{Name: yyDollar[1].colName, Expr: yyDollar[3].valExpr}
 		}
-	case 246:
+	case 281:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1398
+//line yacc.y:1617
 		{
 			yyVAL.empty = struct{}{}
 		}
-	case 247:
+	case 282:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1400
+//line yacc.y:1619
 		{
 			yyVAL.empty = struct{}{}
 		}
-	case 248:
+	case 283:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1403
+//line yacc.y:1622
 		{
 			yyVAL.empty = struct{}{}
 		}
-	case 249:
+	case 284:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1405
+//line yacc.y:1624
 		{
 			yyVAL.empty = struct{}{}
 		}
-	case 250:
+	case 285:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1408
+//line yacc.y:1627
 		{
 			yyVAL.str = ""
 		}
-	case 251:
+	case 286:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1410
+//line yacc.y:1629
 		{
 			yyVAL.str = AST_IGNORE
 		}
-	case 252:
+	case 287:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1413
+//line yacc.y:1632
 		{
 			yyVAL.bytes = nil
 		}
-	case 253:
+	case 288:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1415
+//line yacc.y:1634
 		{
 			yyVAL.bytes = []byte("unique")
 		}
-	case 254:
+	case 289:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1417
+//line yacc.y:1636
 		{
 			yyVAL.bytes = []byte("fulltext")
 		}
-	case 255:
+	case 290:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1421
+//line yacc.y:1640
 		{
 			yyVAL.idxColNames = IndexColNames{yyDollar[1].idxColName}
 		}
-	case 256:
+	case 291:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1425
+//line yacc.y:1644
 		{
 			yyVAL.idxColNames = append(yyDollar[1].idxColNames, yyDollar[3].idxColName)
 		}
-	case 257:
+	case 292:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1431
+//line yacc.y:1650
 		{
 			yyVAL.idxColName = &IndexColName{ColumnName: yyDollar[1].colName, AscOrDesc: yyDollar[2].str}
 		}
-	case 258:
+	case 293:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:1435
+//line yacc.y:1654
 		{
 			yyVAL.idxColName = &IndexColName{ColumnName: yyDollar[1].colName, Length: NumVal(yyDollar[3].bytes), AscOrDesc: yyDollar[5].str}
 		}
-	case 259:
+	case 294:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line yacc.y:1658
+		{
+			yyVAL.idxColName = &IndexColName{Expr: yyDollar[2].expr, AscOrDesc: yyDollar[4].str}
+		}
+	case 295:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1440
+//line yacc.y:1663
 		{
 			yyVAL.bytes = nil
 		}
-	case 260:
+	case 296:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1442
+//line yacc.y:1665
 		{
 			yyVAL.bytes = yyDollar[1].bytes
 		}
-	case 261:
+	case 297:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1446
+//line yacc.y:1669
 		{
 			yyVAL.bytes = []byte("using btree")
 		}
-	case 262:
+	case 298:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1448
+//line yacc.y:1671
 		{
 			yyVAL.bytes = []byte("using hash")
 		}
-	case 263:
+	case 299:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1451
+//line yacc.y:1674
 		{
 			yyVAL.bytes = nil
 		}
-	case 264:
+	case 300:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1453
+//line yacc.y:1676
 		{
 			yyVAL.bytes = yyDollar[1].bytes
 		}
-	case 265:
+	case 301:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1457
+//line yacc.y:1680
 		{
 			yyVAL.bytes = bytes.ToLower(yyDollar[1].bytes)
 		}
-	case 266:
+	case 302:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1461
+//line yacc.y:1684
 		{
 			yyVAL.bytes = []byte("database")
 		}
-	case 267:
+	case 303:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:1688
+		{
+			yyVAL.bytes = []byte("current")
+		}
+	case 304:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:1692
+		{
+			yyVAL.bytes = []byte("preceding")
+		}
+	case 305:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:1696
+		{
+			yyVAL.bytes = []byte("following")
+		}
+	case 306:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:1700
+		{
+			yyVAL.bytes = []byte("generated")
+		}
+	case 307:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:1704
+		{
+			yyVAL.bytes = []byte("always")
+		}
+	case 308:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:1708
+		{
+			yyVAL.bytes = []byte("virtual")
+		}
+	case 309:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:1712
+		{
+			yyVAL.bytes = []byte("stored")
+		}
+	case 310:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:1716
+		{
+			yyVAL.bytes = []byte("enforced")
+		}
+	case 311:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1472
+//line yacc.y:1727
 		{
 			yyVAL.bytes = []byte("armscii8")
 		}
-	case 268:
+	case 312:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1474
+//line yacc.y:1729
 		{
 			yyVAL.bytes = []byte("ascii")
 		}
-	case 269:
+	case 313:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1476
+//line yacc.y:1731
 		{
 			yyVAL.bytes = []byte("big5")
 		}
-	case 270:
+	case 314:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1478
+//line yacc.y:1733
 		{
 			yyVAL.bytes = []byte("binary")
 		}
-	case 271:
+	case 315:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1480
+//line yacc.y:1735
 		{
 			yyVAL.bytes = []byte("cp1250")
 		}
-	case 272:
+	case 316:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1482
+//line yacc.y:1737
 		{
 			yyVAL.bytes = []byte("cp1251")
 		}
-	case 273:
+	case 317:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1484
+//line yacc.y:1739
 		{
 			yyVAL.bytes = []byte("cp1256")
 		}
-	case 274:
+	case 318:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1486
+//line yacc.y:1741
 		{
 			yyVAL.bytes = []byte("cp1257")
 		}
-	case 275:
+	case 319:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1488
+//line yacc.y:1743
 		{
 			yyVAL.bytes = []byte("cp850")
 		}
-	case 276:
+	case 320:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1490
+//line yacc.y:1745
 		{
 			yyVAL.bytes = []byte("cp852")
 		}
-	case 277:
+	case 321:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1492
+//line yacc.y:1747
 		{
 			yyVAL.bytes = []byte("cp866")
 		}
-	case 278:
+	case 322:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1494
+//line yacc.y:1749
 		{
 			yyVAL.bytes = []byte("cp932")
 		}
-	case 279:
+	case 323:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1496
+//line yacc.y:1751
 		{
 			yyVAL.bytes = []byte("dec8")
 		}
-	case 280:
+	case 324:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1498
+//line yacc.y:1753
 		{
 			yyVAL.bytes = []byte("eucjpms")
 		}
-	case 281:
+	case 325:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1500
+//line yacc.y:1755
 		{
 			yyVAL.bytes = []byte("euckr")
 		}
-	case 282:
+	case 326:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1502
+//line yacc.y:1757
 		{
 			yyVAL.bytes = []byte("gb2312")
 		}
-	case 283:
+	case 327:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1504
+//line yacc.y:1759
 		{
 			yyVAL.bytes = []byte("gbk")
 		}
-	case 284:
+	case 328:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1506
+//line yacc.y:1761
 		{
 			yyVAL.bytes = []byte("geostd8")
 		}
-	case 285:
+	case 329:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1508
+//line yacc.y:1763
 		{
 			yyVAL.bytes = []byte("greek")
 		}
-	case 286:
+	case 330:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1510
+//line yacc.y:1765
 		{
 			yyVAL.bytes = []byte("hebrew")
 		}
-	case 287:
+	case 331:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1512
+//line yacc.y:1767
 		{
 			yyVAL.bytes = []byte("hp8")
 		}
-	case 288:
+	case 332:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1514
+//line yacc.y:1769
 		{
 			yyVAL.bytes = []byte("keybcs2")
 		}
-	case 289:
+	case 333:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1516
+//line yacc.y:1771
 		{
 			yyVAL.bytes = []byte("koi8r")
 		}
-	case 290:
+	case 334:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1518
+//line yacc.y:1773
 		{
 			yyVAL.bytes = []byte("koi8u")
 		}
-	case 291:
+	case 335:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1520
+//line yacc.y:1775
 		{
 			yyVAL.bytes = []byte("latin1")
 		}
-	case 292:
+	case 336:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1522
+//line yacc.y:1777
 		{
 			yyVAL.bytes = []byte("latin2")
 		}
-	case 293:
+	case 337:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1524
+//line yacc.y:1779
 		{
 			yyVAL.bytes = []byte("latin5")
 		}
-	case 294:
+	case 338:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1526
+//line yacc.y:1781
 		{
 			yyVAL.bytes = []byte("latin7")
 		}
-	case 295:
+	case 339:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1528
+//line yacc.y:1783
 		{
 			yyVAL.bytes = []byte("macce")
 		}
-	case 296:
+	case 340:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1530
+//line yacc.y:1785
 		{
 			yyVAL.bytes = []byte("macroman")
 		}
-	case 297:
+	case 341:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1532
+//line yacc.y:1787
 		{
 			yyVAL.bytes = []byte("sjis")
 		}
-	case 298:
+	case 342:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1534
+//line yacc.y:1789
 		{
 			yyVAL.bytes = []byte("swe7")
 		}
-	case 299:
+	case 343:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1536
+//line yacc.y:1791
 		{
 			yyVAL.bytes = []byte("tis620")
 		}
-	case 300:
+	case 344:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1538
+//line yacc.y:1793
 		{
 			yyVAL.bytes = []byte("ucs2")
 		}
-	case 301:
+	case 345:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1540
+//line yacc.y:1795
 		{
 			yyVAL.bytes = []byte("ujis")
 		}
-	case 302:
+	case 346:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1542
+//line yacc.y:1797
 		{
 			yyVAL.bytes = []byte("utf16")
 		}
-	case 303:
+	case 347:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1544
+//line yacc.y:1799
 		{
 			yyVAL.bytes = []byte("utf16le")
 		}
-	case 304:
+	case 348:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1546
+//line yacc.y:1801
 		{
 			yyVAL.bytes = []byte("utf32")
 		}
-	case 305:
+	case 349:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1548
+//line yacc.y:1803
 		{
 			yyVAL.bytes = []byte("utf8")
 		}
-	case 306:
+	case 350:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1550
+//line yacc.y:1805
 		{
 			yyVAL.bytes = []byte("utf8mb4")
 		}
-	case 307:
+	case 351:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1554
+//line yacc.y:1809
 		{
 			yyVAL.bytes = []byte("armscii8_general_ci")
 		}
-	case 308:
+	case 352:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1556
+//line yacc.y:1811
 		{
 			yyVAL.bytes = []byte("armscii8_bin")
 		}
-	case 309:
+	case 353:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1558
+//line yacc.y:1813
 		{
 			yyVAL.bytes = []byte("ascii_general_ci")
 		}
-	case 310:
+	case 354:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1560
+//line yacc.y:1815
 		{
 			yyVAL.bytes = []byte("ascii_bin")
 		}
-	case 311:
+	case 355:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1562
+//line yacc.y:1817
 		{
 			yyVAL.bytes = []byte("big5_chinese_ci")
 		}
-	case 312:
+	case 356:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1564
+//line yacc.y:1819
 		{
 			yyVAL.bytes = []byte("big5_bin")
 		}
-	case 313:
+	case 357:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1566
+//line yacc.y:1821
 		{
 			yyVAL.bytes = []byte("binary")
 		}
-	case 314:
+	case 358:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1568
+//line yacc.y:1823
 		{
 			yyVAL.bytes = []byte("cp1250_general_ci")
 		}
-	case 315:
+	case 359:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1570
+//line yacc.y:1825
 		{
 			yyVAL.bytes = []byte("cp1250_bin")
 		}
-	case 316:
+	case 360:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1572
+//line yacc.y:1827
 		{
 			yyVAL.bytes = []byte("cp1251_chinese_ci")
 		}
-	case 317:
+	case 361:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1574
+//line yacc.y:1829
 		{
 			yyVAL.bytes = []byte("cp1251_chinese_cs")
 		}
-	case 318:
+	case 362:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1576
+//line yacc.y:1831
 		{
 			yyVAL.bytes = []byte("cp1251_bin")
 		}
-	case 319:
+	case 363:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1578
+//line yacc.y:1833
 		{
 			yyVAL.bytes = []byte("cp1256_chinese_ci")
 		}
-	case 320:
+	case 364:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1580
+//line yacc.y:1835
 		{
 			yyVAL.bytes = []byte("cp1256_bin")
 		}
-	case 321:
+	case 365:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1582
+//line yacc.y:1837
 		{
 			yyVAL.bytes = []byte("cp1257_chinese_ci")
 		}
-	case 322:
+	case 366:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1584
+//line yacc.y:1839
 		{
 			yyVAL.bytes = []byte("cp1257_bin")
 		}
-	case 323:
+	case 367:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1586
+//line yacc.y:1841
 		{
 			yyVAL.bytes = []byte("cp850_chinese_ci")
 		}
-	case 324:
+	case 368:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1588
+//line yacc.y:1843
 		{
 			yyVAL.bytes = []byte("cp850_bin")
 		}
-	case 325:
+	case 369:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1590
+//line yacc.y:1845
 		{
 			yyVAL.bytes = []byte("cp852_chinese_ci")
 		}
-	case 326:
+	case 370:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1592
+//line yacc.y:1847
 		{
 			yyVAL.bytes = []byte("cp852_bin")
 		}
-	case 327:
+	case 371:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1594
+//line yacc.y:1849
 		{
 			yyVAL.bytes = []byte("cp866_chinese_ci")
 		}
-	case 328:
+	case 372:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1596
+//line yacc.y:1851
 		{
 			yyVAL.bytes = []byte("cp866_bin")
 		}
-	case 329:
+	case 373:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1598
+//line yacc.y:1853
 		{
 			yyVAL.bytes = []byte("cp932_japanese_ci")
 		}
-	case 330:
+	case 374:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1600
+//line yacc.y:1855
 		{
 			yyVAL.bytes = []byte("cp932_bin")
 		}
-	case 331:
+	case 375:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1602
+//line yacc.y:1857
 		{
 			yyVAL.bytes = []byte("dec8_swedish_ci")
 		}
-	case 332:
+	case 376:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1604
+//line yacc.y:1859
 		{
 			yyVAL.bytes = []byte("dec8_bin")
 		}
-	case 333:
+	case 377:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1606
+//line yacc.y:1861
 		{
 			yyVAL.bytes = []byte("eucjpms_japanese_ci")
 		}
-	case 334:
+	case 378:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1608
+//line yacc.y:1863
 		{
 			yyVAL.bytes = []byte("eucjpms_bin")
 		}
-	case 335:
+	case 379:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1610
+//line yacc.y:1865
 		{
 			yyVAL.bytes = []byte("euckr_korean_ci")
 		}
-	case 336:
+	case 380:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1612
+//line yacc.y:1867
 		{
 			yyVAL.bytes = []byte("euckr_bin")
 		}
-	case 337:
+	case 381:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1614
+//line yacc.y:1869
 		{
 			yyVAL.bytes = []byte("gb2312_chinese_ci")
 		}
-	case 338:
+	case 382:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1616
+//line yacc.y:1871
 		{
 			yyVAL.bytes = []byte("gb2312_bin")
 		}
-	case 339:
+	case 383:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1618
+//line yacc.y:1873
 		{
 			yyVAL.bytes = []byte("gbk_chinese_ci")
 		}
-	case 340:
+	case 384:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1620
+//line yacc.y:1875
 		{
 			yyVAL.bytes = []byte("gbk_bin")
 		}
-	case 341:
+	case 385:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1622
+//line yacc.y:1877
 		{
 			yyVAL.bytes = []byte("geostd8_general_ci")
 		}
-	case 342:
+	case 386:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1624
+//line yacc.y:1879
 		{
 			yyVAL.bytes = []byte("geostd8_bin")
 		}
-	case 343:
+	case 387:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1626
+//line yacc.y:1881
 		{
 			yyVAL.bytes = []byte("greek_general_ci")
 		}
-	case 344:
+	case 388:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1628
+//line yacc.y:1883
 		{
 			yyVAL.bytes = []byte("greek_bin")
 		}
-	case 345:
+	case 389:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1630
+//line yacc.y:1885
 		{
 			yyVAL.bytes = []byte("hebrew_general_ci")
 		}
-	case 346:
+	case 390:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1632
+//line yacc.y:1887
 		{
 			yyVAL.bytes = []byte("hebrew_bin")
 		}
-	case 347:
+	case 391:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1634
+//line yacc.y:1889
 		{
 			yyVAL.bytes = []byte("hp8_english_ci")
 		}
-	case 348:
+	case 392:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1636
+//line yacc.y:1891
 		{
 			yyVAL.bytes = []byte("hp8_bin")
 		}
-	case 349:
+	case 393:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1638
+//line yacc.y:1893
 		{
 			yyVAL.bytes = []byte("keybcs2_general_ci")
 		}
-	case 350:
+	case 394:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1640
+//line yacc.y:1895
 		{
 			yyVAL.bytes = []byte("keybcs2_bin")
 		}
-	case 351:
+	case 395:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1642
+//line yacc.y:1897
 		{
 			yyVAL.bytes = []byte("koi8r_general_ci")
 		}
-	case 352:
+	case 396:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1644
+//line yacc.y:1899
 		{
 			yyVAL.bytes = []byte("koi8r_bin")
 		}
-	case 353:
+	case 397:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1646
+//line yacc.y:1901
 		{
 			yyVAL.bytes = []byte("koi8u_general_ci")
 		}
-	case 354:
+	case 398:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1648
+//line yacc.y:1903
 		{
 			yyVAL.bytes = []byte("koi8u_bin")
 		}
-	case 355:
+	case 399:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1650
+//line yacc.y:1905
 		{
 			yyVAL.bytes = []byte("latin1_general_ci")
 		}
-	case 356:
+	case 400:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1652
+//line yacc.y:1907
 		{
 			yyVAL.bytes = []byte("latin1_general_cs")
 		}
-	case 357:
+	case 401:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1654
+//line yacc.y:1909
 		{
 			yyVAL.bytes = []byte("latin1_bin")
 		}
-	case 358:
+	case 402:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1656
+//line yacc.y:1911
 		{
 			yyVAL.bytes = []byte("latin2_general_ci")
 		}
-	case 359:
+	case 403:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1658
+//line yacc.y:1913
 		{
 			yyVAL.bytes = []byte("latin2_bin")
 		}
-	case 360:
+	case 404:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1660
+//line yacc.y:1915
 		{
 			yyVAL.bytes = []byte("latin5_turkish_ci")
 		}
-	case 361:
+	case 405:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1662
+//line yacc.y:1917
 		{
 			yyVAL.bytes = []byte("latin5_bin")
 		}
-	case 362:
+	case 406:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1664
+//line yacc.y:1919
 		{
 			yyVAL.bytes = []byte("latin7_general_ci")
 		}
-	case 363:
+	case 407:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1666
+//line yacc.y:1921
 		{
 			yyVAL.bytes = []byte("latin7_general_cs")
 		}
-	case 364:
+	case 408:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1668
+//line yacc.y:1923
 		{
 			yyVAL.bytes = []byte("latin7_bin")
 		}
-	case 365:
+	case 409:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1670
+//line yacc.y:1925
 		{
 			yyVAL.bytes = []byte("macce_general_ci")
 		}
-	case 366:
+	case 410:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1672
+//line yacc.y:1927
 		{
 			yyVAL.bytes = []byte("macce_bin")
 		}
-	case 367:
+	case 411:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1674
+//line yacc.y:1929
 		{
 			yyVAL.bytes = []byte("macroman_general_ci")
 		}
-	case 368:
+	case 412:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1676
+//line yacc.y:1931
 		{
 			yyVAL.bytes = []byte("macroman_bin")
 		}
-	case 369:
+	case 413:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1678
+//line yacc.y:1933
 		{
 			yyVAL.bytes = []byte("sjis_japanese_ci")
 		}
-	case 370:
+	case 414:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1680
+//line yacc.y:1935
 		{
 			yyVAL.bytes = []byte("sjis_bin")
 		}
-	case 371:
+	case 415:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1682
+//line yacc.y:1937
 		{
 			yyVAL.bytes = []byte("swe7_swedish_ci")
 		}
-	case 372:
+	case 416:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1684
+//line yacc.y:1939
 		{
 			yyVAL.bytes = []byte("swe7_bin")
 		}
-	case 373:
+	case 417:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1686
+//line yacc.y:1941
 		{
 			yyVAL.bytes = []byte("tis620_thai_ci")
 		}
-	case 374:
+	case 418:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1688
+//line yacc.y:1943
 		{
 			yyVAL.bytes = []byte("tis620_bin")
 		}
-	case 375:
+	case 419:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1690
+//line yacc.y:1945
 		{
 			yyVAL.bytes = []byte("ucs2_general_ci")
 		}
-	case 376:
+	case 420:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1692
+//line yacc.y:1947
 		{
 			yyVAL.bytes = []byte("ucs2_unicode_ci")
 		}
-	case 377:
+	case 421:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1694
+//line yacc.y:1949
 		{
 			yyVAL.bytes = []byte("ucs2_bin")
 		}
-	case 378:
+	case 422:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1696
+//line yacc.y:1951
 		{
 			yyVAL.bytes = []byte("ujis_japanese_ci")
 		}
-	case 379:
+	case 423:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1698
+//line yacc.y:1953
 		{
 			yyVAL.bytes = []byte("ujis_bin")
 		}
-	case 380:
+	case 424:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1700
+//line yacc.y:1955
 		{
 			yyVAL.bytes = []byte("utf16_general_ci")
 		}
-	case 381:
+	case 425:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1702
+//line yacc.y:1957
 		{
 			yyVAL.bytes = []byte("utf16_unicode_ci")
 		}
-	case 382:
+	case 426:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1704
+//line yacc.y:1959
 		{
 			yyVAL.bytes = []byte("utf16_bin")
 		}
-	case 383:
+	case 427:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1706
+//line yacc.y:1961
 		{
 			yyVAL.bytes = []byte("utf16le_general_ci")
 		}
-	case 384:
+	case 428:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1708
+//line yacc.y:1963
 		{
 			yyVAL.bytes = []byte("utf16le_bin")
 		}
-	case 385:
+	case 429:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1710
+//line yacc.y:1965
 		{
 			yyVAL.bytes = []byte("utf32_general_ci")
 		}
-	case 386:
+	case 430:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1712
+//line yacc.y:1967
 		{
 			yyVAL.bytes = []byte("utf32_unicode_ci")
 		}
-	case 387:
+	case 431:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1714
+//line yacc.y:1969
 		{
 			yyVAL.bytes = []byte("utf32_bin")
 		}
-	case 388:
+	case 432:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1716
+//line yacc.y:1971
 		{
 			yyVAL.bytes = []byte("utf8_general_ci")
 		}
-	case 389:
+	case 433:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1718
+//line yacc.y:1973
 		{
 			yyVAL.bytes = []byte("utf8_unicode_ci")
 		}
-	case 390:
+	case 434:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1720
+//line yacc.y:1975
 		{
 			yyVAL.bytes = []byte("utf8_bin")
 		}
-	case 391:
+	case 435:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1722
+//line yacc.y:1977
 		{
 			yyVAL.bytes = []byte("utf8mb4_general_ci")
 		}
-	case 392:
+	case 436:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1724
+//line yacc.y:1979
 		{
 			yyVAL.bytes = []byte("utf8mb4_unicode_ci")
 		}
-	case 393:
+	case 437:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1726
+//line yacc.y:1981
 		{
 			yyVAL.bytes = []byte("utf8mb4_bin")
 		}
-	case 394:
+	case 438:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1731
+//line yacc.y:1986
 		{
 			yyVAL.bytes = []byte("read committed")
 		}
-	case 395:
+	case 439:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1733
+//line yacc.y:1988
 		{
 			yyVAL.bytes = []byte("read uncommitted")
 		}
-	case 396:
+	case 440:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1735
+//line yacc.y:1990
 		{
 			yyVAL.bytes = []byte("repeatable read")
 		}
-	case 397:
+	case 441:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1737
+//line yacc.y:1992
 		{
 			yyVAL.bytes = []byte("serializable")
 		}
-	case 398:
+	case 442:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1740
+//line yacc.y:1995
 		{
 			yyVAL.bytes = nil
 		}
-	case 399:
+	case 443:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1742
+//line yacc.y:1997
 		{
 			yyVAL.bytes = []byte("session")
 		}
-	case 400:
+	case 444:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1744
+//line yacc.y:1999
 		{
 			yyVAL.bytes = []byte("global")
 		}
-	case 401:
+	case 445:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:1747
+//line yacc.y:2002
 		{
 			yyVAL.expr = nil
 		}
-	case 402:
+	case 446:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1749
+//line yacc.y:2004
 		{
 			yyVAL.expr = &LikeExpr{Expr: yyDollar[2].valExpr}
 		}
-	case 403:
+	case 447:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1753
+//line yacc.y:2008
 		{
 			yyVAL.expr = &WhereExpr{Expr: yyDollar[2].boolExpr}
 		}
-	case 404:
+	case 448:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1759
+//line yacc.y:2014
 		{
 			yyVAL.createDefs = CreateDefinitions{yyDollar[1].createDef}
 		}
-	case 405:
+	case 449:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1763
+//line yacc.y:2018
 		{
 			yyVAL.createDefs = append(yyDollar[1].createDefs, yyDollar[3].createDef)
 		}
-	case 406:
+	case 450:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:1769
+//line yacc.y:2024
 		{
 			yyVAL.createDef = &CreateColumnDefinition{ColumnName: yyDollar[1].colName, ColumnDef: yyDollar[2].columnDef}
 		}
-	case 407:
+	case 451:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:1773
+//line yacc.y:2028
 		{
 			yyVAL.createDef = &CreatePrimaryKeyDefinition{Symbol: yyDollar[1].bytes, IndexType: yyDollar[4].bytes, IndexColumns: yyDollar[6].idxColNames}
 		}
-	case 408:
+	case 452:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:1777
+//line yacc.y:2032
 		{
 			yyVAL.createDef = &CreateIndexDefinition{Name: yyDollar[2].bytes, IndexType: yyDollar[3].bytes, IndexColumns: yyDollar[5].idxColNames}
 		}
-	case 409:
+	case 453:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:1781
+//line yacc.y:2036
 		{
 			yyVAL.createDef = &CreateIndexDefinition{Name: yyDollar[2].bytes, IndexType: yyDollar[3].bytes, IndexColumns: yyDollar[5].idxColNames}
 		}
-	case 410:
+	case 454:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:1785
+//line yacc.y:2040
 		{
 			yyVAL.createDef = &CreateUniqueIndexDefinition{Symbol: yyDollar[1].bytes, Name: yyDollar[3].bytes, IndexType: yyDollar[4].bytes, IndexColumns: yyDollar[6].idxColNames}
 		}
-	case 411:
+	case 455:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:1789
+//line yacc.y:2044
 		{
 			yyVAL.createDef = &CreateUniqueIndexDefinition{Symbol: yyDollar[1].bytes, Name: yyDollar[4].bytes, IndexType: yyDollar[5].bytes, IndexColumns: yyDollar[7].idxColNames}
 		}
-	case 412:
+	case 456:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:1793
+//line yacc.y:2048
 		{
 			yyVAL.createDef = &CreateUniqueIndexDefinition{Symbol: yyDollar[1].bytes, Name: yyDollar[4].bytes, IndexType: yyDollar[5].bytes, IndexColumns: yyDollar[7].idxColNames}
 		}
-	case 413:
+	case 457:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:1797
+//line yacc.y:2052
 		{
-			yyVAL.createDef = &CreateForeignKeyDefinition{Symbol: yyDollar[1].bytes, IndexColumns: yyDollar[5].idxColNames, ReferenceDef: yyDollar[7].bytes}
+			yyVAL.createDef = &CreateForeignKeyDefinition{Symbol: yyDollar[1].bytes, IndexColumns: yyDollar[5].idxColNames, ReferenceDef: yyDollar[7].refDef}
 		}
-	case 414:
+	case 458:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:2056
+		{
+			yyVAL.createDef = yyDollar[1].checkDef
+		}
+	case 459:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line yacc.y:2062
+		{
+			yyVAL.checkDef = &CreateCheckDefinition{Symbol: yyDollar[1].bytes, Expr: yyDollar[4].boolExpr, Enforced: yyDollar[6].str}
+		}
+	case 460:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line yacc.y:2067
+		{
+			yyVAL.checkDef = nil
+		}
+	case 461:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:2069
+		{
+			yyVAL.checkDef = yyDollar[1].checkDef
+		}
+	case 462:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line yacc.y:2072
+		{
+			yyVAL.str = ""
+		}
+	case 463:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:2074
+		{
+			yyVAL.str = "enforced"
+		}
+	case 464:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line yacc.y:2076
+		{
+			yyVAL.str = "not enforced"
+		}
+	case 465:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line yacc.y:2080
+		{
+			yyDollar[1].columnDef.Check = yyDollar[2].checkDef
+			yyVAL.columnDef = yyDollar[1].columnDef
+		}
+	case 466:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:1803
+//line yacc.y:2087
 		{
 			yyVAL.columnDef = &ColumnDefinition{Type: yyDollar[1].dataType,
 				IsAutoIncrement: yyDollar[2].boolean,
@@ -4299,11 +4821,11 @@ yydefault:
 				ColumnComment:   yyDollar[4].valExpr,
 				ColumnFormat:    yyDollar[5].bytes,
 				ColumnStorage:   yyDollar[6].bytes,
-				ReferenceDef:    yyDollar[7].bytes}
+				ReferenceDef:    yyDollar[7].refDef}
 		}
-	case 415:
+	case 467:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:1813
+//line yacc.y:2097
 		{
 			yyVAL.columnDef = &ColumnDefinition{Type: yyDollar[1].dataType,
 				IsNotNull:       yyDollar[2].boolean,
@@ -4312,892 +4834,990 @@ yydefault:
 				ColumnComment:   yyDollar[5].valExpr,
 				ColumnFormat:    yyDollar[6].bytes,
 				ColumnStorage:   yyDollar[7].bytes,
-				ReferenceDef:    yyDollar[8].bytes}
+				ReferenceDef:    yyDollar[8].refDef}
 		}
-	case 416:
+	case 468:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:1824
+//line yacc.y:2108
 		{
 			yyVAL.columnDef = &ColumnDefinition{Type: yyDollar[1].dataType,
-				DefaultValue:    yyDollar[2].valExpr,
+				DefaultValue:    yyDollar[2].expr,
 				IsAutoIncrement: yyDollar[3].boolean,
 				UniqueOrKey:     yyDollar[4].bytes,
 				ColumnComment:   yyDollar[5].valExpr,
 				ColumnFormat:    yyDollar[6].bytes,
 				ColumnStorage:   yyDollar[7].bytes,
-				ReferenceDef:    yyDollar[8].bytes}
+				ReferenceDef:    yyDollar[8].refDef}
 		}
-	case 417:
+	case 469:
 		yyDollar = yyS[yypt-9 : yypt+1]
-		//line yacc.y:1835
+//line yacc.y:2119
 		{
 			yyVAL.columnDef = &ColumnDefinition{Type: yyDollar[1].dataType,
 				IsNotNull:       yyDollar[2].boolean,
-				DefaultValue:    yyDollar[3].valExpr,
+				DefaultValue:    yyDollar[3].expr,
 				IsAutoIncrement: yyDollar[4].boolean,
 				UniqueOrKey:     yyDollar[5].bytes,
 				ColumnComment:   yyDollar[6].valExpr,
 				ColumnFormat:    yyDollar[7].bytes,
 				ColumnStorage:   yyDollar[8].bytes,
-				ReferenceDef:    yyDollar[9].bytes}
+				ReferenceDef:    yyDollar[9].refDef}
 		}
-	case 418:
+	case 470:
 		yyDollar = yyS[yypt-9 : yypt+1]
-		//line yacc.y:1847
+//line yacc.y:2131
 		{
 			yyVAL.columnDef = &ColumnDefinition{Type: yyDollar[1].dataType,
 				IsNotNull:       yyDollar[3].boolean,
-				DefaultValue:    yyDollar[2].valExpr,
+				DefaultValue:    yyDollar[2].expr,
 				IsAutoIncrement: yyDollar[4].boolean,
 				UniqueOrKey:     yyDollar[5].bytes,
 				ColumnComment:   yyDollar[6].valExpr,
 				ColumnFormat:    yyDollar[7].bytes,
 				ColumnStorage:   yyDollar[8].bytes,
-				ReferenceDef:    yyDollar[9].bytes}
+				ReferenceDef:    yyDollar[9].refDef}
+		}
+	case 471:
+		yyDollar = yyS[yypt-10 : yypt+1]
+//line yacc.y:2143
+		{
+			yyVAL.columnDef = &ColumnDefinition{Type: yyDollar[1].dataType,
+				GeneratedExpr:    yyDollar[5].expr,
+				GeneratedStorage: yyDollar[7].str,
+				IsNotNull:        yyDollar[8].boolean,
+				UniqueOrKey:      yyDollar[9].bytes,
+				ColumnComment:    yyDollar[10].valExpr}
+		}
+	case 472:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line yacc.y:2153
+		{
+			yyVAL.empty = struct{}{}
+		}
+	case 473:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line yacc.y:2155
+		{
+			yyVAL.empty = struct{}{}
+		}
+	case 474:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line yacc.y:2158
+		{
+			yyVAL.str = ""
+		}
+	case 475:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:2160
+		{
+			yyVAL.str = "virtual"
+		}
+	case 476:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:2162
+		{
+			yyVAL.str = "stored"
 		}
-	case 419:
+	case 477:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1861
+//line yacc.y:2166
 		{
 			yyVAL.dataType = &DataType{TypeName: "bit"}
 		}
-	case 420:
+	case 478:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:1865
+//line yacc.y:2170
 		{
 			yyVAL.dataType = &DataType{TypeName: "bit(" + string(yyDollar[3].bytes) + ")"}
 		}
-	case 421:
+	case 479:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1869
+//line yacc.y:2174
 		{
 			yyVAL.dataType = &DataType{TypeName: "tinyint", IsUnsigned: yyDollar[2].boolean, IsZeroFill: yyDollar[3].boolean}
 		}
-	case 422:
+	case 480:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:1873
+//line yacc.y:2178
 		{
 			yyVAL.dataType = &DataType{TypeName: "tinyint(" + string(yyDollar[3].bytes) + ")", IsUnsigned: yyDollar[5].boolean, IsZeroFill: yyDollar[6].boolean}
 		}
-	case 423:
+	case 481:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1877
+//line yacc.y:2182
 		{
 			yyVAL.dataType = &DataType{TypeName: "bool"}
 		}
-	case 424:
+	case 482:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1881
+//line yacc.y:2186
 		{
 			yyVAL.dataType = &DataType{TypeName: "bool"}
 		}
-	case 425:
+	case 483:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1885
+//line yacc.y:2190
 		{
 			yyVAL.dataType = &DataType{TypeName: "smallint", IsUnsigned: yyDollar[2].boolean, IsZeroFill: yyDollar[3].boolean}
 		}
-	case 426:
+	case 484:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:1889
+//line yacc.y:2194
 		{
 			yyVAL.dataType = &DataType{TypeName: "smallint(" + string(yyDollar[3].bytes) + ")", IsUnsigned: yyDollar[5].boolean, IsZeroFill: yyDollar[6].boolean}
 		}
-	case 427:
+	case 485:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1893
+//line yacc.y:2198
 		{
 			yyVAL.dataType = &DataType{TypeName: "mediumint", IsUnsigned: yyDollar[2].boolean, IsZeroFill: yyDollar[3].boolean}
 		}
-	case 428:
+	case 486:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:1897
+//line yacc.y:2202
 		{
 			yyVAL.dataType = &DataType{TypeName: "mediumint(" + string(yyDollar[3].bytes) + ")", IsUnsigned: yyDollar[5].boolean, IsZeroFill: yyDollar[6].boolean}
 		}
-	case 429:
+	case 487:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1901
+//line yacc.y:2206
 		{
 			yyVAL.dataType = &DataType{TypeName: "int", IsUnsigned: yyDollar[2].boolean, IsZeroFill: yyDollar[3].boolean}
 		}
-	case 430:
+	case 488:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:1905
+//line yacc.y:2210
 		{
 			yyVAL.dataType = &DataType{TypeName: "int(" + string(yyDollar[3].bytes) + ")", IsUnsigned: yyDollar[5].boolean, IsZeroFill: yyDollar[6].boolean}
 		}
-	case 431:
+	case 489:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1909
+//line yacc.y:2214
 		{
 			yyVAL.dataType = &DataType{TypeName: "integer", IsUnsigned: yyDollar[2].boolean, IsZeroFill: yyDollar[3].boolean}
 		}
-	case 432:
+	case 490:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:1913
+//line yacc.y:2218
 		{
 			yyVAL.dataType = &DataType{TypeName: "integer(" + string(yyDollar[3].bytes) + ")", IsUnsigned: yyDollar[5].boolean, IsZeroFill: yyDollar[6].boolean}
 		}
-	case 433:
+	case 491:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1917
+//line yacc.y:2222
 		{
 			yyVAL.dataType = &DataType{TypeName: "bigint", IsUnsigned: yyDollar[2].boolean, IsZeroFill: yyDollar[3].boolean}
 		}
-	case 434:
+	case 492:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:1921
+//line yacc.y:2226
 		{
 			yyVAL.dataType = &DataType{TypeName: "bigint(" + string(yyDollar[3].bytes) + ")", IsUnsigned: yyDollar[5].boolean, IsZeroFill: yyDollar[6].boolean}
 		}
-	case 435:
+	case 493:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1925
+//line yacc.y:2230
 		{
 			yyVAL.dataType = &DataType{TypeName: "real", IsUnsigned: yyDollar[2].boolean, IsZeroFill: yyDollar[3].boolean}
 		}
-	case 436:
+	case 494:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:1929
+//line yacc.y:2234
 		{
 			yyVAL.dataType = &DataType{TypeName: "real(" + string(yyDollar[3].bytes) + "," + string(yyDollar[5].bytes) + ")", IsUnsigned: yyDollar[7].boolean, IsZeroFill: yyDollar[8].boolean}
 		}
-	case 437:
+	case 495:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1933
+//line yacc.y:2238
 		{
 			yyVAL.dataType = &DataType{TypeName: "double", IsUnsigned: yyDollar[2].boolean, IsZeroFill: yyDollar[3].boolean}
 		}
-	case 438:
+	case 496:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:1937
+//line yacc.y:2242
 		{
 			yyVAL.dataType = &DataType{TypeName: "double(" + string(yyDollar[3].bytes) + "," + string(yyDollar[5].bytes) + ")", IsUnsigned: yyDollar[7].boolean, IsZeroFill: yyDollar[8].boolean}
 		}
-	case 439:
+	case 497:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1941
+//line yacc.y:2246
 		{
 			yyVAL.dataType = &DataType{TypeName: "float", IsUnsigned: yyDollar[2].boolean, IsZeroFill: yyDollar[3].boolean}
 		}
-	case 440:
+	case 498:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:1945
+//line yacc.y:2250
 		{
 			yyVAL.dataType = &DataType{TypeName: "float(" + string(yyDollar[3].bytes) + "," + string(yyDollar[5].bytes) + ")", IsUnsigned: yyDollar[7].boolean, IsZeroFill: yyDollar[8].boolean}
 		}
-	case 441:
+	case 499:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:1949
+//line yacc.y:2254
 		{
 			yyVAL.dataType = &DataType{TypeName: "decimal", IsUnsigned: yyDollar[2].boolean, IsZeroFill: yyDollar[3].boolean}
 		}
-	case 442:
+	case 500:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:1953
+//line yacc.y:2258
 		{
 			yyVAL.dataType = &DataType{TypeName: "decimal(" + string(yyDollar[3].bytes) + ")", IsUnsigned: yyDollar[5].boolean, IsZeroFill: yyDollar[6].boolean}
 		}
-	case 443:
+	case 501:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:1957
+//line yacc.y:2262
 		{
 			yyVAL.dataType = &DataType{TypeName: "decimal(" + string(yyDollar[3].bytes) + "," + string(yyDollar[5].bytes) + ")", IsUnsigned: yyDollar[7].boolean, IsZeroFill: yyDollar[8].boolean}
 		}
-	case 444:
+	case 502:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1961
+//line yacc.y:2266
 		{
 			yyVAL.dataType = &DataType{TypeName: "date"}
 		}
-	case 445:
+	case 503:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1965
+//line yacc.y:2270
 		{
 			yyVAL.dataType = &DataType{TypeName: "time"}
 		}
-	case 446:
+	case 504:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:1969
+//line yacc.y:2274
 		{
 			yyVAL.dataType = &DataType{TypeName: "time(" + string(yyDollar[3].bytes) + ")"}
 		}
-	case 447:
+	case 505:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1973
+//line yacc.y:2278
 		{
 			yyVAL.dataType = &DataType{TypeName: "timestamp"}
 		}
-	case 448:
+	case 506:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:1977
+//line yacc.y:2282
 		{
 			yyVAL.dataType = &DataType{TypeName: "timestamp(" + string(yyDollar[3].bytes) + ")"}
 		}
-	case 449:
+	case 507:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1981
+//line yacc.y:2286
 		{
 			yyVAL.dataType = &DataType{TypeName: "datetime"}
 		}
-	case 450:
+	case 508:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:1985
+//line yacc.y:2290
 		{
 			yyVAL.dataType = &DataType{TypeName: "datetime(" + string(yyDollar[3].bytes) + ")"}
 		}
-	case 451:
+	case 509:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:1989
+//line yacc.y:2294
 		{
 			yyVAL.dataType = &DataType{TypeName: "year"}
 		}
-	case 452:
+	case 510:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:1993
+//line yacc.y:2298
 		{
 			yyVAL.dataType = &DataType{TypeName: "char", IsBinary: yyDollar[2].boolean, Charset: yyDollar[3].bytes, Collate: yyDollar[4].bytes}
 		}
-	case 453:
+	case 511:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:1997
+//line yacc.y:2302
 		{
 			yyVAL.dataType = &DataType{TypeName: "nchar", IsBinary: yyDollar[2].boolean, Charset: yyDollar[3].bytes, Collate: yyDollar[4].bytes}
 		}
-	case 454:
+	case 512:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:2001
+//line yacc.y:2306
 		{
 			yyVAL.dataType = &DataType{TypeName: "char(" + string(yyDollar[3].bytes) + ")", IsBinary: yyDollar[5].boolean, Charset: yyDollar[6].bytes, Collate: yyDollar[7].bytes}
 		}
-	case 455:
+	case 513:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:2005
+//line yacc.y:2310
 		{
 			yyVAL.dataType = &DataType{TypeName: "nchar(" + string(yyDollar[3].bytes) + ")", IsBinary: yyDollar[5].boolean, Charset: yyDollar[6].bytes, Collate: yyDollar[7].bytes}
 		}
-	case 456:
+	case 514:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:2009
+//line yacc.y:2314
 		{
 			yyVAL.dataType = &DataType{TypeName: "varchar(" + string(yyDollar[3].bytes) + ")", IsBinary: yyDollar[5].boolean, Charset: yyDollar[6].bytes, Collate: yyDollar[7].bytes}
 		}
-	case 457:
+	case 515:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:2013
+//line yacc.y:2318
 		{
 			yyVAL.dataType = &DataType{TypeName: "nvarchar(" + string(yyDollar[3].bytes) + ")", IsBinary: yyDollar[5].boolean, Charset: yyDollar[6].bytes, Collate: yyDollar[7].bytes}
 		}
-	case 458:
+	case 516:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2017
+//line yacc.y:2322
 		{
 			yyVAL.dataType = &DataType{TypeName: "binary"}
 		}
-	case 459:
+	case 517:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:2021
+//line yacc.y:2326
 		{
 			yyVAL.dataType = &DataType{TypeName: "binary(" + string(yyDollar[3].bytes) + ")"}
 		}
-	case 460:
+	case 518:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:2025
+//line yacc.y:2330
 		{
 			yyVAL.dataType = &DataType{TypeName: "varbinary(" + string(yyDollar[3].bytes) + ")"}
 		}
-	case 461:
+	case 519:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2029
+//line yacc.y:2334
 		{
 			yyVAL.dataType = &DataType{TypeName: "tinyblob"}
 		}
-	case 462:
+	case 520:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2033
+//line yacc.y:2338
 		{
 			yyVAL.dataType = &DataType{TypeName: "blob"}
 		}
-	case 463:
+	case 521:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2037
+//line yacc.y:2342
 		{
 			yyVAL.dataType = &DataType{TypeName: "mediumblob"}
 		}
-	case 464:
+	case 522:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2041
+//line yacc.y:2346
 		{
 			yyVAL.dataType = &DataType{TypeName: "longblob"}
 		}
-	case 465:
+	case 523:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:2045
+//line yacc.y:2350
 		{
 			yyVAL.dataType = &DataType{TypeName: "tinytext", IsBinary: yyDollar[2].boolean, Charset: yyDollar[3].bytes, Collate: yyDollar[4].bytes}
 		}
-	case 466:
+	case 524:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:2049
+//line yacc.y:2354
 		{
 			yyVAL.dataType = &DataType{TypeName: "text", IsBinary: yyDollar[2].boolean, Charset: yyDollar[3].bytes, Collate: yyDollar[4].bytes}
 		}
-	case 467:
+	case 525:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:2053
+//line yacc.y:2358
 		{
 			yyVAL.dataType = &DataType{TypeName: "mediumtext", IsBinary: yyDollar[2].boolean, Charset: yyDollar[3].bytes, Collate: yyDollar[4].bytes}
 		}
-	case 468:
+	case 526:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:2057
+//line yacc.y:2362
 		{
 			yyVAL.dataType = &DataType{TypeName: "longtext", IsBinary: yyDollar[2].boolean, Charset: yyDollar[3].bytes, Collate: yyDollar[4].bytes}
 		}
-	case 469:
+	case 527:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:2061
+//line yacc.y:2366
 		{
 			yyVAL.dataType = &DataType{TypeName: "enum(" + String(yyDollar[3].valExprs) + ")", Charset: yyDollar[5].bytes, Collate: yyDollar[6].bytes}
 		}
-	case 470:
+	case 528:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:2065
+//line yacc.y:2370
 		{
 			yyVAL.dataType = &DataType{TypeName: "set(" + String(yyDollar[3].valExprs) + ")", Charset: yyDollar[5].bytes, Collate: yyDollar[6].bytes}
 		}
-	case 471:
+	case 529:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2071
+//line yacc.y:2376
 		{
 			yyVAL.boolean = false
 		}
-	case 472:
+	case 530:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2073
+//line yacc.y:2378
 		{
 			yyVAL.boolean = true
 		}
-	case 473:
+	case 531:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line yacc.y:2381
+		{
+			yyVAL.boolean = false
+		}
+	case 532:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line yacc.y:2383
+		{
+			yyVAL.boolean = yyDollar[1].boolean
+		}
+	case 533:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2077
+//line yacc.y:2387
 		{
-			yyVAL.valExpr = yyDollar[2].valExpr
+			yyVAL.expr = yyDollar[2].valExpr
 		}
-	case 474:
+	case 534:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line yacc.y:2389
+		{
+			yyVAL.expr = yyDollar[2].tuple
+		}
+	case 535:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line yacc.y:2391
+		{
+			yyVAL.expr = &ParenBoolExpr{Expr: yyDollar[3].boolExpr}
+		}
+	case 536:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2080
+//line yacc.y:2394
 		{
 			yyVAL.boolean = false
 		}
-	case 475:
+	case 537:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2082
+//line yacc.y:2396
 		{
 			yyVAL.boolean = true
 		}
-	case 476:
+	case 538:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2085
+//line yacc.y:2399
 		{
 			yyVAL.bytes = nil
 		}
-	case 477:
+	case 539:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2087
+//line yacc.y:2401
 		{
 			yyVAL.bytes = []byte("unique key")
 		}
-	case 478:
+	case 540:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2089
+//line yacc.y:2403
 		{
 			yyVAL.bytes = []byte("unique key")
 		}
-	case 479:
+	case 541:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2091
+//line yacc.y:2405
 		{
 			yyVAL.bytes = []byte("primary key")
 		}
-	case 480:
+	case 542:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2093
+//line yacc.y:2407
 		{
 			yyVAL.bytes = []byte("primary key")
 		}
-	case 481:
+	case 543:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2096
+//line yacc.y:2410
 		{
 			yyVAL.valExpr = nil
 		}
-	case 482:
+	case 544:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2098
+//line yacc.y:2412
 		{
 			yyVAL.valExpr = StrVal(yyDollar[2].bytes)
 		}
-	case 483:
+	case 545:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2103
+//line yacc.y:2417
 		{
 			yyVAL.bytes = nil
 		}
-	case 484:
+	case 546:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2105
+//line yacc.y:2419
 		{
 			yyVAL.bytes = []byte("fixed")
 		}
-	case 485:
+	case 547:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2107
+//line yacc.y:2421
 		{
 			yyVAL.bytes = []byte("dynamic")
 		}
-	case 486:
+	case 548:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2109
+//line yacc.y:2423
 		{
 			yyVAL.bytes = []byte("default")
 		}
-	case 487:
+	case 549:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2112
+//line yacc.y:2426
 		{
 			yyVAL.bytes = nil
 		}
-	case 488:
+	case 550:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2114
+//line yacc.y:2428
 		{
 			yyVAL.bytes = []byte("disk")
 		}
-	case 489:
+	case 551:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2116
+//line yacc.y:2430
 		{
 			yyVAL.bytes = []byte("memory")
 		}
-	case 490:
+	case 552:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2118
+//line yacc.y:2432
 		{
 			yyVAL.bytes = []byte("default")
 		}
-	case 491:
+	case 553:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2121
+//line yacc.y:2435
 		{
-			yyVAL.bytes = nil
+			yyVAL.refDef = nil
 		}
-	case 492:
+	case 554:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2123
+//line yacc.y:2437
 		{
-			yyVAL.bytes = yyDollar[1].bytes
+			yyVAL.refDef = yyDollar[1].refDef
 		}
-	case 493:
+	case 555:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:2127
+//line yacc.y:2441
 		{
-			yyVAL.bytes = []byte("references " + String(yyDollar[2].tableName) + "(" + String(yyDollar[4].idxColNames) + ")" + string(yyDollar[6].bytes) + string(yyDollar[7].bytes))
+			yyVAL.refDef = yyDollar[7].refDef
+			yyVAL.refDef.Table = yyDollar[2].tableName
+			yyVAL.refDef.Columns = yyDollar[4].idxColNames
+			yyVAL.refDef.Match = string(yyDollar[6].bytes)
 		}
-	case 494:
+	case 556:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2130
+//line yacc.y:2449
 		{
 			yyVAL.bytes = nil
 		}
-	case 495:
+	case 557:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2132
+//line yacc.y:2451
 		{
 			yyVAL.bytes = []byte("match full")
 		}
-	case 496:
+	case 558:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2134
+//line yacc.y:2453
 		{
 			yyVAL.bytes = []byte("match partial")
 		}
-	case 497:
+	case 559:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2136
+//line yacc.y:2455
 		{
 			yyVAL.bytes = []byte("match simple")
 		}
-	case 498:
+	case 560:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2139
+//line yacc.y:2458
 		{
-			yyVAL.bytes = nil
+			yyVAL.refDef = &ReferenceDefinition{}
 		}
-	case 499:
+	case 561:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:2141
+//line yacc.y:2460
 		{
-			yyVAL.bytes = []byte("on delete " + string(yyDollar[3].bytes))
+			yyVAL.refDef = &ReferenceDefinition{OnDelete: string(yyDollar[3].bytes)}
 		}
-	case 500:
+	case 562:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:2143
+//line yacc.y:2462
 		{
-			yyVAL.bytes = []byte("on update " + string(yyDollar[3].bytes))
+			yyVAL.refDef = &ReferenceDefinition{OnUpdate: string(yyDollar[3].bytes)}
 		}
-	case 501:
+	case 563:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:2145
+//line yacc.y:2464
 		{
-			yyVAL.bytes = []byte("on update " + string(yyDollar[3].bytes) + " on delete " + string(yyDollar[6].bytes))
+			yyVAL.refDef = &ReferenceDefinition{OnDelete: string(yyDollar[6].bytes), OnUpdate: string(yyDollar[3].bytes)}
 		}
-	case 502:
+	case 564:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:2147
+//line yacc.y:2466
 		{
-			yyVAL.bytes = []byte("on update " + string(yyDollar[6].bytes) + " on delete " + string(yyDollar[3].bytes))
+			yyVAL.refDef = &ReferenceDefinition{OnDelete: string(yyDollar[3].bytes), OnUpdate: string(yyDollar[6].bytes)}
 		}
-	case 503:
+	case 565:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2150
+//line yacc.y:2469
 		{
 			yyVAL.bytes = nil
 		}
-	case 504:
+	case 566:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2152
+//line yacc.y:2471
 		{
 			yyVAL.bytes = yyDollar[1].bytes
 		}
-	case 505:
+	case 567:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2156
+//line yacc.y:2475
 		{
 			yyVAL.bytes = []byte("restrict")
 		}
-	case 506:
+	case 568:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2158
+//line yacc.y:2477
 		{
 			yyVAL.bytes = []byte("cascade")
 		}
-	case 507:
+	case 569:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2160
+//line yacc.y:2479
 		{
 			yyVAL.bytes = []byte("set null")
 		}
-	case 508:
+	case 570:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2162
+//line yacc.y:2481
 		{
 			yyVAL.bytes = []byte("no action")
 		}
-	case 509:
+	case 571:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2165
+//line yacc.y:2484
 		{
 			yyVAL.boolean = false
 		}
-	case 510:
+	case 572:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2167
+//line yacc.y:2486
 		{
 			yyVAL.boolean = true
 		}
-	case 511:
+	case 573:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2170
+//line yacc.y:2489
 		{
 			yyVAL.boolean = false
 		}
-	case 512:
+	case 574:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2172
+//line yacc.y:2491
 		{
 			yyVAL.boolean = true
 		}
-	case 513:
+	case 575:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2175
+//line yacc.y:2494
 		{
 			yyVAL.boolean = false
 		}
-	case 514:
+	case 576:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2177
+//line yacc.y:2496
 		{
 			yyVAL.boolean = true
 		}
-	case 515:
+	case 577:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2180
+//line yacc.y:2499
 		{
 			yyVAL.bytes = nil
 		}
-	case 516:
+	case 578:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:2182
+//line yacc.y:2501
 		{
 			yyVAL.bytes = yyDollar[3].bytes
 		}
-	case 517:
+	case 579:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2185
+//line yacc.y:2504
 		{
 			yyVAL.bytes = nil
 		}
-	case 518:
+	case 580:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2187
+//line yacc.y:2506
 		{
 			yyVAL.bytes = yyDollar[2].bytes
 		}
-	case 519:
+	case 581:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2190
+//line yacc.y:2509
 		{
 			yyVAL.bytes = nil
 		}
-	case 520:
+	case 582:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2192
+//line yacc.y:2511
 		{
 			yyVAL.bytes = yyDollar[2].bytes
 		}
-	case 521:
+	case 583:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2195
+//line yacc.y:2514
 		{
 			yyVAL.optKeyVals = nil
 		}
-	case 522:
+	case 584:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2197
+//line yacc.y:2516
 		{
 			yyVAL.optKeyVals = yyDollar[1].optKeyVals
 		}
-	case 523:
+	case 585:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2201
+//line yacc.y:2520
 		{
 			yyVAL.optKeyVals = OptionKeyValues{yyDollar[1].optKeyVal}
 		}
-	case 524:
+	case 586:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2203
+//line yacc.y:2522
 		{
 			yyVAL.optKeyVals = append(yyDollar[1].optKeyVals, yyDollar[2].optKeyVal)
 		}
-	case 525:
+	case 587:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:2207
+//line yacc.y:2526
 		{
 			yyVAL.optKeyVal = &OptionKeyValue{Key: "engine", Value: string(yyDollar[3].bytes)}
 		}
-	case 526:
+	case 588:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:2211
+//line yacc.y:2530
 		{
 			yyVAL.optKeyVal = &OptionKeyValue{Key: "auto_increment", Value: string(yyDollar[3].bytes)}
 		}
-	case 527:
+	case 589:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:2215
+//line yacc.y:2534
 		{
 			yyVAL.optKeyVal = &OptionKeyValue{Key: "charset", Value: string(yyDollar[5].bytes)}
 		}
-	case 528:
+	case 590:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:2219
+//line yacc.y:2538
 		{
 			yyVAL.optKeyVal = &OptionKeyValue{Key: "charset", Value: string(yyDollar[4].bytes)}
 		}
-	case 529:
+	case 591:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:2223
+//line yacc.y:2542
 		{
 			yyVAL.optKeyVal = &OptionKeyValue{Key: "charset", Value: string(yyDollar[4].bytes)}
 		}
-	case 530:
+	case 592:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:2227
+//line yacc.y:2546
 		{
 			yyVAL.optKeyVal = &OptionKeyValue{Key: "charset", Value: string(yyDollar[3].bytes)}
 		}
-	case 531:
+	case 593:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:2231
+//line yacc.y:2550
 		{
 			yyVAL.optKeyVal = &OptionKeyValue{Key: "collate", Value: string(yyDollar[4].bytes)}
 		}
-	case 532:
+	case 594:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:2235
+//line yacc.y:2554
 		{
 			yyVAL.optKeyVal = &OptionKeyValue{Key: "collate", Value: string(yyDollar[3].bytes)}
 		}
-	case 533:
+	case 595:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:2239
+//line yacc.y:2558
 		{
 			yyVAL.optKeyVal = &OptionKeyValue{Key: "comment", Value: String(StrVal(yyDollar[3].bytes))}
 		}
-	case 534:
+	case 596:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2244
+//line yacc.y:2563
 		{
 			yyVAL.alterSpecs = nil
 		}
-	case 535:
+	case 597:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2246
+//line yacc.y:2565
 		{
 			yyVAL.alterSpecs = yyDollar[1].alterSpecs
 		}
-	case 536:
+	case 598:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2250
+//line yacc.y:2569
 		{
 			yyVAL.alterSpecs = AlterSpecifications{yyDollar[1].alterSpec}
 		}
-	case 537:
+	case 599:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:2252
+//line yacc.y:2571
 		{
 			yyVAL.alterSpecs = append(yyDollar[1].alterSpecs, yyDollar[3].alterSpec)
 		}
-	case 538:
+	case 600:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2256
+//line yacc.y:2575
 		{
 			yyVAL.alterSpec = yyDollar[1].optKeyVals
 		}
-	case 539:
+	case 601:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:2260
+//line yacc.y:2579
 		{
 			yyVAL.alterSpec = &AddOrModifyColumnSpec{Action: "add", ColumnName: yyDollar[3].colName, ColumnDef: yyDollar[4].columnDef, FirstOrAfterColumn: yyDollar[5].fiOAfCol}
 		}
-	case 540:
+	case 602:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:2264
+//line yacc.y:2583
 		{
 			yyVAL.alterSpec = &AddIndexSpec{Name: yyDollar[3].bytes, IndexType: yyDollar[4].bytes, IndexColumns: yyDollar[6].idxColNames}
 		}
-	case 541:
+	case 603:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line yacc.y:2268
+//line yacc.y:2587
 		{
 			yyVAL.alterSpec = &AddIndexSpec{Name: yyDollar[3].bytes, IndexType: yyDollar[4].bytes, IndexColumns: yyDollar[6].idxColNames}
 		}
-	case 542:
+	case 604:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:2272
+//line yacc.y:2591
 		{
 			yyVAL.alterSpec = &AddPrimaryKeySpec{Symbol: yyDollar[2].bytes, IndexType: yyDollar[5].bytes, IndexColumns: yyDollar[7].idxColNames}
 		}
-	case 543:
+	case 605:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:2276
+//line yacc.y:2595
 		{
 			yyVAL.alterSpec = &AddUniqueIndexSpec{Symbol: yyDollar[2].bytes, Name: yyDollar[4].bytes, IndexType: yyDollar[5].bytes, IndexColumns: yyDollar[7].idxColNames}
 		}
-	case 544:
+	case 606:
 		yyDollar = yyS[yypt-9 : yypt+1]
-		//line yacc.y:2280
+//line yacc.y:2599
 		{
 			yyVAL.alterSpec = &AddUniqueIndexSpec{Symbol: yyDollar[2].bytes, Name: yyDollar[5].bytes, IndexType: yyDollar[6].bytes, IndexColumns: yyDollar[8].idxColNames}
 		}
-	case 545:
+	case 607:
 		yyDollar = yyS[yypt-9 : yypt+1]
-		//line yacc.y:2284
+//line yacc.y:2603
 		{
 			yyVAL.alterSpec = &AddUniqueIndexSpec{Symbol: yyDollar[2].bytes, Name: yyDollar[5].bytes, IndexType: yyDollar[6].bytes, IndexColumns: yyDollar[8].idxColNames}
 		}
-	case 546:
+	case 608:
 		yyDollar = yyS[yypt-8 : yypt+1]
-		//line yacc.y:2288
+//line yacc.y:2607
 		{
-			yyVAL.alterSpec = &AddForeignKeySpec{Symbol: yyDollar[2].bytes, IndexColumns: yyDollar[6].idxColNames, ReferenceDef: yyDollar[8].bytes}
+			yyVAL.alterSpec = &AddForeignKeySpec{Symbol: yyDollar[2].bytes, IndexColumns: yyDollar[6].idxColNames, ReferenceDef: yyDollar[8].refDef}
 		}
-	case 547:
+	case 609:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line yacc.y:2611
+		{
+			yyVAL.alterSpec = &AddCheckSpec{Check: yyDollar[2].checkDef}
+		}
+	case 610:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line yacc.y:2615
+		{
+			yyVAL.alterSpec = &AlterCheckSpec{Name: yyDollar[3].bytes, Enforced: yyDollar[4].str}
+		}
+	case 611:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line yacc.y:2619
+		{
+			yyVAL.alterSpec = &AlterCheckSpec{Name: yyDollar[3].bytes, Enforced: yyDollar[4].str}
+		}
+	case 612:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line yacc.y:2292
+//line yacc.y:2623
 		{
 			yyVAL.alterSpec = &ChangeColumnSpec{OldColumnName: yyDollar[3].colName, ColumnName: yyDollar[4].colName, ColumnDef: yyDollar[5].columnDef, FirstOrAfterColumn: yyDollar[6].fiOAfCol}
 		}
-	case 548:
+	case 613:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line yacc.y:2296
+//line yacc.y:2627
 		{
 			yyVAL.alterSpec = &AddOrModifyColumnSpec{Action: "modify", ColumnName: yyDollar[3].colName, ColumnDef: yyDollar[4].columnDef, FirstOrAfterColumn: yyDollar[5].fiOAfCol}
 		}
-	case 549:
+	case 614:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:2300
+//line yacc.y:2631
 		{
 			yyVAL.alterSpec = &DropColumnSpec{ColumnName: yyDollar[3].colName}
 		}
-	case 550:
+	case 615:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:2304
+//line yacc.y:2635
 		{
 			yyVAL.alterSpec = &DropPrimaryKeySpec{}
 		}
-	case 551:
+	case 616:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:2308
+//line yacc.y:2639
 		{
 			yyVAL.alterSpec = &DropIndexSpec{Name: yyDollar[3].bytes}
 		}
-	case 552:
+	case 617:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line yacc.y:2312
+//line yacc.y:2643
 		{
 			yyVAL.alterSpec = &DropIndexSpec{Name: yyDollar[3].bytes}
 		}
-	case 553:
+	case 618:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line yacc.y:2316
+//line yacc.y:2647
 		{
 			yyVAL.alterSpec = &DropForeignKeySpec{Name: yyDollar[4].bytes}
 		}
-	case 554:
+	case 619:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line yacc.y:2651
+		{
+			yyVAL.alterSpec = &DropCheckSpec{Name: yyDollar[3].bytes}
+		}
+	case 620:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line yacc.y:2655
+		{
+			yyVAL.alterSpec = &DropConstraintSpec{Name: yyDollar[3].bytes}
+		}
+	case 621:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2320
+//line yacc.y:2659
 		{
 			yyVAL.alterSpec = &DisableKeysSpec{}
 		}
-	case 555:
+	case 622:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2324
+//line yacc.y:2663
 		{
 			yyVAL.alterSpec = &EnableKeysSpec{}
 		}
-	case 556:
+	case 623:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line yacc.y:2329
+//line yacc.y:2668
 		{
 			yyVAL.fiOAfCol = nil
 		}
-	case 557:
+	case 624:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line yacc.y:2331
+//line yacc.y:2670
 		{
 			yyVAL.fiOAfCol = yyDollar[1].fiOAfCol
 		}
-	case 558:
+	case 625:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2335
+//line yacc.y:2674
 		{
 			yyVAL.fiOAfCol = &FirstOrAfterColumn{FirstOrAfter: "first", ColumnName: yyDollar[2].colName}
 		}
-	case 559:
+	case 626:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line yacc.y:2339
+//line yacc.y:2678
 		{
 			yyVAL.fiOAfCol = &FirstOrAfterColumn{FirstOrAfter: "after", ColumnName: yyDollar[2].colName}
 		}
@@ -73,6 +73,7 @@ var (
   tableExpr   TableExpr
   smTableExpr SimpleTableExpr
   tableName   *TableName
+  tableNames  TableNames
   indexHints  *IndexHints
   expr        Expr
   boolExpr    BoolExpr
@@ -102,15 +103,27 @@ var (
   fiOAfCol    *FirstOrAfterColumn
   alterSpecs  AlterSpecifications
   alterSpec   AlterSpecification
+  selOpts     selectOptions
+  cte         *CommonTableExpr
+  ctes        []*CommonTableExpr
+  windowSpec  *WindowSpec
+  frame       *WindowFrame
+  frameBound  *FrameBound
+  checkDef    *CreateCheckDefinition
+  refDef      *ReferenceDefinition
 }
 
 %token LEX_ERROR
 %token <empty> SELECT INSERT UPDATE DELETE FROM WHERE GROUP HAVING ORDER BY LIMIT FOR
-%token <empty> ALL DISTINCT AS EXISTS NULL ASC DESC VALUES INTO DUPLICATE KEY DEFAULT SET LOCK
+%token <empty> ALL DISTINCT SQL_CALC_FOUND_ROWS AS EXISTS NULL ASC DESC VALUES INTO DUPLICATE KEY DEFAULT SET LOCK
 %token <empty> SHOW EXPLAIN DESCRIBE
+%token <empty> RECURSIVE
+%token <empty> ANY SOME
+%token <empty> OVER PARTITION ROWS RANGE ROW CURRENT UNBOUNDED PRECEDING FOLLOWING
 %token <bytes> ID STRING NUMBER VALUE_ARG COMMENTS
 %token <empty> '(' '~'
 
+%nonassoc <empty> WITH
 %left <empty> UNION MINUS EXCEPT INTERSECT
 %left <empty> ','
 %left <empty> FULL JOIN STRAIGHT_JOIN LEFT RIGHT INNER OUTER CROSS NATURAL USE FORCE
@@ -196,6 +209,8 @@ var (
 %token <empty> FIRST AFTER
 %token <empty> ADD COLUMN CHANGE MODIFY
 %token <empty> ENABLE DISABLE
+%token <empty> GENERATED ALWAYS VIRTUAL STORED
+%token <empty> CHECK ENFORCED
 
 %token <empty> KILL QUERY CONNECTION
 
@@ -216,6 +231,10 @@ var (
 %type <bytes2> comments_list_opt comments_list
 %type <str> union_op
 %type <str> distinct_opt
+%type <selOpts> select_options_opt
+%type <boolean> recursive_opt
+%type <ctes> cte_list
+%type <cte> cte
 %type <str> ignore_opt
 %type <selectExprs> select_expression_list
 %type <selectExpr> select_expression
@@ -226,11 +245,12 @@ var (
 %type <str> join_type
 %type <smTableExpr> simple_table_expression
 %type <tableName> table_name
+%type <tableNames> table_name_list
 %type <indexHints> index_hint_list
 %type <bytes2> sql_id_list
 %type <boolExpr> where_expression_opt
 %type <boolExpr> boolean_expression condition
-%type <str> compare
+%type <str> compare quantifier
 %type <insRows> row_list
 %type <valExpr> value value_expression
 %type <tuple> tuple
@@ -244,6 +264,11 @@ var (
 %type <whens> when_expression_list
 %type <when> when_expression
 %type <funcExpr> function_expression
+%type <windowSpec> window_spec
+%type <valExprs> partition_by_opt
+%type <frame> frame_opt
+%type <str> frame_unit
+%type <frameBound> frame_bound
 %type <valExpr> value_expression_opt else_expression_opt
 %type <valExprs> group_by_opt
 %type <boolExpr> having_opt
@@ -257,7 +282,7 @@ var (
 %type <updateExprs> update_list
 %type <updateExpr> update_expression
 %type <expr> where_or_like_opt
-%type <empty> exists_opt not_exists_opt
+%type <empty> exists_opt not_exists_opt generated_always_opt
 %type <bytes> index_category_opt index_type index_type_opt index_option_opt
 %type <idxColName> index_column_name
 %type <idxColNames> index_column_list
@@ -267,18 +292,21 @@ var (
 %type <bytes> isolation_level
 %type <bytes> scope_opt
 
-%type <valExpr> default_value column_comment_opt
+%type <expr> default_value
+%type <valExpr> column_comment_opt
 %type <bytes> unique_or_primary_opt column_format_opt column_storage_opt
-%type <bytes> reference_definition reference_definition_opt reference_match_opt
-%type <bytes> reference_on_delete_or_update_opt reference_option reference_option_opt
+%type <str> generated_storage_opt enforced_opt
+%type <checkDef> check_definition check_definition_opt
+%type <refDef> reference_definition reference_definition_opt reference_on_delete_or_update_opt
+%type <bytes> reference_match_opt reference_option reference_option_opt
 %type <bytes> data_type_charset_opt data_type_collate_opt
 %type <createDef> create_definition
 %type <createDefs> create_definition_list
 %type <alterSpec> alter_specification
 %type <alterSpecs> alter_specification_list alter_specification_list_opt
 %type <valExprs> value_list
-%type <boolean> not_null auto_increment_opt data_type_unsigned_opt data_type_zerofill_opt data_type_binary_opt
-%type <columnDef> column_definition
+%type <boolean> not_null not_null_opt auto_increment_opt data_type_unsigned_opt data_type_zerofill_opt data_type_binary_opt
+%type <columnDef> column_definition column_definition_without_check
 %type <dataType> data_type
 %type <bytes> constraint_opt
 %type <optKeyVal> table_option
@@ -326,24 +354,79 @@ command:
   { $$ = nil }
 
 select_statement:
-  SELECT comments_list_opt distinct_opt select_expression_list limit_opt
+  SELECT comments_list_opt select_options_opt select_expression_list limit_opt
   {
-    $$ = &SimpleSelect{Comments: Comments($2), Distinct: $3, SelectExprs: $4, Limit: $5}
+    $$ = &SimpleSelect{Comments: Comments($2), CalcFoundRows: $3.calcFoundRows, Distinct: $3.distinct, SelectExprs: $4, Limit: $5}
   }
-| SELECT comments_list_opt distinct_opt select_expression_list FROM table_expression_list where_expression_opt group_by_opt having_opt order_by_opt limit_opt lock_opt
+| SELECT comments_list_opt select_options_opt select_expression_list FROM table_expression_list where_expression_opt group_by_opt having_opt order_by_opt limit_opt lock_opt
   {
-    $$ = &Select{Comments: Comments($2), Distinct: $3, SelectExprs: $4, From: $6, Where: NewWhere(AST_WHERE, $7), GroupBy: GroupBy($8), Having: NewWhere(AST_HAVING, $9), OrderBy: $10, Limit: $11, Lock: $12}
+    $$ = &Select{Comments: Comments($2), CalcFoundRows: $3.calcFoundRows, Distinct: $3.distinct, SelectExprs: $4, From: $6, Where: NewWhere(AST_WHERE, $7), GroupBy: GroupBy($8), Having: NewWhere(AST_HAVING, $9), OrderBy: $10, Limit: $11, Lock: $12}
   }
 | select_statement union_op select_statement %prec UNION
   {
     $$ = &Union{Type: $2, Left: $1, Right: $3}
   }
+| WITH recursive_opt cte_list select_statement %prec WITH
+  {
+    with := &With{Recursive: $2, CTEs: $3}
+    switch sel := $4.(type) {
+    case *Select:
+      if sel.With != nil {
+        yylex.Error("duplicate with")
+        return 1
+      }
+      sel.With = with
+    case *Union:
+      if sel.With != nil {
+        yylex.Error("duplicate with")
+        return 1
+      }
+      sel.With = with
+    default:
+      yylex.Error("expecting from")
+      return 1
+    }
+    $$ = $4
+  }
+
+recursive_opt:
+  {
+    $$ = false
+  }
+| RECURSIVE
+  {
+    $$ = true
+  }
+
+cte_list:
+  cte
+  {
+    $$ = []*CommonTableExpr{$1}
+  }
+| cte_list ',' cte
+  {
+    $$ = append($1, $3)
+  }
+
+cte:
+  sql_id column_list_opt AS subquery
+  {
+    $$ = &CommonTableExpr{Name: $1, Columns: $2, Select: $4.Select}
+  }
 
 insert_statement:
   INSERT comments_list_opt ignore_opt INTO table_name column_list_opt row_list on_dup_opt
   {
     $$ = &Insert{Comments: Comments($2), Ignore:$3, Table: $5, Columns: $6, Rows: $7, OnDup: OnDup($8)}
   }
+| INSERT comments_list_opt ignore_opt INTO table_name '(' select_statement ')' on_dup_opt
+  {
+    $$ = &Insert{Comments: Comments($2), Ignore:$3, Table: $5, Rows: $7, OnDup: OnDup($9)}
+  }
+| INSERT comments_list_opt ignore_opt INTO table_name '(' column_list ')' '(' select_statement ')' on_dup_opt
+  {
+    $$ = &Insert{Comments: Comments($2), Ignore:$3, Table: $5, Columns: $7, Rows: $10, OnDup: OnDup($12)}
+  }
 | INSERT comments_list_opt ignore_opt INTO table_name SET update_list on_dup_opt
   {
     cols := make(Columns, 0, len($7))
@@ -360,6 +443,14 @@ replace_statement:
   {
     $$ = &Replace{Comments: Comments($2), Table: $4, Columns: $5, Rows: $6}
   }
+| REPLACE comments_list_opt INTO table_name '(' select_statement ')'
+  {
+    $$ = &Replace{Comments: Comments($2), Table: $4, Rows: $6}
+  }
+| REPLACE comments_list_opt INTO table_name '(' column_list ')' '(' select_statement ')'
+  {
+    $$ = &Replace{Comments: Comments($2), Table: $4, Columns: $6, Rows: $9}
+  }
 | REPLACE comments_list_opt INTO table_name SET update_list
   {
     cols := make(Columns, 0, len($6))
@@ -372,9 +463,17 @@ replace_statement:
   }
 
 update_statement:
-  UPDATE comments_list_opt table_name SET update_list where_expression_opt order_by_opt limit_opt
+  UPDATE comments_list_opt table_expression_list SET update_list where_expression_opt order_by_opt limit_opt
   {
-    $$ = &Update{Comments: Comments($2), Table: $3, Exprs: $5, Where: NewWhere(AST_WHERE, $6), OrderBy: $7, Limit: $8}
+    update := &Update{Comments: Comments($2), Exprs: $5, Where: NewWhere(AST_WHERE, $6), OrderBy: $7, Limit: $8}
+    if update.Table = singleTableName($3); update.Table == nil {
+      if $7 != nil || $8 != nil {
+        yylex.Error("order by or limit in multiple-table update")
+        return 1
+      }
+      update.TableExprs = $3
+    }
+    $$ = update
   }
 
 delete_statement:
@@ -382,6 +481,24 @@ delete_statement:
   {
     $$ = &Delete{Comments: Comments($2), Table: $4, Where: NewWhere(AST_WHERE, $5), OrderBy: $6, Limit: $7}
   }
+| DELETE comments_list_opt table_name_list FROM table_expression_list where_expression_opt
+  {
+    $$ = &Delete{Comments: Comments($2), Targets: $3, TableExprs: $5, Where: NewWhere(AST_WHERE, $6)}
+  }
+| DELETE comments_list_opt FROM table_name_list USING table_expression_list where_expression_opt
+  {
+    $$ = &Delete{Comments: Comments($2), Targets: $4, TableExprs: $6, Where: NewWhere(AST_WHERE, $7)}
+  }
+
+table_name_list:
+  table_name
+  {
+    $$ = TableNames{$1}
+  }
+| table_name_list ',' table_name
+  {
+    $$ = append($$, $3)
+  }
 
 explain_statement:
   EXPLAIN select_statement
@@ -748,6 +865,20 @@ union_op:
     $$ = AST_INTERSECT
   }
 
+select_options_opt:
+  distinct_opt
+  {
+    $$ = selectOptions{distinct: $1}
+  }
+| SQL_CALC_FOUND_ROWS distinct_opt
+  {
+    $$ = selectOptions{distinct: $2, calcFoundRows: true}
+  }
+| DISTINCT SQL_CALC_FOUND_ROWS
+  {
+    $$ = selectOptions{distinct: AST_DISTINCT, calcFoundRows: true}
+  }
+
 distinct_opt:
   {
     $$ = ""
@@ -956,12 +1087,22 @@ condition:
   {
     $$ = &ComparisonExpr{Left: $1, Operator: $2, Right: $3}
   }
+| value_expression compare quantifier subquery
+  {
+    $$ = &ComparisonExpr{Left: $1, Operator: $2, Right: &QuantifiedExpr{Quantifier: $3, Subquery: $4}}
+  }
 | value_expression IN tuple
   {
+    if !CheckInList(yylex, $3) {
+      return 1
+    }
     $$ = &ComparisonExpr{Left: $1, Operator: AST_IN, Right: $3}
   }
 | value_expression NOT IN tuple
   {
+    if !CheckInList(yylex, $4) {
+      return 1
+    }
     $$ = &ComparisonExpr{Left: $1, Operator: AST_NOT_IN, Right: $4}
   }
 | value_expression LIKE value_expression
@@ -1023,6 +1164,20 @@ compare:
     $$ = AST_NSE
   }
 
+quantifier:
+  ANY
+  {
+    $$ = AST_ANY
+  }
+| SOME
+  {
+    $$ = AST_SOME
+  }
+| ALL
+  {
+    $$ = AST_ALL
+  }
+
 row_list:
   VALUES tuple_list
   {
@@ -1133,6 +1288,10 @@ value_expression:
   {
     $$ = $1
   }
+| function_expression OVER window_spec
+  {
+    $$ = &WindowFuncExpr{Func: $1, Window: $3}
+  }
 | case_expression
   {
     $$ = $1
@@ -1164,6 +1323,66 @@ function_expression:
     $$ = &FuncExpr{Name: $1, Exprs: $3}
   }
 
+window_spec:
+  '(' partition_by_opt order_by_opt frame_opt ')'
+  {
+    $$ = &WindowSpec{PartitionBy: $2, OrderBy: $3, Frame: $4}
+  }
+
+partition_by_opt:
+  {
+    $$ = nil
+  }
+| PARTITION BY value_expression_list
+  {
+    $$ = $3
+  }
+
+frame_opt:
+  {
+    $$ = nil
+  }
+| frame_unit frame_bound
+  {
+    $$ = &WindowFrame{Unit: $1, Start: $2}
+  }
+| frame_unit BETWEEN frame_bound AND frame_bound
+  {
+    $$ = &WindowFrame{Unit: $1, Start: $3, End: $5}
+  }
+
+frame_unit:
+  ROWS
+  {
+    $$ = AST_FRAME_ROWS
+  }
+| RANGE
+  {
+    $$ = AST_FRAME_RANGE
+  }
+
+frame_bound:
+  UNBOUNDED PRECEDING
+  {
+    $$ = &FrameBound{Type: AST_UNBOUNDED_PRECEDING}
+  }
+| UNBOUNDED FOLLOWING
+  {
+    $$ = &FrameBound{Type: AST_UNBOUNDED_FOLLOWING}
+  }
+| CURRENT ROW
+  {
+    $$ = &FrameBound{Type: AST_CURRENT_ROW}
+  }
+| value_expression PRECEDING
+  {
+    $$ = &FrameBound{Type: AST_PRECEDING, Expr: $1}
+  }
+| value_expression FOLLOWING
+  {
+    $$ = &FrameBound{Type: AST_FOLLOWING, Expr: $1}
+  }
+
 keyword_as_func:
   IF
   {
@@ -1435,6 +1654,10 @@ index_column_name:
   {
     $$ = &IndexColName{ColumnName: $1, Length: NumVal($3), AscOrDesc: $5}
   }
+| '(' expression ')' asc_desc_opt
+  {
+    $$ = &IndexColName{Expr: $2, AscOrDesc: $4}
+  }
 
 index_type_opt:
   { $$ = nil }
@@ -1461,6 +1684,38 @@ sql_id:
   {
     $$ = []byte("database")
   }
+| CURRENT
+  {
+    $$ = []byte("current")
+  }
+| PRECEDING
+  {
+    $$ = []byte("preceding")
+  }
+| FOLLOWING
+  {
+    $$ = []byte("following")
+  }
+| GENERATED
+  {
+    $$ = []byte("generated")
+  }
+| ALWAYS
+  {
+    $$ = []byte("always")
+  }
+| VIRTUAL
+  {
+    $$ = []byte("virtual")
+  }
+| STORED
+  {
+    $$ = []byte("stored")
+  }
+| ENFORCED
+  {
+    $$ = []byte("enforced")
+  }
 
 // force_eof:
 // {
@@ -1797,8 +2052,37 @@ create_definition:
   {
     $$ = &CreateForeignKeyDefinition{Symbol: $1, IndexColumns: $5, ReferenceDef: $7}
   }
+| check_definition
+  {
+    $$ = $1
+  }
+
+check_definition:
+  constraint_opt CHECK '(' boolean_expression ')' enforced_opt
+  {
+    $$ = &CreateCheckDefinition{Symbol: $1, Expr: $4, Enforced: $6}
+  }
+
+check_definition_opt:
+  { $$ = nil }
+| check_definition
+  { $$ = $1 }
+
+enforced_opt:
+  { $$ = "" }
+| ENFORCED
+  { $$ = "enforced" }
+| NOT ENFORCED
+  { $$ = "not enforced" }
 
 column_definition:
+  column_definition_without_check check_definition_opt
+  {
+    $1.Check = $2
+    $$ = $1
+  }
+
+column_definition_without_check:
   data_type auto_increment_opt unique_or_primary_opt column_comment_opt column_format_opt column_storage_opt reference_definition_opt
   {
     $$ = &ColumnDefinition{Type: $1,
@@ -1855,6 +2139,27 @@ column_definition:
           ColumnStorage: $8,
           ReferenceDef: $9 }
   }
+| data_type generated_always_opt AS '(' expression ')' generated_storage_opt not_null_opt unique_or_primary_opt column_comment_opt
+  {
+    $$ = &ColumnDefinition{Type: $1,
+          GeneratedExpr: $5,
+          GeneratedStorage: $7,
+          IsNotNull: $8,
+          UniqueOrKey: $9,
+          ColumnComment: $10 }
+  }
+
+generated_always_opt:
+  { $$ = struct{}{} }
+| GENERATED ALWAYS
+  { $$ = struct{}{} }
+
+generated_storage_opt:
+  { $$ = "" }
+| VIRTUAL
+  { $$ = "virtual" }
+| STORED
+  { $$ = "stored" }
 
 data_type:
   BIT
@@ -2072,9 +2377,18 @@ not_null:
 | NOT NULL
   { $$ = true }
 
+not_null_opt:
+  { $$ = false }
+| not_null
+  { $$ = $1 }
+
 default_value:
   DEFAULT value
   { $$ = $2 }
+| DEFAULT tuple
+  { $$ = $2 }
+| DEFAULT '(' boolean_expression ')'
+  { $$ = &ParenBoolExpr{Expr: $3} }
 
 auto_increment_opt:
   { $$ = false }
@@ -2124,7 +2438,12 @@ reference_definition_opt:
 
 reference_definition:
   REFERENCES table_name '(' index_column_list ')' reference_match_opt reference_on_delete_or_update_opt
-  { $$ = []byte("references " + String($2) + "(" + String($4) + ")" + string($6) + string($7) ) }
+  {
+    $$ = $7
+    $$.Table = $2
+    $$.Columns = $4
+    $$.Match = string($6)
+  }
 
 reference_match_opt:
   { $$ = nil}
@@ -2136,15 +2455,15 @@ reference_match_opt:
   { $$ = []byte("match simple") }
 
 reference_on_delete_or_update_opt:
-  { $$ = nil }
+  { $$ = &ReferenceDefinition{} }
 | ON DELETE reference_option
-  { $$ = []byte("on delete " + string($3)) }
+  { $$ = &ReferenceDefinition{OnDelete: string($3)} }
 | ON UPDATE reference_option
-  { $$ = []byte("on update " + string($3)) }
+  { $$ = &ReferenceDefinition{OnUpdate: string($3)} }
 | ON UPDATE reference_option ON DELETE reference_option
-  { $$ = []byte("on update " + string($3) + " on delete " + string($6)) }
+  { $$ = &ReferenceDefinition{OnDelete: string($6), OnUpdate: string($3)} }
 | ON DELETE reference_option ON UPDATE reference_option
-  { $$ = []byte("on update " + string($6) + " on delete " + string($3)) }
+  { $$ = &ReferenceDefinition{OnDelete: string($3), OnUpdate: string($6)} }
 
 reference_option_opt:
   { $$ = nil }
@@ -2288,6 +2607,18 @@ alter_specification:
   {
     $$ = &AddForeignKeySpec{Symbol: $2, IndexColumns: $6, ReferenceDef: $8}
   }
+| ADD check_definition
+  {
+    $$ = &AddCheckSpec{Check: $2}
+  }
+| ALTER CHECK sql_id enforced_opt
+  {
+    $$ = &AlterCheckSpec{Name: $3, Enforced: $4}
+  }
+| ALTER CONSTRAINT sql_id enforced_opt
+  {
+    $$ = &AlterCheckSpec{Name: $3, Enforced: $4}
+  }
 | CHANGE COLUMN column_name column_name column_definition first_or_after_column_opt
   {
     $$ = &ChangeColumnSpec{OldColumnName: $3, ColumnName: $4, ColumnDef: $5, FirstOrAfterColumn: $6 }
@@ -2316,6 +2647,14 @@ alter_specification:
   {
     $$ = &DropForeignKeySpec{Name: $4}
   }
+| DROP CHECK sql_id
+  {
+    $$ = &DropCheckSpec{Name: $3}
+  }
+| DROP CONSTRAINT sql_id
+  {
+    $$ = &DropConstraintSpec{Name: $3}
+  }
 | DISABLE KEYS
   {
     $$ = &DisableKeysSpec{}
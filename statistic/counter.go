@@ -36,6 +36,17 @@ type Counter struct {
 	ClientQPS    int64
 	ErrLogTotal  int64
 	SlowLogTotal int64
+
+	SchemaDriftChecks int64
+	SchemaDrifts      int64 // drifts found in last check.
+	SchemaDriftErrors int64 // errors in last check.
+
+	labels labelCounters // statistics by label set of annotations.
+
+	BytesReceived int64 // bytes of statements received from clients.
+	BytesSent     int64 // bytes of results sent to clients.
+
+	statements statementCounters // statistics by fingerprint of statements.
 }
 
 // IncrClientConns is to increase client conns.
@@ -63,10 +74,16 @@ func (c *Counter) IncrSlowLogTotal() {
 	atomic.AddInt64(&c.SlowLogTotal, 1)
 }
 
+// IncrSchemaDriftChecks is to increase schema drift checks, and set result of last check.
+func (c *Counter) IncrSchemaDriftChecks(drifts, errors int) {
+	atomic.AddInt64(&c.SchemaDriftChecks, 1)
+	atomic.StoreInt64(&c.SchemaDrifts, int64(drifts))
+	atomic.StoreInt64(&c.SchemaDriftErrors, int64(errors))
+}
+
 // FlushCounter is to flush the count per second
 func (c *Counter) FlushCounter() {
-	atomic.StoreInt64(&c.OldClientQPS, c.ClientQPS)
-	atomic.StoreInt64(&c.OldErrLogTotal, c.ErrLogTotal)
-	atomic.StoreInt64(&c.OldSlowLogTotal, c.SlowLogTotal)
-	atomic.StoreInt64(&c.ClientQPS, 0)
+	atomic.StoreInt64(&c.OldClientQPS, atomic.SwapInt64(&c.ClientQPS, 0))
+	atomic.StoreInt64(&c.OldErrLogTotal, atomic.LoadInt64(&c.ErrLogTotal))
+	atomic.StoreInt64(&c.OldSlowLogTotal, atomic.LoadInt64(&c.SlowLogTotal))
 }
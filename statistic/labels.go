@@ -0,0 +1,198 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package statistic
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// MaxLabelSets is max count of distinct label sets counted, others are counted as OtherLabels.
+var MaxLabelSets = 1000
+
+// OtherLabels is label set counted after MaxLabelSets reached.
+const OtherLabels = "other"
+
+// reserved keys of comment are hints, not labels.
+var reservedLabelKeys = []string{"idempotency_key", "replica_role"}
+
+var (
+	regComment = regexp.MustCompile(`/\*\s*([^!*][^*]*)\*/`)
+	regLabel   = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_.-]*)=([^,=\s]+)$`)
+)
+
+// ParseLabels parse annotations of statement, such as '/*app=checkout,endpoint=create_order*/',
+// return label set sorted by key 'app=checkout,endpoint=create_order', empty if not annotated.
+func ParseLabels(sql string) string {
+	if !strings.Contains(sql, "/*") {
+		return ""
+	}
+	labels := make(map[string]string)
+	for _, matches := range regComment.FindAllStringSubmatch(sql, -1) {
+		pairs := strings.Split(strings.TrimSpace(matches[1]), ",")
+		parsed := make(map[string]string, len(pairs))
+		for _, pair := range pairs {
+			label := regLabel.FindStringSubmatch(strings.TrimSpace(pair))
+			if label == nil {
+				parsed = nil
+				break
+			}
+			parsed[strings.ToLower(label[1])] = label[2]
+		}
+		for key, value := range parsed {
+			reserved := false
+			for _, reservedKey := range reservedLabelKeys {
+				reserved = reserved || key == reservedKey
+			}
+			if !reserved {
+				labels[key] = value
+			}
+		}
+	}
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		keys[i] = key + "=" + labels[key]
+	}
+	return strings.Join(keys, ",")
+}
+
+// IsLabelValue check value could be used in label set, without ',', '=' and spaces.
+func IsLabelValue(value string) bool {
+	return regLabel.MatchString("k=" + value)
+}
+
+// ParseAnnotation get value of key annotated in statement, such as reserved key '/*replica_role=reporting*/'.
+func ParseAnnotation(sql string, key string) string {
+	if !strings.Contains(sql, "/*") {
+		return ""
+	}
+	var value string
+	for _, matches := range regComment.FindAllStringSubmatch(sql, -1) {
+		for _, pair := range strings.Split(strings.TrimSpace(matches[1]), ",") {
+			if label := regLabel.FindStringSubmatch(strings.TrimSpace(pair)); label != nil && strings.ToLower(label[1]) == key {
+				value = label[2]
+			}
+		}
+	}
+	return value
+}
+
+// AddLabel add label to label set if key not annotated, keep label set sorted by key.
+func AddLabel(labels, key, value string) string {
+	if len(value) == 0 {
+		return labels
+	}
+	if len(labels) == 0 {
+		return key + "=" + value
+	}
+	pairs := strings.Split(labels, ",")
+	keyOf := func(pair string) string { return pair[:strings.IndexByte(pair, '=')] }
+	i := sort.Search(len(pairs), func(i int) bool { return keyOf(pairs[i]) >= key })
+	if i < len(pairs) && keyOf(pairs[i]) == key {
+		return labels
+	}
+	pairs = append(pairs, "")
+	copy(pairs[i+1:], pairs[i:])
+	pairs[i] = key + "=" + value
+	return strings.Join(pairs, ",")
+}
+
+// LabelStats is statistics of statements annotated with the same label set.
+type LabelStats struct {
+	Labels      string
+	Queries     int64
+	Errors      int64
+	SlowQueries int64
+	TotalTime   int64 // us
+}
+
+// labelCounters is statistics by label set.
+// Readers get immutable snapshot of map without lock, new label set is added by copy-on-write.
+type labelCounters struct {
+	sync.Mutex
+	stats atomic.Value // map[string]*LabelStats
+}
+
+func (l *labelCounters) load() map[string]*LabelStats {
+	stats, _ := l.stats.Load().(map[string]*LabelStats)
+	return stats
+}
+
+// RecordLabels count execution of statement annotated with label set, execTime is ms.
+func (c *Counter) RecordLabels(labels string, execTime float64, failed bool, slow bool) {
+	if len(labels) == 0 {
+		return
+	}
+	stats, ok := c.labels.load()[labels]
+	if !ok {
+		c.labels.Lock()
+		current := c.labels.load()
+		if len(current) >= MaxLabelSets {
+			labels = OtherLabels
+		}
+		if stats, ok = current[labels]; !ok {
+			stats = &LabelStats{Labels: labels}
+			newStats := make(map[string]*LabelStats, len(current)+1)
+			for k, v := range current {
+				newStats[k] = v
+			}
+			newStats[labels] = stats
+			c.labels.stats.Store(newStats)
+		}
+		c.labels.Unlock()
+	}
+	atomic.AddInt64(&stats.Queries, 1)
+	atomic.AddInt64(&stats.TotalTime, int64(execTime*1000))
+	if failed {
+		atomic.AddInt64(&stats.Errors, 1)
+	}
+	if slow {
+		atomic.AddInt64(&stats.SlowQueries, 1)
+	}
+}
+
+// GetLabelStats get snapshot of statistics by label set.
+func (c *Counter) GetLabelStats() []LabelStats {
+	current := c.labels.load()
+	list := make([]LabelStats, 0, len(current))
+	for _, stats := range current {
+		list = append(list, LabelStats{
+			Labels:      stats.Labels,
+			Queries:     atomic.LoadInt64(&stats.Queries),
+			Errors:      atomic.LoadInt64(&stats.Errors),
+			SlowQueries: atomic.LoadInt64(&stats.SlowQueries),
+			TotalTime:   atomic.LoadInt64(&stats.TotalTime),
+		})
+	}
+	return list
+}
@@ -0,0 +1,37 @@
+package statistic
+
+import (
+	"strconv"
+	"testing"
+)
+
+// BenchmarkRecordLabels count statements of known label sets concurrently.
+func BenchmarkRecordLabels(b *testing.B) {
+	c := new(Counter)
+	labels := make([]string, 16)
+	for i := range labels {
+		labels[i] = "app=" + strconv.Itoa(i)
+		c.RecordLabels(labels[i], 1, false, false)
+	}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			c.RecordLabels(labels[i%len(labels)], 1, false, false)
+			i++
+		}
+	})
+}
+
+// BenchmarkRecordLabelsNew count statements of new label sets concurrently, until MaxLabelSets.
+func BenchmarkRecordLabelsNew(b *testing.B) {
+	c := new(Counter)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			c.RecordLabels("app="+strconv.Itoa(i%(MaxLabelSets*2)), 1, false, false)
+			i++
+		}
+	})
+}
@@ -0,0 +1,92 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package statistic
+
+import (
+	"sync/atomic"
+)
+
+// MigrationCounter is a counter of shard migration, per schema.
+type MigrationCounter struct {
+	DualWrites          int64
+	DualWriteErrors     int64
+	DualWriteMismatches int64
+	DualWriteDiscarded  int64
+
+	DarkReads          int64
+	DarkReadErrors     int64
+	DarkReadMismatches int64
+}
+
+// IncrDualWrites is to increase dual writes.
+func (c *MigrationCounter) IncrDualWrites() {
+	atomic.AddInt64(&c.DualWrites, 1)
+}
+
+// IncrDualWriteErrors is to increase dual writes failed in mirror location.
+func (c *MigrationCounter) IncrDualWriteErrors() {
+	atomic.AddInt64(&c.DualWriteErrors, 1)
+}
+
+// IncrDualWriteMismatches is to increase dual writes, that affected rows is different.
+func (c *MigrationCounter) IncrDualWriteMismatches() {
+	atomic.AddInt64(&c.DualWriteMismatches, 1)
+}
+
+// IncrDualWriteDiscarded is to increase dual writes discarded by transaction rollback.
+func (c *MigrationCounter) IncrDualWriteDiscarded() {
+	atomic.AddInt64(&c.DualWriteDiscarded, 1)
+}
+
+// DualWriteMismatchRate is rate of dual writes that failed or mismatched.
+func (c *MigrationCounter) DualWriteMismatchRate() float64 {
+	total := atomic.LoadInt64(&c.DualWrites)
+	if total == 0 {
+		return 0
+	}
+	return float64(atomic.LoadInt64(&c.DualWriteErrors)+atomic.LoadInt64(&c.DualWriteMismatches)) / float64(total)
+}
+
+// IncrDarkReads is to increase dark reads.
+func (c *MigrationCounter) IncrDarkReads() {
+	atomic.AddInt64(&c.DarkReads, 1)
+}
+
+// IncrDarkReadErrors is to increase dark reads failed in mirror location.
+func (c *MigrationCounter) IncrDarkReadErrors() {
+	atomic.AddInt64(&c.DarkReadErrors, 1)
+}
+
+// IncrDarkReadMismatches is to increase dark reads, that row count or checksum is different.
+func (c *MigrationCounter) IncrDarkReadMismatches() {
+	atomic.AddInt64(&c.DarkReadMismatches, 1)
+}
+
+// DarkReadMismatchRate is rate of dark reads that failed or mismatched.
+func (c *MigrationCounter) DarkReadMismatchRate() float64 {
+	total := atomic.LoadInt64(&c.DarkReads)
+	if total == 0 {
+		return 0
+	}
+	return float64(atomic.LoadInt64(&c.DarkReadErrors)+atomic.LoadInt64(&c.DarkReadMismatches)) / float64(total)
+}
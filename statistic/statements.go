@@ -0,0 +1,129 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package statistic
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// MaxStatements is max count of distinct statement fingerprints counted, others are counted as OtherStatements.
+var MaxStatements = 1000
+
+// OtherStatements is digest of statements counted after MaxStatements reached.
+const OtherStatements = "other"
+
+// StatementStats is statistics of statements of the same fingerprint in schema.
+type StatementStats struct {
+	Schema        string
+	Digest        string
+	Fingerprint   string
+	Queries       int64
+	Errors        int64
+	TotalTime     int64 // us
+	MaxTime       int64 // us
+	BytesReceived int64 // bytes of statements received from clients.
+	BytesSent     int64 // bytes of results sent to clients.
+}
+
+// statementCounters is statistics by schema and digest of fingerprint.
+// Readers get immutable snapshot of map without lock, new fingerprint is added by copy-on-write.
+type statementCounters struct {
+	sync.Mutex
+	stats atomic.Value // map[string]*StatementStats
+}
+
+func (s *statementCounters) load() map[string]*StatementStats {
+	stats, _ := s.stats.Load().(map[string]*StatementStats)
+	return stats
+}
+
+// RecordStatement count execution of statement of fingerprint in schema, execTime is ms.
+func (c *Counter) RecordStatement(schema, digest, fingerprint string, execTime float64, failed bool,
+	bytesReceived, bytesSent int64) {
+	atomic.AddInt64(&c.BytesReceived, bytesReceived)
+	atomic.AddInt64(&c.BytesSent, bytesSent)
+	if len(digest) == 0 {
+		return
+	}
+	key := schema + "/" + digest
+	stats, ok := c.statements.load()[key]
+	if !ok {
+		c.statements.Lock()
+		current := c.statements.load()
+		if len(current) >= MaxStatements {
+			digest, fingerprint = OtherStatements, ""
+			key = schema + "/" + digest
+		}
+		if stats, ok = current[key]; !ok {
+			stats = &StatementStats{Schema: schema, Digest: digest, Fingerprint: fingerprint}
+			newStats := make(map[string]*StatementStats, len(current)+1)
+			for k, v := range current {
+				newStats[k] = v
+			}
+			newStats[key] = stats
+			c.statements.stats.Store(newStats)
+		}
+		c.statements.Unlock()
+	}
+	us := int64(execTime * 1000)
+	atomic.AddInt64(&stats.Queries, 1)
+	atomic.AddInt64(&stats.TotalTime, us)
+	for maxTime := atomic.LoadInt64(&stats.MaxTime); us > maxTime; maxTime = atomic.LoadInt64(&stats.MaxTime) {
+		if atomic.CompareAndSwapInt64(&stats.MaxTime, maxTime, us) {
+			break
+		}
+	}
+	if failed {
+		atomic.AddInt64(&stats.Errors, 1)
+	}
+	atomic.AddInt64(&stats.BytesReceived, bytesReceived)
+	atomic.AddInt64(&stats.BytesSent, bytesSent)
+}
+
+// GetStatementStats get snapshot of statistics by schema and digest of fingerprint.
+func (c *Counter) GetStatementStats() []StatementStats {
+	current := c.statements.load()
+	list := make([]StatementStats, 0, len(current))
+	for _, stats := range current {
+		list = append(list, StatementStats{
+			Schema:        stats.Schema,
+			Digest:        stats.Digest,
+			Fingerprint:   stats.Fingerprint,
+			Queries:       atomic.LoadInt64(&stats.Queries),
+			Errors:        atomic.LoadInt64(&stats.Errors),
+			TotalTime:     atomic.LoadInt64(&stats.TotalTime),
+			MaxTime:       atomic.LoadInt64(&stats.MaxTime),
+			BytesReceived: atomic.LoadInt64(&stats.BytesReceived),
+			BytesSent:     atomic.LoadInt64(&stats.BytesSent),
+		})
+	}
+	return list
+}
+
+// ResetStatementStats clear statistics by fingerprint, such as after a fingerprint is fixed.
+func (c *Counter) ResetStatementStats() {
+	c.statements.Lock()
+	c.statements.stats.Store(map[string]*StatementStats{})
+	c.statements.Unlock()
+}
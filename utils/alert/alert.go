@@ -0,0 +1,203 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// Event types.
+const (
+	EventNodeDown           = "node_down"
+	EventNodeUp             = "node_up"
+	EventFailover           = "failover"
+	EventMigrationStep      = "migration_step"
+	EventConfigReloadFailed = "config_reload_failed"
+	EventSchemaDrift        = "schema_drift"
+)
+
+// Event severities.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Webhook formats.
+const (
+	// FormatJSON post event as it is, it's default.
+	FormatJSON = "json"
+	// FormatSlack post as slack incoming webhook message.
+	FormatSlack = "slack"
+	// FormatPagerDuty post as pagerduty events api v2.
+	FormatPagerDuty = "pagerduty"
+)
+
+// Event is a structured event for operators.
+type Event struct {
+	Type     string            `json:"type"`
+	Severity string            `json:"severity"`
+	Source   string            `json:"source"`
+	Summary  string            `json:"summary"`
+	Details  map[string]string `json:"details,omitempty"`
+	Host     string            `json:"host"`
+	Time     time.Time         `json:"time"`
+}
+
+// DefaultQueueSize is max count of events waiting to send, new events are dropped if full.
+var DefaultQueueSize = 1024
+
+var (
+	webhooks    []config.WebhookConfig
+	events      chan *Event
+	hostname, _ = os.Hostname()
+	client      = &http.Client{Timeout: 10 * time.Second}
+)
+
+// Init webhooks and start sending events in background, events are only logged if no webhook.
+func Init(hooks []config.WebhookConfig) {
+	webhooks = hooks
+	if len(webhooks) > 0 && events == nil {
+		events = make(chan *Event, DefaultQueueSize)
+		go run()
+	}
+}
+
+// Emit event to webhooks asynchronously.
+func Emit(eventType, severity, source, summary string, details map[string]string) {
+	e := &Event{
+		Type:     eventType,
+		Severity: severity,
+		Source:   source,
+		Summary:  summary,
+		Details:  details,
+		Host:     hostname,
+		Time:     time.Now(),
+	}
+	simplelog.Warn("%s %s %s type=%s,severity=%s,source=%s", "alert", "Emit", summary, eventType, severity, source)
+	if events == nil {
+		return
+	}
+	select {
+	case events <- e:
+	default:
+		simplelog.Error("%s %s %s type=%s,source=%s", "alert", "Emit", "Event queue is full, dropped", eventType, source)
+	}
+}
+
+func run() {
+	for e := range events {
+		for _, hook := range webhooks {
+			if !matchEvent(hook, e) {
+				continue
+			}
+			if err := post(hook, e); err != nil {
+				simplelog.Error("%s %s %s url=%s,type=%s", "alert", "run", err.Error(), hook.URL, e.Type)
+			}
+		}
+	}
+}
+
+// matchEvent the webhook subscribes the event or not, all events if not specified.
+func matchEvent(hook config.WebhookConfig, e *Event) bool {
+	if len(hook.Events) == 0 {
+		return true
+	}
+	for _, eventType := range hook.Events {
+		if strings.ToLower(eventType) == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+func post(hook config.WebhookConfig, e *Event) error {
+	data, err := json.Marshal(payload(hook, e))
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(hook.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook response status %s", resp.Status)
+	}
+	return nil
+}
+
+// payload of event in format of webhook.
+func payload(hook config.WebhookConfig, e *Event) interface{} {
+	switch strings.ToLower(hook.Format) {
+	case FormatSlack:
+		color := "good"
+		switch e.Severity {
+		case SeverityWarning:
+			color = "warning"
+		case SeverityCritical:
+			color = "danger"
+		}
+		fields := make([]map[string]interface{}, 0, len(e.Details)+2)
+		fields = append(fields, map[string]interface{}{"title": "source", "value": e.Source, "short": true})
+		fields = append(fields, map[string]interface{}{"title": "host", "value": e.Host, "short": true})
+		for k, v := range e.Details {
+			fields = append(fields, map[string]interface{}{"title": k, "value": v, "short": true})
+		}
+		return map[string]interface{}{
+			"text": fmt.Sprintf("[saashard] %s: %s", strings.ToUpper(e.Severity), e.Summary),
+			"attachments": []map[string]interface{}{{
+				"color":  color,
+				"title":  e.Type,
+				"fields": fields,
+				"ts":     e.Time.Unix(),
+			}},
+		}
+	case FormatPagerDuty:
+		return map[string]interface{}{
+			"routing_key":  hook.RoutingKey,
+			"event_action": "trigger",
+			"dedup_key":    e.Type + ":" + e.Source,
+			"payload": map[string]interface{}{
+				"summary":        e.Summary,
+				"source":         e.Source,
+				"severity":       e.Severity,
+				"timestamp":      e.Time.Format(time.RFC3339),
+				"component":      "saashard",
+				"class":          e.Type,
+				"custom_details": e.Details,
+			},
+		}
+	default:
+		return e
+	}
+}
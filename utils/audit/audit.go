@@ -0,0 +1,208 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/berkaroad/saashard/config"
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// Kinds of statement in audit log.
+const (
+	KindDML = "DML"
+	KindDDL = "DDL"
+	// KindUnparsed is statement sent to unsharded schema as it is, as not parsed by proxy.
+	KindUnparsed = "UNPARSED"
+)
+
+// Defaults of audit log.
+const (
+	DefaultQueueSize = 10000
+	// DefaultBatchSize is max count of events written to sink at once.
+	DefaultBatchSize = 100
+)
+
+// Event is a statement in audit log.
+type Event struct {
+	Time         time.Time `json:"time"`
+	User         string    `json:"user"`
+	ClientIP     string    `json:"client_ip"`
+	ConnectionID uint32    `json:"connection_id"`
+	Schema       string    `json:"schema"`
+	Kind         string    `json:"kind"`
+	// ShardKey is value of shard key, as tenant of the statement, empty if not only one.
+	ShardKey string   `json:"shard_key,omitempty"`
+	Nodes    []string `json:"nodes"`
+	SQL      string   `json:"sql"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Sink is where events are written to.
+type Sink interface {
+	// Write events in order, events are not retried if failed.
+	Write(events []*Event) error
+	Close() error
+}
+
+// Reopener is sink to be reopened, such as file rotated by logrotate.
+type Reopener interface {
+	Reopen() error
+}
+
+// OpenFunc open sink by config, file is in log_path if not absolute.
+type OpenFunc func(cfg config.AuditConfig, logPath string) (Sink, error)
+
+var (
+	lock      sync.Mutex
+	sinks     = map[string]OpenFunc{SinkFile: openFileSink, SinkSyslog: openSyslogSink, SinkKafka: openKafkaSink}
+	sinkLock  sync.Mutex
+	sink      Sink
+	queueLock sync.RWMutex
+	events    chan *Event
+	done      chan struct{}
+	dropped   int64
+)
+
+// RegisterSink register sink by name, to be used as sink of audit config, built-in sink is replaced if same name.
+func RegisterSink(name string, open OpenFunc) {
+	lock.Lock()
+	defer lock.Unlock()
+	sinks[strings.ToLower(name)] = open
+}
+
+// Init open sink by config and start writing events in background, disabled if sink is empty.
+func Init(cfg config.AuditConfig, logPath string) error {
+	lock.Lock()
+	defer lock.Unlock()
+	if len(cfg.Sink) == 0 || events != nil {
+		return nil
+	}
+	open, ok := sinks[strings.ToLower(cfg.Sink)]
+	if !ok {
+		return fmt.Errorf("sink '%s' of audit log not supported", cfg.Sink)
+	}
+	s, err := open(cfg, logPath)
+	if err != nil {
+		return err
+	}
+	sinkLock.Lock()
+	sink = s
+	sinkLock.Unlock()
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	queueLock.Lock()
+	events = make(chan *Event, queueSize)
+	queueLock.Unlock()
+	done = make(chan struct{})
+	go run(events, done)
+	return nil
+}
+
+// Enabled is true if sink of audit log is opened.
+func Enabled() bool {
+	queueLock.RLock()
+	defer queueLock.RUnlock()
+	return events != nil
+}
+
+// Write event to sink asynchronously, event is dropped if queue is full.
+func Write(e *Event) {
+	queueLock.RLock()
+	defer queueLock.RUnlock()
+	if events == nil {
+		return
+	}
+	select {
+	case events <- e:
+	default:
+		atomic.AddInt64(&dropped, 1)
+	}
+}
+
+// Reopen sink, such as file rotated by logrotate.
+func Reopen() error {
+	sinkLock.Lock()
+	defer sinkLock.Unlock()
+	if r, ok := sink.(Reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// Close sink after events in queue are written.
+func Close() {
+	lock.Lock()
+	defer lock.Unlock()
+	queueLock.Lock()
+	queue := events
+	events = nil
+	queueLock.Unlock()
+	if queue == nil {
+		return
+	}
+	close(queue)
+	<-done
+	sinkLock.Lock()
+	defer sinkLock.Unlock()
+	if err := sink.Close(); err != nil {
+		simplelog.Error("%s %s %s", "audit", "Close", err.Error())
+	}
+	sink = nil
+}
+
+func run(events chan *Event, done chan struct{}) {
+	defer close(done)
+	batch := make([]*Event, 0, DefaultBatchSize)
+	for e := range events {
+		batch = append(batch[:0], e)
+	more:
+		for len(batch) < DefaultBatchSize {
+			select {
+			case e, ok := <-events:
+				if !ok {
+					break more
+				}
+				batch = append(batch, e)
+			default:
+				break more
+			}
+		}
+		sinkLock.Lock()
+		err := sink.Write(batch)
+		sinkLock.Unlock()
+		if err != nil {
+			simplelog.Error("%s %s %s events=%d", "audit", "run", err.Error(), len(batch))
+		}
+		if n := atomic.SwapInt64(&dropped, 0); n > 0 {
+			simplelog.Error("%s %s %s events=%d", "audit", "run", "Event queue is full, dropped", n)
+		}
+	}
+}
@@ -0,0 +1,405 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/berkaroad/saashard/config"
+)
+
+// DefaultKafkaTimeout is default timeout of kafka request in milliseconds.
+const DefaultKafkaTimeout = 5000
+
+// api keys and versions of kafka protocol, produce v3 is the first of record batch v2,
+// and the oldest supported by kafka 4.
+const (
+	kafkaProduceKey      = 0
+	kafkaProduceVersion  = 3
+	kafkaMetadataKey     = 3
+	kafkaMetadataVersion = 4
+	kafkaClientID        = "saashard"
+	kafkaAcksAll         = -1
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// kafkaSink produce events to leader of partition, with a minimal client of kafka protocol.
+type kafkaSink struct {
+	brokers       []string
+	topic         string
+	partition     int32
+	timeout       time.Duration
+	conn          net.Conn
+	rd            *bufio.Reader
+	correlationID int32
+}
+
+func openKafkaSink(cfg config.AuditConfig, logPath string) (Sink, error) {
+	if len(cfg.Kafka.Brokers) == 0 || len(cfg.Kafka.Topic) == 0 {
+		return nil, fmt.Errorf("brokers and topic of kafka sink of audit log are required")
+	}
+	s := &kafkaSink{
+		brokers:   cfg.Kafka.Brokers,
+		topic:     cfg.Kafka.Topic,
+		partition: int32(cfg.Kafka.Partition),
+		timeout:   time.Duration(cfg.Kafka.Timeout) * time.Millisecond,
+	}
+	if s.timeout <= 0 {
+		s.timeout = DefaultKafkaTimeout * time.Millisecond
+	}
+	// leader is connected lazily, so that proxy could start when kafka is down.
+	return s, nil
+}
+
+// Write produce events as a record batch, retry once with leader found again if failed.
+func (s *kafkaSink) Write(events []*Event) error {
+	batch, err := s.recordBatch(events)
+	if err != nil {
+		return err
+	}
+	if err = s.produce(batch); err != nil {
+		s.disconnect()
+		err = s.produce(batch)
+	}
+	if err != nil {
+		s.disconnect()
+	}
+	return err
+}
+
+func (s *kafkaSink) Close() error {
+	s.disconnect()
+	return nil
+}
+
+func (s *kafkaSink) disconnect() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+func (s *kafkaSink) produce(batch []byte) error {
+	if s.conn == nil {
+		if err := s.connectLeader(); err != nil {
+			return err
+		}
+	}
+	var body kafkaEncoder
+	body.putInt16(-1) // transactional_id is null.
+	body.putInt16(kafkaAcksAll)
+	body.putInt32(int32(s.timeout / time.Millisecond))
+	body.putInt32(1)
+	body.putString(s.topic)
+	body.putInt32(1)
+	body.putInt32(s.partition)
+	body.putBytes(batch)
+	resp, err := s.request(s.conn, s.rd, kafkaProduceKey, kafkaProduceVersion, body.Bytes())
+	if err != nil {
+		return err
+	}
+	dec := kafkaDecoder{data: resp}
+	for i, topics := int32(0), dec.int32(); i < topics; i++ {
+		dec.string()
+		for j, partitions := int32(0), dec.int32(); j < partitions; j++ {
+			partition, errCode := dec.int32(), dec.int16()
+			dec.int64() // base_offset
+			dec.int64() // log_append_time_ms
+			if dec.err == nil && partition == s.partition && errCode != 0 {
+				return fmt.Errorf("kafka produce to %s-%d failed, error code %d", s.topic, s.partition, errCode)
+			}
+		}
+	}
+	return dec.err
+}
+
+// connectLeader find leader of partition by metadata of brokers, and connect to it.
+func (s *kafkaSink) connectLeader() error {
+	var lastErr error
+	for _, broker := range s.brokers {
+		leader, err := s.findLeader(broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		conn, err := net.DialTimeout("tcp", leader, s.timeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		s.conn, s.rd = conn, bufio.NewReader(conn)
+		return nil
+	}
+	return lastErr
+}
+
+func (s *kafkaSink) findLeader(broker string) (string, error) {
+	conn, err := net.DialTimeout("tcp", broker, s.timeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	var body kafkaEncoder
+	body.putInt32(1)
+	body.putString(s.topic)
+	body.putInt8(0) // allow_auto_topic_creation is false.
+	resp, err := s.request(conn, bufio.NewReader(conn), kafkaMetadataKey, kafkaMetadataVersion, body.Bytes())
+	if err != nil {
+		return "", err
+	}
+	dec := kafkaDecoder{data: resp}
+	dec.int32() // throttle_time_ms
+	brokers := make(map[int32]string)
+	for i, n := int32(0), dec.int32(); i < n && dec.err == nil; i++ {
+		nodeID, host, port := dec.int32(), dec.string(), dec.int32()
+		dec.string() // rack
+		brokers[nodeID] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	dec.string() // cluster_id
+	dec.int32()  // controller_id
+	for i, topics := int32(0), dec.int32(); i < topics && dec.err == nil; i++ {
+		topicErr, name := dec.int16(), dec.string()
+		dec.int8() // is_internal
+		for j, partitions := int32(0), dec.int32(); j < partitions && dec.err == nil; j++ {
+			partitionErr, partition, leader := dec.int16(), dec.int32(), dec.int32()
+			dec.int32Array() // replica_nodes
+			dec.int32Array() // isr_nodes
+			if name != s.topic || partition != s.partition {
+				continue
+			}
+			if topicErr != 0 || partitionErr != 0 {
+				return "", fmt.Errorf("kafka metadata of %s-%d from %s failed, error code %d", s.topic, s.partition, broker,
+					topicErr|partitionErr)
+			}
+			if addr, ok := brokers[leader]; ok {
+				return addr, nil
+			}
+		}
+	}
+	if dec.err != nil {
+		return "", dec.err
+	}
+	return "", fmt.Errorf("kafka leader of %s-%d not found from %s", s.topic, s.partition, broker)
+}
+
+// request send request with header v1, and read response of header v0.
+func (s *kafkaSink) request(conn net.Conn, rd *bufio.Reader, apiKey, apiVersion int16, body []byte) ([]byte, error) {
+	s.correlationID++
+	var req kafkaEncoder
+	req.putInt32(0) // size
+	req.putInt16(apiKey)
+	req.putInt16(apiVersion)
+	req.putInt32(s.correlationID)
+	req.putString(kafkaClientID)
+	req.Write(body)
+	data := req.Bytes()
+	binary.BigEndian.PutUint32(data, uint32(len(data)-4))
+
+	conn.SetDeadline(time.Now().Add(s.timeout))
+	defer conn.SetDeadline(time.Time{})
+	if _, err := conn.Write(data); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(rd, header); err != nil {
+		return nil, err
+	}
+	size := int32(binary.BigEndian.Uint32(header))
+	if size < 4 {
+		return nil, errors.New("kafka response is malformed")
+	}
+	if correlationID := int32(binary.BigEndian.Uint32(header[4:])); correlationID != s.correlationID {
+		return nil, fmt.Errorf("kafka response of correlation id %d, expect %d", correlationID, s.correlationID)
+	}
+	resp := make([]byte, size-4)
+	if _, err := io.ReadFull(rd, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// recordBatch encode events as record batch v2 without compression, value of record is event in json.
+func (s *kafkaSink) recordBatch(events []*Event) ([]byte, error) {
+	baseTime := events[0].Time.UnixNano() / int64(time.Millisecond)
+	maxTime := baseTime
+	var records kafkaEncoder
+	for i, e := range events {
+		value, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		timestamp := e.Time.UnixNano() / int64(time.Millisecond)
+		if timestamp > maxTime {
+			maxTime = timestamp
+		}
+		var record kafkaEncoder
+		record.putInt8(0) // attributes
+		record.putVarint(timestamp - baseTime)
+		record.putVarint(int64(i))
+		record.putVarint(int64(len(e.Schema)))
+		record.WriteString(e.Schema)
+		record.putVarint(int64(len(value)))
+		record.Write(value)
+		record.putVarint(0) // headers
+		records.putVarint(int64(record.Len()))
+		records.Write(record.Bytes())
+	}
+
+	var batch kafkaEncoder
+	batch.putInt64(0)  // base_offset
+	batch.putInt32(0)  // batch_length
+	batch.putInt32(-1) // partition_leader_epoch
+	batch.putInt8(2)   // magic
+	batch.putInt32(0)  // crc
+	crcStart := batch.Len()
+	batch.putInt16(0) // attributes
+	batch.putInt32(int32(len(events) - 1))
+	batch.putInt64(baseTime)
+	batch.putInt64(maxTime)
+	batch.putInt64(-1) // producer_id
+	batch.putInt16(-1) // producer_epoch
+	batch.putInt32(-1) // base_sequence
+	batch.putInt32(int32(len(events)))
+	batch.Write(records.Bytes())
+	data := batch.Bytes()
+	binary.BigEndian.PutUint32(data[8:], uint32(len(data)-12))
+	binary.BigEndian.PutUint32(data[crcStart-4:], crc32.Checksum(data[crcStart:], castagnoli))
+	return data, nil
+}
+
+type kafkaEncoder struct {
+	bytes.Buffer
+}
+
+func (e *kafkaEncoder) putInt8(v int8) {
+	e.WriteByte(byte(v))
+}
+
+func (e *kafkaEncoder) putInt16(v int16) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(v))
+	e.Write(b[:])
+}
+
+func (e *kafkaEncoder) putInt32(v int32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v))
+	e.Write(b[:])
+}
+
+func (e *kafkaEncoder) putInt64(v int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(v))
+	e.Write(b[:])
+}
+
+// putVarint in zigzag encoding, as kafka.
+func (e *kafkaEncoder) putVarint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	e.Write(b[:binary.PutVarint(b[:], v)])
+}
+
+func (e *kafkaEncoder) putString(v string) {
+	e.putInt16(int16(len(v)))
+	e.WriteString(v)
+}
+
+func (e *kafkaEncoder) putBytes(v []byte) {
+	e.putInt32(int32(len(v)))
+	e.Write(v)
+}
+
+// kafkaDecoder decode response, err is set if data is short, and zero values are returned after it.
+type kafkaDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *kafkaDecoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.data) < n {
+		d.err = errors.New("kafka response is short")
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *kafkaDecoder) int8() int8 {
+	if b := d.next(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	if b := d.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	if b := d.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	if b := d.next(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+// string of nullable string, null is empty.
+func (d *kafkaDecoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+
+func (d *kafkaDecoder) int32Array() {
+	n := d.int32()
+	if n > 0 {
+		d.next(int(n) * 4)
+	}
+}
@@ -0,0 +1,217 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berkaroad/saashard/config"
+)
+
+// fixture concat hex of bytes and raw strings, raw strings are quoted by '`'.
+func fixture(parts ...string) []byte {
+	var buf bytes.Buffer
+	for _, part := range parts {
+		if strings.HasPrefix(part, "`") {
+			buf.WriteString(strings.Trim(part, "`"))
+			continue
+		}
+		b, err := hex.DecodeString(strings.Replace(part, " ", "", -1))
+		if err != nil {
+			panic(err)
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes()
+}
+
+func TestKafkaCRC32C(t *testing.T) {
+	// check value of CRC-32C in RFC 3720.
+	if got := crc32.Checksum([]byte("123456789"), castagnoli); got != 0xe3069283 {
+		t.Errorf("expect crc32c 0xe3069283, got %#x", got)
+	}
+}
+
+func TestKafkaVarint(t *testing.T) {
+	cases := []struct {
+		value int64
+		hex   string
+	}{
+		{0, "00"},
+		{-1, "01"},
+		{1, "02"},
+		{63, "7e"},
+		{-64, "7f"},
+		{64, "8001"},
+		{300, "d804"},
+		{-300, "d704"},
+	}
+	for _, tc := range cases {
+		var e kafkaEncoder
+		e.putVarint(tc.value)
+		if got := hex.EncodeToString(e.Bytes()); got != tc.hex {
+			t.Errorf("varint %d: expect %s, got %s", tc.value, tc.hex, got)
+		}
+	}
+}
+
+var kafkaTestEvents = []*Event{
+	{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), User: "u", ConnectionID: 1, Schema: "db1", Kind: "query",
+		Nodes: []string{"n1"}, SQL: "select 1"},
+	{Time: time.Date(2024, 1, 2, 3, 4, 5, 5000000, time.UTC), User: "u", ConnectionID: 1, Schema: "db1", Kind: "query",
+		Nodes: []string{"n1"}, SQL: "select 2"},
+}
+
+func TestKafkaRecordBatch(t *testing.T) {
+	expect := fixture(
+		// base_offset, batch_length, partition_leader_epoch, magic, crc
+		"0000000000000000 0000015f ffffffff 02 c9b32e2b",
+		// attributes, last_offset_delta, first_timestamp, max_timestamp, producer_id, producer_epoch, base_sequence, records
+		"0000 00000001 0000018cc820d888 0000018cc820d88d ffffffffffffffff ffff ffffffff 00000002",
+		// length, attributes, timestamp_delta, offset_delta, key, value, headers
+		"a602 00 00 00 06 646231 9202",
+		"`"+`{"time":"2024-01-02T03:04:05Z","user":"u","client_ip":"","connection_id":1,"schema":"db1","kind":"query","nodes":["n1"],"sql":"select 1"}`+"`",
+		"00",
+		"ae02 00 0a 02 06 646231 9a02",
+		"`"+`{"time":"2024-01-02T03:04:05.005Z","user":"u","client_ip":"","connection_id":1,"schema":"db1","kind":"query","nodes":["n1"],"sql":"select 2"}`+"`",
+		"00")
+	s := new(kafkaSink)
+	batch, err := s.recordBatch(kafkaTestEvents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(batch, expect) {
+		t.Errorf("expect record batch\n%s\ngot\n%s", hex.Dump(expect), hex.Dump(batch))
+	}
+}
+
+// fakeBroker answer metadata and produce requests of audit-0 by canned responses, it's leader of the partition.
+type fakeBroker struct {
+	listener     net.Listener
+	produceError int16
+	batches      chan []byte
+}
+
+func newFakeBroker(t *testing.T, produceError int16) *fakeBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeBroker{listener: listener, produceError: produceError, batches: make(chan []byte, 4)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(header))
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		dec := kafkaDecoder{data: req}
+		apiKey, apiVersion, correlationID := dec.int16(), dec.int16(), dec.int32()
+		dec.string() // client_id
+		var resp kafkaEncoder
+		resp.putInt32(0)
+		resp.putInt32(correlationID)
+		switch {
+		case apiKey == kafkaMetadataKey && apiVersion == kafkaMetadataVersion:
+			_, port, _ := net.SplitHostPort(b.listener.Addr().String())
+			portNum, _ := strconv.Atoi(port)
+			resp.Write(fixture(
+				// throttle_time_ms, brokers [node_id, host, port, rack]
+				"00000000 00000002 00000001 0009", "`127.0.0.1`", fmt.Sprintf("%08x", portNum), "ffff",
+				"00000002 0007", "`0.0.0.0`", "00000001 ffff",
+				// cluster_id, controller_id
+				"ffff 00000001",
+				// topics [error_code, name, is_internal, partitions [error_code, partition, leader, replicas, isr]]
+				"00000001 0000 0005", "`audit`", "00",
+				"00000002 0000 00000001 00000002 00000001 00000002 00000001 00000002",
+				"0000 00000000 00000001 00000001 00000001 00000001 00000001"))
+		case apiKey == kafkaProduceKey && apiVersion == kafkaProduceVersion:
+			dec.int16() // transactional_id
+			dec.int16() // acks
+			dec.int32() // timeout
+			dec.int32() // topics
+			dec.string()
+			dec.int32() // partitions
+			dec.int32()
+			b.batches <- dec.next(int(dec.int32()))
+			// responses [topic, partitions [partition, error_code, base_offset, log_append_time]], throttle_time_ms
+			resp.Write(fixture("00000001 0005", "`audit`",
+				"00000001 00000000", fmt.Sprintf("%04x", uint16(b.produceError)),
+				"0000000000000000 ffffffffffffffff 00000000"))
+		default:
+			return
+		}
+		data := resp.Bytes()
+		binary.BigEndian.PutUint32(data, uint32(len(data)-4))
+		if _, err := conn.Write(data); err != nil {
+			return
+		}
+	}
+}
+
+func TestKafkaSinkWrite(t *testing.T) {
+	cases := []struct {
+		produceError int16
+		failed       bool
+	}{
+		{0, false},
+		{6, true}, // NOT_LEADER_OR_FOLLOWER
+	}
+	for _, tc := range cases {
+		broker := newFakeBroker(t, tc.produceError)
+		cfg := config.AuditConfig{}
+		cfg.Kafka.Brokers = []string{broker.listener.Addr().String()}
+		cfg.Kafka.Topic = "audit"
+		sink, err := openKafkaSink(cfg, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sink.Write(kafkaTestEvents)
+		if tc.failed != (err != nil) {
+			t.Errorf("error code %d: expect failed %v, got %v", tc.produceError, tc.failed, err)
+		}
+		expect, _ := sink.(*kafkaSink).recordBatch(kafkaTestEvents)
+		if batch := <-broker.batches; !bytes.Equal(batch, expect) {
+			t.Errorf("error code %d: expect record batch produced as encoded", tc.produceError)
+		}
+		sink.Close()
+		broker.listener.Close()
+	}
+}
+
+func TestKafkaDecoderShort(t *testing.T) {
+	dec := kafkaDecoder{data: []byte{0, 1, 0}}
+	if dec.int16() != 1 || dec.err != nil {
+		t.Fatal("expect int16 decoded")
+	}
+	if dec.int32() != 0 || dec.err == nil {
+		t.Error("expect error of short response")
+	}
+	if dec.string() != "" || dec.err == nil {
+		t.Error("expect zero value after error")
+	}
+}
@@ -0,0 +1,129 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/berkaroad/saashard/config"
+)
+
+// Built-in sinks of audit log.
+const (
+	// SinkFile write an event as a line of json to file.
+	SinkFile = "file"
+	// SinkSyslog write an event as a message of json to syslog.
+	SinkSyslog = "syslog"
+	// SinkKafka produce an event as a record of json to partition of kafka topic, key is schema.
+	SinkKafka = "kafka"
+)
+
+// Defaults of built-in sinks.
+const (
+	DefaultFileName  = "audit.log"
+	DefaultSyslogTag = "saashard-audit"
+)
+
+type fileSink struct {
+	path string
+	f    *os.File
+}
+
+func openFileSink(cfg config.AuditConfig, logPath string) (Sink, error) {
+	s := &fileSink{path: cfg.File}
+	if len(s.path) == 0 {
+		s.path = DefaultFileName
+	}
+	if !filepath.IsAbs(s.path) && len(logPath) > 0 {
+		s.path = filepath.Join(logPath, s.path)
+	}
+	if err := s.Reopen(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) Write(events []*Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	_, err := s.f.Write(buf.Bytes())
+	return err
+}
+
+func (s *fileSink) Reopen() error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if s.f != nil {
+		s.f.Close()
+	}
+	s.f = f
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	return s.f.Close()
+}
+
+type syslogSink struct {
+	w io.WriteCloser
+}
+
+func openSyslogSink(cfg config.AuditConfig, logPath string) (Sink, error) {
+	tag := cfg.SyslogTag
+	if len(tag) == 0 {
+		tag = DefaultSyslogTag
+	}
+	w, err := openSyslog(tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Write(events []*Event) error {
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err = s.w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
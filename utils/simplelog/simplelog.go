@@ -26,3 +26,10 @@ func Error(format string, args ...interface{}) {
 func Verbose(format string, args ...interface{}) {
 	verboseLog.Println(fmt.Sprintf(format, args...))
 }
+
+var debugLog = log.New(os.Stdout, "[debug] ", log.LstdFlags)
+
+// Debug log is written by caller explicitly, such as routing decisions of session in debug.
+func Debug(format string, args ...interface{}) {
+	debugLog.Println(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,183 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package slowlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/berkaroad/saashard/config"
+)
+
+// Outputs of slow log.
+const (
+	OutputFile   = "file"
+	OutputSyslog = "syslog"
+)
+
+// Defaults of slow log.
+const (
+	DefaultFileName  = "slow.log"
+	DefaultSyslogTag = "saashard"
+)
+
+// Shard is statement executed in data node for slow query.
+type Shard struct {
+	Node    string
+	Backend string
+	SQL     string
+}
+
+// Entry is slow query, written in format of MySQL slow log.
+type Entry struct {
+	Time         time.Time
+	User         string
+	ClientIP     string
+	ConnectionID uint32
+	DB           string
+	QueryTime    time.Duration
+	RowsSent     int64
+	RowsAffected int64
+	OnSlave      bool
+	Failed       bool
+	Labels       string
+	Shards       []Shard
+	SQL          string
+}
+
+var (
+	lock    sync.Mutex
+	cfg     config.SlowLogConfig
+	path    string
+	writer  io.WriteCloser
+	enabled int32
+)
+
+// Init open slow log by config, disabled if output is empty. File is in log_path if not absolute.
+func Init(slowLogCfg config.SlowLogConfig, logPath string) error {
+	lock.Lock()
+	defer lock.Unlock()
+	cfg = slowLogCfg
+	switch strings.ToLower(cfg.Output) {
+	case "":
+		return nil
+	case OutputFile:
+		path = cfg.File
+		if len(path) == 0 {
+			path = DefaultFileName
+		}
+		if !filepath.IsAbs(path) && len(logPath) > 0 {
+			path = filepath.Join(logPath, path)
+		}
+		if err := openFile(); err != nil {
+			return err
+		}
+	case OutputSyslog:
+		tag := cfg.SyslogTag
+		if len(tag) == 0 {
+			tag = DefaultSyslogTag
+		}
+		var err error
+		if writer, err = openSyslog(tag); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("output '%s' of slow log not supported", cfg.Output)
+	}
+	atomic.StoreInt32(&enabled, 1)
+	return nil
+}
+
+func openFile() error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	writer = f
+	return nil
+}
+
+// Reopen file of slow log, such as after rotated by logrotate.
+func Reopen() error {
+	lock.Lock()
+	defer lock.Unlock()
+	if strings.ToLower(cfg.Output) != OutputFile {
+		return nil
+	}
+	if writer != nil {
+		writer.Close()
+	}
+	return openFile()
+}
+
+// Enabled is true if slow log is opened.
+func Enabled() bool {
+	return atomic.LoadInt32(&enabled) == 1
+}
+
+// Write entry of slow query.
+func Write(e *Entry) error {
+	data := e.format()
+	lock.Lock()
+	defer lock.Unlock()
+	if writer == nil {
+		return nil
+	}
+	_, err := writer.Write(data)
+	return err
+}
+
+// format entry as MySQL slow log, parsed by pt-query-digest. Statements in data nodes are in comments.
+func (e *Entry) format() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Time: %s\n", e.Time.UTC().Format("2006-01-02T15:04:05.000000Z"))
+	fmt.Fprintf(&buf, "# User@Host: %s[%s] @  [%s]  Id: %d\n", e.User, e.User, e.ClientIP, e.ConnectionID)
+	fmt.Fprintf(&buf, "# Query_time: %.6f  Lock_time: 0.000000 Rows_sent: %d  Rows_examined: 0  Rows_affected: %d\n",
+		e.QueryTime.Seconds(), e.RowsSent, e.RowsAffected)
+	fmt.Fprintf(&buf, "# Schema: %s  On_slave: %v  Failed: %v", e.DB, e.OnSlave, e.Failed)
+	if len(e.Labels) > 0 {
+		fmt.Fprintf(&buf, "  Labels: %s", e.Labels)
+	}
+	buf.WriteString("\n")
+	for _, shard := range e.Shards {
+		fmt.Fprintf(&buf, "# Shard: %s  Backend: %s  Shard_sql: %s\n", shard.Node, shard.Backend, oneLine(shard.SQL))
+	}
+	if len(e.DB) > 0 {
+		fmt.Fprintf(&buf, "use %s;\n", e.DB)
+	}
+	fmt.Fprintf(&buf, "SET timestamp=%d;\n", e.Time.Unix())
+	buf.WriteString(strings.TrimRight(strings.TrimSpace(e.SQL), ";"))
+	buf.WriteString(";\n")
+	return buf.Bytes()
+}
+
+func oneLine(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
@@ -0,0 +1,70 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package xa
+
+import (
+	"fmt"
+
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+	"github.com/berkaroad/saashard/errors"
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+// CommitMarkTable is table of commit marks, in database of data node committed as the last resource.
+// Mark is inserted in transaction of the last resource, so that decision of commit is durable atomically with it.
+var CommitMarkTable = "saashard_xa_commit"
+
+const sqlCreateCommitMarkTable = "CREATE TABLE IF NOT EXISTS `%s` (" +
+	"`gtrid` VARCHAR(128) NOT NULL, `time` BIGINT NOT NULL, PRIMARY KEY (`gtrid`)) ENGINE=InnoDB"
+
+// CreateCommitMarkTableSQL get DDL of commit mark table, executed out of transaction as DDL commits implicitly.
+func CreateCommitMarkTableSQL() string {
+	return fmt.Sprintf(sqlCreateCommitMarkTable, CommitMarkTable)
+}
+
+// CommitMarkSQL get statement to mark transaction committed, executed in transaction of the last resource.
+func CommitMarkSQL(gtrid string, time int64) string {
+	return fmt.Sprintf("INSERT INTO `%s` (`gtrid`, `time`) VALUES (%s, %d)",
+		CommitMarkTable, mysqlBackend.QuoteString(gtrid), time)
+}
+
+// IsCommitMarked the transaction is marked committed in database of conn, false if table not exists.
+func IsCommitMarked(conn *mysqlBackend.Conn, gtrid string) (bool, error) {
+	result, err := conn.Query(fmt.Sprintf("SELECT 1 FROM `%s` WHERE `gtrid` = %s",
+		CommitMarkTable, mysqlBackend.QuoteString(gtrid)))
+	if sqlErr, ok := err.(*errors.SqlError); ok && sqlErr.Code == mysql.ER_NO_SUCH_TABLE {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return result.RowNumber() > 0, nil
+}
+
+// DeleteCommitMark delete mark of transaction done in database of conn, nothing if table not exists.
+func DeleteCommitMark(conn *mysqlBackend.Conn, gtrid string) error {
+	_, err := conn.Query(fmt.Sprintf("DELETE FROM `%s` WHERE `gtrid` = %s",
+		CommitMarkTable, mysqlBackend.QuoteString(gtrid)))
+	if sqlErr, ok := err.(*errors.SqlError); ok && sqlErr.Code == mysql.ER_NO_SUCH_TABLE {
+		return nil
+	}
+	return err
+}
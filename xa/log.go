@@ -0,0 +1,131 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xa
+
+import (
+	"sort"
+	"sync"
+)
+
+// States of distributed transaction in log.
+const (
+	StateBegin    = "begin"    // Branches started, decision is not made.
+	StateCommit   = "commit"   // All branches prepared, and decided to commit.
+	StateRollback = "rollback" // Decided to rollback.
+	StateDone     = "done"     // All branches completed.
+)
+
+// Storages of transaction log.
+const (
+	StorageFile  = "file"
+	StorageTable = "table"
+)
+
+// Entry of transaction log.
+type Entry struct {
+	Gtrid string   `json:"gtrid"`
+	State string   `json:"state"`
+	Nodes []string `json:"nodes,omitempty"`
+	Time  int64    `json:"time"`
+}
+
+// Log of distributed transaction decisions, entry is durable before Append returned.
+// Transaction begun by this process is owned until done, so that it isn't resolved by recovery.
+type Log interface {
+	// Append state of transaction, nodes are kept from previous entry if empty.
+	Append(gtrid string, state string, nodes []string) error
+	// Get latest entry of transaction not done, nil if not exists or done.
+	Get(gtrid string) *Entry
+	// Owned the transaction is begun by this process and not done.
+	Owned(gtrid string) bool
+	// Disown the transaction of unknown outcome, so that it's resolved by recovery.
+	Disown(gtrid string)
+	// Pending get entries of transactions not done, in order of time.
+	Pending() []*Entry
+	// Compact remove entries of transactions done from storage.
+	Compact() error
+	// Close log.
+	Close() error
+}
+
+// logState is transactions not done in memory, shared by log storages.
+type logState struct {
+	sync.Mutex
+	entries map[string]*Entry // latest entry of transactions not done.
+	owned   map[string]bool
+}
+
+func (s *logState) init() {
+	s.entries = make(map[string]*Entry)
+	s.owned = make(map[string]bool)
+}
+
+// apply entry without lock.
+func (s *logState) apply(entry *Entry, own bool) {
+	if entry.State == StateDone {
+		delete(s.entries, entry.Gtrid)
+		delete(s.owned, entry.Gtrid)
+		return
+	}
+	if prev, ok := s.entries[entry.Gtrid]; ok && len(entry.Nodes) == 0 {
+		entry.Nodes = prev.Nodes
+	}
+	s.entries[entry.Gtrid] = entry
+	if own && entry.State == StateBegin {
+		s.owned[entry.Gtrid] = true
+	}
+}
+
+func (s *logState) Get(gtrid string) *Entry {
+	s.Lock()
+	defer s.Unlock()
+	return s.entries[gtrid]
+}
+
+func (s *logState) Owned(gtrid string) bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.owned[gtrid]
+}
+
+func (s *logState) Disown(gtrid string) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.owned, gtrid)
+}
+
+func (s *logState) Pending() []*Entry {
+	s.Lock()
+	entries := make([]*Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	s.Unlock()
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Time != entries[j].Time {
+			return entries[i].Time < entries[j].Time
+		}
+		return entries[i].Gtrid < entries[j].Gtrid
+	})
+	return entries
+}
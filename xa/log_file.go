@@ -0,0 +1,204 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xa
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/berkaroad/saashard/utils/simplelog"
+)
+
+// DefaultLogMaxSize is default size of log file to compact, in bytes.
+var DefaultLogMaxSize int64 = 64 * 1024 * 1024
+
+// FileLog is transaction log appended as json lines to local file, synced before returned.
+// The file is rotated to '<file>.1' and compacted when more than max size,
+// only entries of transactions not done are kept.
+type FileLog struct {
+	logState
+	path    string
+	maxSize int64
+	size    int64
+	file    *os.File
+}
+
+// OpenFileLog open log file, and load transactions not done. maxSize <= 0 is DefaultLogMaxSize.
+func OpenFileLog(path string, maxSize int64) (*FileLog, error) {
+	l := new(FileLog)
+	l.init()
+	l.path = path
+	l.maxSize = maxSize
+	if l.maxSize <= 0 {
+		l.maxSize = DefaultLogMaxSize
+	}
+
+	// crashed between renames of rotation, the rotated file is complete.
+	loadPath := path
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if _, err = os.Stat(path + ".1"); err == nil {
+			loadPath = path + ".1"
+		}
+	}
+	if err := l.load(loadPath); err != nil {
+		return nil, err
+	}
+	if loadPath != path {
+		if err := l.Compact(); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	info, err := l.file.Stat()
+	if err != nil {
+		l.file.Close()
+		return nil, err
+	}
+	l.size = info.Size()
+	return l, nil
+}
+
+func (l *FileLog) load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		entry := new(Entry)
+		// ignore broken tail line of crash.
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil || len(entry.Gtrid) == 0 {
+			continue
+		}
+		l.apply(entry, false)
+	}
+	return scanner.Err()
+}
+
+// Append state of transaction, nodes are kept from previous entry if empty.
+func (l *FileLog) Append(gtrid string, state string, nodes []string) error {
+	entry := &Entry{Gtrid: gtrid, State: state, Nodes: nodes, Time: time.Now().Unix()}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	l.Lock()
+	defer l.Unlock()
+	// compact again if failed in last compaction, instead of reopen, as the file may be rotated,
+	// and new file of only new entries hides the rotated one when loaded.
+	if l.file == nil {
+		if err = l.compact(); err != nil {
+			return err
+		}
+	}
+	if _, err = l.file.Write(data); err != nil {
+		return err
+	}
+	if err = l.file.Sync(); err != nil {
+		return err
+	}
+	l.size += int64(len(data))
+	l.apply(entry, true)
+	// entry is synced, compaction failure is retried by next append instead of failing the transaction.
+	if l.size > l.maxSize {
+		if err = l.compact(); err != nil {
+			simplelog.Error("%s %s %s path=%s", "xa", "Append", "Compact failed: "+err.Error(), l.path)
+		}
+	}
+	return nil
+}
+
+// Compact rotate log file, and write entries of transactions not done to new file.
+func (l *FileLog) Compact() error {
+	l.Lock()
+	defer l.Unlock()
+	return l.compact()
+}
+
+func (l *FileLog) compact() error {
+	tmpPath := l.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	var size int64
+	for _, entry := range l.entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		w.Write(data)
+		w.WriteByte('\n')
+		size += int64(len(data) + 1)
+	}
+	if err = w.Flush(); err == nil {
+		err = tmp.Sync()
+	}
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+		if err = os.Rename(l.path, l.path+".1"); err != nil && !os.IsNotExist(err) {
+			tmp.Close()
+			return err
+		}
+	}
+	tmp.Close()
+	if err = os.Rename(tmpPath, l.path); err != nil {
+		return err
+	}
+	l.size = size
+	return l.open()
+}
+
+func (l *FileLog) open() (err error) {
+	l.file, err = os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	return
+}
+
+// Close log file.
+func (l *FileLog) Close() error {
+	l.Lock()
+	defer l.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,107 @@
+package xa
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func expectPending(t *testing.T, l Log, want map[string]string) {
+	t.Helper()
+	pending := l.Pending()
+	if len(pending) != len(want) {
+		t.Fatalf("expect %d pending, got %d", len(want), len(pending))
+	}
+	for _, entry := range pending {
+		if want[entry.Gtrid] != entry.State {
+			t.Errorf("expect %s %s, got %s", entry.Gtrid, want[entry.Gtrid], entry.State)
+		}
+		if len(entry.Nodes) != 2 {
+			t.Errorf("expect nodes of %s kept, got %v", entry.Gtrid, entry.Nodes)
+		}
+	}
+}
+
+// TestFileLogLoad transactions not done are loaded when reopened, nodes are kept from begin.
+func TestFileLogLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "xa.log")
+	l, err := OpenFileLog(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []struct{ gtrid, state string }{
+		{"ss-1-1", StateBegin}, {"ss-1-2", StateBegin}, {"ss-1-3", StateBegin},
+		{"ss-1-1", StateCommit}, {"ss-1-2", StateDone}, {"ss-1-3", StateRollback},
+	} {
+		var nodes []string
+		if e.state == StateBegin {
+			nodes = []string{"n1", "n2"}
+		}
+		if err = l.Append(e.gtrid, e.state, nodes); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !l.Owned("ss-1-1") || l.Owned("ss-1-2") {
+		t.Error("expect transaction not done owned")
+	}
+	l.Close()
+
+	if l, err = OpenFileLog(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	expectPending(t, l, map[string]string{"ss-1-1": StateCommit, "ss-1-3": StateRollback})
+	if l.Owned("ss-1-1") {
+		t.Error("expect loaded transaction not owned")
+	}
+}
+
+// TestFileLogCompact file is compacted when more than max size, and interrupted compaction loses no entries.
+func TestFileLogCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "xa.log")
+	l, err := OpenFileLog(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := []string{"n1", "n2"}
+	if err = l.Append("ss-1-1", StateBegin, nodes); err != nil {
+		t.Fatal(err)
+	}
+	if err = l.Append("ss-1-1", StateCommit, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(path + ".1"); err != nil {
+		t.Fatalf("expect file rotated, got %v", err)
+	}
+
+	// failed between renames of compaction: file is rotated, and new one is not renamed yet.
+	// next append isn't more than max size to compact again.
+	l.maxSize = DefaultLogMaxSize
+	l.file.Close()
+	l.file = nil
+	if err = os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = l.Append("ss-1-2", StateBegin, nodes); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	if l, err = OpenFileLog(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	expectPending(t, l, map[string]string{"ss-1-1": StateCommit, "ss-1-2": StateBegin})
+	l.Close()
+
+	// process crashed between renames, the rotated file is loaded.
+	if err = os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if l, err = OpenFileLog(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	expectPending(t, l, map[string]string{"ss-1-1": StateCommit, "ss-1-2": StateBegin})
+	if _, err = os.Stat(path); err != nil {
+		t.Errorf("expect file compacted from rotated one, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xa
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berkaroad/saashard/backend"
+	mysqlBackend "github.com/berkaroad/saashard/backend/mysql"
+)
+
+// DefaultLogTable is default table name of transaction log.
+var DefaultLogTable = "saashard_xa_log"
+
+const sqlCreateLogTable = "CREATE TABLE IF NOT EXISTS `%s` (" +
+	"`gtrid` VARCHAR(128) NOT NULL, `state` VARCHAR(16) NOT NULL, `nodes` VARCHAR(4096) NOT NULL DEFAULT '', " +
+	"`time` BIGINT NOT NULL, PRIMARY KEY (`gtrid`)) ENGINE=InnoDB"
+
+// TableLog is transaction log in mysql table of a data node, a row per transaction not done.
+// Row is deleted when done, so that it's always compacted.
+type TableLog struct {
+	logState
+	node  *backend.DataNode
+	table string
+}
+
+// OpenTableLog create log table in master of data node if not exists, and load transactions not done.
+func OpenTableLog(node *backend.DataNode, table string) (*TableLog, error) {
+	l := new(TableLog)
+	l.init()
+	l.node = node
+	l.table = strings.Replace(table, "`", "", -1)
+	if len(l.table) == 0 {
+		l.table = DefaultLogTable
+	}
+
+	err := l.exec(func(conn *mysqlBackend.Conn) error {
+		if _, err := conn.Query(fmt.Sprintf(sqlCreateLogTable, l.table)); err != nil {
+			return err
+		}
+		result, err := conn.Query(fmt.Sprintf("SELECT `gtrid`, `state`, `nodes`, `time` FROM `%s`", l.table))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < result.RowNumber(); i++ {
+			entry := new(Entry)
+			entry.Gtrid, _ = result.GetString(i, 0)
+			entry.State, _ = result.GetString(i, 1)
+			if nodes, _ := result.GetString(i, 2); len(nodes) > 0 {
+				entry.Nodes = strings.Split(nodes, ",")
+			}
+			entry.Time, _ = result.GetInt(i, 3)
+			l.apply(entry, false)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *TableLog) exec(f func(conn *mysqlBackend.Conn) error) error {
+	conn, err := l.node.DataHost.GetMaster().GetConnection(l.node.Database)
+	if err != nil {
+		return err
+	}
+	defer conn.ReturnConnection()
+	mysqlConn := conn.(*mysqlBackend.Conn)
+	if err = mysqlConn.UseDB(l.node.Database); err != nil {
+		return err
+	}
+	return f(mysqlConn)
+}
+
+// Append state of transaction, nodes are kept from previous entry if empty. Row is deleted if done.
+func (l *TableLog) Append(gtrid string, state string, nodes []string) error {
+	entry := &Entry{Gtrid: gtrid, State: state, Nodes: nodes, Time: time.Now().Unix()}
+	var sql string
+	if state == StateDone {
+		sql = fmt.Sprintf("DELETE FROM `%s` WHERE `gtrid` = %s", l.table, mysqlBackend.QuoteString(gtrid))
+	} else if len(nodes) == 0 {
+		sql = fmt.Sprintf("INSERT INTO `%s` (`gtrid`, `state`, `time`) VALUES (%s, %s, %d) "+
+			"ON DUPLICATE KEY UPDATE `state` = VALUES(`state`), `time` = VALUES(`time`)",
+			l.table, mysqlBackend.QuoteString(gtrid), mysqlBackend.QuoteString(state), entry.Time)
+	} else {
+		sql = fmt.Sprintf("INSERT INTO `%s` (`gtrid`, `state`, `nodes`, `time`) VALUES (%s, %s, %s, %d) "+
+			"ON DUPLICATE KEY UPDATE `state` = VALUES(`state`), `nodes` = VALUES(`nodes`), `time` = VALUES(`time`)",
+			l.table, mysqlBackend.QuoteString(gtrid), mysqlBackend.QuoteString(state),
+			mysqlBackend.QuoteString(strings.Join(nodes, ",")), entry.Time)
+	}
+	// autocommit set by exec, durable as committed.
+	err := l.exec(func(conn *mysqlBackend.Conn) error {
+		_, err := conn.Query(sql)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	l.Lock()
+	l.apply(entry, true)
+	l.Unlock()
+	return nil
+}
+
+// Compact nothing, as rows of transactions done are deleted.
+func (l *TableLog) Compact() error {
+	return nil
+}
+
+// Close nothing, connections belong to data node.
+func (l *TableLog) Close() error {
+	return nil
+}
@@ -0,0 +1,117 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xa
+
+import (
+	"fmt"
+
+	"github.com/berkaroad/saashard/net/mysql"
+)
+
+// Actions to resolve prepared branch.
+const (
+	ActionCommit   = "commit"
+	ActionRollback = "rollback"
+	ActionSkip     = "skip" // In progress by this process, or unknown as no log.
+)
+
+// PreparedBranch is prepared branch in db host, returned by 'XA RECOVER'.
+type PreparedBranch struct {
+	Host     string
+	Xid      Xid
+	LogState string // State in log, empty if not logged.
+	Action   string
+	Err      error
+}
+
+// ParseRecoverResult parse result of 'XA RECOVER': formatID, gtrid_length, bqual_length, data.
+func ParseRecoverResult(host string, result *mysql.Result) ([]*PreparedBranch, error) {
+	if result == nil || result.Resultset == nil {
+		return nil, nil
+	}
+	branches := make([]*PreparedBranch, 0, result.RowNumber())
+	for i := 0; i < result.RowNumber(); i++ {
+		formatID, err := result.GetInt(i, 0)
+		if err != nil {
+			return nil, err
+		}
+		gtridLength, err := result.GetInt(i, 1)
+		if err != nil {
+			return nil, err
+		}
+		bqualLength, err := result.GetInt(i, 2)
+		if err != nil {
+			return nil, err
+		}
+		data, err := result.GetString(i, 3)
+		if err != nil {
+			return nil, err
+		}
+		if gtridLength < 0 || bqualLength < 0 || int(gtridLength+bqualLength) > len(data) {
+			return nil, fmt.Errorf("invalid xid '%s' of 'XA RECOVER'", data)
+		}
+		branch := new(PreparedBranch)
+		branch.Host = host
+		branch.Xid = Xid{
+			Gtrid:    data[:gtridLength],
+			Bqual:    data[gtridLength : gtridLength+bqualLength],
+			FormatID: formatID,
+		}
+		branches = append(branches, branch)
+	}
+	return branches, nil
+}
+
+// Decide action of prepared branch in namespace by log:
+// skip if in progress by this process, commit if decided to commit, otherwise rollback (presumed abort).
+// Transaction not decided in log is committed if marked committed by the last resource, checked by marked.
+// Skip all if no log, as decision is unknown, and skip if mark couldn't be checked.
+func Decide(log Log, branch *PreparedBranch, marked func(entry *Entry) (bool, error)) {
+	if log == nil {
+		branch.Action = ActionSkip
+		return
+	}
+	entry := log.Get(branch.Xid.Gtrid)
+	if entry != nil {
+		branch.LogState = entry.State
+	}
+	switch {
+	case log.Owned(branch.Xid.Gtrid):
+		branch.Action = ActionSkip
+	case entry != nil && entry.State == StateCommit:
+		branch.Action = ActionCommit
+	case entry != nil && entry.State == StateBegin && marked != nil:
+		ok, err := marked(entry)
+		switch {
+		case err != nil:
+			branch.Action = ActionSkip
+			branch.Err = err
+		case ok:
+			branch.Action = ActionCommit
+		default:
+			branch.Action = ActionRollback
+		}
+	default:
+		branch.Action = ActionRollback
+	}
+}
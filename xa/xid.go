@@ -0,0 +1,76 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2016 Jerry Bai
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package xa is log and recovery of distributed transactions, that executed by XA of mysql in data nodes.
+package xa
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	gtridSeq   uint64
+	gtridEpoch = time.Now().Unix()
+)
+
+// NewGtrid create unique gtrid in namespace: '<namespace>-<epoch>-<seq>'.
+func NewGtrid(namespace string) string {
+	return fmt.Sprintf("%s-%d-%d", namespace, gtridEpoch, atomic.AddUint64(&gtridSeq, 1))
+}
+
+// InNamespace the gtrid is created in namespace or not, by NewGtrid.
+// Namespace that is prefix of others, such as 'ss' of 'ss-1', doesn't match gtrids of them.
+func InNamespace(namespace string, gtrid string) bool {
+	if !strings.HasPrefix(gtrid, namespace+"-") {
+		return false
+	}
+	parts := strings.Split(gtrid[len(namespace)+1:], "-")
+	return len(parts) == 2 && isDigits(parts[0]) && isDigits(parts[1])
+}
+
+func isDigits(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Xid is id of a branch of distributed transaction, bqual is data node name.
+type Xid struct {
+	Gtrid    string
+	Bqual    string
+	FormatID int64
+}
+
+// String format xid for XA statements: X'gtrid',X'bqual',formatID.
+// Hex literals are not escaped, so they are the same in any sql_mode.
+func (x Xid) String() string {
+	return fmt.Sprintf("X'%x',X'%x',%d", x.Gtrid, x.Bqual, x.FormatID)
+}
@@ -0,0 +1,29 @@
+package xa
+
+import "testing"
+
+// TestInNamespace gtrid of namespace that has the namespace as prefix isn't matched.
+func TestInNamespace(t *testing.T) {
+	if gtrid := NewGtrid("ss"); !InNamespace("ss", gtrid) {
+		t.Errorf("expect %s in namespace ss", gtrid)
+	}
+	cases := []struct {
+		gtrid string
+		ok    bool
+	}{
+		{"ss-1600000000-1", true},
+		{"ss-1-1600000000-1", false},
+		{"ss-prod-1600000000-1", false},
+		{"ss-1600000000-", false},
+		{"ss-1600000000", false},
+		{"ss1-1600000000-1", false},
+	}
+	for _, c := range cases {
+		if ok := InNamespace("ss", c.gtrid); ok != c.ok {
+			t.Errorf("expect %s in namespace ss %v, got %v", c.gtrid, c.ok, ok)
+		}
+	}
+	if !InNamespace("ss-1", "ss-1-1600000000-1") {
+		t.Error("expect ss-1-1600000000-1 in namespace ss-1")
+	}
+}